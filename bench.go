@@ -0,0 +1,128 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"net"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/grpcmetadata"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/spiffejwt"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/bench"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/chains/memory"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/tlssource"
+)
+
+// benchCommand is the command running the synthetic clients against a registry and reporting the throughput and the
+// latency percentiles instead of serving
+const benchCommand = "bench"
+
+// runBench runs the synthetic clients against the -target registry, or against an in-process memory registry chain
+// if there is no target, and writes the report to out
+func runBench(ctx context.Context, out io.Writer, args []string) error {
+	// The warnings of the registry chains about each synthetic request would drown the report
+	logrus.SetLevel(logrus.ErrorLevel)
+
+	flags := flag.NewFlagSet(benchCommand, flag.ContinueOnError)
+	target := flags.String("target", "", "url of the registry to load, e.g. tcp://registry:5002, empty runs an in-process registry")
+	tlsMode := flags.String("tls-mode", tlsModeSPIFFE, "TLS mode of the target: spiffe (workload API) or insecure")
+	clients := flags.Int("clients", 10, "number of the concurrent clients")
+	endpoints := flags.Int("endpoints", 10, "number of the NSEs each client registers")
+	services := flags.Int("services", 10, "number of the network services the NSEs are spread over")
+	duration := flags.Duration("duration", 30*time.Second, "how long the clients refresh and find after registering")
+	findRatio := flags.Float64("find-ratio", 0.5, "share of the finds among the refreshes and the finds")
+	if err := flags.Parse(args); err != nil {
+		return errors.WithStack(err)
+	}
+	if *clients <= 0 || *endpoints < 0 || *services <= 0 || *duration < 0 || *findRatio < 0 || *findRatio > 1 {
+		return errors.New("invalid bench parameters: expected positive clients and services, non-negative endpoints and duration and find ratio in [0, 1]")
+	}
+
+	client, closeClient, err := newBenchClient(ctx, *target, *tlsMode)
+	if err != nil {
+		return err
+	}
+	defer closeClient()
+
+	report := bench.Run(ctx, client,
+		bench.WithClients(*clients),
+		bench.WithEndpointsPerClient(*endpoints),
+		bench.WithServices(*services),
+		bench.WithDuration(*duration),
+		bench.WithFindRatio(*findRatio))
+	return report.Write(out)
+}
+
+// newBenchClient returns the NSE client of the target registry, or of an in-process memory registry chain served on a
+// loopback port if there is no target, and the function closing it
+func newBenchClient(ctx context.Context, target, tlsMode string) (registry.NetworkServiceEndpointRegistryClient, func(), error) {
+	stopServer := func() {}
+	if target == "" {
+		// The in-process registry serves in the insecure TLS mode, signing its own tokens with an ephemeral SVID
+		ephemeral, err := tlssource.NewEphemeralSource(spiffeid.RequireFromString("spiffe://bench.local/registry-memory"))
+		if err != nil {
+			return nil, nil, err
+		}
+		if target, stopServer, err = serveBenchRegistry(ctx, ephemeral); err != nil {
+			return nil, nil, err
+		}
+		tlsMode = tlsModeInsecure
+	}
+
+	cc, closeConn, err := dialCommandTarget(ctx, target, tlsMode)
+	if err != nil {
+		stopServer()
+		return nil, nil, err
+	}
+	client := next.NewNetworkServiceEndpointRegistryClient(
+		grpcmetadata.NewNetworkServiceEndpointRegistryClient(),
+		registry.NewNetworkServiceEndpointRegistryClient(cc),
+	)
+	return client, func() {
+		closeConn()
+		stopServer()
+	}, nil
+}
+
+// serveBenchRegistry serves the memory registry chain signing the tokens with the source on a plaintext loopback port
+// and returns its URL and the function stopping it
+func serveBenchRegistry(ctx context.Context, source x509Source) (string, func(), error) {
+	server := grpc.NewServer(grpc.Creds(insecure.NewCredentials()))
+	memory.NewServer(ctx, spiffejwt.TokenGeneratorFunc(source, commandTokenLifetime)).Register(server)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return "", nil, errors.Wrap(err, "failed to listen for the in-process registry")
+	}
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	return "tcp://" + listener.Addr().String(), server.Stop, nil
+}
@@ -0,0 +1,190 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/opa"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/buildinfo"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/configschema"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/counters"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/federation"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/identity"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/listen"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/natspublisher"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/outboundcreds"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/derivedlabels"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/serviceoverrides"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/tlssource"
+)
+
+// checkConfigCommand is the command checking the configuration and exiting instead of serving
+const checkConfigCommand = "check-config"
+
+// checkConfig checks the listen URLs, the policies, the files and the TLS material of the config. It returns all the
+// problems found.
+func checkConfig(ctx context.Context, config *Config) error {
+	var errs []error
+	for i := range config.ListenOn {
+		errs = append(errs, checkListenURL(&config.ListenOn[i]))
+	}
+	if _, listenErr := listen.ExpandInterface(config.ListenOn, config.ListenInterface); listenErr != nil {
+		errs = append(errs, listenErr)
+	}
+	if _, policyErr := opa.PoliciesByFileMask(config.RegistryServerPolicies...); policyErr != nil {
+		errs = append(errs, errors.Wrap(policyErr, "invalid registry server policies"))
+	}
+	if _, policyErr := opa.PoliciesByFileMask(config.RegistryClientPolicies...); policyErr != nil {
+		errs = append(errs, errors.Wrap(policyErr, "invalid registry client policies"))
+	}
+	if config.ServiceOverridesFile != "" {
+		if _, loadErr := serviceoverrides.LoadFile(config.ServiceOverridesFile); loadErr != nil {
+			errs = append(errs, loadErr)
+		}
+	}
+	if config.DerivedLabelsFile != "" {
+		if _, loadErr := derivedlabels.LoadFile(config.DerivedLabelsFile); loadErr != nil {
+			errs = append(errs, loadErr)
+		}
+	}
+	if config.IdentityMappingFile != "" {
+		if loadErr := identity.NewMapping().Load(config.IdentityMappingFile); loadErr != nil {
+			errs = append(errs, loadErr)
+		}
+	}
+	if config.CountersFile != "" {
+		if _, loadErr := counters.Load(config.CountersFile); loadErr != nil {
+			errs = append(errs, loadErr)
+		}
+	}
+	if _, loadErr := loadRevocationList(config); loadErr != nil {
+		errs = append(errs, loadErr)
+	}
+	if config.PeerCredentialsFile != "" {
+		if _, loadErr := outboundcreds.LoadFile(config.PeerCredentialsFile); loadErr != nil {
+			errs = append(errs, loadErr)
+		}
+	}
+	if _, loadErr := natspublisher.LoadTLSConfig(config.NATSTLSCAFile, config.NATSTLSCertFile, config.NATSTLSKeyFile); loadErr != nil {
+		errs = append(errs, loadErr)
+	}
+	if _, parseErr := federation.ParseEndpoints(config.FederatesWith); parseErr != nil {
+		errs = append(errs, parseErr)
+	}
+	errs = append(errs, checkTLS(ctx, config))
+
+	var messages []string
+	for _, err := range errs {
+		if err != nil {
+			messages = append(messages, err.Error())
+		}
+	}
+	if len(messages) > 0 {
+		return errors.Errorf("%d config problems:\n%s", len(messages), strings.Join(messages, "\n"))
+	}
+	return nil
+}
+
+func checkListenURL(u *url.URL) error {
+	switch u.Scheme {
+	case "unix":
+		dir := filepath.Dir(u.Path)
+		if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+			return errors.Errorf("invalid listen URL %s: directory %s doesn't exist", u.String(), dir)
+		}
+	case "tcp":
+		if _, _, err := net.SplitHostPort(u.Host); err != nil {
+			return errors.Wrapf(err, "invalid listen URL %s", u.String())
+		}
+	default:
+		return errors.Errorf("invalid listen URL %s: scheme must be unix or tcp", u.String())
+	}
+	return nil
+}
+
+func checkTLS(ctx context.Context, config *Config) error {
+	switch strings.ToLower(config.TLSMode) {
+	case tlsModeSPIFFE, tlsModeInsecure, tlsModePeerCred:
+		return nil
+	case tlsModeFile:
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		_, err := tlssource.NewFileSource(ctx, config.TLSCertFile, config.TLSKeyFile, config.TLSCAFile)
+		return errors.Wrap(err, "invalid TLS material")
+	default:
+		return errors.Errorf("invalid TLS mode %s", config.TLSMode)
+	}
+}
+
+// printConfig prints the effective config values, one field per line. The URL passwords are redacted.
+func printConfig(w io.Writer, config *Config) {
+	v := reflect.ValueOf(config).Elem()
+	for i := 0; i < v.NumField(); i++ {
+		var value string
+		switch field := v.Field(i).Interface().(type) {
+		case url.URL:
+			value = field.Redacted()
+		case []url.URL:
+			value = redactURLs(field)
+		case listen.URLs:
+			value = redactURLs(field)
+		case []string:
+			value = strings.Join(field, ",")
+		default:
+			value = fmt.Sprint(field)
+		}
+		_, _ = fmt.Fprintf(w, "%s=%s\n", v.Type().Field(i).Name, value)
+	}
+}
+
+// redactURLs returns the comma separated URLs with the passwords redacted
+func redactURLs(urls []url.URL) string {
+	values := make([]string, 0, len(urls))
+	for i := range urls {
+		values = append(values, urls[i].Redacted())
+	}
+	return strings.Join(values, ",")
+}
+
+// printConfigSchema prints the JSON Schema of the config environment variables
+func printConfigSchema(w io.Writer) error {
+	schema, err := configschema.Generate("registry_memory", &Config{})
+	if err != nil {
+		return err
+	}
+	info := buildinfo.Get()
+	schema.Title = "cmd-registry-memory configuration"
+	schema.Description = "Environment variables of cmd-registry-memory " + info.Version
+	data, err := schema.Marshal()
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the config schema")
+	}
+	_, err = fmt.Fprintln(w, string(data))
+	return err
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
+	"github.com/spiffe/go-spiffe/v2/workloadapi"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/grpcutils"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/spiffejwt"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/token"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/listen"
+)
+
+// commandTokenLifetime is the lifetime of the tokens the commands call a registry with
+const commandTokenLifetime = 10 * time.Minute
+
+// runCommand runs the command with the args until done or interrupted and exits
+func runCommand(run func(ctx context.Context, out io.Writer, args []string) error, args []string) {
+	ctx, cancel := signal.NotifyContext(context.Background(), append(shutdownSignals, dumpSignals...)...)
+	err := run(ctx, os.Stdout, args)
+	cancel()
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	os.Exit(0)
+}
+
+// dialCommandTarget dials the target registry in the TLS mode and returns the connection and the function closing it.
+// The spiffe mode uses mTLS with the workload API SVID and the tokens signed by it, the insecure mode is plaintext
+// without the tokens.
+func dialCommandTarget(ctx context.Context, target, tlsMode string) (*grpc.ClientConn, func(), error) {
+	u, err := listen.ParseURL(target)
+	if err != nil {
+		return nil, nil, err
+	}
+	dialOptions := []grpc.DialOption{grpc.WithDefaultCallOptions(grpc.WaitForReady(true))}
+	closeSource := func() {}
+	switch strings.ToLower(tlsMode) {
+	case tlsModeSPIFFE:
+		workloadSource, sourceErr := workloadapi.NewX509Source(ctx)
+		if sourceErr != nil {
+			return nil, nil, errors.Wrap(sourceErr, "failed to get the x509 source")
+		}
+		closeSource = func() { _ = workloadSource.Close() }
+		tlsClientConfig := tlsconfig.MTLSClientConfig(workloadSource, workloadSource, tlsconfig.AuthorizeAny())
+		tlsClientConfig.MinVersion = tls.VersionTLS12
+		dialOptions = append(dialOptions,
+			grpc.WithTransportCredentials(credentials.NewTLS(tlsClientConfig)),
+			grpc.WithPerRPCCredentials(token.NewPerRPCCredentials(spiffejwt.TokenGeneratorFunc(workloadSource, commandTokenLifetime))))
+	case tlsModeInsecure:
+		dialOptions = append(dialOptions, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	default:
+		return nil, nil, errors.Errorf("invalid TLS mode %s, expected %s or %s", tlsMode, tlsModeSPIFFE, tlsModeInsecure)
+	}
+
+	cc, err := grpc.DialContext(ctx, grpcutils.URLToTarget(u), dialOptions...)
+	if err != nil {
+		closeSource()
+		return nil, nil, errors.Wrapf(err, "failed to dial %s", target)
+	}
+	return cc, func() {
+		_ = cc.Close()
+		closeSource()
+	}, nil
+}
@@ -0,0 +1,155 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime/pprof"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/log"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/buildinfo"
+)
+
+// The exit codes of the fatal startup errors. The code 2 is left to the Go runtime exiting on a panic and to the flag
+// package exiting on an invalid flag.
+const (
+	// exitInternal is an unexpected internal error
+	exitInternal = 1
+	// exitConfig is an invalid config, policy or plugin
+	exitConfig = 3
+	// exitListen is a failure to listen on or to serve a listen URL
+	exitListen = 4
+	// exitIdentity is a failure to get the x509 source, the SVID or the federated bundles
+	exitIdentity = 5
+	// exitState is a corrupt or unreadable persisted state
+	exitState = 6
+	// exitDependency is a failure to dial a required dependency: the proxy registry or the ext_authz service
+	exitDependency = 7
+)
+
+// fatalLine is the machine-readable line printed before exiting on a fatal error
+type fatalLine struct {
+	Msg     string `json:"msg"`
+	Version string `json:"version"`
+	Code    int    `json:"code"`
+	Reason  string `json:"reason"`
+	Error   string `json:"error"`
+}
+
+// fatalReasons are the reasons of the fatalLine by exit code
+var fatalReasons = map[int]string{
+	exitInternal:   "internal",
+	exitConfig:     "config",
+	exitListen:     "listen",
+	exitIdentity:   "identity",
+	exitState:      "state",
+	exitDependency: "dependency",
+}
+
+// fatal logs err, prints the single line JSON FATAL message to stdout, independent of the log format, and exits with
+// code
+func fatal(code int, err error) {
+	logrus.Error(err)
+	data, marshalErr := json.Marshal(&fatalLine{
+		Msg:     "FATAL",
+		Version: buildinfo.Get().Version,
+		Code:    code,
+		Reason:  fatalReasons[code],
+		Error:   err.Error(),
+	})
+	if marshalErr == nil {
+		_, _ = fmt.Fprintln(os.Stdout, string(data))
+	}
+	os.Exit(code)
+}
+
+// setupDiagnostics sets up the logging, the shutdown on the dump signals and, if config.CrashDumpDir is set, the
+// goroutine dumps on the dump signals and the panic output to the dump directory. The dump signals are not the shutdown
+// signals, so cancel is called only after the dump is written.
+func setupDiagnostics(ctx context.Context, cancel context.CancelFunc, config *Config) error {
+	if err := setupLogging(config); err != nil {
+		return err
+	}
+	if config.CrashDumpDir != "" {
+		if err := os.MkdirAll(config.CrashDumpDir, 0o700); err != nil {
+			return errors.Wrapf(err, "failed to create the crash dump directory %s", config.CrashDumpDir)
+		}
+		if err := setPanicOutput(ctx, config.CrashDumpDir); err != nil {
+			log.FromContext(ctx).Warnf("panics are not written to %s: %s", config.CrashDumpDir, err.Error())
+		}
+	}
+	if len(dumpSignals) > 0 {
+		signalCh := make(chan os.Signal, 1)
+		signal.Notify(signalCh, dumpSignals...)
+		go func() {
+			defer signal.Stop(signalCh)
+			select {
+			case <-ctx.Done():
+			case sig := <-signalCh:
+				if config.CrashDumpDir != "" {
+					writeGoroutineDump(ctx, config.CrashDumpDir, sig.String())
+				}
+				cancel()
+			}
+		}()
+	}
+	return nil
+}
+
+// writeGoroutineDump writes the stacks of all the goroutines to a new goroutines-<time>.txt file in dir
+func writeGoroutineDump(ctx context.Context, dir, reason string) {
+	path := filepath.Join(dir, fmt.Sprintf("goroutines-%s.txt", time.Now().UTC().Format("20060102T150405.000Z")))
+	f, err := os.OpenFile(filepath.Clean(path), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		log.FromContext(ctx).Errorf("failed to write the goroutine dump: %s", err.Error())
+		return
+	}
+	defer func() { _ = f.Close() }()
+	_, _ = fmt.Fprintf(f, "reason: %s\nversion: %s\n\n", reason, buildinfo.Get().Version)
+	if err = pprof.Lookup("goroutine").WriteTo(f, 2); err != nil {
+		log.FromContext(ctx).Errorf("failed to write the goroutine dump: %s", err.Error())
+		return
+	}
+	log.FromContext(ctx).Warnf("Goroutine dump written to %s", path)
+}
+
+// openPanicFile creates the file the runtime writes an unrecovered panic to, the file is removed on ctx done if
+// nothing has been written to it
+func openPanicFile(ctx context.Context, dir string) (*os.File, error) {
+	path := filepath.Join(dir, fmt.Sprintf("panic-%d.txt", os.Getpid()))
+	f, err := os.OpenFile(filepath.Clean(path), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the panic file")
+	}
+	go func() {
+		<-ctx.Done()
+		if info, statErr := f.Stat(); statErr == nil && info.Size() == 0 {
+			_ = os.Remove(path)
+		}
+	}()
+	return f, nil
+}
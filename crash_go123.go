@@ -0,0 +1,35 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.23
+
+package main
+
+import (
+	"context"
+	"runtime/debug"
+)
+
+// setPanicOutput makes the runtime write an unrecovered panic with the stacks of all the goroutines to a file in dir
+// in addition to stderr
+func setPanicOutput(ctx context.Context, dir string) error {
+	f, err := openPanicFile(ctx, dir)
+	if err != nil {
+		return err
+	}
+	debug.SetTraceback("all")
+	return debug.SetCrashOutput(f, debug.CrashOptions{})
+}
@@ -0,0 +1,30 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !go1.23
+
+package main
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+)
+
+// setPanicOutput fails, the runtime writes the panics to stderr only before go1.23
+func setPanicOutput(_ context.Context, _ string) error {
+	return errors.New("writing the panics to a file requires go1.23")
+}
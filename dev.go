@@ -0,0 +1,55 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+// devFakeStateSize is the number of the synthetic NSEs seeded in the dev mode unless -generate-fake-state is set
+const devFakeStateSize = 50
+
+// devEnv is the configuration of the dev mode: in-memory self-signed identity, plaintext loopback listeners, the gRPC
+// reflection for grpcurl, the status HTTP endpoints and debug logging
+var devEnv = map[string]string{
+	"REGISTRY_MEMORY_TLS_MODE":              tlsModeInsecure,
+	"REGISTRY_MEMORY_LISTEN_ON":             "tcp://127.0.0.1:5002",
+	"REGISTRY_MEMORY_LOG_LEVEL":             "DEBUG",
+	"REGISTRY_MEMORY_LOG_FORMAT":            "text",
+	"REGISTRY_MEMORY_GRPC_REFLECTION":       "true",
+	"REGISTRY_MEMORY_HEALTH_HTTP_LISTEN_ON": "127.0.0.1:8080",
+	"REGISTRY_MEMORY_STATUS_HTTP_LISTEN_ON": "127.0.0.1:8081",
+}
+
+// applyDevMode sets the dev mode configuration for the variables not set in the environment and seeds the synthetic
+// NSEs unless their number is set
+func applyDevMode(fakeStateSize *int) error {
+	for name, value := range devEnv {
+		if _, ok := os.LookupEnv(name); ok {
+			continue
+		}
+		if err := os.Setenv(name, value); err != nil {
+			return errors.Wrapf(err, "failed to set %s", name)
+		}
+	}
+	if *fakeStateSize == 0 {
+		*fakeStateSize = devFakeStateSize
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/admin"
+)
+
+// exportStatsCommand is the command writing the per network service statistics of a registry as a one-shot Prometheus
+// text exposition instead of serving, e.g. for the cron-based collection without a Prometheus server
+const exportStatsCommand = "export-stats"
+
+// runExportStats gets the statistics of the -target registry with the admin Export API and writes them to out
+func runExportStats(ctx context.Context, out io.Writer, args []string) error {
+	flags := flag.NewFlagSet(exportStatsCommand, flag.ContinueOnError)
+	target := flags.String("target", "", "url of the registry, e.g. tcp://registry:5002")
+	tlsMode := flags.String("tls-mode", tlsModeSPIFFE, "TLS mode of the target: spiffe (workload API) or insecure")
+	if err := flags.Parse(args); err != nil {
+		return errors.WithStack(err)
+	}
+	if *target == "" {
+		return errors.New("the target registry url is required")
+	}
+
+	cc, closeConn, err := dialCommandTarget(ctx, *target, *tlsMode)
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	in, err := structpb.NewStruct(map[string]interface{}{"dataset": "stats", "format": "prometheus"})
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	exported, err := admin.NewClient(cc).Export(ctx, in)
+	if err != nil {
+		return errors.Wrapf(err, "failed to export the statistics of %s", *target)
+	}
+	_, err = out.Write(exported.GetValue())
+	return errors.WithStack(err)
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/connectivity"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/grpcutils"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/opa"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/connpool"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/counters"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/synced"
+)
+
+// Health check names, see synced.Condition.Failures
+const (
+	healthCheckPersistence   = "persistence"
+	healthCheckProxyRegistry = "proxy-registry"
+	healthCheckPolicy        = "policy"
+)
+
+// newHealthChecks returns the checks of the registry dependencies: the persistence backend being writable, the proxy
+// registry being reachable and the policy engine being loaded. Each of them fails all the registry services.
+func newHealthChecks(config *Config, pool *connpool.Pool) []synced.Check {
+	var checks []synced.Check
+	if config.CountersFile != "" {
+		checks = append(checks, synced.Check{
+			Name: healthCheckPersistence,
+			Func: func(context.Context) error { return checkCountersFile(config.CountersFile) },
+		})
+	}
+	var proxyURLs []*url.URL
+	if config.ProxyRegistryURL.String() != "" {
+		proxyURLs = append(proxyURLs, &config.ProxyRegistryURL)
+	}
+	for i := range config.ProxyRegistryPeers {
+		proxyURLs = append(proxyURLs, &config.ProxyRegistryPeers[i])
+	}
+	if len(proxyURLs) > 0 {
+		checks = append(checks, synced.Check{
+			Name: healthCheckProxyRegistry,
+			Func: func(ctx context.Context) error { return checkAnyReachable(ctx, pool, proxyURLs) },
+		})
+	}
+	policyCheck := synced.Check{Name: healthCheckPolicy, Func: func(context.Context) error { return checkPolicies(config) }}
+	if config.ExtAuthzURL.String() != "" {
+		policyCheck.Func = func(ctx context.Context) error {
+			return checkAnyReachable(ctx, pool, []*url.URL{&config.ExtAuthzURL})
+		}
+	}
+	return append(checks, policyCheck)
+}
+
+// checkCountersFile returns an error if the counters file can't be read, parsed or saved. The file is saved by renaming
+// a temporary file in its directory, so the directory must be writable too.
+func checkCountersFile(filePath string) error {
+	if err := checkWritableDir(filepath.Dir(filePath)); err != nil {
+		return err
+	}
+	// #nosec G304 - the file path comes from the configuration
+	f, err := os.OpenFile(filePath, os.O_RDWR|os.O_APPEND, 0)
+	switch {
+	case os.IsNotExist(err):
+		// Not saved yet
+		return nil
+	case err != nil:
+		return errors.Wrapf(err, "counters file %s is not writable", filePath)
+	}
+	_ = f.Close()
+	_, err = counters.Load(filePath)
+	return err
+}
+
+// checkWritableDir returns an error if a file can't be created in dir
+func checkWritableDir(dir string) error {
+	f, err := os.CreateTemp(dir, ".health.*")
+	if err != nil {
+		return errors.Wrapf(err, "persistence directory %s is not writable", dir)
+	}
+	_ = f.Close()
+	return os.Remove(f.Name())
+}
+
+// checkAnyReachable returns an error unless a connection to one of the URLs gets ready before ctx is done
+func checkAnyReachable(ctx context.Context, pool *connpool.Pool, urls []*url.URL) error {
+	var err error
+	for _, u := range urls {
+		if err = checkReachable(ctx, pool, u); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func checkReachable(ctx context.Context, pool *connpool.Pool, u *url.URL) error {
+	cc, release, err := pool.Get(ctx, grpcutils.URLToTarget(u))
+	if err != nil {
+		return errors.Wrapf(err, "%s is unreachable", u.String())
+	}
+	defer release()
+
+	cc.Connect()
+	for state := cc.GetState(); state != connectivity.Ready; state = cc.GetState() {
+		if !cc.WaitForStateChange(ctx, state) {
+			return errors.Errorf("%s is unreachable: connection is %s", u.String(), state)
+		}
+	}
+	return nil
+}
+
+// checkPolicies returns an error if the registry policies can't be loaded
+func checkPolicies(config *Config) error {
+	for _, masks := range [][]string{config.RegistryServerPolicies, config.RegistryClientPolicies} {
+		policies, err := opa.PoliciesByFileMask(masks...)
+		if err != nil {
+			return errors.Wrap(err, "failed to load the registry policies")
+		}
+		if len(policies) == 0 && len(masks) > 0 {
+			return errors.Errorf("no registry policies match %v", masks)
+		}
+	}
+	return nil
+}
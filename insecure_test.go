@@ -0,0 +1,75 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main_test
+
+import (
+	"context"
+	"net"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// InsecureRegistryTestSuite runs the registry cases against the registry in the insecure TLS mode on a loopback TCP
+// port, so it needs neither spire nor unix sockets and runs on Windows too
+type InsecureRegistryTestSuite struct {
+	registrySuite
+	sut *exec.Cmd
+}
+
+func (t *InsecureRegistryTestSuite) SetupSuite() {
+	t.ctx, t.cancel = context.WithCancel(context.Background())
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t.T(), err)
+	t.target = listener.Addr().String()
+	require.NoError(t.T(), listener.Close())
+
+	// Without TLS the server policy checking the client signature of the previous path segment can't pass, the
+	// token policies still apply
+	t.sut = exec.CommandContext(t.ctx, "registry-memory")
+	t.sut.Env = append(os.Environ(),
+		"REGISTRY_MEMORY_TLS_MODE=insecure",
+		"REGISTRY_MEMORY_LISTEN_ON=tcp://"+t.target,
+		"REGISTRY_MEMORY_REGISTRY_SERVER_POLICIES=etc/nsm/opa/common/.*.rego,etc/nsm/opa/registry/.*.rego",
+	)
+	t.sut.Stdout = os.Stdout
+	t.sut.Stderr = os.Stderr
+	require.NoError(t.T(), t.sut.Start())
+
+	t.dialOptions = []grpc.DialOption{
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.WaitForReady(true)),
+	}
+}
+
+func (t *InsecureRegistryTestSuite) TearDownSuite() {
+	t.cancel()
+	_ = t.sut.Wait()
+}
+
+func TestInsecureRegistryTestSuite(t *testing.T) {
+	if _, err := exec.LookPath("registry-memory"); err != nil {
+		t.Skip("registry-memory is not built into the PATH")
+	}
+	suite.Run(t, new(InsecureRegistryTestSuite))
+}
@@ -0,0 +1,640 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: admin.proto
+
+package admin
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	anypb "google.golang.org/protobuf/types/known/anypb"
+	emptypb "google.golang.org/protobuf/types/known/emptypb"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	wrapperspb "google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Admin_ListTombstones_FullMethodName      = "/registry.memory.admin.Admin/ListTombstones"
+	Admin_Relabel_FullMethodName             = "/registry.memory.admin.Admin/Relabel"
+	Admin_GetStatus_FullMethodName           = "/registry.memory.admin.Admin/GetStatus"
+	Admin_Export_FullMethodName              = "/registry.memory.admin.Admin/Export"
+	Admin_Revalidate_FullMethodName          = "/registry.memory.admin.Admin/Revalidate"
+	Admin_ExportState_FullMethodName         = "/registry.memory.admin.Admin/ExportState"
+	Admin_ImportState_FullMethodName         = "/registry.memory.admin.Admin/ImportState"
+	Admin_GetStats_FullMethodName            = "/registry.memory.admin.Admin/GetStats"
+	Admin_RegisterTransaction_FullMethodName = "/registry.memory.admin.Admin/RegisterTransaction"
+	Admin_GetPeers_FullMethodName            = "/registry.memory.admin.Admin/GetPeers"
+	Admin_History_FullMethodName             = "/registry.memory.admin.Admin/History"
+	Admin_Bulk_FullMethodName                = "/registry.memory.admin.Admin/Bulk"
+)
+
+// AdminClient is the client API for Admin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type AdminClient interface {
+	// ListTombstones returns the tombstones of the removed NSEs
+	ListTombstones(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*structpb.Struct, error)
+	// Relabel changes the labels of the NSEs matching the selector
+	Relabel(ctx context.Context, in *structpb.Struct, opts ...grpc.CallOption) (*structpb.Struct, error)
+	// GetStatus returns the registry status
+	GetStatus(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*structpb.Struct, error)
+	// Export returns the registry data for offline analytics
+	Export(ctx context.Context, in *structpb.Struct, opts ...grpc.CallOption) (*wrapperspb.BytesValue, error)
+	// Revalidate probes the NSE and reports its state
+	Revalidate(ctx context.Context, in *structpb.Struct, opts ...grpc.CallOption) (*structpb.Struct, error)
+	// ExportState streams the NSs, then the NSEs of the registry, each packed into Any
+	ExportState(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (Admin_ExportStateClient, error)
+	// ImportState registers the streamed NSs and NSEs, each packed into Any
+	ImportState(ctx context.Context, opts ...grpc.CallOption) (Admin_ImportStateClient, error)
+	// GetStats returns the per network service statistics
+	GetStats(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*structpb.Struct, error)
+	// RegisterTransaction registers the streamed NSs and NSEs, each packed into Any, all together or none of them
+	RegisterTransaction(ctx context.Context, opts ...grpc.CallOption) (Admin_RegisterTransactionClient, error)
+	// GetPeers returns the connected peers accounting
+	GetPeers(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*structpb.Struct, error)
+	// History returns the last revisions of the NSE
+	History(ctx context.Context, in *structpb.Struct, opts ...grpc.CallOption) (*structpb.Struct, error)
+	// Bulk applies the NS and NSE mutations all together or none of them
+	Bulk(ctx context.Context, in *structpb.Struct, opts ...grpc.CallOption) (*structpb.Struct, error)
+}
+
+type adminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewAdminClient(cc grpc.ClientConnInterface) AdminClient {
+	return &adminClient{cc}
+}
+
+func (c *adminClient) ListTombstones(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*structpb.Struct, error) {
+	out := new(structpb.Struct)
+	err := c.cc.Invoke(ctx, Admin_ListTombstones_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) Relabel(ctx context.Context, in *structpb.Struct, opts ...grpc.CallOption) (*structpb.Struct, error) {
+	out := new(structpb.Struct)
+	err := c.cc.Invoke(ctx, Admin_Relabel_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) GetStatus(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*structpb.Struct, error) {
+	out := new(structpb.Struct)
+	err := c.cc.Invoke(ctx, Admin_GetStatus_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) Export(ctx context.Context, in *structpb.Struct, opts ...grpc.CallOption) (*wrapperspb.BytesValue, error) {
+	out := new(wrapperspb.BytesValue)
+	err := c.cc.Invoke(ctx, Admin_Export_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) Revalidate(ctx context.Context, in *structpb.Struct, opts ...grpc.CallOption) (*structpb.Struct, error) {
+	out := new(structpb.Struct)
+	err := c.cc.Invoke(ctx, Admin_Revalidate_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) ExportState(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (Admin_ExportStateClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Admin_ServiceDesc.Streams[0], Admin_ExportState_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &adminExportStateClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Admin_ExportStateClient interface {
+	Recv() (*anypb.Any, error)
+	grpc.ClientStream
+}
+
+type adminExportStateClient struct {
+	grpc.ClientStream
+}
+
+func (x *adminExportStateClient) Recv() (*anypb.Any, error) {
+	m := new(anypb.Any)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *adminClient) ImportState(ctx context.Context, opts ...grpc.CallOption) (Admin_ImportStateClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Admin_ServiceDesc.Streams[1], Admin_ImportState_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &adminImportStateClient{stream}
+	return x, nil
+}
+
+type Admin_ImportStateClient interface {
+	Send(*anypb.Any) error
+	CloseAndRecv() (*structpb.Struct, error)
+	grpc.ClientStream
+}
+
+type adminImportStateClient struct {
+	grpc.ClientStream
+}
+
+func (x *adminImportStateClient) Send(m *anypb.Any) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *adminImportStateClient) CloseAndRecv() (*structpb.Struct, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(structpb.Struct)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *adminClient) GetStats(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*structpb.Struct, error) {
+	out := new(structpb.Struct)
+	err := c.cc.Invoke(ctx, Admin_GetStats_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) RegisterTransaction(ctx context.Context, opts ...grpc.CallOption) (Admin_RegisterTransactionClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Admin_ServiceDesc.Streams[2], Admin_RegisterTransaction_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &adminRegisterTransactionClient{stream}
+	return x, nil
+}
+
+type Admin_RegisterTransactionClient interface {
+	Send(*anypb.Any) error
+	CloseAndRecv() (*structpb.Struct, error)
+	grpc.ClientStream
+}
+
+type adminRegisterTransactionClient struct {
+	grpc.ClientStream
+}
+
+func (x *adminRegisterTransactionClient) Send(m *anypb.Any) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *adminRegisterTransactionClient) CloseAndRecv() (*structpb.Struct, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(structpb.Struct)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *adminClient) GetPeers(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*structpb.Struct, error) {
+	out := new(structpb.Struct)
+	err := c.cc.Invoke(ctx, Admin_GetPeers_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) History(ctx context.Context, in *structpb.Struct, opts ...grpc.CallOption) (*structpb.Struct, error) {
+	out := new(structpb.Struct)
+	err := c.cc.Invoke(ctx, Admin_History_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *adminClient) Bulk(ctx context.Context, in *structpb.Struct, opts ...grpc.CallOption) (*structpb.Struct, error) {
+	out := new(structpb.Struct)
+	err := c.cc.Invoke(ctx, Admin_Bulk_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// AdminServer is the server API for Admin service.
+// All implementations must embed UnimplementedAdminServer
+// for forward compatibility
+type AdminServer interface {
+	// ListTombstones returns the tombstones of the removed NSEs
+	ListTombstones(context.Context, *emptypb.Empty) (*structpb.Struct, error)
+	// Relabel changes the labels of the NSEs matching the selector
+	Relabel(context.Context, *structpb.Struct) (*structpb.Struct, error)
+	// GetStatus returns the registry status
+	GetStatus(context.Context, *emptypb.Empty) (*structpb.Struct, error)
+	// Export returns the registry data for offline analytics
+	Export(context.Context, *structpb.Struct) (*wrapperspb.BytesValue, error)
+	// Revalidate probes the NSE and reports its state
+	Revalidate(context.Context, *structpb.Struct) (*structpb.Struct, error)
+	// ExportState streams the NSs, then the NSEs of the registry, each packed into Any
+	ExportState(*emptypb.Empty, Admin_ExportStateServer) error
+	// ImportState registers the streamed NSs and NSEs, each packed into Any
+	ImportState(Admin_ImportStateServer) error
+	// GetStats returns the per network service statistics
+	GetStats(context.Context, *emptypb.Empty) (*structpb.Struct, error)
+	// RegisterTransaction registers the streamed NSs and NSEs, each packed into Any, all together or none of them
+	RegisterTransaction(Admin_RegisterTransactionServer) error
+	// GetPeers returns the connected peers accounting
+	GetPeers(context.Context, *emptypb.Empty) (*structpb.Struct, error)
+	// History returns the last revisions of the NSE
+	History(context.Context, *structpb.Struct) (*structpb.Struct, error)
+	// Bulk applies the NS and NSE mutations all together or none of them
+	Bulk(context.Context, *structpb.Struct) (*structpb.Struct, error)
+	mustEmbedUnimplementedAdminServer()
+}
+
+// UnimplementedAdminServer must be embedded to have forward compatible implementations.
+type UnimplementedAdminServer struct {
+}
+
+func (UnimplementedAdminServer) ListTombstones(context.Context, *emptypb.Empty) (*structpb.Struct, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTombstones not implemented")
+}
+func (UnimplementedAdminServer) Relabel(context.Context, *structpb.Struct) (*structpb.Struct, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Relabel not implemented")
+}
+func (UnimplementedAdminServer) GetStatus(context.Context, *emptypb.Empty) (*structpb.Struct, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStatus not implemented")
+}
+func (UnimplementedAdminServer) Export(context.Context, *structpb.Struct) (*wrapperspb.BytesValue, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Export not implemented")
+}
+func (UnimplementedAdminServer) Revalidate(context.Context, *structpb.Struct) (*structpb.Struct, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Revalidate not implemented")
+}
+func (UnimplementedAdminServer) ExportState(*emptypb.Empty, Admin_ExportStateServer) error {
+	return status.Errorf(codes.Unimplemented, "method ExportState not implemented")
+}
+func (UnimplementedAdminServer) ImportState(Admin_ImportStateServer) error {
+	return status.Errorf(codes.Unimplemented, "method ImportState not implemented")
+}
+func (UnimplementedAdminServer) GetStats(context.Context, *emptypb.Empty) (*structpb.Struct, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetStats not implemented")
+}
+func (UnimplementedAdminServer) RegisterTransaction(Admin_RegisterTransactionServer) error {
+	return status.Errorf(codes.Unimplemented, "method RegisterTransaction not implemented")
+}
+func (UnimplementedAdminServer) GetPeers(context.Context, *emptypb.Empty) (*structpb.Struct, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetPeers not implemented")
+}
+func (UnimplementedAdminServer) History(context.Context, *structpb.Struct) (*structpb.Struct, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method History not implemented")
+}
+func (UnimplementedAdminServer) Bulk(context.Context, *structpb.Struct) (*structpb.Struct, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Bulk not implemented")
+}
+func (UnimplementedAdminServer) mustEmbedUnimplementedAdminServer() {}
+
+// UnsafeAdminServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to AdminServer will
+// result in compilation errors.
+type UnsafeAdminServer interface {
+	mustEmbedUnimplementedAdminServer()
+}
+
+func RegisterAdminServer(s grpc.ServiceRegistrar, srv AdminServer) {
+	s.RegisterService(&Admin_ServiceDesc, srv)
+}
+
+func _Admin_ListTombstones_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).ListTombstones(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_ListTombstones_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).ListTombstones(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_Relabel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(structpb.Struct)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).Relabel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_Relabel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).Relabel(ctx, req.(*structpb.Struct))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_GetStatus_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).GetStatus(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_GetStatus_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).GetStatus(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_Export_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(structpb.Struct)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).Export(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_Export_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).Export(ctx, req.(*structpb.Struct))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_Revalidate_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(structpb.Struct)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).Revalidate(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_Revalidate_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).Revalidate(ctx, req.(*structpb.Struct))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_ExportState_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(emptypb.Empty)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(AdminServer).ExportState(m, &adminExportStateServer{stream})
+}
+
+type Admin_ExportStateServer interface {
+	Send(*anypb.Any) error
+	grpc.ServerStream
+}
+
+type adminExportStateServer struct {
+	grpc.ServerStream
+}
+
+func (x *adminExportStateServer) Send(m *anypb.Any) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Admin_ImportState_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(AdminServer).ImportState(&adminImportStateServer{stream})
+}
+
+type Admin_ImportStateServer interface {
+	SendAndClose(*structpb.Struct) error
+	Recv() (*anypb.Any, error)
+	grpc.ServerStream
+}
+
+type adminImportStateServer struct {
+	grpc.ServerStream
+}
+
+func (x *adminImportStateServer) SendAndClose(m *structpb.Struct) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *adminImportStateServer) Recv() (*anypb.Any, error) {
+	m := new(anypb.Any)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Admin_GetStats_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).GetStats(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_GetStats_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).GetStats(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_RegisterTransaction_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(AdminServer).RegisterTransaction(&adminRegisterTransactionServer{stream})
+}
+
+type Admin_RegisterTransactionServer interface {
+	SendAndClose(*structpb.Struct) error
+	Recv() (*anypb.Any, error)
+	grpc.ServerStream
+}
+
+type adminRegisterTransactionServer struct {
+	grpc.ServerStream
+}
+
+func (x *adminRegisterTransactionServer) SendAndClose(m *structpb.Struct) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *adminRegisterTransactionServer) Recv() (*anypb.Any, error) {
+	m := new(anypb.Any)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _Admin_GetPeers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).GetPeers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_GetPeers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).GetPeers(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_History_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(structpb.Struct)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).History(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_History_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).History(ctx, req.(*structpb.Struct))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Admin_Bulk_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(structpb.Struct)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(AdminServer).Bulk(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Admin_Bulk_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(AdminServer).Bulk(ctx, req.(*structpb.Struct))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Admin_ServiceDesc is the grpc.ServiceDesc for Admin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Admin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "registry.memory.admin.Admin",
+	HandlerType: (*AdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListTombstones",
+			Handler:    _Admin_ListTombstones_Handler,
+		},
+		{
+			MethodName: "Relabel",
+			Handler:    _Admin_Relabel_Handler,
+		},
+		{
+			MethodName: "GetStatus",
+			Handler:    _Admin_GetStatus_Handler,
+		},
+		{
+			MethodName: "Export",
+			Handler:    _Admin_Export_Handler,
+		},
+		{
+			MethodName: "Revalidate",
+			Handler:    _Admin_Revalidate_Handler,
+		},
+		{
+			MethodName: "GetStats",
+			Handler:    _Admin_GetStats_Handler,
+		},
+		{
+			MethodName: "GetPeers",
+			Handler:    _Admin_GetPeers_Handler,
+		},
+		{
+			MethodName: "History",
+			Handler:    _Admin_History_Handler,
+		},
+		{
+			MethodName: "Bulk",
+			Handler:    _Admin_Bulk_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ExportState",
+			Handler:       _Admin_ExportState_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ImportState",
+			Handler:       _Admin_ImportState_Handler,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "RegisterTransaction",
+			Handler:       _Admin_RegisterTransaction_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "admin.proto",
+}
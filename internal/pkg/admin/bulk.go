@@ -0,0 +1,182 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/inprocess"
+)
+
+// Bulk operations
+const (
+	bulkRegister   = "register"
+	bulkUnregister = "unregister"
+)
+
+// Bulk mutation fields with the mutated resource
+const (
+	bulkNetworkService         = "network_service"
+	bulkNetworkServiceEndpoint = "network_service_endpoint"
+)
+
+// mutation is a parsed Bulk mutation of either an NS or an NSE
+type mutation struct {
+	operation string
+	ns        *registry.NetworkService
+	nse       *registry.NetworkServiceEndpoint
+}
+
+func (s *adminServer) Bulk(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if s.nsClient == nil || s.nseClient == nil {
+		return nil, status.Error(codes.Unimplemented, "bulk is not available")
+	}
+	var mutations []*mutation
+	for i, value := range in.GetFields()["mutations"].GetListValue().GetValues() {
+		m, err := parseMutation(value.GetStructValue())
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid mutation %d: %s", i, err.Error())
+		}
+		mutations = append(mutations, m)
+	}
+	ctx = inprocess.WithContext(ctx)
+
+	var undos []undo
+	results := make([]interface{}, 0, len(mutations))
+	for _, m := range mutations {
+		u, result, err := s.apply(ctx, m)
+		if err != nil {
+			return nil, s.abort(ctx, undos, "bulk", errors.Wrapf(err, "failed to %s %s", m.operation, m.name()))
+		}
+		undos = append(undos, u)
+		results = append(results, result)
+	}
+
+	result, err := structpb.NewStruct(map[string]interface{}{"results": results})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to build bulk response: %s", err.Error())
+	}
+	return result, nil
+}
+
+// apply applies the mutation and returns the undo reverting it and the mutation result
+func (s *adminServer) apply(ctx context.Context, m *mutation) (undo, map[string]interface{}, error) {
+	result := map[string]interface{}{"operation": m.operation}
+	var u undo
+	var err error
+	switch {
+	case m.ns != nil && m.operation == bulkRegister:
+		result[bulkNetworkService] = m.ns.GetName()
+		u, err = s.registerNS(ctx, m.ns)
+	case m.ns != nil:
+		result[bulkNetworkService] = m.ns.GetName()
+		u, err = s.unregisterNS(ctx, m.ns)
+	case m.operation == bulkRegister:
+		result[bulkNetworkServiceEndpoint] = m.nse.GetName()
+		var registered *registry.NetworkServiceEndpoint
+		if registered, u, err = s.registerNSE(ctx, m.nse); err == nil && registered.GetExpirationTime() != nil {
+			result["expiration_time"] = registered.GetExpirationTime().AsTime().UTC().Format(time.RFC3339Nano)
+		}
+	default:
+		result[bulkNetworkServiceEndpoint] = m.nse.GetName()
+		u, err = s.unregisterNSE(ctx, m.nse)
+	}
+	return u, result, err
+}
+
+// unregisterNS unregisters the NS and returns the undo registering it again, nothing is done if there is no such NS
+func (s *adminServer) unregisterNS(ctx context.Context, ns *registry.NetworkService) (undo, error) {
+	prev, err := s.findNS(ctx, ns.GetName())
+	if err != nil || prev == nil {
+		return func(context.Context) error { return nil }, err
+	}
+	if _, err = s.nsClient.Unregister(ctx, prev.Clone()); err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context) error {
+		_, undoErr := s.nsClient.Register(ctx, prev)
+		return undoErr
+	}, nil
+}
+
+// unregisterNSE unregisters the NSE and returns the undo registering it again, nothing is done if there is no such NSE
+func (s *adminServer) unregisterNSE(ctx context.Context, nse *registry.NetworkServiceEndpoint) (undo, error) {
+	prev, err := s.findNSE(ctx, nse.GetName())
+	if err != nil || prev == nil {
+		return func(context.Context) error { return nil }, err
+	}
+	if _, err = s.nseClient.Unregister(ctx, prev.Clone()); err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context) error {
+		_, undoErr := s.nseClient.Register(ctx, prev)
+		return undoErr
+	}, nil
+}
+
+func (m *mutation) name() string {
+	if m.ns != nil {
+		return m.ns.GetName()
+	}
+	return m.nse.GetName()
+}
+
+// parseMutation parses {"operation", "network_service" or "network_service_endpoint": object in protojson}
+func parseMutation(in *structpb.Struct) (*mutation, error) {
+	m := &mutation{operation: in.GetFields()["operation"].GetStringValue()}
+	if m.operation != bulkRegister && m.operation != bulkUnregister {
+		return nil, errors.Errorf("unknown operation %q, expected %s or %s", m.operation, bulkRegister, bulkUnregister)
+	}
+	nsValue, hasNS := in.GetFields()[bulkNetworkService]
+	nseValue, hasNSE := in.GetFields()[bulkNetworkServiceEndpoint]
+	var value *structpb.Value
+	var resource proto.Message
+	switch {
+	case hasNS && !hasNSE:
+		m.ns = new(registry.NetworkService)
+		value, resource = nsValue, m.ns
+	case hasNSE && !hasNS:
+		m.nse = new(registry.NetworkServiceEndpoint)
+		value, resource = nseValue, m.nse
+	default:
+		return nil, errors.Errorf("expected either %s or %s", bulkNetworkService, bulkNetworkServiceEndpoint)
+	}
+	data, err := protojson.Marshal(value)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	if err = protojson.Unmarshal(data, resource); err != nil {
+		return nil, errors.Wrap(err, "failed to unmarshal the mutated resource")
+	}
+	if m.name() == "" {
+		return nil, errors.New("name is required")
+	}
+	return m, nil
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/chain"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/admin"
+)
+
+func TestAdmin_Bulk(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	nsClient := adapters.NetworkServiceServerToClient(memory.NewNetworkServiceRegistryServer())
+	nseClient := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		&rejectNSEServer{name: "bad-nse"},
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+	server := admin.NewServer(admin.WithNSClient(nsClient), admin.WithNSEClient(nseClient))
+
+	_, err := nseClient.Register(ctx, &registry.NetworkServiceEndpoint{Name: "old-nse", NetworkServiceNames: []string{"ns-0"}})
+	require.NoError(t, err)
+
+	bulk := func(mutations ...interface{}) (*structpb.Struct, error) {
+		request, structErr := structpb.NewStruct(map[string]interface{}{"mutations": mutations})
+		require.NoError(t, structErr)
+		return server.Bulk(ctx, request)
+	}
+	register := func(field, name string) interface{} {
+		return map[string]interface{}{"operation": "register", field: map[string]interface{}{"name": name}}
+	}
+	unregister := func(field, name string) interface{} {
+		return map[string]interface{}{"operation": "unregister", field: map[string]interface{}{"name": name}}
+	}
+
+	// The unregistered NSE is restored on rollback
+	_, err = bulk(
+		register("network_service", "ns-1"),
+		unregister("network_service_endpoint", "old-nse"),
+		register("network_service_endpoint", "nse-1"),
+		register("network_service_endpoint", "bad-nse"),
+	)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "rolled back")
+	require.Equal(t, []string{"old-nse"}, nseNames(ctx, t, nseClient))
+
+	result, err := bulk(
+		register("network_service", "ns-1"),
+		unregister("network_service_endpoint", "old-nse"),
+		register("network_service_endpoint", "nse-1"),
+		register("network_service_endpoint", "nse-2"),
+		unregister("network_service_endpoint", "missing-nse"),
+	)
+	require.NoError(t, err)
+	require.Len(t, result.GetFields()["results"].GetListValue().GetValues(), 5)
+	require.ElementsMatch(t, []string{"nse-1", "nse-2"}, nseNames(ctx, t, nseClient))
+
+	_, err = bulk(map[string]interface{}{"operation": "upsert", "network_service": map[string]interface{}{"name": "ns-1"}})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	_, err = bulk(map[string]interface{}{"operation": "register"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func nseNames(ctx context.Context, t *testing.T, client registry.NetworkServiceEndpointRegistryClient) []string {
+	var names []string
+	for name := range labelsOf(ctx, t, client) {
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestAdmin_Bulk_Cancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	callCtx, cancelCall := context.WithCancel(ctx)
+	defer cancelCall()
+
+	nseClient := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		&cancelNSEServer{name: "cancel-nse", cancel: cancelCall},
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+	server := admin.NewServer(
+		admin.WithNSClient(adapters.NetworkServiceServerToClient(memory.NewNetworkServiceRegistryServer())),
+		admin.WithNSEClient(nseClient))
+
+	_, err := nseClient.Register(ctx, &registry.NetworkServiceEndpoint{Name: "old-nse", NetworkServiceNames: []string{"ns-0"}})
+	require.NoError(t, err)
+
+	// The call is cancelled in the middle of the bulk, the applied mutations are still reverted
+	request, err := structpb.NewStruct(map[string]interface{}{"mutations": []interface{}{
+		map[string]interface{}{"operation": "unregister", "network_service_endpoint": map[string]interface{}{"name": "old-nse"}},
+		map[string]interface{}{"operation": "register", "network_service_endpoint": map[string]interface{}{"name": "nse-1"}},
+		map[string]interface{}{"operation": "register", "network_service_endpoint": map[string]interface{}{"name": "cancel-nse"}},
+	}})
+	require.NoError(t, err)
+	_, err = server.Bulk(callCtx, request)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "bulk is rolled back")
+	require.Equal(t, []string{"old-nse"}, nseNames(ctx, t, nseClient))
+}
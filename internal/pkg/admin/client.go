@@ -0,0 +1,169 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin
+
+import (
+	"context"
+	"io"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/snapshot"
+)
+
+// Client is the client API of the admin service converting the state streams from and to the snapshots
+type Client struct {
+	client AdminClient
+}
+
+// NewClient creates a new admin client
+func NewClient(cc grpc.ClientConnInterface) *Client {
+	return &Client{
+		client: NewAdminClient(cc),
+	}
+}
+
+// ListTombstones returns the tombstones of the removed NSEs
+func (c *Client) ListTombstones(ctx context.Context, opts ...grpc.CallOption) (*structpb.Struct, error) {
+	return c.client.ListTombstones(ctx, new(emptypb.Empty), opts...)
+}
+
+// Relabel changes the labels of the NSEs matching the selector, see the package doc for the request format
+func (c *Client) Relabel(ctx context.Context, in *structpb.Struct, opts ...grpc.CallOption) (*structpb.Struct, error) {
+	return c.client.Relabel(ctx, in, opts...)
+}
+
+// GetStatus returns the registry status
+func (c *Client) GetStatus(ctx context.Context, opts ...grpc.CallOption) (*structpb.Struct, error) {
+	return c.client.GetStatus(ctx, new(emptypb.Empty), opts...)
+}
+
+// Export returns the registry data, see the package doc for the request format
+func (c *Client) Export(ctx context.Context, in *structpb.Struct, opts ...grpc.CallOption) (*wrapperspb.BytesValue, error) {
+	return c.client.Export(ctx, in, opts...)
+}
+
+// Revalidate probes the NSE and reports its state, see the package doc for the request format
+func (c *Client) Revalidate(ctx context.Context, in *structpb.Struct, opts ...grpc.CallOption) (*structpb.Struct, error) {
+	return c.client.Revalidate(ctx, in, opts...)
+}
+
+// GetStats returns the per network service statistics, see the package doc for the response format
+func (c *Client) GetStats(ctx context.Context, opts ...grpc.CallOption) (*structpb.Struct, error) {
+	return c.client.GetStats(ctx, new(emptypb.Empty), opts...)
+}
+
+// GetPeers returns the connected peers accounting, see the package doc for the response format
+func (c *Client) GetPeers(ctx context.Context, opts ...grpc.CallOption) (*structpb.Struct, error) {
+	return c.client.GetPeers(ctx, new(emptypb.Empty), opts...)
+}
+
+// History returns the last revisions of the NSE, see the package doc for the request and the response formats
+func (c *Client) History(ctx context.Context, in *structpb.Struct, opts ...grpc.CallOption) (*structpb.Struct, error) {
+	return c.client.History(ctx, in, opts...)
+}
+
+// Bulk applies the NS and NSE mutations all together or none of them, see the package doc for the request and the
+// response formats
+func (c *Client) Bulk(ctx context.Context, in *structpb.Struct, opts ...grpc.CallOption) (*structpb.Struct, error) {
+	return c.client.Bulk(ctx, in, opts...)
+}
+
+// ExportState returns the full registry state
+func (c *Client) ExportState(ctx context.Context, opts ...grpc.CallOption) (*snapshot.Snapshot, error) {
+	stream, err := c.client.ExportState(ctx, new(emptypb.Empty), opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	s := new(snapshot.Snapshot)
+	for {
+		a, recvErr := stream.Recv()
+		if recvErr == io.EOF {
+			return s, nil
+		}
+		if recvErr != nil {
+			return nil, recvErr
+		}
+		m, unmarshalErr := a.UnmarshalNew()
+		if unmarshalErr != nil {
+			return nil, errors.Wrap(unmarshalErr, "failed to unmarshal the exported state")
+		}
+		switch v := m.(type) {
+		case *registry.NetworkService:
+			s.NetworkServices = append(s.NetworkServices, v)
+		case *registry.NetworkServiceEndpoint:
+			s.NetworkServiceEndpoints = append(s.NetworkServiceEndpoints, v)
+		default:
+			return nil, errors.Errorf("unexpected message type %s", a.GetTypeUrl())
+		}
+	}
+}
+
+// ImportState registers the NSs and NSEs of the state, see the package doc for the response format
+func (c *Client) ImportState(ctx context.Context, s *snapshot.Snapshot, opts ...grpc.CallOption) (*structpb.Struct, error) {
+	stream, err := c.client.ImportState(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return sendSnapshot(stream, s)
+}
+
+// RegisterTransaction registers the NSs and NSEs of the state all together or none of them, see the package doc for
+// the response format
+func (c *Client) RegisterTransaction(ctx context.Context, s *snapshot.Snapshot, opts ...grpc.CallOption) (*structpb.Struct, error) {
+	stream, err := c.client.RegisterTransaction(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return sendSnapshot(stream, s)
+}
+
+// snapshotStream is the client stream of the state, see ImportState and RegisterTransaction
+type snapshotStream interface {
+	Send(*anypb.Any) error
+	CloseAndRecv() (*structpb.Struct, error)
+}
+
+// sendSnapshot streams the NSs and the NSEs of the state and returns the response
+func sendSnapshot(stream snapshotStream, s *snapshot.Snapshot) (*structpb.Struct, error) {
+	messages := make([]proto.Message, 0, len(s.NetworkServices)+len(s.NetworkServiceEndpoints))
+	for _, ns := range s.NetworkServices {
+		messages = append(messages, ns)
+	}
+	for _, nse := range s.NetworkServiceEndpoints {
+		messages = append(messages, nse)
+	}
+	for _, m := range messages {
+		a, anyErr := anypb.New(m)
+		if anyErr != nil {
+			return nil, errors.Wrap(anyErr, "failed to marshal the state")
+		}
+		if err := stream.Send(a); err != nil {
+			return nil, errors.Wrap(err, "failed to send the state")
+		}
+	}
+	return stream.CloseAndRecv()
+}
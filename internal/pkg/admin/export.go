@@ -0,0 +1,123 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/export"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/inprocess"
+)
+
+const (
+	datasetRegistrations = "registrations"
+	datasetChurn         = "churn"
+	datasetStats         = "stats"
+	formatCSV            = "csv"
+	formatPrometheus     = "prometheus"
+	formatParquet        = "parquet"
+)
+
+func (s *adminServer) Export(ctx context.Context, in *structpb.Struct) (*wrapperspb.BytesValue, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	dataset := in.GetFields()["dataset"].GetStringValue()
+	format := in.GetFields()["format"].GetStringValue()
+	if format == "" {
+		format = formatCSV
+		if dataset == datasetStats {
+			format = formatPrometheus
+		}
+	}
+	if format == formatParquet {
+		// There is no Parquet writer in the dependencies, the warehouses load the CSV or convert it
+		return nil, status.Error(codes.Unimplemented, "parquet export is not supported, export csv and convert it, "+
+			"e.g. with duckdb: COPY (SELECT * FROM 'registrations.csv') TO 'registrations.parquet'")
+	}
+	supported := format == formatCSV
+	if dataset == datasetStats {
+		supported = format == formatPrometheus
+	}
+	if !supported {
+		return nil, status.Errorf(codes.InvalidArgument, "unsupported export format %q of dataset %q", format, dataset)
+	}
+
+	buf := new(bytes.Buffer)
+	switch dataset {
+	case datasetRegistrations, "":
+		if s.nseClient == nil {
+			return nil, status.Error(codes.Unimplemented, "registrations export is not available")
+		}
+		nses, err := s.listNSEs(inprocess.WithContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+		if err := export.RegistrationsCSV(buf, nses); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	case datasetChurn:
+		if s.tombstones == nil {
+			return nil, status.Error(codes.Unimplemented, "tombstones are disabled")
+		}
+		if err := export.ChurnCSV(buf, s.tombstones.List()); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	case datasetStats:
+		if s.stats == nil {
+			return nil, status.Error(codes.Unimplemented, "statistics are not available")
+		}
+		now := clock.FromContext(ctx).Now()
+		if err := export.StatsPrometheus(buf, s.stats.Stats(now), now); err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "unknown dataset %q", dataset)
+	}
+	return wrapperspb.Bytes(buf.Bytes()), nil
+}
+
+func (s *adminServer) listNSEs(ctx context.Context) ([]*registry.NetworkServiceEndpoint, error) {
+	stream, err := s.nseClient.Find(ctx, &registry.NetworkServiceEndpointQuery{
+		NetworkServiceEndpoint: new(registry.NetworkServiceEndpoint),
+	})
+	if err != nil {
+		return nil, err
+	}
+	var result []*registry.NetworkServiceEndpoint
+	for {
+		resp, recvErr := stream.Recv()
+		if recvErr == io.EOF {
+			return result, nil
+		}
+		if recvErr != nil {
+			return nil, recvErr
+		}
+		result = append(result, resp.GetNetworkServiceEndpoint())
+	}
+}
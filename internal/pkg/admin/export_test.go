@@ -0,0 +1,66 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/admin"
+)
+
+func TestAdmin_Export(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	nseClient := adapters.NetworkServiceEndpointServerToClient(memory.NewNetworkServiceEndpointRegistryServer())
+	_, err := nseClient.Register(ctx, &registry.NetworkServiceEndpoint{
+		Name:                "nse-1",
+		Url:                 "tcp://1.1.1.1:5001",
+		NetworkServiceNames: []string{"ns-1"},
+	})
+	require.NoError(t, err)
+
+	server := admin.NewServer(admin.WithNSEClient(nseClient))
+
+	request, err := structpb.NewStruct(map[string]interface{}{"dataset": "registrations", "format": "csv"})
+	require.NoError(t, err)
+	exported, err := server.Export(ctx, request)
+	require.NoError(t, err)
+	require.Contains(t, string(exported.GetValue()), "nse-1,tcp://1.1.1.1:5001,ns-1")
+
+	request, err = structpb.NewStruct(map[string]interface{}{"dataset": "registrations", "format": "parquet"})
+	require.NoError(t, err)
+	_, err = server.Export(ctx, request)
+	require.Equal(t, codes.Unimplemented, status.Code(err))
+
+	request, err = structpb.NewStruct(map[string]interface{}{"dataset": "registrations", "format": "xlsx"})
+	require.NoError(t, err)
+	_, err = server.Export(ctx, request)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
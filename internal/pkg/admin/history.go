@@ -0,0 +1,82 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func (s *adminServer) History(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if s.history == nil {
+		return nil, status.Error(codes.Unimplemented, "history is disabled")
+	}
+	name := in.GetFields()["name"].GetStringValue()
+	if name == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is not set")
+	}
+
+	list := make([]interface{}, 0)
+	for _, r := range s.history.Revisions(name) {
+		labels := make(map[string]interface{})
+		for service, l := range r.NSE.GetNetworkServiceLabels() {
+			serviceLabels := make(map[string]interface{})
+			for key, value := range l.GetLabels() {
+				serviceLabels[key] = value
+			}
+			labels[service] = serviceLabels
+		}
+		names := make([]interface{}, 0, len(r.NSE.GetNetworkServiceNames()))
+		for _, ns := range r.NSE.GetNetworkServiceNames() {
+			names = append(names, ns)
+		}
+		changes := make([]interface{}, 0, len(r.Changes))
+		for _, c := range r.Changes {
+			changes = append(changes, map[string]interface{}{
+				"field": c.Field,
+				"old":   c.Old,
+				"new":   c.New,
+			})
+		}
+		revision := map[string]interface{}{
+			"time":                  r.Time.UTC().Format(time.RFC3339Nano),
+			"identity":              r.Identity,
+			"operation":             string(r.Operation),
+			"url":                   r.NSE.GetUrl(),
+			"network_service_names": names,
+			"labels":                labels,
+			"refreshes":             r.Refreshes,
+			"changes":               changes,
+		}
+		if r.NSE.GetExpirationTime() != nil {
+			revision["expiration_time"] = r.NSE.GetExpirationTime().AsTime().UTC().Format(time.RFC3339Nano)
+		}
+		list = append(list, revision)
+	}
+	result, err := structpb.NewStruct(map[string]interface{}{"name": name, "revisions": list})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to build history response: %s", err.Error())
+	}
+	return result, nil
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+)
+
+func (s *adminServer) GetPeers(ctx context.Context, _ *emptypb.Empty) (*structpb.Struct, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if s.peers == nil {
+		return nil, status.Error(codes.Unimplemented, "peers accounting is not available")
+	}
+
+	list := make([]interface{}, 0)
+	for _, p := range s.peers.Peers(clock.FromContext(ctx).Now()) {
+		list = append(list, map[string]interface{}{
+			"identity":      p.Identity,
+			"remote_addr":   p.RemoteAddr,
+			"registrations": p.Registrations,
+			"streams":       p.Streams,
+			"watch_streams": p.WatchStreams,
+			"bytes_sent":    p.BytesSent,
+			"last_seen":     p.LastSeen.UTC().Format(time.RFC3339Nano),
+		})
+	}
+	result, err := structpb.NewStruct(map[string]interface{}{"peers": list})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to build peers response: %s", err.Error())
+	}
+	return result, nil
+}
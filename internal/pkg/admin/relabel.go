@@ -0,0 +1,197 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/inprocess"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/selector"
+)
+
+// relabeling is a parsed Relabel request
+type relabeling struct {
+	selector       selector.Selector
+	networkService string
+	add            map[string]string
+	remove         []string
+	rename         map[string]string
+	dryRun         bool
+}
+
+func parseRelabeling(in *structpb.Struct) (*relabeling, error) {
+	fields := in.GetFields()
+	sel, err := selector.Parse(fields["selector"].GetStringValue())
+	if err != nil {
+		return nil, err
+	}
+	r := &relabeling{
+		selector:       sel,
+		networkService: fields["network_service"].GetStringValue(),
+		add:            make(map[string]string),
+		rename:         make(map[string]string),
+		dryRun:         fields["dry_run"].GetBoolValue(),
+	}
+	for key, value := range fields["add"].GetStructValue().GetFields() {
+		if _, ok := value.GetKind().(*structpb.Value_StringValue); !ok {
+			return nil, errors.Errorf("add: value of %s is not a string", key)
+		}
+		r.add[key] = value.GetStringValue()
+	}
+	for _, value := range fields["remove"].GetListValue().GetValues() {
+		if _, ok := value.GetKind().(*structpb.Value_StringValue); !ok {
+			return nil, errors.New("remove: label is not a string")
+		}
+		r.remove = append(r.remove, value.GetStringValue())
+	}
+	for key, value := range fields["rename"].GetStructValue().GetFields() {
+		if _, ok := value.GetKind().(*structpb.Value_StringValue); !ok || value.GetStringValue() == "" {
+			return nil, errors.Errorf("rename: new name of %s is not a string", key)
+		}
+		r.rename[key] = value.GetStringValue()
+	}
+	if len(r.add)+len(r.remove)+len(r.rename) == 0 {
+		return nil, errors.New("no label operations: set add, remove or rename")
+	}
+	return r, nil
+}
+
+// apply returns the relabeled copy of nse or nil if nothing changes. The operations are applied to the labels of the
+// network services matching the selector in the order: rename, remove, add.
+func (r *relabeling) apply(nse *registry.NetworkServiceEndpoint) *registry.NetworkServiceEndpoint {
+	var result *registry.NetworkServiceEndpoint
+	for _, name := range nse.GetNetworkServiceNames() {
+		if r.networkService != "" && name != r.networkService {
+			continue
+		}
+		labels := nse.GetNetworkServiceLabels()[name].GetLabels()
+		if !r.selector.Matches(labels) {
+			continue
+		}
+		relabeled := r.relabel(labels)
+		if equal(labels, relabeled) {
+			continue
+		}
+		if result == nil {
+			result = proto.Clone(nse).(*registry.NetworkServiceEndpoint)
+			if result.NetworkServiceLabels == nil {
+				result.NetworkServiceLabels = make(map[string]*registry.NetworkServiceLabels)
+			}
+		}
+		result.NetworkServiceLabels[name] = &registry.NetworkServiceLabels{Labels: relabeled}
+	}
+	return result
+}
+
+func (r *relabeling) relabel(labels map[string]string) map[string]string {
+	result := make(map[string]string, len(labels)+len(r.add))
+	for key, value := range labels {
+		result[key] = value
+	}
+	for from, to := range r.rename {
+		if value, ok := result[from]; ok {
+			delete(result, from)
+			result[to] = value
+		}
+	}
+	for _, key := range r.remove {
+		delete(result, key)
+	}
+	for key, value := range r.add {
+		result[key] = value
+	}
+	return result
+}
+
+func equal(a, b map[string]string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key, value := range a {
+		if other, ok := b[key]; !ok || other != value {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *adminServer) Relabel(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if s.nseClient == nil {
+		return nil, status.Error(codes.Unimplemented, "relabel is not available")
+	}
+	r, err := parseRelabeling(in)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	// The NSEs are changed on behalf of the registry, not of the admin
+	ctx = inprocess.WithContext(ctx)
+
+	nses, err := s.listNSEs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var relabeled []*registry.NetworkServiceEndpoint
+	for _, nse := range nses {
+		if changed := r.apply(nse); changed != nil {
+			relabeled = append(relabeled, changed)
+		}
+	}
+	sort.Slice(relabeled, func(i, j int) bool {
+		return relabeled[i].GetName() < relabeled[j].GetName()
+	})
+
+	changed := make([]interface{}, 0, len(relabeled))
+	for _, nse := range relabeled {
+		if !r.dryRun {
+			if _, err = s.nseClient.Register(ctx, nse); err != nil {
+				return nil, errors.Wrapf(err, "failed to relabel %s", nse.GetName())
+			}
+		}
+		labels := make(map[string]interface{})
+		for name, l := range nse.GetNetworkServiceLabels() {
+			values := make(map[string]interface{})
+			for key, value := range l.GetLabels() {
+				values[key] = value
+			}
+			labels[name] = values
+		}
+		changed = append(changed, map[string]interface{}{
+			"name":   nse.GetName(),
+			"labels": labels,
+		})
+	}
+	result, err := structpb.NewStruct(map[string]interface{}{
+		"dry_run": r.dryRun,
+		"changed": changed,
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to build relabel response: %s", err.Error())
+	}
+	return result, nil
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin_test
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/admin"
+)
+
+func labelsOf(ctx context.Context, t *testing.T, client registry.NetworkServiceEndpointRegistryClient) map[string]map[string]string {
+	stream, err := client.Find(ctx, &registry.NetworkServiceEndpointQuery{
+		NetworkServiceEndpoint: new(registry.NetworkServiceEndpoint),
+	})
+	require.NoError(t, err)
+	result := make(map[string]map[string]string)
+	for {
+		resp, recvErr := stream.Recv()
+		if recvErr == io.EOF {
+			return result
+		}
+		require.NoError(t, recvErr)
+		nse := resp.GetNetworkServiceEndpoint()
+		result[nse.GetName()] = nse.GetNetworkServiceLabels()["ns-1"].GetLabels()
+	}
+}
+
+func TestAdmin_Relabel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	nseClient := adapters.NetworkServiceEndpointServerToClient(memory.NewNetworkServiceEndpointRegistryServer())
+	for name, labels := range map[string]map[string]string{
+		"nse-1": {"zone": "a", "app": "firewall"},
+		"nse-2": {"zone": "b", "app": "vpn"},
+		"nse-3": {"app": "firewall"},
+	} {
+		_, err := nseClient.Register(ctx, &registry.NetworkServiceEndpoint{
+			Name:                 name,
+			NetworkServiceNames:  []string{"ns-1"},
+			NetworkServiceLabels: map[string]*registry.NetworkServiceLabels{"ns-1": {Labels: labels}},
+		})
+		require.NoError(t, err)
+	}
+
+	server := admin.NewServer(admin.WithNSEClient(nseClient))
+
+	request, err := structpb.NewStruct(map[string]interface{}{
+		"selector": "zone",
+		"rename":   map[string]interface{}{"zone": "topology.zone"},
+		"add":      map[string]interface{}{"migrated": "true"},
+		"dry_run":  true,
+	})
+	require.NoError(t, err)
+
+	resp, err := server.Relabel(ctx, request)
+	require.NoError(t, err)
+	require.True(t, resp.GetFields()["dry_run"].GetBoolValue())
+	changed := resp.GetFields()["changed"].GetListValue().GetValues()
+	require.Len(t, changed, 2)
+	require.Equal(t, "nse-1", changed[0].GetStructValue().GetFields()["name"].GetStringValue())
+	require.Equal(t, "a", changed[0].GetStructValue().GetFields()["labels"].GetStructValue().
+		GetFields()["ns-1"].GetStructValue().GetFields()["topology.zone"].GetStringValue())
+	require.Equal(t, "a", labelsOf(ctx, t, nseClient)["nse-1"]["zone"])
+
+	request.Fields["dry_run"] = structpb.NewBoolValue(false)
+	_, err = server.Relabel(ctx, request)
+	require.NoError(t, err)
+
+	require.Equal(t, map[string]map[string]string{
+		"nse-1": {"topology.zone": "a", "app": "firewall", "migrated": "true"},
+		"nse-2": {"topology.zone": "b", "app": "vpn", "migrated": "true"},
+		"nse-3": {"app": "firewall"},
+	}, labelsOf(ctx, t, nseClient))
+
+	_, err = server.Relabel(ctx, new(structpb.Struct))
+	require.Error(t, err)
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/url"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/inprocess"
+)
+
+const defaultProbeTimeout = 5 * time.Second
+
+func (s *adminServer) Revalidate(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if s.nseClient == nil {
+		return nil, status.Error(codes.Unimplemented, "revalidate is not available")
+	}
+	fields := in.GetFields()
+	name := fields["name"].GetStringValue()
+	if name == "" {
+		return nil, status.Error(codes.InvalidArgument, "name is not set")
+	}
+	probeTimeout := defaultProbeTimeout
+	if value := fields["probe_timeout"].GetStringValue(); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid probe_timeout %q", value)
+		}
+		probeTimeout = d
+	}
+	// The NSE is read and refreshed on behalf of the registry, not of the admin
+	ctx = inprocess.WithContext(ctx)
+
+	nse, err := s.findNSE(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if nse == nil {
+		return nil, status.Errorf(codes.NotFound, "%s is not registered", name)
+	}
+
+	result := map[string]interface{}{
+		"name": nse.GetName(),
+		"url":  nse.GetUrl(),
+	}
+
+	probeErr := probe(ctx, nse.GetUrl(), probeTimeout)
+	result["reachable"] = probeErr == nil
+	if probeErr != nil {
+		result["probe_error"] = probeErr.Error()
+	}
+
+	result["alive"] = true
+	if nse.GetExpirationTime() != nil {
+		expiresIn := nse.GetExpirationTime().AsTime().Sub(clock.FromContext(ctx).Now())
+		result["expiration_time"] = nse.GetExpirationTime().AsTime().UTC().Format(time.RFC3339Nano)
+		result["expires_in"] = expiresIn.String()
+		result["alive"] = expiresIn > 0
+	}
+
+	demandRefresh := fields["demand_refresh"].GetBoolValue()
+	if demandRefresh {
+		if _, err = s.nseClient.Register(ctx, nse); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to notify the watchers of %s: %s", name, err.Error())
+		}
+	}
+	result["refresh_demanded"] = demandRefresh
+
+	resp, err := structpb.NewStruct(result)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to build revalidate response: %s", err.Error())
+	}
+	return resp, nil
+}
+
+func (s *adminServer) findNSE(ctx context.Context, name string) (*registry.NetworkServiceEndpoint, error) {
+	stream, err := s.nseClient.Find(ctx, &registry.NetworkServiceEndpointQuery{
+		NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{Name: name},
+	})
+	if err != nil {
+		return nil, err
+	}
+	for {
+		resp, recvErr := stream.Recv()
+		if recvErr == io.EOF {
+			return nil, nil
+		}
+		if recvErr != nil {
+			return nil, recvErr
+		}
+		if resp.GetNetworkServiceEndpoint().GetName() == name {
+			return resp.GetNetworkServiceEndpoint(), nil
+		}
+	}
+}
+
+// probe opens a connection to the NSE URL: unix://<path> or <scheme>://<host>:<port>
+func probe(ctx context.Context, rawURL string, timeout time.Duration) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return err
+	}
+	network, address := "tcp", u.Host
+	if u.Scheme == "unix" {
+		network, address = "unix", u.Path
+	}
+	dialer := &net.Dialer{Timeout: timeout}
+	conn, err := dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
+}
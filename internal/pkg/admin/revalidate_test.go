@@ -0,0 +1,90 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/admin"
+)
+
+func TestAdmin_Revalidate(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = listener.Close() }()
+
+	nseClient := adapters.NetworkServiceEndpointServerToClient(memory.NewNetworkServiceEndpointRegistryServer())
+	_, err = nseClient.Register(ctx, &registry.NetworkServiceEndpoint{
+		Name:           "nse-1",
+		Url:            "tcp://" + listener.Addr().String(),
+		ExpirationTime: timestamppb.New(time.Now().Add(time.Minute)),
+	})
+	require.NoError(t, err)
+
+	stream, err := nseClient.Find(ctx, &registry.NetworkServiceEndpointQuery{
+		NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{Name: "nse-1"},
+		Watch:                  true,
+	})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.NoError(t, err)
+
+	server := admin.NewServer(admin.WithNSEClient(nseClient))
+	resp, err := server.Revalidate(ctx, &structpb.Struct{Fields: map[string]*structpb.Value{
+		"name":           structpb.NewStringValue("nse-1"),
+		"demand_refresh": structpb.NewBoolValue(true),
+	}})
+	require.NoError(t, err)
+	require.True(t, resp.GetFields()["reachable"].GetBoolValue())
+	require.True(t, resp.GetFields()["alive"].GetBoolValue())
+	require.True(t, resp.GetFields()["refresh_demanded"].GetBoolValue())
+
+	// The watchers get the NSE again
+	event, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "nse-1", event.GetNetworkServiceEndpoint().GetName())
+
+	require.NoError(t, listener.Close())
+	resp, err = server.Revalidate(ctx, &structpb.Struct{Fields: map[string]*structpb.Value{
+		"name": structpb.NewStringValue("nse-1"),
+	}})
+	require.NoError(t, err)
+	require.False(t, resp.GetFields()["reachable"].GetBoolValue())
+	require.NotEmpty(t, resp.GetFields()["probe_error"].GetStringValue())
+
+	_, err = server.Revalidate(ctx, &structpb.Struct{Fields: map[string]*structpb.Value{
+		"name": structpb.NewStringValue("nse-2"),
+	}})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
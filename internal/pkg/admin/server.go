@@ -0,0 +1,264 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package admin provides the registry administration gRPC API. Only the configured admin SPIFFE IDs and the IDs with
+// the admin role in the identity mapping can call it.
+//
+// The API is defined in admin.proto from well-known protobuf types only, the Struct requests and responses follow.
+//
+// ListTombstones returns {"tombstones": [{"name", "reason", "deleted_at", "url", "network_service_names",
+// "expiration_time"}...]}.
+//
+// Relabel changes the labels of all the NSEs matching the selector: {"selector", "network_service", "add": {key:
+// value}, "remove": [key], "rename": {old: new}, "dry_run"}. Only the labels of the network services matching the
+// selector (and network_service if set) are changed: the keys are renamed, then removed, then added. It returns
+// {"dry_run", "changed": [{"name", "labels": {network service: {key: value}}}...]}; nothing is changed on dry run.
+//
+// GetStatus returns {"synced", "failed_health_checks": {check: reason}}: whether the registry has restored its initial
+// state and the failing health checks turning the registry services NOT_SERVING.
+//
+// Export returns the registry data for offline analytics: {"dataset": "registrations" or "churn", "format": "csv"}.
+// The churn is the tombstones of the NSEs removed within the tombstone retention, see export package for the columns.
+// Parquet is not supported: "format": "parquet" fails with Unimplemented, the CSV is to be converted instead.
+// {"dataset": "stats", "format": "prometheus"} returns the GetStats statistics as a one-shot Prometheus text
+// exposition for the cron-based collection.
+//
+// Revalidate checks the NSE right away: {"name", "probe_timeout": duration, default 5s, "demand_refresh"}. It opens a
+// connection to the NSE URL and returns {"name", "url", "reachable", "probe_error", "expiration_time", "expires_in",
+// "alive", "refresh_demanded"}, where alive means the owner keeps refreshing the registration before it expires. On
+// demand_refresh the NSE is re-registered unchanged, so the watchers get it again (unless the watch deduplication
+// suppresses it).
+//
+// ExportState streams the full registry state for a blue/green upgrade: all the NSs, then all the NSEs, each packed
+// into Any. ImportState registers the streamed NSs and NSEs, skipping the already expired NSEs, and returns
+// {"network_services", "network_service_endpoints", "expired"} counts. The imported NSEs keep their expiration time,
+// so they stay until the owners refresh them on the new registry. Once the import completes, the
+// registry.restored-from-snapshot event is published with the counts.
+//
+// GetStats returns {"network_services": [{"name", "endpoints", "oldest_registration", "newest_registration",
+// "average_remaining_expiration", "last_modified"}...]}, see nsstats package for the meaning.
+//
+// RegisterTransaction registers the streamed NSs and NSEs, packed into Any as for ImportState, all together or none of
+// them: the NSs are registered first, then the NSEs, and if any registration fails the applied ones are rolled back
+// (the previous versions are registered again, the new ones are unregistered) and the error is returned. It returns
+// {"network_services", "network_service_endpoints"} counts. The watchers may see the registrations rolled back.
+//
+// GetPeers returns {"peers": [{"identity", "remote_addr", "registrations", "streams", "watch_streams", "bytes_sent",
+// "last_seen"}...]}, see peerstats package for the meaning.
+//
+// History returns the last revisions of the NSE: {"name"}. It returns {"name", "revisions": [{"time", "identity",
+// "operation", "url", "network_service_names", "labels": {network service: {key: value}}, "expiration_time",
+// "refreshes", "changes": [{"field", "old", "new"}...]}...]} from the oldest to the newest, where identity is the
+// caller SPIFFE ID and refreshes is the number of the following refreshes changing nothing but the expiration time,
+// see history package for the meaning.
+//
+// Bulk applies many NS and NSE mutations in one call, all together or none of them: {"mutations": [{"operation":
+// "register" or "unregister", "network_service" or "network_service_endpoint": the NS or NSE in protojson}...]}. The
+// mutations are applied in the order, so the NSs should go before the NSEs referring to them. Unregistering a missing
+// NS or NSE changes nothing. If any mutation fails the applied ones are rolled back as for RegisterTransaction and the
+// error is returned. It returns {"results": [{"operation", "network_service" or "network_service_endpoint": name,
+// "expiration_time"}...]}, where expiration_time is the one granted to a registered NSE.
+package admin
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/events"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/history"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/identity"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/nsstats"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/peerstats"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/synced"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/tombstones"
+)
+
+type options struct {
+	adminIDs   map[string]struct{}
+	tombstones *tombstones.Store
+	nsClient   registry.NetworkServiceRegistryClient
+	nseClient  registry.NetworkServiceEndpointRegistryClient
+	synced     *synced.Condition
+	identities *identity.Mapping
+	stats      *nsstats.Tracker
+	peers      *peerstats.Tracker
+	history    *history.Store
+	bus        *events.Bus
+}
+
+// Option is an option for the admin server
+type Option func(o *options)
+
+// WithAdminIDs sets the SPIFFE IDs allowed to call the admin API
+func WithAdminIDs(ids ...string) Option {
+	return func(o *options) {
+		for _, id := range ids {
+			o.adminIDs[id] = struct{}{}
+		}
+	}
+}
+
+// WithTombstones sets the tombstones store
+func WithTombstones(store *tombstones.Store) Option {
+	return func(o *options) {
+		o.tombstones = store
+	}
+}
+
+// WithNSEClient sets the client of the registry NSE chain used to change the NSEs
+func WithNSEClient(client registry.NetworkServiceEndpointRegistryClient) Option {
+	return func(o *options) {
+		o.nseClient = client
+	}
+}
+
+// WithNSClient sets the client of the registry NS chain used to export and import the NSs
+func WithNSClient(client registry.NetworkServiceRegistryClient) Option {
+	return func(o *options) {
+		o.nsClient = client
+	}
+}
+
+// WithSynced sets the synced condition reported by GetStatus
+func WithSynced(condition *synced.Condition) Option {
+	return func(o *options) {
+		o.synced = condition
+	}
+}
+
+// WithIdentities sets the identity mapping granting the admin role
+func WithIdentities(identities *identity.Mapping) Option {
+	return func(o *options) {
+		o.identities = identities
+	}
+}
+
+// WithStats sets the NSE tracker reported by GetStats
+func WithStats(tracker *nsstats.Tracker) Option {
+	return func(o *options) {
+		o.stats = tracker
+	}
+}
+
+// WithPeers sets the peers tracker reported by GetPeers
+func WithPeers(tracker *peerstats.Tracker) Option {
+	return func(o *options) {
+		o.peers = tracker
+	}
+}
+
+// WithHistory sets the NSE history store reported by History
+func WithHistory(store *history.Store) Option {
+	return func(o *options) {
+		o.history = store
+	}
+}
+
+// WithBus sets the bus to publish the events.TypeRestoredFromSnapshot events to
+func WithBus(bus *events.Bus) Option {
+	return func(o *options) {
+		o.bus = bus
+	}
+}
+
+type adminServer struct {
+	UnimplementedAdminServer
+	options
+}
+
+// NewServer creates a new admin server, to be registered with RegisterAdminServer
+func NewServer(opts ...Option) AdminServer {
+	s := &adminServer{
+		options: options{
+			adminIDs: make(map[string]struct{}),
+		},
+	}
+	for _, opt := range opts {
+		opt(&s.options)
+	}
+	return s
+}
+
+func (s *adminServer) ListTombstones(ctx context.Context, _ *emptypb.Empty) (*structpb.Struct, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	if s.tombstones == nil {
+		return nil, status.Error(codes.Unimplemented, "tombstones are disabled")
+	}
+
+	list := make([]interface{}, 0)
+	for _, t := range s.tombstones.List() {
+		names := make([]interface{}, 0, len(t.NSE.GetNetworkServiceNames()))
+		for _, name := range t.NSE.GetNetworkServiceNames() {
+			names = append(names, name)
+		}
+		list = append(list, map[string]interface{}{
+			"name":                  t.NSE.GetName(),
+			"reason":                string(t.Reason),
+			"deleted_at":            t.DeletedAt.UTC().Format(time.RFC3339Nano),
+			"url":                   t.NSE.GetUrl(),
+			"network_service_names": names,
+			"expiration_time":       t.NSE.GetExpirationTime().AsTime().UTC().Format(time.RFC3339Nano),
+		})
+	}
+	result, err := structpb.NewStruct(map[string]interface{}{"tombstones": list})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to build tombstones response: %s", err.Error())
+	}
+	return result, nil
+}
+
+func (s *adminServer) GetStatus(ctx context.Context, _ *emptypb.Empty) (*structpb.Struct, error) {
+	if err := s.authorize(ctx); err != nil {
+		return nil, err
+	}
+	failures := &structpb.Struct{Fields: make(map[string]*structpb.Value)}
+	if s.synced != nil {
+		for check, reason := range s.synced.Failures() {
+			failures.Fields[check] = structpb.NewStringValue(reason)
+		}
+	}
+	return &structpb.Struct{
+		Fields: map[string]*structpb.Value{
+			"synced":               structpb.NewBoolValue(s.synced == nil || s.synced.Synced()),
+			"failed_health_checks": structpb.NewStructValue(failures),
+		},
+	}, nil
+}
+
+// authorize rejects the remote callers with SPIFFE ID neither in the admin IDs nor having the admin role
+func (s *adminServer) authorize(ctx context.Context) error {
+	if _, ok := peer.FromContext(ctx); !ok {
+		return nil
+	}
+	id, labels, ok := s.identities.FromContext(ctx)
+	if !ok {
+		return status.Error(codes.PermissionDenied, "admin API requires a SPIFFE ID")
+	}
+	if _, isAdmin := s.adminIDs[id.String()]; !isAdmin && labels[identity.LabelRole] != identity.RoleAdmin {
+		return status.Errorf(codes.PermissionDenied, "%s is not an admin", id.String())
+	}
+	return nil
+}
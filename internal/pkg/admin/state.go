@@ -0,0 +1,141 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin
+
+import (
+	"io"
+	"strconv"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/events"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/inprocess"
+)
+
+func (s *adminServer) ExportState(_ *emptypb.Empty, server Admin_ExportStateServer) error {
+	if err := s.authorize(server.Context()); err != nil {
+		return err
+	}
+	if s.nsClient == nil || s.nseClient == nil {
+		return status.Error(codes.Unimplemented, "state export is not available")
+	}
+	ctx := inprocess.WithContext(server.Context())
+
+	nsStream, err := s.nsClient.Find(ctx, &registry.NetworkServiceQuery{NetworkService: new(registry.NetworkService)})
+	if err != nil {
+		return err
+	}
+	for _, ns := range registry.ReadNetworkServiceList(nsStream) {
+		if err = sendAny(server, ns.GetName(), ns); err != nil {
+			return err
+		}
+	}
+	nses, err := s.listNSEs(ctx)
+	if err != nil {
+		return err
+	}
+	for _, nse := range nses {
+		if err = sendAny(server, nse.GetName(), nse); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sendAny(server Admin_ExportStateServer, name string, m proto.Message) error {
+	a, err := anypb.New(m)
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to marshal %s: %s", name, err.Error())
+	}
+	return errors.Wrapf(server.Send(a), "failed to send %s", name)
+}
+
+func (s *adminServer) ImportState(server Admin_ImportStateServer) error {
+	if err := s.authorize(server.Context()); err != nil {
+		return err
+	}
+	if s.nsClient == nil || s.nseClient == nil {
+		return status.Error(codes.Unimplemented, "state import is not available")
+	}
+	// The registrations are restored on behalf of the registry, not of the admin
+	ctx := inprocess.WithContext(server.Context())
+	now := clock.FromContext(ctx).Now()
+
+	var nsCount, nseCount, expiredCount int
+	for {
+		a, recvErr := server.Recv()
+		if recvErr == io.EOF {
+			break
+		}
+		if recvErr != nil {
+			return recvErr
+		}
+		switch {
+		case a.MessageIs(new(registry.NetworkService)):
+			ns := new(registry.NetworkService)
+			if err := a.UnmarshalTo(ns); err != nil {
+				return status.Errorf(codes.InvalidArgument, "failed to unmarshal network service: %s", err.Error())
+			}
+			if _, err := s.nsClient.Register(ctx, ns); err != nil {
+				return errors.Wrapf(err, "failed to import %s", ns.GetName())
+			}
+			nsCount++
+		case a.MessageIs(new(registry.NetworkServiceEndpoint)):
+			nse := new(registry.NetworkServiceEndpoint)
+			if err := a.UnmarshalTo(nse); err != nil {
+				return status.Errorf(codes.InvalidArgument, "failed to unmarshal network service endpoint: %s", err.Error())
+			}
+			if nse.GetExpirationTime() != nil && !nse.GetExpirationTime().AsTime().After(now) {
+				expiredCount++
+				continue
+			}
+			if _, err := s.nseClient.Register(ctx, nse); err != nil {
+				return errors.Wrapf(err, "failed to import %s", nse.GetName())
+			}
+			nseCount++
+		default:
+			return status.Errorf(codes.InvalidArgument, "unexpected message type %s", a.GetTypeUrl())
+		}
+	}
+
+	result, err := structpb.NewStruct(map[string]interface{}{
+		"network_services":          nsCount,
+		"network_service_endpoints": nseCount,
+		"expired":                   expiredCount,
+	})
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to build import response: %s", err.Error())
+	}
+	if s.bus != nil {
+		s.bus.Publish(ctx, events.New(events.TypeRestoredFromSnapshot, map[string]string{
+			"network_services":          strconv.Itoa(nsCount),
+			"network_service_endpoints": strconv.Itoa(nseCount),
+			"expired":                   strconv.Itoa(expiredCount),
+		}))
+	}
+	return server.SendAndClose(result)
+}
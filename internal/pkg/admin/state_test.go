@@ -0,0 +1,155 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/admin"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/events"
+)
+
+var adminID = spiffeid.RequireFromString("spiffe://test.com/admin")
+
+type adminStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *adminStream) Context() context.Context {
+	return s.ctx
+}
+
+// withAdminPeer makes the streams look like coming from the admin SPIFFE ID over mTLS
+func withAdminPeer(srv interface{}, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	ctx := peer.NewContext(ss.Context(), &peer.Peer{
+		AuthInfo: credentials.TLSInfo{State: tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{{URIs: []*url.URL{adminID.URL()}}},
+		}},
+	})
+	return handler(srv, &adminStream{ServerStream: ss, ctx: ctx})
+}
+
+type registryClients struct {
+	ns  registry.NetworkServiceRegistryClient
+	nse registry.NetworkServiceEndpointRegistryClient
+}
+
+func startAdmin(ctx context.Context, t *testing.T, opts ...admin.Option) (*admin.Client, registryClients) {
+	clients := registryClients{
+		ns:  adapters.NetworkServiceServerToClient(memory.NewNetworkServiceRegistryServer()),
+		nse: adapters.NetworkServiceEndpointServerToClient(memory.NewNetworkServiceEndpointRegistryServer()),
+	}
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(grpc.StreamInterceptor(withAdminPeer))
+	admin.RegisterAdminServer(server, admin.NewServer(append([]admin.Option{
+		admin.WithAdminIDs(adminID.String()),
+		admin.WithNSClient(clients.ns),
+		admin.WithNSEClient(clients.nse),
+	}, opts...)...))
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	cc, err := grpc.DialContext(ctx, "bufconn",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = cc.Close() })
+
+	return admin.NewClient(cc), clients
+}
+
+func TestAdmin_ExportImportState(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	bus := events.NewBus()
+	defer bus.Close()
+	restored := bus.Subscribe(1)
+
+	oldClient, oldRegistry := startAdmin(ctx, t)
+	newClient, newRegistry := startAdmin(ctx, t, admin.WithBus(bus))
+
+	_, err := oldRegistry.ns.Register(ctx, &registry.NetworkService{Name: "ns-1"})
+	require.NoError(t, err)
+	for _, name := range []string{"nse-1", "nse-2"} {
+		_, err = oldRegistry.nse.Register(ctx, &registry.NetworkServiceEndpoint{
+			Name:                name,
+			NetworkServiceNames: []string{"ns-1"},
+			ExpirationTime:      timestamppb.New(time.Now().Add(time.Minute)),
+		})
+		require.NoError(t, err)
+	}
+
+	state, err := oldClient.ExportState(ctx)
+	require.NoError(t, err)
+	require.Len(t, state.NetworkServices, 1)
+	require.Len(t, state.NetworkServiceEndpoints, 2)
+
+	// An expired NSE is skipped
+	state.NetworkServiceEndpoints = append(state.NetworkServiceEndpoints, &registry.NetworkServiceEndpoint{
+		Name:           "nse-3",
+		ExpirationTime: timestamppb.New(time.Now().Add(-time.Minute)),
+	})
+
+	result, err := newClient.ImportState(ctx, state)
+	require.NoError(t, err)
+	require.Equal(t, 1.0, result.GetFields()["network_services"].GetNumberValue())
+	require.Equal(t, 2.0, result.GetFields()["network_service_endpoints"].GetNumberValue())
+	require.Equal(t, 1.0, result.GetFields()["expired"].GetNumberValue())
+
+	event := <-restored
+	require.Equal(t, events.TypeRestoredFromSnapshot, event.Type)
+	require.Equal(t, map[string]string{
+		"network_services":          "1",
+		"network_service_endpoints": "2",
+		"expired":                   "1",
+	}, event.Attributes)
+
+	imported, err := newClient.ExportState(ctx)
+	require.NoError(t, err)
+	require.Len(t, imported.NetworkServices, 1)
+	require.Len(t, imported.NetworkServiceEndpoints, 2)
+
+	stream, err := newRegistry.nse.Find(ctx, &registry.NetworkServiceEndpointQuery{
+		NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{Name: "nse-1"},
+	})
+	require.NoError(t, err)
+	require.Len(t, registry.ReadNetworkServiceEndpointList(stream), 1)
+}
@@ -0,0 +1,194 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin
+
+import (
+	"context"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/log"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/inprocess"
+)
+
+// rollbackTimeout is the timeout of reverting the mutations of a failed operation
+const rollbackTimeout = 15 * time.Second
+
+// undo reverts an applied registration
+type undo func(ctx context.Context) error
+
+func (s *adminServer) RegisterTransaction(server Admin_RegisterTransactionServer) error {
+	if err := s.authorize(server.Context()); err != nil {
+		return err
+	}
+	if s.nsClient == nil || s.nseClient == nil {
+		return status.Error(codes.Unimplemented, "register transaction is not available")
+	}
+	ctx := inprocess.WithContext(server.Context())
+
+	var nses []*registry.NetworkServiceEndpoint
+	var nss []*registry.NetworkService
+	for {
+		a, recvErr := server.Recv()
+		if recvErr == io.EOF {
+			break
+		}
+		if recvErr != nil {
+			return recvErr
+		}
+		switch {
+		case a.MessageIs(new(registry.NetworkService)):
+			ns := new(registry.NetworkService)
+			if err := a.UnmarshalTo(ns); err != nil {
+				return status.Errorf(codes.InvalidArgument, "failed to unmarshal network service: %s", err.Error())
+			}
+			nss = append(nss, ns)
+		case a.MessageIs(new(registry.NetworkServiceEndpoint)):
+			nse := new(registry.NetworkServiceEndpoint)
+			if err := a.UnmarshalTo(nse); err != nil {
+				return status.Errorf(codes.InvalidArgument, "failed to unmarshal network service endpoint: %s", err.Error())
+			}
+			nses = append(nses, nse)
+		default:
+			return status.Errorf(codes.InvalidArgument, "unexpected message type %s", a.GetTypeUrl())
+		}
+	}
+
+	// The NSs are registered first, so the NSEs can refer to them
+	var undos []undo
+	for _, ns := range nss {
+		u, err := s.registerNS(ctx, ns)
+		if err != nil {
+			return s.abort(ctx, undos, "transaction", errors.Wrapf(err, "failed to register %s", ns.GetName()))
+		}
+		undos = append(undos, u)
+	}
+	for _, nse := range nses {
+		_, u, err := s.registerNSE(ctx, nse)
+		if err != nil {
+			return s.abort(ctx, undos, "transaction", errors.Wrapf(err, "failed to register %s", nse.GetName()))
+		}
+		undos = append(undos, u)
+	}
+
+	result, err := structpb.NewStruct(map[string]interface{}{
+		"network_services":          len(nss),
+		"network_service_endpoints": len(nses),
+	})
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to build transaction response: %s", err.Error())
+	}
+	return server.SendAndClose(result)
+}
+
+// registerNS registers the NS and returns the undo restoring the previous NS or unregistering the new one
+func (s *adminServer) registerNS(ctx context.Context, ns *registry.NetworkService) (undo, error) {
+	prev, err := s.findNS(ctx, ns.GetName())
+	if err != nil {
+		return nil, err
+	}
+	if _, err = s.nsClient.Register(ctx, ns.Clone()); err != nil {
+		return nil, err
+	}
+	return func(ctx context.Context) error {
+		if prev != nil {
+			_, undoErr := s.nsClient.Register(ctx, prev)
+			return undoErr
+		}
+		_, undoErr := s.nsClient.Unregister(ctx, ns)
+		return undoErr
+	}, nil
+}
+
+// registerNSE registers the NSE and returns the registered NSE and the undo restoring the previous NSE or unregistering
+// the new one
+func (s *adminServer) registerNSE(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*registry.NetworkServiceEndpoint, undo, error) {
+	prev, err := s.findNSE(ctx, nse.GetName())
+	if err != nil {
+		return nil, nil, err
+	}
+	registered, err := s.nseClient.Register(ctx, nse.Clone())
+	if err != nil {
+		return nil, nil, err
+	}
+	return registered, func(ctx context.Context) error {
+		if prev != nil {
+			_, undoErr := s.nseClient.Register(ctx, prev)
+			return undoErr
+		}
+		_, undoErr := s.nseClient.Unregister(ctx, registered)
+		return undoErr
+	}, nil
+}
+
+// abort rolls back the applied mutations of the failed operation and returns err with the rollback result
+func (s *adminServer) abort(ctx context.Context, undos []undo, operation string, err error) error {
+	if rollbackErr := s.rollback(ctx, undos); rollbackErr != nil {
+		return errors.Wrapf(err, "%s is partially rolled back: %s", operation, rollbackErr.Error())
+	}
+	return errors.Wrapf(err, "%s is rolled back", operation)
+}
+
+// rollback reverts the applied mutations in the reverse order. It doesn't use the ctx cancellation, so the mutations
+// are reverted even if the call is cancelled or its deadline is exceeded.
+func (s *adminServer) rollback(ctx context.Context, undos []undo) error {
+	logger := log.FromContext(ctx)
+	rollbackCtx, cancel := context.WithTimeout(inprocess.WithContext(log.WithLog(context.Background(), logger)), rollbackTimeout)
+	defer cancel()
+
+	var failed []string
+	for i := len(undos) - 1; i >= 0; i-- {
+		if err := undos[i](rollbackCtx); err != nil {
+			logger.Errorf("failed to roll back a mutation: %s", err.Error())
+			failed = append(failed, err.Error())
+		}
+	}
+	if len(failed) > 0 {
+		return errors.Errorf("failed to revert %d of %d mutations: %s", len(failed), len(undos), strings.Join(failed, "; "))
+	}
+	return nil
+}
+
+func (s *adminServer) findNS(ctx context.Context, name string) (*registry.NetworkService, error) {
+	stream, err := s.nsClient.Find(ctx, &registry.NetworkServiceQuery{
+		NetworkService: &registry.NetworkService{Name: name},
+	})
+	if err != nil {
+		return nil, err
+	}
+	for {
+		resp, recvErr := stream.Recv()
+		if recvErr == io.EOF {
+			return nil, nil
+		}
+		if recvErr != nil {
+			return nil, recvErr
+		}
+		if resp.GetNetworkService().GetName() == name {
+			return resp.GetNetworkService(), nil
+		}
+	}
+}
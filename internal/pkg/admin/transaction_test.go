@@ -0,0 +1,252 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admin_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/chain"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/admin"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/snapshot"
+)
+
+// rejectNSEServer rejects the registrations of the NSEs with the name
+type rejectNSEServer struct {
+	name string
+}
+
+func (s *rejectNSEServer) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*registry.NetworkServiceEndpoint, error) {
+	if nse.GetName() == s.name {
+		return nil, status.Errorf(codes.InvalidArgument, "%s is rejected", nse.GetName())
+	}
+	return next.NetworkServiceEndpointRegistryServer(ctx).Register(ctx, nse)
+}
+
+func (s *rejectNSEServer) Find(query *registry.NetworkServiceEndpointQuery, server registry.NetworkServiceEndpointRegistry_FindServer) error {
+	return next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, server)
+}
+
+func (s *rejectNSEServer) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*empty.Empty, error) {
+	return next.NetworkServiceEndpointRegistryServer(ctx).Unregister(ctx, nse)
+}
+
+func TestAdmin_RegisterTransaction(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	nsClient := adapters.NetworkServiceServerToClient(memory.NewNetworkServiceRegistryServer())
+	nseClient := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		&rejectNSEServer{name: "bad-nse"},
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(grpc.StreamInterceptor(withAdminPeer))
+	admin.RegisterAdminServer(server, admin.NewServer(
+		admin.WithAdminIDs(adminID.String()),
+		admin.WithNSClient(nsClient),
+		admin.WithNSEClient(nseClient),
+	))
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	cc, err := grpc.DialContext(ctx, "bufconn",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer func() { _ = cc.Close() }()
+	client := admin.NewClient(cc)
+
+	// The existing NSE is restored on rollback
+	_, err = nseClient.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-1", NetworkServiceNames: []string{"ns-0"}})
+	require.NoError(t, err)
+
+	_, err = client.RegisterTransaction(ctx, &snapshot.Snapshot{
+		NetworkServices: []*registry.NetworkService{{Name: "ns-1"}},
+		NetworkServiceEndpoints: []*registry.NetworkServiceEndpoint{
+			{Name: "nse-1", NetworkServiceNames: []string{"ns-1"}},
+			{Name: "nse-2", NetworkServiceNames: []string{"ns-1"}},
+			{Name: "bad-nse", NetworkServiceNames: []string{"ns-1"}},
+		},
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "rolled back")
+
+	state, err := client.ExportState(ctx)
+	require.NoError(t, err)
+	require.Empty(t, state.NetworkServices)
+	require.Len(t, state.NetworkServiceEndpoints, 1)
+	require.Equal(t, []string{"ns-0"}, state.NetworkServiceEndpoints[0].GetNetworkServiceNames())
+
+	result, err := client.RegisterTransaction(ctx, &snapshot.Snapshot{
+		NetworkServices: []*registry.NetworkService{{Name: "ns-1"}},
+		NetworkServiceEndpoints: []*registry.NetworkServiceEndpoint{
+			{Name: "nse-1", NetworkServiceNames: []string{"ns-1"}},
+			{Name: "nse-2", NetworkServiceNames: []string{"ns-1"}},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1.0, result.GetFields()["network_services"].GetNumberValue())
+	require.Equal(t, 2.0, result.GetFields()["network_service_endpoints"].GetNumberValue())
+
+	state, err = client.ExportState(ctx)
+	require.NoError(t, err)
+	require.Len(t, state.NetworkServices, 1)
+	require.Len(t, state.NetworkServiceEndpoints, 2)
+}
+
+// cancelNSEServer fails the calls with a done context like the remote calls do, and cancels the call registering the
+// NSE with the name
+type cancelNSEServer struct {
+	name   string
+	cancel context.CancelFunc
+}
+
+func (s *cancelNSEServer) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*registry.NetworkServiceEndpoint, error) {
+	if nse.GetName() == s.name {
+		s.cancel()
+		<-ctx.Done()
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return next.NetworkServiceEndpointRegistryServer(ctx).Register(ctx, nse)
+}
+
+func (s *cancelNSEServer) Find(query *registry.NetworkServiceEndpointQuery, server registry.NetworkServiceEndpointRegistry_FindServer) error {
+	return next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, server)
+}
+
+func (s *cancelNSEServer) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*empty.Empty, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return next.NetworkServiceEndpointRegistryServer(ctx).Unregister(ctx, nse)
+}
+
+func TestAdmin_RegisterTransaction_Cancel(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	callCtx, cancelCall := context.WithCancel(ctx)
+	defer cancelCall()
+
+	nseClient := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		&cancelNSEServer{name: "cancel-nse", cancel: cancelCall},
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(grpc.StreamInterceptor(withAdminPeer))
+	admin.RegisterAdminServer(server, admin.NewServer(
+		admin.WithAdminIDs(adminID.String()),
+		admin.WithNSClient(adapters.NetworkServiceServerToClient(memory.NewNetworkServiceRegistryServer())),
+		admin.WithNSEClient(nseClient),
+	))
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	cc, err := grpc.DialContext(ctx, "bufconn",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer func() { _ = cc.Close() }()
+
+	// The client cancels the call in the middle of the transaction, the applied registrations are still rolled back
+	_, err = admin.NewClient(cc).RegisterTransaction(callCtx, &snapshot.Snapshot{
+		NetworkServiceEndpoints: []*registry.NetworkServiceEndpoint{
+			{Name: "nse-1", NetworkServiceNames: []string{"ns-1"}},
+			{Name: "cancel-nse", NetworkServiceNames: []string{"ns-1"}},
+		},
+	})
+	require.Equal(t, codes.Canceled, status.Code(err))
+
+	require.Eventually(t, func() bool {
+		stream, findErr := nseClient.Find(ctx, &registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: new(registry.NetworkServiceEndpoint)})
+		return findErr == nil && len(registry.ReadNetworkServiceEndpointList(stream)) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+// unavailableUnregisterNSEServer fails all the Unregister calls
+type unavailableUnregisterNSEServer struct{}
+
+func (s *unavailableUnregisterNSEServer) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*registry.NetworkServiceEndpoint, error) {
+	return next.NetworkServiceEndpointRegistryServer(ctx).Register(ctx, nse)
+}
+
+func (s *unavailableUnregisterNSEServer) Find(query *registry.NetworkServiceEndpointQuery, server registry.NetworkServiceEndpointRegistry_FindServer) error {
+	return next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, server)
+}
+
+func (s *unavailableUnregisterNSEServer) Unregister(context.Context, *registry.NetworkServiceEndpoint) (*empty.Empty, error) {
+	return nil, status.Error(codes.Unavailable, "store is unavailable")
+}
+
+func TestAdmin_RegisterTransaction_RollbackFailure(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(grpc.StreamInterceptor(withAdminPeer))
+	admin.RegisterAdminServer(server, admin.NewServer(
+		admin.WithAdminIDs(adminID.String()),
+		admin.WithNSClient(adapters.NetworkServiceServerToClient(memory.NewNetworkServiceRegistryServer())),
+		admin.WithNSEClient(adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+			&unavailableUnregisterNSEServer{},
+			&rejectNSEServer{name: "bad-nse"},
+			memory.NewNetworkServiceEndpointRegistryServer(),
+		))),
+	))
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	cc, err := grpc.DialContext(ctx, "bufconn",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer func() { _ = cc.Close() }()
+
+	_, err = admin.NewClient(cc).RegisterTransaction(ctx, &snapshot.Snapshot{
+		NetworkServiceEndpoints: []*registry.NetworkServiceEndpoint{
+			{Name: "nse-1", NetworkServiceNames: []string{"ns-1"}},
+			{Name: "bad-nse", NetworkServiceNames: []string{"ns-1"}},
+		},
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "partially rolled back")
+	require.Contains(t, err.Error(), "store is unavailable")
+}
@@ -0,0 +1,307 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bench provides a load generator registering, refreshing and finding synthetic NSEs with a number of
+// concurrent clients and reporting the throughput and the latency percentiles per operation
+package bench
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"sort"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+)
+
+// Operations
+const (
+	OpRegister   = "register"
+	OpRefresh    = "refresh"
+	OpFind       = "find"
+	OpUnregister = "unregister"
+)
+
+// NamePrefix is the prefix of all the NS and NSE names used by the load generator
+const NamePrefix = "bench-"
+
+var operations = []string{OpRegister, OpRefresh, OpFind, OpUnregister}
+
+type options struct {
+	clients            int
+	duration           time.Duration
+	endpointsPerClient int
+	services           int
+	findRatio          float64
+}
+
+// Option is an option for Run
+type Option func(o *options)
+
+// WithClients sets the number of the concurrent clients, 10 by default
+func WithClients(clients int) Option {
+	return func(o *options) {
+		o.clients = clients
+	}
+}
+
+// WithDuration sets how long the clients refresh and find after registering, 30s by default
+func WithDuration(duration time.Duration) Option {
+	return func(o *options) {
+		o.duration = duration
+	}
+}
+
+// WithEndpointsPerClient sets the number of the NSEs each client registers, 10 by default
+func WithEndpointsPerClient(endpoints int) Option {
+	return func(o *options) {
+		o.endpointsPerClient = endpoints
+	}
+}
+
+// WithServices sets the number of the network services the NSEs are spread over, 10 by default
+func WithServices(services int) Option {
+	return func(o *options) {
+		o.services = services
+	}
+}
+
+// WithFindRatio sets the share of the finds among the refreshes and the finds, 0.5 by default
+func WithFindRatio(ratio float64) Option {
+	return func(o *options) {
+		o.findRatio = ratio
+	}
+}
+
+// Result is the outcome of an operation
+type Result struct {
+	Operation string
+	Count     int
+	Errors    int
+	// Throughput is the number of the operations per second
+	Throughput float64
+	P50        time.Duration
+	P90        time.Duration
+	P99        time.Duration
+	Max        time.Duration
+}
+
+// Report is the outcome of a Run
+type Report struct {
+	// Duration is the time the whole run took, including the registration and the cleanup
+	Duration time.Duration
+	Results  []Result
+}
+
+// Result returns the result of the operation
+func (r *Report) Result(op string) Result {
+	for _, result := range r.Results {
+		if result.Operation == op {
+			return result
+		}
+	}
+	return Result{Operation: op}
+}
+
+// Write writes the report as a table
+func (r *Report) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	_, _ = fmt.Fprintln(tw, "operation\tcount\terrors\tops/s\tp50\tp90\tp99\tmax\t")
+	for _, result := range r.Results {
+		_, _ = fmt.Fprintf(tw, "%s\t%d\t%d\t%.1f\t%v\t%v\t%v\t%v\t\n", result.Operation, result.Count, result.Errors,
+			result.Throughput, result.P50, result.P90, result.P99, result.Max)
+	}
+	_, _ = fmt.Fprintf(tw, "total time %v\t\t\t\t\t\t\t\t\n", r.Duration)
+	return tw.Flush()
+}
+
+type samples struct {
+	latencies map[string][]time.Duration
+	errors    map[string]int
+	elapsed   map[string]time.Duration
+}
+
+// Run registers the NSEs of each client, refreshes and finds them for the duration and unregisters them with the
+// client. The errors are counted in the report rather than returned, so a run against a failing registry still
+// reports.
+func Run(ctx context.Context, client registry.NetworkServiceEndpointRegistryClient, opts ...Option) *Report {
+	o := &options{
+		clients:            10,
+		duration:           30 * time.Second,
+		endpointsPerClient: 10,
+		services:           10,
+		findRatio:          0.5,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+	if o.services <= 0 {
+		o.services = 1
+	}
+
+	clk := clock.FromContext(ctx)
+	start := clk.Now()
+
+	results := make([]*samples, o.clients)
+	var wg sync.WaitGroup
+	for i := 0; i < o.clients; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = runClient(ctx, client, o, i)
+		}(i)
+	}
+	wg.Wait()
+
+	report := &Report{Duration: clk.Since(start)}
+	for _, op := range operations {
+		var latencies []time.Duration
+		var errs int
+		var elapsed time.Duration
+		for _, s := range results {
+			latencies = append(latencies, s.latencies[op]...)
+			errs += s.errors[op]
+			if s.elapsed[op] > elapsed {
+				elapsed = s.elapsed[op]
+			}
+		}
+		report.Results = append(report.Results, newResult(op, latencies, errs, elapsed))
+	}
+	return report
+}
+
+func runClient(ctx context.Context, client registry.NetworkServiceEndpointRegistryClient, o *options, index int) *samples {
+	s := &samples{
+		latencies: make(map[string][]time.Duration),
+		errors:    make(map[string]int),
+		elapsed:   make(map[string]time.Duration),
+	}
+	clk := clock.FromContext(ctx)
+	measure := func(op string, f func() error) {
+		start := clk.Now()
+		err := f()
+		latency := clk.Since(start)
+		if err != nil {
+			s.errors[op]++
+			return
+		}
+		s.latencies[op] = append(s.latencies[op], latency)
+	}
+
+	phaseStart := clk.Now()
+	nses := make([]*registry.NetworkServiceEndpoint, 0, o.endpointsPerClient)
+	for i := 0; i < o.endpointsPerClient && ctx.Err() == nil; i++ {
+		nse := &registry.NetworkServiceEndpoint{
+			Name:                fmt.Sprintf("%snse-%d-%d", NamePrefix, index, i),
+			NetworkServiceNames: []string{serviceName((index*o.endpointsPerClient + i) % o.services)},
+			Url:                 fmt.Sprintf("tcp://127.0.0.1:%d", 10000+i),
+		}
+		measure(OpRegister, func() error {
+			resp, err := client.Register(ctx, nse)
+			if err == nil {
+				nses = append(nses, resp)
+			}
+			return err
+		})
+	}
+
+	s.elapsed[OpRegister] = clk.Since(phaseStart)
+
+	// #nosec G404 - the operations mix doesn't need a secure random
+	r := rand.New(rand.NewSource(int64(index)))
+	phaseStart = clk.Now()
+	deadline := phaseStart.Add(o.duration)
+	for next := 0; ctx.Err() == nil && clk.Now().Before(deadline); {
+		if len(nses) == 0 || r.Float64() < o.findRatio {
+			ns := serviceName(r.Intn(o.services))
+			measure(OpFind, func() error {
+				return find(ctx, client, ns)
+			})
+			continue
+		}
+		next = (next + 1) % len(nses)
+		measure(OpRefresh, func() error {
+			resp, err := client.Register(ctx, nses[next])
+			if err == nil {
+				nses[next] = resp
+			}
+			return err
+		})
+	}
+
+	s.elapsed[OpRefresh] = clk.Since(phaseStart)
+	s.elapsed[OpFind] = s.elapsed[OpRefresh]
+
+	phaseStart = clk.Now()
+	for _, nse := range nses {
+		if ctx.Err() != nil {
+			break
+		}
+		measure(OpUnregister, func() error {
+			_, err := client.Unregister(ctx, nse)
+			return err
+		})
+	}
+	s.elapsed[OpUnregister] = clk.Since(phaseStart)
+	return s
+}
+
+func find(ctx context.Context, client registry.NetworkServiceEndpointRegistryClient, ns string) error {
+	stream, err := client.Find(ctx, &registry.NetworkServiceEndpointQuery{
+		NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{NetworkServiceNames: []string{ns}},
+	})
+	if err != nil {
+		return err
+	}
+	for {
+		if _, err := stream.Recv(); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+func serviceName(i int) string {
+	return fmt.Sprintf("%sns-%d", NamePrefix, i)
+}
+
+// newResult computes the result of the operation. The throughput is the number of the successful operations per
+// second of the longest time a client spent in the phase of the operation: registering, refreshing and finding or
+// unregistering.
+func newResult(op string, latencies []time.Duration, errs int, elapsed time.Duration) Result {
+	result := Result{Operation: op, Count: len(latencies), Errors: errs}
+	if len(latencies) == 0 {
+		return result
+	}
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	percentile := func(p float64) time.Duration {
+		return latencies[int(p*float64(len(latencies)-1))]
+	}
+	result.P50, result.P90, result.P99 = percentile(0.5), percentile(0.9), percentile(0.99)
+	result.Max = latencies[len(latencies)-1]
+	if elapsed > 0 {
+		result.Throughput = float64(len(latencies)) / elapsed.Seconds()
+	}
+	return result
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bench_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/bench"
+)
+
+func TestRun(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := adapters.NetworkServiceEndpointServerToClient(memory.NewNetworkServiceEndpointRegistryServer())
+
+	report := bench.Run(ctx, client,
+		bench.WithClients(4),
+		bench.WithEndpointsPerClient(5),
+		bench.WithServices(3),
+		bench.WithDuration(100*time.Millisecond))
+
+	require.Equal(t, 20, report.Result(bench.OpRegister).Count)
+	require.Equal(t, 20, report.Result(bench.OpUnregister).Count)
+	for _, op := range []string{bench.OpRefresh, bench.OpFind} {
+		result := report.Result(op)
+		require.Positive(t, result.Count, op)
+		require.Positive(t, result.Throughput, op)
+		require.LessOrEqual(t, result.P50, result.P99, op)
+		require.LessOrEqual(t, result.P99, result.Max, op)
+	}
+	for _, result := range report.Results {
+		require.Zero(t, result.Errors, result.Operation)
+	}
+
+	// The NSEs are cleaned up
+	stream, err := client.Find(ctx, &registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: new(registry.NetworkServiceEndpoint)})
+	require.NoError(t, err)
+	require.Empty(t, registry.ReadNetworkServiceEndpointList(stream))
+
+	var out bytes.Buffer
+	require.NoError(t, report.Write(&out))
+	require.Contains(t, out.String(), bench.OpFind)
+}
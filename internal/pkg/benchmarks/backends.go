@@ -0,0 +1,75 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package benchmarks compares the NSE storage backends of the registry: Register, Find and Watch throughput and
+// latency at several numbers of the stored NSEs. Run the benchmarks with
+//
+//	make bench
+//
+// or export the results as JSON to BENCH_RESULTS (benchmark-results.json by default) for the comparison between the
+// releases with
+//
+//	make bench-results
+package benchmarks
+
+import (
+	"fmt"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/nsestore"
+)
+
+// Scales are the numbers of the NSEs stored before the measured operations
+var Scales = []int{100, 1000, 10000}
+
+// EndpointsPerService is the number of the NSEs of each network service, so a Find by a network service returns as
+// many NSEs at any scale
+const EndpointsPerService = 10
+
+// Backend is an NSE storage backend
+type Backend struct {
+	Name string
+	New  func() registry.NetworkServiceEndpointRegistryServer
+}
+
+// Backends returns the NSE storage backends of the registry: the store serving the registry chain and the sdk memory
+// store it replaced
+func Backends() []Backend {
+	return []Backend{
+		{Name: "nsestore", New: func() registry.NetworkServiceEndpointRegistryServer {
+			return nsestore.NewNetworkServiceEndpointRegistryServer()
+		}},
+		{Name: "sdk-memory", New: func() registry.NetworkServiceEndpointRegistryServer {
+			return memory.NewNetworkServiceEndpointRegistryServer()
+		}},
+	}
+}
+
+// Endpoint returns the i-th NSE of the benchmarks
+func Endpoint(i int) *registry.NetworkServiceEndpoint {
+	return &registry.NetworkServiceEndpoint{
+		Name:                fmt.Sprintf("nse-%d", i),
+		NetworkServiceNames: []string{Service(i / EndpointsPerService)},
+	}
+}
+
+// Service returns the name of the i-th network service of the benchmarks
+func Service(i int) string {
+	return fmt.Sprintf("ns-%d", i)
+}
@@ -0,0 +1,168 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package benchmarks_test
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/benchmarks"
+)
+
+var resultsPath = flag.String("results", "", "file to export the benchmark results to as JSON")
+
+var operations = []struct {
+	name string
+	run  func(b *testing.B, backend benchmarks.Backend, scale int)
+}{
+	{name: "register", run: benchmarkRegister},
+	{name: "find", run: benchmarkFind},
+	{name: "watch", run: benchmarkWatch},
+}
+
+// newClient returns the client of the backend storing scale NSEs
+func newClient(b *testing.B, backend benchmarks.Backend, scale int) registry.NetworkServiceEndpointRegistryClient {
+	client := adapters.NetworkServiceEndpointServerToClient(backend.New())
+	for i := 0; i < scale; i++ {
+		_, err := client.Register(context.Background(), benchmarks.Endpoint(i))
+		require.NoError(b, err)
+	}
+	return client
+}
+
+// benchmarkRegister measures the refreshes of the stored NSEs
+func benchmarkRegister(b *testing.B, backend benchmarks.Backend, scale int) {
+	ctx := context.Background()
+	client := newClient(b, backend, scale)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := client.Register(ctx, benchmarks.Endpoint(i%scale)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// benchmarkFind measures the finds of the NSEs of a network service
+func benchmarkFind(b *testing.B, backend benchmarks.Backend, scale int) {
+	ctx := context.Background()
+	client := newClient(b, backend, scale)
+	services := scale / benchmarks.EndpointsPerService
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		stream, err := client.Find(ctx, &registry.NetworkServiceEndpointQuery{
+			NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{NetworkServiceNames: []string{benchmarks.Service(i % services)}},
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+		if n := len(registry.ReadNetworkServiceEndpointList(stream)); n != benchmarks.EndpointsPerService {
+			b.Fatalf("expected %d NSEs, got %d", benchmarks.EndpointsPerService, n)
+		}
+	}
+}
+
+// benchmarkWatch measures the time from a refresh of a stored NSE till a watcher of all the NSEs receives it
+func benchmarkWatch(b *testing.B, backend benchmarks.Backend, scale int) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	client := newClient(b, backend, scale)
+
+	stream, err := client.Find(ctx, &registry.NetworkServiceEndpointQuery{
+		NetworkServiceEndpoint: new(registry.NetworkServiceEndpoint),
+		Watch:                  true,
+	})
+	require.NoError(b, err)
+	for i := 0; i < scale; i++ {
+		_, err = stream.Recv()
+		require.NoError(b, err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := client.Register(ctx, benchmarks.Endpoint(i%scale)); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := stream.Recv(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func runOperation(b *testing.B, op string) {
+	for _, o := range operations {
+		if o.name != op {
+			continue
+		}
+		for _, backend := range benchmarks.Backends() {
+			for _, scale := range benchmarks.Scales {
+				backend, scale := backend, scale
+				b.Run(fmt.Sprintf("%s/%d", backend.Name, scale), func(b *testing.B) {
+					o.run(b, backend, scale)
+				})
+			}
+		}
+	}
+}
+
+func BenchmarkRegister(b *testing.B) {
+	runOperation(b, "register")
+}
+
+func BenchmarkFind(b *testing.B) {
+	runOperation(b, "find")
+}
+
+func BenchmarkWatch(b *testing.B) {
+	runOperation(b, "watch")
+}
+
+// TestExportResults runs all the benchmarks and exports the results to the -results file. It is skipped without the
+// file.
+func TestExportResults(t *testing.T) {
+	if *resultsPath == "" {
+		t.Skip("no -results file to export the benchmark results to")
+	}
+
+	var results []benchmarks.Result
+	for _, o := range operations {
+		for _, backend := range benchmarks.Backends() {
+			for _, scale := range benchmarks.Scales {
+				o, backend, scale := o, backend, scale
+				result := testing.Benchmark(func(b *testing.B) {
+					o.run(b, backend, scale)
+				})
+				require.NotZero(t, result.N, "%s of %s at %d failed", o.name, backend.Name, scale)
+				results = append(results, benchmarks.NewResult(backend.Name, o.name, scale, result))
+				t.Logf("%s/%s/%d: %s", o.name, backend.Name, scale, result.String())
+			}
+		}
+	}
+	require.NoError(t, benchmarks.NewReport(results).WriteFile(*resultsPath))
+}
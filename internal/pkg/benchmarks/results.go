@@ -0,0 +1,92 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package benchmarks
+
+import (
+	"encoding/json"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/buildinfo"
+)
+
+// Result is the outcome of a benchmark of an operation on a backend at a scale
+type Result struct {
+	Backend     string  `json:"backend"`
+	Operation   string  `json:"operation"`
+	Scale       int     `json:"scale"`
+	Iterations  int     `json:"iterations"`
+	NsPerOp     int64   `json:"ns_per_op"`
+	OpsPerSec   float64 `json:"ops_per_sec"`
+	AllocsPerOp int64   `json:"allocs_per_op"`
+	BytesPerOp  int64   `json:"bytes_per_op"`
+}
+
+// NewResult returns the result of the benchmark
+func NewResult(backend, op string, scale int, r testing.BenchmarkResult) Result {
+	result := Result{
+		Backend:     backend,
+		Operation:   op,
+		Scale:       scale,
+		Iterations:  r.N,
+		NsPerOp:     r.NsPerOp(),
+		AllocsPerOp: r.AllocsPerOp(),
+		BytesPerOp:  r.AllocedBytesPerOp(),
+	}
+	if result.NsPerOp > 0 {
+		result.OpsPerSec = float64(time.Second) / float64(result.NsPerOp)
+	}
+	return result
+}
+
+// Report is the exported outcome of the benchmarks with the build and the machine they ran on
+type Report struct {
+	Build   buildinfo.Info `json:"build"`
+	GOOS    string         `json:"goos"`
+	GOARCH  string         `json:"goarch"`
+	CPUs    int            `json:"cpus"`
+	Time    time.Time      `json:"time"`
+	Results []Result       `json:"results"`
+}
+
+// NewReport returns the report of the results
+func NewReport(results []Result) *Report {
+	return &Report{
+		Build:   buildinfo.Get(),
+		GOOS:    runtime.GOOS,
+		GOARCH:  runtime.GOARCH,
+		CPUs:    runtime.NumCPU(),
+		Time:    time.Now().UTC(),
+		Results: results,
+	}
+}
+
+// WriteFile writes the report as JSON to the file
+func (r *Report) WriteFile(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal the benchmark report")
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0o600); err != nil {
+		return errors.Wrapf(err, "failed to write the benchmark report to %s", path)
+	}
+	return nil
+}
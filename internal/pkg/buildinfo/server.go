@@ -0,0 +1,112 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package buildinfo provides the build version info embedded at link time and a gRPC API to read it:
+//
+//	go build -ldflags "-X github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/buildinfo.Version=v1.0.0 \
+//	    -X github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/buildinfo.Commit=$(git rev-parse HEAD) \
+//	    -X github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/buildinfo.Date=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// The API is built from well-known protobuf types only:
+//
+//	service Info {
+//	    rpc Version (google.protobuf.Empty) returns (google.protobuf.Struct);
+//	}
+//
+// Version returns {"version", "commit", "date", "go_version"}.
+package buildinfo
+
+import (
+	"context"
+	"runtime"
+	"runtime/debug"
+
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Version, Commit and Date are set at link time with -X. Commit and Date default to the VCS info stamped by go build.
+var (
+	Version = "dev"
+	Commit  = ""
+	Date    = ""
+)
+
+// ServiceName is the full gRPC name of the info service
+const ServiceName = "registry.memory.info.Info"
+
+// Info is the build version info
+type Info struct {
+	Version   string
+	Commit    string
+	Date      string
+	GoVersion string
+}
+
+// Get returns the build version info
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		Date:      Date,
+		GoVersion: runtime.Version(),
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range bi.Settings {
+			switch {
+			case setting.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = setting.Value
+			case setting.Key == "vcs.time" && info.Date == "":
+				info.Date = setting.Value
+			}
+		}
+	}
+	if info.Commit == "" {
+		info.Commit = "unknown"
+	}
+	if info.Date == "" {
+		info.Date = "unknown"
+	}
+	return info
+}
+
+// Server is the server API of the info service
+type Server interface {
+	Version(ctx context.Context, in *emptypb.Empty) (*structpb.Struct, error)
+}
+
+type infoServer struct{}
+
+// NewServer creates a new info server
+func NewServer() Server {
+	return new(infoServer)
+}
+
+// Register registers the info server on the gRPC server
+func Register(s grpc.ServiceRegistrar, server Server) {
+	s.RegisterService(&serviceDesc, server)
+}
+
+func (s *infoServer) Version(_ context.Context, _ *emptypb.Empty) (*structpb.Struct, error) {
+	info := Get()
+	return structpb.NewStruct(map[string]interface{}{
+		"version":    info.Version,
+		"commit":     info.Commit,
+		"date":       info.Date,
+		"go_version": info.GoVersion,
+	})
+}
@@ -0,0 +1,58 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package buildinfo_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/buildinfo"
+)
+
+func TestInfo_Version(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	buildinfo.Version, buildinfo.Commit, buildinfo.Date = "v1.2.3", "abcdef", "2023-07-20T00:00:00Z"
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	buildinfo.Register(server, buildinfo.NewServer())
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	cc, err := grpc.DialContext(ctx, "bufconn",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer func() { _ = cc.Close() }()
+
+	resp, err := buildinfo.NewClient(cc).Version(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "v1.2.3", resp.GetFields()["version"].GetStringValue())
+	require.Equal(t, "abcdef", resp.GetFields()["commit"].GetStringValue())
+	require.Equal(t, "2023-07-20T00:00:00Z", resp.GetFields()["date"].GetStringValue())
+	require.NotEmpty(t, resp.GetFields()["go_version"].GetStringValue())
+}
@@ -0,0 +1,238 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package catalog provides the read-only registry servers of the public service catalog: the unauthenticated
+// listener serves Find of the configured network services and of their endpoints only, rate limited, while Register,
+// Unregister and Watch are refused. The calls are made to the registry chain in-process on behalf of the registry, so
+// the queries are rebuilt by the catalog: they are limited to the catalog network services and never go to the other
+// domains.
+package catalog
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/log"
+)
+
+// Results of the Find calls reported in the registry.catalog.finds metric
+const (
+	ResultServed  = "served"
+	ResultLimited = "limited"
+)
+
+type options struct {
+	networkServices []string
+	rate            float64
+	burst           int
+}
+
+// Option is an option for the Catalog
+type Option func(o *options)
+
+// WithNetworkServices sets the names of the network services in the catalog, the catalog is empty by default
+func WithNetworkServices(names ...string) Option {
+	return func(o *options) {
+		o.networkServices = names
+	}
+}
+
+// WithRateLimit limits the Find calls of all the clients to rate per second with bursts of up to burst calls, default
+// 10 per second with bursts of 20
+func WithRateLimit(rate float64, burst int) Option {
+	return func(o *options) {
+		o.rate = rate
+		o.burst = burst
+	}
+}
+
+// Catalog filters the registry state down to the catalog network services and limits the rate of the Find calls. It
+// is shared by the NS and NSE servers.
+type Catalog struct {
+	options
+	names map[string]struct{}
+	finds metric.Int64Counter
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// New creates a new Catalog
+func New(opts ...Option) *Catalog {
+	c := &Catalog{
+		options: options{
+			rate:  10,
+			burst: 20,
+		},
+		names: make(map[string]struct{}),
+	}
+	for _, opt := range opts {
+		opt(&c.options)
+	}
+	for _, name := range c.networkServices {
+		c.names[name] = struct{}{}
+	}
+	c.tokens = float64(c.burst)
+
+	finds, err := otel.Meter("registry-memory").Int64Counter("registry.catalog.finds",
+		metric.WithDescription("Number of the public catalog Find calls by result"))
+	if err != nil {
+		log.L().Errorf("failed to create catalog finds counter: %s", err.Error())
+	}
+	c.finds = finds
+	return c
+}
+
+// allow takes a token from the bucket or returns ResourceExhausted if there is none
+func (c *Catalog) allow(ctx context.Context) error {
+	now := clock.FromContext(ctx).Now()
+
+	c.mu.Lock()
+	if !c.last.IsZero() {
+		c.tokens += now.Sub(c.last).Seconds() * c.rate
+		if c.tokens > float64(c.burst) {
+			c.tokens = float64(c.burst)
+		}
+	}
+	c.last = now
+	allowed := c.tokens >= 1
+	if allowed {
+		c.tokens--
+	}
+	c.mu.Unlock()
+
+	if !allowed {
+		c.record(ctx, ResultLimited)
+		return status.Error(codes.ResourceExhausted, "catalog rate limit exceeded")
+	}
+	c.record(ctx, ResultServed)
+	return nil
+}
+
+// checkFind returns an error if the query cannot be served by the catalog
+func (c *Catalog) checkFind(ctx context.Context, watch bool) error {
+	if watch {
+		return status.Error(codes.PermissionDenied, "catalog does not serve Watch")
+	}
+	return c.allow(ctx)
+}
+
+// nsQueries returns the queries of the catalog network services matching the caller query, the caller query itself
+// is never passed to the registry
+func (c *Catalog) nsQueries(query *registry.NetworkServiceQuery) ([]*registry.NetworkServiceQuery, error) {
+	name := query.GetNetworkService().GetName()
+	if err := checkName(name); err != nil {
+		return nil, err
+	}
+	var queries []*registry.NetworkServiceQuery
+	for _, published := range c.networkServices {
+		if strings.Contains(published, name) {
+			queries = append(queries, &registry.NetworkServiceQuery{
+				NetworkService: &registry.NetworkService{Name: published},
+			})
+		}
+	}
+	return queries, nil
+}
+
+// nseQuery returns the query of the catalog network services endpoints matching the caller query, or nil if there are
+// none. It keeps the caller name, network service names and labels of the catalog network services only.
+func (c *Catalog) nseQuery(query *registry.NetworkServiceEndpointQuery) (*registry.NetworkServiceEndpointQuery, error) {
+	nse := query.GetNetworkServiceEndpoint()
+	if err := checkName(nse.GetName()); err != nil {
+		return nil, err
+	}
+	if len(c.names) == 0 {
+		return nil, nil
+	}
+	limited := &registry.NetworkServiceEndpoint{Name: nse.GetName()}
+	for _, name := range nse.GetNetworkServiceNames() {
+		if err := checkName(name); err != nil {
+			return nil, err
+		}
+		if !c.contains(name) {
+			return nil, nil
+		}
+		limited.NetworkServiceNames = append(limited.NetworkServiceNames, name)
+	}
+	for name, labels := range nse.GetNetworkServiceLabels() {
+		if !c.contains(name) {
+			return nil, nil
+		}
+		if limited.NetworkServiceLabels == nil {
+			limited.NetworkServiceLabels = make(map[string]*registry.NetworkServiceLabels)
+		}
+		limited.NetworkServiceLabels[name] = labels
+	}
+	return &registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: limited}, nil
+}
+
+// checkName returns InvalidArgument for the interdomain names: the registry would find them in the other domains with
+// its own credentials
+func checkName(name string) error {
+	if strings.Contains(name, "@") {
+		return status.Errorf(codes.InvalidArgument, "catalog does not serve interdomain name %s", name)
+	}
+	return nil
+}
+
+func (c *Catalog) contains(name string) bool {
+	_, ok := c.names[name]
+	return ok
+}
+
+// filterNSE returns a copy of nse with the catalog network services only, or nil if it provides none of them
+func (c *Catalog) filterNSE(nse *registry.NetworkServiceEndpoint) *registry.NetworkServiceEndpoint {
+	var names []string
+	for _, name := range nse.GetNetworkServiceNames() {
+		if c.contains(name) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	filtered := proto.Clone(nse).(*registry.NetworkServiceEndpoint)
+	filtered.NetworkServiceNames = names
+	for name := range filtered.GetNetworkServiceLabels() {
+		if !c.contains(name) {
+			delete(filtered.NetworkServiceLabels, name)
+		}
+	}
+	return filtered
+}
+
+func (c *Catalog) record(ctx context.Context, result string) {
+	if c.finds != nil {
+		c.finds.Add(ctx, 1, metric.WithAttributes(attribute.String("result", result)))
+	}
+}
+
+// errReadOnly is returned by the Register and Unregister calls
+var errReadOnly = status.Error(codes.PermissionDenied, "catalog is read-only")
@@ -0,0 +1,75 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package catalog
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/inprocess"
+)
+
+type catalogNSServer struct {
+	catalog *Catalog
+	client  registry.NetworkServiceRegistryClient
+}
+
+// NewNetworkServiceRegistryServer creates a new read-only NS server finding the catalog network services with client
+func NewNetworkServiceRegistryServer(catalog *Catalog, client registry.NetworkServiceRegistryClient) registry.NetworkServiceRegistryServer {
+	return &catalogNSServer{
+		catalog: catalog,
+		client:  client,
+	}
+}
+
+func (s *catalogNSServer) Register(context.Context, *registry.NetworkService) (*registry.NetworkService, error) {
+	return nil, errReadOnly
+}
+
+func (s *catalogNSServer) Find(query *registry.NetworkServiceQuery, server registry.NetworkServiceRegistry_FindServer) error {
+	if err := s.catalog.checkFind(server.Context(), query.GetWatch()); err != nil {
+		return err
+	}
+	queries, err := s.catalog.nsQueries(query)
+	if err != nil {
+		return err
+	}
+	ctx := inprocess.WithContext(server.Context())
+	for _, limited := range queries {
+		stream, findErr := s.client.Find(ctx, limited)
+		if findErr != nil {
+			return findErr
+		}
+		for _, ns := range registry.ReadNetworkServiceList(stream) {
+			// The registry matches the names by substring
+			if ns.GetName() != limited.GetNetworkService().GetName() {
+				continue
+			}
+			if err = server.Send(&registry.NetworkServiceResponse{NetworkService: ns}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *catalogNSServer) Unregister(context.Context, *registry.NetworkService) (*empty.Empty, error) {
+	return nil, errReadOnly
+}
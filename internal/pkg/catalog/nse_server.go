@@ -0,0 +1,76 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package catalog
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/inprocess"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/pagination"
+)
+
+type catalogNSEServer struct {
+	catalog *Catalog
+	client  registry.NetworkServiceEndpointRegistryClient
+}
+
+// NewNetworkServiceEndpointRegistryServer creates a new read-only NSE server finding the endpoints of the catalog
+// network services with client. The endpoints are returned with the catalog network services only.
+func NewNetworkServiceEndpointRegistryServer(catalog *Catalog, client registry.NetworkServiceEndpointRegistryClient) registry.NetworkServiceEndpointRegistryServer {
+	return &catalogNSEServer{
+		catalog: catalog,
+		client:  client,
+	}
+}
+
+func (s *catalogNSEServer) Register(context.Context, *registry.NetworkServiceEndpoint) (*registry.NetworkServiceEndpoint, error) {
+	return nil, errReadOnly
+}
+
+func (s *catalogNSEServer) Find(query *registry.NetworkServiceEndpointQuery, server registry.NetworkServiceEndpointRegistry_FindServer) error {
+	if err := s.catalog.checkFind(server.Context(), query.GetWatch()); err != nil {
+		return err
+	}
+	limited, err := s.catalog.nseQuery(query)
+	if err != nil || limited == nil {
+		return err
+	}
+	// The filter goes to the pagination as well, so the pages are cut after the other network services are dropped
+	ctx := pagination.WithFilter(inprocess.WithContext(server.Context()), s.catalog.filterNSE)
+	stream, err := s.client.Find(ctx, limited)
+	if err != nil {
+		return err
+	}
+	for _, nse := range registry.ReadNetworkServiceEndpointList(stream) {
+		filtered := s.catalog.filterNSE(nse)
+		if filtered == nil {
+			continue
+		}
+		if err = server.Send(&registry.NetworkServiceEndpointResponse{NetworkServiceEndpoint: filtered}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *catalogNSEServer) Unregister(context.Context, *registry.NetworkServiceEndpoint) (*empty.Empty, error) {
+	return nil, errReadOnly
+}
@@ -0,0 +1,202 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package catalog_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/chain"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clockmock"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/catalog"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/pagination"
+)
+
+func TestCatalog_Find(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	nsClient := adapters.NetworkServiceServerToClient(memory.NewNetworkServiceRegistryServer())
+	nseClient := adapters.NetworkServiceEndpointServerToClient(memory.NewNetworkServiceEndpointRegistryServer())
+	for _, name := range []string{"public", "private"} {
+		_, err := nsClient.Register(ctx, &registry.NetworkService{Name: name})
+		require.NoError(t, err)
+	}
+	_, err := nseClient.Register(ctx, &registry.NetworkServiceEndpoint{
+		Name:                "nse-1",
+		NetworkServiceNames: []string{"public", "private"},
+		NetworkServiceLabels: map[string]*registry.NetworkServiceLabels{
+			"public":  {Labels: map[string]string{"app": "web"}},
+			"private": {Labels: map[string]string{"app": "db"}},
+		},
+	})
+	require.NoError(t, err)
+	_, err = nseClient.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-2", NetworkServiceNames: []string{"private"}})
+	require.NoError(t, err)
+
+	c := catalog.New(catalog.WithNetworkServices("public"))
+	catalogNSClient := adapters.NetworkServiceServerToClient(catalog.NewNetworkServiceRegistryServer(c, nsClient))
+	catalogNSEClient := adapters.NetworkServiceEndpointServerToClient(catalog.NewNetworkServiceEndpointRegistryServer(c, nseClient))
+
+	nsStream, err := catalogNSClient.Find(ctx, &registry.NetworkServiceQuery{NetworkService: new(registry.NetworkService)})
+	require.NoError(t, err)
+	nses := registry.ReadNetworkServiceList(nsStream)
+	require.Len(t, nses, 1)
+	require.Equal(t, "public", nses[0].GetName())
+
+	nseStream, err := catalogNSEClient.Find(ctx, &registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: new(registry.NetworkServiceEndpoint)})
+	require.NoError(t, err)
+	endpoints := registry.ReadNetworkServiceEndpointList(nseStream)
+	require.Len(t, endpoints, 1)
+	require.Equal(t, "nse-1", endpoints[0].GetName())
+	require.Equal(t, []string{"public"}, endpoints[0].GetNetworkServiceNames())
+	require.NotContains(t, endpoints[0].GetNetworkServiceLabels(), "private")
+
+	// The registry state is not changed by the catalog
+	stream, err := nseClient.Find(ctx, &registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{Name: "nse-1"}})
+	require.NoError(t, err)
+	require.Len(t, registry.ReadNetworkServiceEndpointList(stream)[0].GetNetworkServiceNames(), 2)
+}
+
+func TestCatalog_FindPaged(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The registry cuts the Find results to a single NSE
+	nseClient := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		pagination.NewNetworkServiceEndpointRegistryServer(1),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+	for _, name := range []string{"nse-1", "nse-2", "nse-3"} {
+		_, err := nseClient.Register(ctx, &registry.NetworkServiceEndpoint{Name: name, NetworkServiceNames: []string{"private"}})
+		require.NoError(t, err)
+	}
+	_, err := nseClient.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-public", NetworkServiceNames: []string{"public"}})
+	require.NoError(t, err)
+
+	c := catalog.New(catalog.WithNetworkServices("public"))
+	catalogNSEClient := adapters.NetworkServiceEndpointServerToClient(catalog.NewNetworkServiceEndpointRegistryServer(c, nseClient))
+
+	stream, err := catalogNSEClient.Find(ctx, &registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: new(registry.NetworkServiceEndpoint)})
+	require.NoError(t, err)
+	endpoints := registry.ReadNetworkServiceEndpointList(stream)
+	require.Len(t, endpoints, 1)
+	require.Equal(t, "nse-public", endpoints[0].GetName())
+}
+
+func TestCatalog_ReadOnly(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	c := catalog.New(catalog.WithNetworkServices("public"))
+	nsClient := adapters.NetworkServiceServerToClient(catalog.NewNetworkServiceRegistryServer(c,
+		adapters.NetworkServiceServerToClient(memory.NewNetworkServiceRegistryServer())))
+	nseClient := adapters.NetworkServiceEndpointServerToClient(catalog.NewNetworkServiceEndpointRegistryServer(c,
+		adapters.NetworkServiceEndpointServerToClient(memory.NewNetworkServiceEndpointRegistryServer())))
+
+	_, err := nsClient.Register(ctx, &registry.NetworkService{Name: "public"})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = nsClient.Unregister(ctx, &registry.NetworkService{Name: "public"})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = nseClient.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse"})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = nseClient.Unregister(ctx, &registry.NetworkServiceEndpoint{Name: "nse"})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	stream, err := nseClient.Find(ctx, &registry.NetworkServiceEndpointQuery{
+		NetworkServiceEndpoint: new(registry.NetworkServiceEndpoint),
+		Watch:                  true,
+	})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.Error(t, err)
+}
+
+func TestCatalog_RateLimit(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	clockMock := clockmock.New(ctx)
+	ctx = clock.WithClock(ctx, clockMock)
+
+	c := catalog.New(catalog.WithNetworkServices("public"), catalog.WithRateLimit(1, 2))
+	client := adapters.NetworkServiceServerToClient(catalog.NewNetworkServiceRegistryServer(c,
+		adapters.NetworkServiceServerToClient(memory.NewNetworkServiceRegistryServer())))
+	find := func() error {
+		_, err := client.Find(ctx, &registry.NetworkServiceQuery{NetworkService: new(registry.NetworkService)})
+		return err
+	}
+
+	require.NoError(t, find())
+	require.NoError(t, find())
+	require.Equal(t, codes.ResourceExhausted, status.Code(find()))
+
+	clockMock.Add(time.Second)
+	require.NoError(t, find())
+	require.Equal(t, codes.ResourceExhausted, status.Code(find()))
+}
+
+func TestCatalog_LimitedQuery(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	nsClient := adapters.NetworkServiceServerToClient(memory.NewNetworkServiceRegistryServer())
+	nseClient := adapters.NetworkServiceEndpointServerToClient(memory.NewNetworkServiceEndpointRegistryServer())
+	for _, name := range []string{"public", "public-private"} {
+		_, err := nsClient.Register(ctx, &registry.NetworkService{Name: name})
+		require.NoError(t, err)
+	}
+	_, err := nseClient.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-1", NetworkServiceNames: []string{"public-private"}})
+	require.NoError(t, err)
+
+	c := catalog.New(catalog.WithNetworkServices("public"))
+	catalogNSClient := adapters.NetworkServiceServerToClient(catalog.NewNetworkServiceRegistryServer(c, nsClient))
+	catalogNSEClient := adapters.NetworkServiceEndpointServerToClient(catalog.NewNetworkServiceEndpointRegistryServer(c, nseClient))
+
+	// The catalog network service names are matched exactly
+	nsStream, err := catalogNSClient.Find(ctx, &registry.NetworkServiceQuery{NetworkService: &registry.NetworkService{Name: "pub"}})
+	require.NoError(t, err)
+	nses := registry.ReadNetworkServiceList(nsStream)
+	require.Len(t, nses, 1)
+	require.Equal(t, "public", nses[0].GetName())
+
+	nseStream, err := catalogNSEClient.Find(ctx, &registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{
+		NetworkServiceNames: []string{"public-private"},
+	}})
+	require.NoError(t, err)
+	require.Empty(t, registry.ReadNetworkServiceEndpointList(nseStream))
+
+	// The interdomain names are never looked up
+	_, err = catalogNSClient.Find(ctx, &registry.NetworkServiceQuery{NetworkService: &registry.NetworkService{Name: "public@other.com"}})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	nseStream, err = catalogNSEClient.Find(ctx, &registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{
+		Name: "nse-1@other.com",
+	}})
+	require.NoError(t, err)
+	_, err = nseStream.Recv()
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
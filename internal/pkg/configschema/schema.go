@@ -0,0 +1,207 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package configschema generates the JSON Schema of an envconfig configuration struct. The schema is an object with a
+// property per environment variable describing its type, default and constraints. Besides the envconfig tags, the
+// fields may have the constraint tags:
+//
+//	enum:"a,b,c"  allowed values
+//	min:"0"       minimum value of the numbers
+//	max:"100"     maximum value of the numbers
+package configschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+	"github.com/pkg/errors"
+)
+
+// Draft is the JSON Schema draft of the generated schemas
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// durationPattern matches the time.ParseDuration values
+const durationPattern = `^[-+]?(0|([0-9]*(\.[0-9]*)?(ns|us|µs|ms|s|m|h))+)$`
+
+var (
+	durationType = reflect.TypeOf(time.Duration(0))
+	urlType      = reflect.TypeOf(url.URL{})
+)
+
+// keysTemplate lists the environment variables with their field names in the field order
+var keysTemplate = template.Must(template.New("keys").Parse("{{range .}}{{.Key}}\t{{.Name}}\n{{end}}"))
+
+// Schema is a JSON Schema
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	// GoField is the name of the struct field
+	GoField string `json:"x-go-field,omitempty"`
+}
+
+// Generate returns the schema of the spec struct pointer loaded with envconfig.Process(prefix, spec). The property
+// values have the JSON types of the fields, the lists are arrays even though they are comma-separated in the
+// environment.
+func Generate(prefix string, spec interface{}) (*Schema, error) {
+	buf := new(bytes.Buffer)
+	if err := envconfig.Usaget(prefix, spec, buf, keysTemplate); err != nil {
+		return nil, errors.Wrap(err, "failed to gather the config fields")
+	}
+
+	specType := reflect.TypeOf(spec).Elem()
+	additional := false
+	schema := &Schema{
+		Schema:               Draft,
+		Type:                 "object",
+		Properties:           make(map[string]*Schema),
+		AdditionalProperties: &additional,
+	}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		key, name, ok := strings.Cut(line, "\t")
+		if !ok {
+			continue
+		}
+		field, ok := specType.FieldByName(name)
+		if !ok {
+			continue
+		}
+		property, err := fieldSchema(field)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid config field %s", name)
+		}
+		schema.Properties[key] = property
+		if ok, _ := strconv.ParseBool(field.Tag.Get("required")); ok {
+			schema.Required = append(schema.Required, key)
+		}
+	}
+	return schema, nil
+}
+
+// Marshal returns the indented JSON of the schema
+func (s *Schema) Marshal() ([]byte, error) {
+	return json.MarshalIndent(s, "", "  ")
+}
+
+func fieldSchema(field reflect.StructField) (*Schema, error) {
+	s := typeSchema(field.Type)
+	s.Description = field.Tag.Get("desc")
+	s.GoField = field.Name
+
+	target := s
+	if s.Items != nil {
+		target = s.Items
+	}
+	if enum, ok := field.Tag.Lookup("enum"); ok {
+		for _, value := range strings.Split(enum, ",") {
+			v, err := parseValue(target.Type, value)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid enum value %s", value)
+			}
+			target.Enum = append(target.Enum, v)
+		}
+	}
+	for tag, bound := range map[string]**float64{"min": &target.Minimum, "max": &target.Maximum} {
+		value, ok := field.Tag.Lookup(tag)
+		if !ok {
+			continue
+		}
+		v, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid %s value %s", tag, value)
+		}
+		*bound = &v
+	}
+
+	if value, ok := field.Tag.Lookup("default"); ok {
+		v, err := defaultValue(s, value)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid default value %s", value)
+		}
+		s.Default = v
+	}
+	return s, nil
+}
+
+func typeSchema(t reflect.Type) *Schema {
+	switch {
+	case t == durationType:
+		return &Schema{Type: "string", Pattern: durationPattern}
+	case t == urlType:
+		return &Schema{Type: "string", Format: "uri-reference"}
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: "integer"}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		zero := 0.0
+		return &Schema{Type: "integer", Minimum: &zero}
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}
+	case reflect.Slice:
+		return &Schema{Type: "array", Items: typeSchema(t.Elem())}
+	default:
+		return &Schema{Type: "string"}
+	}
+}
+
+func defaultValue(s *Schema, value string) (interface{}, error) {
+	if s.Items == nil {
+		return parseValue(s.Type, value)
+	}
+	values := make([]interface{}, 0)
+	for _, item := range strings.Split(value, ",") {
+		v, err := parseValue(s.Items.Type, item)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, nil
+}
+
+func parseValue(schemaType, value string) (interface{}, error) {
+	switch schemaType {
+	case "boolean":
+		return strconv.ParseBool(value)
+	case "integer":
+		return strconv.ParseInt(value, 0, 64)
+	case "number":
+		return strconv.ParseFloat(value, 64)
+	default:
+		return value, nil
+	}
+}
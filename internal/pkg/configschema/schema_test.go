@@ -0,0 +1,87 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configschema_test
+
+import (
+	"encoding/json"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/configschema"
+)
+
+type testConfig struct {
+	ListenOn   []url.URL     `default:"unix:///listen.on.socket" desc:"urls to listen on" split_words:"true"`
+	Mode       string        `default:"a" desc:"mode" enum:"a,b"`
+	Jitter     int           `desc:"jitter" min:"0" max:"100"`
+	Period     time.Duration `default:"1s" desc:"period" split_words:"true"`
+	Enabled    bool          `default:"true" desc:"enabled"`
+	Streams    uint32        `desc:"streams"`
+	Token      string        `required:"true" envconfig:"SECRET_TOKEN"`
+	IgnoredOne string        `ignored:"true"`
+}
+
+func TestGenerate(t *testing.T) {
+	schema, err := configschema.Generate("test", &testConfig{})
+	require.NoError(t, err)
+
+	data, err := schema.Marshal()
+	require.NoError(t, err)
+
+	var actual map[string]interface{}
+	require.NoError(t, json.Unmarshal(data, &actual))
+
+	var expected map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(`{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"additionalProperties": false,
+		"required": ["TEST_SECRET_TOKEN"],
+		"properties": {
+			"TEST_LISTEN_ON": {
+				"description": "urls to listen on",
+				"type": "array",
+				"items": {"type": "string", "format": "uri-reference"},
+				"default": ["unix:///listen.on.socket"],
+				"x-go-field": "ListenOn"
+			},
+			"TEST_MODE": {"description": "mode", "type": "string", "enum": ["a", "b"], "default": "a", "x-go-field": "Mode"},
+			"TEST_JITTER": {"description": "jitter", "type": "integer", "minimum": 0, "maximum": 100, "x-go-field": "Jitter"},
+			"TEST_PERIOD": {
+				"description": "period",
+				"type": "string",
+				"pattern": "^[-+]?(0|([0-9]*(\\.[0-9]*)?(ns|us|µs|ms|s|m|h))+)$",
+				"default": "1s",
+				"x-go-field": "Period"
+			},
+			"TEST_ENABLED": {"description": "enabled", "type": "boolean", "default": true, "x-go-field": "Enabled"},
+			"TEST_STREAMS": {"description": "streams", "type": "integer", "minimum": 0, "x-go-field": "Streams"},
+			"TEST_SECRET_TOKEN": {"type": "string", "x-go-field": "Token"}
+		}
+	}`), &expected))
+	require.Equal(t, expected, actual)
+}
+
+func TestGenerate_InvalidDefault(t *testing.T) {
+	_, err := configschema.Generate("test", &struct {
+		Count int `default:"many"`
+	}{})
+	require.Error(t, err)
+}
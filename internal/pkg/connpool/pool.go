@@ -0,0 +1,294 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package connpool provides a pool of the outbound gRPC connections shared by target. The connections are dialed once
+// and reused by the concurrent and the following calls, the unused ones are closed after the idle timeout and the ones
+// failing the health check are closed to be dialed again on the next use.
+package connpool
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/log"
+)
+
+const targetAttribute = "target"
+
+type options struct {
+	dialOptions         []grpc.DialOption
+	targetDialOptions   func(target string) []grpc.DialOption
+	dialTimeout         time.Duration
+	idleTimeout         time.Duration
+	healthCheckInterval time.Duration
+}
+
+// Option is an option for the Pool
+type Option func(o *options)
+
+// WithDialOptions sets the grpc.DialOptions for the pooled connections
+func WithDialOptions(dialOptions ...grpc.DialOption) Option {
+	return func(o *options) {
+		o.dialOptions = dialOptions
+	}
+}
+
+// WithTargetDialOptions sets the function returning the additional grpc.DialOptions of a target, e.g. its dedicated
+// credentials. They follow the common ones.
+func WithTargetDialOptions(targetDialOptions func(target string) []grpc.DialOption) Option {
+	return func(o *options) {
+		o.targetDialOptions = targetDialOptions
+	}
+}
+
+// WithDialTimeout sets the timeout of a dial, default 5s
+func WithDialTimeout(dialTimeout time.Duration) Option {
+	return func(o *options) {
+		o.dialTimeout = dialTimeout
+	}
+}
+
+// WithIdleTimeout sets how long an unused connection is kept open, default 5m
+func WithIdleTimeout(idleTimeout time.Duration) Option {
+	return func(o *options) {
+		o.idleTimeout = idleTimeout
+	}
+}
+
+// WithHealthCheckInterval sets the interval of the idle and the health checks, default 30s
+func WithHealthCheckInterval(healthCheckInterval time.Duration) Option {
+	return func(o *options) {
+		o.healthCheckInterval = healthCheckInterval
+	}
+}
+
+type entry struct {
+	target string
+	ready  chan struct{}
+	cc     *grpc.ClientConn
+	err    error
+	refs   int
+	// lastUsed is the last time the entry was released by its last user
+	lastUsed time.Time
+}
+
+// Pool is a pool of the outbound gRPC connections
+type Pool struct {
+	options
+	ctx     context.Context
+	mu      sync.Mutex
+	entries map[string]*entry
+
+	connections metric.Int64UpDownCounter
+	dials       metric.Int64Counter
+	reuses      metric.Int64Counter
+}
+
+// NewPool creates a new Pool dialing the connections with ctx. The connections are exposed as the
+// registry.outbound.connections, registry.outbound.dials and registry.outbound.reuses metrics by target.
+func NewPool(ctx context.Context, opts ...Option) *Pool {
+	p := &Pool{
+		options: options{
+			dialTimeout:         5 * time.Second,
+			idleTimeout:         5 * time.Minute,
+			healthCheckInterval: 30 * time.Second,
+		},
+		ctx:     ctx,
+		entries: make(map[string]*entry),
+	}
+	for _, opt := range opts {
+		opt(&p.options)
+	}
+
+	meter := otel.Meter("registry-memory")
+	var err error
+	if p.connections, err = meter.Int64UpDownCounter("registry.outbound.connections",
+		metric.WithDescription("Number of the open outbound gRPC connections by target")); err != nil {
+		log.L().Errorf("failed to create outbound connections counter: %s", err.Error())
+	}
+	if p.dials, err = meter.Int64Counter("registry.outbound.dials",
+		metric.WithDescription("Number of the outbound gRPC dials by target")); err != nil {
+		log.L().Errorf("failed to create outbound dials counter: %s", err.Error())
+	}
+	if p.reuses, err = meter.Int64Counter("registry.outbound.reuses",
+		metric.WithDescription("Number of the outbound gRPC connection uses served by an already open connection by target")); err != nil {
+		log.L().Errorf("failed to create outbound reuses counter: %s", err.Error())
+	}
+	return p
+}
+
+// Get returns the connection to the target, dialing it if there is none. The concurrent calls for the same target wait
+// for the single dial. The returned release function must be called once the connection is no longer used.
+func (p *Pool) Get(ctx context.Context, target string) (*grpc.ClientConn, func(), error) {
+	p.mu.Lock()
+	e, ok := p.entries[target]
+	if ok && e.cc != nil && e.cc.GetState() == connectivity.Shutdown {
+		p.removeLocked(e)
+		ok = false
+	}
+	if !ok {
+		e = &entry{target: target, ready: make(chan struct{})}
+		p.entries[target] = e
+		go p.dial(e)
+	} else {
+		add(ctx, p.reuses, target, 1)
+	}
+	e.refs++
+	p.mu.Unlock()
+
+	release := p.releaseFunc(e)
+	select {
+	case <-ctx.Done():
+		release()
+		return nil, nil, ctx.Err()
+	case <-e.ready:
+	}
+	if e.err != nil {
+		release()
+		return nil, nil, e.err
+	}
+	return e.cc, release, nil
+}
+
+func (p *Pool) dial(e *entry) {
+	add(p.ctx, p.dials, e.target, 1)
+
+	dialCtx, cancel := context.WithTimeout(p.ctx, p.dialTimeout)
+	defer cancel()
+	dialOptions := p.dialOptions
+	if p.targetDialOptions != nil {
+		dialOptions = append(append([]grpc.DialOption(nil), dialOptions...), p.targetDialOptions(e.target)...)
+	}
+	cc, err := grpc.DialContext(dialCtx, e.target, dialOptions...)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e.cc, e.err = cc, err
+	close(e.ready)
+	if err != nil {
+		log.FromContext(p.ctx).Warnf("failed to dial %s: %s", e.target, err.Error())
+		// The failed entry is not reused, the next Get dials again
+		if p.entries[e.target] == e {
+			delete(p.entries, e.target)
+		}
+		return
+	}
+	add(p.ctx, p.connections, e.target, 1)
+}
+
+func (p *Pool) releaseFunc(e *entry) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			e.refs--
+			if e.refs == 0 {
+				e.lastUsed = clock.FromContext(p.ctx).Now()
+				if p.entries[e.target] != e && e.cc != nil {
+					// The entry has been replaced while in use
+					p.closeLocked(e)
+				}
+			}
+		})
+	}
+}
+
+// Run checks the connections every health check interval and closes all of them once ctx is done
+func (p *Pool) Run(ctx context.Context) {
+	ticker := clock.FromContext(ctx).Ticker(p.healthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			p.closeAll()
+			return
+		case <-ticker.C():
+			p.Check()
+		}
+	}
+}
+
+// Check closes the unused connections idle for the idle timeout or failing the health check: in TransientFailure or
+// Shutdown state. The connections in use are replaced on the next Get once they are shut down.
+func (p *Pool) Check() {
+	now := clock.FromContext(p.ctx).Now()
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, e := range p.entries {
+		if e.cc == nil || e.refs > 0 {
+			continue
+		}
+		switch state := e.cc.GetState(); {
+		case state == connectivity.TransientFailure || state == connectivity.Shutdown:
+			log.FromContext(p.ctx).Warnf("closing unhealthy connection to %s: %s", e.target, state)
+		case now.Sub(e.lastUsed) < p.idleTimeout:
+			continue
+		}
+		p.removeLocked(e)
+	}
+}
+
+// Len returns the number of the pooled connections, including the ones being dialed
+func (p *Pool) Len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.entries)
+}
+
+func (p *Pool) closeAll() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, e := range p.entries {
+		if e.cc != nil {
+			p.removeLocked(e)
+		}
+	}
+}
+
+// removeLocked removes the entry from the pool, closing its connection unless in use
+func (p *Pool) removeLocked(e *entry) {
+	delete(p.entries, e.target)
+	if e.refs == 0 {
+		p.closeLocked(e)
+	}
+}
+
+func (p *Pool) closeLocked(e *entry) {
+	_ = e.cc.Close()
+	add(p.ctx, p.connections, e.target, -1)
+}
+
+type adder interface {
+	Add(ctx context.Context, incr int64, options ...metric.AddOption)
+}
+
+func add(ctx context.Context, counter adder, target string, incr int64) {
+	if counter == nil {
+		return
+	}
+	counter.Add(ctx, incr, metric.WithAttributes(attribute.String(targetAttribute, target)))
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package connpool_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clockmock"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/connpool"
+)
+
+func newPool(ctx context.Context, t *testing.T, opts ...connpool.Option) *connpool.Pool {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	return connpool.NewPool(ctx, append([]connpool.Option{
+		connpool.WithDialOptions(
+			grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+			grpc.WithTransportCredentials(insecure.NewCredentials()),
+		),
+	}, opts...)...)
+}
+
+func TestPool_Reuse(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pool := newPool(ctx, t)
+
+	cc1, release1, err := pool.Get(ctx, "target-1")
+	require.NoError(t, err)
+	defer release1()
+	cc2, release2, err := pool.Get(ctx, "target-1")
+	require.NoError(t, err)
+	defer release2()
+	require.Same(t, cc1, cc2)
+
+	cc3, release3, err := pool.Get(ctx, "target-2")
+	require.NoError(t, err)
+	defer release3()
+	require.NotSame(t, cc1, cc3)
+	require.Equal(t, 2, pool.Len())
+}
+
+func TestPool_IdleTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	clockMock := clockmock.New(ctx)
+	ctx = clock.WithClock(ctx, clockMock)
+
+	pool := newPool(ctx, t, connpool.WithIdleTimeout(time.Minute))
+
+	cc, release, err := pool.Get(ctx, "target")
+	require.NoError(t, err)
+
+	// The connection in use is kept
+	clockMock.Add(2 * time.Minute)
+	pool.Check()
+	require.Equal(t, 1, pool.Len())
+
+	release()
+	clockMock.Add(time.Minute / 2)
+	pool.Check()
+	require.Equal(t, 1, pool.Len())
+
+	clockMock.Add(time.Minute / 2)
+	pool.Check()
+	require.Equal(t, 0, pool.Len())
+	require.Equal(t, connectivity.Shutdown, cc.GetState())
+}
+
+func TestPool_ReplacesShutdown(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pool := newPool(ctx, t)
+
+	cc1, release1, err := pool.Get(ctx, "target")
+	require.NoError(t, err)
+	release1()
+	_ = cc1.Close()
+
+	cc2, release2, err := pool.Get(ctx, "target")
+	require.NoError(t, err)
+	defer release2()
+	require.NotSame(t, cc1, cc2)
+	require.NotEqual(t, connectivity.Shutdown, cc2.GetState())
+}
+
+func TestPool_RunClosesOnDone(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pool := newPool(ctx, t)
+
+	cc, release, err := pool.Get(ctx, "target")
+	require.NoError(t, err)
+	release()
+
+	runCtx, runCancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		pool.Run(runCtx)
+		close(done)
+	}()
+	runCancel()
+	<-done
+
+	require.Equal(t, 0, pool.Len())
+	require.Equal(t, connectivity.Shutdown, cc.GetState())
+}
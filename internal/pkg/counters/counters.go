@@ -0,0 +1,177 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package counters provides the long-horizon registry counters persisted to a file, so restarts don't reset them
+package counters
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/log"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/events"
+)
+
+// State is the persisted counters state
+type State struct {
+	// Registrations is the number of the new NSE registrations since install, refreshes are not counted
+	Registrations int64 `json:"registrations"`
+	// Expirations is the number of the NSEs removed by expiration since install
+	Expirations int64 `json:"expirations"`
+	// Restarts is the number of the registry starts with the existing counters file
+	Restarts int64 `json:"restarts"`
+}
+
+// Counters are the persisted counters
+type Counters struct {
+	filePath string
+
+	mu    sync.Mutex
+	state State
+	dirty bool
+}
+
+// Load loads the counters from the file and counts the restart. A missing file starts the counters from zero.
+func Load(filePath string) (*Counters, error) {
+	c := &Counters{filePath: filePath}
+	// #nosec G304 - the file path comes from the configuration
+	data, err := os.ReadFile(filePath)
+	switch {
+	case os.IsNotExist(err):
+		c.dirty = true
+	case err != nil:
+		return nil, errors.Wrapf(err, "failed to read counters %s", filePath)
+	default:
+		if err = json.Unmarshal(data, &c.state); err != nil {
+			return nil, errors.Wrapf(err, "failed to parse counters %s", filePath)
+		}
+		c.state.Restarts++
+		c.dirty = true
+	}
+	return c, nil
+}
+
+// State returns the current counters state
+func (c *Counters) State() State {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.state
+}
+
+// RegisterMetrics exposes the counters as the OpenTelemetry counters registry.registrations.total,
+// registry.expirations.total and registry.restarts.total
+func (c *Counters) RegisterMetrics() error {
+	meter := otel.Meter("registry-memory")
+	for name, value := range map[string]func(s State) int64{
+		"registry.registrations.total": func(s State) int64 { return s.Registrations },
+		"registry.expirations.total":   func(s State) int64 { return s.Expirations },
+		"registry.restarts.total":      func(s State) int64 { return s.Restarts },
+	} {
+		value := value
+		if _, err := meter.Int64ObservableCounter(name,
+			metric.WithDescription("Persisted counter, not reset on restart"),
+			metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+				o.Observe(value(c.State()))
+				return nil
+			})); err != nil {
+			return errors.Wrapf(err, "failed to create %s counter", name)
+		}
+	}
+	return nil
+}
+
+// Run counts the NSE events and saves the counters every savePeriod until the channel is closed, then saves them once
+// more
+func (c *Counters) Run(ctx context.Context, ch <-chan events.Event, savePeriod time.Duration) {
+	ticker := clock.FromContext(ctx).Ticker(savePeriod)
+	defer ticker.Stop()
+	defer c.saveLogged(ctx)
+
+	for {
+		select {
+		case <-ticker.C():
+			c.saveLogged(ctx)
+		case event, ok := <-ch:
+			if !ok {
+				return
+			}
+			c.count(event)
+		}
+	}
+}
+
+func (c *Counters) count(event events.Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch event.Type {
+	case events.TypeNSERegistered:
+		c.state.Registrations++
+	case events.TypeNSEExpired:
+		c.state.Expirations++
+	default:
+		return
+	}
+	c.dirty = true
+}
+
+func (c *Counters) saveLogged(ctx context.Context) {
+	if err := c.Save(); err != nil {
+		log.FromContext(ctx).Errorf("failed to save counters: %s", err.Error())
+	}
+}
+
+// Save writes the counters to the file if they have changed. The file is replaced atomically.
+func (c *Counters) Save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.dirty {
+		return nil
+	}
+	data, err := json.Marshal(&c.state)
+	if err != nil {
+		return errors.Wrap(err, "failed to marshal counters")
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(c.filePath), filepath.Base(c.filePath)+".*")
+	if err != nil {
+		return errors.Wrapf(err, "failed to save counters %s", c.filePath)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err = tmp.Write(data); err != nil {
+		_ = tmp.Close()
+		return errors.Wrapf(err, "failed to save counters %s", c.filePath)
+	}
+	if err = tmp.Close(); err != nil {
+		return errors.Wrapf(err, "failed to save counters %s", c.filePath)
+	}
+	if err = os.Rename(tmp.Name(), c.filePath); err != nil {
+		return errors.Wrapf(err, "failed to save counters %s", c.filePath)
+	}
+	c.dirty = false
+	return nil
+}
@@ -0,0 +1,52 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package counters_test
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/counters"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/events"
+)
+
+func TestCounters_Persisted(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "counters.json")
+
+	run := func(eventTypes ...string) counters.State {
+		c, err := counters.Load(filePath)
+		require.NoError(t, err)
+
+		ch := make(chan events.Event, len(eventTypes))
+		for _, eventType := range eventTypes {
+			ch <- events.New(eventType, nil)
+		}
+		close(ch)
+		c.Run(context.Background(), ch, time.Hour)
+		return c.State()
+	}
+
+	require.Equal(t, counters.State{Registrations: 2, Expirations: 1},
+		run(events.TypeNSERegistered, events.TypeNSERefreshed, events.TypeNSERegistered, events.TypeNSEExpired))
+	require.Equal(t, counters.State{Registrations: 3, Expirations: 1, Restarts: 1},
+		run(events.TypeNSERegistered, events.TypeNSEUnregistered))
+	require.Equal(t, counters.State{Registrations: 3, Expirations: 1, Restarts: 2}, run())
+}
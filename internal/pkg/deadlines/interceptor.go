@@ -0,0 +1,160 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package deadlines provides gRPC server interceptors bounding the resources a call can keep alive: the unary calls
+// get a server deadline and the streams blocked on sending to a peer not reading them are reaped.
+//
+// A peer disappearing uncleanly is detected by the server keepalive pings, see the keepalive config: the transport is
+// closed once a ping isn't acknowledged within the keepalive timeout, which cancels the contexts of all its streams.
+package deadlines
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/log"
+)
+
+// Reap reasons
+const (
+	reasonDeadline = "deadline"
+	reasonIdle     = "idle"
+)
+
+type options struct {
+	unaryTimeout time.Duration
+	sendTimeout  time.Duration
+}
+
+// Option is an option for the Reaper
+type Option func(o *options)
+
+// WithUnaryTimeout sets the server deadline of the unary calls, the earlier client deadline is kept. 0 means none.
+func WithUnaryTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.unaryTimeout = timeout
+	}
+}
+
+// WithSendTimeout sets how long a stream send may block on the peer not reading before the stream is closed with
+// DeadlineExceeded. 0 means forever.
+func WithSendTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.sendTimeout = timeout
+	}
+}
+
+// Reaper ends the calls exceeding the deadlines. The reaped calls are counted by the rpc.server.reaped metric.
+type Reaper struct {
+	options
+	reaped metric.Int64Counter
+}
+
+// NewReaper creates a new Reaper
+func NewReaper(opts ...Option) *Reaper {
+	r := new(Reaper)
+	for _, opt := range opts {
+		opt(&r.options)
+	}
+	var err error
+	if r.reaped, err = otel.Meter("registry-memory").Int64Counter("rpc.server.reaped",
+		metric.WithDescription("Number of the calls ended by the server deadlines by method and reason")); err != nil {
+		log.L().Errorf("failed to create reaped calls counter: %s", err.Error())
+	}
+	return r
+}
+
+// UnaryServerInterceptor returns a server interceptor setting the unary timeout deadline
+func (r *Reaper) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if r.unaryTimeout <= 0 {
+			return handler(ctx, req)
+		}
+		callCtx, cancel := context.WithTimeout(ctx, r.unaryTimeout)
+		defer cancel()
+		resp, err := handler(callCtx, req)
+		if callCtx.Err() == context.DeadlineExceeded && ctx.Err() == nil {
+			r.count(ctx, info.FullMethod, reasonDeadline)
+		}
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor returns a server interceptor closing the streams with a send blocked for the send timeout
+func (r *Reaper) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if r.sendTimeout <= 0 {
+			return handler(srv, ss)
+		}
+		s := &serverStream{ServerStream: ss, timeout: r.sendTimeout}
+		err := handler(srv, s)
+		if s.isReaped() {
+			r.count(ss.Context(), info.FullMethod, reasonIdle)
+			log.FromContext(ss.Context()).Warnf("%s stream is reaped: the peer hasn't read it for %s", info.FullMethod, r.sendTimeout)
+			return status.Errorf(codes.DeadlineExceeded, "stream is reaped: the peer hasn't read it for %s", r.sendTimeout)
+		}
+		return err
+	}
+}
+
+func (r *Reaper) count(ctx context.Context, method, reason string) {
+	if r.reaped != nil {
+		r.reaped.Add(ctx, 1, metric.WithAttributes(attribute.String("method", method), attribute.String("reason", reason)))
+	}
+}
+
+// serverStream fails the sends once a send has been blocked for the timeout. The blocked send returns once the
+// handler returns and the stream is closed.
+type serverStream struct {
+	grpc.ServerStream
+	timeout time.Duration
+	mu      sync.Mutex
+	reaped  bool
+}
+
+func (s *serverStream) SendMsg(m interface{}) error {
+	if s.isReaped() {
+		return status.Error(codes.DeadlineExceeded, "stream is reaped")
+	}
+	done := make(chan error, 1)
+	go func() { done <- s.ServerStream.SendMsg(m) }()
+
+	timer := time.NewTimer(s.timeout)
+	defer timer.Stop()
+	select {
+	case err := <-done:
+		return err
+	case <-timer.C:
+		s.mu.Lock()
+		s.reaped = true
+		s.mu.Unlock()
+		return status.Errorf(codes.DeadlineExceeded, "send is blocked for %s", s.timeout)
+	}
+}
+
+func (s *serverStream) isReaped() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.reaped
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deadlines_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/deadlines"
+)
+
+func TestReaper_UnaryTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	interceptor := deadlines.NewReaper(deadlines.WithUnaryTimeout(50 * time.Millisecond)).UnaryServerInterceptor()
+	_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/test/Unary"},
+		func(ctx context.Context, _ interface{}) (interface{}, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.NoError(t, ctx.Err())
+}
+
+func TestReaper_SendTimeout(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	reaper := deadlines.NewReaper(deadlines.WithSendTimeout(100 * time.Millisecond))
+	handlerErr := make(chan error, 1)
+
+	listener := bufconn.Listen(1024)
+	server := grpc.NewServer(
+		grpc.StreamInterceptor(reaper.StreamServerInterceptor()),
+		grpc.UnknownServiceHandler(func(_ interface{}, stream grpc.ServerStream) error {
+			payload := wrapperspb.Bytes(make([]byte, 16*1024))
+			for {
+				if err := stream.SendMsg(payload); err != nil {
+					handlerErr <- err
+					return err
+				}
+			}
+		}))
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	cc, err := grpc.DialContext(ctx, "bufconn",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer func() { _ = cc.Close() }()
+
+	stream, err := cc.NewStream(ctx, &grpc.StreamDesc{ServerStreams: true}, "/test.Test/Watch")
+	require.NoError(t, err)
+	require.NoError(t, stream.SendMsg(new(emptypb.Empty)))
+	require.NoError(t, stream.CloseSend())
+
+	// The client doesn't read, so the handler send blocks
+	select {
+	case err = <-handlerErr:
+		require.Equal(t, codes.DeadlineExceeded, status.Code(err))
+	case <-ctx.Done():
+		t.Fatal("the stream is not reaped")
+	}
+
+	// The client gets the sent messages and then the stream status
+	for err = nil; err == nil; err = stream.RecvMsg(new(wrapperspb.BytesValue)) {
+	}
+	require.Equal(t, codes.DeadlineExceeded, status.Code(err))
+}
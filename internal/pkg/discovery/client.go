@@ -0,0 +1,68 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// Client is the client API of the discovery service
+type Client struct {
+	client DiscoveryClient
+}
+
+// NewClient creates a new discovery client
+func NewClient(cc grpc.ClientConnInterface) *Client {
+	return &Client{
+		client: NewDiscoveryClient(cc),
+	}
+}
+
+// Resolve returns endpoints of the service
+func (c *Client) Resolve(ctx context.Context, service string, opts ...grpc.CallOption) (*structpb.Struct, error) {
+	return c.client.Resolve(ctx, wrapperspb.String(service), opts...)
+}
+
+// Watch returns a channel of the service endpoint events. The channel is closed when the stream ends.
+func (c *Client) Watch(ctx context.Context, service string, opts ...grpc.CallOption) (<-chan *structpb.Struct, error) {
+	stream, err := c.client.Watch(ctx, wrapperspb.String(service), opts...)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to watch %s", service)
+	}
+
+	ch := make(chan *structpb.Struct)
+	go func() {
+		defer close(ch)
+		for {
+			event, recvErr := stream.Recv()
+			if recvErr != nil {
+				return
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case ch <- event:
+			}
+		}
+	}()
+	return ch, nil
+}
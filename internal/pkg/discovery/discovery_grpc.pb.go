@@ -0,0 +1,180 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             (unknown)
+// source: discovery.proto
+
+package discovery
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	structpb "google.golang.org/protobuf/types/known/structpb"
+	wrapperspb "google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	Discovery_Resolve_FullMethodName = "/registry.memory.discovery.Discovery/Resolve"
+	Discovery_Watch_FullMethodName   = "/registry.memory.discovery.Discovery/Watch"
+)
+
+// DiscoveryClient is the client API for Discovery service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type DiscoveryClient interface {
+	// Resolve returns the endpoints of the service
+	Resolve(ctx context.Context, in *wrapperspb.StringValue, opts ...grpc.CallOption) (*structpb.Struct, error)
+	// Watch streams the endpoint events of the service
+	Watch(ctx context.Context, in *wrapperspb.StringValue, opts ...grpc.CallOption) (Discovery_WatchClient, error)
+}
+
+type discoveryClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewDiscoveryClient(cc grpc.ClientConnInterface) DiscoveryClient {
+	return &discoveryClient{cc}
+}
+
+func (c *discoveryClient) Resolve(ctx context.Context, in *wrapperspb.StringValue, opts ...grpc.CallOption) (*structpb.Struct, error) {
+	out := new(structpb.Struct)
+	err := c.cc.Invoke(ctx, Discovery_Resolve_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *discoveryClient) Watch(ctx context.Context, in *wrapperspb.StringValue, opts ...grpc.CallOption) (Discovery_WatchClient, error) {
+	stream, err := c.cc.NewStream(ctx, &Discovery_ServiceDesc.Streams[0], Discovery_Watch_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &discoveryWatchClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Discovery_WatchClient interface {
+	Recv() (*structpb.Struct, error)
+	grpc.ClientStream
+}
+
+type discoveryWatchClient struct {
+	grpc.ClientStream
+}
+
+func (x *discoveryWatchClient) Recv() (*structpb.Struct, error) {
+	m := new(structpb.Struct)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// DiscoveryServer is the server API for Discovery service.
+// All implementations must embed UnimplementedDiscoveryServer
+// for forward compatibility
+type DiscoveryServer interface {
+	// Resolve returns the endpoints of the service
+	Resolve(context.Context, *wrapperspb.StringValue) (*structpb.Struct, error)
+	// Watch streams the endpoint events of the service
+	Watch(*wrapperspb.StringValue, Discovery_WatchServer) error
+	mustEmbedUnimplementedDiscoveryServer()
+}
+
+// UnimplementedDiscoveryServer must be embedded to have forward compatible implementations.
+type UnimplementedDiscoveryServer struct {
+}
+
+func (UnimplementedDiscoveryServer) Resolve(context.Context, *wrapperspb.StringValue) (*structpb.Struct, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Resolve not implemented")
+}
+func (UnimplementedDiscoveryServer) Watch(*wrapperspb.StringValue, Discovery_WatchServer) error {
+	return status.Errorf(codes.Unimplemented, "method Watch not implemented")
+}
+func (UnimplementedDiscoveryServer) mustEmbedUnimplementedDiscoveryServer() {}
+
+// UnsafeDiscoveryServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to DiscoveryServer will
+// result in compilation errors.
+type UnsafeDiscoveryServer interface {
+	mustEmbedUnimplementedDiscoveryServer()
+}
+
+func RegisterDiscoveryServer(s grpc.ServiceRegistrar, srv DiscoveryServer) {
+	s.RegisterService(&Discovery_ServiceDesc, srv)
+}
+
+func _Discovery_Resolve_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(wrapperspb.StringValue)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(DiscoveryServer).Resolve(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Discovery_Resolve_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(DiscoveryServer).Resolve(ctx, req.(*wrapperspb.StringValue))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Discovery_Watch_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(wrapperspb.StringValue)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(DiscoveryServer).Watch(m, &discoveryWatchServer{stream})
+}
+
+type Discovery_WatchServer interface {
+	Send(*structpb.Struct) error
+	grpc.ServerStream
+}
+
+type discoveryWatchServer struct {
+	grpc.ServerStream
+}
+
+func (x *discoveryWatchServer) Send(m *structpb.Struct) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// Discovery_ServiceDesc is the grpc.ServiceDesc for Discovery service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Discovery_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "registry.memory.discovery.Discovery",
+	HandlerType: (*DiscoveryServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Resolve",
+			Handler:    _Discovery_Resolve_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Watch",
+			Handler:       _Discovery_Watch_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "discovery.proto",
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package discovery provides a plain gRPC service discovery API (service name -> endpoints) on top of the NSE
+// registry, so tools that don't depend on NSM protos can use the registry.
+//
+// The API is defined in discovery.proto from well-known protobuf types only. Resolve returns {"service": <name>,
+// "endpoints": [<endpoint>...]}, Watch streams {"endpoint": <endpoint>, "deleted": <bool>} events. An endpoint is
+// {"name", "url", "labels", "expiration_time"}.
+package discovery
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+)
+
+type discoveryServer struct {
+	UnimplementedDiscoveryServer
+	client registry.NetworkServiceEndpointRegistryClient
+}
+
+// NewServer creates a new discovery server resolving services with the NSE registry client, to be registered with
+// RegisterDiscoveryServer
+func NewServer(client registry.NetworkServiceEndpointRegistryClient) DiscoveryServer {
+	return &discoveryServer{
+		client: client,
+	}
+}
+
+func (s *discoveryServer) Resolve(ctx context.Context, service *wrapperspb.StringValue) (*structpb.Struct, error) {
+	if service.GetValue() == "" {
+		return nil, status.Error(codes.InvalidArgument, "service name is empty")
+	}
+
+	stream, err := s.client.Find(ctx, query(service.GetValue(), false))
+	if err != nil {
+		return nil, err
+	}
+
+	var endpoints []interface{}
+	for _, nse := range registry.ReadNetworkServiceEndpointList(stream) {
+		endpoints = append(endpoints, endpoint(service.GetValue(), nse))
+	}
+
+	return structpb.NewStruct(map[string]interface{}{
+		"service":   service.GetValue(),
+		"endpoints": endpoints,
+	})
+}
+
+func (s *discoveryServer) Watch(service *wrapperspb.StringValue, server Discovery_WatchServer) error {
+	if service.GetValue() == "" {
+		return status.Error(codes.InvalidArgument, "service name is empty")
+	}
+
+	stream, err := s.client.Find(server.Context(), query(service.GetValue(), true))
+	if err != nil {
+		return err
+	}
+
+	for resp := range registry.ReadNetworkServiceEndpointChannel(stream) {
+		event, structErr := structpb.NewStruct(map[string]interface{}{
+			"endpoint": endpoint(service.GetValue(), resp.GetNetworkServiceEndpoint()),
+			"deleted":  resp.GetDeleted(),
+		})
+		if structErr != nil {
+			return structErr
+		}
+		if sendErr := server.Send(event); sendErr != nil {
+			return errors.Wrapf(sendErr, "discovery watch server failed to send an event for %s", service.GetValue())
+		}
+	}
+	return nil
+}
+
+func query(service string, watch bool) *registry.NetworkServiceEndpointQuery {
+	return &registry.NetworkServiceEndpointQuery{
+		NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{
+			NetworkServiceNames: []string{service},
+		},
+		Watch: watch,
+	}
+}
+
+func endpoint(service string, nse *registry.NetworkServiceEndpoint) map[string]interface{} {
+	labels := make(map[string]interface{})
+	for k, v := range nse.GetNetworkServiceLabels()[service].GetLabels() {
+		labels[k] = v
+	}
+
+	result := map[string]interface{}{
+		"name":   nse.GetName(),
+		"url":    nse.GetUrl(),
+		"labels": labels,
+	}
+	if nse.GetExpirationTime() != nil {
+		result["expiration_time"] = nse.GetExpirationTime().AsTime().Format(time.RFC3339Nano)
+	}
+	return result
+}
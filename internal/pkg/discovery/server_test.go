@@ -0,0 +1,95 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/discovery"
+)
+
+func TestDiscovery_ResolveAndWatch(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	nseClient := adapters.NetworkServiceEndpointServerToClient(memory.NewNetworkServiceEndpointRegistryServer())
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	discovery.RegisterDiscoveryServer(server, discovery.NewServer(nseClient))
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	cc, err := grpc.DialContext(ctx, "bufconn",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer func() { _ = cc.Close() }()
+
+	client := discovery.NewClient(cc)
+
+	events, err := client.Watch(ctx, "ns-1")
+	require.NoError(t, err)
+
+	_, err = nseClient.Register(ctx, &registry.NetworkServiceEndpoint{
+		Name:                "nse-1",
+		Url:                 "tcp://1.1.1.1:5001",
+		NetworkServiceNames: []string{"ns-1"},
+		NetworkServiceLabels: map[string]*registry.NetworkServiceLabels{
+			"ns-1": {Labels: map[string]string{"app": "firewall"}},
+		},
+	})
+	require.NoError(t, err)
+	_, err = nseClient.Register(ctx, &registry.NetworkServiceEndpoint{
+		Name:                "nse-2",
+		Url:                 "tcp://2.2.2.2:5001",
+		NetworkServiceNames: []string{"ns-2"},
+	})
+	require.NoError(t, err)
+
+	resp, err := client.Resolve(ctx, "ns-1")
+	require.NoError(t, err)
+	require.Equal(t, "ns-1", resp.GetFields()["service"].GetStringValue())
+
+	endpoints := resp.GetFields()["endpoints"].GetListValue().GetValues()
+	require.Len(t, endpoints, 1)
+	endpoint := endpoints[0].GetStructValue().GetFields()
+	require.Equal(t, "nse-1", endpoint["name"].GetStringValue())
+	require.Equal(t, "tcp://1.1.1.1:5001", endpoint["url"].GetStringValue())
+	require.Equal(t, "firewall", endpoint["labels"].GetStructValue().GetFields()["app"].GetStringValue())
+
+	event := <-events
+	require.Equal(t, "nse-1", event.GetFields()["endpoint"].GetStructValue().GetFields()["name"].GetStringValue())
+	require.False(t, event.GetFields()["deleted"].GetBoolValue())
+
+	_, err = client.Resolve(ctx, "")
+	require.Error(t, err)
+}
@@ -0,0 +1,215 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dnsexpose provides a DNS handler serving SRV and A/AAAA records derived from the registered NSEs
+package dnsexpose
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/dnsutils"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/log"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/inprocess"
+)
+
+const minTTL = 1
+
+type handler struct {
+	zone      string
+	tenant    string
+	nseClient registry.NetworkServiceEndpointRegistryClient
+}
+
+// Option is an option for the DNS handler
+type Option func(h *handler)
+
+// WithTenant sets the tenant the NSEs are exposed of when the registry partitions the registrations by tenant. The
+// DNS clients see none of the tenant NSEs by default.
+func WithTenant(tenant string) Option {
+	return func(h *handler) {
+		h.tenant = tenant
+	}
+}
+
+// NewDNSHandler creates a new DNS handler answering the queries in zone:
+//   - <ns>.<zone> SRV returns an <nse>.<zone> target per NSE registered for the NS ns;
+//   - <ns>.<zone> A/AAAA returns the addresses of the NSEs registered for the NS ns;
+//   - <nse>.<zone> A/AAAA returns the address of the NSE nse.
+//
+// Only NSEs with an IP address in the URL are exposed. TTL is the time left before the NSE expiration. The NSEs are
+// found with in-process calls, as the DNS clients have no identity to authorize, made on behalf of the tenant, so the
+// tenancy filters them. The labels are limited to the DNS-safe characters, so they never name NSEs of other domains.
+func NewDNSHandler(zone string, nseClient registry.NetworkServiceEndpointRegistryClient, opts ...Option) dnsutils.Handler {
+	h := &handler{
+		zone:      dns.Fqdn(strings.ToLower(zone)),
+		nseClient: nseClient,
+	}
+	for _, opt := range opts {
+		opt(h)
+	}
+	return h
+}
+
+func (h *handler) ServeDNS(ctx context.Context, rw dns.ResponseWriter, msg *dns.Msg) {
+	if len(msg.Question) == 0 {
+		dns.HandleFailed(rw, msg)
+		return
+	}
+
+	var question = msg.Question[0]
+	var name = strings.ToLower(dns.Fqdn(question.Name))
+	var resp = new(dns.Msg)
+	resp.SetReply(msg)
+	resp.Authoritative = true
+
+	label, ok := h.label(name)
+	if !ok {
+		_ = rw.WriteMsg(resp.SetRcode(msg, dns.RcodeRefused))
+		return
+	}
+
+	nses, err := h.find(ctx, &registry.NetworkServiceEndpoint{NetworkServiceNames: []string{label}})
+	if err == nil && len(nses) == 0 {
+		nses, err = h.findByName(ctx, label)
+	}
+	if err != nil {
+		log.FromContext(ctx).Warnf("failed to find NSEs for %s: %s", name, err.Error())
+		dns.HandleFailed(rw, msg)
+		return
+	}
+	if len(nses) == 0 {
+		_ = rw.WriteMsg(resp.SetRcode(msg, dns.RcodeNameError))
+		return
+	}
+
+	for _, nse := range nses {
+		ip, port, hasIP := address(nse)
+		if !hasIP {
+			continue
+		}
+		var ttl = nseTTL(nse)
+		switch question.Qtype {
+		case dns.TypeSRV:
+			var target = nse.GetName() + "." + h.zone
+			resp.Answer = append(resp.Answer, &dns.SRV{
+				Hdr:    dns.RR_Header{Name: name, Rrtype: dns.TypeSRV, Class: dns.ClassINET, Ttl: ttl},
+				Target: target,
+				Port:   port,
+			})
+			if rr := addressRR(target, ip, ttl); rr != nil {
+				resp.Extra = append(resp.Extra, rr)
+			}
+		case dns.TypeA, dns.TypeAAAA:
+			if rr := addressRR(name, ip, ttl); rr != nil && rr.Header().Rrtype == question.Qtype {
+				resp.Answer = append(resp.Answer, rr)
+			}
+		}
+	}
+
+	if writeErr := rw.WriteMsg(resp); writeErr != nil {
+		dns.HandleFailed(rw, msg)
+	}
+}
+
+func (h *handler) label(name string) (string, bool) {
+	if !strings.HasSuffix(name, "."+h.zone) {
+		return "", false
+	}
+	var label = strings.TrimSuffix(name, "."+h.zone)
+	return label, label != "" && dnsSafe(label)
+}
+
+// dnsSafe returns true if label has the letters, digits, '-', '_' and '.' only. The name is lower case already.
+func dnsSafe(label string) bool {
+	for _, r := range label {
+		if !(r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.') {
+			return false
+		}
+	}
+	return true
+}
+
+func (h *handler) find(ctx context.Context, nse *registry.NetworkServiceEndpoint) ([]*registry.NetworkServiceEndpoint, error) {
+	stream, err := h.nseClient.Find(inprocess.WithTenant(ctx, h.tenant), &registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: nse})
+	if err != nil {
+		return nil, err
+	}
+	return registry.ReadNetworkServiceEndpointList(stream), nil
+}
+
+// findByName returns the NSE named name. The registry matches the names by substring, so the other NSEs found are
+// dropped.
+func (h *handler) findByName(ctx context.Context, name string) ([]*registry.NetworkServiceEndpoint, error) {
+	nses, err := h.find(ctx, &registry.NetworkServiceEndpoint{Name: name})
+	if err != nil {
+		return nil, err
+	}
+	var named []*registry.NetworkServiceEndpoint
+	for _, nse := range nses {
+		if nse.GetName() == name {
+			named = append(named, nse)
+		}
+	}
+	return named, nil
+}
+
+func address(nse *registry.NetworkServiceEndpoint) (ip net.IP, port uint16, ok bool) {
+	u, err := url.Parse(nse.GetUrl())
+	if err != nil {
+		return nil, 0, false
+	}
+	if ip = net.ParseIP(u.Hostname()); ip == nil {
+		return nil, 0, false
+	}
+	if p, parseErr := strconv.ParseUint(u.Port(), 10, 16); parseErr == nil {
+		port = uint16(p)
+	}
+	return ip, port, true
+}
+
+func addressRR(name string, ip net.IP, ttl uint32) dns.RR {
+	if ip4 := ip.To4(); ip4 != nil {
+		return &dns.A{
+			Hdr: dns.RR_Header{Name: name, Rrtype: dns.TypeA, Class: dns.ClassINET, Ttl: ttl},
+			A:   ip4,
+		}
+	}
+	return &dns.AAAA{
+		Hdr:  dns.RR_Header{Name: name, Rrtype: dns.TypeAAAA, Class: dns.ClassINET, Ttl: ttl},
+		AAAA: ip,
+	}
+}
+
+func nseTTL(nse *registry.NetworkServiceEndpoint) uint32 {
+	if nse.GetExpirationTime() == nil {
+		return minTTL
+	}
+	var left = time.Until(nse.GetExpirationTime().AsTime()) / time.Second
+	if left < minTTL {
+		return minTTL
+	}
+	return uint32(left)
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dnsexpose_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/miekg/dns"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/chain"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/utils/inject/injecterror"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/dnsutils"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/dnsexpose"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/inprocess"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/tenancy"
+)
+
+type responseWriter struct {
+	dns.ResponseWriter
+	resp *dns.Msg
+}
+
+func (w *responseWriter) WriteMsg(m *dns.Msg) error {
+	w.resp = m
+	return nil
+}
+
+func serve(ctx context.Context, handler dnsutils.Handler, name string, qtype uint16) *dns.Msg {
+	rw := new(responseWriter)
+	handler.ServeDNS(ctx, rw, new(dns.Msg).SetQuestion(name, qtype))
+	return rw.resp
+}
+
+func TestDNSHandler(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nseClient := adapters.NetworkServiceEndpointServerToClient(memory.NewNetworkServiceEndpointRegistryServer())
+	for _, nse := range []*registry.NetworkServiceEndpoint{
+		{Name: "nse-1", Url: "tcp://1.1.1.1:5001", NetworkServiceNames: []string{"ns-1"}},
+		{Name: "nse-2", Url: "tcp://[fe80::1]:5002", NetworkServiceNames: []string{"ns-1"}},
+		{Name: "nse-3", Url: "unix:///nse.sock", NetworkServiceNames: []string{"ns-1"}},
+		{Name: "nse-10", Url: "tcp://10.10.10.10:5010", NetworkServiceNames: []string{"ns-10"}},
+	} {
+		_, err := nseClient.Register(ctx, nse)
+		require.NoError(t, err)
+	}
+
+	handler := dnsexpose.NewDNSHandler("nsm", nseClient)
+
+	resp := serve(ctx, handler, "ns-1.nsm.", dns.TypeSRV)
+	require.Equal(t, dns.RcodeSuccess, resp.Rcode)
+	require.Len(t, resp.Extra, 2)
+	targets := make(map[string]uint16)
+	for _, rr := range resp.Answer {
+		targets[rr.(*dns.SRV).Target] = rr.(*dns.SRV).Port
+	}
+	require.Equal(t, map[string]uint16{"nse-1.nsm.": 5001, "nse-2.nsm.": 5002}, targets)
+
+	resp = serve(ctx, handler, "ns-1.nsm.", dns.TypeA)
+	require.Len(t, resp.Answer, 1)
+	require.Equal(t, "1.1.1.1", resp.Answer[0].(*dns.A).A.String())
+
+	resp = serve(ctx, handler, "nse-2.nsm.", dns.TypeAAAA)
+	require.Len(t, resp.Answer, 1)
+	require.Equal(t, "fe80::1", resp.Answer[0].(*dns.AAAA).AAAA.String())
+
+	// The NSE name matches exactly, nse-10 is not an answer for nse-1
+	resp = serve(ctx, handler, "nse-1.nsm.", dns.TypeA)
+	require.Len(t, resp.Answer, 1)
+	require.Equal(t, "1.1.1.1", resp.Answer[0].(*dns.A).A.String())
+	require.Equal(t, dns.RcodeNameError, serve(ctx, handler, "nse.nsm.", dns.TypeA).Rcode)
+
+	require.Equal(t, dns.RcodeNameError, serve(ctx, handler, "ns-2.nsm.", dns.TypeA).Rcode)
+	require.Equal(t, dns.RcodeRefused, serve(ctx, handler, "ns-1.example.com.", dns.TypeA).Rcode)
+}
+
+func TestDNSHandler_InProcess(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The NSEs are found in-process, skipping the authorization the DNS clients could not pass
+	server := chain.NewNetworkServiceEndpointRegistryServer(
+		inprocess.NewNetworkServiceEndpointRegistryServer(chain.NewNetworkServiceEndpointRegistryServer(
+			injecterror.NewNetworkServiceEndpointRegistryServer(injecterror.WithError(status.Error(codes.PermissionDenied, "denied"))),
+		)),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	)
+	_, err := server.Register(inprocess.WithContext(ctx), &registry.NetworkServiceEndpoint{
+		Name:                "nse-1",
+		Url:                 "tcp://1.1.1.1:5001",
+		NetworkServiceNames: []string{"ns-1"},
+	})
+	require.NoError(t, err)
+
+	resp := serve(ctx, dnsexpose.NewDNSHandler("nsm", adapters.NetworkServiceEndpointServerToClient(server)), "ns-1.nsm.", dns.TypeA)
+	require.Equal(t, dns.RcodeSuccess, resp.Rcode)
+	require.Len(t, resp.Answer, 1)
+}
+
+func TestDNSHandler_UnsafeLabel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nseClient := adapters.NetworkServiceEndpointServerToClient(memory.NewNetworkServiceEndpointRegistryServer())
+	_, err := nseClient.Register(ctx, &registry.NetworkServiceEndpoint{
+		Name:                "nse-1@other.com",
+		Url:                 "tcp://1.1.1.1:5001",
+		NetworkServiceNames: []string{"ns-1@other.com"},
+	})
+	require.NoError(t, err)
+
+	// The interdomain names are never looked up
+	handler := dnsexpose.NewDNSHandler("nsm", nseClient)
+	require.Equal(t, dns.RcodeRefused, serve(ctx, handler, `ns-1\@other.com.nsm.`, dns.TypeA).Rcode)
+	require.Equal(t, dns.RcodeRefused, serve(ctx, handler, `nse-1\@other.com.nsm.`, dns.TypeA).Rcode)
+}
+
+func TestDNSHandler_Tenancy(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	server := chain.NewNetworkServiceEndpointRegistryServer(
+		tenancy.NewNetworkServiceEndpointRegistryServer(),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	)
+	for tenant, name := range map[string]string{"a.com": "nse-a", "b.com": "nse-b"} {
+		_, err := server.Register(inprocess.WithTenant(ctx, tenant), &registry.NetworkServiceEndpoint{
+			Name:                name,
+			Url:                 "tcp://1.1.1.1:5001",
+			NetworkServiceNames: []string{"ns-1"},
+		})
+		require.NoError(t, err)
+	}
+	nseClient := adapters.NetworkServiceEndpointServerToClient(server)
+
+	// The DNS clients see the NSEs of the configured tenant only
+	resp := serve(ctx, dnsexpose.NewDNSHandler("nsm", nseClient, dnsexpose.WithTenant("a.com")), "ns-1.nsm.", dns.TypeSRV)
+	require.Len(t, resp.Answer, 1)
+	require.Equal(t, "nse-a.nsm.", resp.Answer[0].(*dns.SRV).Target)
+
+	require.Equal(t, dns.RcodeNameError, serve(ctx, dnsexpose.NewDNSHandler("nsm", nseClient), "ns-1.nsm.", dns.TypeSRV).Rcode)
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package events provides an in-process bus delivering registry events to the integrations subscribed to it
+package events
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/log"
+)
+
+// Registry process lifecycle event types
+const (
+	// TypeStarted is published when the registry is ready to serve
+	TypeStarted = "registry.started"
+	// TypeShuttingDown is published when the registry starts shutting down
+	TypeShuttingDown = "registry.shutting-down"
+	// TypeRestoredFromSnapshot is published when a state snapshot has been imported into the registry
+	TypeRestoredFromSnapshot = "registry.restored-from-snapshot"
+)
+
+// Event is a registry event
+type Event struct {
+	Type       string            `json:"type"`
+	Time       time.Time         `json:"time"`
+	Attributes map[string]string `json:"attributes,omitempty"`
+}
+
+// New creates a new event of the type happened now
+func New(eventType string, attributes map[string]string) Event {
+	return Event{
+		Type:       eventType,
+		Time:       time.Now().UTC(),
+		Attributes: attributes,
+	}
+}
+
+// Bus delivers published events to all the subscribers. Publish never blocks: if a subscriber doesn't keep up, the
+// event is dropped for it.
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers []chan Event
+	closed      bool
+}
+
+// NewBus creates a new Bus
+func NewBus() *Bus {
+	return new(Bus)
+}
+
+// Subscribe returns a channel receiving the events published after the call. The channel is closed on Close.
+func (b *Bus) Subscribe(bufferSize int) <-chan Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	ch := make(chan Event, bufferSize)
+	if b.closed {
+		close(ch)
+		return ch
+	}
+	b.subscribers = append(b.subscribers, ch)
+	return ch
+}
+
+// Publish sends the event to all the subscribers
+func (b *Bus) Publish(ctx context.Context, event Event) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	log.FromContext(ctx).WithField("event", event.Type).Debugf("publishing event: %v", event.Attributes)
+	if b.closed {
+		return
+	}
+	for _, ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			log.FromContext(ctx).WithField("event", event.Type).Warn("subscriber is too slow, dropping event")
+		}
+	}
+}
+
+// Close closes all the subscriber channels, events published after Close are dropped
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for _, ch := range b.subscribers {
+		close(ch)
+	}
+	b.subscribers = nil
+}
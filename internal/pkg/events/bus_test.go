@@ -0,0 +1,49 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/events"
+)
+
+func TestBus_PublishSubscribeClose(t *testing.T) {
+	bus := events.NewBus()
+
+	first := bus.Subscribe(1)
+	second := bus.Subscribe(0)
+
+	bus.Publish(context.Background(), events.New(events.TypeStarted, nil))
+	bus.Publish(context.Background(), events.New(events.TypeShuttingDown, nil))
+	bus.Close()
+
+	var received []string
+	for event := range first {
+		received = append(received, event.Type)
+	}
+	require.Equal(t, []string{events.TypeStarted}, received)
+
+	_, ok := <-second
+	require.False(t, ok)
+
+	_, ok = <-bus.Subscribe(1)
+	require.False(t, ok)
+}
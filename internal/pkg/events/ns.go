@@ -0,0 +1,29 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+// NS event types
+const (
+	// TypeNSRegistered is published when a new network service is registered
+	TypeNSRegistered = "ns.registered"
+	// TypeNSUpdated is published when a registered network service is registered again
+	TypeNSUpdated = "ns.updated"
+	// TypeNSUnregistered is published when a network service is unregistered, the garbage collected ones included
+	TypeNSUnregistered = "ns.unregistered"
+	// TypeNSCollected is published when a network service with no NSEs is unregistered by the garbage collection
+	TypeNSCollected = "ns.collected"
+)
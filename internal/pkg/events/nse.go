@@ -0,0 +1,91 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/log"
+)
+
+// NSE event types
+const (
+	// TypeNSERegistered is published when a new NSE is registered
+	TypeNSERegistered = "nse.registered"
+	// TypeNSERefreshed is published when a registered NSE is registered again
+	TypeNSERefreshed = "nse.refreshed"
+	// TypeNSEExpired is published when an NSE is removed by expiration
+	TypeNSEExpired = "nse.expired"
+	// TypeNSEUnregistered is published when an NSE is removed before its expiration
+	TypeNSEUnregistered = "nse.unregistered"
+)
+
+// PublishNSEEvents watches the NSEs with the client and publishes NSE events to the bus until ctx is done
+func PublishNSEEvents(ctx context.Context, bus *Bus, client registry.NetworkServiceEndpointRegistryClient) {
+	known := make(map[string]struct{})
+	for ctx.Err() == nil {
+		stream, err := client.Find(ctx, &registry.NetworkServiceEndpointQuery{
+			NetworkServiceEndpoint: new(registry.NetworkServiceEndpoint),
+			Watch:                  true,
+		})
+		if err != nil {
+			log.FromContext(ctx).Warnf("failed to watch NSEs for events: %s", err.Error())
+			select {
+			case <-ctx.Done():
+			case <-clock.FromContext(ctx).After(time.Second):
+			}
+			continue
+		}
+		for resp := range registry.ReadNetworkServiceEndpointChannel(stream) {
+			nse := resp.GetNetworkServiceEndpoint()
+			bus.Publish(ctx, New(nseEventType(ctx, known, resp), nseAttributes(nse)))
+		}
+	}
+}
+
+func nseEventType(ctx context.Context, known map[string]struct{}, resp *registry.NetworkServiceEndpointResponse) string {
+	nse := resp.GetNetworkServiceEndpoint()
+	if resp.GetDeleted() {
+		delete(known, nse.GetName())
+		if expirationTime := nse.GetExpirationTime(); expirationTime != nil && !clock.FromContext(ctx).Now().Before(expirationTime.AsTime()) {
+			return TypeNSEExpired
+		}
+		return TypeNSEUnregistered
+	}
+	if _, ok := known[nse.GetName()]; ok {
+		return TypeNSERefreshed
+	}
+	known[nse.GetName()] = struct{}{}
+	return TypeNSERegistered
+}
+
+func nseAttributes(nse *registry.NetworkServiceEndpoint) map[string]string {
+	attributes := map[string]string{
+		"name":                  nse.GetName(),
+		"url":                   nse.GetUrl(),
+		"network_service_names": strings.Join(nse.GetNetworkServiceNames(), ","),
+	}
+	if expirationTime := nse.GetExpirationTime(); expirationTime != nil {
+		attributes["expiration_time"] = expirationTime.AsTime().UTC().Format(time.RFC3339Nano)
+	}
+	return attributes
+}
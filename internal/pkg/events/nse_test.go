@@ -0,0 +1,66 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package events_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/events"
+)
+
+func TestPublishNSEEvents(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	nseClient := adapters.NetworkServiceEndpointServerToClient(memory.NewNetworkServiceEndpointRegistryServer())
+	nse := &registry.NetworkServiceEndpoint{
+		Name:                "nse-1",
+		Url:                 "tcp://1.1.1.1:5001",
+		NetworkServiceNames: []string{"ns-1", "ns-2"},
+		ExpirationTime:      timestamppb.New(time.Now().Add(time.Hour)),
+	}
+	_, err := nseClient.Register(ctx, nse)
+	require.NoError(t, err)
+
+	bus := events.NewBus()
+	ch := bus.Subscribe(10)
+	go events.PublishNSEEvents(ctx, bus, nseClient)
+
+	event := <-ch
+	require.Equal(t, events.TypeNSERegistered, event.Type)
+	require.Equal(t, "nse-1", event.Attributes["name"])
+	require.Equal(t, "tcp://1.1.1.1:5001", event.Attributes["url"])
+	require.Equal(t, "ns-1,ns-2", event.Attributes["network_service_names"])
+
+	_, err = nseClient.Register(ctx, nse)
+	require.NoError(t, err)
+	require.Equal(t, events.TypeNSERefreshed, (<-ch).Type)
+
+	_, err = nseClient.Unregister(ctx, nse)
+	require.NoError(t, err)
+	require.Equal(t, events.TypeNSEUnregistered, (<-ch).Type)
+}
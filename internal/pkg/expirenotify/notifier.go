@@ -0,0 +1,204 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package expirenotify provides the notification of the expired NSEs: the registry calls Unregister on the registry
+// server at the URL of the NSE, so the endpoint side state is cleaned up as well
+package expirenotify
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/grpcutils"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/log"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/connpool"
+)
+
+const maxBackoff = 30 * time.Second
+
+// Notification results
+const (
+	resultNotified = "notified"
+	resultFailed   = "failed"
+)
+
+type options struct {
+	maxRetries int
+	backoff    time.Duration
+	timeout    time.Duration
+}
+
+// Option is an option for the Notifier
+type Option func(o *options)
+
+// WithMaxRetries sets the number of retries of a failed notification, default is 3
+func WithMaxRetries(maxRetries int) Option {
+	return func(o *options) {
+		o.maxRetries = maxRetries
+	}
+}
+
+// WithBackoff sets the delay before the first retry, it doubles with each retry up to 30s. Default is 1s.
+func WithBackoff(backoff time.Duration) Option {
+	return func(o *options) {
+		o.backoff = backoff
+	}
+}
+
+// WithTimeout sets the timeout of a notification attempt, default is 5s
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// Notifier calls Unregister at the URLs of the expired NSEs. Unavailable, DeadlineExceeded and ResourceExhausted
+// failures are retried with exponential backoff, the others are not: e.g. the endpoints not serving the registry API
+// fail with Unimplemented.
+type Notifier struct {
+	options
+	pool          *connpool.Pool
+	notifications metric.Int64Counter
+}
+
+// NewNotifier creates a new Notifier getting the connections to the NSE URLs from the pool
+func NewNotifier(pool *connpool.Pool, opts ...Option) *Notifier {
+	n := &Notifier{
+		options: options{
+			maxRetries: 3,
+			backoff:    time.Second,
+			timeout:    5 * time.Second,
+		},
+		pool: pool,
+	}
+	for _, opt := range opts {
+		opt(&n.options)
+	}
+	notifications, err := otel.Meter("registry-memory").Int64Counter("registry.nse.expire_notifications",
+		metric.WithDescription("Number of the Unregister notifications of the expired NSEs sent to their URLs by result"))
+	if err != nil {
+		log.L().Errorf("failed to create NSE expire notifications counter: %s", err.Error())
+	}
+	n.notifications = notifications
+	return n
+}
+
+// Run watches the NSEs with the client and notifies the expired ones until ctx is done
+func (n *Notifier) Run(ctx context.Context, client registry.NetworkServiceEndpointRegistryClient) {
+	for ctx.Err() == nil {
+		stream, err := client.Find(ctx, &registry.NetworkServiceEndpointQuery{
+			NetworkServiceEndpoint: new(registry.NetworkServiceEndpoint),
+			Watch:                  true,
+		})
+		if err != nil {
+			log.FromContext(ctx).Warnf("failed to watch NSEs for expire notifications: %s", err.Error())
+			select {
+			case <-ctx.Done():
+			case <-clock.FromContext(ctx).After(time.Second):
+			}
+			continue
+		}
+		for resp := range registry.ReadNetworkServiceEndpointChannel(stream) {
+			if nse := resp.GetNetworkServiceEndpoint(); resp.GetDeleted() && expired(ctx, nse) && nse.GetUrl() != "" {
+				go n.Notify(ctx, nse)
+			}
+		}
+	}
+}
+
+func expired(ctx context.Context, nse *registry.NetworkServiceEndpoint) bool {
+	expirationTime := nse.GetExpirationTime()
+	return expirationTime != nil && !clock.FromContext(ctx).Now().Before(expirationTime.AsTime())
+}
+
+// Notify calls Unregister of the NSE at its URL, retrying the transient failures
+func (n *Notifier) Notify(ctx context.Context, nse *registry.NetworkServiceEndpoint) {
+	logger := log.FromContext(ctx).WithField("nse", nse.GetName())
+
+	result := resultNotified
+	defer func() {
+		if n.notifications != nil {
+			n.notifications.Add(ctx, 1, metric.WithAttributes(attribute.String("result", result)))
+		}
+	}()
+
+	backoff := n.backoff
+	for attempt := 0; ; attempt++ {
+		err := n.unregister(ctx, nse)
+		if err == nil {
+			logger.Debugf("notified %s of the expiration", nse.GetUrl())
+			return
+		}
+		if !retriable(err) || attempt >= n.maxRetries {
+			result = resultFailed
+			logger.Warnf("failed to notify %s of the expiration: %s", nse.GetUrl(), err.Error())
+			return
+		}
+		logger.Debugf("failed to notify %s of the expiration, retrying in %s: %s", nse.GetUrl(), backoff, err.Error())
+		select {
+		case <-ctx.Done():
+			result = resultFailed
+			return
+		case <-clock.FromContext(ctx).After(backoff):
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+func (n *Notifier) unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint) error {
+	u, err := url.Parse(nse.GetUrl())
+	if err != nil {
+		return errors.Wrapf(err, "invalid NSE URL %s", nse.GetUrl())
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, n.timeout)
+	defer cancel()
+
+	cc, release, err := n.pool.Get(ctx, grpcutils.URLToTarget(u))
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	_, err = registry.NewNetworkServiceEndpointRegistryClient(cc).Unregister(ctx, nse)
+	return err
+}
+
+func retriable(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	switch status.Code(errors.Cause(err)) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted:
+		return true
+	default:
+		return false
+	}
+}
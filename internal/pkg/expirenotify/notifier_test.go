@@ -0,0 +1,191 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package expirenotify_test
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clockmock"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/connpool"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/expirenotify"
+)
+
+const nseURL = "tcp://127.0.0.1:5001"
+
+type endpointRegistry struct {
+	registry.NetworkServiceEndpointRegistryServer
+	failures      int32
+	unregistered  chan string
+	failuresCount int32
+}
+
+func (s *endpointRegistry) Unregister(_ context.Context, nse *registry.NetworkServiceEndpoint) (*empty.Empty, error) {
+	if atomic.AddInt32(&s.failuresCount, 1) <= s.failures {
+		return nil, status.Error(codes.Unavailable, "not yet")
+	}
+	s.unregistered <- nse.GetName()
+	return new(empty.Empty), nil
+}
+
+// watchingClient signals once the watch receives the first event, so it receives all the following ones
+type watchingClient struct {
+	registry.NetworkServiceEndpointRegistryClient
+	watching chan struct{}
+}
+
+func (c *watchingClient) Find(ctx context.Context, query *registry.NetworkServiceEndpointQuery, opts ...grpc.CallOption) (registry.NetworkServiceEndpointRegistry_FindClient, error) {
+	stream, err := c.NetworkServiceEndpointRegistryClient.Find(ctx, query, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &watchingStream{NetworkServiceEndpointRegistry_FindClient: stream, watching: c.watching}, nil
+}
+
+type watchingStream struct {
+	registry.NetworkServiceEndpointRegistry_FindClient
+	watching chan struct{}
+	once     sync.Once
+}
+
+func (s *watchingStream) Recv() (*registry.NetworkServiceEndpointResponse, error) {
+	resp, err := s.NetworkServiceEndpointRegistry_FindClient.Recv()
+	s.once.Do(func() { close(s.watching) })
+	return resp, err
+}
+
+func newPool(ctx context.Context, t *testing.T, endpoint *endpointRegistry) *connpool.Pool {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	if endpoint != nil {
+		registry.RegisterNetworkServiceEndpointRegistryServer(server, endpoint)
+	}
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	return connpool.NewPool(ctx, connpool.WithDialOptions(
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	))
+}
+
+func TestNotifier_Run(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	clockMock := clockmock.New(ctx)
+	ctx = clock.WithClock(ctx, clockMock)
+
+	endpoint := &endpointRegistry{unregistered: make(chan string, 2)}
+	notifier := expirenotify.NewNotifier(newPool(ctx, t, endpoint))
+
+	client := adapters.NetworkServiceEndpointServerToClient(memory.NewNetworkServiceEndpointRegistryServer())
+
+	expiring := &registry.NetworkServiceEndpoint{Name: "nse-expired", Url: nseURL, ExpirationTime: timestamppb.New(clockMock.Now())}
+	unregistered := &registry.NetworkServiceEndpoint{Name: "nse-unregistered", Url: nseURL, ExpirationTime: timestamppb.New(clockMock.Now().Add(time.Hour))}
+	for _, nse := range []*registry.NetworkServiceEndpoint{unregistered, expiring} {
+		_, err := client.Register(ctx, nse)
+		require.NoError(t, err)
+	}
+
+	watching := make(chan struct{})
+	go notifier.Run(ctx, &watchingClient{NetworkServiceEndpointRegistryClient: client, watching: watching})
+	<-watching
+
+	for _, nse := range []*registry.NetworkServiceEndpoint{unregistered, expiring} {
+		_, err := client.Unregister(ctx, nse)
+		require.NoError(t, err)
+	}
+
+	select {
+	case name := <-endpoint.unregistered:
+		require.Equal(t, expiring.GetName(), name)
+	case <-ctx.Done():
+		t.Fatal("the expired NSE is not notified")
+	}
+	require.Never(t, func() bool { return len(endpoint.unregistered) > 0 }, 100*time.Millisecond, 10*time.Millisecond)
+}
+
+func TestNotifier_Retries(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	clockMock := clockmock.New(ctx)
+	ctx = clock.WithClock(ctx, clockMock)
+
+	endpoint := &endpointRegistry{failures: 2, unregistered: make(chan string, 1)}
+	notifier := expirenotify.NewNotifier(newPool(ctx, t, endpoint),
+		expirenotify.WithBackoff(time.Second),
+		expirenotify.WithTimeout(time.Hour))
+
+	done := make(chan struct{})
+	go func() {
+		notifier.Notify(ctx, &registry.NetworkServiceEndpoint{Name: "nse", Url: nseURL})
+		close(done)
+	}()
+
+	require.Eventually(t, func() bool {
+		clockMock.Add(time.Second)
+		select {
+		case <-done:
+			return true
+		default:
+			return false
+		}
+	}, time.Second, 10*time.Millisecond)
+	require.Equal(t, "nse", <-endpoint.unregistered)
+	require.Equal(t, int32(3), atomic.LoadInt32(&endpoint.failuresCount))
+}
+
+func TestNotifier_NoRetryUnimplemented(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The endpoint doesn't serve the registry API
+	notifier := expirenotify.NewNotifier(newPool(ctx, t, nil), expirenotify.WithBackoff(time.Hour))
+
+	done := make(chan struct{})
+	go func() {
+		notifier.Notify(ctx, &registry.NetworkServiceEndpoint{Name: "nse", Url: nseURL})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+		t.Fatal("the Unimplemented failure is retried")
+	}
+}
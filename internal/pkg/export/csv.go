@@ -0,0 +1,110 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package export provides CSV export of the registry data for offline analytics and the Prometheus text exposition of
+// the per network service statistics for the collection without a Prometheus server
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/tombstones"
+)
+
+// RegistrationsHeader is the header of the registrations CSV
+var RegistrationsHeader = []string{
+	"name", "url", "network_service_names", "labels", "initial_registration_time", "expiration_time",
+}
+
+// ChurnHeader is the header of the churn CSV
+var ChurnHeader = []string{
+	"name", "url", "network_service_names", "reason", "deleted_at", "expiration_time",
+}
+
+// RegistrationsCSV writes the NSEs sorted by name as CSV. The network service names are separated with ';', the
+// labels are JSON: {"network service": {"key": "value"}}.
+func RegistrationsCSV(w io.Writer, nses []*registry.NetworkServiceEndpoint) error {
+	sorted := append([]*registry.NetworkServiceEndpoint(nil), nses...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].GetName() < sorted[j].GetName()
+	})
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(RegistrationsHeader); err != nil {
+		return errors.Wrap(err, "failed to write CSV header")
+	}
+	for _, nse := range sorted {
+		labels := make(map[string]map[string]string)
+		for name, l := range nse.GetNetworkServiceLabels() {
+			labels[name] = l.GetLabels()
+		}
+		labelsJSON, err := json.Marshal(labels)
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal labels of %s", nse.GetName())
+		}
+		if writeErr := writer.Write([]string{
+			nse.GetName(),
+			nse.GetUrl(),
+			strings.Join(nse.GetNetworkServiceNames(), ";"),
+			string(labelsJSON),
+			formatTime(nse.GetInitialRegistrationTime()),
+			formatTime(nse.GetExpirationTime()),
+		}); writeErr != nil {
+			return errors.Wrapf(writeErr, "failed to write %s", nse.GetName())
+		}
+	}
+	writer.Flush()
+	return errors.Wrap(writer.Error(), "failed to flush CSV")
+}
+
+// ChurnCSV writes the tombstones of the removed NSEs in the removal order as CSV
+func ChurnCSV(w io.Writer, removed []*tombstones.Tombstone) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write(ChurnHeader); err != nil {
+		return errors.Wrap(err, "failed to write CSV header")
+	}
+	for _, t := range removed {
+		if err := writer.Write([]string{
+			t.NSE.GetName(),
+			t.NSE.GetUrl(),
+			strings.Join(t.NSE.GetNetworkServiceNames(), ";"),
+			string(t.Reason),
+			t.DeletedAt.UTC().Format(time.RFC3339Nano),
+			formatTime(t.NSE.GetExpirationTime()),
+		}); err != nil {
+			return errors.Wrapf(err, "failed to write %s", t.NSE.GetName())
+		}
+	}
+	writer.Flush()
+	return errors.Wrap(writer.Error(), "failed to flush CSV")
+}
+
+func formatTime(t *timestamppb.Timestamp) string {
+	if t == nil {
+		return ""
+	}
+	return t.AsTime().UTC().Format(time.RFC3339Nano)
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/export"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/tombstones"
+)
+
+func TestRegistrationsCSV(t *testing.T) {
+	expiration := time.Date(2023, 7, 17, 10, 0, 0, 0, time.UTC)
+	buf := new(bytes.Buffer)
+	require.NoError(t, export.RegistrationsCSV(buf, []*registry.NetworkServiceEndpoint{
+		{
+			Name:                "nse-2",
+			Url:                 "tcp://2.2.2.2:5001",
+			NetworkServiceNames: []string{"ns-1", "ns-2"},
+			NetworkServiceLabels: map[string]*registry.NetworkServiceLabels{
+				"ns-1": {Labels: map[string]string{"app": "firewall"}},
+			},
+			ExpirationTime: timestamppb.New(expiration),
+		},
+		{
+			Name: "nse-1",
+		},
+	}))
+
+	records, err := csv.NewReader(buf).ReadAll()
+	require.NoError(t, err)
+	require.Equal(t, [][]string{
+		export.RegistrationsHeader,
+		{"nse-1", "", "", "{}", "", ""},
+		{"nse-2", "tcp://2.2.2.2:5001", "ns-1;ns-2", `{"ns-1":{"app":"firewall"}}`, "", "2023-07-17T10:00:00Z"},
+	}, records)
+}
+
+func TestChurnCSV(t *testing.T) {
+	buf := new(bytes.Buffer)
+	require.NoError(t, export.ChurnCSV(buf, []*tombstones.Tombstone{{
+		NSE:       &registry.NetworkServiceEndpoint{Name: "nse-1", NetworkServiceNames: []string{"ns-1"}},
+		Reason:    tombstones.ReasonExpired,
+		DeletedAt: time.Date(2023, 7, 17, 10, 0, 0, 0, time.UTC),
+	}}))
+
+	records, err := csv.NewReader(buf).ReadAll()
+	require.NoError(t, err)
+	require.Equal(t, [][]string{
+		export.ChurnHeader,
+		{"nse-1", "", "ns-1", "expired", "2023-07-17T10:00:00Z", ""},
+	}, records)
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/nsstats"
+)
+
+// PrometheusContentType is the content type of the Prometheus text exposition format
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+type statsGauge struct {
+	name  string
+	help  string
+	value func(s *nsstats.Stats) (float64, bool)
+}
+
+var statsGauges = []statsGauge{
+	{
+		name: "registry_snapshot_endpoints",
+		help: "Number of the registered NSEs of the network service.",
+		value: func(s *nsstats.Stats) (float64, bool) {
+			return float64(s.Endpoints), true
+		},
+	},
+	{
+		name: "registry_snapshot_average_remaining_expiration_seconds",
+		help: "Average time left until the NSEs of the network service expire.",
+		value: func(s *nsstats.Stats) (float64, bool) {
+			return s.AverageRemaining.Seconds(), s.Endpoints > 0
+		},
+	},
+	{
+		name: "registry_snapshot_oldest_registration_timestamp_seconds",
+		help: "First registration time of the oldest NSE of the network service.",
+		value: func(s *nsstats.Stats) (float64, bool) {
+			return unixSeconds(s.OldestRegistration)
+		},
+	},
+	{
+		name: "registry_snapshot_newest_registration_timestamp_seconds",
+		help: "First registration time of the newest NSE of the network service.",
+		value: func(s *nsstats.Stats) (float64, bool) {
+			return unixSeconds(s.NewestRegistration)
+		},
+	},
+	{
+		name: "registry_snapshot_last_modified_timestamp_seconds",
+		help: "Last time a NSE of the network service was registered, changed or removed.",
+		value: func(s *nsstats.Stats) (float64, bool) {
+			return unixSeconds(s.LastModified)
+		},
+	},
+}
+
+// StatsPrometheus writes the per network service statistics taken at now as a one-shot Prometheus text exposition:
+// a gauge family per statistic with a network_service label, and registry_snapshot_timestamp_seconds with now. The
+// zero times are left out.
+func StatsPrometheus(w io.Writer, stats []*nsstats.Stats, now time.Time) error {
+	bw := bufio.NewWriter(w)
+	writeFamily := func(name, help string) {
+		_, _ = fmt.Fprintf(bw, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+	}
+
+	writeFamily("registry_snapshot_timestamp_seconds", "Time the snapshot was taken at.")
+	ts, _ := unixSeconds(now)
+	_, _ = fmt.Fprintf(bw, "registry_snapshot_timestamp_seconds %s\n", formatFloat(ts))
+	for _, gauge := range statsGauges {
+		writeFamily(gauge.name, gauge.help)
+		for _, s := range stats {
+			if value, ok := gauge.value(s); ok {
+				_, _ = fmt.Fprintf(bw, "%s{network_service=\"%s\"} %s\n", gauge.name, escapeLabelValue(s.NetworkService), formatFloat(value))
+			}
+		}
+	}
+	return errors.Wrap(bw.Flush(), "failed to write the Prometheus snapshot")
+}
+
+func unixSeconds(t time.Time) (float64, bool) {
+	if t.IsZero() {
+		return 0, false
+	}
+	return float64(t.UnixNano()) / float64(time.Second), true
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(s string) string {
+	return labelValueReplacer.Replace(s)
+}
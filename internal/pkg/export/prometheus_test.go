@@ -0,0 +1,69 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package export_test
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/export"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/nsstats"
+)
+
+func TestStatsPrometheus(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	buf := new(bytes.Buffer)
+	require.NoError(t, export.StatsPrometheus(buf, []*nsstats.Stats{
+		{
+			NetworkService:     "ns-1",
+			Endpoints:          2,
+			OldestRegistration: now.Add(-time.Hour),
+			NewestRegistration: now.Add(-time.Minute),
+			AverageRemaining:   1500 * time.Millisecond,
+			LastModified:       now.Add(-time.Minute),
+		},
+		{
+			NetworkService: `ns-"2"`,
+			LastModified:   now.Add(-time.Second),
+		},
+	}, now))
+
+	require.Equal(t, `# HELP registry_snapshot_timestamp_seconds Time the snapshot was taken at.
+# TYPE registry_snapshot_timestamp_seconds gauge
+registry_snapshot_timestamp_seconds 1.7e+09
+# HELP registry_snapshot_endpoints Number of the registered NSEs of the network service.
+# TYPE registry_snapshot_endpoints gauge
+registry_snapshot_endpoints{network_service="ns-1"} 2
+registry_snapshot_endpoints{network_service="ns-\"2\""} 0
+# HELP registry_snapshot_average_remaining_expiration_seconds Average time left until the NSEs of the network service expire.
+# TYPE registry_snapshot_average_remaining_expiration_seconds gauge
+registry_snapshot_average_remaining_expiration_seconds{network_service="ns-1"} 1.5
+# HELP registry_snapshot_oldest_registration_timestamp_seconds First registration time of the oldest NSE of the network service.
+# TYPE registry_snapshot_oldest_registration_timestamp_seconds gauge
+registry_snapshot_oldest_registration_timestamp_seconds{network_service="ns-1"} 1.6999964e+09
+# HELP registry_snapshot_newest_registration_timestamp_seconds First registration time of the newest NSE of the network service.
+# TYPE registry_snapshot_newest_registration_timestamp_seconds gauge
+registry_snapshot_newest_registration_timestamp_seconds{network_service="ns-1"} 1.69999994e+09
+# HELP registry_snapshot_last_modified_timestamp_seconds Last time a NSE of the network service was registered, changed or removed.
+# TYPE registry_snapshot_last_modified_timestamp_seconds gauge
+registry_snapshot_last_modified_timestamp_seconds{network_service="ns-1"} 1.69999994e+09
+registry_snapshot_last_modified_timestamp_seconds{network_service="ns-\"2\""} 1.699999999e+09
+`, buf.String())
+}
@@ -0,0 +1,117 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package fakestate provides a generator of synthetic registry state for UI and performance testing
+package fakestate
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+
+	"github.com/pkg/errors"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/networkservice/payload"
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+)
+
+const (
+	// NamePrefix is the prefix of all generated NS and NSE names
+	NamePrefix = "fake-"
+
+	endpointsPerService = 10
+	seed                = 1
+)
+
+type weighted struct {
+	value  string
+	weight int
+}
+
+// labelDistributions are the label values with their weights (in percents)
+var labelDistributions = []struct {
+	key    string
+	values []weighted
+}{
+	{"env", []weighted{{"prod", 60}, {"staging", 30}, {"dev", 10}}},
+	{"zone", []weighted{{"zone-a", 34}, {"zone-b", 33}, {"zone-c", 33}}},
+	{"version", []weighted{{"v1", 80}, {"v2", 20}}},
+	{"canary", []weighted{{"false", 95}, {"true", 5}}},
+}
+
+// Generate registers n synthetic NSEs spread over n/10 (at least one) synthetic NSs with the clients. The same n
+// always produces the same state.
+func Generate(ctx context.Context, n int, nsClient registry.NetworkServiceRegistryClient, nseClient registry.NetworkServiceEndpointRegistryClient) error {
+	if n <= 0 {
+		return nil
+	}
+
+	// #nosec G404 - fake state doesn't need a secure random
+	r := rand.New(rand.NewSource(seed))
+
+	serviceCount := (n + endpointsPerService - 1) / endpointsPerService
+	for i := 0; i < serviceCount; i++ {
+		ns := &registry.NetworkService{
+			Name:    fmt.Sprintf("%sns-%d", NamePrefix, i),
+			Payload: payload.IP,
+		}
+		if i%2 == 1 {
+			ns.Payload = payload.Ethernet
+		}
+		if _, err := nsClient.Register(ctx, ns); err != nil {
+			return errors.Wrapf(err, "failed to register fake NS %s", ns.GetName())
+		}
+	}
+
+	for i := 0; i < n; i++ {
+		nsName := fmt.Sprintf("%sns-%d", NamePrefix, r.Intn(serviceCount))
+		labels := map[string]string{
+			"app": nsName,
+		}
+		for _, d := range labelDistributions {
+			labels[d.key] = pick(r, d.values)
+		}
+
+		nse := &registry.NetworkServiceEndpoint{
+			Name:                fmt.Sprintf("%snse-%d", NamePrefix, i),
+			Url:                 fmt.Sprintf("tcp://10.%d.%d.%d:5001", (i>>16)&0xff, (i>>8)&0xff, i&0xff),
+			NetworkServiceNames: []string{nsName},
+			NetworkServiceLabels: map[string]*registry.NetworkServiceLabels{
+				nsName: {Labels: labels},
+			},
+		}
+		if _, err := nseClient.Register(ctx, nse); err != nil {
+			return errors.Wrapf(err, "failed to register fake NSE %s", nse.GetName())
+		}
+	}
+
+	return nil
+}
+
+func pick(r *rand.Rand, values []weighted) string {
+	total := 0
+	for _, v := range values {
+		total += v.weight
+	}
+	n := r.Intn(total)
+	for _, v := range values {
+		if n < v.weight {
+			return v.value
+		}
+		n -= v.weight
+	}
+	return values[len(values)-1].value
+}
@@ -0,0 +1,56 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fakestate_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/fakestate"
+)
+
+func TestGenerate(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	nsClient := adapters.NetworkServiceServerToClient(memory.NewNetworkServiceRegistryServer())
+	nseClient := adapters.NetworkServiceEndpointServerToClient(memory.NewNetworkServiceEndpointRegistryServer())
+
+	require.NoError(t, fakestate.Generate(ctx, 25, nsClient, nseClient))
+
+	nsStream, err := nsClient.Find(ctx, &registry.NetworkServiceQuery{NetworkService: new(registry.NetworkService)})
+	require.NoError(t, err)
+	require.Len(t, registry.ReadNetworkServiceList(nsStream), 3)
+
+	nseStream, err := nseClient.Find(ctx, &registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: new(registry.NetworkServiceEndpoint)})
+	require.NoError(t, err)
+	nses := registry.ReadNetworkServiceEndpointList(nseStream)
+	require.Len(t, nses, 25)
+	for _, nse := range nses {
+		require.Len(t, nse.GetNetworkServiceNames(), 1)
+		labels := nse.GetNetworkServiceLabels()[nse.GetNetworkServiceNames()[0]].GetLabels()
+		require.Contains(t, []string{"prod", "staging", "dev"}, labels["env"])
+		require.NotEmpty(t, labels["zone"])
+	}
+}
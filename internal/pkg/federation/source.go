@@ -0,0 +1,120 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package federation provides X.509 bundle source trusting the bundles of the federated SPIFFE trust domains
+package federation
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spiffe/go-spiffe/v2/bundle/spiffebundle"
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/federation"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/log"
+)
+
+// defaultRefresh is the bundle refresh period for the endpoints without refresh hint
+const defaultRefresh = 5 * time.Minute
+
+// Endpoint is a bundle endpoint of a federated trust domain
+type Endpoint struct {
+	TrustDomain spiffeid.TrustDomain
+	URL         string
+}
+
+// ParseEndpoints parses the "<trust domain>=<bundle endpoint URL>" entries
+func ParseEndpoints(entries []string) ([]Endpoint, error) {
+	var result []Endpoint
+	for _, entry := range entries {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		name, url, ok := strings.Cut(entry, "=")
+		if !ok || url == "" {
+			return nil, errors.Errorf("invalid federated trust domain %q: expected <trust domain>=<bundle endpoint URL>", entry)
+		}
+		td, err := spiffeid.TrustDomainFromString(name)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid federated trust domain %q", entry)
+		}
+		// TrustDomainFromString also accepts SPIFFE IDs, the bare trust domain name is expected here
+		if td.String() != name {
+			return nil, errors.Errorf("invalid federated trust domain %q: expected a trust domain name, not a SPIFFE ID", entry)
+		}
+		result = append(result, Endpoint{TrustDomain: td, URL: url})
+	}
+	return result, nil
+}
+
+// Source is an X.509 bundle source returning the federated bundles fetched from the bundle endpoints and the local
+// bundles for the rest of the trust domains
+type Source struct {
+	local   x509bundle.Source
+	bundles *spiffebundle.Set
+}
+
+// NewSource creates a new Source falling back to local
+func NewSource(local x509bundle.Source) *Source {
+	return &Source{
+		local:   local,
+		bundles: spiffebundle.NewSet(),
+	}
+}
+
+// GetX509BundleForTrustDomain returns the X.509 bundle for the trust domain
+func (s *Source) GetX509BundleForTrustDomain(trustDomain spiffeid.TrustDomain) (*x509bundle.Bundle, error) {
+	if bundle, ok := s.bundles.Get(trustDomain); ok {
+		return bundle.X509Bundle(), nil
+	}
+	return s.local.GetX509BundleForTrustDomain(trustDomain)
+}
+
+// Watch fetches the bundle from the endpoint and refreshes it until ctx is done. The endpoint is authenticated with
+// Web PKI and the system roots by default. Peers from the trust domain are rejected until the first fetch succeeds.
+func (s *Source) Watch(ctx context.Context, endpoint Endpoint, opts ...federation.FetchOption) {
+	logger := log.FromContext(ctx).WithField("federation", endpoint.TrustDomain.String())
+	_ = federation.WatchBundle(ctx, endpoint.TrustDomain, endpoint.URL, &watcher{
+		bundles: s.bundles,
+		logger:  logger,
+	}, opts...)
+}
+
+type watcher struct {
+	bundles *spiffebundle.Set
+	logger  log.Logger
+}
+
+func (w *watcher) NextRefresh(refreshHint time.Duration) time.Duration {
+	if refreshHint > 0 {
+		return refreshHint
+	}
+	return defaultRefresh
+}
+
+func (w *watcher) OnUpdate(bundle *spiffebundle.Bundle) {
+	w.bundles.Add(bundle)
+	w.logger.Infof("federated bundle updated: %d X.509 authorities", len(bundle.X509Authorities()))
+}
+
+func (w *watcher) OnError(err error) {
+	w.logger.Warnf("failed to fetch federated bundle: %s", err.Error())
+}
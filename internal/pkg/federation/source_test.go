@@ -0,0 +1,100 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package federation_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"math/big"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/bundle/spiffebundle"
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/federation"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/stretchr/testify/require"
+
+	federationsource "github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/federation"
+)
+
+func newAuthority(t *testing.T) *x509.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert
+}
+
+func TestParseEndpoints(t *testing.T) {
+	endpoints, err := federationsource.ParseEndpoints([]string{"example.org=https://spire.example.org:8443", " "})
+	require.NoError(t, err)
+	require.Equal(t, []federationsource.Endpoint{{
+		TrustDomain: spiffeid.RequireTrustDomainFromString("example.org"),
+		URL:         "https://spire.example.org:8443",
+	}}, endpoints)
+
+	_, err = federationsource.ParseEndpoints([]string{"example.org"})
+	require.Error(t, err)
+	_, err = federationsource.ParseEndpoints([]string{"spiffe://example.org/path=https://spire.example.org"})
+	require.Error(t, err)
+}
+
+func TestSource_Watch(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	local := spiffeid.RequireTrustDomainFromString("local.org")
+	remote := spiffeid.RequireTrustDomainFromString("remote.org")
+	localAuthority, remoteAuthority := newAuthority(t), newAuthority(t)
+
+	handler, err := federation.NewHandler(remote, spiffebundle.NewSet(
+		spiffebundle.FromX509Authorities(remote, []*x509.Certificate{remoteAuthority})))
+	require.NoError(t, err)
+	endpoint := httptest.NewTLSServer(handler)
+	defer endpoint.Close()
+	roots := x509.NewCertPool()
+	roots.AddCert(endpoint.Certificate())
+
+	source := federationsource.NewSource(x509bundle.NewSet(x509bundle.FromX509Authorities(local, []*x509.Certificate{localAuthority})))
+
+	bundle, err := source.GetX509BundleForTrustDomain(local)
+	require.NoError(t, err)
+	require.True(t, bundle.HasX509Authority(localAuthority))
+	_, err = source.GetX509BundleForTrustDomain(remote)
+	require.Error(t, err)
+
+	go source.Watch(ctx, federationsource.Endpoint{TrustDomain: remote, URL: endpoint.URL}, federation.WithWebPKIRoots(roots))
+
+	require.Eventually(t, func() bool {
+		b, getErr := source.GetX509BundleForTrustDomain(remote)
+		return getErr == nil && b.HasX509Authority(remoteAuthority)
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package handshake provides the server transport credentials recording the TLS handshakes, so the cost of the
+// reconnecting clients and the effect of the TLS session resumption are visible
+package handshake
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/log"
+)
+
+// Handshake results
+const (
+	ResultFull    = "full"
+	ResultResumed = "resumed"
+	ResultFailed  = "failed"
+)
+
+const resultAttribute = "result"
+
+// Counts are the numbers of the server handshakes by result
+type Counts map[string]int64
+
+type recordingCredentials struct {
+	credentials.TransportCredentials
+	*recorder
+}
+
+// recorder is shared by the clones of the credentials
+type recorder struct {
+	ctx        context.Context
+	handshakes metric.Int64Counter
+
+	mu        sync.Mutex
+	counts    Counts
+	total     int64
+	lastTotal int64
+	lastTime  time.Time
+}
+
+// Credentials are the transport credentials recording the server handshakes
+type Credentials interface {
+	credentials.TransportCredentials
+	// Counts returns the numbers of the server handshakes by result
+	Counts() Counts
+}
+
+// NewCredentials wraps the TLS transport credentials to record the server handshakes by result in the
+// registry.tls.handshakes counter and their rate per second in the registry.tls.handshake_rate gauge. ctx provides
+// the clock.
+func NewCredentials(ctx context.Context, creds credentials.TransportCredentials) Credentials {
+	c := &recordingCredentials{
+		TransportCredentials: creds,
+		recorder: &recorder{
+			ctx:      ctx,
+			counts:   make(Counts),
+			lastTime: clock.FromContext(ctx).Now(),
+		},
+	}
+
+	meter := otel.Meter("registry-memory")
+	var err error
+	if c.handshakes, err = meter.Int64Counter("registry.tls.handshakes",
+		metric.WithDescription("Number of the server TLS handshakes by result: full, resumed or failed")); err != nil {
+		log.L().Errorf("failed to create TLS handshakes counter: %s", err.Error())
+	}
+	if _, err = meter.Float64ObservableGauge("registry.tls.handshake_rate",
+		metric.WithUnit("1/s"),
+		metric.WithDescription("Rate of the server TLS handshakes since the previous observation"),
+		metric.WithFloat64Callback(c.observeRate)); err != nil {
+		log.L().Errorf("failed to create TLS handshake rate gauge: %s", err.Error())
+	}
+	return c
+}
+
+func (c *recordingCredentials) ServerHandshake(rawConn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	conn, authInfo, err := c.TransportCredentials.ServerHandshake(rawConn)
+
+	result := ResultFull
+	switch info, ok := authInfo.(credentials.TLSInfo); {
+	case err != nil:
+		result = ResultFailed
+	case ok && info.State.DidResume:
+		result = ResultResumed
+	}
+
+	c.mu.Lock()
+	c.counts[result]++
+	c.total++
+	c.mu.Unlock()
+
+	if c.handshakes != nil {
+		c.handshakes.Add(c.ctx, 1, metric.WithAttributes(attribute.String(resultAttribute, result)))
+	}
+	return conn, authInfo, err
+}
+
+func (c *recordingCredentials) Clone() credentials.TransportCredentials {
+	return &recordingCredentials{
+		TransportCredentials: c.TransportCredentials.Clone(),
+		recorder:             c.recorder,
+	}
+}
+
+func (c *recorder) Counts() Counts {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	counts := make(Counts, len(c.counts))
+	for result, count := range c.counts {
+		counts[result] = count
+	}
+	return counts
+}
+
+func (c *recorder) observeRate(_ context.Context, observer metric.Float64Observer) error {
+	now := clock.FromContext(c.ctx).Now()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elapsed := now.Sub(c.lastTime); elapsed > 0 {
+		observer.Observe(float64(c.total-c.lastTotal) / elapsed.Seconds())
+	}
+	c.lastTotal = c.total
+	c.lastTime = now
+	return nil
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handshake_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/handshake"
+)
+
+func selfSigned(t *testing.T) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "registry"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+func TestCredentials_Counts(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	creds := handshake.NewCredentials(ctx, credentials.NewTLS(&tls.Config{
+		Certificates: []tls.Certificate{selfSigned(t)},
+		MinVersion:   tls.VersionTLS12,
+	}))
+
+	// TLS 1.2 sends the session ticket within the handshake
+	clientConfig := &tls.Config{
+		InsecureSkipVerify: true, //nolint:gosec // the server certificate is self-signed
+		MaxVersion:         tls.VersionTLS12,
+		ClientSessionCache: tls.NewLRUClientSessionCache(1),
+	}
+	handshakeOnce := func(clientConfig *tls.Config) {
+		clientConn, serverConn := net.Pipe()
+		defer func() { _ = clientConn.Close() }()
+		defer func() { _ = serverConn.Close() }()
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			_, _, _ = creds.ServerHandshake(serverConn)
+		}()
+		_ = tls.Client(clientConn, clientConfig).Handshake()
+		_ = clientConn.Close()
+		<-done
+	}
+
+	handshakeOnce(clientConfig)
+	handshakeOnce(clientConfig)
+	handshakeOnce(&tls.Config{MinVersion: tls.VersionTLS12})
+
+	require.Equal(t, handshake.Counts{
+		handshake.ResultFull:    1,
+		handshake.ResultResumed: 1,
+		handshake.ResultFailed:  1,
+	}, creds.Counts())
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc/peer"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/peerid"
+)
+
+// LocalIdentity is the identity of the in-process callers, e.g. the admin API
+const LocalIdentity = "local"
+
+type historyNSEServer struct {
+	store *Store
+}
+
+// NewNetworkServiceEndpointRegistryServer creates a new NSE server chain element recording the successful
+// registrations and unregistrations in the store
+func NewNetworkServiceEndpointRegistryServer(store *Store) registry.NetworkServiceEndpointRegistryServer {
+	return &historyNSEServer{
+		store: store,
+	}
+}
+
+func (s *historyNSEServer) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*registry.NetworkServiceEndpoint, error) {
+	resp, err := next.NetworkServiceEndpointRegistryServer(ctx).Register(ctx, nse)
+	if err != nil {
+		return nil, err
+	}
+	s.store.Record(ctx, callerIdentity(ctx), OperationRegister, resp)
+	return resp, nil
+}
+
+func (s *historyNSEServer) Find(query *registry.NetworkServiceEndpointQuery, server registry.NetworkServiceEndpointRegistry_FindServer) error {
+	return next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, server)
+}
+
+func (s *historyNSEServer) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*empty.Empty, error) {
+	resp, err := next.NetworkServiceEndpointRegistryServer(ctx).Unregister(ctx, nse)
+	if err != nil {
+		return nil, err
+	}
+	s.store.Record(ctx, callerIdentity(ctx), OperationUnregister, nse)
+	return resp, nil
+}
+
+// callerIdentity returns the SPIFFE ID of the caller, or its address if it has no SPIFFE ID
+func callerIdentity(ctx context.Context) string {
+	if id, ok := peerid.FromContext(ctx); ok {
+		return id.String()
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		return p.Addr.String()
+	}
+	return LocalIdentity
+}
@@ -0,0 +1,236 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package history keeps the last revisions of the registered NSEs with the caller identity and the changes of each
+// revision
+package history
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+)
+
+// Operation is the registry operation making the revision
+type Operation string
+
+const (
+	// OperationRegister is set for the revisions made by Register
+	OperationRegister Operation = "register"
+	// OperationUnregister is set for the revisions made by Unregister
+	OperationUnregister Operation = "unregister"
+)
+
+// Change is the change of a field from the previous revision
+type Change struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// Revision is a revision of an NSE
+type Revision struct {
+	Time      time.Time
+	Identity  string
+	Operation Operation
+	NSE       *registry.NetworkServiceEndpoint
+	Changes   []Change
+	// Refreshes is the number of the following refreshes changing nothing but the expiration time
+	Refreshes int
+}
+
+func (r *Revision) nse() *registry.NetworkServiceEndpoint {
+	if r == nil {
+		return nil
+	}
+	return r.NSE
+}
+
+type entry struct {
+	revisions []*Revision
+	updated   time.Time
+}
+
+// Store keeps the last revisions of each NSE. A refresh by the same caller changing nothing but the expiration time is
+// not a new revision, it is counted by the last revision and updates its expiration time. The history of an NSE is removed after
+// the retention since its last change or refresh.
+type Store struct {
+	maxRevisions int
+	retention    time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*entry
+}
+
+// NewStore creates a new Store keeping maxRevisions revisions per NSE for retention
+func NewStore(maxRevisions int, retention time.Duration) *Store {
+	return &Store{
+		maxRevisions: maxRevisions,
+		retention:    retention,
+		entries:      make(map[string]*entry),
+	}
+}
+
+// Record records the operation on the NSE by the caller identity
+func (s *Store) Record(ctx context.Context, identity string, operation Operation, nse *registry.NetworkServiceEndpoint) {
+	now := clock.FromContext(ctx).Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[nse.GetName()]
+	if !ok {
+		e = new(entry)
+		s.entries[nse.GetName()] = e
+	}
+	e.updated = now
+
+	var changes []Change
+	if operation == OperationRegister {
+		var last *Revision
+		if len(e.revisions) > 0 && e.revisions[len(e.revisions)-1].Operation == OperationRegister {
+			last = e.revisions[len(e.revisions)-1]
+		}
+		changes = Diff(last.nse(), nse)
+		if last != nil && last.Identity == identity && onlyExpirationChanged(changes) {
+			last.NSE = nse.Clone()
+			last.Refreshes++
+			return
+		}
+	}
+
+	e.revisions = append(e.revisions, &Revision{
+		Time:      now,
+		Identity:  identity,
+		Operation: operation,
+		NSE:       nse.Clone(),
+		Changes:   changes,
+	})
+	if len(e.revisions) > s.maxRevisions {
+		e.revisions = append([]*Revision(nil), e.revisions[len(e.revisions)-s.maxRevisions:]...)
+	}
+}
+
+// Revisions returns the revisions of the NSE from the oldest to the newest
+func (s *Store) Revisions(name string) []*Revision {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	e, ok := s.entries[name]
+	if !ok {
+		return nil
+	}
+	result := make([]*Revision, 0, len(e.revisions))
+	for _, r := range e.revisions {
+		revision := *r
+		result = append(result, &revision)
+	}
+	return result
+}
+
+// Run removes the histories older than the retention every retention/2 until ctx is done
+func (s *Store) Run(ctx context.Context) {
+	period := s.retention / 2
+	if period < time.Second {
+		period = time.Second
+	}
+	ticker := clock.FromContext(ctx).Ticker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			s.gc(clock.FromContext(ctx).Now())
+		}
+	}
+}
+
+func (s *Store) gc(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for name, e := range s.entries {
+		if now.Sub(e.updated) > s.retention {
+			delete(s.entries, name)
+		}
+	}
+}
+
+// Diff returns the changes of the URL, the network service names, the labels and the expiration time from the old
+// to the new NSE. All the fields of the new NSE are changes if there is no old one.
+func Diff(old, nse *registry.NetworkServiceEndpoint) []Change {
+	var changes []Change
+	add := func(field, oldValue, newValue string) {
+		if oldValue != newValue {
+			changes = append(changes, Change{Field: field, Old: oldValue, New: newValue})
+		}
+	}
+
+	add("url", old.GetUrl(), nse.GetUrl())
+	add("network_service_names", fmt.Sprint(old.GetNetworkServiceNames()), fmt.Sprint(nse.GetNetworkServiceNames()))
+
+	labels := func(e *registry.NetworkServiceEndpoint) map[string]string {
+		result := make(map[string]string)
+		for service, l := range e.GetNetworkServiceLabels() {
+			for key, value := range l.GetLabels() {
+				result["labels."+service+"."+key] = value
+			}
+		}
+		return result
+	}
+	oldLabels, newLabels := labels(old), labels(nse)
+	var fields []string
+	for field := range oldLabels {
+		fields = append(fields, field)
+	}
+	for field := range newLabels {
+		if _, ok := oldLabels[field]; !ok {
+			fields = append(fields, field)
+		}
+	}
+	sort.Strings(fields)
+	for _, field := range fields {
+		add(field, oldLabels[field], newLabels[field])
+	}
+
+	add(expirationTimeField, formatTime(old), formatTime(nse))
+	return changes
+}
+
+const expirationTimeField = "expiration_time"
+
+func formatTime(nse *registry.NetworkServiceEndpoint) string {
+	if nse.GetExpirationTime() == nil {
+		return ""
+	}
+	return nse.GetExpirationTime().AsTime().UTC().Format(time.RFC3339Nano)
+}
+
+func onlyExpirationChanged(changes []Change) bool {
+	for _, c := range changes {
+		if c.Field != expirationTimeField {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package history_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clockmock"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/history"
+)
+
+func TestStore_Revisions(t *testing.T) {
+	clockMock := clockmock.New(context.Background())
+	ctx, cancel := context.WithCancel(clock.WithClock(context.Background(), clockMock))
+	defer cancel()
+
+	store := history.NewStore(2, time.Hour)
+	client := adapters.NetworkServiceEndpointServerToClient(next.NewNetworkServiceEndpointRegistryServer(
+		history.NewNetworkServiceEndpointRegistryServer(store),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+
+	register := func(url, color string) {
+		_, err := client.Register(ctx, &registry.NetworkServiceEndpoint{
+			Name:                "nse-1",
+			Url:                 url,
+			NetworkServiceNames: []string{"ns-1"},
+			NetworkServiceLabels: map[string]*registry.NetworkServiceLabels{
+				"ns-1": {Labels: map[string]string{"color": color}},
+			},
+			ExpirationTime: timestamppb.New(clockMock.Now().Add(time.Minute)),
+		})
+		require.NoError(t, err)
+	}
+
+	register("tcp://1.1.1.1:5000", "red")
+	start := clockMock.Now()
+
+	// The refreshes changing nothing but the expiration time are counted by the revision
+	clockMock.Add(10 * time.Second)
+	register("tcp://1.1.1.1:5000", "red")
+	clockMock.Add(10 * time.Second)
+	register("tcp://1.1.1.1:5000", "red")
+
+	revisions := store.Revisions("nse-1")
+	require.Len(t, revisions, 1)
+	require.Equal(t, history.OperationRegister, revisions[0].Operation)
+	require.Equal(t, history.LocalIdentity, revisions[0].Identity)
+	require.Equal(t, start, revisions[0].Time)
+	require.Equal(t, 2, revisions[0].Refreshes)
+	require.Equal(t, clockMock.Now().Add(time.Minute), revisions[0].NSE.GetExpirationTime().AsTime().Local())
+
+	clockMock.Add(10 * time.Second)
+	register("tcp://1.1.1.1:5000", "blue")
+
+	revisions = store.Revisions("nse-1")
+	require.Len(t, revisions, 2)
+	require.Equal(t, []history.Change{
+		{Field: "labels.ns-1.color", Old: "red", New: "blue"},
+		{
+			Field: "expiration_time",
+			Old:   clockMock.Now().Add(50 * time.Second).UTC().Format(time.RFC3339Nano),
+			New:   clockMock.Now().Add(time.Minute).UTC().Format(time.RFC3339Nano),
+		},
+	}, revisions[1].Changes)
+
+	// Only the last revisions are kept
+	_, err := client.Unregister(ctx, &registry.NetworkServiceEndpoint{Name: "nse-1"})
+	require.NoError(t, err)
+
+	revisions = store.Revisions("nse-1")
+	require.Len(t, revisions, 2)
+	require.Equal(t, history.OperationRegister, revisions[0].Operation)
+	require.Equal(t, "blue", revisions[0].NSE.GetNetworkServiceLabels()["ns-1"].GetLabels()["color"])
+	require.Equal(t, history.OperationUnregister, revisions[1].Operation)
+	require.Empty(t, revisions[1].Changes)
+}
+
+func TestStore_Retention(t *testing.T) {
+	clockMock := clockmock.New(context.Background())
+	ctx, cancel := context.WithCancel(clock.WithClock(context.Background(), clockMock))
+	defer cancel()
+
+	store := history.NewStore(10, time.Minute)
+	go store.Run(ctx)
+
+	store.Record(ctx, "spiffe://test.com/nse", history.OperationRegister, &registry.NetworkServiceEndpoint{Name: "nse-1"})
+	require.Len(t, store.Revisions("nse-1"), 1)
+
+	require.Eventually(t, func() bool {
+		clockMock.Add(30 * time.Second)
+		return len(store.Revisions("nse-1")) == 0
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,155 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package identity provides a reloadable mapping of the workload SPIFFE IDs to logical identity labels (team, tenant,
+// role, ...), so all the policy subsystems share one identity model
+package identity
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"regexp"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/peerid"
+)
+
+const (
+	// LabelTenant is the label overriding the caller tenant, which is the trust domain by default
+	LabelTenant = "tenant"
+	// LabelRole is the label granting the caller a role
+	LabelRole = "role"
+	// RoleAdmin is the role seeing and managing everything, same as the configured admin IDs
+	RoleAdmin = "admin"
+)
+
+type rule struct {
+	pattern *regexp.Regexp
+	labels  map[string]string
+}
+
+type fileRule struct {
+	SPIFFEID string            `json:"spiffe_id"`
+	Labels   map[string]string `json:"labels"`
+}
+
+// Mapping maps SPIFFE IDs to identity labels
+type Mapping struct {
+	mu    sync.RWMutex
+	rules []rule
+}
+
+// NewMapping creates a new empty mapping
+func NewMapping() *Mapping {
+	return new(Mapping)
+}
+
+// Load replaces the mapping content with the JSON file content:
+//
+//	[
+//	  {"spiffe_id": "spiffe://example.org/ns/team-a/.*", "labels": {"team": "a", "tenant": "a"}},
+//	  {"spiffe_id": "spiffe://example.org/ns/ops/sa/admin", "labels": {"role": "admin"}}
+//	]
+//
+// The spiffe_id is a regular expression matching the whole SPIFFE ID. The labels of all the matching rules are
+// merged in the file order, so the later rules win.
+func (m *Mapping) Load(filePath string) error {
+	loaded, err := LoadFile(filePath)
+	if err != nil {
+		return err
+	}
+	m.Set(loaded)
+	return nil
+}
+
+// LoadFile returns a new mapping with the JSON file content, see Load
+func LoadFile(filePath string) (*Mapping, error) {
+	// #nosec G304 - the file path comes from the configuration
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read identity mapping %s", filePath)
+	}
+	var fileRules []*fileRule
+	if err = json.Unmarshal(data, &fileRules); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse identity mapping %s", filePath)
+	}
+
+	rules := make([]rule, 0, len(fileRules))
+	for i, r := range fileRules {
+		pattern, compileErr := regexp.Compile("^(?:" + r.SPIFFEID + ")$")
+		if compileErr != nil {
+			return nil, errors.Wrapf(compileErr, "%s: rule %d: invalid spiffe_id", filePath, i)
+		}
+		rules = append(rules, rule{pattern: pattern, labels: r.Labels})
+	}
+	return &Mapping{rules: rules}, nil
+}
+
+// Set replaces the mapping content with the other mapping content, nil clears it
+func (m *Mapping) Set(other *Mapping) {
+	var rules []rule
+	if other != nil {
+		other.mu.RLock()
+		rules = other.rules
+		other.mu.RUnlock()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.rules = rules
+}
+
+// Clear removes all the rules
+func (m *Mapping) Clear() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.rules = nil
+}
+
+// Labels returns the labels of the SPIFFE ID, empty if no rules match
+func (m *Mapping) Labels(id spiffeid.ID) map[string]string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	labels := make(map[string]string)
+	for _, r := range m.rules {
+		if !r.pattern.MatchString(id.String()) {
+			continue
+		}
+		for key, value := range r.labels {
+			labels[key] = value
+		}
+	}
+	return labels
+}
+
+// FromContext returns the caller SPIFFE ID and its labels. A nil mapping maps to no labels.
+func (m *Mapping) FromContext(ctx context.Context) (spiffeid.ID, map[string]string, bool) {
+	id, ok := peerid.FromContext(ctx)
+	if !ok {
+		return spiffeid.ID{}, nil, false
+	}
+	if m == nil {
+		return id, map[string]string{}, true
+	}
+	return id, m.Labels(id), true
+}
@@ -0,0 +1,55 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package identity_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/identity"
+)
+
+func TestMapping_Labels(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "identities.json")
+	require.NoError(t, os.WriteFile(filePath, []byte(`[
+		{"spiffe_id": "spiffe://example.org/ns/team-a/.*", "labels": {"team": "a", "tenant": "a"}},
+		{"spiffe_id": "spiffe://example.org/ns/team-a/sa/lead", "labels": {"role": "admin", "tenant": "ops"}}
+	]`), 0o600))
+
+	m := identity.NewMapping()
+	require.NoError(t, m.Load(filePath))
+
+	require.Equal(t, map[string]string{"team": "a", "tenant": "a"},
+		m.Labels(spiffeid.RequireFromString("spiffe://example.org/ns/team-a/sa/nse")))
+	require.Equal(t, map[string]string{"team": "a", "tenant": "ops", "role": "admin"},
+		m.Labels(spiffeid.RequireFromString("spiffe://example.org/ns/team-a/sa/lead")))
+	require.Empty(t, m.Labels(spiffeid.RequireFromString("spiffe://example.org/ns/team-b/sa/nse")))
+	// The pattern matches the whole ID
+	require.Empty(t, m.Labels(spiffeid.RequireFromString("spiffe://other.org/ns/team-a/sa/nse")))
+
+	require.NoError(t, os.WriteFile(filePath, []byte(`[{"spiffe_id": "(", "labels": {}}]`), 0o600))
+	require.Error(t, m.Load(filePath))
+	// The failed load keeps the previous rules
+	require.NotEmpty(t, m.Labels(spiffeid.RequireFromString("spiffe://example.org/ns/team-a/sa/nse")))
+
+	m.Clear()
+	require.Empty(t, m.Labels(spiffeid.RequireFromString("spiffe://example.org/ns/team-a/sa/nse")))
+}
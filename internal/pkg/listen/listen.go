@@ -0,0 +1,121 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package listen provides the listen URLs parsing and expansion supporting IPv6 link-local addresses with zone IDs and
+// listening on the addresses of a named interface, and the unix listen sockets permissions
+package listen
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// URLs are the listen URLs decoded by envconfig from a comma separated list
+type URLs []url.URL
+
+// Decode parses the comma separated listen URLs, see ParseURL
+func (u *URLs) Decode(value string) error {
+	var urls URLs
+	for _, s := range strings.Split(value, ",") {
+		if s = strings.TrimSpace(s); s == "" {
+			continue
+		}
+		parsed, err := ParseURL(s)
+		if err != nil {
+			return err
+		}
+		urls = append(urls, *parsed)
+	}
+	*u = urls
+	return nil
+}
+
+func (u URLs) String() string {
+	s := make([]string, 0, len(u))
+	for i := range u {
+		s = append(s, u[i].String())
+	}
+	return strings.Join(s, ",")
+}
+
+// ParseURL parses the listen URL. The IPv6 zone ID may be written as is, e.g. tcp://[fe80::1%eth0]:5002, or escaped
+// as RFC 6874 requires, e.g. tcp://[fe80::1%25eth0]:5002. On windows the unix URL may hold a drive letter path, e.g.
+// unix:///C:/registry/registry.sock.
+func ParseURL(s string) (*url.URL, error) {
+	if start, end := strings.Index(s, "["), strings.Index(s, "]"); start >= 0 && end > start {
+		if zone := strings.Index(s[start:end], "%"); zone >= 0 && !strings.HasPrefix(s[start+zone:end], "%25") {
+			s = s[:start+zone] + "%25" + s[start+zone+1:]
+		}
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid listen URL %s", s)
+	}
+	if u.Scheme == "unix" {
+		u.Path = socketPath(u.Path)
+	}
+	return u, nil
+}
+
+// ExpandInterface returns the urls with the tcp URLs listening on all the addresses (empty, 0.0.0.0 or [::] host)
+// replaced by a URL per address of the named interface. IPv6 link-local addresses get the interface zone. The urls are
+// returned as is if name is empty.
+func ExpandInterface(urls []url.URL, name string) ([]url.URL, error) {
+	if name == "" {
+		return urls, nil
+	}
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid listen interface %s", name)
+	}
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to get the addresses of %s", name)
+	}
+	var ips []net.IPAddr
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		ip := net.IPAddr{IP: ipNet.IP}
+		if ip.IP.To4() == nil && ip.IP.IsLinkLocalUnicast() {
+			ip.Zone = iface.Name
+		}
+		ips = append(ips, ip)
+	}
+	if len(ips) == 0 {
+		return nil, errors.Errorf("listen interface %s has no addresses", name)
+	}
+
+	var result []url.URL
+	for i := range urls {
+		host, port, splitErr := net.SplitHostPort(urls[i].Host)
+		if urls[i].Scheme != "tcp" || splitErr != nil || (host != "" && !net.ParseIP(host).IsUnspecified()) {
+			result = append(result, urls[i])
+			continue
+		}
+		for j := range ips {
+			u := urls[i]
+			u.Host = net.JoinHostPort(ips[j].String(), port)
+			result = append(result, u)
+		}
+	}
+	return result, nil
+}
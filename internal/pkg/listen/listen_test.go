@@ -0,0 +1,83 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package listen_test
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/listen"
+)
+
+func TestURLs_Decode(t *testing.T) {
+	var urls listen.URLs
+	require.NoError(t, urls.Decode("tcp://[::]:5002, tcp://[fe80::1%eth0]:5002,tcp://[fe80::1%25eth1]:5002,unix:///listen.on.socket"))
+	require.Len(t, urls, 4)
+
+	require.Equal(t, "[::]:5002", urls[0].Host)
+	require.Equal(t, "[fe80::1%eth0]:5002", urls[1].Host)
+	require.Equal(t, "[fe80::1%eth1]:5002", urls[2].Host)
+	require.Equal(t, "/listen.on.socket", urls[3].Path)
+	require.Equal(t, "tcp://[::]:5002,tcp://[fe80::1%25eth0]:5002,tcp://[fe80::1%25eth1]:5002,unix:///listen.on.socket", urls.String())
+
+	require.Error(t, urls.Decode("tcp://%zz"))
+}
+
+func TestExpandInterface(t *testing.T) {
+	ifaces, err := net.Interfaces()
+	require.NoError(t, err)
+	var loopback string
+	for i := range ifaces {
+		if ifaces[i].Flags&net.FlagLoopback != 0 {
+			loopback = ifaces[i].Name
+		}
+	}
+	if loopback == "" {
+		t.Skip("no loopback interface")
+	}
+
+	urls := []url.URL{
+		{Scheme: "tcp", Host: "[::]:0"},
+		{Scheme: "tcp", Host: "10.0.0.1:5002"},
+		{Scheme: "unix", Path: "/listen.on.socket"},
+	}
+	expanded, err := listen.ExpandInterface(urls, loopback)
+	require.NoError(t, err)
+	require.Greater(t, len(expanded), 2)
+	require.Equal(t, urls[1:], expanded[len(expanded)-2:])
+
+	for i := range expanded[:len(expanded)-2] {
+		host, _, splitErr := net.SplitHostPort(expanded[i].Host)
+		require.NoError(t, splitErr)
+		require.True(t, net.ParseIP(host).IsLoopback(), host)
+
+		ln, listenErr := new(net.ListenConfig).Listen(context.Background(), "tcp", expanded[i].Host)
+		require.NoError(t, listenErr)
+		require.NoError(t, ln.Close())
+	}
+
+	_, err = listen.ExpandInterface(urls, "no-such-interface")
+	require.Error(t, err)
+
+	unchanged, err := listen.ExpandInterface(urls, "")
+	require.NoError(t, err)
+	require.Equal(t, urls, unchanged)
+}
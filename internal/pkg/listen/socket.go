@@ -0,0 +1,83 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package listen
+
+import (
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// SocketPermissions are the file mode and the owner of the unix listen sockets
+type SocketPermissions struct {
+	// Mode is the socket file mode, 0 keeps the mode
+	Mode os.FileMode
+	// UID is the socket owner user ID, -1 keeps the owner
+	UID int
+	// GID is the socket owner group ID, -1 keeps the group
+	GID int
+}
+
+// ParseSocketPermissions parses the octal file mode, e.g. 0660, and the <uid>[:<gid>] owner. Empty values keep the
+// socket mode and owner.
+func ParseSocketPermissions(mode, owner string) (*SocketPermissions, error) {
+	p := &SocketPermissions{UID: -1, GID: -1}
+	if mode != "" {
+		m, err := strconv.ParseUint(mode, 8, 32)
+		if err != nil || os.FileMode(m)&^os.ModePerm != 0 {
+			return nil, errors.Errorf("invalid listen socket mode %s, expected octal permissions, e.g. 0660", mode)
+		}
+		p.Mode = os.FileMode(m)
+	}
+	if err := checkSocketOwner(owner); err != nil {
+		return nil, err
+	}
+	if owner != "" {
+		uid, gid, hasGID := strings.Cut(owner, ":")
+		var err error
+		if p.UID, err = strconv.Atoi(uid); err != nil || p.UID < 0 {
+			return nil, errors.Errorf("invalid listen socket owner %s, expected <uid>[:<gid>]", owner)
+		}
+		if hasGID {
+			if p.GID, err = strconv.Atoi(gid); err != nil || p.GID < 0 {
+				return nil, errors.Errorf("invalid listen socket owner %s, expected <uid>[:<gid>]", owner)
+			}
+		}
+	}
+	return p, nil
+}
+
+// Apply sets the mode and the owner of the socket file of the unix URL u, other URLs are left as is
+func (p *SocketPermissions) Apply(u *url.URL) error {
+	if u.Scheme != "unix" {
+		return nil
+	}
+	if p.UID >= 0 || p.GID >= 0 {
+		if err := os.Chown(u.Path, p.UID, p.GID); err != nil {
+			return errors.Wrapf(err, "failed to change the owner of %s", u.Path)
+		}
+	}
+	if p.Mode != 0 {
+		if err := os.Chmod(u.Path, p.Mode); err != nil {
+			return errors.Wrapf(err, "failed to change the mode of %s", u.Path)
+		}
+	}
+	return nil
+}
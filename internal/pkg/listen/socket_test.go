@@ -0,0 +1,73 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+
+package listen_test
+
+import (
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/listen"
+)
+
+func TestParseSocketPermissions(t *testing.T) {
+	p, err := listen.ParseSocketPermissions("", "")
+	require.NoError(t, err)
+	require.Equal(t, &listen.SocketPermissions{UID: -1, GID: -1}, p)
+
+	p, err = listen.ParseSocketPermissions("0660", "1000:2000")
+	require.NoError(t, err)
+	require.Equal(t, &listen.SocketPermissions{Mode: 0o660, UID: 1000, GID: 2000}, p)
+
+	p, err = listen.ParseSocketPermissions("", "1000")
+	require.NoError(t, err)
+	require.Equal(t, &listen.SocketPermissions{UID: 1000, GID: -1}, p)
+
+	for _, mode := range []string{"rw", "0999", "17777"} {
+		_, err = listen.ParseSocketPermissions(mode, "")
+		require.Error(t, err, mode)
+	}
+	for _, owner := range []string{"root", "1000:", "-1", "1000:group"} {
+		_, err = listen.ParseSocketPermissions("", owner)
+		require.Error(t, err, owner)
+	}
+}
+
+func TestSocketPermissions_Apply(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "registry.sock")
+	listener, err := net.Listen("unix", path)
+	require.NoError(t, err)
+	defer func() { _ = listener.Close() }()
+
+	p, err := listen.ParseSocketPermissions("0600", strconv.Itoa(os.Getuid())+":"+strconv.Itoa(os.Getgid()))
+	require.NoError(t, err)
+	require.NoError(t, p.Apply(&url.URL{Scheme: "unix", Path: path}))
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	// Only the unix sockets are changed
+	require.NoError(t, p.Apply(&url.URL{Scheme: "tcp", Host: "127.0.0.1:5002"}))
+}
@@ -0,0 +1,40 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+
+package listen
+
+import (
+	"github.com/pkg/errors"
+)
+
+// socketPath drops the leading slash of the drive letter paths, so unix:///C:/registry/registry.sock listens on
+// C:/registry/registry.sock
+func socketPath(path string) string {
+	if len(path) >= 3 && path[0] == '/' && path[2] == ':' &&
+		('a' <= path[1] && path[1] <= 'z' || 'A' <= path[1] && path[1] <= 'Z') {
+		return path[1:]
+	}
+	return path
+}
+
+func checkSocketOwner(owner string) error {
+	if owner != "" {
+		return errors.Errorf("invalid listen socket owner %s: changing the socket owner is not supported on windows", owner)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build windows
+
+package listen_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/listen"
+)
+
+func TestParseURL_WindowsSocketPath(t *testing.T) {
+	u, err := listen.ParseURL("unix:///C:/registry/registry.sock")
+	require.NoError(t, err)
+	require.Equal(t, "C:/registry/registry.sock", u.Path)
+
+	u, err = listen.ParseURL("unix:///listen.on.socket")
+	require.NoError(t, err)
+	require.Equal(t, "/listen.on.socket", u.Path)
+}
+
+func TestParseSocketPermissions_WindowsOwner(t *testing.T) {
+	_, err := listen.ParseSocketPermissions("0600", "")
+	require.NoError(t, err)
+
+	_, err = listen.ParseSocketPermissions("", "1000:2000")
+	require.Error(t, err)
+}
@@ -0,0 +1,211 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package natspublisher provides mirroring of the registry events onto a NATS subject with the NATS Go client: the
+// user and password or the token of the URL authenticate the connection, TLS is used for the tls:// URLs and the
+// servers requiring it, and the client reconnects on its own once connected. Kafka is not supported, the subject can be
+// bridged to a Kafka topic with the NATS Kafka connector.
+//
+// Each event is published as a JSON message:
+//
+//	{
+//	    "type": "nse.registered",                    // nse.registered, nse.refreshed, nse.expired, nse.unregistered,
+//	                                                 // ns.registered, ns.updated, ns.unregistered, ns.collected,
+//	                                                 // registry.started, registry.shutting-down,
+//	                                                 // registry.restored-from-snapshot
+//	    "time": "2023-07-17T07:07:59.123456789Z",    // RFC 3339 UTC
+//	    "attributes": {                              // depends on the type, for the NSE events:
+//	        "name": "nse-1",
+//	        "url": "tcp://10.0.0.1:5001",
+//	        "network_service_names": "ns-1,ns-2",
+//	        "expiration_time": "2023-07-17T07:08:59Z"
+//	    }
+//	}
+package natspublisher
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/nats-io/nats.go"
+	"github.com/pkg/errors"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/log"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/events"
+)
+
+const (
+	dialTimeout    = 5 * time.Second
+	reconnectDelay = time.Second
+	clientName     = "registry-memory"
+)
+
+// Option is an option for the Publisher
+type Option func(p *Publisher)
+
+// WithTLSConfig sets the TLS config of the connections to the servers requiring TLS or to the tls:// URLs, default is
+// the system roots
+func WithTLSConfig(tlsConfig *tls.Config) Option {
+	return func(p *Publisher) {
+		p.tlsConfig = tlsConfig
+	}
+}
+
+// LoadTLSConfig loads the TLS config of the connections from the PEM files: the CA certificates verifying the server,
+// the system roots if empty, and the client certificate and key, none if empty. It returns nil if no file is set.
+func LoadTLSConfig(caFile, certFile, keyFile string) (*tls.Config, error) {
+	if caFile == "" && certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+	if caFile != "" {
+		data, err := os.ReadFile(caFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to read NATS CA file %s", caFile)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(data) {
+			return nil, errors.Errorf("invalid NATS CA file %s: no PEM certificates", caFile)
+		}
+	}
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid NATS client certificate")
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+	return tlsConfig, nil
+}
+
+// Publisher publishes the events to the NATS subject. Events published before the first connection or while it is
+// failing are dropped, the ones published while the client reconnects are buffered by it.
+type Publisher struct {
+	url       string
+	subject   string
+	tlsConfig *tls.Config
+}
+
+// NewPublisher creates a new Publisher to the nats://[user:password@]host[:port], nats://token@host[:port] or
+// tls://... server URL. The subject must be valid, see ValidateSubject.
+func NewPublisher(serverURL *url.URL, subject string, opts ...Option) *Publisher {
+	p := &Publisher{
+		url:     serverURL.String(),
+		subject: subject,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// ValidateSubject returns an error if the subject is not a valid NATS subject to publish on: the dot separated
+// non-empty tokens with no whitespace, control characters or wildcards
+func ValidateSubject(subject string) error {
+	if subject == "" {
+		return errors.New("invalid NATS subject: empty")
+	}
+	for _, token := range strings.Split(subject, ".") {
+		if token == "" {
+			return errors.Errorf("invalid NATS subject %q: empty token", subject)
+		}
+		if token == "*" || token == ">" {
+			return errors.Errorf("invalid NATS subject %q: wildcards are not allowed", subject)
+		}
+		for _, r := range token {
+			if unicode.IsSpace(r) || unicode.IsControl(r) {
+				return errors.Errorf("invalid NATS subject %q: whitespace and control characters are not allowed", subject)
+			}
+		}
+	}
+	return nil
+}
+
+// Run publishes the events until the channel is closed, then flushes the published ones and closes the connection.
+// The events are read till the channel is closed even once ctx is done, so the events of the shutdown, e.g.
+// registry.shutting-down, are published too. ctx provides the logger and the clock.
+func (p *Publisher) Run(ctx context.Context, ch <-chan events.Event) {
+	logger := log.FromContext(ctx).WithField("nats", p.subject)
+
+	var nc *nats.Conn
+	defer func() {
+		if nc == nil {
+			return
+		}
+		if nc.IsConnected() {
+			if err := nc.FlushTimeout(dialTimeout); err != nil {
+				logger.Warnf("failed to flush the events: %s", err.Error())
+			}
+		}
+		nc.Close()
+	}()
+	var lastAttempt time.Time
+	for event := range ch {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			logger.Errorf("failed to marshal %s event: %s", event.Type, err.Error())
+			continue
+		}
+		if nc == nil {
+			if clock.FromContext(ctx).Since(lastAttempt) < reconnectDelay {
+				logger.Warnf("not connected, dropping %s event", event.Type)
+				continue
+			}
+			lastAttempt = clock.FromContext(ctx).Now()
+			if nc, err = p.connect(logger); err != nil {
+				logger.Warnf("failed to connect, dropping %s event: %s", event.Type, err.Error())
+				continue
+			}
+		}
+		if err = nc.Publish(p.subject, payload); err != nil {
+			logger.Warnf("failed to publish %s event: %s", event.Type, err.Error())
+		}
+	}
+}
+
+func (p *Publisher) connect(logger log.Logger) (*nats.Conn, error) {
+	nc, err := nats.Connect(p.url,
+		nats.Name(clientName),
+		nats.Timeout(dialTimeout),
+		nats.MaxReconnects(-1),
+		nats.ReconnectWait(reconnectDelay),
+		// The TLS config is set without requiring TLS, so it is used only when the URL or the server asks for TLS
+		func(o *nats.Options) error {
+			o.TLSConfig = p.tlsConfig
+			return nil
+		},
+		nats.DisconnectErrHandler(func(_ *nats.Conn, disconnectErr error) {
+			if disconnectErr != nil {
+				logger.Warnf("connection lost: %s", disconnectErr.Error())
+			}
+		}),
+		nats.ErrorHandler(func(_ *nats.Conn, _ *nats.Subscription, asyncErr error) {
+			logger.Warnf("server error: %s", asyncErr.Error())
+		}))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to connect to the NATS server")
+	}
+	return nc, nil
+}
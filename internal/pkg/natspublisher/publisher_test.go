@@ -0,0 +1,155 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package natspublisher_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/events"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/natspublisher"
+)
+
+func TestPublisher_Run(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = listener.Close() }()
+
+	type message struct {
+		connect string
+		subject string
+		payload []byte
+	}
+	received := make(chan message, 1)
+	go func() {
+		c, acceptErr := listener.Accept()
+		if acceptErr != nil {
+			return
+		}
+		defer func() { _ = c.Close() }()
+		_, _ = c.Write([]byte("INFO {\"server_id\":\"test\",\"max_payload\":1048576}\r\n"))
+
+		reader := bufio.NewReader(c)
+		var m message
+		for {
+			line, readErr := reader.ReadString('\n')
+			if readErr != nil {
+				return
+			}
+			fields := strings.Fields(line)
+			switch fields[0] {
+			case "CONNECT":
+				m.connect = strings.TrimSpace(strings.TrimPrefix(line, "CONNECT"))
+			case "PING":
+				_, _ = c.Write([]byte("PONG\r\n"))
+			case "PUB":
+				size, _ := strconv.Atoi(fields[2])
+				m.subject = fields[1]
+				m.payload = make([]byte, size+2)
+				_, _ = io.ReadFull(reader, m.payload)
+				m.payload = m.payload[:size]
+				received <- m
+			}
+		}
+	}()
+
+	ch := make(chan events.Event, 1)
+	ch <- events.New(events.TypeNSERegistered, map[string]string{"name": "nse-1"})
+	close(ch)
+
+	serverURL := &url.URL{Scheme: "nats", Host: listener.Addr().String(), User: url.UserPassword("user", "secret")}
+	natspublisher.NewPublisher(serverURL, "registry.events").Run(ctx, ch)
+
+	m := <-received
+	require.Equal(t, "registry.events", m.subject)
+	require.Contains(t, m.connect, `"user":"user"`)
+	require.Contains(t, m.connect, `"pass":"secret"`)
+
+	var event events.Event
+	require.NoError(t, json.Unmarshal(m.payload, &event))
+	require.Equal(t, events.TypeNSERegistered, event.Type)
+	require.Equal(t, "nse-1", event.Attributes["name"])
+}
+
+func TestPublisher_AuthorizationViolation(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = listener.Close() }()
+
+	connects := make(chan string, 1)
+	published := make(chan struct{}, 1)
+	go func() {
+		c, acceptErr := listener.Accept()
+		if acceptErr != nil {
+			return
+		}
+		defer func() { _ = c.Close() }()
+		_, _ = c.Write([]byte("INFO {\"server_id\":\"test\",\"max_payload\":1048576,\"auth_required\":true}\r\n"))
+
+		reader := bufio.NewReader(c)
+		for {
+			line, readErr := reader.ReadString('\n')
+			if readErr != nil {
+				return
+			}
+			switch strings.Fields(line)[0] {
+			case "CONNECT":
+				connects <- strings.TrimSpace(strings.TrimPrefix(line, "CONNECT"))
+			case "PING":
+				_, _ = c.Write([]byte("-ERR 'Authorization Violation'\r\n"))
+				return
+			case "PUB":
+				published <- struct{}{}
+			}
+		}
+	}()
+
+	ch := make(chan events.Event, 1)
+	ch <- events.New(events.TypeNSERegistered, map[string]string{"name": "nse-1"})
+	close(ch)
+
+	// The user without password is the token
+	serverURL := &url.URL{Scheme: "nats", Host: listener.Addr().String(), User: url.User("token")}
+	natspublisher.NewPublisher(serverURL, "registry.events").Run(ctx, ch)
+
+	require.Contains(t, <-connects, `"auth_token":"token"`)
+	require.Empty(t, published)
+}
+
+func TestValidateSubject(t *testing.T) {
+	require.NoError(t, natspublisher.ValidateSubject("registry-memory.events"))
+
+	for _, subject := range []string{"", "registry events", "registry.events\r\nPUB x 1", "registry..events", "registry.*", "registry.>", "registry.events."} {
+		require.Error(t, natspublisher.ValidateSubject(subject), subject)
+	}
+}
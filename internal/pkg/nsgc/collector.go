@@ -0,0 +1,152 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package nsgc provides the garbage collection of the network services having no NSEs
+package nsgc
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/log"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/events"
+)
+
+type options struct {
+	bus *events.Bus
+}
+
+// Option is an option for the Collector
+type Option func(o *options)
+
+// WithBus sets the bus to publish the events.TypeNSCollected events to
+func WithBus(bus *events.Bus) Option {
+	return func(o *options) {
+		o.bus = bus
+	}
+}
+
+// Collector unregisters the network services having no NSEs for the retention
+type Collector struct {
+	options
+	nsClient   registry.NetworkServiceRegistryClient
+	nseClient  registry.NetworkServiceEndpointRegistryClient
+	retention  time.Duration
+	emptySince map[string]time.Time
+	collected  metric.Int64Counter
+}
+
+// NewCollector creates a new Collector finding and unregistering the network services with nsClient and finding the
+// NSEs with nseClient
+func NewCollector(
+	nsClient registry.NetworkServiceRegistryClient,
+	nseClient registry.NetworkServiceEndpointRegistryClient,
+	retention time.Duration,
+	opts ...Option,
+) *Collector {
+	c := &Collector{
+		nsClient:   nsClient,
+		nseClient:  nseClient,
+		retention:  retention,
+		emptySince: make(map[string]time.Time),
+	}
+	for _, opt := range opts {
+		opt(&c.options)
+	}
+	collected, err := otel.Meter("registry-memory").Int64Counter("registry.ns.collected",
+		metric.WithDescription("Number of the network services with no NSEs unregistered by the garbage collection"))
+	if err != nil {
+		log.L().Errorf("failed to create NS collected counter: %s", err.Error())
+	}
+	c.collected = collected
+	return c
+}
+
+// Run collects the network services every half of the retention until ctx is done, so a network service is
+// unregistered after having no NSEs for 1 to 1.5 retentions
+func (c *Collector) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-clock.FromContext(ctx).After(c.retention / 2):
+			c.Collect(ctx)
+		}
+	}
+}
+
+// Collect unregisters the network services seen with no NSEs for the retention by the previous calls. A network service
+// failed to unregister, e.g. a protected one, is kept for another retention. It must not be called concurrently.
+func (c *Collector) Collect(ctx context.Context) {
+	logger := log.FromContext(ctx).WithField("nsgc", "Collect")
+	now := clock.FromContext(ctx).Now()
+
+	nsStream, err := c.nsClient.Find(ctx, &registry.NetworkServiceQuery{NetworkService: new(registry.NetworkService)})
+	if err != nil {
+		logger.Warnf("failed to find network services: %s", err.Error())
+		return
+	}
+	nss := registry.ReadNetworkServiceList(nsStream)
+	nseStream, err := c.nseClient.Find(ctx, &registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: new(registry.NetworkServiceEndpoint)})
+	if err != nil {
+		logger.Warnf("failed to find NSEs: %s", err.Error())
+		return
+	}
+	endpoints := make(map[string]int)
+	for _, nse := range registry.ReadNetworkServiceEndpointList(nseStream) {
+		for _, name := range nse.GetNetworkServiceNames() {
+			endpoints[name]++
+		}
+	}
+
+	emptySince := make(map[string]time.Time)
+	for _, ns := range nss {
+		if endpoints[ns.GetName()] > 0 {
+			continue
+		}
+		since, ok := c.emptySince[ns.GetName()]
+		if !ok {
+			since = now
+		}
+		if now.Sub(since) < c.retention {
+			emptySince[ns.GetName()] = since
+			continue
+		}
+		if _, err := c.nsClient.Unregister(ctx, ns); err != nil {
+			logger.Debugf("failed to unregister network service %s with no NSEs: %s", ns.GetName(), err.Error())
+			emptySince[ns.GetName()] = now
+			continue
+		}
+		logger.Infof("unregistered network service %s with no NSEs since %s", ns.GetName(), since.Format(time.RFC3339))
+		if c.collected != nil {
+			c.collected.Add(ctx, 1)
+		}
+		if c.bus != nil {
+			c.bus.Publish(ctx, events.New(events.TypeNSCollected, map[string]string{
+				"name":        ns.GetName(),
+				"empty_since": since.UTC().Format(time.RFC3339Nano),
+			}))
+		}
+	}
+	c.emptySince = emptySince
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nsgc_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/chain"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clockmock"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/events"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/nsgc"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/serviceoverrides"
+)
+
+func TestCollector_Collect(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	clockMock := clockmock.New(ctx)
+	ctx = clock.WithClock(ctx, clockMock)
+
+	nsClient := adapters.NetworkServiceServerToClient(chain.NewNetworkServiceRegistryServer(
+		serviceoverrides.NewNetworkServiceRegistryServer(serviceoverrides.Overrides{
+			"protected": {Protected: true},
+		}),
+		memory.NewNetworkServiceRegistryServer(),
+	))
+	nseClient := adapters.NetworkServiceEndpointServerToClient(memory.NewNetworkServiceEndpointRegistryServer())
+
+	for _, name := range []string{"served", "empty", "protected"} {
+		_, err := nsClient.Register(ctx, &registry.NetworkService{Name: name})
+		require.NoError(t, err)
+	}
+	_, err := nseClient.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse", NetworkServiceNames: []string{"served"}})
+	require.NoError(t, err)
+
+	bus := events.NewBus()
+	collected := bus.Subscribe(10)
+	collector := nsgc.NewCollector(nsClient, nseClient, time.Minute, nsgc.WithBus(bus))
+
+	names := func() []string {
+		stream, findErr := nsClient.Find(ctx, &registry.NetworkServiceQuery{NetworkService: new(registry.NetworkService)})
+		require.NoError(t, findErr)
+		var result []string
+		for _, ns := range registry.ReadNetworkServiceList(stream) {
+			result = append(result, ns.GetName())
+		}
+		return result
+	}
+
+	collector.Collect(ctx)
+	clockMock.Add(30 * time.Second)
+	collector.Collect(ctx)
+	require.ElementsMatch(t, []string{"served", "empty", "protected"}, names())
+
+	clockMock.Add(30 * time.Second)
+	collector.Collect(ctx)
+	require.ElementsMatch(t, []string{"served", "protected"}, names())
+
+	event := <-collected
+	require.Equal(t, events.TypeNSCollected, event.Type)
+	require.Equal(t, "empty", event.Attributes["name"])
+	require.Empty(t, collected)
+}
@@ -0,0 +1,176 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package nsstats provides per network service statistics of the registered NSEs
+package nsstats
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/log"
+)
+
+// Stats are the statistics of a network service
+type Stats struct {
+	NetworkService string
+	// Endpoints is the number of the registered NSEs
+	Endpoints int
+	// OldestRegistration and NewestRegistration are the first registration times of the NSEs
+	OldestRegistration time.Time
+	NewestRegistration time.Time
+	// AverageRemaining is the average time left until the NSEs with expiration time expire
+	AverageRemaining time.Duration
+	// LastModified is the last time a NSE of the network service was registered, changed or removed. Refreshes
+	// changing only the expiration time are not modifications.
+	LastModified time.Time
+}
+
+type entry struct {
+	nse          *registry.NetworkServiceEndpoint
+	registeredAt time.Time
+}
+
+// Tracker tracks the registration and modification times of the NSEs
+type Tracker struct {
+	mu           sync.Mutex
+	entries      map[string]*entry
+	lastModified map[string]time.Time
+}
+
+// NewTracker creates a new Tracker
+func NewTracker() *Tracker {
+	return &Tracker{
+		entries:      make(map[string]*entry),
+		lastModified: make(map[string]time.Time),
+	}
+}
+
+// Run watches the NSEs with the client and tracks them until ctx is done
+func (t *Tracker) Run(ctx context.Context, client registry.NetworkServiceEndpointRegistryClient) {
+	for ctx.Err() == nil {
+		stream, err := client.Find(ctx, &registry.NetworkServiceEndpointQuery{
+			NetworkServiceEndpoint: new(registry.NetworkServiceEndpoint),
+			Watch:                  true,
+		})
+		if err != nil {
+			log.FromContext(ctx).Warnf("failed to watch NSEs for statistics: %s", err.Error())
+			select {
+			case <-ctx.Done():
+			case <-clock.FromContext(ctx).After(time.Second):
+			}
+			continue
+		}
+		for resp := range registry.ReadNetworkServiceEndpointChannel(stream) {
+			t.update(clock.FromContext(ctx).Now(), resp)
+		}
+	}
+}
+
+func (t *Tracker) update(now time.Time, resp *registry.NetworkServiceEndpointResponse) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	nse := resp.GetNetworkServiceEndpoint()
+	e, ok := t.entries[nse.GetName()]
+	if resp.GetDeleted() {
+		if ok {
+			delete(t.entries, nse.GetName())
+			t.modified(now, e.nse)
+		}
+		return
+	}
+	if !ok {
+		t.entries[nse.GetName()] = &entry{nse: proto.Clone(nse).(*registry.NetworkServiceEndpoint), registeredAt: now}
+		t.modified(now, nse)
+		return
+	}
+	if !equalIgnoringExpiration(e.nse, nse) {
+		// Both the old and the new network services are modified
+		t.modified(now, e.nse)
+		t.modified(now, nse)
+	}
+	e.nse = proto.Clone(nse).(*registry.NetworkServiceEndpoint)
+}
+
+func (t *Tracker) modified(now time.Time, nse *registry.NetworkServiceEndpoint) {
+	for _, name := range nse.GetNetworkServiceNames() {
+		t.lastModified[name] = now
+	}
+}
+
+func equalIgnoringExpiration(a, b *registry.NetworkServiceEndpoint) bool {
+	a = proto.Clone(a).(*registry.NetworkServiceEndpoint)
+	b = proto.Clone(b).(*registry.NetworkServiceEndpoint)
+	a.ExpirationTime, b.ExpirationTime = nil, nil
+	return proto.Equal(a, b)
+}
+
+// Stats returns the statistics of the network services having registered NSEs or modified ones, sorted by name
+func (t *Tracker) Stats(now time.Time) []*Stats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	byName := make(map[string]*Stats)
+	get := func(name string) *Stats {
+		s, ok := byName[name]
+		if !ok {
+			s = &Stats{NetworkService: name, LastModified: t.lastModified[name]}
+			byName[name] = s
+		}
+		return s
+	}
+	remaining := make(map[string]time.Duration)
+	expiring := make(map[string]int)
+	for _, e := range t.entries {
+		for _, name := range e.nse.GetNetworkServiceNames() {
+			s := get(name)
+			s.Endpoints++
+			if s.OldestRegistration.IsZero() || e.registeredAt.Before(s.OldestRegistration) {
+				s.OldestRegistration = e.registeredAt
+			}
+			if e.registeredAt.After(s.NewestRegistration) {
+				s.NewestRegistration = e.registeredAt
+			}
+			if e.nse.GetExpirationTime() != nil {
+				remaining[name] += e.nse.GetExpirationTime().AsTime().Sub(now)
+				expiring[name]++
+			}
+		}
+	}
+	for name := range t.lastModified {
+		get(name)
+	}
+
+	result := make([]*Stats, 0, len(byName))
+	for name, s := range byName {
+		if expiring[name] > 0 {
+			s.AverageRemaining = remaining[name] / time.Duration(expiring[name])
+		}
+		result = append(result, s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].NetworkService < result[j].NetworkService
+	})
+	return result
+}
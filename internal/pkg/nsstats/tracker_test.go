@@ -0,0 +1,90 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nsstats_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clockmock"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/nsstats"
+)
+
+func TestTracker_Stats(t *testing.T) {
+	clockMock := clockmock.New(context.Background())
+	ctx, cancel := context.WithTimeout(clock.WithClock(context.Background(), clockMock), 5*time.Second)
+	defer cancel()
+
+	client := adapters.NetworkServiceEndpointServerToClient(memory.NewNetworkServiceEndpointRegistryServer())
+	tracker := nsstats.NewTracker()
+	go tracker.Run(ctx, client)
+
+	start := clockMock.Now()
+	register := func(name string, expiresIn time.Duration, names ...string) {
+		_, err := client.Register(ctx, &registry.NetworkServiceEndpoint{
+			Name:                name,
+			NetworkServiceNames: names,
+			ExpirationTime:      timestamppb.New(clockMock.Now().Add(expiresIn)),
+		})
+		require.NoError(t, err)
+	}
+	endpoints := func(name string) int {
+		for _, s := range tracker.Stats(clockMock.Now()) {
+			if s.NetworkService == name {
+				return s.Endpoints
+			}
+		}
+		return 0
+	}
+
+	register("nse-1", time.Minute, "ns-1")
+	require.Eventually(t, func() bool { return endpoints("ns-1") == 1 }, time.Second, 10*time.Millisecond)
+
+	clockMock.Add(10 * time.Second)
+	register("nse-2", 3*time.Minute, "ns-1", "ns-2")
+	require.Eventually(t, func() bool { return endpoints("ns-2") == 1 }, time.Second, 10*time.Millisecond)
+
+	// Refresh is not a modification
+	clockMock.Add(10 * time.Second)
+	register("nse-1", time.Minute, "ns-1")
+	require.Eventually(t, func() bool {
+		stats := tracker.Stats(clockMock.Now())
+		return stats[0].AverageRemaining == 115*time.Second
+	}, time.Second, 10*time.Millisecond)
+
+	stats := tracker.Stats(clockMock.Now())
+	require.Len(t, stats, 2)
+	require.Equal(t, "ns-1", stats[0].NetworkService)
+	require.Equal(t, 2, stats[0].Endpoints)
+	require.Equal(t, start, stats[0].OldestRegistration)
+	require.Equal(t, start.Add(10*time.Second), stats[0].NewestRegistration)
+	require.Equal(t, start.Add(10*time.Second), stats[0].LastModified)
+
+	_, err := client.Unregister(ctx, &registry.NetworkServiceEndpoint{Name: "nse-2"})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool { return endpoints("ns-2") == 0 }, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,184 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package outboundcreds provides the client credentials per outbound peer: a dedicated certificate and CA, and the JWT
+// audience of the tokens sent to the peer. The peers not listed get the default credentials.
+package outboundcreds
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"net/url"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/pkg/errors"
+	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/grpcutils"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/spiffejwt"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/token"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/tlssource"
+)
+
+// Peer is the client credentials of an outbound peer
+type Peer struct {
+	// Target is the gRPC target of the peer URL
+	Target string
+	// CertFile, KeyFile and CAFile are the PEM files of the dedicated client certificate with a SPIFFE ID URI SAN, its
+	// key and the CA certificates trusted for the peer. The default transport credentials are used if not set.
+	CertFile string
+	KeyFile  string
+	CAFile   string
+	// Audience is the audience of the tokens sent to the peer, the SPIFFE ID of the peer certificate if not set
+	Audience []string
+}
+
+type filePeer struct {
+	CertFile string   `json:"cert_file"`
+	KeyFile  string   `json:"key_file"`
+	CAFile   string   `json:"ca_file"`
+	Audience []string `json:"audience"`
+}
+
+// LoadFile reads the peers from the JSON file keyed by the peer URLs:
+//
+//	{
+//	  "tcp://proxy.partner.example:5005": {"cert_file": "partner.pem", "key_file": "partner-key.pem", "ca_file": "partner-ca.pem"},
+//	  "tcp://proxy.other.example:5005": {"audience": ["spiffe://other.example/registry"]}
+//	}
+func LoadFile(filePath string) ([]*Peer, error) {
+	// #nosec G304 - the file path comes from the configuration
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read peer credentials %s", filePath)
+	}
+
+	var filePeers map[string]*filePeer
+	if err = json.Unmarshal(data, &filePeers); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse peer credentials %s", filePath)
+	}
+
+	peers := make([]*Peer, 0, len(filePeers))
+	for rawURL, p := range filePeers {
+		u, parseErr := url.Parse(rawURL)
+		if parseErr != nil || u.Scheme == "" {
+			return nil, errors.Errorf("invalid peer URL %q in %s", rawURL, filePath)
+		}
+		files := 0
+		for _, file := range []string{p.CertFile, p.KeyFile, p.CAFile} {
+			if file != "" {
+				files++
+			}
+		}
+		if files != 0 && files != 3 {
+			return nil, errors.Errorf("invalid peer %s credentials: cert_file, key_file and ca_file are set together", rawURL)
+		}
+		peers = append(peers, &Peer{
+			Target:   grpcutils.URLToTarget(u),
+			CertFile: p.CertFile,
+			KeyFile:  p.KeyFile,
+			CAFile:   p.CAFile,
+			Audience: p.Audience,
+		})
+	}
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].Target < peers[j].Target
+	})
+	return peers, nil
+}
+
+// Defaults are the defaults of the peer credentials
+type Defaults struct {
+	SVIDSource       x509svid.Source
+	MaxTokenLifetime time.Duration
+	// WrapCredentials wraps the peer transport credentials, e.g. to pass file descriptors
+	WrapCredentials func(credentials.TransportCredentials) credentials.TransportCredentials
+}
+
+// DialOptions returns the dial options setting the peer credentials. They are to follow the default dial options to
+// override their transport and per RPC credentials. The certificate files are reloaded on change until ctx is done.
+func (p *Peer) DialOptions(ctx context.Context, defaults *Defaults) ([]grpc.DialOption, error) {
+	var opts []grpc.DialOption
+	svidSource := defaults.SVIDSource
+	if p.CertFile != "" {
+		source, err := tlssource.NewFileSource(ctx, p.CertFile, p.KeyFile, p.CAFile)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid peer %s credentials", p.Target)
+		}
+		svidSource = source
+
+		tlsConfig := tlsconfig.MTLSClientConfig(source, source, tlsconfig.AuthorizeAny())
+		tlsConfig.MinVersion = tls.VersionTLS12
+		transportCredentials := credentials.NewTLS(tlsConfig)
+		if defaults.WrapCredentials != nil {
+			transportCredentials = defaults.WrapCredentials(transportCredentials)
+		}
+		opts = append(opts, grpc.WithTransportCredentials(transportCredentials))
+	}
+	if p.CertFile != "" || len(p.Audience) > 0 {
+		opts = append(opts, grpc.WithDefaultCallOptions(
+			grpc.PerRPCCredentials(token.NewPerRPCCredentials(tokenGenerator(svidSource, defaults.MaxTokenLifetime, p.Audience)))))
+	}
+	return opts, nil
+}
+
+// tokenGenerator returns the spiffejwt token generator with the audience set, if any
+func tokenGenerator(source x509svid.Source, maxTokenLifetime time.Duration, audience []string) token.GeneratorFunc {
+	if len(audience) == 0 {
+		return spiffejwt.TokenGeneratorFunc(source, maxTokenLifetime)
+	}
+	return func(authInfo credentials.AuthInfo) (string, time.Time, error) {
+		svid, err := source.GetX509SVID()
+		if err != nil {
+			return "", time.Time{}, errors.Wrap(err, "failed to get the SVID signing the token")
+		}
+		expireTime := time.Now().Add(maxTokenLifetime)
+		if svid.Certificates[0].NotAfter.Before(expireTime) {
+			expireTime = svid.Certificates[0].NotAfter
+		}
+		claims := jwt.RegisteredClaims{
+			Subject:   svid.ID.String(),
+			Audience:  audience,
+			ExpiresAt: jwt.NewNumericDate(expireTime),
+		}
+		tok, err := jwt.NewWithClaims(jwt.SigningMethodES256, claims).SignedString(svid.PrivateKey)
+		return tok, expireTime, errors.Wrapf(err, "failed to sign the token of %s", claims.Subject)
+	}
+}
+
+// TargetDialOptions returns the function returning the dial options of the peer of a target, nil for the other
+// targets
+func TargetDialOptions(ctx context.Context, peers []*Peer, defaults *Defaults) (func(target string) []grpc.DialOption, error) {
+	byTarget := make(map[string][]grpc.DialOption, len(peers))
+	for _, p := range peers {
+		opts, err := p.DialOptions(ctx, defaults)
+		if err != nil {
+			return nil, err
+		}
+		byTarget[p.Target] = opts
+	}
+	return func(target string) []grpc.DialOption {
+		return byTarget[target]
+	}, nil
+}
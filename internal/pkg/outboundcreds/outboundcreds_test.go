@@ -0,0 +1,191 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package outboundcreds_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/outboundcreds"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/tlssource"
+)
+
+func writeFile(t *testing.T, name string, data []byte) string {
+	filePath := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(filePath, data, 0o600))
+	return filePath
+}
+
+func newCA(t *testing.T) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
+// newLeaf writes the certificate with the SPIFFE ID signed by the CA and its key
+func newLeaf(t *testing.T, caCert *x509.Certificate, caKey *ecdsa.PrivateKey, id spiffeid.ID) (certFile, keyFile string) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		URIs:         []*url.URL{id.URL()},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	name := strings.ReplaceAll(id.Path(), "/", "")
+	return writeFile(t, name+".pem", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		writeFile(t, name+"-key.pem", pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}))
+}
+
+func TestLoadFile(t *testing.T) {
+	peers, err := outboundcreds.LoadFile(writeFile(t, "peers.json", []byte(`{
+		"tcp://proxy.b.example:5005": {"audience": ["spiffe://b.example/registry"]},
+		"tcp://proxy.a.example:5005": {"cert_file": "a.pem", "key_file": "a-key.pem", "ca_file": "a-ca.pem"}
+	}`)))
+	require.NoError(t, err)
+	require.Equal(t, []*outboundcreds.Peer{
+		{Target: "proxy.a.example:5005", CertFile: "a.pem", KeyFile: "a-key.pem", CAFile: "a-ca.pem"},
+		{Target: "proxy.b.example:5005", Audience: []string{"spiffe://b.example/registry"}},
+	}, peers)
+
+	for _, invalid := range []string{
+		`{"tcp://proxy.a.example:5005": {"cert_file": "a.pem"}}`,
+		`{"proxy.a.example": {}}`,
+		`[]`,
+	} {
+		_, err = outboundcreds.LoadFile(writeFile(t, "peers.json", []byte(invalid)))
+		require.Error(t, err, invalid)
+	}
+}
+
+func TestPeer_DialOptions(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	clientID := spiffeid.RequireFromString("spiffe://partner.example/registry-memory")
+	caCert, caKey := newCA(t)
+	caFile := writeFile(t, "ca.pem", pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caCert.Raw}))
+	serverCertFile, serverKeyFile := newLeaf(t, caCert, caKey, spiffeid.RequireFromString("spiffe://partner.example/proxy"))
+	clientCertFile, clientKeyFile := newLeaf(t, caCert, caKey, clientID)
+
+	serverSource, err := tlssource.NewFileSource(ctx, serverCertFile, serverKeyFile, caFile)
+	require.NoError(t, err)
+
+	// The server trusts the dedicated client certificate and records the tokens
+	tokens := make(chan string, 1)
+	listener := bufconn.Listen(1024 * 1024)
+	serverTLSConfig := tlsconfig.MTLSServerConfig(serverSource, serverSource, tlsconfig.AuthorizeAny())
+	serverTLSConfig.MinVersion = tls.VersionTLS12
+	server := grpc.NewServer(
+		grpc.Creds(credentials.NewTLS(serverTLSConfig)),
+		grpc.UnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			md, _ := metadata.FromIncomingContext(ctx)
+			tokens <- strings.Join(md.Get("nsm-client-token"), "")
+			return handler(ctx, req)
+		}),
+	)
+	grpc_health_v1.RegisterHealthServer(server, health.NewServer())
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	peer := &outboundcreds.Peer{
+		Target:   "bufconn",
+		CertFile: clientCertFile,
+		KeyFile:  clientKeyFile,
+		CAFile:   caFile,
+		Audience: []string{"spiffe://partner.example/registry"},
+	}
+	opts, err := peer.DialOptions(ctx, &outboundcreds.Defaults{MaxTokenLifetime: time.Minute})
+	require.NoError(t, err)
+
+	cc, err := grpc.DialContext(ctx, peer.Target, append(opts,
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }))...)
+	require.NoError(t, err)
+	defer func() { _ = cc.Close() }()
+
+	_, err = grpc_health_v1.NewHealthClient(cc).Check(ctx, new(grpc_health_v1.HealthCheckRequest))
+	require.NoError(t, err)
+
+	claims := new(jwt.RegisteredClaims)
+	_, _, err = new(jwt.Parser).ParseUnverified(<-tokens, claims)
+	require.NoError(t, err)
+	require.Equal(t, clientID.String(), claims.Subject)
+	require.Equal(t, jwt.ClaimStrings{"spiffe://partner.example/registry"}, claims.Audience)
+}
+
+func TestTargetDialOptions(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	source, err := tlssource.NewEphemeralSource(spiffeid.RequireFromString("spiffe://example.org/registry-memory"))
+	require.NoError(t, err)
+
+	targetDialOptions, err := outboundcreds.TargetDialOptions(ctx, []*outboundcreds.Peer{
+		{Target: "proxy.b.example:5005", Audience: []string{"spiffe://b.example/registry"}},
+	}, &outboundcreds.Defaults{SVIDSource: source, MaxTokenLifetime: time.Minute})
+	require.NoError(t, err)
+	require.Len(t, targetDialOptions("proxy.b.example:5005"), 1)
+	require.Empty(t, targetDialOptions("proxy.c.example:5005"))
+
+	_, err = outboundcreds.TargetDialOptions(ctx, []*outboundcreds.Peer{
+		{Target: "proxy.a.example:5005", CertFile: "missing.pem", KeyFile: "missing-key.pem", CAFile: "missing-ca.pem"},
+	}, &outboundcreds.Defaults{SVIDSource: source})
+	require.Error(t, err)
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package peercred provides gRPC transport credentials authenticating the local peers connected to the unix sockets
+// by their SO_PEERCRED UID and GID instead of mTLS
+package peercred
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/pkg/errors"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// AuthType is the auth type of the peer credentials
+const AuthType = "peercred"
+
+// TrustDomain is the trust domain of the local peer identities
+var TrustDomain = spiffeid.RequireTrustDomainFromString("local")
+
+// AuthInfo is the credentials of the local peer
+type AuthInfo struct {
+	credentials.CommonAuthInfo
+	PID int32
+	UID uint32
+	GID uint32
+}
+
+// AuthType returns AuthType
+func (AuthInfo) AuthType() string {
+	return AuthType
+}
+
+// SPIFFEID returns the identity of the local peer: spiffe://local/uid/<uid>
+func (a AuthInfo) SPIFFEID() spiffeid.ID {
+	id, _ := spiffeid.FromSegments(TrustDomain, "uid", fmt.Sprint(a.UID))
+	return id
+}
+
+type options struct {
+	uids map[uint32]struct{}
+	gids map[uint32]struct{}
+}
+
+// Option is an option for the peer credentials
+type Option func(o *options)
+
+// WithUIDs allows the peers with the UIDs
+func WithUIDs(uids ...uint32) Option {
+	return func(o *options) {
+		for _, uid := range uids {
+			o.uids[uid] = struct{}{}
+		}
+	}
+}
+
+// WithGIDs allows the peers with the GIDs
+func WithGIDs(gids ...uint32) Option {
+	return func(o *options) {
+		for _, gid := range gids {
+			o.gids[gid] = struct{}{}
+		}
+	}
+}
+
+type peerCredentials struct {
+	credentials.TransportCredentials
+	options
+}
+
+// NewCredentials returns the server transport credentials accepting only the unix socket connections of the peers
+// with the allowed UID or GID. All the local peers are allowed if neither UIDs nor GIDs are set. The client handshake
+// is insecure.
+func NewCredentials(opts ...Option) credentials.TransportCredentials {
+	c := &peerCredentials{
+		TransportCredentials: insecure.NewCredentials(),
+		options: options{
+			uids: make(map[uint32]struct{}),
+			gids: make(map[uint32]struct{}),
+		},
+	}
+	for _, opt := range opts {
+		opt(&c.options)
+	}
+	return c
+}
+
+func (c *peerCredentials) ServerHandshake(conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	unixConn, ok := conn.(*net.UnixConn)
+	if !ok {
+		return nil, nil, errors.Errorf("peer credentials require a unix socket connection, got %s", conn.RemoteAddr().Network())
+	}
+	info, err := peerCred(unixConn)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !c.allowed(info) {
+		return nil, nil, errors.Errorf("local peer uid %d gid %d is not allowed", info.UID, info.GID)
+	}
+	return conn, info, nil
+}
+
+func (c *peerCredentials) ClientHandshake(ctx context.Context, authority string, conn net.Conn) (net.Conn, credentials.AuthInfo, error) {
+	return c.TransportCredentials.ClientHandshake(ctx, authority, conn)
+}
+
+func (c *peerCredentials) Info() credentials.ProtocolInfo {
+	return credentials.ProtocolInfo{SecurityProtocol: AuthType}
+}
+
+func (c *peerCredentials) Clone() credentials.TransportCredentials {
+	return &peerCredentials{
+		TransportCredentials: c.TransportCredentials.Clone(),
+		options:              c.options,
+	}
+}
+
+func (c *peerCredentials) allowed(info AuthInfo) bool {
+	if len(c.uids) == 0 && len(c.gids) == 0 {
+		return true
+	}
+	_, uidOK := c.uids[info.UID]
+	_, gidOK := c.gids[info.GID]
+	return uidOK || gidOK
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+
+package peercred
+
+import (
+	"net"
+	"syscall"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/credentials"
+)
+
+func peerCred(conn *net.UnixConn) (AuthInfo, error) {
+	rawConn, err := conn.SyscallConn()
+	if err != nil {
+		return AuthInfo{}, errors.WithStack(err)
+	}
+	var ucred *syscall.Ucred
+	var credErr error
+	if err = rawConn.Control(func(fd uintptr) {
+		ucred, credErr = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	}); err != nil {
+		return AuthInfo{}, errors.WithStack(err)
+	}
+	if credErr != nil {
+		return AuthInfo{}, errors.Wrap(credErr, "failed to get the peer credentials")
+	}
+	return AuthInfo{
+		// The unix socket peer is local, so the connection can't be intercepted
+		CommonAuthInfo: credentials.CommonAuthInfo{SecurityLevel: credentials.PrivacyAndIntegrity},
+		PID:            ucred.Pid,
+		UID:            ucred.Uid,
+		GID:            ucred.Gid,
+	}, nil
+}
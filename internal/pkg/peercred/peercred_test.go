@@ -0,0 +1,74 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build linux
+
+package peercred_test
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/peercred"
+)
+
+// connect returns the server side of a new connection to the listener
+func connect(t *testing.T, listener net.Listener) net.Conn {
+	client, err := net.Dial(listener.Addr().Network(), listener.Addr().String())
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = client.Close() })
+
+	server, err := listener.Accept()
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = server.Close() })
+	return server
+}
+
+func TestCredentials_ServerHandshake(t *testing.T) {
+	listener, err := net.Listen("unix", filepath.Join(t.TempDir(), "registry.sock"))
+	require.NoError(t, err)
+	defer func() { _ = listener.Close() }()
+
+	uid, gid := uint32(os.Getuid()), uint32(os.Getgid())
+
+	_, authInfo, err := peercred.NewCredentials().ServerHandshake(connect(t, listener))
+	require.NoError(t, err)
+	info := authInfo.(peercred.AuthInfo)
+	require.Equal(t, uid, info.UID)
+	require.Equal(t, gid, info.GID)
+	require.Equal(t, int32(os.Getpid()), info.PID)
+	require.Equal(t, "spiffe://local/uid/"+strconv.Itoa(os.Getuid()), info.SPIFFEID().String())
+
+	_, _, err = peercred.NewCredentials(peercred.WithGIDs(gid)).ServerHandshake(connect(t, listener))
+	require.NoError(t, err)
+
+	_, _, err = peercred.NewCredentials(peercred.WithUIDs(uid + 1)).ServerHandshake(connect(t, listener))
+	require.Error(t, err)
+}
+
+func TestCredentials_ServerHandshake_TCP(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = listener.Close() }()
+
+	_, _, err = peercred.NewCredentials().ServerHandshake(connect(t, listener))
+	require.Error(t, err)
+}
@@ -0,0 +1,54 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package peerid provides the SPIFFE ID of the gRPC peer
+package peerid
+
+import (
+	"context"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// identityAuthInfo is the AuthInfo of the transport credentials identifying the peer without certificates, e.g. the
+// local peer credentials
+type identityAuthInfo interface {
+	SPIFFEID() spiffeid.ID
+}
+
+// FromContext returns the SPIFFE ID from the peer certificate of the incoming call, or from the peer AuthInfo providing
+// the SPIFFE ID
+func FromContext(ctx context.Context) (spiffeid.ID, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return spiffeid.ID{}, false
+	}
+	if info, ok := p.AuthInfo.(identityAuthInfo); ok {
+		return info.SPIFFEID(), true
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.PeerCertificates) == 0 {
+		return spiffeid.ID{}, false
+	}
+	id, err := x509svid.IDFromCert(tlsInfo.State.PeerCertificates[0])
+	if err != nil {
+		return spiffeid.ID{}, false
+	}
+	return id, true
+}
@@ -0,0 +1,322 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package peerstats provides the accounting of the connected peers: the NSE registrations they own, the streams and the
+// watch streams they hold and the bytes sent to them. The peers are identified by their SPIFFE ID and remote address.
+package peerstats
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/log"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/peerid"
+)
+
+const (
+	nseRegisterMethod   = "/registry.NetworkServiceEndpointRegistry/Register"
+	nseUnregisterMethod = "/registry.NetworkServiceEndpointRegistry/Unregister"
+
+	unknownIdentity   = "unknown"
+	identityAttribute = "identity"
+)
+
+// Peer is the accounting of a connected peer
+type Peer struct {
+	Identity   string
+	RemoteAddr string
+	// Registrations is the number of the registered NSEs last registered by the peer
+	Registrations int
+	// Streams is the number of the open streams, WatchStreams is the number of the open Find watch streams of them
+	Streams      int
+	WatchStreams int
+	// BytesSent is the total size of the messages sent to the peer
+	BytesSent int64
+	LastSeen  time.Time
+}
+
+type peerKey struct {
+	identity   string
+	remoteAddr string
+}
+
+type options struct {
+	idleTimeout time.Duration
+}
+
+// Option is an option for the Tracker
+type Option func(o *options)
+
+// WithIdleTimeout sets how long the peers with no registrations and no streams are kept, default 10m
+func WithIdleTimeout(idleTimeout time.Duration) Option {
+	return func(o *options) {
+		o.idleTimeout = idleTimeout
+	}
+}
+
+// Tracker tracks the connected peers with the gRPC server interceptors
+type Tracker struct {
+	options
+	mu     sync.Mutex
+	peers  map[peerKey]*Peer
+	owners map[string]peerKey
+
+	watchStreams metric.Int64UpDownCounter
+	bytesSent    metric.Int64Counter
+}
+
+// NewTracker creates a new Tracker. The peers are exposed as the registry.peer.registrations,
+// registry.peer.watch_streams and registry.peer.sent.bytes metrics by SPIFFE ID.
+func NewTracker(opts ...Option) *Tracker {
+	t := &Tracker{
+		options: options{
+			idleTimeout: 10 * time.Minute,
+		},
+		peers:  make(map[peerKey]*Peer),
+		owners: make(map[string]peerKey),
+	}
+	for _, opt := range opts {
+		opt(&t.options)
+	}
+
+	meter := otel.Meter("registry-memory")
+	var err error
+	if t.watchStreams, err = meter.Int64UpDownCounter("registry.peer.watch_streams",
+		metric.WithDescription("Number of the open Find watch streams by peer SPIFFE ID")); err != nil {
+		log.L().Errorf("failed to create peer watch streams counter: %s", err.Error())
+	}
+	if t.bytesSent, err = meter.Int64Counter("registry.peer.sent.bytes",
+		metric.WithUnit("By"),
+		metric.WithDescription("Size of the messages sent by peer SPIFFE ID")); err != nil {
+		log.L().Errorf("failed to create peer sent bytes counter: %s", err.Error())
+	}
+	if _, err = meter.Int64ObservableGauge("registry.peer.registrations",
+		metric.WithDescription("Number of the registered NSEs by the SPIFFE ID of the peer last registered them"),
+		metric.WithInt64Callback(t.observeRegistrations)); err != nil {
+		log.L().Errorf("failed to create peer registrations gauge: %s", err.Error())
+	}
+	return t
+}
+
+// Run watches the NSEs with the client and forgets the owners of the removed ones until ctx is done
+func (t *Tracker) Run(ctx context.Context, client registry.NetworkServiceEndpointRegistryClient) {
+	for ctx.Err() == nil {
+		stream, err := client.Find(ctx, &registry.NetworkServiceEndpointQuery{
+			NetworkServiceEndpoint: new(registry.NetworkServiceEndpoint),
+			Watch:                  true,
+		})
+		if err != nil {
+			log.FromContext(ctx).Warnf("failed to watch NSEs for peer statistics: %s", err.Error())
+			select {
+			case <-ctx.Done():
+			case <-clock.FromContext(ctx).After(time.Second):
+			}
+			continue
+		}
+		for resp := range registry.ReadNetworkServiceEndpointChannel(stream) {
+			if resp.GetDeleted() {
+				t.disown(resp.GetNetworkServiceEndpoint().GetName())
+			}
+		}
+	}
+}
+
+// UnaryServerInterceptor returns a server interceptor accounting the unary calls
+func (t *Tracker) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		key := keyFromContext(ctx)
+		t.update(ctx, key, func(*Peer) {})
+		resp, err := handler(ctx, req)
+		if err != nil {
+			return resp, err
+		}
+		t.sent(ctx, key, resp)
+		switch info.FullMethod {
+		case nseRegisterMethod:
+			if nse, ok := resp.(*registry.NetworkServiceEndpoint); ok {
+				t.own(nse.GetName(), key)
+			}
+		case nseUnregisterMethod:
+			if nse, ok := req.(*registry.NetworkServiceEndpoint); ok {
+				t.disown(nse.GetName())
+			}
+		}
+		return resp, nil
+	}
+}
+
+// StreamServerInterceptor returns a server interceptor accounting the streams
+func (t *Tracker) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := ss.Context()
+		stream := &serverStream{
+			ServerStream: ss,
+			tracker:      t,
+			key:          keyFromContext(ctx),
+		}
+		t.update(ctx, stream.key, func(p *Peer) { p.Streams++ })
+		defer func() {
+			t.update(ctx, stream.key, func(p *Peer) {
+				p.Streams--
+				if stream.watch {
+					p.WatchStreams--
+				}
+			})
+			if stream.watch && t.watchStreams != nil {
+				t.watchStreams.Add(ctx, -1, identityAttributes(stream.key))
+			}
+		}()
+		return handler(srv, stream)
+	}
+}
+
+// Peers returns the tracked peers sorted by the identity and the remote address. The idle peers are forgotten.
+func (t *Tracker) Peers(now time.Time) []*Peer {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	registrations := make(map[peerKey]int)
+	for _, key := range t.owners {
+		registrations[key]++
+	}
+	result := make([]*Peer, 0, len(t.peers))
+	for key, p := range t.peers {
+		if registrations[key] == 0 && p.Streams == 0 && now.Sub(p.LastSeen) > t.idleTimeout {
+			delete(t.peers, key)
+			continue
+		}
+		peerCopy := *p
+		peerCopy.Registrations = registrations[key]
+		result = append(result, &peerCopy)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Identity != result[j].Identity {
+			return result[i].Identity < result[j].Identity
+		}
+		return result[i].RemoteAddr < result[j].RemoteAddr
+	})
+	return result
+}
+
+func (t *Tracker) update(ctx context.Context, key peerKey, f func(p *Peer)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	p, ok := t.peers[key]
+	if !ok {
+		p = &Peer{Identity: key.identity, RemoteAddr: key.remoteAddr}
+		t.peers[key] = p
+	}
+	p.LastSeen = clock.FromContext(ctx).Now()
+	f(p)
+}
+
+func (t *Tracker) sent(ctx context.Context, key peerKey, m interface{}) {
+	msg, ok := m.(proto.Message)
+	if !ok {
+		return
+	}
+	size := int64(proto.Size(msg))
+	t.update(ctx, key, func(p *Peer) { p.BytesSent += size })
+	if t.bytesSent != nil {
+		t.bytesSent.Add(ctx, size, identityAttributes(key))
+	}
+}
+
+func (t *Tracker) own(name string, key peerKey) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.owners[name] = key
+}
+
+func (t *Tracker) disown(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	delete(t.owners, name)
+}
+
+func (t *Tracker) observeRegistrations(_ context.Context, observer metric.Int64Observer) error {
+	t.mu.Lock()
+	byIdentity := make(map[string]int64)
+	for _, key := range t.owners {
+		byIdentity[key.identity]++
+	}
+	t.mu.Unlock()
+
+	for identity, count := range byIdentity {
+		observer.Observe(count, metric.WithAttributes(attribute.String(identityAttribute, identity)))
+	}
+	return nil
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	tracker *Tracker
+	key     peerKey
+	watch   bool
+}
+
+func (s *serverStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	if query, ok := m.(interface{ GetWatch() bool }); ok && query.GetWatch() && !s.watch {
+		s.watch = true
+		s.tracker.update(s.Context(), s.key, func(p *Peer) { p.WatchStreams++ })
+		if s.tracker.watchStreams != nil {
+			s.tracker.watchStreams.Add(s.Context(), 1, identityAttributes(s.key))
+		}
+	}
+	return nil
+}
+
+func (s *serverStream) SendMsg(m interface{}) error {
+	if err := s.ServerStream.SendMsg(m); err != nil {
+		return err
+	}
+	s.tracker.sent(s.Context(), s.key, m)
+	return nil
+}
+
+func keyFromContext(ctx context.Context) peerKey {
+	key := peerKey{identity: unknownIdentity}
+	if id, ok := peerid.FromContext(ctx); ok {
+		key.identity = id.String()
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		key.remoteAddr = p.Addr.String()
+	}
+	return key
+}
+
+func identityAttributes(key peerKey) metric.MeasurementOption {
+	return metric.WithAttributes(attribute.String(identityAttribute, key.identity))
+}
@@ -0,0 +1,91 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package peerstats_test
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/peerstats"
+)
+
+func TestTracker(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tracker := peerstats.NewTracker(peerstats.WithIdleTimeout(time.Minute))
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(
+		grpc.UnaryInterceptor(tracker.UnaryServerInterceptor()),
+		grpc.StreamInterceptor(tracker.StreamServerInterceptor()))
+	registry.RegisterNetworkServiceEndpointRegistryServer(server, memory.NewNetworkServiceEndpointRegistryServer())
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	cc, err := grpc.DialContext(ctx, "bufconn",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer func() { _ = cc.Close() }()
+	client := registry.NewNetworkServiceEndpointRegistryClient(cc)
+
+	nse := &registry.NetworkServiceEndpoint{Name: "nse", NetworkServiceNames: []string{"ns"}}
+	_, err = client.Register(ctx, nse)
+	require.NoError(t, err)
+
+	watchCtx, cancelWatch := context.WithCancel(ctx)
+	stream, err := client.Find(watchCtx, &registry.NetworkServiceEndpointQuery{
+		NetworkServiceEndpoint: new(registry.NetworkServiceEndpoint),
+		Watch:                  true,
+	})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	require.NoError(t, err)
+
+	peers := tracker.Peers(time.Now())
+	require.Len(t, peers, 1)
+	require.Equal(t, "unknown", peers[0].Identity)
+	require.Equal(t, 1, peers[0].Registrations)
+	require.Equal(t, 1, peers[0].Streams)
+	require.Equal(t, 1, peers[0].WatchStreams)
+	require.Greater(t, peers[0].BytesSent, int64(0))
+
+	_, err = client.Unregister(ctx, nse)
+	require.NoError(t, err)
+	cancelWatch()
+	require.Eventually(t, func() bool {
+		peers = tracker.Peers(time.Now())
+		return len(peers) == 1 && peers[0].Streams == 0 && peers[0].WatchStreams == 0
+	}, time.Second, 10*time.Millisecond)
+	require.Zero(t, peers[0].Registrations)
+
+	// The idle peers are forgotten
+	require.Empty(t, tracker.Peers(time.Now().Add(2*time.Minute)))
+}
@@ -0,0 +1,241 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package probe provides the synthetic client periodically registering, finding and unregistering a reserved NSE
+// through the live registry server and recording the end-to-end results and latencies as metrics.
+//
+// The interdomain probe registers a canary NSE named <name>@<remote domain> through the local registry, so it is
+// forwarded to the remote registry via the proxy registry, and finds it with a peer query to the proxy registry.
+package probe
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/log"
+)
+
+// DefaultName is the reserved name of the probe NSE and network service
+const DefaultName = "registry-memory-probe"
+
+// Steps of a probe
+const (
+	StepRegister   = "register"
+	StepFind       = "find"
+	StepUnregister = "unregister"
+)
+
+// Paths of a probe
+const (
+	PathLocal       = "local"
+	PathInterdomain = "interdomain"
+)
+
+type options struct {
+	name       string
+	timeout    time.Duration
+	path       string
+	findClient registry.NetworkServiceEndpointRegistryClient
+}
+
+// Option is an option for the Prober
+type Option func(o *options)
+
+// WithName sets the reserved name of the probe NSE and network service, default is DefaultName
+func WithName(name string) Option {
+	return func(o *options) {
+		o.name = name
+	}
+}
+
+// WithTimeout sets the timeout of a probe, default is 10s
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// WithPath sets the path attribute of the probe metrics, default is PathLocal
+func WithPath(path string) Option {
+	return func(o *options) {
+		o.path = path
+	}
+}
+
+// WithFindClient sets the client the probe NSE is found with, e.g. the proxy registry one verifying the NSE is
+// discoverable from the remote side, default is the probed client
+func WithFindClient(client registry.NetworkServiceEndpointRegistryClient) Option {
+	return func(o *options) {
+		o.findClient = client
+	}
+}
+
+// Prober probes the registry with the client
+type Prober struct {
+	options
+	client  registry.NetworkServiceEndpointRegistryClient
+	results metric.Int64Counter
+	latency metric.Float64Histogram
+	healthy atomic.Bool
+}
+
+// New creates a new Prober probing the registry with the client. The result of the last probe is exposed as the
+// registry.probe.healthy gauge by path.
+func New(client registry.NetworkServiceEndpointRegistryClient, opts ...Option) *Prober {
+	p := &Prober{
+		options: options{
+			name:    DefaultName,
+			timeout: 10 * time.Second,
+			path:    PathLocal,
+		},
+		client: client,
+	}
+	for _, opt := range opts {
+		opt(&p.options)
+	}
+	if p.findClient == nil {
+		p.findClient = client
+	}
+
+	meter := otel.Meter("registry-memory")
+	var err error
+	if p.results, err = meter.Int64Counter("registry.probe.results",
+		metric.WithDescription("Number of the synthetic probe steps by step and result")); err != nil {
+		log.L().Errorf("failed to create probe results counter: %s", err.Error())
+	}
+	if p.latency, err = meter.Float64Histogram("registry.probe.latency",
+		metric.WithUnit("s"),
+		metric.WithDescription("Latency of the successful synthetic probe steps")); err != nil {
+		log.L().Errorf("failed to create probe latency histogram: %s", err.Error())
+	}
+	if _, err = meter.Int64ObservableGauge("registry.probe.healthy",
+		metric.WithDescription("Whether the last synthetic probe succeeded by path"),
+		metric.WithInt64Callback(func(_ context.Context, o metric.Int64Observer) error {
+			var healthy int64
+			if p.healthy.Load() {
+				healthy = 1
+			}
+			o.Observe(healthy, metric.WithAttributes(attribute.String("path", p.path)))
+			return nil
+		})); err != nil {
+		log.L().Errorf("failed to create probe healthy gauge: %s", err.Error())
+	}
+	return p
+}
+
+// Run probes the registry every period until ctx is done
+func (p *Prober) Run(ctx context.Context, period time.Duration) {
+	ticker := clock.FromContext(ctx).Ticker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			if err := p.Probe(ctx); err != nil {
+				log.FromContext(ctx).Warnf("registry %s probe failed: %s", p.path, err.Error())
+			}
+		}
+	}
+}
+
+// Probe registers, finds and unregisters the probe NSE once, recording the result and the latency of each step
+func (p *Prober) Probe(ctx context.Context) error {
+	err := p.probe(ctx)
+	p.healthy.Store(err == nil)
+	return err
+}
+
+func (p *Prober) probe(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+
+	nse := &registry.NetworkServiceEndpoint{
+		Name:                p.name,
+		NetworkServiceNames: []string{p.name},
+		ExpirationTime:      timestamppb.New(clock.FromContext(ctx).Now().Add(p.timeout)),
+	}
+
+	err := p.step(ctx, StepRegister, func() error {
+		resp, err := p.client.Register(ctx, nse)
+		if err == nil {
+			nse = resp
+		}
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	findErr := p.step(ctx, StepFind, func() error {
+		stream, err := p.findClient.Find(ctx, &registry.NetworkServiceEndpointQuery{
+			NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{Name: nse.GetName()},
+		})
+		if err != nil {
+			return err
+		}
+		for _, found := range registry.ReadNetworkServiceEndpointList(stream) {
+			if localName(found.GetName()) == localName(nse.GetName()) {
+				return nil
+			}
+		}
+		return errors.Errorf("%s is not found after Register", nse.GetName())
+	})
+
+	// The probe NSE is unregistered even if it isn't found
+	unregisterErr := p.step(ctx, StepUnregister, func() error {
+		_, err := p.client.Unregister(ctx, nse)
+		return err
+	})
+	if findErr != nil {
+		return findErr
+	}
+	return unregisterErr
+}
+
+func (p *Prober) step(ctx context.Context, name string, f func() error) error {
+	start := clock.FromContext(ctx).Now()
+	err := f()
+	result := "success"
+	if err != nil {
+		result = "failure"
+	} else if p.latency != nil {
+		p.latency.Record(ctx, clock.FromContext(ctx).Since(start).Seconds(),
+			metric.WithAttributes(attribute.String("step", name), attribute.String("path", p.path)))
+	}
+	if p.results != nil {
+		p.results.Add(ctx, 1, metric.WithAttributes(attribute.String("step", name), attribute.String("result", result),
+			attribute.String("path", p.path)))
+	}
+	return errors.Wrapf(err, "probe %s failed", name)
+}
+
+// localName returns the NSE name without the @domain suffix the interdomain registries add or strip
+func localName(name string) string {
+	local, _, _ := strings.Cut(name, "@")
+	return local
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package probe_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/probe"
+)
+
+type failingFindServer struct {
+	registry.NetworkServiceEndpointRegistryServer
+}
+
+func (s *failingFindServer) Find(*registry.NetworkServiceEndpointQuery, registry.NetworkServiceEndpointRegistry_FindServer) error {
+	return status.Error(codes.Internal, "find is broken")
+}
+
+func findNames(ctx context.Context, t *testing.T, server registry.NetworkServiceEndpointRegistryServer) []string {
+	stream, err := adapters.NetworkServiceEndpointServerToClient(server).Find(ctx, &registry.NetworkServiceEndpointQuery{
+		NetworkServiceEndpoint: new(registry.NetworkServiceEndpoint),
+	})
+	require.NoError(t, err)
+	var names []string
+	for _, nse := range registry.ReadNetworkServiceEndpointList(stream) {
+		names = append(names, nse.GetName())
+	}
+	return names
+}
+
+func TestProber_Probe(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	store := memory.NewNetworkServiceEndpointRegistryServer()
+	prober := probe.New(adapters.NetworkServiceEndpointServerToClient(store))
+
+	require.NoError(t, prober.Probe(ctx))
+	require.Empty(t, findNames(ctx, t, store))
+}
+
+func TestProber_ProbeFailure(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	store := memory.NewNetworkServiceEndpointRegistryServer()
+	prober := probe.New(adapters.NetworkServiceEndpointServerToClient(&failingFindServer{
+		NetworkServiceEndpointRegistryServer: store,
+	}), probe.WithName("probe"))
+
+	err := prober.Probe(ctx)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "probe find failed")
+
+	// The probe NSE is unregistered anyway
+	require.Empty(t, findNames(ctx, t, store))
+}
+
+func TestProber_FindClient(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	store := memory.NewNetworkServiceEndpointRegistryServer()
+	client := adapters.NetworkServiceEndpointServerToClient(store)
+
+	// The remote side finds the canary
+	prober := probe.New(client, probe.WithName("probe@remote.domain"), probe.WithPath(probe.PathInterdomain),
+		probe.WithFindClient(client))
+	require.NoError(t, prober.Probe(ctx))
+
+	// The remote side doesn't find the canary
+	remote := adapters.NetworkServiceEndpointServerToClient(memory.NewNetworkServiceEndpointRegistryServer())
+	prober = probe.New(client, probe.WithName("probe@remote.domain"), probe.WithPath(probe.PathInterdomain),
+		probe.WithFindClient(remote))
+	err := prober.Probe(ctx)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "is not found")
+	require.Empty(t, findNames(ctx, t, store))
+}
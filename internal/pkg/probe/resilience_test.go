@@ -0,0 +1,62 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build faultinject
+
+package probe_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/probe"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/nsestore"
+)
+
+func TestProber_StorageFaults(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	faults := nsestore.NewFaults()
+	store := nsestore.NewNetworkServiceEndpointRegistryServer(nsestore.WithFaults(faults))
+	prober := probe.New(adapters.NetworkServiceEndpointServerToClient(store), probe.WithTimeout(100*time.Millisecond))
+	require.NoError(t, prober.Probe(ctx))
+
+	// The probe turns unhealthy on the failing storage...
+	faults.Set(nsestore.OpRegister, nsestore.Fault{Err: status.Error(codes.Unavailable, "storage is down")})
+	err := prober.Probe(ctx)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "probe register failed")
+
+	// ...and on the storage slower than the probe timeout
+	faults.Reset()
+	faults.Set(nsestore.OpFind, nsestore.Fault{Latency: time.Hour})
+	err = prober.Probe(ctx)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "probe find failed")
+
+	// It turns healthy again once the storage recovers, cleaning the probe NSE up
+	faults.Reset()
+	require.NoError(t, prober.Probe(ctx))
+	require.Empty(t, findNames(ctx, t, store))
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redact
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+type spanExporter struct {
+	sdktrace.SpanExporter
+	redactor *Redactor
+}
+
+// NewSpanExporter returns the span exporter redacting the attributes, the event attributes and the status description
+// of the spans before exporting them with exporter
+func NewSpanExporter(exporter sdktrace.SpanExporter, redactor *Redactor) sdktrace.SpanExporter {
+	return &spanExporter{
+		SpanExporter: exporter,
+		redactor:     redactor,
+	}
+}
+
+func (e *spanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
+	redacted := make([]sdktrace.ReadOnlySpan, 0, len(spans))
+	for _, span := range spans {
+		redacted = append(redacted, &redactedSpan{ReadOnlySpan: span, redactor: e.redactor})
+	}
+	return e.SpanExporter.ExportSpans(ctx, redacted)
+}
+
+type redactedSpan struct {
+	sdktrace.ReadOnlySpan
+	redactor *Redactor
+}
+
+func (s *redactedSpan) Attributes() []attribute.KeyValue {
+	return s.redactor.attributes(s.ReadOnlySpan.Attributes())
+}
+
+func (s *redactedSpan) Events() []sdktrace.Event {
+	events := s.ReadOnlySpan.Events()
+	redacted := make([]sdktrace.Event, 0, len(events))
+	for _, event := range events {
+		event.Attributes = s.redactor.attributes(event.Attributes)
+		redacted = append(redacted, event)
+	}
+	return redacted
+}
+
+func (s *redactedSpan) Status() sdktrace.Status {
+	status := s.ReadOnlySpan.Status()
+	status.Description = s.redactor.String(status.Description)
+	return status
+}
+
+func (r *Redactor) attributes(attrs []attribute.KeyValue) []attribute.KeyValue {
+	redacted := make([]attribute.KeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		switch {
+		case r.Contains(string(attr.Key)):
+			attr.Value = attribute.StringValue(r.Value(attr.Value.Emit()))
+		case attr.Value.Type() == attribute.STRING:
+			attr.Value = attribute.StringValue(r.String(attr.Value.AsString()))
+		}
+		redacted = append(redacted, attr)
+	}
+	return redacted
+}
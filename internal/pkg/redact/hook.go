@@ -0,0 +1,58 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redact
+
+import (
+	"github.com/sirupsen/logrus"
+)
+
+type hook struct {
+	redactor *Redactor
+}
+
+// NewHook returns a logrus hook redacting the messages and the fields of all the entries before they are formatted
+func NewHook(redactor *Redactor) logrus.Hook {
+	return &hook{
+		redactor: redactor,
+	}
+}
+
+func (h *hook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (h *hook) Fire(entry *logrus.Entry) error {
+	entry.Message = h.redactor.String(entry.Message)
+	for k, v := range entry.Data {
+		switch {
+		case h.redactor.Contains(k):
+			if s, ok := v.(string); ok {
+				entry.Data[k] = h.redactor.Value(s)
+			} else {
+				entry.Data[k] = Stripped
+			}
+		default:
+			switch value := v.(type) {
+			case string:
+				entry.Data[k] = h.redactor.String(value)
+			case error:
+				entry.Data[k] = h.redactor.String(value.Error())
+			}
+		}
+	}
+	return nil
+}
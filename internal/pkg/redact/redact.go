@@ -0,0 +1,100 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package redact provides the redaction of the sensitive registry fields and labels from the log lines and the trace
+// attributes, enforced centrally by a logrus hook and a span exporter rather than by each log call. The values of the
+// configured names are stripped or replaced by their hashes in the JSON, protobuf text and key=value formats the
+// registry objects are logged in, the stored registry data is not changed.
+package redact
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+)
+
+// Stripped is the value the stripped fields are replaced with
+const Stripped = "REDACTED"
+
+type options struct {
+	hash bool
+}
+
+// Option is an option for the Redactor
+type Option func(o *options)
+
+// WithHash replaces the values with their truncated SHA-256 hashes instead of stripping them, so the redacted values
+// can still be correlated across the log lines
+func WithHash() Option {
+	return func(o *options) {
+		o.hash = true
+	}
+}
+
+// Redactor redacts the values of the configured field and label names
+type Redactor struct {
+	options
+	names   map[string]struct{}
+	pattern *regexp.Regexp
+}
+
+// New creates a new Redactor of the names, nil if there are none
+func New(names []string, opts ...Option) *Redactor {
+	if len(names) == 0 {
+		return nil
+	}
+	r := &Redactor{
+		names: make(map[string]struct{}, len(names)),
+	}
+	for _, opt := range opts {
+		opt(&r.options)
+	}
+	quoted := make([]string, 0, len(names))
+	for _, name := range names {
+		r.names[name] = struct{}{}
+		quoted = append(quoted, regexp.QuoteMeta(name))
+	}
+	// "name":"value", name:"value", name="value", the same escaped inside a JSON string, and name:value, name=value
+	r.pattern = regexp.MustCompile(`(\\?"?\b(?:` + strings.Join(quoted, "|") + `)\\?"?\s*[:=]\s*)` +
+		`(\\?"(?:[^"\\]|\\[^"])*|[^\s,\]})"\\{\[]+)`)
+	return r
+}
+
+// Contains returns true if name is redacted
+func (r *Redactor) Contains(name string) bool {
+	_, ok := r.names[name]
+	return ok
+}
+
+// String returns s with the values of the redacted names redacted
+func (r *Redactor) String(s string) string {
+	return r.pattern.ReplaceAllStringFunc(s, func(match string) string {
+		groups := r.pattern.FindStringSubmatch(match)
+		value := groups[2]
+		quote := value[:len(value)-len(strings.TrimLeft(value, `\"`))]
+		return groups[1] + quote + r.Value(value[len(quote):])
+	})
+}
+
+// Value returns the redacted value
+func (r *Redactor) Value(value string) string {
+	if !r.hash {
+		return Stripped
+	}
+	sum := sha256.Sum256([]byte(value))
+	return "sha256:" + hex.EncodeToString(sum[:8])
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package redact_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/redact"
+)
+
+func TestRedactor_String(t *testing.T) {
+	r := redact.New([]string{"url", "token"})
+
+	nse := &registry.NetworkServiceEndpoint{
+		Name: "nse-1",
+		Url:  "tcp://10.0.0.1:5001",
+		NetworkServiceLabels: map[string]*registry.NetworkServiceLabels{
+			"ns-1": {Labels: map[string]string{"token": "secret", "app": "web"}},
+		},
+	}
+	data, err := json.Marshal(nse)
+	require.NoError(t, err)
+	redacted := r.String(string(data))
+	require.NotContains(t, redacted, "10.0.0.1")
+	require.NotContains(t, redacted, "secret")
+	require.Contains(t, redacted, `"url":"REDACTED"`)
+	require.Contains(t, redacted, `"app":"web"`)
+	require.Contains(t, redacted, `"name":"nse-1"`)
+
+	require.Equal(t, `name:"nse-1" url:"REDACTED"`, r.String(`name:"nse-1" url:"tcp://10.0.0.1:5001"`))
+	require.Equal(t, `msg="{\"url\":\"REDACTED\"}"`, r.String(`msg="{\"url\":\"tcp://10.0.0.1:5001\"}"`))
+	require.Equal(t, `map[name:nse-1 url:REDACTED]`, r.String(`map[name:nse-1 url:tcp://10.0.0.1:5001]`))
+	require.Equal(t, `name=nse-1 url=REDACTED`, r.String(`name=nse-1 url=tcp://10.0.0.1:5001`))
+	// The names are matched as a whole
+	require.Equal(t, `"curl":"x"`, r.String(`"curl":"x"`))
+
+	hashed := redact.New([]string{"url"}, redact.WithHash())
+	first, second := hashed.String(`"url":"a"`), hashed.String(`"url":"a"`)
+	require.Equal(t, first, second)
+	require.NotEqual(t, first, hashed.String(`"url":"b"`))
+	require.Contains(t, first, `"url":"sha256:`)
+
+	require.Nil(t, redact.New(nil))
+}
+
+func TestHook(t *testing.T) {
+	buf := new(bytes.Buffer)
+	logger := logrus.New()
+	logger.SetOutput(buf)
+	logger.SetFormatter(&logrus.JSONFormatter{})
+	logger.AddHook(redact.NewHook(redact.New([]string{"url"})))
+
+	logger.WithField("url", "tcp://10.0.0.1:5001").WithField("nse", `{"url":"tcp://10.0.0.2:5001"}`).
+		Infof(`register={"name":"nse-1","url":"tcp://10.0.0.3:5001"}`)
+	require.NotContains(t, buf.String(), "10.0.0.")
+	require.Contains(t, buf.String(), "nse-1")
+}
+
+func TestSpanExporter(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	redacting := redact.NewSpanExporter(exporter, redact.New([]string{"url"}))
+
+	span := tracetest.SpanStub{
+		Name: "Register",
+		Attributes: []attribute.KeyValue{
+			attribute.String("url", "tcp://10.0.0.1:5001"),
+			attribute.String("nse", `{"name":"nse-1","url":"tcp://10.0.0.2:5001"}`),
+		},
+		Events: []sdktrace.Event{{
+			Name:       "log",
+			Attributes: []attribute.KeyValue{attribute.String("message", `register={"url":"tcp://10.0.0.3:5001"}`)},
+		}},
+	}
+	require.NoError(t, redacting.ExportSpans(context.Background(), []sdktrace.ReadOnlySpan{span.Snapshot()}))
+
+	spans := exporter.GetSpans()
+	require.Len(t, spans, 1)
+	require.Equal(t, "REDACTED", spans[0].Attributes[0].Value.AsString())
+	require.Equal(t, `{"name":"nse-1","url":"REDACTED"}`, spans[0].Attributes[1].Value.AsString())
+	require.Equal(t, `register={"url":"REDACTED"}`, spans[0].Events[0].Attributes[0].Value.AsString())
+}
@@ -0,0 +1,155 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package admission provides registry server chain elements validating the registrations with a synchronous admission
+// webhook.
+//
+// Before a Register is accepted, the candidate is POSTed as JSON to the webhook URL:
+//
+//	{"kind": "network_service" or "network_service_endpoint", "object": <protojson of the NS or NSE>}
+//
+// and the webhook responds {"allowed": <bool>, "message": <reason>}. A rejected registration fails with
+// PermissionDenied and the message. The in-process registrations made by the registry itself are not validated.
+package admission
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/log"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/inprocess"
+)
+
+const (
+	kindNetworkService         = "network_service"
+	kindNetworkServiceEndpoint = "network_service_endpoint"
+
+	// maxResponseSize limits the webhook response read
+	maxResponseSize = 64 * 1024
+)
+
+type options struct {
+	client   *http.Client
+	failOpen bool
+}
+
+// Option is an option for the admission chain elements
+type Option func(o *options)
+
+// WithHTTPClient sets the HTTP client, default has 5s timeout
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.client = client
+	}
+}
+
+// WithFailOpen accepts the registrations when the webhook can't be reached or responds with an error. By default
+// they fail with Unavailable.
+func WithFailOpen() Option {
+	return func(o *options) {
+		o.failOpen = true
+	}
+}
+
+type request struct {
+	Kind   string          `json:"kind"`
+	Object json.RawMessage `json:"object"`
+}
+
+type response struct {
+	Allowed bool   `json:"allowed"`
+	Message string `json:"message"`
+}
+
+// reviewer calls the webhook
+type reviewer struct {
+	options
+	url string
+}
+
+func newReviewer(url string, opts ...Option) *reviewer {
+	r := &reviewer{
+		options: options{
+			client: &http.Client{Timeout: 5 * time.Second},
+		},
+		url: url,
+	}
+	for _, opt := range opts {
+		opt(&r.options)
+	}
+	return r
+}
+
+// review returns nil if the webhook allows the registration of the object
+func (r *reviewer) review(ctx context.Context, kind, name string, object proto.Message) error {
+	if inprocess.FromContext(ctx) {
+		return nil
+	}
+	resp, err := r.call(ctx, kind, object)
+	if err != nil {
+		if r.failOpen {
+			log.FromContext(ctx).Warnf("admission webhook failed, accepting %s: %s", name, err.Error())
+			return nil
+		}
+		return status.Errorf(codes.Unavailable, "admission webhook failed for %s: %s", name, err.Error())
+	}
+	if !resp.Allowed {
+		return status.Errorf(codes.PermissionDenied, "%s is rejected by the admission webhook: %s", name, resp.Message)
+	}
+	return nil
+}
+
+func (r *reviewer) call(ctx context.Context, kind string, object proto.Message) (*response, error) {
+	data, err := protojson.Marshal(object)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal the object")
+	}
+	body, err := json.Marshal(&request{Kind: kind, Object: data})
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to marshal the request")
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create the request")
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := r.client.Do(req)
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	defer func() { _ = httpResp.Body.Close() }()
+
+	if httpResp.StatusCode < 200 || httpResp.StatusCode >= 300 {
+		return nil, errors.Errorf("unexpected status %s", httpResp.Status)
+	}
+	resp := new(response)
+	if err = json.NewDecoder(io.LimitReader(httpResp.Body, maxResponseSize)).Decode(resp); err != nil {
+		return nil, errors.Wrap(err, "failed to parse the response")
+	}
+	return resp, nil
+}
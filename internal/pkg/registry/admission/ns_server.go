@@ -0,0 +1,54 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admission
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+)
+
+type admissionNSServer struct {
+	*reviewer
+}
+
+// NewNetworkServiceRegistryServer creates a new NS server chain element validating the registrations with the
+// admission webhook at url
+func NewNetworkServiceRegistryServer(url string, opts ...Option) registry.NetworkServiceRegistryServer {
+	return &admissionNSServer{
+		reviewer: newReviewer(url, opts...),
+	}
+}
+
+func (s *admissionNSServer) Register(ctx context.Context, ns *registry.NetworkService) (*registry.NetworkService, error) {
+	if err := s.review(ctx, kindNetworkService, ns.GetName(), ns); err != nil {
+		return nil, err
+	}
+	return next.NetworkServiceRegistryServer(ctx).Register(ctx, ns)
+}
+
+func (s *admissionNSServer) Find(query *registry.NetworkServiceQuery, server registry.NetworkServiceRegistry_FindServer) error {
+	return next.NetworkServiceRegistryServer(server.Context()).Find(query, server)
+}
+
+func (s *admissionNSServer) Unregister(ctx context.Context, ns *registry.NetworkService) (*empty.Empty, error) {
+	return next.NetworkServiceRegistryServer(ctx).Unregister(ctx, ns)
+}
@@ -0,0 +1,118 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package admission_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/chain"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/admission"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/inprocess"
+)
+
+// namingWebhook allows only the NSEs with the "team-" name prefix and tcp:// URL
+func namingWebhook(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Kind   string `json:"kind"`
+		Object struct {
+			Name string `json:"name"`
+			URL  string `json:"url"`
+		} `json:"object"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	resp := map[string]interface{}{"allowed": true}
+	switch {
+	case req.Kind != "network_service_endpoint":
+		resp = map[string]interface{}{"allowed": false, "message": "unexpected kind " + req.Kind}
+	case !strings.HasPrefix(req.Object.Name, "team-"):
+		resp = map[string]interface{}{"allowed": false, "message": "name must start with team-"}
+	case !strings.HasPrefix(req.Object.URL, "tcp://"):
+		resp = map[string]interface{}{"allowed": false, "message": "url must be tcp"}
+	}
+	_ = json.NewEncoder(w).Encode(resp)
+}
+
+func TestAdmissionNSEServer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	webhook := httptest.NewServer(http.HandlerFunc(namingWebhook))
+	defer webhook.Close()
+
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		admission.NewNetworkServiceEndpointRegistryServer(webhook.URL),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+
+	_, err := client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "team-nse", Url: "tcp://1.1.1.1:5001"})
+	require.NoError(t, err)
+
+	_, err = client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse", Url: "tcp://1.1.1.1:5001"})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	require.Contains(t, err.Error(), "name must start with team-")
+
+	_, err = client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "team-nse-2", Url: "unix:///nse.sock"})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	// The registry own registrations are not validated
+	_, err = client.Register(inprocess.WithContext(ctx), &registry.NetworkServiceEndpoint{Name: "nse"})
+	require.NoError(t, err)
+}
+
+func TestAdmissionNSEServer_Unavailable(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer webhook.Close()
+
+	nse := &registry.NetworkServiceEndpoint{Name: "team-nse", Url: "tcp://1.1.1.1:5001"}
+
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		admission.NewNetworkServiceEndpointRegistryServer(webhook.URL),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+	_, err := client.Register(ctx, nse)
+	require.Equal(t, codes.Unavailable, status.Code(err))
+
+	client = adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		admission.NewNetworkServiceEndpointRegistryServer(webhook.URL, admission.WithFailOpen()),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+	_, err = client.Register(ctx, nse)
+	require.NoError(t, err)
+}
@@ -0,0 +1,321 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package budget provides NSE registry server chain element capping the number and the approximate memory use of the
+// registered NSEs
+package budget
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/log"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/inprocess"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/tombstones"
+)
+
+type budgetNSEServer struct {
+	options
+
+	mu      sync.Mutex
+	entries map[string]*entry
+	bytes   int64
+}
+
+// entry is a registered NSE accounted in the budget until it is unregistered or expires, or a registration in
+// progress reserving its size
+type entry struct {
+	size           int64
+	expirationTime *timestamppb.Timestamp
+	expire         clock.Timer
+	expired        bool
+	pending        bool
+}
+
+// reservation is the budget reserved for a registration in progress
+type reservation struct {
+	name    string
+	entry   *entry
+	prev    *entry
+	evicted map[string]*entry
+}
+
+// NewNetworkServiceEndpointRegistryServer creates a new NSE server chain element enforcing the budget on the
+// registrations. Refreshes are checked for the growth of the NSE size only.
+func NewNetworkServiceEndpointRegistryServer(opts ...Option) registry.NetworkServiceEndpointRegistryServer {
+	s := &budgetNSEServer{
+		options: options{
+			limits: new(Limits),
+			policy: PolicyReject,
+		},
+		entries: make(map[string]*entry),
+	}
+	for _, opt := range opts {
+		opt(&s.options)
+	}
+	return s
+}
+
+func (s *budgetNSEServer) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*registry.NetworkServiceEndpoint, error) {
+	// The NSEs are accounted even with no limits, so the limits can be set while the registry runs. The budget is
+	// reserved under the lock, so the concurrent registrations can't exceed it together, and the rest of the chain is
+	// called without it
+	r, err := s.reserve(nse)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := next.NetworkServiceEndpointRegistryServer(ctx).Register(ctx, nse)
+	if err != nil {
+		s.rollback(r)
+		return nil, err
+	}
+	// The NSEs chosen for eviction are evicted only once the registration has passed the rest of the chain, e.g. the
+	// authorization, so a denied registration evicts none
+	s.evict(ctx, nse.GetName(), r)
+	s.commit(ctx, r, resp)
+	return resp, nil
+}
+
+func (s *budgetNSEServer) Find(query *registry.NetworkServiceEndpointQuery, server registry.NetworkServiceEndpointRegistry_FindServer) error {
+	return next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, server)
+}
+
+func (s *budgetNSEServer) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*empty.Empty, error) {
+	resp, err := next.NetworkServiceEndpointRegistryServer(ctx).Unregister(ctx, nse)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	s.remove(nse.GetName(), nil)
+	s.mu.Unlock()
+	return resp, nil
+}
+
+// reserve reserves the budget for nse, a refresh reserves the growth of the NSE size only and a refresh not growing it
+// always fits. If nse doesn't fit, the NSEs closest to expiration are chosen for eviction if the policy allows, they
+// stop being accounted right away.
+func (s *budgetNSEServer) reserve(nse *registry.NetworkServiceEndpoint) (*reservation, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	r := &reservation{
+		name:    nse.GetName(),
+		entry:   &entry{size: int64(proto.Size(nse)), pending: true},
+		prev:    s.entries[nse.GetName()],
+		evicted: make(map[string]*entry),
+	}
+	entries, size := len(s.entries), s.bytes+r.entry.size
+	if r.prev != nil {
+		size -= r.prev.size
+	} else {
+		entries++
+	}
+	// A refresh not growing the NSE is never rejected, even if the limits have been lowered below the registered NSEs
+	refresh := r.prev != nil && r.entry.size <= r.prev.size
+	if !refresh && !s.limits.fits(entries, size) {
+		if s.policy != PolicyEvictExpiring {
+			return nil, status.Errorf(codes.ResourceExhausted, "registry budget exceeded: %d entries, %d bytes", entries, size)
+		}
+		for _, candidate := range s.evictionOrder(r.name) {
+			if s.limits.fits(entries, size) {
+				break
+			}
+			r.evicted[candidate] = s.entries[candidate]
+			entries--
+			size -= s.entries[candidate].size
+		}
+		if !s.limits.fits(entries, size) {
+			return nil, status.Errorf(codes.ResourceExhausted, "registry budget exceeded: %s doesn't fit even into empty registry", r.name)
+		}
+	}
+
+	for name, e := range r.evicted {
+		s.remove(name, e)
+	}
+	// The previous entry is restored if the registration fails, so its timer keeps running
+	if r.prev != nil {
+		s.bytes -= r.prev.size
+	}
+	s.entries[r.name] = r.entry
+	s.bytes += r.entry.size
+	return r, nil
+}
+
+// evictionOrder returns the names of the registered NSEs but name in the order of eviction: NSEs without expiration
+// time never expire, so they are evicted last, the registrations in progress are never evicted. s.mu must be held.
+func (s *budgetNSEServer) evictionOrder(name string) []string {
+	var candidates []string
+	for candidate, e := range s.entries {
+		if candidate != name && !e.pending {
+			candidates = append(candidates, candidate)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		ei, ej := s.entries[candidates[i]].expirationTime, s.entries[candidates[j]].expirationTime
+		if ei == nil || ej == nil {
+			return ej == nil && ei != nil
+		}
+		if !ei.AsTime().Equal(ej.AsTime()) {
+			return ei.AsTime().Before(ej.AsTime())
+		}
+		return candidates[i] < candidates[j]
+	})
+	return candidates
+}
+
+// evict unregisters the NSEs chosen for eviction by the reservation. The NSEs failed to evict are accounted again.
+func (s *budgetNSEServer) evict(ctx context.Context, name string, r *reservation) {
+	evictCtx := inprocess.WithContext(ctx)
+	for evictedName, e := range r.evicted {
+		if s.tombstones != nil {
+			s.tombstones.Mark(ctx, evictedName, tombstones.ReasonEvicted)
+		}
+		evicted, err := s.find(evictCtx, evictedName)
+		if err == nil && evicted != nil {
+			_, err = next.NetworkServiceEndpointRegistryServer(ctx).Unregister(evictCtx, evicted)
+		}
+		delete(r.evicted, evictedName)
+		if err != nil {
+			log.FromContext(ctx).Warnf("failed to evict %s to fit %s into the registry budget: %s", evictedName, name, err.Error())
+			s.mu.Lock()
+			if !e.expired {
+				s.restore(evictedName, e)
+			}
+			s.mu.Unlock()
+			continue
+		}
+		if e.expire != nil {
+			e.expire.Stop()
+		}
+		log.FromContext(ctx).Warnf("evicted %s to fit %s into the registry budget", evictedName, name)
+	}
+}
+
+// rollback releases the budget reserved by r, restoring the previous entry and the NSEs not evicted
+func (s *budgetNSEServer) rollback(r *reservation) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.entries[r.name] == r.entry {
+		delete(s.entries, r.name)
+		s.bytes -= r.entry.size
+		if r.prev != nil && !r.prev.expired {
+			s.restore(r.name, r.prev)
+		}
+	}
+	for name, e := range r.evicted {
+		if !e.expired {
+			s.restore(name, e)
+		}
+	}
+}
+
+// commit accounts the registered NSE in place of the budget reserved by r until its expiration time
+func (s *budgetNSEServer) commit(ctx context.Context, r *reservation, nse *registry.NetworkServiceEndpoint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.prev != nil && r.prev.expire != nil {
+		r.prev.expire.Stop()
+	}
+	if current, ok := s.entries[r.name]; ok && current != r.entry {
+		// Another registration of the name has taken over
+		return
+	}
+	s.remove(r.name, nil)
+
+	e := &entry{
+		size:           int64(proto.Size(nse)),
+		expirationTime: nse.GetExpirationTime(),
+	}
+	if e.expirationTime != nil {
+		timeClock := clock.FromContext(ctx)
+		e.expire = timeClock.AfterFunc(timeClock.Until(e.expirationTime.AsTime()), func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			e.expired = true
+			s.remove(r.name, e)
+		})
+	}
+	s.entries[r.name] = e
+	s.bytes += e.size
+}
+
+// find returns the registered NSE with the name or nil if there is none
+func (s *budgetNSEServer) find(ctx context.Context, name string) (*registry.NetworkServiceEndpoint, error) {
+	collector := &nseCollector{ctx: ctx}
+	query := &registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{Name: name}}
+	if err := next.NetworkServiceEndpointRegistryServer(ctx).Find(query, collector); err != nil {
+		return nil, err
+	}
+	for _, nse := range collector.nses {
+		if nse.GetName() == name {
+			return nse, nil
+		}
+	}
+	return nil, nil
+}
+
+// restore accounts e again if the name is not accounted. s.mu must be held.
+func (s *budgetNSEServer) restore(name string, e *entry) {
+	if _, ok := s.entries[name]; ok {
+		return
+	}
+	s.entries[name] = e
+	s.bytes += e.size
+}
+
+// remove stops accounting the NSE with the name if it is e, or any if e is nil. The timer of e is kept, so it can be
+// restored. s.mu must be held.
+func (s *budgetNSEServer) remove(name string, e *entry) {
+	current, ok := s.entries[name]
+	if !ok || (e != nil && current != e) {
+		return
+	}
+	if e == nil && current.expire != nil {
+		current.expire.Stop()
+	}
+	delete(s.entries, name)
+	s.bytes -= current.size
+}
+
+type nseCollector struct {
+	grpc.ServerStream
+	ctx  context.Context
+	nses []*registry.NetworkServiceEndpoint
+}
+
+func (c *nseCollector) Send(resp *registry.NetworkServiceEndpointResponse) error {
+	c.nses = append(c.nses, resp.GetNetworkServiceEndpoint())
+	return nil
+}
+
+func (c *nseCollector) Context() context.Context {
+	return c.ctx
+}
@@ -0,0 +1,282 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package budget_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/chain"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/utils/inject/injecterror"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/budget"
+)
+
+func names(ctx context.Context, t *testing.T, client registry.NetworkServiceEndpointRegistryClient) []string {
+	stream, err := client.Find(ctx, &registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: new(registry.NetworkServiceEndpoint)})
+	require.NoError(t, err)
+	var result []string
+	for _, nse := range registry.ReadNetworkServiceEndpointList(stream) {
+		result = append(result, nse.GetName())
+	}
+	return result
+}
+
+func TestBudget_Reject(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		budget.NewNetworkServiceEndpointRegistryServer(budget.WithLimits(budget.NewLimits(2, 0))),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+
+	for _, name := range []string{"nse-1", "nse-2"} {
+		_, err := client.Register(ctx, &registry.NetworkServiceEndpoint{Name: name})
+		require.NoError(t, err)
+	}
+
+	_, err := client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-3"})
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	_, err = client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-1", Url: "tcp://1.1.1.1:5001"})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"nse-1", "nse-2"}, names(ctx, t, client))
+}
+
+func TestBudget_EvictExpiring(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		budget.NewNetworkServiceEndpointRegistryServer(
+			budget.WithLimits(budget.NewLimits(2, 0)),
+			budget.WithPolicy(budget.PolicyEvictExpiring)),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+
+	now := time.Now()
+	for name, expiration := range map[string]*timestamppb.Timestamp{
+		"nse-1": timestamppb.New(now.Add(time.Hour)),
+		"nse-2": timestamppb.New(now.Add(time.Minute)),
+	} {
+		_, err := client.Register(ctx, &registry.NetworkServiceEndpoint{Name: name, ExpirationTime: expiration})
+		require.NoError(t, err)
+	}
+
+	_, err := client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-3"})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"nse-1", "nse-3"}, names(ctx, t, client))
+}
+
+func TestBudget_EvictDenied(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The third registration is denied further down the chain, e.g. by the authorization
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		budget.NewNetworkServiceEndpointRegistryServer(
+			budget.WithLimits(budget.NewLimits(2, 0)),
+			budget.WithPolicy(budget.PolicyEvictExpiring)),
+		injecterror.NewNetworkServiceEndpointRegistryServer(
+			injecterror.WithError(status.Error(codes.PermissionDenied, "denied")),
+			injecterror.WithRegisterErrorTimes(2),
+			injecterror.WithFindErrorTimes(),
+			injecterror.WithUnregisterErrorTimes()),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+
+	now := time.Now()
+	for _, nse := range []*registry.NetworkServiceEndpoint{
+		{Name: "nse-1", ExpirationTime: timestamppb.New(now.Add(time.Hour))},
+		{Name: "nse-2", ExpirationTime: timestamppb.New(now.Add(time.Minute))},
+	} {
+		_, err := client.Register(ctx, nse)
+		require.NoError(t, err)
+	}
+
+	// The denied registration evicts nothing
+	_, err := client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-3"})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	require.ElementsMatch(t, []string{"nse-1", "nse-2"}, names(ctx, t, client))
+
+	_, err = client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-3"})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"nse-1", "nse-3"}, names(ctx, t, client))
+}
+
+func TestBudget_ConcurrentRegister(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		budget.NewNetworkServiceEndpointRegistryServer(budget.WithLimits(budget.NewLimits(10, 0))),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+
+	const count = 100
+	var wg sync.WaitGroup
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, _ = client.Register(ctx, &registry.NetworkServiceEndpoint{Name: fmt.Sprintf("nse-%d", i)})
+		}(i)
+	}
+	wg.Wait()
+
+	require.Len(t, names(ctx, t, client), 10)
+}
+
+func TestBudget_Unregister(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		budget.NewNetworkServiceEndpointRegistryServer(budget.WithLimits(budget.NewLimits(1, 0))),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+
+	nse, err := client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-1"})
+	require.NoError(t, err)
+	_, err = client.Unregister(ctx, nse)
+	require.NoError(t, err)
+
+	_, err = client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-2"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"nse-2"}, names(ctx, t, client))
+}
+
+func TestBudget_RefreshGrowth(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	nse1 := &registry.NetworkServiceEndpoint{Name: "nse-1"}
+	nse2 := &registry.NetworkServiceEndpoint{Name: "nse-2"}
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		budget.NewNetworkServiceEndpointRegistryServer(budget.WithLimits(budget.NewLimits(0, int64(proto.Size(nse1)+proto.Size(nse2))))),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+
+	for _, nse := range []*registry.NetworkServiceEndpoint{nse1, nse2} {
+		_, err := client.Register(ctx, nse.Clone())
+		require.NoError(t, err)
+	}
+
+	// The refresh is checked for the growth of the NSE size
+	_, err := client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-1", Url: "tcp://1.1.1.1:5001"})
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	_, err = client.Register(ctx, nse1.Clone())
+	require.NoError(t, err)
+}
+
+type blockingNSEServer struct {
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingNSEServer) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*registry.NetworkServiceEndpoint, error) {
+	if nse.GetName() == "nse-slow" {
+		close(s.entered)
+		<-s.release
+	}
+	return next.NetworkServiceEndpointRegistryServer(ctx).Register(ctx, nse)
+}
+
+func (s *blockingNSEServer) Find(query *registry.NetworkServiceEndpointQuery, server registry.NetworkServiceEndpointRegistry_FindServer) error {
+	return next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, server)
+}
+
+func (s *blockingNSEServer) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*empty.Empty, error) {
+	return next.NetworkServiceEndpointRegistryServer(ctx).Unregister(ctx, nse)
+}
+
+func TestBudget_RegisterInProgress(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	blocking := &blockingNSEServer{entered: make(chan struct{}), release: make(chan struct{})}
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		budget.NewNetworkServiceEndpointRegistryServer(budget.WithLimits(budget.NewLimits(2, 0))),
+		blocking,
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-slow"})
+		errCh <- err
+	}()
+	<-blocking.entered
+
+	// The registration in progress reserves its entry without blocking the others
+	_, err := client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-1"})
+	require.NoError(t, err)
+	_, err = client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-2"})
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	close(blocking.release)
+	require.NoError(t, <-errCh)
+	require.ElementsMatch(t, []string{"nse-slow", "nse-1"}, names(ctx, t, client))
+}
+
+func TestBudget_Limits(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	limits := new(budget.Limits)
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		budget.NewNetworkServiceEndpointRegistryServer(budget.WithLimits(limits)),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+
+	for _, name := range []string{"nse-1", "nse-2"} {
+		_, err := client.Register(ctx, &registry.NetworkServiceEndpoint{Name: name})
+		require.NoError(t, err)
+	}
+
+	// The NSEs registered with no limits are accounted once the limits are set
+	limits.Store(2, 0)
+	_, err := client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-3"})
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+
+	limits.Store(3, 0)
+	_, err = client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-3"})
+	require.NoError(t, err)
+
+	// The registered NSEs are still refreshed once the limits are lowered below them
+	limits.Store(1, 0)
+	_, err = client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-1"})
+	require.NoError(t, err)
+	_, err = client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-4"})
+	require.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
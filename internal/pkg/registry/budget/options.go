@@ -0,0 +1,89 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package budget
+
+import (
+	"sync/atomic"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/tombstones"
+)
+
+// Policy is the action taken when a registration exceeds the budget
+type Policy string
+
+const (
+	// PolicyReject rejects the registration with ResourceExhausted
+	PolicyReject Policy = "reject"
+	// PolicyEvictExpiring evicts the NSEs closest to expiration to fit the registration
+	PolicyEvictExpiring Policy = "evict-expiring"
+)
+
+// Limits are the maximum number of NSEs and the maximum total encoded size of the NSEs approximating their memory
+// use, 0 means no limit. They can be changed while the registry runs: the NSEs registered over the new limits are kept
+// until they are unregistered or expire.
+type Limits struct {
+	maxEntries atomic.Int64
+	maxBytes   atomic.Int64
+}
+
+// NewLimits creates new Limits
+func NewLimits(maxEntries int, maxBytes int64) *Limits {
+	l := new(Limits)
+	l.Store(maxEntries, maxBytes)
+	return l
+}
+
+// Store replaces the limits
+func (l *Limits) Store(maxEntries int, maxBytes int64) {
+	l.maxEntries.Store(int64(maxEntries))
+	l.maxBytes.Store(maxBytes)
+}
+
+func (l *Limits) fits(entries int, size int64) bool {
+	maxEntries, maxBytes := l.maxEntries.Load(), l.maxBytes.Load()
+	return (maxEntries <= 0 || int64(entries) <= maxEntries) && (maxBytes <= 0 || size <= maxBytes)
+}
+
+type options struct {
+	limits     *Limits
+	policy     Policy
+	tombstones *tombstones.Store
+}
+
+// Option is an option for the budget chain element
+type Option func(o *options)
+
+// WithLimits sets the limits, default is no limit
+func WithLimits(limits *Limits) Option {
+	return func(o *options) {
+		o.limits = limits
+	}
+}
+
+// WithPolicy sets the policy, default is PolicyReject
+func WithPolicy(policy Policy) Option {
+	return func(o *options) {
+		o.policy = policy
+	}
+}
+
+// WithTombstones sets the tombstones store to mark the evicted NSEs in
+func WithTombstones(store *tombstones.Store) Option {
+	return func(o *options) {
+		o.tombstones = store
+	}
+}
@@ -0,0 +1,295 @@
+// Copyright (c) 2020-2022 Doc.ai and/or its affiliates.
+//
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package memory provides registry chain based on memory chain elements. It is the sdk memory chain with the same
+// elements in the same order, only these differ:
+//   - the chains are elementerrors chains, so the errors are counted by the element returning them;
+//   - the local NSEs are stored in the nsestore NSE store instead of the sdk memory one;
+//   - the proxy registry is selected by peerselect and dialed with pooldial when configured.
+//
+// The sdk chain can't be composed instead: it hard-codes its chain wrapper, NSE store, clienturl and dial elements, and
+// its dial element dials per Find even if the connection is already set. The differing elements are built by the
+// new*Server and new*Client functions, the chain itself is kept in sync with the sdk one, see server_test.go.
+package memory
+
+import (
+	"context"
+	"net/url"
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	registryserver "github.com/NikitaSkrynnik/sdk/pkg/registry"
+	registryauthorize "github.com/NikitaSkrynnik/sdk/pkg/registry/common/authorize"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/grpcmetadata"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/updatepath"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/begin"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/clientconn"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/clienturl"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/connect"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/dial"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/expire"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/setpayload"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/setregistrationtime"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/switchcase"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/utils/metadata"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/interdomain"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/token"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/connpool"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/elementerrors"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/nsestore"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/peerselect"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/pooldial"
+)
+
+type serverOptions struct {
+	authorizeNSRegistryServer  registry.NetworkServiceRegistryServer
+	authorizeNSERegistryServer registry.NetworkServiceEndpointRegistryServer
+	authorizeNSRegistryClient  registry.NetworkServiceRegistryClient
+	authorizeNSERegistryClient registry.NetworkServiceEndpointRegistryClient
+	defaultExpiration          time.Duration
+	proxyRegistryURL           *url.URL
+	dialOptions                []grpc.DialOption
+	connPool                   *connpool.Pool
+	proxyRegistrySelector      *peerselect.Selector
+	nseStoreOptions            []nsestore.Option
+}
+
+// Option modifies server option value
+type Option func(o *serverOptions)
+
+// WithAuthorizeNSRegistryServer sets authorization NetworkServiceRegistry chain element
+func WithAuthorizeNSRegistryServer(authorizeNSRegistryServer registry.NetworkServiceRegistryServer) Option {
+	if authorizeNSRegistryServer == nil {
+		panic("authorizeNSRegistryServer cannot be nil")
+	}
+	return func(o *serverOptions) {
+		o.authorizeNSRegistryServer = authorizeNSRegistryServer
+	}
+}
+
+// WithAuthorizeNSERegistryServer sets authorization NetworkServiceEndpointRegistry chain element
+func WithAuthorizeNSERegistryServer(authorizeNSERegistryServer registry.NetworkServiceEndpointRegistryServer) Option {
+	if authorizeNSERegistryServer == nil {
+		panic("authorizeNSERegistryServer cannot be nil")
+	}
+	return func(o *serverOptions) {
+		o.authorizeNSERegistryServer = authorizeNSERegistryServer
+	}
+}
+
+// WithAuthorizeNSRegistryClient sets authorization NetworkServiceRegistry chain element
+func WithAuthorizeNSRegistryClient(authorizeNSRegistryClient registry.NetworkServiceRegistryClient) Option {
+	if authorizeNSRegistryClient == nil {
+		panic("authorizeNSRegistryClient cannot be nil")
+	}
+	return func(o *serverOptions) {
+		o.authorizeNSRegistryClient = authorizeNSRegistryClient
+	}
+}
+
+// WithAuthorizeNSERegistryClient sets authorization NetworkServiceEndpointRegistry chain element
+func WithAuthorizeNSERegistryClient(authorizeNSERegistryClient registry.NetworkServiceEndpointRegistryClient) Option {
+	if authorizeNSERegistryClient == nil {
+		panic("authorizeNSERegistryClient cannot be nil")
+	}
+	return func(o *serverOptions) {
+		o.authorizeNSERegistryClient = authorizeNSERegistryClient
+	}
+}
+
+// WithDefaultExpiration sets the default expiration for endpoints
+func WithDefaultExpiration(d time.Duration) Option {
+	return func(o *serverOptions) {
+		o.defaultExpiration = d
+	}
+}
+
+// WithProxyRegistryURL sets URL to reach the proxy registry
+func WithProxyRegistryURL(proxyRegistryURL *url.URL) Option {
+	return func(o *serverOptions) {
+		o.proxyRegistryURL = proxyRegistryURL
+	}
+}
+
+// WithDialOptions sets grpc.DialOptions for the server
+func WithDialOptions(dialOptions ...grpc.DialOption) Option {
+	return func(o *serverOptions) {
+		o.dialOptions = dialOptions
+	}
+}
+
+// WithConnPool sets the pool to get the connections to the proxy registry from instead of dialing them per NSE and per
+// Find with the dial options
+func WithConnPool(pool *connpool.Pool) Option {
+	return func(o *serverOptions) {
+		o.connPool = pool
+	}
+}
+
+// WithProxyRegistrySelector sets the selector of the healthiest of the redundant proxy registries to use instead of the
+// proxy registry URL
+func WithProxyRegistrySelector(selector *peerselect.Selector) Option {
+	return func(o *serverOptions) {
+		o.proxyRegistrySelector = selector
+	}
+}
+
+// WithNSEStoreOptions sets the options of the NSE store
+func WithNSEStoreOptions(opts ...nsestore.Option) Option {
+	return func(o *serverOptions) {
+		o.nseStoreOptions = opts
+	}
+}
+
+// NewServer creates new registry server based on memory storage
+func NewServer(ctx context.Context, tokenGenerator token.GeneratorFunc, options ...Option) registryserver.Registry {
+	opts := &serverOptions{
+		authorizeNSRegistryServer:  registryauthorize.NewNetworkServiceRegistryServer(registryauthorize.Any()),
+		authorizeNSERegistryServer: registryauthorize.NewNetworkServiceEndpointRegistryServer(registryauthorize.Any()),
+		authorizeNSRegistryClient:  registryauthorize.NewNetworkServiceRegistryClient(registryauthorize.Any()),
+		authorizeNSERegistryClient: registryauthorize.NewNetworkServiceEndpointRegistryClient(registryauthorize.Any()),
+		defaultExpiration:          time.Minute,
+		proxyRegistryURL:           nil,
+	}
+	for _, opt := range options {
+		opt(opts)
+	}
+
+	nseChain := elementerrors.NewNetworkServiceEndpointRegistryServer(
+		grpcmetadata.NewNetworkServiceEndpointRegistryServer(),
+		updatepath.NewNetworkServiceEndpointRegistryServer(tokenGenerator),
+		opts.authorizeNSERegistryServer,
+		begin.NewNetworkServiceEndpointRegistryServer(),
+		metadata.NewNetworkServiceEndpointServer(),
+		switchcase.NewNetworkServiceEndpointRegistryServer(switchcase.NSEServerCase{
+			Condition: func(c context.Context, nse *registry.NetworkServiceEndpoint) bool {
+				if interdomain.Is(nse.GetName()) {
+					return true
+				}
+				for _, ns := range nse.GetNetworkServiceNames() {
+					if interdomain.Is(ns) {
+						return true
+					}
+				}
+				return false
+			},
+			Action: elementerrors.NewNetworkServiceEndpointRegistryServer(
+				connect.NewNetworkServiceEndpointRegistryServer(newProxyNSEClient(ctx, opts)),
+			),
+		},
+			switchcase.NSEServerCase{
+				Condition: func(c context.Context, nse *registry.NetworkServiceEndpoint) bool { return true },
+				Action:    newLocalNSEServer(ctx, opts),
+			},
+		),
+	)
+	nsChain := elementerrors.NewNetworkServiceRegistryServer(
+		grpcmetadata.NewNetworkServiceRegistryServer(),
+		updatepath.NewNetworkServiceRegistryServer(tokenGenerator),
+		opts.authorizeNSRegistryServer,
+		metadata.NewNetworkServiceServer(),
+		setpayload.NewNetworkServiceRegistryServer(),
+		switchcase.NewNetworkServiceRegistryServer(
+			switchcase.NSServerCase{
+				Condition: func(c context.Context, ns *registry.NetworkService) bool {
+					return interdomain.Is(ns.GetName())
+				},
+				Action: connect.NewNetworkServiceRegistryServer(newProxyNSClient(ctx, opts)),
+			},
+			switchcase.NSServerCase{
+				Condition: func(c context.Context, ns *registry.NetworkService) bool {
+					return true
+				},
+				Action: memory.NewNetworkServiceRegistryServer(),
+			},
+		),
+	)
+
+	return registryserver.NewServer(nsChain, nseChain)
+}
+
+// newLocalNSEServer returns the chain storing the local NSEs: the sdk one with the nsestore NSE store
+func newLocalNSEServer(ctx context.Context, opts *serverOptions) registry.NetworkServiceEndpointRegistryServer {
+	return elementerrors.NewNetworkServiceEndpointRegistryServer(
+		setregistrationtime.NewNetworkServiceEndpointRegistryServer(),
+		expire.NewNetworkServiceEndpointRegistryServer(ctx, expire.WithDefaultExpiration(opts.defaultExpiration)),
+		nsestore.NewNetworkServiceEndpointRegistryServer(opts.nseStoreOptions...),
+	)
+}
+
+// newProxyNSEClient returns the client chain forwarding the interdomain NSEs to the proxy registry: the sdk one with
+// the proxy registry selected and dialed as configured
+func newProxyNSEClient(ctx context.Context, opts *serverOptions) registry.NetworkServiceEndpointRegistryClient {
+	return elementerrors.NewNetworkServiceEndpointRegistryClient(
+		begin.NewNetworkServiceEndpointRegistryClient(),
+		newClientURLNSEClient(opts),
+		clientconn.NewNetworkServiceEndpointRegistryClient(),
+		opts.authorizeNSERegistryClient,
+		grpcmetadata.NewNetworkServiceEndpointRegistryClient(),
+		newDialNSEClient(ctx, opts),
+		connect.NewNetworkServiceEndpointRegistryClient(),
+	)
+}
+
+// newProxyNSClient returns the client chain forwarding the interdomain NSs to the proxy registry: the sdk one with the
+// proxy registry selected and dialed as configured
+func newProxyNSClient(ctx context.Context, opts *serverOptions) registry.NetworkServiceRegistryClient {
+	return elementerrors.NewNetworkServiceRegistryClient(
+		newClientURLNSClient(opts),
+		begin.NewNetworkServiceRegistryClient(),
+		clientconn.NewNetworkServiceRegistryClient(),
+		opts.authorizeNSRegistryClient,
+		grpcmetadata.NewNetworkServiceRegistryClient(),
+		newDialNSClient(ctx, opts),
+		connect.NewNetworkServiceRegistryClient(),
+	)
+}
+
+func newClientURLNSEClient(opts *serverOptions) registry.NetworkServiceEndpointRegistryClient {
+	if opts.proxyRegistrySelector != nil {
+		return peerselect.NewNetworkServiceEndpointRegistryClient(opts.proxyRegistrySelector)
+	}
+	return clienturl.NewNetworkServiceEndpointRegistryClient(opts.proxyRegistryURL)
+}
+
+func newClientURLNSClient(opts *serverOptions) registry.NetworkServiceRegistryClient {
+	if opts.proxyRegistrySelector != nil {
+		return peerselect.NewNetworkServiceRegistryClient(opts.proxyRegistrySelector)
+	}
+	return clienturl.NewNetworkServiceRegistryClient(opts.proxyRegistryURL)
+}
+
+func newDialNSEClient(ctx context.Context, opts *serverOptions) registry.NetworkServiceEndpointRegistryClient {
+	if opts.connPool != nil {
+		return pooldial.NewNetworkServiceEndpointRegistryClient(opts.connPool)
+	}
+	return dial.NewNetworkServiceEndpointRegistryClient(ctx, dial.WithDialOptions(opts.dialOptions...))
+}
+
+func newDialNSClient(ctx context.Context, opts *serverOptions) registry.NetworkServiceRegistryClient {
+	if opts.connPool != nil {
+		return pooldial.NewNetworkServiceRegistryClient(opts.connPool)
+	}
+	return dial.NewNetworkServiceRegistryClient(ctx, dial.WithDialOptions(opts.dialOptions...))
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memory_test
+
+import (
+	"context"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/networkservice/payload"
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	registryserver "github.com/NikitaSkrynnik/sdk/pkg/registry"
+	registryclient "github.com/NikitaSkrynnik/sdk/pkg/registry/chains/client"
+	sdkmemory "github.com/NikitaSkrynnik/sdk/pkg/registry/chains/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/token"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/chains/memory"
+)
+
+func generateToken(_ credentials.AuthInfo) (string, time.Time, error) {
+	expireTime := time.Now().Add(time.Hour)
+	tok, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.RegisteredClaims{
+		Subject:   "spiffe://test.com/subject",
+		ExpiresAt: jwt.NewNumericDate(expireTime),
+	}).SignedString([]byte("supersecret"))
+	return tok, expireTime, err
+}
+
+type newServerFunc func(ctx context.Context, tokenGenerator token.GeneratorFunc) registryserver.Registry
+
+// servers are the chains the behavior of which must not differ: the sdk memory chain and the chain replacing it
+var servers = map[string]newServerFunc{
+	"sdk": func(ctx context.Context, tokenGenerator token.GeneratorFunc) registryserver.Registry {
+		return sdkmemory.NewServer(ctx, tokenGenerator)
+	},
+	"cmd": func(ctx context.Context, tokenGenerator token.GeneratorFunc) registryserver.Registry {
+		return memory.NewServer(ctx, tokenGenerator)
+	},
+}
+
+func startServer(ctx context.Context, t *testing.T, newServer newServerFunc) *url.URL {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := grpc.NewServer()
+	newServer(ctx, generateToken).Register(server)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	return &url.URL{Scheme: "tcp", Host: listener.Addr().String()}
+}
+
+// TestNewServer_RegisterFindUnregister checks the chain behaves as the sdk memory chain it replaces
+func TestNewServer_RegisterFindUnregister(t *testing.T) {
+	for name, newServer := range servers {
+		newServer := newServer
+		t.Run(name, func(t *testing.T) {
+			testRegisterFindUnregister(t, newServer)
+		})
+	}
+}
+
+func testRegisterFindUnregister(t *testing.T, newServer newServerFunc) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	u := startServer(ctx, t, newServer)
+	dialOptions := registryclient.WithDialOptions(grpc.WithTransportCredentials(insecure.NewCredentials()))
+	nsClient := registryclient.NewNetworkServiceRegistryClient(ctx, dialOptions, registryclient.WithClientURL(u))
+	nseClient := registryclient.NewNetworkServiceEndpointRegistryClient(ctx, dialOptions, registryclient.WithClientURL(u))
+
+	ns, err := nsClient.Register(ctx, &registry.NetworkService{Name: "ns-1"})
+	require.NoError(t, err)
+	require.Equal(t, payload.IP, ns.GetPayload())
+
+	nse, err := nseClient.Register(ctx, &registry.NetworkServiceEndpoint{
+		Name:                "nse-1",
+		NetworkServiceNames: []string{"ns-1"},
+	})
+	require.NoError(t, err)
+	require.NotNil(t, nse.GetExpirationTime())
+
+	nsStream, err := nsClient.Find(ctx, &registry.NetworkServiceQuery{NetworkService: &registry.NetworkService{Name: "ns-1"}})
+	require.NoError(t, err)
+	nsList := registry.ReadNetworkServiceList(nsStream)
+	require.Len(t, nsList, 1)
+
+	nseStream, err := nseClient.Find(ctx, &registry.NetworkServiceEndpointQuery{
+		NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{NetworkServiceNames: []string{"ns-1"}},
+	})
+	require.NoError(t, err)
+	nseList := registry.ReadNetworkServiceEndpointList(nseStream)
+	require.Len(t, nseList, 1)
+	require.Equal(t, "nse-1", nseList[0].GetName())
+	require.NotNil(t, nseList[0].GetInitialRegistrationTime())
+
+	_, err = nseClient.Unregister(ctx, nse)
+	require.NoError(t, err)
+	_, err = nsClient.Unregister(ctx, ns)
+	require.NoError(t, err)
+
+	nseStream, err = nseClient.Find(ctx, &registry.NetworkServiceEndpointQuery{
+		NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{NetworkServiceNames: []string{"ns-1"}},
+	})
+	require.NoError(t, err)
+	require.Empty(t, registry.ReadNetworkServiceEndpointList(nseStream))
+
+	nsStream, err = nsClient.Find(ctx, &registry.NetworkServiceQuery{NetworkService: &registry.NetworkService{Name: "ns-1"}})
+	require.NoError(t, err)
+	require.Empty(t, registry.ReadNetworkServiceList(nsStream))
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package chaos provides registry server chain elements injecting faults for the integration testing of the registry
+// clients: artificial latency, transient Unavailable errors and dropped Find stream events, each at a configured rate.
+// The in-process calls made by the registry itself are not affected.
+//
+// The faults are for testing only and must never be enabled in production.
+package chaos
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/log"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/inprocess"
+)
+
+// Fault kinds reported in the registry.chaos.faults metric
+const (
+	FaultLatency = "latency"
+	FaultError   = "error"
+	FaultDrop    = "drop"
+)
+
+type options struct {
+	latency   time.Duration
+	errorRate float64
+	dropRate  float64
+}
+
+// Option is an option for the chaos chain elements
+type Option func(o *options)
+
+// WithLatency delays every call by a random duration of up to latency
+func WithLatency(latency time.Duration) Option {
+	return func(o *options) {
+		o.latency = latency
+	}
+}
+
+// WithErrorRate fails the rate (from 0 to 1) of the calls with Unavailable
+func WithErrorRate(rate float64) Option {
+	return func(o *options) {
+		o.errorRate = rate
+	}
+}
+
+// WithDropRate drops the rate (from 0 to 1) of the Find stream events
+func WithDropRate(rate float64) Option {
+	return func(o *options) {
+		o.dropRate = rate
+	}
+}
+
+// Injector injects the faults into the calls. It is shared by the NS and NSE chain elements.
+type Injector struct {
+	options
+	faults metric.Int64Counter
+}
+
+// NewInjector creates a new Injector
+func NewInjector(opts ...Option) *Injector {
+	i := new(Injector)
+	for _, opt := range opts {
+		opt(&i.options)
+	}
+	faults, err := otel.Meter("registry-memory").Int64Counter("registry.chaos.faults",
+		metric.WithDescription("Number of the faults injected by the chaos mode by kind"))
+	if err != nil {
+		log.L().Errorf("failed to create chaos faults counter: %s", err.Error())
+	}
+	i.faults = faults
+	return i
+}
+
+// inject delays the call and returns the injected error, if any
+func (i *Injector) inject(ctx context.Context) error {
+	if inprocess.FromContext(ctx) {
+		return nil
+	}
+	if i.latency > 0 {
+		// #nosec G404 - the faults don't need a secure random
+		delay := time.Duration(rand.Int63n(int64(i.latency) + 1))
+		i.record(ctx, FaultLatency)
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-clock.FromContext(ctx).After(delay):
+		}
+	}
+	if i.happens(i.errorRate) {
+		i.record(ctx, FaultError)
+		return status.Error(codes.Unavailable, "chaos: injected transient failure")
+	}
+	return nil
+}
+
+// drop returns true if the Find stream event should be dropped
+func (i *Injector) drop(ctx context.Context) bool {
+	if inprocess.FromContext(ctx) || !i.happens(i.dropRate) {
+		return false
+	}
+	i.record(ctx, FaultDrop)
+	return true
+}
+
+func (i *Injector) happens(rate float64) bool {
+	// #nosec G404 - the faults don't need a secure random
+	return rate > 0 && rand.Float64() < rate
+}
+
+func (i *Injector) record(ctx context.Context, fault string) {
+	if i.faults != nil {
+		i.faults.Add(ctx, 1, metric.WithAttributes(attribute.String("fault", fault)))
+	}
+}
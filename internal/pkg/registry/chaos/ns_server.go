@@ -0,0 +1,74 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaos
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+)
+
+type chaosNSServer struct {
+	injector *Injector
+}
+
+// NewNetworkServiceRegistryServer creates a new NS server chain element injecting the faults
+func NewNetworkServiceRegistryServer(injector *Injector) registry.NetworkServiceRegistryServer {
+	return &chaosNSServer{
+		injector: injector,
+	}
+}
+
+func (s *chaosNSServer) Register(ctx context.Context, ns *registry.NetworkService) (*registry.NetworkService, error) {
+	if err := s.injector.inject(ctx); err != nil {
+		return nil, err
+	}
+	return next.NetworkServiceRegistryServer(ctx).Register(ctx, ns)
+}
+
+func (s *chaosNSServer) Find(query *registry.NetworkServiceQuery, server registry.NetworkServiceRegistry_FindServer) error {
+	if err := s.injector.inject(server.Context()); err != nil {
+		return err
+	}
+	return next.NetworkServiceRegistryServer(server.Context()).Find(query, &chaosNSFindServer{
+		NetworkServiceRegistry_FindServer: server,
+		injector:                          s.injector,
+	})
+}
+
+func (s *chaosNSServer) Unregister(ctx context.Context, ns *registry.NetworkService) (*empty.Empty, error) {
+	if err := s.injector.inject(ctx); err != nil {
+		return nil, err
+	}
+	return next.NetworkServiceRegistryServer(ctx).Unregister(ctx, ns)
+}
+
+type chaosNSFindServer struct {
+	registry.NetworkServiceRegistry_FindServer
+	injector *Injector
+}
+
+func (s *chaosNSFindServer) Send(resp *registry.NetworkServiceResponse) error {
+	if s.injector.drop(s.Context()) {
+		return nil
+	}
+	return s.NetworkServiceRegistry_FindServer.Send(resp)
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaos
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+)
+
+type chaosNSEServer struct {
+	injector *Injector
+}
+
+// NewNetworkServiceEndpointRegistryServer creates a new NSE server chain element injecting the faults
+func NewNetworkServiceEndpointRegistryServer(injector *Injector) registry.NetworkServiceEndpointRegistryServer {
+	return &chaosNSEServer{
+		injector: injector,
+	}
+}
+
+func (s *chaosNSEServer) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*registry.NetworkServiceEndpoint, error) {
+	if err := s.injector.inject(ctx); err != nil {
+		return nil, err
+	}
+	return next.NetworkServiceEndpointRegistryServer(ctx).Register(ctx, nse)
+}
+
+func (s *chaosNSEServer) Find(query *registry.NetworkServiceEndpointQuery, server registry.NetworkServiceEndpointRegistry_FindServer) error {
+	if err := s.injector.inject(server.Context()); err != nil {
+		return err
+	}
+	return next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, &chaosNSEFindServer{
+		NetworkServiceEndpointRegistry_FindServer: server,
+		injector: s.injector,
+	})
+}
+
+func (s *chaosNSEServer) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*empty.Empty, error) {
+	if err := s.injector.inject(ctx); err != nil {
+		return nil, err
+	}
+	return next.NetworkServiceEndpointRegistryServer(ctx).Unregister(ctx, nse)
+}
+
+type chaosNSEFindServer struct {
+	registry.NetworkServiceEndpointRegistry_FindServer
+	injector *Injector
+}
+
+func (s *chaosNSEFindServer) Send(resp *registry.NetworkServiceEndpointResponse) error {
+	if s.injector.drop(s.Context()) {
+		return nil
+	}
+	return s.NetworkServiceEndpointRegistry_FindServer.Send(resp)
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package chaos_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/chain"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clockmock"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/chaos"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/inprocess"
+)
+
+func newNSEClient(opts ...chaos.Option) registry.NetworkServiceEndpointRegistryClient {
+	return adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		chaos.NewNetworkServiceEndpointRegistryServer(chaos.NewInjector(opts...)),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+}
+
+func TestChaosNSEServer_Errors(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := newNSEClient(chaos.WithErrorRate(1))
+
+	_, err := client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse"})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+	_, err = client.Find(ctx, &registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: new(registry.NetworkServiceEndpoint)})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+
+	// The in-process calls are not affected
+	_, err = client.Register(inprocess.WithContext(ctx), &registry.NetworkServiceEndpoint{Name: "nse"})
+	require.NoError(t, err)
+}
+
+func TestChaosNSEServer_DroppedEvents(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := newNSEClient(chaos.WithDropRate(1))
+
+	_, err := client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse"})
+	require.NoError(t, err)
+
+	stream, err := client.Find(ctx, &registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: new(registry.NetworkServiceEndpoint)})
+	require.NoError(t, err)
+	require.Empty(t, registry.ReadNetworkServiceEndpointList(stream))
+}
+
+func TestChaosNSEServer_Latency(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	clockMock := clockmock.New(ctx)
+	ctx = clock.WithClock(ctx, clockMock)
+
+	client := newNSEClient(chaos.WithLatency(time.Minute))
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse"})
+		done <- err
+	}()
+
+	require.Empty(t, done)
+	// The delay timer is set asynchronously
+	require.Eventually(t, func() bool {
+		clockMock.Add(time.Minute)
+		return len(done) == 1
+	}, time.Second, 10*time.Millisecond)
+	require.NoError(t, <-done)
+}
@@ -0,0 +1,169 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package clockskew provides NSE registry server chain element computing the granted expiration time relative to the
+// server clock, so the clients with skewed clocks get neither instantly expired nor near-immortal registrations
+package clockskew
+
+import (
+	"context"
+	"math"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/log"
+)
+
+// ClientTimeMetadata is the gRPC metadata key with the client clock time the Register call is sent at, RFC 3339. The
+// NSM clients don't send it, the client is to add it to the outgoing metadata to get the skew measured exactly.
+const ClientTimeMetadata = "registry-memory-client-time"
+
+type options struct {
+	minLifetime      time.Duration
+	maxLifetime      time.Duration
+	expectedLifetime time.Duration
+}
+
+// Option is an option for the clockskew chain element
+type Option func(o *options)
+
+// WithMinLifetime sets the minimum lifetime of the registration granted from the server receive time, 0 means none
+func WithMinLifetime(lifetime time.Duration) Option {
+	return func(o *options) {
+		o.minLifetime = lifetime
+	}
+}
+
+// WithMaxLifetime sets the maximum lifetime of the registration granted from the server receive time, 0 means none
+func WithMaxLifetime(lifetime time.Duration) Option {
+	return func(o *options) {
+		o.maxLifetime = lifetime
+	}
+}
+
+// WithExpectedLifetime sets the lifetime the clients ask for, e.g. the registry default expiration. If the request
+// has no ClientTimeMetadata, the skew is estimated as the difference between the asked and the expected lifetimes, so
+// a client asking for another lifetime is reported as skewed too. 0 means the skew is measured with
+// ClientTimeMetadata only.
+func WithExpectedLifetime(lifetime time.Duration) Option {
+	return func(o *options) {
+		o.expectedLifetime = lifetime
+	}
+}
+
+type clockSkewNSEServer struct {
+	options
+	skew    metric.Float64Histogram
+	clamped metric.Int64Counter
+}
+
+// NewNetworkServiceEndpointRegistryServer creates a new NSE server chain element normalizing the expiration time of
+// the registered NSE. The lifetime the client asks for is the expiration time minus the client time from the
+// ClientTimeMetadata, or minus the server receive time if there is none. The granted expiration time is the server
+// receive time plus the lifetime clamped into [min, max] lifetime and is returned to the client with the registered
+// NSE. The absolute client clock skew is exposed as the registry.nse.clock_skew metric with the "source" attribute:
+// "metadata" for the skew measured with ClientTimeMetadata, "lifetime" for the one estimated with the expected
+// lifetime. The clamped lifetimes are exposed as the registry.nse.expiration.clamped metric.
+func NewNetworkServiceEndpointRegistryServer(opts ...Option) registry.NetworkServiceEndpointRegistryServer {
+	s := new(clockSkewNSEServer)
+	for _, opt := range opts {
+		opt(&s.options)
+	}
+	meter := otel.Meter("registry-memory")
+	var err error
+	if s.skew, err = meter.Float64Histogram("registry.nse.clock_skew",
+		metric.WithDescription("Absolute difference between the client and the server clocks detected on NSE registration"),
+		metric.WithUnit("s")); err != nil {
+		log.L().Errorf("failed to create NSE clock skew histogram: %s", err.Error())
+	}
+	if s.clamped, err = meter.Int64Counter("registry.nse.expiration.clamped",
+		metric.WithDescription("Number of the NSE registrations with the lifetime clamped to the min or max lifetime by bound")); err != nil {
+		log.L().Errorf("failed to create NSE clamped expiration counter: %s", err.Error())
+	}
+	return s
+}
+
+func (s *clockSkewNSEServer) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*registry.NetworkServiceEndpoint, error) {
+	if nse.GetExpirationTime() != nil {
+		received := clock.FromContext(ctx).Now()
+		sent := received
+		clientTime, ok := clientTimeFromContext(ctx)
+		if ok {
+			sent = clientTime
+		}
+		lifetime := nse.GetExpirationTime().AsTime().Sub(sent)
+		switch {
+		case ok:
+			s.record(ctx, received.Sub(clientTime), "metadata")
+		case s.expectedLifetime > 0:
+			s.record(ctx, lifetime-s.expectedLifetime, "lifetime")
+		}
+		switch {
+		case s.minLifetime > 0 && lifetime < s.minLifetime:
+			lifetime = s.minLifetime
+			s.count(ctx, "min")
+		case s.maxLifetime > 0 && lifetime > s.maxLifetime:
+			lifetime = s.maxLifetime
+			s.count(ctx, "max")
+		}
+		nse.ExpirationTime = timestamppb.New(received.Add(lifetime))
+	}
+	return next.NetworkServiceEndpointRegistryServer(ctx).Register(ctx, nse)
+}
+
+func (s *clockSkewNSEServer) record(ctx context.Context, skew time.Duration, source string) {
+	if s.skew != nil {
+		s.skew.Record(ctx, math.Abs(skew.Seconds()), metric.WithAttributes(attribute.String("source", source)))
+	}
+}
+
+func (s *clockSkewNSEServer) count(ctx context.Context, bound string) {
+	if s.clamped != nil {
+		s.clamped.Add(ctx, 1, metric.WithAttributes(attribute.String("bound", bound)))
+	}
+}
+
+// clientTimeFromContext returns the client time from the ClientTimeMetadata
+func clientTimeFromContext(ctx context.Context) (time.Time, bool) {
+	md, _ := metadata.FromIncomingContext(ctx)
+	values := md.Get(ClientTimeMetadata)
+	if len(values) == 0 {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, values[0])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+func (s *clockSkewNSEServer) Find(query *registry.NetworkServiceEndpointQuery, server registry.NetworkServiceEndpointRegistry_FindServer) error {
+	return next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, server)
+}
+
+func (s *clockSkewNSEServer) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*empty.Empty, error) {
+	return next.NetworkServiceEndpointRegistryServer(ctx).Unregister(ctx, nse)
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clockskew_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/chain"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clockmock"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/clockskew"
+)
+
+func TestClockSkewNSEServer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clockMock := clockmock.New(ctx)
+	ctx = clock.WithClock(ctx, clockMock)
+
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		clockskew.NewNetworkServiceEndpointRegistryServer(
+			clockskew.WithMinLifetime(10*time.Second),
+			clockskew.WithMaxLifetime(time.Hour)),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+	now := clockMock.Now()
+
+	for _, tc := range []struct {
+		name       string
+		clientTime time.Time
+		expiration time.Time
+		granted    time.Time
+	}{
+		{name: "no skew", expiration: now.Add(time.Minute), granted: now.Add(time.Minute)},
+		{name: "client ahead", clientTime: now.Add(time.Hour), expiration: now.Add(time.Hour + time.Minute), granted: now.Add(time.Minute)},
+		{name: "client behind", clientTime: now.Add(-time.Hour), expiration: now.Add(-time.Hour + time.Minute), granted: now.Add(time.Minute)},
+		{name: "expired", expiration: now.Add(-time.Minute), granted: now.Add(10 * time.Second)},
+		{name: "near-immortal", expiration: now.Add(24 * time.Hour), granted: now.Add(time.Hour)},
+	} {
+		registerCtx := ctx
+		if !tc.clientTime.IsZero() {
+			registerCtx = metadata.NewIncomingContext(ctx,
+				metadata.Pairs(clockskew.ClientTimeMetadata, tc.clientTime.Format(time.RFC3339Nano)))
+		}
+		registered, err := client.Register(registerCtx, &registry.NetworkServiceEndpoint{
+			Name:           "nse",
+			ExpirationTime: timestamppb.New(tc.expiration),
+		})
+		require.NoError(t, err, tc.name)
+		require.True(t, tc.granted.Equal(registered.GetExpirationTime().AsTime()), tc.name)
+	}
+}
+
+func TestClockSkewNSEServer_NoExpiration(t *testing.T) {
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		clockskew.NewNetworkServiceEndpointRegistryServer(clockskew.WithMinLifetime(time.Second)),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+
+	registered, err := client.Register(context.Background(), &registry.NetworkServiceEndpoint{Name: "nse"})
+	require.NoError(t, err)
+	require.Nil(t, registered.GetExpirationTime())
+}
+
+func TestClockSkewNSEServer_SkewMetric(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clockMock := clockmock.New(ctx)
+	ctx = clock.WithClock(ctx, clockMock)
+
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		clockskew.NewNetworkServiceEndpointRegistryServer(clockskew.WithExpectedLifetime(time.Minute)),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+	now := clockMock.Now()
+
+	// The client time is 10s behind
+	registerCtx := metadata.NewIncomingContext(ctx,
+		metadata.Pairs(clockskew.ClientTimeMetadata, now.Add(-10*time.Second).Format(time.RFC3339Nano)))
+	_, err := client.Register(registerCtx, &registry.NetworkServiceEndpoint{
+		Name:           "nse-1",
+		ExpirationTime: timestamppb.New(now.Add(50 * time.Second)),
+	})
+	require.NoError(t, err)
+
+	// No client time, the client asking for 1m is 30s ahead
+	_, err = client.Register(ctx, &registry.NetworkServiceEndpoint{
+		Name:           "nse-2",
+		ExpirationTime: timestamppb.New(now.Add(90 * time.Second)),
+	})
+	require.NoError(t, err)
+
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(ctx, &rm))
+	skews := make(map[string]float64)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			histogram, ok := m.Data.(metricdata.Histogram[float64])
+			if !ok || m.Name != "registry.nse.clock_skew" {
+				continue
+			}
+			for _, dp := range histogram.DataPoints {
+				source, _ := dp.Attributes.Value(attribute.Key("source"))
+				skews[source.AsString()] = dp.Sum
+			}
+		}
+	}
+	require.Equal(t, map[string]float64{"metadata": 10, "lifetime": 30}, skews)
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package conflicts provides a registry server chain element rejecting the conflicting NSE registrations instead of
+// silently overwriting the existing ones
+package conflicts
+
+import (
+	"context"
+
+	"github.com/edwarnicke/genericsync"
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/peerid"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/inprocess"
+)
+
+type conflictsNSEServer struct {
+	// owners keeps the SPIFFE IDs registered the NSE names
+	owners genericsync.Map[string, string]
+}
+
+// NewNetworkServiceEndpointRegistryServer creates a new NSE server chain element rejecting a Register:
+//   - with AlreadyExists if the NSE URL is already used by an NSE with a different name;
+//   - with PermissionDenied if the NSE name is already registered by a different SPIFFE ID.
+//
+// The in-process registrations made by the registry itself are not checked.
+func NewNetworkServiceEndpointRegistryServer() registry.NetworkServiceEndpointRegistryServer {
+	return new(conflictsNSEServer)
+}
+
+func (s *conflictsNSEServer) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*registry.NetworkServiceEndpoint, error) {
+	if inprocess.FromContext(ctx) {
+		return next.NetworkServiceEndpointRegistryServer(ctx).Register(ctx, nse)
+	}
+	callerID, hasID := peerid.FromContext(ctx)
+
+	if owner, ok := s.owners.Load(nse.GetName()); ok && hasID && owner != callerID.String() {
+		existing, err := s.find(ctx, &registry.NetworkServiceEndpoint{Name: nse.GetName()})
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range existing {
+			if e.GetName() == nse.GetName() {
+				return nil, status.Errorf(codes.PermissionDenied, "%s is already registered by %s", nse.GetName(), owner)
+			}
+		}
+	}
+
+	if nse.GetUrl() != "" {
+		existing, err := s.find(ctx, &registry.NetworkServiceEndpoint{Url: nse.GetUrl()})
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range existing {
+			if e.GetUrl() == nse.GetUrl() && e.GetName() != nse.GetName() {
+				return nil, status.Errorf(codes.AlreadyExists, "%s is already registered by %s", nse.GetUrl(), e.GetName())
+			}
+		}
+	}
+
+	resp, err := next.NetworkServiceEndpointRegistryServer(ctx).Register(ctx, nse)
+	if err != nil {
+		return nil, err
+	}
+	if hasID {
+		s.owners.Store(resp.GetName(), callerID.String())
+	}
+	return resp, nil
+}
+
+func (s *conflictsNSEServer) Find(query *registry.NetworkServiceEndpointQuery, server registry.NetworkServiceEndpointRegistry_FindServer) error {
+	return next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, server)
+}
+
+func (s *conflictsNSEServer) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*empty.Empty, error) {
+	resp, err := next.NetworkServiceEndpointRegistryServer(ctx).Unregister(ctx, nse)
+	if err != nil {
+		return nil, err
+	}
+	s.owners.Delete(nse.GetName())
+	return resp, nil
+}
+
+// find returns the registered NSEs matching the pattern
+func (s *conflictsNSEServer) find(ctx context.Context, pattern *registry.NetworkServiceEndpoint) ([]*registry.NetworkServiceEndpoint, error) {
+	collector := &nseCollector{ctx: ctx}
+	query := &registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: pattern}
+	if err := next.NetworkServiceEndpointRegistryServer(ctx).Find(query, collector); err != nil {
+		return nil, err
+	}
+	return collector.nses, nil
+}
+
+type nseCollector struct {
+	grpc.ServerStream
+	ctx  context.Context
+	nses []*registry.NetworkServiceEndpoint
+}
+
+func (c *nseCollector) Send(resp *registry.NetworkServiceEndpointResponse) error {
+	c.nses = append(c.nses, resp.GetNetworkServiceEndpoint())
+	return nil
+}
+
+func (c *nseCollector) Context() context.Context {
+	return c.ctx
+}
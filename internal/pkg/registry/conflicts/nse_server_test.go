@@ -0,0 +1,84 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conflicts_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/chain"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/conflicts"
+)
+
+func withPeer(ctx context.Context, spiffeID string) context.Context {
+	u, _ := url.Parse(spiffeID)
+	return peer.NewContext(ctx, &peer.Peer{
+		AuthInfo: credentials.TLSInfo{
+			State: tls.ConnectionState{PeerCertificates: []*x509.Certificate{{URIs: []*url.URL{u}}}},
+		},
+	})
+}
+
+func TestConflictsNSEServer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		conflicts.NewNetworkServiceEndpointRegistryServer(),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+
+	nseA := withPeer(ctx, "spiffe://example.com/nse-a")
+	nseB := withPeer(ctx, "spiffe://example.com/nse-b")
+
+	_, err := client.Register(nseA, &registry.NetworkServiceEndpoint{Name: "nse-a", Url: "tcp://1.1.1.1:5001"})
+	require.NoError(t, err)
+
+	// Refresh by the owner is allowed
+	_, err = client.Register(nseA, &registry.NetworkServiceEndpoint{Name: "nse-a", Url: "tcp://1.1.1.1:5001"})
+	require.NoError(t, err)
+
+	_, err = client.Register(nseB, &registry.NetworkServiceEndpoint{Name: "nse-b", Url: "tcp://1.1.1.1:5001"})
+	require.Equal(t, codes.AlreadyExists, status.Code(err))
+
+	_, err = client.Register(nseB, &registry.NetworkServiceEndpoint{Name: "nse-a", Url: "tcp://1.1.1.1:5002"})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	// A prefix of the registered URL is not a duplicate
+	_, err = client.Register(nseB, &registry.NetworkServiceEndpoint{Name: "nse-b", Url: "tcp://1.1.1.1:500"})
+	require.NoError(t, err)
+
+	_, err = client.Unregister(nseA, &registry.NetworkServiceEndpoint{Name: "nse-a"})
+	require.NoError(t, err)
+
+	_, err = client.Register(nseB, &registry.NetworkServiceEndpoint{Name: "nse-a", Url: "tcp://1.1.1.1:5001"})
+	require.NoError(t, err)
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package defaultexpiration provides NSE registry server chain element setting expiration time for endpoints
+// registered without one
+package defaultexpiration
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+)
+
+type defaultExpirationNSEServer struct {
+	defaultExpiration time.Duration
+}
+
+// NewNetworkServiceEndpointRegistryServer creates a new NSE server chain element setting expiration time to
+// now + defaultExpiration if the registered NSE has no expiration time
+func NewNetworkServiceEndpointRegistryServer(defaultExpiration time.Duration) registry.NetworkServiceEndpointRegistryServer {
+	return &defaultExpirationNSEServer{
+		defaultExpiration: defaultExpiration,
+	}
+}
+
+func (s *defaultExpirationNSEServer) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*registry.NetworkServiceEndpoint, error) {
+	if nse.GetExpirationTime() == nil && s.defaultExpiration > 0 {
+		nse.ExpirationTime = timestamppb.New(clock.FromContext(ctx).Now().Add(s.defaultExpiration))
+	}
+	return next.NetworkServiceEndpointRegistryServer(ctx).Register(ctx, nse)
+}
+
+func (s *defaultExpirationNSEServer) Find(query *registry.NetworkServiceEndpointQuery, server registry.NetworkServiceEndpointRegistry_FindServer) error {
+	return next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, server)
+}
+
+func (s *defaultExpirationNSEServer) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*empty.Empty, error) {
+	return next.NetworkServiceEndpointRegistryServer(ctx).Unregister(ctx, nse)
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package defaultexpiration_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/chain"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clockmock"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/defaultexpiration"
+)
+
+func newNSEClient(defaultExpiration time.Duration) registry.NetworkServiceEndpointRegistryClient {
+	return adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		defaultexpiration.NewNetworkServiceEndpointRegistryServer(defaultExpiration),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+}
+
+func TestDefaultExpirationNSEServer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	clockMock := clockmock.New(ctx)
+	ctx = clock.WithClock(ctx, clockMock)
+
+	client := newNSEClient(time.Minute)
+
+	resp, err := client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-1"})
+	require.NoError(t, err)
+	require.True(t, clockMock.Now().Add(time.Minute).Equal(resp.GetExpirationTime().AsTime()))
+
+	// The expiration time of the NSE is kept
+	expirationTime := clockMock.Now().Add(time.Hour)
+	resp, err = client.Register(ctx, &registry.NetworkServiceEndpoint{
+		Name:           "nse-2",
+		ExpirationTime: timestamppb.New(expirationTime),
+	})
+	require.NoError(t, err)
+	require.True(t, expirationTime.Equal(resp.GetExpirationTime().AsTime()))
+}
+
+func TestDefaultExpirationNSEServer_Disabled(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	resp, err := newNSEClient(0).Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-1"})
+	require.NoError(t, err)
+	require.Nil(t, resp.GetExpirationTime())
+}
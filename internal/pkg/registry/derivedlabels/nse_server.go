@@ -0,0 +1,96 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package derivedlabels
+
+import (
+	"context"
+	"strings"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/pkg/errors"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+)
+
+type derivedLabelsNSEServer struct {
+	rules []*Rule
+}
+
+// NewNetworkServiceEndpointRegistryServer creates a new NSE server chain element setting the derived labels of the
+// registered NSEs
+func NewNetworkServiceEndpointRegistryServer(rules []*Rule) registry.NetworkServiceEndpointRegistryServer {
+	return &derivedLabelsNSEServer{
+		rules: rules,
+	}
+}
+
+func (s *derivedLabelsNSEServer) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*registry.NetworkServiceEndpoint, error) {
+	if err := s.derive(nse); err != nil {
+		return nil, err
+	}
+	return next.NetworkServiceEndpointRegistryServer(ctx).Register(ctx, nse)
+}
+
+func (s *derivedLabelsNSEServer) Find(query *registry.NetworkServiceEndpointQuery, server registry.NetworkServiceEndpointRegistry_FindServer) error {
+	return next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, server)
+}
+
+func (s *derivedLabelsNSEServer) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*empty.Empty, error) {
+	return next.NetworkServiceEndpointRegistryServer(ctx).Unregister(ctx, nse)
+}
+
+// derive sets the labels rendered by the rules for each network service of the NSE
+func (s *derivedLabelsNSEServer) derive(nse *registry.NetworkServiceEndpoint) error {
+	for _, ns := range nse.GetNetworkServiceNames() {
+		for _, rule := range s.rules {
+			if rule.NetworkService != "" && rule.NetworkService != ns {
+				continue
+			}
+			labels := nse.GetNetworkServiceLabels()[ns].GetLabels()
+			if _, ok := labels[rule.Label]; ok && !rule.Override {
+				continue
+			}
+
+			var value strings.Builder
+			if err := rule.Template.Execute(&value, &Data{
+				Name:           nse.GetName(),
+				URL:            nse.GetUrl(),
+				NetworkService: ns,
+				Labels:         labels,
+			}); err != nil {
+				return errors.Wrapf(err, "failed to derive label %s of %s", rule.Label, nse.GetName())
+			}
+			if value.Len() == 0 {
+				continue
+			}
+
+			if nse.NetworkServiceLabels == nil {
+				nse.NetworkServiceLabels = make(map[string]*registry.NetworkServiceLabels)
+			}
+			if nse.NetworkServiceLabels[ns] == nil {
+				nse.NetworkServiceLabels[ns] = new(registry.NetworkServiceLabels)
+			}
+			if nse.NetworkServiceLabels[ns].Labels == nil {
+				nse.NetworkServiceLabels[ns].Labels = make(map[string]string)
+			}
+			nse.NetworkServiceLabels[ns].Labels[rule.Label] = value.String()
+		}
+	}
+	return nil
+}
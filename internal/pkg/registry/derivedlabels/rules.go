@@ -0,0 +1,140 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package derivedlabels provides the registry server chain element setting the labels derived from the other NSE
+// fields by the operator defined templates, so all the consumers see uniform metadata
+package derivedlabels
+
+import (
+	"encoding/json"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/pkg/errors"
+)
+
+// Rule derives a label from the NSE fields
+type Rule struct {
+	// Label is the key of the derived label
+	Label string
+	// NetworkService is the network service the label is set for, empty means all the network services of the NSE
+	NetworkService string
+	// Template renders the label value from Data, the label is not set if the value is empty
+	Template *template.Template
+	// Override replaces the value set by the NSE, otherwise the NSE value is kept
+	Override bool
+}
+
+// Data is the data the templates are executed with
+type Data struct {
+	// Name is the NSE name
+	Name string
+	// URL is the NSE URL
+	URL string
+	// NetworkService is the network service the label is derived for
+	NetworkService string
+	// Labels are the labels of the NSE for the network service
+	Labels map[string]string
+}
+
+type fileRule struct {
+	Label          string `json:"label"`
+	NetworkService string `json:"network_service"`
+	Template       string `json:"template"`
+	Override       bool   `json:"override"`
+}
+
+// funcs are the template functions in addition to the text/template builtins
+var funcs = template.FuncMap{
+	"host": func(rawURL string) string {
+		if u, err := url.Parse(rawURL); err == nil {
+			return u.Hostname()
+		}
+		return ""
+	},
+	"before": func(sep, s string) string {
+		before, _, _ := strings.Cut(s, sep)
+		return before
+	},
+	"after": func(sep, s string) string {
+		_, after, _ := strings.Cut(s, sep)
+		return after
+	},
+	"split":      func(sep, s string) []string { return strings.Split(s, sep) },
+	"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+	"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+	"lower":      strings.ToLower,
+	"upper":      strings.ToUpper,
+	"regex": func(pattern, s string) (string, error) {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return "", err
+		}
+		match := re.FindStringSubmatch(s)
+		switch {
+		case match == nil:
+			return "", nil
+		case len(match) > 1:
+			return match[1], nil
+		default:
+			return match[0], nil
+		}
+	},
+}
+
+// LoadFile reads the rules from the JSON file, they are applied in the file order:
+//
+//	[
+//	  {"label": "cluster", "template": "{{ .URL | host | after \".\" | before \".\" }}"},
+//	  {"label": "app", "network_service": "<ns name>", "template": "{{ .Name | before \"-\" }}", "override": true}
+//	]
+//
+// The templates are text/template ones executed with Data. Besides the builtins there are the functions: host (the
+// URL host name), before and after (the part of the string before or after the first separator), split, trimPrefix,
+// trimSuffix, lower, upper and regex (the first submatch of the pattern, or the whole match if there are no groups).
+func LoadFile(filePath string) ([]*Rule, error) {
+	// #nosec G304 - the file path comes from the configuration
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read derived labels %s", filePath)
+	}
+
+	var fileRules []*fileRule
+	if err = json.Unmarshal(data, &fileRules); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse derived labels %s", filePath)
+	}
+
+	rules := make([]*Rule, 0, len(fileRules))
+	for i, r := range fileRules {
+		if r.Label == "" {
+			return nil, errors.Errorf("derived label %d has no label", i)
+		}
+		tmpl, parseErr := template.New(r.Label).Funcs(funcs).Option("missingkey=zero").Parse(r.Template)
+		if parseErr != nil {
+			return nil, errors.Wrapf(parseErr, "invalid template of derived label %s", r.Label)
+		}
+		rules = append(rules, &Rule{
+			Label:          r.Label,
+			NetworkService: r.NetworkService,
+			Template:       tmpl,
+			Override:       r.Override,
+		})
+	}
+	return rules, nil
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package derivedlabels_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/chain"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/derivedlabels"
+)
+
+const rulesJSON = `[
+  {"label": "cluster", "template": "{{ .URL | host | after \".\" | before \".\" }}"},
+  {"label": "app", "template": "{{ .Name | before \"-\" }}", "override": true},
+  {"label": "zone", "network_service": "ns-2", "template": "{{ regex \"zone-([a-z]+)\" .Name }}"},
+  {"label": "tier", "template": "{{ .Labels.app | upper }}"}
+]`
+
+func TestDerivedLabelsNSEServer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	filePath := filepath.Join(t.TempDir(), "derived-labels.json")
+	require.NoError(t, os.WriteFile(filePath, []byte(rulesJSON), 0o600))
+	rules, err := derivedlabels.LoadFile(filePath)
+	require.NoError(t, err)
+
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		derivedlabels.NewNetworkServiceEndpointRegistryServer(rules),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+
+	resp, err := client.Register(ctx, &registry.NetworkServiceEndpoint{
+		Name:                "web-zone-east-1",
+		Url:                 "tcp://nse.cluster-1.example.com:5001",
+		NetworkServiceNames: []string{"ns-1", "ns-2"},
+		NetworkServiceLabels: map[string]*registry.NetworkServiceLabels{
+			"ns-1": {Labels: map[string]string{"app": "custom", "cluster": "custom"}},
+		},
+	})
+	require.NoError(t, err)
+
+	// The NSE labels are kept unless overridden, the later rules see the derived labels
+	require.Equal(t, map[string]string{"app": "web", "cluster": "custom", "tier": "WEB"},
+		resp.GetNetworkServiceLabels()["ns-1"].GetLabels())
+	require.Equal(t, map[string]string{"app": "web", "cluster": "cluster-1", "zone": "east", "tier": "WEB"},
+		resp.GetNetworkServiceLabels()["ns-2"].GetLabels())
+}
+
+func TestLoadFile_Invalid(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "derived-labels.json")
+	require.NoError(t, os.WriteFile(filePath, []byte(`[{"label": "app", "template": "{{ .Name | before }"}]`), 0o600))
+	_, err := derivedlabels.LoadFile(filePath)
+	require.Error(t, err)
+
+	require.NoError(t, os.WriteFile(filePath, []byte(`[{"template": "{{ .Name }}"}]`), 0o600))
+	_, err = derivedlabels.LoadFile(filePath)
+	require.Error(t, err)
+}
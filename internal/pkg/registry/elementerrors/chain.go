@@ -0,0 +1,116 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elementerrors
+
+import (
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/trace"
+)
+
+// NewNetworkServiceRegistryServer creates a chain of servers tagging the errors with the element names
+func NewNetworkServiceRegistryServer(servers ...registry.NetworkServiceRegistryServer) registry.NetworkServiceRegistryServer {
+	return next.NewNetworkServiceRegistryServer(next.NewWrappedNetworkServiceRegistryServer(
+		func(server registry.NetworkServiceRegistryServer) registry.NetworkServiceRegistryServer {
+			name := elementName(server)
+			if named, ok := server.(*namedNSServer); ok {
+				name, server = named.name, named.NetworkServiceRegistryServer
+			}
+			return &nsServer{name: name, server: trace.NewNetworkServiceRegistryServer(server)}
+		}, servers...))
+}
+
+// NewNetworkServiceEndpointRegistryServer creates a chain of servers tagging the errors with the element names
+func NewNetworkServiceEndpointRegistryServer(servers ...registry.NetworkServiceEndpointRegistryServer) registry.NetworkServiceEndpointRegistryServer {
+	return next.NewNetworkServiceEndpointRegistryServer(next.NewWrappedNetworkServiceEndpointRegistryServer(
+		func(server registry.NetworkServiceEndpointRegistryServer) registry.NetworkServiceEndpointRegistryServer {
+			name := elementName(server)
+			if named, ok := server.(*namedNSEServer); ok {
+				name, server = named.name, named.NetworkServiceEndpointRegistryServer
+			}
+			return &nseServer{name: name, server: trace.NewNetworkServiceEndpointRegistryServer(server)}
+		}, servers...))
+}
+
+// NewNetworkServiceRegistryClient creates a chain of clients tagging the errors with the element names
+func NewNetworkServiceRegistryClient(clients ...registry.NetworkServiceRegistryClient) registry.NetworkServiceRegistryClient {
+	return next.NewNetworkServiceRegistryClient(next.NewWrappedNetworkServiceRegistryClient(
+		func(client registry.NetworkServiceRegistryClient) registry.NetworkServiceRegistryClient {
+			name := elementName(client)
+			if named, ok := client.(*namedNSClient); ok {
+				name, client = named.name, named.NetworkServiceRegistryClient
+			}
+			return &nsClient{name: name, client: trace.NewNetworkServiceRegistryClient(client)}
+		}, clients...))
+}
+
+// NewNetworkServiceEndpointRegistryClient creates a chain of clients tagging the errors with the element names
+func NewNetworkServiceEndpointRegistryClient(clients ...registry.NetworkServiceEndpointRegistryClient) registry.NetworkServiceEndpointRegistryClient {
+	return next.NewNetworkServiceEndpointRegistryClient(next.NewWrappedNetworkServiceEndpointRegistryClient(
+		func(client registry.NetworkServiceEndpointRegistryClient) registry.NetworkServiceEndpointRegistryClient {
+			name := elementName(client)
+			if named, ok := client.(*namedNSEClient); ok {
+				name, client = named.name, named.NetworkServiceEndpointRegistryClient
+			}
+			return &nseClient{name: name, client: trace.NewNetworkServiceEndpointRegistryClient(client)}
+		}, clients...))
+}
+
+type namedNSServer struct {
+	registry.NetworkServiceRegistryServer
+	name string
+}
+
+// NamedNetworkServiceRegistryServer sets the element name for the wrapping elements like swap, whose package name
+// says nothing about the errors
+func NamedNetworkServiceRegistryServer(name string, server registry.NetworkServiceRegistryServer) registry.NetworkServiceRegistryServer {
+	return &namedNSServer{NetworkServiceRegistryServer: server, name: name}
+}
+
+type namedNSEServer struct {
+	registry.NetworkServiceEndpointRegistryServer
+	name string
+}
+
+// NamedNetworkServiceEndpointRegistryServer sets the element name for the wrapping elements like swap, whose package
+// name says nothing about the errors
+func NamedNetworkServiceEndpointRegistryServer(name string, server registry.NetworkServiceEndpointRegistryServer) registry.NetworkServiceEndpointRegistryServer {
+	return &namedNSEServer{NetworkServiceEndpointRegistryServer: server, name: name}
+}
+
+type namedNSClient struct {
+	registry.NetworkServiceRegistryClient
+	name string
+}
+
+// NamedNetworkServiceRegistryClient sets the element name for the wrapping elements like swap, whose package name
+// says nothing about the errors
+func NamedNetworkServiceRegistryClient(name string, client registry.NetworkServiceRegistryClient) registry.NetworkServiceRegistryClient {
+	return &namedNSClient{NetworkServiceRegistryClient: client, name: name}
+}
+
+type namedNSEClient struct {
+	registry.NetworkServiceEndpointRegistryClient
+	name string
+}
+
+// NamedNetworkServiceEndpointRegistryClient sets the element name for the wrapping elements like swap, whose package
+// name says nothing about the errors
+func NamedNetworkServiceEndpointRegistryClient(name string, client registry.NetworkServiceEndpointRegistryClient) registry.NetworkServiceEndpointRegistryClient {
+	return &namedNSEClient{NetworkServiceEndpointRegistryClient: client, name: name}
+}
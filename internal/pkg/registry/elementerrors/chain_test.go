@@ -0,0 +1,87 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elementerrors_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/null"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/elementerrors"
+)
+
+type failNSEServer struct {
+	err error
+}
+
+func (s *failNSEServer) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*registry.NetworkServiceEndpoint, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return next.NetworkServiceEndpointRegistryServer(ctx).Register(ctx, nse)
+}
+
+func (s *failNSEServer) Find(query *registry.NetworkServiceEndpointQuery, server registry.NetworkServiceEndpointRegistry_FindServer) error {
+	return next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, server)
+}
+
+func (s *failNSEServer) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*empty.Empty, error) {
+	return next.NetworkServiceEndpointRegistryServer(ctx).Unregister(ctx, nse)
+}
+
+func TestChain_TagsOriginatingElement(t *testing.T) {
+	server := elementerrors.NewNetworkServiceEndpointRegistryServer(
+		null.NewNetworkServiceEndpointRegistryServer(),
+		elementerrors.NamedNetworkServiceEndpointRegistryServer("outer", &failNSEServer{}),
+		elementerrors.NewNetworkServiceEndpointRegistryServer(
+			elementerrors.NamedNetworkServiceEndpointRegistryServer("inner", &failNSEServer{
+				err: status.Error(codes.PermissionDenied, "denied"),
+			}),
+		),
+	)
+
+	_, err := server.Register(context.Background(), &registry.NetworkServiceEndpoint{Name: "nse-1"})
+	require.Error(t, err)
+
+	element, ok := elementerrors.Element(err)
+	require.True(t, ok)
+	require.Equal(t, "inner", element)
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	require.Contains(t, status.Convert(err).Message(), "denied")
+}
+
+func TestChain_NoError(t *testing.T) {
+	server := elementerrors.NewNetworkServiceEndpointRegistryServer(
+		elementerrors.NamedNetworkServiceEndpointRegistryServer("pass", &failNSEServer{}),
+	)
+
+	resp, err := server.Register(context.Background(), &registry.NetworkServiceEndpoint{Name: "nse-1"})
+	require.NoError(t, err)
+	require.Equal(t, "nse-1", resp.GetName())
+
+	_, ok := elementerrors.Element(err)
+	require.False(t, ok)
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elementerrors
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+)
+
+type nsClient struct {
+	name   string
+	client registry.NetworkServiceRegistryClient
+}
+
+func (c *nsClient) Register(ctx context.Context, ns *registry.NetworkService, opts ...grpc.CallOption) (*registry.NetworkService, error) {
+	resp, err := c.client.Register(ctx, ns, opts...)
+	return resp, tag(ctx, c.name, methodRegister, err)
+}
+
+func (c *nsClient) Find(ctx context.Context, query *registry.NetworkServiceQuery, opts ...grpc.CallOption) (registry.NetworkServiceRegistry_FindClient, error) {
+	resp, err := c.client.Find(ctx, query, opts...)
+	return resp, tag(ctx, c.name, methodFind, err)
+}
+
+func (c *nsClient) Unregister(ctx context.Context, ns *registry.NetworkService, opts ...grpc.CallOption) (*empty.Empty, error) {
+	resp, err := c.client.Unregister(ctx, ns, opts...)
+	return resp, tag(ctx, c.name, methodUnregister, err)
+}
+
+type nseClient struct {
+	name   string
+	client registry.NetworkServiceEndpointRegistryClient
+}
+
+func (c *nseClient) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint, opts ...grpc.CallOption) (*registry.NetworkServiceEndpoint, error) {
+	resp, err := c.client.Register(ctx, nse, opts...)
+	return resp, tag(ctx, c.name, methodRegister, err)
+}
+
+func (c *nseClient) Find(ctx context.Context, query *registry.NetworkServiceEndpointQuery, opts ...grpc.CallOption) (registry.NetworkServiceEndpointRegistry_FindClient, error) {
+	resp, err := c.client.Find(ctx, query, opts...)
+	return resp, tag(ctx, c.name, methodFind, err)
+}
+
+func (c *nseClient) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint, opts ...grpc.CallOption) (*empty.Empty, error) {
+	resp, err := c.client.Unregister(ctx, nse, opts...)
+	return resp, tag(ctx, c.name, methodUnregister, err)
+}
@@ -0,0 +1,127 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package elementerrors provides registry chains tagging the errors with the name of the chain element returning them
+// and counting them by the element in the registry.chain.errors metric
+package elementerrors
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/grpc/status"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/log"
+)
+
+type stackTracer interface {
+	StackTrace() errors.StackTrace
+}
+
+type elementError struct {
+	element string
+	err     error
+}
+
+func (e *elementError) Error() string {
+	return e.err.Error()
+}
+
+func (e *elementError) Unwrap() error {
+	return e.err
+}
+
+func (e *elementError) Cause() error {
+	return e.err
+}
+
+// GRPCStatus keeps the status of the tagged error for the clients
+func (e *elementError) GRPCStatus() *status.Status {
+	return status.Convert(e.err)
+}
+
+// StackTrace keeps the stack trace of the tagged error for the trace chain elements
+func (e *elementError) StackTrace() errors.StackTrace {
+	if st, ok := e.err.(stackTracer); ok {
+		return st.StackTrace()
+	}
+	return nil
+}
+
+// Format prints the element name along with the tagged error
+func (e *elementError) Format(s fmt.State, verb rune) {
+	_, _ = fmt.Fprintf(s, "[%s] ", e.element)
+	if f, ok := e.err.(fmt.Formatter); ok {
+		f.Format(s, verb)
+		return
+	}
+	_, _ = fmt.Fprint(s, e.err.Error())
+}
+
+// Element returns the name of the chain element the error originates from
+func Element(err error) (string, bool) {
+	var e *elementError
+	if errors.As(err, &e) {
+		return e.element, true
+	}
+	return "", false
+}
+
+var (
+	errorsCounterOnce sync.Once
+	errorsCounter     metric.Int64Counter
+)
+
+// tag tags err with the element name and counts it, unless it is already tagged by a deeper element
+func tag(ctx context.Context, element, method string, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := Element(err); ok {
+		return err
+	}
+
+	errorsCounterOnce.Do(func() {
+		var counterErr error
+		errorsCounter, counterErr = otel.Meter("registry-memory").Int64Counter("registry.chain.errors",
+			metric.WithDescription("Number of errors returned by the registry chain elements"))
+		if counterErr != nil {
+			log.L().Errorf("failed to create chain errors counter: %s", counterErr.Error())
+		}
+	})
+	if errorsCounter != nil {
+		errorsCounter.Add(ctx, 1, metric.WithAttributes(
+			attribute.String("element", element),
+			attribute.String("method", method),
+			attribute.String("code", status.Code(err).String())))
+	}
+	return &elementError{element: element, err: err}
+}
+
+// elementName returns the package name of the element type, e.g. "authorize" for *authorize.authorizeNSEServer
+func elementName(element interface{}) string {
+	name := strings.TrimLeft(fmt.Sprintf("%T", element), "*")
+	if i := strings.Index(name, "."); i >= 0 {
+		return name[:i]
+	}
+	return name
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package elementerrors
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+)
+
+const (
+	methodRegister   = "Register"
+	methodFind       = "Find"
+	methodUnregister = "Unregister"
+)
+
+type nsServer struct {
+	name   string
+	server registry.NetworkServiceRegistryServer
+}
+
+func (s *nsServer) Register(ctx context.Context, ns *registry.NetworkService) (*registry.NetworkService, error) {
+	resp, err := s.server.Register(ctx, ns)
+	return resp, tag(ctx, s.name, methodRegister, err)
+}
+
+func (s *nsServer) Find(query *registry.NetworkServiceQuery, server registry.NetworkServiceRegistry_FindServer) error {
+	return tag(server.Context(), s.name, methodFind, s.server.Find(query, server))
+}
+
+func (s *nsServer) Unregister(ctx context.Context, ns *registry.NetworkService) (*empty.Empty, error) {
+	resp, err := s.server.Unregister(ctx, ns)
+	return resp, tag(ctx, s.name, methodUnregister, err)
+}
+
+type nseServer struct {
+	name   string
+	server registry.NetworkServiceEndpointRegistryServer
+}
+
+func (s *nseServer) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*registry.NetworkServiceEndpoint, error) {
+	resp, err := s.server.Register(ctx, nse)
+	return resp, tag(ctx, s.name, methodRegister, err)
+}
+
+func (s *nseServer) Find(query *registry.NetworkServiceEndpointQuery, server registry.NetworkServiceEndpointRegistry_FindServer) error {
+	return tag(server.Context(), s.name, methodFind, s.server.Find(query, server))
+}
+
+func (s *nseServer) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*empty.Empty, error) {
+	resp, err := s.server.Unregister(ctx, nse)
+	return resp, tag(ctx, s.name, methodUnregister, err)
+}
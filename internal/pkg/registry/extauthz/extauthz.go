@@ -0,0 +1,342 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package extauthz provides registry server chain elements delegating the authorization decisions to an external
+// gRPC service implementing the Envoy ext_authz API:
+//
+//	service envoy.service.auth.v3.Authorization {
+//	  rpc Check(CheckRequest) returns (CheckResponse);
+//	}
+//
+// The request has the caller SPIFFE ID as attributes.source.principal, the registry gRPC method as
+// attributes.request.http.path and the resource as attributes.context_extensions["kind"] and ["name"]. The NSE calls
+// also have the comma separated network service names of the NSE or of the query as
+// attributes.context_extensions["network_service_names"], so the NSE queries by the network service only can be
+// scoped too. The call is allowed if the response status code is OK. The envoy protos are not a dependency of the
+// registry, so the messages are encoded with protowire.
+package extauthz
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/encoding"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/log"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/peerid"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/inprocess"
+)
+
+// CheckMethod is the full name of the ext_authz Check method
+const CheckMethod = "/envoy.service.auth.v3.Authorization/Check"
+
+const (
+	kindNetworkService         = "network_service"
+	kindNetworkServiceEndpoint = "network_service_endpoint"
+
+	defaultCacheTTL = 10 * time.Second
+	defaultTimeout  = 5 * time.Second
+	// maxCacheSize is the number of the cached decisions triggering the expired ones cleanup
+	maxCacheSize = 10000
+)
+
+type options struct {
+	cacheTTL time.Duration
+	timeout  time.Duration
+	failOpen bool
+}
+
+// Option is an option for the extauthz chain elements
+type Option func(o *options)
+
+// WithCacheTTL sets how long the decisions are cached, 0 disables the cache. Default is 10s.
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(o *options) {
+		o.cacheTTL = ttl
+	}
+}
+
+// WithTimeout sets the Check call timeout, default is 5s
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.timeout = timeout
+	}
+}
+
+// WithFailOpen allows the calls when the authorization service can't be reached. By default they fail with
+// Unavailable.
+func WithFailOpen() Option {
+	return func(o *options) {
+		o.failOpen = true
+	}
+}
+
+type decision struct {
+	err        error
+	expiration time.Time
+}
+
+// Authorizer calls the ext_authz service and caches its decisions. It is shared by the NS and NSE chain elements.
+type Authorizer struct {
+	options
+	cc grpc.ClientConnInterface
+
+	mu    sync.Mutex
+	cache map[string]decision
+}
+
+// NewAuthorizer creates a new Authorizer calling the ext_authz service over cc
+func NewAuthorizer(cc grpc.ClientConnInterface, opts ...Option) *Authorizer {
+	a := &Authorizer{
+		options: options{
+			cacheTTL: defaultCacheTTL,
+			timeout:  defaultTimeout,
+		},
+		cc:    cc,
+		cache: make(map[string]decision),
+	}
+	for _, opt := range opts {
+		opt(&a.options)
+	}
+	return a
+}
+
+// check returns nil if the caller is allowed to call the method for the resource. In-process calls are always allowed.
+func (a *Authorizer) check(ctx context.Context, method, kind, name string, networkServiceNames []string) error {
+	if inprocess.FromContext(ctx) {
+		return nil
+	}
+	var principal string
+	if id, ok := peerid.FromContext(ctx); ok {
+		principal = id.String()
+	}
+
+	nsNames := strings.Join(networkServiceNames, ",")
+	key := principal + "\x00" + method + "\x00" + kind + "\x00" + name + "\x00" + nsNames
+	now := clock.FromContext(ctx).Now()
+	if d, ok := a.load(key, now); ok {
+		return d.err
+	}
+
+	err := a.call(ctx, principal, method, kind, name, nsNames)
+	if status.Code(err) == codes.Unavailable {
+		if a.failOpen {
+			log.FromContext(ctx).Warnf("ext_authz failed, allowing %s for %s: %s", method, name, err.Error())
+			return nil
+		}
+		return err
+	}
+	a.store(key, err, now)
+	return err
+}
+
+func (a *Authorizer) load(key string, now time.Time) (decision, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	d, ok := a.cache[key]
+	if !ok || !now.Before(d.expiration) {
+		return decision{}, false
+	}
+	return d, true
+}
+
+func (a *Authorizer) store(key string, err error, now time.Time) {
+	if a.cacheTTL <= 0 {
+		return
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if len(a.cache) >= maxCacheSize {
+		for k, d := range a.cache {
+			if !now.Before(d.expiration) {
+				delete(a.cache, k)
+			}
+		}
+		if len(a.cache) >= maxCacheSize {
+			a.cache = make(map[string]decision)
+		}
+	}
+	a.cache[key] = decision{err: err, expiration: now.Add(a.cacheTTL)}
+}
+
+// call returns nil if the service allows the call, PermissionDenied if it denies and Unavailable if it fails
+func (a *Authorizer) call(ctx context.Context, principal, method, kind, name, nsNames string) error {
+	ctx, cancel := context.WithTimeout(ctx, a.timeout)
+	defer cancel()
+
+	req := encodeCheckRequest(principal, method, kind, name, nsNames)
+	var resp []byte
+	if err := a.cc.Invoke(ctx, CheckMethod, &req, &resp, grpc.ForceCodec(Codec())); err != nil {
+		return status.Errorf(codes.Unavailable, "ext_authz check failed: %s", err.Error())
+	}
+	code, message, err := decodeCheckResponse(resp)
+	if err != nil {
+		return status.Errorf(codes.Unavailable, "ext_authz check failed: %s", err.Error())
+	}
+	if code != int32(codes.OK) {
+		if message == "" {
+			message = "denied by ext_authz"
+		}
+		return status.Errorf(codes.PermissionDenied, "%s is not allowed for %s: %s", method, name, message)
+	}
+	return nil
+}
+
+// encodeCheckRequest encodes envoy.service.auth.v3.CheckRequest
+func encodeCheckRequest(principal, method, kind, name, nsNames string) []byte {
+	// AttributeContext.Peer{principal = 4}
+	var source []byte
+	source = protowire.AppendTag(source, 4, protowire.BytesType)
+	source = protowire.AppendString(source, principal)
+
+	// AttributeContext.HttpRequest{method = 2, path = 4}
+	var httpRequest []byte
+	httpRequest = protowire.AppendTag(httpRequest, 2, protowire.BytesType)
+	httpRequest = protowire.AppendString(httpRequest, "POST")
+	httpRequest = protowire.AppendTag(httpRequest, 4, protowire.BytesType)
+	httpRequest = protowire.AppendString(httpRequest, method)
+
+	// AttributeContext.Request{http = 2}
+	var request []byte
+	request = protowire.AppendTag(request, 2, protowire.BytesType)
+	request = protowire.AppendBytes(request, httpRequest)
+
+	// AttributeContext{source = 1, request = 4, context_extensions = 10}
+	var attributes []byte
+	attributes = protowire.AppendTag(attributes, 1, protowire.BytesType)
+	attributes = protowire.AppendBytes(attributes, source)
+	attributes = protowire.AppendTag(attributes, 4, protowire.BytesType)
+	attributes = protowire.AppendBytes(attributes, request)
+	extensions := [][2]string{{"kind", kind}, {"name", name}}
+	if nsNames != "" {
+		extensions = append(extensions, [2]string{"network_service_names", nsNames})
+	}
+	for _, kv := range extensions {
+		var entry []byte
+		entry = protowire.AppendTag(entry, 1, protowire.BytesType)
+		entry = protowire.AppendString(entry, kv[0])
+		entry = protowire.AppendTag(entry, 2, protowire.BytesType)
+		entry = protowire.AppendString(entry, kv[1])
+		attributes = protowire.AppendTag(attributes, 10, protowire.BytesType)
+		attributes = protowire.AppendBytes(attributes, entry)
+	}
+
+	// CheckRequest{attributes = 1}
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.BytesType)
+	return protowire.AppendBytes(b, attributes)
+}
+
+// decodeCheckResponse returns the code and message of envoy.service.auth.v3.CheckResponse status
+func decodeCheckResponse(b []byte) (code int32, message string, err error) {
+	// CheckResponse{status = 1}
+	statusBytes, err := field(b, 1)
+	if err != nil {
+		return 0, "", err
+	}
+	// google.rpc.Status{code = 1, message = 2}
+	for len(statusBytes) > 0 {
+		num, typ, n := protowire.ConsumeTag(statusBytes)
+		if n < 0 {
+			return 0, "", errors.WithStack(protowire.ParseError(n))
+		}
+		statusBytes = statusBytes[n:]
+		switch {
+		case num == 1 && typ == protowire.VarintType:
+			v, m := protowire.ConsumeVarint(statusBytes)
+			if m < 0 {
+				return 0, "", errors.WithStack(protowire.ParseError(m))
+			}
+			code, n = int32(v), m
+		case num == 2 && typ == protowire.BytesType:
+			v, m := protowire.ConsumeString(statusBytes)
+			if m < 0 {
+				return 0, "", errors.WithStack(protowire.ParseError(m))
+			}
+			message, n = v, m
+		default:
+			n = protowire.ConsumeFieldValue(num, typ, statusBytes)
+			if n < 0 {
+				return 0, "", errors.WithStack(protowire.ParseError(n))
+			}
+		}
+		statusBytes = statusBytes[n:]
+	}
+	return code, message, nil
+}
+
+// field returns the last value of the bytes field num of the message b
+func field(b []byte, num protowire.Number) ([]byte, error) {
+	var result []byte
+	for len(b) > 0 {
+		n, typ, m := protowire.ConsumeTag(b)
+		if m < 0 {
+			return nil, errors.WithStack(protowire.ParseError(m))
+		}
+		b = b[m:]
+		if n == num && typ == protowire.BytesType {
+			v, k := protowire.ConsumeBytes(b)
+			if k < 0 {
+				return nil, errors.WithStack(protowire.ParseError(k))
+			}
+			result, m = v, k
+		} else if m = protowire.ConsumeFieldValue(n, typ, b); m < 0 {
+			return nil, errors.WithStack(protowire.ParseError(m))
+		}
+		b = b[m:]
+	}
+	return result, nil
+}
+
+type rawCodec struct{}
+
+// Codec returns the gRPC codec passing the already encoded messages as *[]byte. It is named "proto", so the calls
+// have the application/grpc+proto content type expected by the ext_authz services.
+func Codec() encoding.Codec {
+	return rawCodec{}
+}
+
+func (rawCodec) Marshal(v interface{}) ([]byte, error) {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return nil, errors.Errorf("unexpected message type %T", v)
+	}
+	return *b, nil
+}
+
+func (rawCodec) Unmarshal(data []byte, v interface{}) error {
+	b, ok := v.(*[]byte)
+	if !ok {
+		return errors.Errorf("unexpected message type %T", v)
+	}
+	*b = append((*b)[:0], data...)
+	return nil
+}
+
+func (rawCodec) Name() string {
+	return "proto"
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package extauthz
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+)
+
+const (
+	nsRegisterMethod   = "/registry.NetworkServiceRegistry/Register"
+	nsFindMethod       = "/registry.NetworkServiceRegistry/Find"
+	nsUnregisterMethod = "/registry.NetworkServiceRegistry/Unregister"
+)
+
+type extAuthzNSServer struct {
+	authorizer *Authorizer
+}
+
+// NewNetworkServiceRegistryServer creates a new NS server chain element authorizing the calls with the
+// authorizer
+func NewNetworkServiceRegistryServer(authorizer *Authorizer) registry.NetworkServiceRegistryServer {
+	return &extAuthzNSServer{
+		authorizer: authorizer,
+	}
+}
+
+func (s *extAuthzNSServer) Register(ctx context.Context, ns *registry.NetworkService) (*registry.NetworkService, error) {
+	if err := s.authorizer.check(ctx, nsRegisterMethod, kindNetworkService, ns.GetName(), nil); err != nil {
+		return nil, err
+	}
+	return next.NetworkServiceRegistryServer(ctx).Register(ctx, ns)
+}
+
+func (s *extAuthzNSServer) Find(query *registry.NetworkServiceQuery, server registry.NetworkServiceRegistry_FindServer) error {
+	name := query.GetNetworkService().GetName()
+	if err := s.authorizer.check(server.Context(), nsFindMethod, kindNetworkService, name, nil); err != nil {
+		return err
+	}
+	return next.NetworkServiceRegistryServer(server.Context()).Find(query, server)
+}
+
+func (s *extAuthzNSServer) Unregister(ctx context.Context, ns *registry.NetworkService) (*empty.Empty, error) {
+	if err := s.authorizer.check(ctx, nsUnregisterMethod, kindNetworkService, ns.GetName(), nil); err != nil {
+		return nil, err
+	}
+	return next.NetworkServiceRegistryServer(ctx).Unregister(ctx, ns)
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package extauthz
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+)
+
+const (
+	nseRegisterMethod   = "/registry.NetworkServiceEndpointRegistry/Register"
+	nseFindMethod       = "/registry.NetworkServiceEndpointRegistry/Find"
+	nseUnregisterMethod = "/registry.NetworkServiceEndpointRegistry/Unregister"
+)
+
+type extAuthzNSEServer struct {
+	authorizer *Authorizer
+}
+
+// NewNetworkServiceEndpointRegistryServer creates a new NSE server chain element authorizing the calls with the
+// authorizer
+func NewNetworkServiceEndpointRegistryServer(authorizer *Authorizer) registry.NetworkServiceEndpointRegistryServer {
+	return &extAuthzNSEServer{
+		authorizer: authorizer,
+	}
+}
+
+func (s *extAuthzNSEServer) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*registry.NetworkServiceEndpoint, error) {
+	if err := s.authorizer.check(ctx, nseRegisterMethod, kindNetworkServiceEndpoint, nse.GetName(), nse.GetNetworkServiceNames()); err != nil {
+		return nil, err
+	}
+	return next.NetworkServiceEndpointRegistryServer(ctx).Register(ctx, nse)
+}
+
+func (s *extAuthzNSEServer) Find(query *registry.NetworkServiceEndpointQuery, server registry.NetworkServiceEndpointRegistry_FindServer) error {
+	nse := query.GetNetworkServiceEndpoint()
+	if err := s.authorizer.check(server.Context(), nseFindMethod, kindNetworkServiceEndpoint, nse.GetName(), nse.GetNetworkServiceNames()); err != nil {
+		return err
+	}
+	return next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, server)
+}
+
+func (s *extAuthzNSEServer) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*empty.Empty, error) {
+	if err := s.authorizer.check(ctx, nseUnregisterMethod, kindNetworkServiceEndpoint, nse.GetName(), nse.GetNetworkServiceNames()); err != nil {
+		return nil, err
+	}
+	return next.NetworkServiceEndpointRegistryServer(ctx).Unregister(ctx, nse)
+}
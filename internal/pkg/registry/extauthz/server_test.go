@@ -0,0 +1,185 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package extauthz_test
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/chain"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clockmock"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/extauthz"
+)
+
+// checkResponse encodes envoy.service.auth.v3.CheckResponse{status: {code, message}}
+func checkResponse(code codes.Code, message string) []byte {
+	var s []byte
+	s = protowire.AppendTag(s, 1, protowire.VarintType)
+	s = protowire.AppendVarint(s, uint64(code))
+	s = protowire.AppendTag(s, 2, protowire.BytesType)
+	s = protowire.AppendString(s, message)
+
+	var b []byte
+	b = protowire.AppendTag(b, 1, protowire.BytesType)
+	return protowire.AppendBytes(b, s)
+}
+
+// startAuthz starts a fake ext_authz service denying the requests for the "forbidden" names
+func startAuthz(ctx context.Context, t *testing.T, calls *int32) *grpc.ClientConn {
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(
+		grpc.ForceServerCodec(extauthz.Codec()),
+		grpc.UnknownServiceHandler(func(_ interface{}, stream grpc.ServerStream) error {
+			method, _ := grpc.MethodFromServerStream(stream)
+			if method != extauthz.CheckMethod {
+				return status.Errorf(codes.Unimplemented, "unexpected method %s", method)
+			}
+			atomic.AddInt32(calls, 1)
+			var req []byte
+			if err := stream.RecvMsg(&req); err != nil {
+				return err
+			}
+			resp := checkResponse(codes.OK, "")
+			if bytes.Contains(req, []byte("forbidden")) {
+				resp = checkResponse(codes.PermissionDenied, "forbidden name")
+			}
+			return stream.SendMsg(&resp)
+		}),
+	)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	cc, err := grpc.DialContext(ctx, "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = cc.Close() })
+	return cc
+}
+
+func TestExtAuthzNSEServer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	clockMock := clockmock.New(ctx)
+	ctx = clock.WithClock(ctx, clockMock)
+
+	var calls int32
+	authorizer := extauthz.NewAuthorizer(startAuthz(ctx, t, &calls), extauthz.WithCacheTTL(time.Minute))
+
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		extauthz.NewNetworkServiceEndpointRegistryServer(authorizer),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+
+	_, err := client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse"})
+	require.NoError(t, err)
+	_, err = client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse"})
+	require.NoError(t, err)
+	require.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	_, err = client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "forbidden-nse"})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	require.Contains(t, err.Error(), "forbidden name")
+	_, err = client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "forbidden-nse"})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	// The cached decision expires
+	clockMock.Add(time.Minute)
+	_, err = client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse"})
+	require.NoError(t, err)
+	require.Equal(t, int32(3), atomic.LoadInt32(&calls))
+}
+
+func TestExtAuthzNSEServer_FindByNetworkService(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var calls int32
+	authorizer := extauthz.NewAuthorizer(startAuthz(ctx, t, &calls))
+
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		extauthz.NewNetworkServiceEndpointRegistryServer(authorizer),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+
+	_, err := client.Find(ctx, &registry.NetworkServiceEndpointQuery{
+		NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{NetworkServiceNames: []string{"ns"}},
+	})
+	require.NoError(t, err)
+
+	// The query has no NSE name, the network service names scope it
+	_, err = client.Find(ctx, &registry.NetworkServiceEndpointQuery{
+		NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{NetworkServiceNames: []string{"ns", "forbidden-ns"}},
+	})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	require.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestExtAuthzNSEServer_Unavailable(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	listener := bufconn.Listen(1024)
+	require.NoError(t, listener.Close())
+	cc, err := grpc.DialContext(ctx, "bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer func() { _ = cc.Close() }()
+
+	nse := &registry.NetworkServiceEndpoint{Name: "nse"}
+
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		extauthz.NewNetworkServiceEndpointRegistryServer(extauthz.NewAuthorizer(cc, extauthz.WithTimeout(time.Second))),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+	_, err = client.Register(ctx, nse)
+	require.Equal(t, codes.Unavailable, status.Code(err))
+
+	client = adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		extauthz.NewNetworkServiceEndpointRegistryServer(extauthz.NewAuthorizer(cc, extauthz.WithTimeout(time.Second), extauthz.WithFailOpen())),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+	_, err = client.Register(ctx, nse)
+	require.NoError(t, err)
+}
@@ -0,0 +1,68 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package inprocess provides tools for the calls the registry makes to its own chain, e.g. to expunge or to generate
+// registrations. Such calls are trusted and skip the wrapped chain elements (authorization).
+package inprocess
+
+import (
+	"context"
+)
+
+type contextKeyType string
+
+const (
+	contextKey       contextKeyType = "inprocess"
+	tenantContextKey contextKeyType = "inprocess.tenant"
+	localContextKey  contextKeyType = "inprocess.local"
+)
+
+// WithContext marks ctx as the context of an in-process call. Remote peers cannot set the mark.
+func WithContext(ctx context.Context) context.Context {
+	return context.WithValue(ctx, contextKey, true)
+}
+
+// FromContext returns true if ctx is the context of an in-process call
+func FromContext(ctx context.Context) bool {
+	v, ok := ctx.Value(contextKey).(bool)
+	return ok && v
+}
+
+// WithTenant marks ctx as the context of an in-process call made on behalf of the unauthenticated clients of the
+// tenant, e.g. the DNS clients. The call skips the authorization as any in-process call, but it is not privileged: the
+// tenancy filters it as a call of the tenant, the empty tenant sees none of the tenant registrations.
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(WithContext(ctx), tenantContextKey, tenant)
+}
+
+// TenantFromContext returns the tenant of the in-process call made on behalf of the unauthenticated clients, ok is
+// false for the other calls
+func TenantFromContext(ctx context.Context) (tenant string, ok bool) {
+	tenant, ok = ctx.Value(tenantContextKey).(string)
+	return tenant, ok
+}
+
+// WithLocalOnly marks ctx as the context of an in-process Find asking the local store only, e.g. to check if a name is
+// registered. The elements caching the results or reaching the other registries pass such a Find through.
+func WithLocalOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, localContextKey, true)
+}
+
+// LocalOnlyFromContext returns true if ctx is the context of a Find asking the local store only
+func LocalOnlyFromContext(ctx context.Context) bool {
+	v, ok := ctx.Value(localContextKey).(bool)
+	return ok && v
+}
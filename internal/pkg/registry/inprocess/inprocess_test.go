@@ -0,0 +1,110 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inprocess_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/chain"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/utils/inject/injecterror"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/inprocess"
+)
+
+var errDenied = status.Error(codes.PermissionDenied, "denied")
+
+func TestFromContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	require.False(t, inprocess.FromContext(ctx))
+	require.True(t, inprocess.FromContext(inprocess.WithContext(ctx)))
+
+	// The mark is inherited by the derived contexts only
+	derived, cancelDerived := context.WithTimeout(inprocess.WithContext(ctx), time.Second)
+	defer cancelDerived()
+	require.True(t, inprocess.FromContext(derived))
+	require.False(t, inprocess.FromContext(ctx))
+
+	// The calls on behalf of a tenant are in-process as well
+	_, ok := inprocess.TenantFromContext(inprocess.WithContext(ctx))
+	require.False(t, ok)
+	tenantCtx := inprocess.WithTenant(ctx, "a.com")
+	require.True(t, inprocess.FromContext(tenantCtx))
+	tenant, ok := inprocess.TenantFromContext(tenantCtx)
+	require.True(t, ok)
+	require.Equal(t, "a.com", tenant)
+}
+
+func TestInProcessNSEServer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// The wrapped element stands for the authorization denying every remote call
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		inprocess.NewNetworkServiceEndpointRegistryServer(chain.NewNetworkServiceEndpointRegistryServer(
+			injecterror.NewNetworkServiceEndpointRegistryServer(injecterror.WithError(errDenied)),
+		)),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+	nse := &registry.NetworkServiceEndpoint{Name: "nse-1"}
+	query := &registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: new(registry.NetworkServiceEndpoint)}
+
+	_, err := client.Register(ctx, nse.Clone())
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+	_, err = client.Find(ctx, query)
+	require.Error(t, err)
+	_, err = client.Unregister(ctx, nse.Clone())
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	inprocessCtx := inprocess.WithContext(ctx)
+	_, err = client.Register(inprocessCtx, nse.Clone())
+	require.NoError(t, err)
+	stream, err := client.Find(inprocessCtx, query)
+	require.NoError(t, err)
+	require.Len(t, registry.ReadNetworkServiceEndpointList(stream), 1)
+	_, err = client.Unregister(inprocessCtx, nse.Clone())
+	require.NoError(t, err)
+}
+
+func TestInProcessNSServer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := adapters.NetworkServiceServerToClient(chain.NewNetworkServiceRegistryServer(
+		inprocess.NewNetworkServiceRegistryServer(chain.NewNetworkServiceRegistryServer(
+			injecterror.NewNetworkServiceRegistryServer(injecterror.WithError(errDenied)),
+		)),
+		memory.NewNetworkServiceRegistryServer(),
+	))
+
+	_, err := client.Register(ctx, &registry.NetworkService{Name: "ns-1"})
+	require.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	_, err = client.Register(inprocess.WithContext(ctx), &registry.NetworkService{Name: "ns-1"})
+	require.NoError(t, err)
+}
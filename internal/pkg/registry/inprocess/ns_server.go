@@ -0,0 +1,60 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inprocess
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+)
+
+type inprocessNSServer struct {
+	server registry.NetworkServiceRegistryServer
+}
+
+// NewNetworkServiceRegistryServer creates a new NS server chain element calling server for the remote calls
+// and skipping it for the in-process ones
+func NewNetworkServiceRegistryServer(server registry.NetworkServiceRegistryServer) registry.NetworkServiceRegistryServer {
+	return &inprocessNSServer{
+		server: server,
+	}
+}
+
+func (s *inprocessNSServer) Register(ctx context.Context, ns *registry.NetworkService) (*registry.NetworkService, error) {
+	if FromContext(ctx) {
+		return next.NetworkServiceRegistryServer(ctx).Register(ctx, ns)
+	}
+	return s.server.Register(ctx, ns)
+}
+
+func (s *inprocessNSServer) Find(query *registry.NetworkServiceQuery, server registry.NetworkServiceRegistry_FindServer) error {
+	if FromContext(server.Context()) {
+		return next.NetworkServiceRegistryServer(server.Context()).Find(query, server)
+	}
+	return s.server.Find(query, server)
+}
+
+func (s *inprocessNSServer) Unregister(ctx context.Context, ns *registry.NetworkService) (*empty.Empty, error) {
+	if FromContext(ctx) {
+		return next.NetworkServiceRegistryServer(ctx).Unregister(ctx, ns)
+	}
+	return s.server.Unregister(ctx, ns)
+}
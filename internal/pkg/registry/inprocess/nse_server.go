@@ -0,0 +1,60 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package inprocess
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+)
+
+type inprocessNSEServer struct {
+	server registry.NetworkServiceEndpointRegistryServer
+}
+
+// NewNetworkServiceEndpointRegistryServer creates a new NSE server chain element calling server for the remote calls
+// and skipping it for the in-process ones
+func NewNetworkServiceEndpointRegistryServer(server registry.NetworkServiceEndpointRegistryServer) registry.NetworkServiceEndpointRegistryServer {
+	return &inprocessNSEServer{
+		server: server,
+	}
+}
+
+func (s *inprocessNSEServer) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*registry.NetworkServiceEndpoint, error) {
+	if FromContext(ctx) {
+		return next.NetworkServiceEndpointRegistryServer(ctx).Register(ctx, nse)
+	}
+	return s.server.Register(ctx, nse)
+}
+
+func (s *inprocessNSEServer) Find(query *registry.NetworkServiceEndpointQuery, server registry.NetworkServiceEndpointRegistry_FindServer) error {
+	if FromContext(server.Context()) {
+		return next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, server)
+	}
+	return s.server.Find(query, server)
+}
+
+func (s *inprocessNSEServer) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*empty.Empty, error) {
+	if FromContext(ctx) {
+		return next.NetworkServiceEndpointRegistryServer(ctx).Unregister(ctx, nse)
+	}
+	return s.server.Unregister(ctx, nse)
+}
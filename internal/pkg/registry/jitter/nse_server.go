@@ -0,0 +1,79 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package jitter provides NSE registry server chain element spreading the granted expiration times, so the NSEs
+// registered at the same time don't keep refreshing at the same time
+package jitter
+
+import (
+	"context"
+	"math/rand"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/log"
+)
+
+type jitterNSEServer struct {
+	percent  int
+	inFlight metric.Int64UpDownCounter
+}
+
+// NewNetworkServiceEndpointRegistryServer creates a new NSE server chain element shortening the expiration time of the
+// registered NSE by a random amount of up to percent of its remaining lifetime. The expiration time is never extended.
+// The number of the Register calls in progress is exposed as the registry.nse.registers.in_flight metric.
+func NewNetworkServiceEndpointRegistryServer(percent int) registry.NetworkServiceEndpointRegistryServer {
+	inFlight, err := otel.Meter("registry-memory").Int64UpDownCounter("registry.nse.registers.in_flight",
+		metric.WithDescription("Number of the NSE registrations and refreshes in progress"))
+	if err != nil {
+		log.L().Errorf("failed to create NSE registers in flight counter: %s", err.Error())
+	}
+	return &jitterNSEServer{
+		percent:  percent,
+		inFlight: inFlight,
+	}
+}
+
+func (s *jitterNSEServer) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*registry.NetworkServiceEndpoint, error) {
+	if s.inFlight != nil {
+		s.inFlight.Add(ctx, 1)
+		defer s.inFlight.Add(ctx, -1)
+	}
+	if s.percent > 0 && nse.GetExpirationTime() != nil {
+		expirationTime := nse.GetExpirationTime().AsTime()
+		// #nosec G404 - the jitter doesn't need a secure random
+		if maxJitter := int64(expirationTime.Sub(clock.FromContext(ctx).Now())) * int64(s.percent) / 100; maxJitter > 0 {
+			nse.ExpirationTime = timestamppb.New(expirationTime.Add(-time.Duration(rand.Int63n(maxJitter + 1))))
+		}
+	}
+	return next.NetworkServiceEndpointRegistryServer(ctx).Register(ctx, nse)
+}
+
+func (s *jitterNSEServer) Find(query *registry.NetworkServiceEndpointQuery, server registry.NetworkServiceEndpointRegistry_FindServer) error {
+	return next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, server)
+}
+
+func (s *jitterNSEServer) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*empty.Empty, error) {
+	return next.NetworkServiceEndpointRegistryServer(ctx).Unregister(ctx, nse)
+}
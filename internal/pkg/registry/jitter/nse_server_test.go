@@ -0,0 +1,83 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package jitter_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/chain"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clockmock"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/jitter"
+)
+
+func TestJitterNSEServer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clockMock := clockmock.New(ctx)
+	ctx = clock.WithClock(ctx, clockMock)
+
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		jitter.NewNetworkServiceEndpointRegistryServer(20),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+
+	expirationTime := clockMock.Now().Add(time.Minute)
+	expirationTimes := make(map[time.Time]struct{})
+	for i := 0; i < 10; i++ {
+		registered, err := client.Register(ctx, &registry.NetworkServiceEndpoint{
+			Name:           "nse",
+			ExpirationTime: timestamppb.New(expirationTime),
+		})
+		require.NoError(t, err)
+
+		granted := registered.GetExpirationTime().AsTime()
+		require.False(t, granted.After(expirationTime))
+		require.False(t, granted.Before(expirationTime.Add(-12*time.Second)))
+		expirationTimes[granted] = struct{}{}
+	}
+	require.Greater(t, len(expirationTimes), 1)
+}
+
+func TestJitterNSEServer_Disabled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		jitter.NewNetworkServiceEndpointRegistryServer(0),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+
+	expirationTime := time.Now().Add(time.Minute).UTC()
+	registered, err := client.Register(ctx, &registry.NetworkServiceEndpoint{
+		Name:           "nse",
+		ExpirationTime: timestamppb.New(expirationTime),
+	})
+	require.NoError(t, err)
+	require.Equal(t, expirationTime, registered.GetExpirationTime().AsTime())
+}
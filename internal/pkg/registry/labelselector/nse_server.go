@@ -0,0 +1,94 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package labelselector provides NSE registry server chain element filtering the Find results with the label
+// selector set in the query parameters
+package labelselector
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/queryparams"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/selector"
+)
+
+// SelectorParam is the query parameter with the label selector, see selector package for the syntax
+const SelectorParam = "selector"
+
+type labelSelectorNSEServer struct{}
+
+// NewNetworkServiceEndpointRegistryServer creates a new NSE server chain element sending only the NSEs with the
+// labels matching the selector query parameter. If the query has network service names, only the labels of these
+// network services are checked.
+func NewNetworkServiceEndpointRegistryServer() registry.NetworkServiceEndpointRegistryServer {
+	return new(labelSelectorNSEServer)
+}
+
+func (s *labelSelectorNSEServer) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*registry.NetworkServiceEndpoint, error) {
+	return next.NetworkServiceEndpointRegistryServer(ctx).Register(ctx, nse)
+}
+
+func (s *labelSelectorNSEServer) Find(query *registry.NetworkServiceEndpointQuery, server registry.NetworkServiceEndpointRegistry_FindServer) error {
+	value, ok := queryparams.FromContext(server.Context())[SelectorParam]
+	if !ok {
+		return next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, server)
+	}
+	sel, err := selector.Parse(value)
+	if err != nil {
+		return err
+	}
+	return next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, &selectorFindServer{
+		NetworkServiceEndpointRegistry_FindServer: server,
+		selector: sel,
+		names:    query.GetNetworkServiceEndpoint().GetNetworkServiceNames(),
+	})
+}
+
+func (s *labelSelectorNSEServer) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*empty.Empty, error) {
+	return next.NetworkServiceEndpointRegistryServer(ctx).Unregister(ctx, nse)
+}
+
+type selectorFindServer struct {
+	registry.NetworkServiceEndpointRegistry_FindServer
+	selector selector.Selector
+	names    []string
+}
+
+func (s *selectorFindServer) Send(resp *registry.NetworkServiceEndpointResponse) error {
+	if !s.matches(resp.GetNetworkServiceEndpoint()) {
+		return nil
+	}
+	return s.NetworkServiceEndpointRegistry_FindServer.Send(resp)
+}
+
+func (s *selectorFindServer) matches(nse *registry.NetworkServiceEndpoint) bool {
+	names := s.names
+	if len(names) == 0 {
+		names = nse.GetNetworkServiceNames()
+	}
+	for _, name := range names {
+		if s.selector.Matches(nse.GetNetworkServiceLabels()[name].GetLabels()) {
+			return true
+		}
+	}
+	return len(names) == 0 && s.selector.Matches(nil)
+}
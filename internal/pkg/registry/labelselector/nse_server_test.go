@@ -0,0 +1,70 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package labelselector_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/chain"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/labelselector"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/queryparams"
+)
+
+func TestLabelSelectorNSEServer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		queryparams.NewNetworkServiceEndpointRegistryServer(),
+		labelselector.NewNetworkServiceEndpointRegistryServer(),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+	for name, env := range map[string]string{"nse-1": "prod", "nse-2": "staging", "nse-3": "dev"} {
+		_, err := client.Register(ctx, &registry.NetworkServiceEndpoint{
+			Name:                name,
+			NetworkServiceNames: []string{"ns-1"},
+			NetworkServiceLabels: map[string]*registry.NetworkServiceLabels{
+				"ns-1": {Labels: map[string]string{"env": env}},
+			},
+		})
+		require.NoError(t, err)
+	}
+
+	stream, err := client.Find(ctx, &registry.NetworkServiceEndpointQuery{
+		NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{
+			NetworkServiceNames: []string{"ns-1"},
+			NetworkServiceLabels: map[string]*registry.NetworkServiceLabels{
+				queryparams.Key: {Labels: map[string]string{labelselector.SelectorParam: "env in (prod,staging)"}},
+			},
+		},
+	})
+	require.NoError(t, err)
+
+	var names []string
+	for _, nse := range registry.ReadNetworkServiceEndpointList(stream) {
+		names = append(names, nse.GetName())
+	}
+	require.ElementsMatch(t, []string{"nse-1", "nse-2"}, names)
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package listwatch
+
+import (
+	"context"
+)
+
+type contextKeyType string
+
+const (
+	contextKey       contextKeyType = "listwatch"
+	resyncContextKey contextKeyType = "listwatch-resync"
+)
+
+type hooks []func() error
+
+// OnSnapshotDone returns ctx with f added to the hooks called once the watch stream has sent the snapshot. The hooks
+// added later, by the elements closer to the store, are called first, so the elements buffering the stream can flush
+// it before the marker is sent.
+func OnSnapshotDone(ctx context.Context, f func() error) context.Context {
+	parent, _ := ctx.Value(contextKey).(hooks)
+	return context.WithValue(ctx, contextKey, append(hooks{f}, parent...))
+}
+
+// SnapshotDone calls the hooks of ctx. The store calls it on the watch stream after the snapshot and before the
+// updates.
+func SnapshotDone(ctx context.Context) error {
+	h, _ := ctx.Value(contextKey).(hooks)
+	for _, f := range h {
+		if err := f(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// OnResync returns ctx with f added to the hooks called before the watch stream sends a resync snapshot, ordered as the
+// OnSnapshotDone hooks. The stream is resynced only if it has the hooks.
+func OnResync(ctx context.Context, f func() error) context.Context {
+	parent, _ := ctx.Value(resyncContextKey).(hooks)
+	return context.WithValue(ctx, resyncContextKey, append(hooks{f}, parent...))
+}
+
+// ResyncRequested returns if the watch stream of ctx is resynced
+func ResyncRequested(ctx context.Context) bool {
+	h, _ := ctx.Value(resyncContextKey).(hooks)
+	return len(h) > 0
+}
+
+// Resync calls the resync hooks of ctx. The store calls it on the watch stream before the resync snapshot, then
+// SnapshotDone after it.
+func Resync(ctx context.Context) error {
+	h, _ := ctx.Value(resyncContextKey).(hooks)
+	for _, f := range h {
+		if err := f(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
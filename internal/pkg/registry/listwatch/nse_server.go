@@ -0,0 +1,123 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package listwatch provides NSE registry server chain element delivering the "list then watch" Find streams: the
+// consistent snapshot of the matching NSEs, then the marker, then the updates. The store starts the watch from the
+// snapshot generation, so no update after the snapshot is lost and no update before it is sent again, and the clients
+// may reconcile their state with the snapshot once they get the marker.
+//
+// A client asks for the marker with the "snapshot-marker" query parameter or the "registry-memory-snapshot-marker"
+// gRPC metadata set to true. The marker is a response with the MarkerName NSE. Only the local store sends it, the
+// interdomain Find streams forwarded to the proxy registry don't get it.
+//
+// A client caching the NSEs may also ask for the periodic resyncs with the "resync" query parameter or the
+// "registry-memory-resync" gRPC metadata set to true, so it heals from the missed updates. A resync is the
+// ResyncMarkerName NSE, then the full snapshot of the matching NSEs, then the marker, the client replaces its state with
+// the snapshot. The store spreads the resyncs of the streams and adapts their interval to its load.
+package listwatch
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/streamcontext"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/queryparams"
+)
+
+const (
+	// MarkerParam is the query parameter asking for the snapshot marker
+	MarkerParam = "snapshot-marker"
+	// MarkerMetadata is the gRPC metadata key asking for the snapshot marker
+	MarkerMetadata = "registry-memory-snapshot-marker"
+	// MarkerName is the reserved name of the marker NSE
+	MarkerName = "registry-memory.snapshot-end"
+	// ResyncParam is the query parameter asking for the periodic resyncs, it implies the snapshot marker
+	ResyncParam = "resync"
+	// ResyncMetadata is the gRPC metadata key asking for the periodic resyncs
+	ResyncMetadata = "registry-memory-resync"
+	// ResyncMarkerName is the reserved name of the NSE starting a resync
+	ResyncMarkerName = "registry-memory.resync-start"
+)
+
+// IsMarker returns if the response is the snapshot marker
+func IsMarker(resp *registry.NetworkServiceEndpointResponse) bool {
+	return resp.GetNetworkServiceEndpoint().GetName() == MarkerName
+}
+
+// IsResyncMarker returns if the response starts a resync
+func IsResyncMarker(resp *registry.NetworkServiceEndpointResponse) bool {
+	return resp.GetNetworkServiceEndpoint().GetName() == ResyncMarkerName
+}
+
+type listWatchNSEServer struct{}
+
+// NewNetworkServiceEndpointRegistryServer creates a new NSE server chain element sending the snapshot marker to the
+// clients asking for it. It should follow the queryparams chain element, so the marker skips the elements filtering
+// and annotating the NSEs.
+func NewNetworkServiceEndpointRegistryServer() registry.NetworkServiceEndpointRegistryServer {
+	return new(listWatchNSEServer)
+}
+
+func (s *listWatchNSEServer) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*registry.NetworkServiceEndpoint, error) {
+	return next.NetworkServiceEndpointRegistryServer(ctx).Register(ctx, nse)
+}
+
+func (s *listWatchNSEServer) Find(query *registry.NetworkServiceEndpointQuery, server registry.NetworkServiceEndpointRegistry_FindServer) error {
+	resync := requested(server.Context(), ResyncParam, ResyncMetadata)
+	if !query.GetWatch() || !resync && !requested(server.Context(), MarkerParam, MarkerMetadata) {
+		return next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, server)
+	}
+	ctx := OnSnapshotDone(server.Context(), func() error {
+		marker := &registry.NetworkServiceEndpointResponse{
+			NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{Name: MarkerName},
+		}
+		return errors.Wrap(server.Send(marker), "NetworkServiceEndpointRegistry find server failed to send the snapshot marker")
+	})
+	if resync {
+		ctx = OnResync(ctx, func() error {
+			marker := &registry.NetworkServiceEndpointResponse{
+				NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{Name: ResyncMarkerName},
+			}
+			return errors.Wrap(server.Send(marker), "NetworkServiceEndpointRegistry find server failed to send the resync marker")
+		})
+	}
+	return next.NetworkServiceEndpointRegistryServer(ctx).Find(query, streamcontext.NetworkServiceEndpointRegistryFindServer(ctx, server))
+}
+
+func (s *listWatchNSEServer) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*empty.Empty, error) {
+	return next.NetworkServiceEndpointRegistryServer(ctx).Unregister(ctx, nse)
+}
+
+// requested returns if the query parameter or the metadata key is set to true
+func requested(ctx context.Context, param, key string) bool {
+	value, ok := queryparams.FromContext(ctx)[param]
+	if !ok {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if values := md.Get(key); len(values) > 0 {
+			value = values[0]
+		}
+	}
+	requested, _ := strconv.ParseBool(value)
+	return requested
+}
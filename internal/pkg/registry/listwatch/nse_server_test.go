@@ -0,0 +1,247 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package listwatch_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/chain"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clockmock"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/listwatch"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/nsestore"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/queryparams"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/watchdedup"
+)
+
+func markerQuery() *registry.NetworkServiceEndpointQuery {
+	return &registry.NetworkServiceEndpointQuery{
+		NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{
+			NetworkServiceLabels: map[string]*registry.NetworkServiceLabels{
+				queryparams.Key: {Labels: map[string]string{listwatch.MarkerParam: "true"}},
+			},
+		},
+		Watch: true,
+	}
+}
+
+func resyncQuery() *registry.NetworkServiceEndpointQuery {
+	return &registry.NetworkServiceEndpointQuery{
+		NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{
+			NetworkServiceLabels: map[string]*registry.NetworkServiceLabels{
+				queryparams.Key: {Labels: map[string]string{listwatch.ResyncParam: "true"}},
+			},
+		},
+		Watch: true,
+	}
+}
+
+// recvSnapshot receives the snapshot till the marker and returns the names of its NSEs
+func recvSnapshot(t *testing.T, stream registry.NetworkServiceEndpointRegistry_FindClient) map[string]bool {
+	snapshot := make(map[string]bool)
+	for {
+		resp, err := stream.Recv()
+		require.NoError(t, err)
+		if listwatch.IsMarker(resp) {
+			return snapshot
+		}
+		snapshot[resp.GetNetworkServiceEndpoint().GetName()] = true
+	}
+}
+
+func TestListWatchNSEServer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		queryparams.NewNetworkServiceEndpointRegistryServer(),
+		listwatch.NewNetworkServiceEndpointRegistryServer(),
+		nsestore.NewNetworkServiceEndpointRegistryServer(),
+	))
+
+	for _, name := range []string{"nse-1", "nse-2"} {
+		_, err := client.Register(ctx, &registry.NetworkServiceEndpoint{Name: name})
+		require.NoError(t, err)
+	}
+
+	stream, err := client.Find(ctx, markerQuery())
+	require.NoError(t, err)
+
+	snapshot := make(map[string]bool)
+	for i := 0; i < 2; i++ {
+		resp, recvErr := stream.Recv()
+		require.NoError(t, recvErr)
+		require.False(t, listwatch.IsMarker(resp))
+		snapshot[resp.GetNetworkServiceEndpoint().GetName()] = true
+	}
+	require.Equal(t, map[string]bool{"nse-1": true, "nse-2": true}, snapshot)
+
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	require.True(t, listwatch.IsMarker(resp))
+
+	_, err = client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-3"})
+	require.NoError(t, err)
+	resp, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "nse-3", resp.GetNetworkServiceEndpoint().GetName())
+}
+
+func TestListWatchNSEServer_EmptySnapshot(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		queryparams.NewNetworkServiceEndpointRegistryServer(),
+		listwatch.NewNetworkServiceEndpointRegistryServer(),
+		nsestore.NewNetworkServiceEndpointRegistryServer(),
+	))
+
+	stream, err := client.Find(ctx, markerQuery())
+	require.NoError(t, err)
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	require.True(t, listwatch.IsMarker(resp))
+
+	// No marker without asking for it
+	stream, err = client.Find(ctx, &registry.NetworkServiceEndpointQuery{
+		NetworkServiceEndpoint: new(registry.NetworkServiceEndpoint),
+		Watch:                  true,
+	})
+	require.NoError(t, err)
+	_, err = client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-1"})
+	require.NoError(t, err)
+	resp, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "nse-1", resp.GetNetworkServiceEndpoint().GetName())
+}
+
+func TestListWatchNSEServer_CoalescedSnapshot(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		queryparams.NewNetworkServiceEndpointRegistryServer(),
+		listwatch.NewNetworkServiceEndpointRegistryServer(),
+		watchdedup.NewNetworkServiceEndpointRegistryServer(watchdedup.WithCoalesceWindow(time.Hour)),
+		nsestore.NewNetworkServiceEndpointRegistryServer(),
+	))
+
+	_, err := client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-1"})
+	require.NoError(t, err)
+
+	// The snapshot buffered for the coalesce window is flushed before the marker
+	stream, err := client.Find(ctx, markerQuery())
+	require.NoError(t, err)
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "nse-1", resp.GetNetworkServiceEndpoint().GetName())
+	resp, err = stream.Recv()
+	require.NoError(t, err)
+	require.True(t, listwatch.IsMarker(resp))
+}
+
+func TestListWatchNSEServer_Resync(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	clockMock := clockmock.New(ctx)
+	ctx = clock.WithClock(ctx, clockMock)
+
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		queryparams.NewNetworkServiceEndpointRegistryServer(),
+		listwatch.NewNetworkServiceEndpointRegistryServer(),
+		watchdedup.NewNetworkServiceEndpointRegistryServer(),
+		nsestore.NewNetworkServiceEndpointRegistryServer(nsestore.WithResync(time.Minute, 0)),
+	))
+
+	for _, name := range []string{"nse-1", "nse-2"} {
+		_, err := client.Register(ctx, &registry.NetworkServiceEndpoint{Name: name})
+		require.NoError(t, err)
+	}
+
+	stream, err := client.Find(ctx, resyncQuery())
+	require.NoError(t, err)
+	require.Equal(t, map[string]bool{"nse-1": true, "nse-2": true}, recvSnapshot(t, stream))
+
+	_, err = client.Unregister(ctx, &registry.NetworkServiceEndpoint{Name: "nse-2"})
+	require.NoError(t, err)
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	require.True(t, resp.GetDeleted())
+
+	// The resync is sent in full, the unchanged NSEs are not deduplicated
+	clockMock.Add(2 * time.Minute)
+	resp, err = stream.Recv()
+	require.NoError(t, err)
+	require.True(t, listwatch.IsResyncMarker(resp))
+	require.Equal(t, map[string]bool{"nse-1": true}, recvSnapshot(t, stream))
+
+	// The updates follow the resync
+	_, err = client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-3"})
+	require.NoError(t, err)
+	resp, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "nse-3", resp.GetNetworkServiceEndpoint().GetName())
+}
+
+func TestListWatchNSEServer_ResyncUnderLoad(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	clockMock := clockmock.New(ctx)
+	ctx = clock.WithClock(ctx, clockMock)
+
+	// 10 NSEs at 1 NSE per second stretch the 1s interval to 10s
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		queryparams.NewNetworkServiceEndpointRegistryServer(),
+		listwatch.NewNetworkServiceEndpointRegistryServer(),
+		nsestore.NewNetworkServiceEndpointRegistryServer(nsestore.WithResync(time.Second, 1)),
+	))
+	for i := 0; i < 10; i++ {
+		_, err := client.Register(ctx, &registry.NetworkServiceEndpoint{Name: fmt.Sprintf("nse-%d", i)})
+		require.NoError(t, err)
+	}
+
+	stream, err := client.Find(ctx, resyncQuery())
+	require.NoError(t, err)
+	require.Len(t, recvSnapshot(t, stream), 10)
+
+	received := make(chan *registry.NetworkServiceEndpointResponse, 1)
+	go func() {
+		resp, recvErr := stream.Recv()
+		if recvErr == nil {
+			received <- resp
+		}
+	}()
+
+	clockMock.Add(2 * time.Second)
+	require.Never(t, func() bool { return len(received) > 0 }, 100*time.Millisecond, 10*time.Millisecond)
+
+	clockMock.Add(20 * time.Second)
+	require.Eventually(t, func() bool { return len(received) > 0 }, time.Second, 10*time.Millisecond)
+	require.True(t, listwatch.IsResyncMarker(<-received))
+}
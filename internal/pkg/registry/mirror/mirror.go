@@ -0,0 +1,261 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package mirror provides the read-through mirror of a primary registry for the edge sites: the Mirror replicates
+// the primary registry state to the local registry with the Find watches, so the local Find queries are served even
+// when the primary is unreachable, and the chain elements forward Register and Unregister to the primary before
+// applying them locally. The chain elements must follow the authorization, so the primary is called with the registry
+// credentials for the authorized callers only. The in-process calls made by the registry itself are not forwarded.
+package mirror
+
+import (
+	"context"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/log"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/inprocess"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/listwatch"
+)
+
+// Kinds of the mirrored registrations reported in the registry.mirror.events metric
+const (
+	KindNS  = "ns"
+	KindNSE = "nse"
+)
+
+type options struct {
+	retryPeriod time.Duration
+}
+
+// Option is an option for the Mirror
+type Option func(o *options)
+
+// WithRetryPeriod sets the period the failed watch of the primary registry is retried with, default 5s
+func WithRetryPeriod(retryPeriod time.Duration) Option {
+	return func(o *options) {
+		o.retryPeriod = retryPeriod
+	}
+}
+
+// Mirror replicates the primary registry state to the local registry
+type Mirror struct {
+	options
+	primaryNS  registry.NetworkServiceRegistryClient
+	primaryNSE registry.NetworkServiceEndpointRegistryClient
+	localNS    registry.NetworkServiceRegistryClient
+	localNSE   registry.NetworkServiceEndpointRegistryClient
+	events     metric.Int64Counter
+}
+
+// NewMirror creates a new Mirror watching the primary registry with the primary clients and applying the changes with
+// the local ones
+func NewMirror(
+	primaryNS registry.NetworkServiceRegistryClient,
+	primaryNSE registry.NetworkServiceEndpointRegistryClient,
+	localNS registry.NetworkServiceRegistryClient,
+	localNSE registry.NetworkServiceEndpointRegistryClient,
+	opts ...Option,
+) *Mirror {
+	m := &Mirror{
+		options: options{
+			retryPeriod: 5 * time.Second,
+		},
+		primaryNS:  primaryNS,
+		primaryNSE: primaryNSE,
+		localNS:    localNS,
+		localNSE:   localNSE,
+	}
+	for _, opt := range opts {
+		opt(&m.options)
+	}
+	events, err := otel.Meter("registry-memory").Int64Counter("registry.mirror.events",
+		metric.WithDescription("Number of the primary registry changes applied to the local registry by kind"))
+	if err != nil {
+		log.L().Errorf("failed to create mirror events counter: %s", err.Error())
+	}
+	m.events = events
+	return m
+}
+
+// Run mirrors the primary registry until ctx is done. A failed watch is retried every retry period, the mirrored
+// state is kept meanwhile. Once the watch is restored, the registrations deleted from the primary during the outage
+// are unregistered locally, the NSEs only if the primary sends the listwatch snapshot marker, else they expire.
+func (m *Mirror) Run(ctx context.Context) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		m.retry(ctx, KindNS, m.watchNS)
+	}()
+	go func() {
+		defer wg.Done()
+		m.retry(ctx, KindNSE, m.watchNSE)
+	}()
+	wg.Wait()
+}
+
+func (m *Mirror) retry(ctx context.Context, kind string, watch func(ctx context.Context) error) {
+	for {
+		err := watch(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		log.FromContext(ctx).Warnf("lost the %s watch of the primary registry, retrying in %s: %s", kind, m.retryPeriod, err.Error())
+		select {
+		case <-ctx.Done():
+			return
+		case <-clock.FromContext(ctx).After(m.retryPeriod):
+		}
+	}
+}
+
+func (m *Mirror) watchNS(ctx context.Context) error {
+	stream, err := m.primaryNS.Find(ctx, &registry.NetworkServiceQuery{NetworkService: new(registry.NetworkService), Watch: true})
+	if err != nil {
+		return errors.Wrap(err, "failed to watch the NSs")
+	}
+	localCtx := inprocess.WithContext(ctx)
+	// The NS watch has no snapshot marker, so the primary NSs are listed once the watch is started: the NSs deleted
+	// before the listing are unregistered locally, the ones changed after it are sent by the watch
+	if err = m.reconcileNSs(ctx, localCtx); err != nil {
+		return err
+	}
+	for {
+		resp, recvErr := stream.Recv()
+		if recvErr != nil {
+			return errors.Wrap(recvErr, "failed to receive an NS")
+		}
+		ns := resp.GetNetworkService()
+		if resp.GetDeleted() {
+			_, err = m.localNS.Unregister(localCtx, ns)
+		} else {
+			_, err = m.localNS.Register(localCtx, ns)
+		}
+		if err != nil {
+			log.FromContext(ctx).Warnf("failed to mirror NS %s: %s", ns.GetName(), err.Error())
+		}
+		m.record(ctx, KindNS)
+	}
+}
+
+// reconcileNSs unregisters the local NSs missing from the primary registry
+func (m *Mirror) reconcileNSs(ctx, localCtx context.Context) error {
+	primaryStream, err := m.primaryNS.Find(ctx, &registry.NetworkServiceQuery{NetworkService: new(registry.NetworkService)})
+	if err != nil {
+		return errors.Wrap(err, "failed to list the NSs")
+	}
+	// A partial listing would unregister the NSs it misses, so it fails the watch instead
+	snapshot := make(map[string]bool)
+	for {
+		resp, recvErr := primaryStream.Recv()
+		if recvErr == io.EOF {
+			break
+		}
+		if recvErr != nil {
+			return errors.Wrap(recvErr, "failed to list the NSs")
+		}
+		snapshot[resp.GetNetworkService().GetName()] = true
+	}
+	localStream, err := m.localNS.Find(localCtx, &registry.NetworkServiceQuery{NetworkService: new(registry.NetworkService)})
+	if err != nil {
+		return errors.Wrap(err, "failed to list the local NSs")
+	}
+	for _, ns := range registry.ReadNetworkServiceList(localStream) {
+		if snapshot[ns.GetName()] {
+			continue
+		}
+		if _, err = m.localNS.Unregister(localCtx, ns); err != nil {
+			log.FromContext(ctx).Warnf("failed to unregister NS %s deleted from the primary: %s", ns.GetName(), err.Error())
+			continue
+		}
+		m.record(ctx, KindNS)
+	}
+	return nil
+}
+
+func (m *Mirror) watchNSE(ctx context.Context) error {
+	// The snapshot marker tells the end of the primary NSEs, the local NSEs missing from them are unregistered then
+	stream, err := m.primaryNSE.Find(metadata.AppendToOutgoingContext(ctx, listwatch.MarkerMetadata, "true"),
+		&registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: new(registry.NetworkServiceEndpoint), Watch: true})
+	if err != nil {
+		return errors.Wrap(err, "failed to watch the NSEs")
+	}
+	localCtx := inprocess.WithContext(ctx)
+	snapshot := make(map[string]bool)
+	for {
+		resp, recvErr := stream.Recv()
+		if recvErr != nil {
+			return errors.Wrap(recvErr, "failed to receive an NSE")
+		}
+		if listwatch.IsMarker(resp) {
+			if snapshot != nil {
+				m.reconcileNSEs(ctx, localCtx, snapshot)
+				snapshot = nil
+			}
+			continue
+		}
+		nse := resp.GetNetworkServiceEndpoint()
+		if resp.GetDeleted() {
+			_, err = m.localNSE.Unregister(localCtx, nse)
+		} else {
+			if snapshot != nil {
+				snapshot[nse.GetName()] = true
+			}
+			_, err = m.localNSE.Register(localCtx, nse)
+		}
+		if err != nil {
+			log.FromContext(ctx).Warnf("failed to mirror NSE %s: %s", nse.GetName(), err.Error())
+		}
+		m.record(ctx, KindNSE)
+	}
+}
+
+// reconcileNSEs unregisters the local NSEs missing from the snapshot of the primary registry
+func (m *Mirror) reconcileNSEs(ctx, localCtx context.Context, snapshot map[string]bool) {
+	stream, err := m.localNSE.Find(localCtx, &registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: new(registry.NetworkServiceEndpoint)})
+	if err != nil {
+		log.FromContext(ctx).Warnf("failed to list the local NSEs: %s", err.Error())
+		return
+	}
+	for _, nse := range registry.ReadNetworkServiceEndpointList(stream) {
+		if snapshot[nse.GetName()] {
+			continue
+		}
+		if _, err = m.localNSE.Unregister(localCtx, nse); err != nil {
+			log.FromContext(ctx).Warnf("failed to unregister NSE %s deleted from the primary: %s", nse.GetName(), err.Error())
+			continue
+		}
+		m.record(ctx, KindNSE)
+	}
+}
+
+func (m *Mirror) record(ctx context.Context, kind string) {
+	if m.events != nil {
+		m.events.Add(ctx, 1, metric.WithAttributes(attribute.String("kind", kind)))
+	}
+}
@@ -0,0 +1,168 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mirror_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/metadata"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/chain"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/streamcontext"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/inprocess"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/listwatch"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/mirror"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/nsestore"
+)
+
+// incomingMetadataNSEServer passes the outgoing metadata of the Find queries as the incoming one, as gRPC does
+type incomingMetadataNSEServer struct{}
+
+func (s *incomingMetadataNSEServer) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*registry.NetworkServiceEndpoint, error) {
+	return next.NetworkServiceEndpointRegistryServer(ctx).Register(ctx, nse)
+}
+
+func (s *incomingMetadataNSEServer) Find(query *registry.NetworkServiceEndpointQuery, server registry.NetworkServiceEndpointRegistry_FindServer) error {
+	md, _ := metadata.FromOutgoingContext(server.Context())
+	ctx := metadata.NewIncomingContext(server.Context(), md)
+	return next.NetworkServiceEndpointRegistryServer(ctx).Find(query, streamcontext.NetworkServiceEndpointRegistryFindServer(ctx, server))
+}
+
+func (s *incomingMetadataNSEServer) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*empty.Empty, error) {
+	return next.NetworkServiceEndpointRegistryServer(ctx).Unregister(ctx, nse)
+}
+
+func findNSEs(ctx context.Context, t *testing.T, client registry.NetworkServiceEndpointRegistryClient) []string {
+	stream, err := client.Find(ctx, &registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: new(registry.NetworkServiceEndpoint)})
+	require.NoError(t, err)
+	var names []string
+	for _, nse := range registry.ReadNetworkServiceEndpointList(stream) {
+		names = append(names, nse.GetName())
+	}
+	return names
+}
+
+func TestMirror_Run(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	primaryNS := adapters.NetworkServiceServerToClient(memory.NewNetworkServiceRegistryServer())
+	primaryNSE := adapters.NetworkServiceEndpointServerToClient(memory.NewNetworkServiceEndpointRegistryServer())
+	localNS := adapters.NetworkServiceServerToClient(memory.NewNetworkServiceRegistryServer())
+	localNSE := adapters.NetworkServiceEndpointServerToClient(memory.NewNetworkServiceEndpointRegistryServer())
+
+	_, err := primaryNSE.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-1"})
+	require.NoError(t, err)
+
+	go mirror.NewMirror(primaryNS, primaryNSE, localNS, localNSE).Run(ctx)
+
+	require.Eventually(t, func() bool {
+		return len(findNSEs(ctx, t, localNSE)) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	_, err = primaryNS.Register(ctx, &registry.NetworkService{Name: "ns-1"})
+	require.NoError(t, err)
+	_, err = primaryNSE.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-2"})
+	require.NoError(t, err)
+	_, err = primaryNSE.Unregister(ctx, &registry.NetworkServiceEndpoint{Name: "nse-1"})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		names := findNSEs(ctx, t, localNSE)
+		return len(names) == 1 && names[0] == "nse-2"
+	}, time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool {
+		stream, findErr := localNS.Find(ctx, &registry.NetworkServiceQuery{NetworkService: new(registry.NetworkService)})
+		return findErr == nil && len(registry.ReadNetworkServiceList(stream)) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestMirror_Reconcile(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	primaryNS := adapters.NetworkServiceServerToClient(memory.NewNetworkServiceRegistryServer())
+	primaryNSE := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		new(incomingMetadataNSEServer),
+		listwatch.NewNetworkServiceEndpointRegistryServer(),
+		nsestore.NewNetworkServiceEndpointRegistryServer(),
+	))
+	localNS := adapters.NetworkServiceServerToClient(memory.NewNetworkServiceRegistryServer())
+	localNSE := adapters.NetworkServiceEndpointServerToClient(memory.NewNetworkServiceEndpointRegistryServer())
+
+	// The local registrations left from before the watch was lost, the primary has deleted them meanwhile
+	_, err := localNS.Register(ctx, &registry.NetworkService{Name: "ns-deleted"})
+	require.NoError(t, err)
+	_, err = localNSE.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-deleted"})
+	require.NoError(t, err)
+
+	_, err = primaryNS.Register(ctx, &registry.NetworkService{Name: "ns-1"})
+	require.NoError(t, err)
+	_, err = primaryNSE.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-1"})
+	require.NoError(t, err)
+
+	go mirror.NewMirror(primaryNS, primaryNSE, localNS, localNSE).Run(ctx)
+
+	require.Eventually(t, func() bool {
+		names := findNSEs(ctx, t, localNSE)
+		return len(names) == 1 && names[0] == "nse-1"
+	}, time.Second, 10*time.Millisecond)
+	require.Eventually(t, func() bool {
+		stream, findErr := localNS.Find(ctx, &registry.NetworkServiceQuery{NetworkService: new(registry.NetworkService)})
+		if findErr != nil {
+			return false
+		}
+		nss := registry.ReadNetworkServiceList(stream)
+		return len(nss) == 1 && nss[0].GetName() == "ns-1"
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestMirrorNSEServer_Forward(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	primary := adapters.NetworkServiceEndpointServerToClient(memory.NewNetworkServiceEndpointRegistryServer())
+	local := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		mirror.NewNetworkServiceEndpointRegistryServer(primary),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+
+	_, err := local.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-1"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"nse-1"}, findNSEs(ctx, t, primary))
+	require.Equal(t, []string{"nse-1"}, findNSEs(ctx, t, local))
+
+	// The in-process registrations are local only
+	_, err = local.Register(inprocess.WithContext(ctx), &registry.NetworkServiceEndpoint{Name: "nse-2"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"nse-1"}, findNSEs(ctx, t, primary))
+
+	_, err = local.Unregister(ctx, &registry.NetworkServiceEndpoint{Name: "nse-1"})
+	require.NoError(t, err)
+	require.Empty(t, findNSEs(ctx, t, primary))
+	require.Equal(t, []string{"nse-2"}, findNSEs(ctx, t, local))
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mirror
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/inprocess"
+)
+
+type mirrorNSServer struct {
+	client registry.NetworkServiceRegistryClient
+}
+
+// NewNetworkServiceRegistryServer creates a new NS server chain element forwarding Register and Unregister to the
+// primary registry with client and applying them locally once the primary has accepted them
+func NewNetworkServiceRegistryServer(client registry.NetworkServiceRegistryClient) registry.NetworkServiceRegistryServer {
+	return &mirrorNSServer{
+		client: client,
+	}
+}
+
+func (s *mirrorNSServer) Register(ctx context.Context, ns *registry.NetworkService) (*registry.NetworkService, error) {
+	if inprocess.FromContext(ctx) {
+		return next.NetworkServiceRegistryServer(ctx).Register(ctx, ns)
+	}
+	resp, err := s.client.Register(ctx, ns.Clone())
+	if err != nil {
+		return nil, err
+	}
+	return next.NetworkServiceRegistryServer(ctx).Register(ctx, resp)
+}
+
+func (s *mirrorNSServer) Find(query *registry.NetworkServiceQuery, server registry.NetworkServiceRegistry_FindServer) error {
+	return next.NetworkServiceRegistryServer(server.Context()).Find(query, server)
+}
+
+func (s *mirrorNSServer) Unregister(ctx context.Context, ns *registry.NetworkService) (*empty.Empty, error) {
+	if !inprocess.FromContext(ctx) {
+		if _, err := s.client.Unregister(ctx, ns.Clone()); err != nil {
+			return nil, err
+		}
+	}
+	return next.NetworkServiceRegistryServer(ctx).Unregister(ctx, ns)
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mirror
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/inprocess"
+)
+
+type mirrorNSEServer struct {
+	client registry.NetworkServiceEndpointRegistryClient
+}
+
+// NewNetworkServiceEndpointRegistryServer creates a new NSE server chain element forwarding Register and Unregister to
+// the primary registry with client and applying them locally once the primary has accepted them. The local
+// registration gets the expiration time granted by the primary.
+func NewNetworkServiceEndpointRegistryServer(client registry.NetworkServiceEndpointRegistryClient) registry.NetworkServiceEndpointRegistryServer {
+	return &mirrorNSEServer{
+		client: client,
+	}
+}
+
+func (s *mirrorNSEServer) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*registry.NetworkServiceEndpoint, error) {
+	if inprocess.FromContext(ctx) {
+		return next.NetworkServiceEndpointRegistryServer(ctx).Register(ctx, nse)
+	}
+	resp, err := s.client.Register(ctx, nse.Clone())
+	if err != nil {
+		return nil, err
+	}
+	return next.NetworkServiceEndpointRegistryServer(ctx).Register(ctx, resp)
+}
+
+func (s *mirrorNSEServer) Find(query *registry.NetworkServiceEndpointQuery, server registry.NetworkServiceEndpointRegistry_FindServer) error {
+	return next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, server)
+}
+
+func (s *mirrorNSEServer) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*empty.Empty, error) {
+	if !inprocess.FromContext(ctx) {
+		if _, err := s.client.Unregister(ctx, nse.Clone()); err != nil {
+			return nil, err
+		}
+	}
+	return next.NetworkServiceEndpointRegistryServer(ctx).Unregister(ctx, nse)
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package negativecache provides registry server chain elements caching the empty results of the Find queries going
+// to remote domains for a TTL, so the repeated queries for nonexistent services don't hammer the remote registries
+package negativecache
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/log"
+)
+
+type options struct {
+	fallback bool
+}
+
+// Option is an option for the negative cache chain elements
+type Option func(o *options)
+
+// WithFallback caches all the queries, not only the interdomain ones. Use it along with the upstream fallback, which
+// forwards the queries with no local match to the proxy registry.
+func WithFallback() Option {
+	return func(o *options) {
+		o.fallback = true
+	}
+}
+
+// cache keeps the queries with empty result until their expiration. Any registration clears it, so the local
+// registrations are found right away.
+type cache struct {
+	options
+	ttl  time.Duration
+	kind string
+	hits metric.Int64Counter
+
+	mu      sync.Mutex
+	entries map[string]time.Time
+}
+
+func newCache(kind string, ttl time.Duration, opts ...Option) *cache {
+	hits, err := otel.Meter("registry-memory").Int64Counter("registry.negative_cache.hits",
+		metric.WithDescription("Number of Find queries answered from the negative cache"))
+	if err != nil {
+		log.L().Errorf("failed to create negative cache hits counter: %s", err.Error())
+	}
+	c := &cache{
+		ttl:     ttl,
+		kind:    kind,
+		hits:    hits,
+		entries: make(map[string]time.Time),
+	}
+	for _, opt := range opts {
+		opt(&c.options)
+	}
+	return c
+}
+
+func key(query proto.Message) (string, bool) {
+	data, err := proto.MarshalOptions{Deterministic: true}.Marshal(query)
+	if err != nil {
+		return "", false
+	}
+	return string(data), true
+}
+
+// hit returns true if the query result is cached as empty
+func (c *cache) hit(ctx context.Context, key string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	expiration, ok := c.entries[key]
+	if !ok {
+		return false
+	}
+	if !clock.FromContext(ctx).Now().Before(expiration) {
+		delete(c.entries, key)
+		return false
+	}
+	if c.hits != nil {
+		c.hits.Add(ctx, 1, metric.WithAttributes(attribute.String("kind", c.kind)))
+	}
+	return true
+}
+
+func (c *cache) store(ctx context.Context, key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := clock.FromContext(ctx).Now()
+	for k, expiration := range c.entries {
+		if !now.Before(expiration) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = now.Add(c.ttl)
+}
+
+func (c *cache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]time.Time)
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package negativecache
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/interdomain"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/inprocess"
+)
+
+type negativeCacheNSServer struct {
+	*cache
+}
+
+// NewNetworkServiceRegistryServer creates a new NS server chain element caching the empty results of the non
+// watching interdomain Find queries for ttl
+func NewNetworkServiceRegistryServer(ttl time.Duration, opts ...Option) registry.NetworkServiceRegistryServer {
+	return &negativeCacheNSServer{
+		cache: newCache("ns", ttl, opts...),
+	}
+}
+
+func (s *negativeCacheNSServer) Register(ctx context.Context, ns *registry.NetworkService) (*registry.NetworkService, error) {
+	resp, err := next.NetworkServiceRegistryServer(ctx).Register(ctx, ns)
+	if err == nil {
+		s.clear()
+	}
+	return resp, err
+}
+
+func (s *negativeCacheNSServer) Find(query *registry.NetworkServiceQuery, server registry.NetworkServiceRegistry_FindServer) error {
+	k, ok := key(query)
+	if query.GetWatch() || !ok || inprocess.LocalOnlyFromContext(server.Context()) || !(s.fallback || interdomain.Is(query.GetNetworkService().GetName())) {
+		return next.NetworkServiceRegistryServer(server.Context()).Find(query, server)
+	}
+	if s.hit(server.Context(), k) {
+		return nil
+	}
+	counter := &nsCounter{NetworkServiceRegistry_FindServer: server}
+	if err := next.NetworkServiceRegistryServer(server.Context()).Find(query, counter); err != nil {
+		return err
+	}
+	if counter.count == 0 {
+		s.store(server.Context(), k)
+	}
+	return nil
+}
+
+func (s *negativeCacheNSServer) Unregister(ctx context.Context, ns *registry.NetworkService) (*empty.Empty, error) {
+	return next.NetworkServiceRegistryServer(ctx).Unregister(ctx, ns)
+}
+
+type nsCounter struct {
+	registry.NetworkServiceRegistry_FindServer
+	count int
+}
+
+func (c *nsCounter) Send(resp *registry.NetworkServiceResponse) error {
+	c.count++
+	return c.NetworkServiceRegistry_FindServer.Send(resp)
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package negativecache
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/interdomain"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/inprocess"
+)
+
+type negativeCacheNSEServer struct {
+	*cache
+}
+
+// NewNetworkServiceEndpointRegistryServer creates a new NSE server chain element caching the empty results of the
+// non watching interdomain Find queries for ttl
+func NewNetworkServiceEndpointRegistryServer(ttl time.Duration, opts ...Option) registry.NetworkServiceEndpointRegistryServer {
+	return &negativeCacheNSEServer{
+		cache: newCache("nse", ttl, opts...),
+	}
+}
+
+func (s *negativeCacheNSEServer) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*registry.NetworkServiceEndpoint, error) {
+	resp, err := next.NetworkServiceEndpointRegistryServer(ctx).Register(ctx, nse)
+	if err == nil {
+		s.clear()
+	}
+	return resp, err
+}
+
+func (s *negativeCacheNSEServer) Find(query *registry.NetworkServiceEndpointQuery, server registry.NetworkServiceEndpointRegistry_FindServer) error {
+	k, ok := key(query)
+	if query.GetWatch() || !ok || inprocess.LocalOnlyFromContext(server.Context()) || !(s.fallback || isInterdomainNSE(query.GetNetworkServiceEndpoint())) {
+		return next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, server)
+	}
+	if s.hit(server.Context(), k) {
+		return nil
+	}
+	counter := &nseCounter{NetworkServiceEndpointRegistry_FindServer: server}
+	if err := next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, counter); err != nil {
+		return err
+	}
+	if counter.count == 0 {
+		s.store(server.Context(), k)
+	}
+	return nil
+}
+
+func (s *negativeCacheNSEServer) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*empty.Empty, error) {
+	return next.NetworkServiceEndpointRegistryServer(ctx).Unregister(ctx, nse)
+}
+
+func isInterdomainNSE(nse *registry.NetworkServiceEndpoint) bool {
+	if interdomain.Is(nse.GetName()) {
+		return true
+	}
+	for _, name := range nse.GetNetworkServiceNames() {
+		if interdomain.Is(name) {
+			return true
+		}
+	}
+	return false
+}
+
+type nseCounter struct {
+	registry.NetworkServiceEndpointRegistry_FindServer
+	count int
+}
+
+func (c *nseCounter) Send(resp *registry.NetworkServiceEndpointResponse) error {
+	c.count++
+	return c.NetworkServiceEndpointRegistry_FindServer.Send(resp)
+}
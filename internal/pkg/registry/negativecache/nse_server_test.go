@@ -0,0 +1,115 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package negativecache_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/chain"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clockmock"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/negativecache"
+)
+
+type findCounter struct {
+	count int
+}
+
+func (c *findCounter) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*registry.NetworkServiceEndpoint, error) {
+	return next.NetworkServiceEndpointRegistryServer(ctx).Register(ctx, nse)
+}
+
+func (c *findCounter) Find(query *registry.NetworkServiceEndpointQuery, server registry.NetworkServiceEndpointRegistry_FindServer) error {
+	c.count++
+	return next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, server)
+}
+
+func (c *findCounter) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*empty.Empty, error) {
+	return next.NetworkServiceEndpointRegistryServer(ctx).Unregister(ctx, nse)
+}
+
+func find(ctx context.Context, t *testing.T, client registry.NetworkServiceEndpointRegistryClient, name string) int {
+	stream, err := client.Find(ctx, &registry.NetworkServiceEndpointQuery{
+		NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{Name: name},
+	})
+	require.NoError(t, err)
+	return len(registry.ReadNetworkServiceEndpointList(stream))
+}
+
+func TestNegativeCacheNSEServer(t *testing.T) {
+	clockMock := clockmock.New(context.Background())
+	ctx, cancel := context.WithTimeout(clock.WithClock(context.Background(), clockMock), 5*time.Second)
+	defer cancel()
+
+	counter := new(findCounter)
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		negativecache.NewNetworkServiceEndpointRegistryServer(time.Minute),
+		counter,
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+
+	// Empty interdomain results are cached for the TTL
+	require.Equal(t, 0, find(ctx, t, client, "nse-1@remote.domain"))
+	require.Equal(t, 0, find(ctx, t, client, "nse-1@remote.domain"))
+	require.Equal(t, 1, counter.count)
+
+	clockMock.Add(time.Minute)
+	require.Equal(t, 0, find(ctx, t, client, "nse-1@remote.domain"))
+	require.Equal(t, 2, counter.count)
+
+	// Local queries are not cached without fallback
+	require.Equal(t, 0, find(ctx, t, client, "nse-2"))
+	require.Equal(t, 0, find(ctx, t, client, "nse-2"))
+	require.Equal(t, 4, counter.count)
+
+	// Register clears the cache
+	_, err := client.Register(ctx, &registry.NetworkServiceEndpoint{
+		Name:           "nse-1@remote.domain",
+		ExpirationTime: timestamppb.New(clockMock.Now().Add(time.Hour)),
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, find(ctx, t, client, "nse-1@remote.domain"))
+	require.Equal(t, 5, counter.count)
+}
+
+func TestNegativeCacheNSEServer_Fallback(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	counter := new(findCounter)
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		negativecache.NewNetworkServiceEndpointRegistryServer(time.Minute, negativecache.WithFallback()),
+		counter,
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+
+	require.Equal(t, 0, find(ctx, t, client, "nse-2"))
+	require.Equal(t, 0, find(ctx, t, client, "nse-2"))
+	require.Equal(t, 1, counter.count)
+}
@@ -0,0 +1,31 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !faultinject
+
+package nsestore
+
+import (
+	"context"
+)
+
+// Faults are the failures injected into the store operations. They are available with the faultinject build tag only,
+// the other builds inject nothing.
+type Faults struct{}
+
+func (f *Faults) inject(context.Context, string) error {
+	return nil
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build faultinject
+
+package nsestore
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+)
+
+// Store operations the faults are injected into
+const (
+	OpRegister   = opRegister
+	OpFind       = opFind
+	OpUnregister = opUnregister
+)
+
+// Fault is the failure injected into a store operation: the operation is delayed by Latency, or until the context is
+// done, and then fails with Err, if set
+type Fault struct {
+	Latency time.Duration
+	Err     error
+}
+
+// Faults are the failures injected into the store operations for the resilience tests. They are safe for concurrent
+// use, so the faults can be changed while the store serves.
+type Faults struct {
+	mu     sync.Mutex
+	faults map[string]Fault
+}
+
+// NewFaults creates new Faults injecting nothing
+func NewFaults() *Faults {
+	return &Faults{
+		faults: make(map[string]Fault),
+	}
+}
+
+// Set injects the fault into each following op
+func (f *Faults) Set(op string, fault Fault) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.faults[op] = fault
+}
+
+// Reset stops injecting the faults
+func (f *Faults) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.faults = make(map[string]Fault)
+}
+
+// WithFaults injects the faults into the store operations
+func WithFaults(f *Faults) Option {
+	return func(s *nseStoreServer) {
+		s.faults = f
+	}
+}
+
+func (f *Faults) inject(ctx context.Context, op string) error {
+	if f == nil {
+		return nil
+	}
+	f.mu.Lock()
+	fault := f.faults[op]
+	f.mu.Unlock()
+
+	if fault.Latency > 0 {
+		select {
+		case <-ctx.Done():
+			return errors.WithStack(ctx.Err())
+		case <-clock.FromContext(ctx).After(fault.Latency):
+		}
+	}
+	return fault.Err
+}
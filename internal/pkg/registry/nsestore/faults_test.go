@@ -0,0 +1,94 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build faultinject
+
+package nsestore_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/nsestore"
+)
+
+func TestFaults_Register(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	faults := nsestore.NewFaults()
+	client := adapters.NetworkServiceEndpointServerToClient(nsestore.NewNetworkServiceEndpointRegistryServer(nsestore.WithFaults(faults)))
+
+	stream, err := client.Find(ctx, &registry.NetworkServiceEndpointQuery{
+		NetworkServiceEndpoint: new(registry.NetworkServiceEndpoint),
+		Watch:                  true,
+	})
+	require.NoError(t, err)
+
+	faults.Set(nsestore.OpRegister, nsestore.Fault{Err: status.Error(codes.Unavailable, "storage is down")})
+	_, err = client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-1"})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+
+	// The failed registration is neither stored nor sent to the watchers
+	faults.Reset()
+	_, err = client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-2"})
+	require.NoError(t, err)
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "nse-2", resp.GetNetworkServiceEndpoint().GetName())
+
+	findStream, err := client.Find(ctx, &registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: new(registry.NetworkServiceEndpoint)})
+	require.NoError(t, err)
+	require.Len(t, registry.ReadNetworkServiceEndpointList(findStream), 1)
+}
+
+func TestFaults_SlowFind(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	faults := nsestore.NewFaults()
+	client := adapters.NetworkServiceEndpointServerToClient(nsestore.NewNetworkServiceEndpointRegistryServer(nsestore.WithFaults(faults)))
+	faults.Set(nsestore.OpFind, nsestore.Fault{Latency: time.Hour})
+
+	// The slow Find gives up at the caller deadline, the writes are not blocked meanwhile
+	findCtx, findCancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer findCancel()
+	errCh := make(chan error, 1)
+	go func() {
+		stream, err := client.Find(findCtx, &registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: new(registry.NetworkServiceEndpoint)})
+		if err == nil {
+			_, err = stream.Recv()
+		}
+		errCh <- err
+	}()
+	_, err := client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-1"})
+	require.NoError(t, err)
+	require.ErrorIs(t, <-errCh, context.DeadlineExceeded)
+
+	faults.Reset()
+	stream, err := client.Find(ctx, &registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: new(registry.NetworkServiceEndpoint)})
+	require.NoError(t, err)
+	require.Len(t, registry.ReadNetworkServiceEndpointList(stream), 1)
+}
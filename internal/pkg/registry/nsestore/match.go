@@ -0,0 +1,69 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package nsestore provides the in-memory NSE store chain element. It is the sdk memory NSE server with an
+// allocation free Find matching: the query is matched without building sets, the entries are cloned only once
+// selected, and the events are cloned only for the watchers they match.
+package nsestore
+
+import (
+	"strings"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+)
+
+// Match returns true if the nse matches the query NSE. It has the semantics of matchutils.MatchNetworkServiceEndpoints
+// and doesn't allocate.
+func Match(query, nse *registry.NetworkServiceEndpoint) bool {
+	return (query.GetName() == "" || strings.Contains(nse.GetName(), query.GetName())) &&
+		(query.GetNetworkServiceLabels() == nil || labelsContain(nse.GetNetworkServiceLabels(), query.GetNetworkServiceLabels())) &&
+		(query.GetExpirationTime() == nil || query.GetExpirationTime().GetSeconds() == nse.GetExpirationTime().GetSeconds()) &&
+		(query.GetNetworkServiceNames() == nil || containsAll(nse.GetNetworkServiceNames(), query.GetNetworkServiceNames())) &&
+		(query.GetUrl() == "" || strings.Contains(nse.GetUrl(), query.GetUrl()))
+}
+
+func labelsContain(where, what map[string]*registry.NetworkServiceLabels) bool {
+	for service, labels := range what {
+		whereLabels, ok := where[service]
+		if !ok {
+			return false
+		}
+		for key, value := range labels.GetLabels() {
+			if whereValue, ok := whereLabels.GetLabels()[key]; !ok || whereValue != value {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// containsAll returns true if all what items are in where. The lists are short, so the nested loops are faster than
+// a set.
+func containsAll(where, what []string) bool {
+	for _, s := range what {
+		found := false
+		for _, w := range where {
+			if w == s {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,99 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nsestore_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/matchutils"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/nsestore"
+)
+
+func testNSE() *registry.NetworkServiceEndpoint {
+	return &registry.NetworkServiceEndpoint{
+		Name:                "nse-1",
+		Url:                 "tcp://1.1.1.1:5001",
+		NetworkServiceNames: []string{"ns-1", "ns-2"},
+		NetworkServiceLabels: map[string]*registry.NetworkServiceLabels{
+			"ns-1": {Labels: map[string]string{"app": "firewall", "zone": "a"}},
+		},
+		ExpirationTime: &timestamppb.Timestamp{Seconds: 100},
+	}
+}
+
+func TestMatch(t *testing.T) {
+	for name, query := range map[string]*registry.NetworkServiceEndpoint{
+		"empty":          {},
+		"name":           {Name: "nse"},
+		"other name":     {Name: "nse-2"},
+		"url":            {Url: "1.1.1.1"},
+		"other url":      {Url: "2.2.2.2"},
+		"names":          {NetworkServiceNames: []string{"ns-2", "ns-1"}},
+		"other names":    {NetworkServiceNames: []string{"ns-1", "ns-3"}},
+		"no names":       {NetworkServiceNames: []string{}},
+		"labels":         {NetworkServiceLabels: map[string]*registry.NetworkServiceLabels{"ns-1": {Labels: map[string]string{"app": "firewall"}}}},
+		"other labels":   {NetworkServiceLabels: map[string]*registry.NetworkServiceLabels{"ns-1": {Labels: map[string]string{"app": "vpn"}}}},
+		"other service":  {NetworkServiceLabels: map[string]*registry.NetworkServiceLabels{"ns-2": {}}},
+		"expiration":     {ExpirationTime: &timestamppb.Timestamp{Seconds: 100}},
+		"other expiry":   {ExpirationTime: &timestamppb.Timestamp{Seconds: 200}},
+		"name and names": {Name: "nse-1", NetworkServiceNames: []string{"ns-1"}},
+	} {
+		query := query
+		t.Run(name, func(t *testing.T) {
+			nse := testNSE()
+			require.Equal(t, matchutils.MatchNetworkServiceEndpoints(query, nse), nsestore.Match(query, nse))
+		})
+	}
+}
+
+func TestMatch_NoAllocs(t *testing.T) {
+	nse := testNSE()
+	query := &registry.NetworkServiceEndpoint{
+		Name:                "nse",
+		NetworkServiceNames: []string{"ns-2", "ns-1"},
+		NetworkServiceLabels: map[string]*registry.NetworkServiceLabels{
+			"ns-1": {Labels: map[string]string{"app": "firewall"}},
+		},
+	}
+	require.Zero(t, testing.AllocsPerRun(100, func() {
+		nsestore.Match(query, nse)
+	}))
+}
+
+func BenchmarkMatch(b *testing.B) {
+	nse := testNSE()
+	query := &registry.NetworkServiceEndpoint{NetworkServiceNames: []string{"ns-2", "ns-1"}}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		nsestore.Match(query, nse)
+	}
+}
+
+func BenchmarkMatchUtils(b *testing.B) {
+	nse := testNSE()
+	query := &registry.NetworkServiceEndpoint{NetworkServiceNames: []string{"ns-2", "ns-1"}}
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		matchutils.MatchNetworkServiceEndpoints(query, nse)
+	}
+}
@@ -0,0 +1,272 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nsestore
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/edwarnicke/serialize"
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/listwatch"
+)
+
+const defaultEventChannelSize = 10
+
+// Store operations, see Faults
+const (
+	opRegister   = "register"
+	opFind       = "find"
+	opUnregister = "unregister"
+)
+
+type watcher struct {
+	query *registry.NetworkServiceEndpoint
+	ch    chan *registry.NetworkServiceEndpointResponse
+	// generation is the generation of the snapshot the watcher started from, the older events are in the snapshot
+	generation uint64
+	// size is the size of the last snapshot
+	size int
+}
+
+// nseStoreServer stores the NSEs. Each write increments the store generation and its event is queued to the watchers
+// in the generation order. Find streams are served from a snapshot of a single generation, so a stream never sees an
+// entry twice or misses an entry because of the concurrent writes.
+type nseStoreServer struct {
+	mu                      sync.RWMutex
+	networkServiceEndpoints map[string]*registry.NetworkServiceEndpoint
+	generation              uint64
+	executor                serialize.Executor
+	watchers                map[string]*watcher
+	eventChannelSize        int
+	faults                  *Faults
+	resync                  *resync
+}
+
+// NewNetworkServiceEndpointRegistryServer creates a new in-memory NSE store server
+func NewNetworkServiceEndpointRegistryServer(opts ...Option) registry.NetworkServiceEndpointRegistryServer {
+	s := &nseStoreServer{
+		networkServiceEndpoints: make(map[string]*registry.NetworkServiceEndpoint),
+		watchers:                make(map[string]*watcher),
+		eventChannelSize:        defaultEventChannelSize,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+func (s *nseStoreServer) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*registry.NetworkServiceEndpoint, error) {
+	if err := s.faults.inject(ctx, opRegister); err != nil {
+		return nil, err
+	}
+	r, err := next.NetworkServiceEndpointRegistryServer(ctx).Register(ctx, nse)
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.networkServiceEndpoints[r.GetName()] = r.Clone()
+	s.sendEvent(&registry.NetworkServiceEndpointResponse{NetworkServiceEndpoint: r})
+
+	return r, nil
+}
+
+// sendEvent starts a new generation and sends a copy of the event to every watcher it matches started from an older
+// generation. It must be called with the write lock held, so the events are queued in the generation order.
+func (s *nseStoreServer) sendEvent(event *registry.NetworkServiceEndpointResponse) {
+	s.generation++
+	generation := s.generation
+	event = event.Clone()
+	s.executor.AsyncExec(func() {
+		for _, w := range s.watchers {
+			if w.generation < generation && Match(w.query, event.GetNetworkServiceEndpoint()) {
+				w.ch <- event.Clone()
+			}
+		}
+	})
+}
+
+// snapshot returns the NSEs matching the query at the current generation
+func (s *nseStoreServer) snapshot(query *registry.NetworkServiceEndpoint) (nses []*registry.NetworkServiceEndpoint, generation uint64) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, nse := range s.networkServiceEndpoints {
+		if Match(query, nse) {
+			nses = append(nses, nse)
+		}
+	}
+	return nses, s.generation
+}
+
+func (s *nseStoreServer) Find(query *registry.NetworkServiceEndpointQuery, server registry.NetworkServiceEndpointRegistry_FindServer) error {
+	if err := s.faults.inject(server.Context(), opFind); err != nil {
+		return err
+	}
+	if !query.GetWatch() {
+		nses, _ := s.snapshot(query.GetNetworkServiceEndpoint())
+		for _, nse := range nses {
+			resp := &registry.NetworkServiceEndpointResponse{NetworkServiceEndpoint: nse.Clone()}
+			if err := server.Send(resp); err != nil {
+				return errors.Wrapf(err, "NetworkServiceRegistry find server failed to send a response %s", resp.String())
+			}
+		}
+		return next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, server)
+	}
+
+	if err := next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, server); err != nil {
+		return err
+	}
+
+	w := &watcher{
+		query: query.GetNetworkServiceEndpoint(),
+		ch:    make(chan *registry.NetworkServiceEndpointResponse, s.eventChannelSize),
+	}
+	id := uuid.New().String()
+
+	s.executor.AsyncExec(func() {
+		var nses []*registry.NetworkServiceEndpoint
+		nses, w.generation = s.snapshot(w.query)
+		w.size = len(nses)
+		s.watchers[id] = w
+		for _, nse := range nses {
+			w.ch <- &registry.NetworkServiceEndpointResponse{NetworkServiceEndpoint: nse.Clone()}
+		}
+		// nil marks the end of the snapshot
+		w.ch <- nil
+	})
+	defer s.closeWatcher(id, w)
+
+	timer := s.resync.newTimer(server.Context())
+	defer timer.stop()
+
+	var err error
+	for ; err == nil; err = s.receiveEvent(server, id, w, timer) {
+	}
+	if !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+func (s *nseStoreServer) closeWatcher(id string, w *watcher) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s.executor.AsyncExec(func() {
+		delete(s.watchers, id)
+		cancel()
+	})
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.ch:
+		}
+	}
+}
+
+func (s *nseStoreServer) receiveEvent(server registry.NetworkServiceEndpointRegistry_FindServer, id string, w *watcher, timer *resyncTimer) error {
+	select {
+	case <-server.Context().Done():
+		return errors.WithStack(io.EOF)
+	case <-timer.C():
+		timer.fired()
+		s.resyncWatcher(id, w)
+		return nil
+	case event := <-w.ch:
+		switch event {
+		case nil:
+			timer.reset(w.size)
+			return s.sendSnapshotDone(server)
+		case resyncStart:
+			return s.sendResync(server)
+		}
+		if err := server.Send(event); err != nil {
+			if server.Context().Err() != nil {
+				return errors.WithStack(io.EOF)
+			}
+			return errors.Wrapf(err, "NetworkServiceRegistry find server failed to send a response %s", event.String())
+		}
+		return nil
+	}
+}
+
+// resyncWatcher queues the resync snapshot of the watcher at the current generation, so the events following it are
+// not lost and the events preceding it are not sent again
+func (s *nseStoreServer) resyncWatcher(id string, w *watcher) {
+	s.executor.AsyncExec(func() {
+		if s.watchers[id] != w {
+			return
+		}
+		var nses []*registry.NetworkServiceEndpoint
+		nses, w.generation = s.snapshot(w.query)
+		w.size = len(nses)
+		w.ch <- resyncStart
+		for _, nse := range nses {
+			w.ch <- &registry.NetworkServiceEndpointResponse{NetworkServiceEndpoint: nse.Clone()}
+		}
+		w.ch <- nil
+	})
+}
+
+// sendResync calls the listwatch resync hooks of the stream before the resync snapshot is sent
+func (s *nseStoreServer) sendResync(server registry.NetworkServiceEndpointRegistry_FindServer) error {
+	if err := listwatch.Resync(server.Context()); err != nil {
+		if server.Context().Err() != nil {
+			return errors.WithStack(io.EOF)
+		}
+		return err
+	}
+	return nil
+}
+
+// sendSnapshotDone calls the listwatch hooks of the stream once the snapshot is sent
+func (s *nseStoreServer) sendSnapshotDone(server registry.NetworkServiceEndpointRegistry_FindServer) error {
+	if err := listwatch.SnapshotDone(server.Context()); err != nil {
+		if server.Context().Err() != nil {
+			return errors.WithStack(io.EOF)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *nseStoreServer) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*empty.Empty, error) {
+	if err := s.faults.inject(ctx, opUnregister); err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	if unregisterNSE, ok := s.networkServiceEndpoints[nse.GetName()]; ok {
+		delete(s.networkServiceEndpoints, nse.GetName())
+		s.sendEvent(&registry.NetworkServiceEndpointResponse{NetworkServiceEndpoint: unregisterNSE, Deleted: true})
+	}
+	s.mu.Unlock()
+
+	return next.NetworkServiceEndpointRegistryServer(ctx).Unregister(ctx, nse)
+}
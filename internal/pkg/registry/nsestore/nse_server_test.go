@@ -0,0 +1,149 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nsestore_test
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/nsestore"
+)
+
+func TestNSEStoreServer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := adapters.NetworkServiceEndpointServerToClient(nsestore.NewNetworkServiceEndpointRegistryServer())
+
+	_, err := client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-1", NetworkServiceNames: []string{"ns-1"}})
+	require.NoError(t, err)
+
+	stream, err := client.Find(ctx, &registry.NetworkServiceEndpointQuery{
+		NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{NetworkServiceNames: []string{"ns-1"}},
+		Watch:                  true,
+	})
+	require.NoError(t, err)
+
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "nse-1", resp.GetNetworkServiceEndpoint().GetName())
+
+	// Not matching events are not sent to the watcher
+	_, err = client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-2", NetworkServiceNames: []string{"ns-2"}})
+	require.NoError(t, err)
+	_, err = client.Unregister(ctx, &registry.NetworkServiceEndpoint{Name: "nse-1"})
+	require.NoError(t, err)
+
+	resp, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "nse-1", resp.GetNetworkServiceEndpoint().GetName())
+	require.True(t, resp.GetDeleted())
+
+	findStream, err := client.Find(ctx, &registry.NetworkServiceEndpointQuery{
+		NetworkServiceEndpoint: new(registry.NetworkServiceEndpoint),
+	})
+	require.NoError(t, err)
+	nses := registry.ReadNetworkServiceEndpointList(findStream)
+	require.Len(t, nses, 1)
+	require.Equal(t, "nse-2", nses[0].GetName())
+}
+
+func TestNSEStoreServer_WatchDuringWrites(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := adapters.NetworkServiceEndpointServerToClient(nsestore.NewNetworkServiceEndpointRegistryServer())
+
+	const count = 200
+	var wg sync.WaitGroup
+	for i := 0; i < count; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, err := client.Register(ctx, &registry.NetworkServiceEndpoint{Name: fmt.Sprintf("nse-%d", i)})
+			require.NoError(t, err)
+		}(i)
+	}
+
+	stream, err := client.Find(ctx, &registry.NetworkServiceEndpointQuery{
+		NetworkServiceEndpoint: new(registry.NetworkServiceEndpoint),
+		Watch:                  true,
+	})
+	require.NoError(t, err)
+	wg.Wait()
+
+	// Each NSE is either in the snapshot or in the events after it, never in both
+	seen := make(map[string]bool)
+	for len(seen) < count {
+		resp, recvErr := stream.Recv()
+		require.NoError(t, recvErr)
+		name := resp.GetNetworkServiceEndpoint().GetName()
+		require.False(t, seen[name], "duplicate %s", name)
+		seen[name] = true
+	}
+
+	_, err = client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-last"})
+	require.NoError(t, err)
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "nse-last", resp.GetNetworkServiceEndpoint().GetName())
+}
+
+func benchmarkFind(b *testing.B, server registry.NetworkServiceEndpointRegistryServer) {
+	ctx := context.Background()
+	client := adapters.NetworkServiceEndpointServerToClient(server)
+	for i := 0; i < 1000; i++ {
+		_, err := client.Register(ctx, &registry.NetworkServiceEndpoint{
+			Name:                fmt.Sprintf("nse-%d", i),
+			NetworkServiceNames: []string{fmt.Sprintf("ns-%d", i%100)},
+		})
+		require.NoError(b, err)
+	}
+	query := &registry.NetworkServiceEndpointQuery{
+		NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{NetworkServiceNames: []string{"ns-1"}},
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		stream, err := client.Find(ctx, query)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if n := len(registry.ReadNetworkServiceEndpointList(stream)); n != 10 {
+			b.Fatalf("expected 10 NSEs, got %d", n)
+		}
+	}
+}
+
+func BenchmarkNSEStoreServer_Find(b *testing.B) {
+	benchmarkFind(b, nsestore.NewNetworkServiceEndpointRegistryServer())
+}
+
+func BenchmarkMemoryNSEServer_Find(b *testing.B) {
+	benchmarkFind(b, memory.NewNetworkServiceEndpointRegistryServer())
+}
@@ -0,0 +1,39 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nsestore
+
+import (
+	"time"
+)
+
+// Option is an option for the NSE store server
+type Option func(s *nseStoreServer)
+
+// WithEventChannelSize sets the size of the watcher event channels, default is 10
+func WithEventChannelSize(size int) Option {
+	return func(s *nseStoreServer) {
+		s.eventChannelSize = size
+	}
+}
+
+// WithResync resyncs the watch streams asking for it every interval, stretched so the resyncs of all the streams send at
+// most maxRate NSEs per second, 0 is no limit
+func WithResync(interval time.Duration, maxRate float64) Option {
+	return func(s *nseStoreServer) {
+		s.resync = newResync(interval, maxRate)
+	}
+}
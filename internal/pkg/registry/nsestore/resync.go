@@ -0,0 +1,131 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nsestore
+
+import (
+	"context"
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/metric"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/log"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/listwatch"
+)
+
+// resyncStart marks the start of a resync snapshot in the watcher channel
+var resyncStart = new(registry.NetworkServiceEndpointResponse)
+
+// resync schedules the resyncs of the watch streams asking for them. The interval of a stream is the base interval
+// stretched so the resyncs of all the streams send at most maxRate NSEs per second, then jittered by ±25%, so the
+// streams started together don't resync together.
+type resync struct {
+	interval time.Duration
+	maxRate  float64
+	streams  int64
+	resyncs  metric.Int64Counter
+}
+
+func newResync(interval time.Duration, maxRate float64) *resync {
+	resyncs, err := otel.Meter("registry-memory").Int64Counter("registry.watch.resyncs",
+		metric.WithDescription("Number of the resync snapshots sent to the NSE watch streams"))
+	if err != nil {
+		log.L().Errorf("failed to create watch resyncs counter: %s", err.Error())
+	}
+	return &resync{
+		interval: interval,
+		maxRate:  maxRate,
+		resyncs:  resyncs,
+	}
+}
+
+// next returns the interval till the next resync of a stream with the snapshot of size NSEs
+func (r *resync) next(size int) time.Duration {
+	interval := r.interval
+	if r.maxRate > 0 {
+		seconds := float64(atomic.LoadInt64(&r.streams)) * float64(size) / r.maxRate
+		if loaded := time.Duration(seconds * float64(time.Second)); loaded > interval {
+			interval = loaded
+		}
+	}
+	// #nosec G404 - the jitter doesn't need a secure random
+	return time.Duration(float64(interval) * (0.75 + rand.Float64()/2))
+}
+
+// newTimer returns the resync timer of the watch stream, nil if the stream is not resynced
+func (r *resync) newTimer(ctx context.Context) *resyncTimer {
+	if r == nil || !listwatch.ResyncRequested(ctx) {
+		return nil
+	}
+	atomic.AddInt64(&r.streams, 1)
+	return &resyncTimer{
+		resync: r,
+		ctx:    ctx,
+	}
+}
+
+// resyncTimer is the resync timer of a watch stream. It is started once the stream has sent a snapshot, so the
+// interval accounts for the snapshot size.
+type resyncTimer struct {
+	resync *resync
+	ctx    context.Context
+	timer  clock.Timer
+}
+
+// C returns the channel the time is sent to once the stream should be resynced
+func (t *resyncTimer) C() <-chan time.Time {
+	if t == nil || t.timer == nil {
+		return nil
+	}
+	return t.timer.C()
+}
+
+// reset starts the timer after the stream has sent the snapshot of size NSEs
+func (t *resyncTimer) reset(size int) {
+	if t == nil {
+		return
+	}
+	d := t.resync.next(size)
+	if t.timer == nil {
+		t.timer = clock.FromContext(t.ctx).Timer(d)
+		return
+	}
+	t.timer.Reset(d)
+}
+
+// fired records the resync once the timer has fired
+func (t *resyncTimer) fired() {
+	if t.resync.resyncs != nil {
+		t.resync.resyncs.Add(t.ctx, 1)
+	}
+}
+
+func (t *resyncTimer) stop() {
+	if t == nil {
+		return
+	}
+	if t.timer != nil {
+		t.timer.Stop()
+	}
+	atomic.AddInt64(&t.resync.streams, -1)
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package nsevents provides a registry server chain element publishing the NS events to the bus. The memory NS store
+// doesn't send the unregistered NSs to the watchers, so the events are published on the calls instead of the watch.
+package nsevents
+
+import (
+	"context"
+
+	"github.com/edwarnicke/genericsync"
+	"github.com/golang/protobuf/ptypes/empty"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/interdomain"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/events"
+)
+
+type nsEventsNSServer struct {
+	bus *events.Bus
+	// registered keeps the names of the registered local NSs
+	registered genericsync.Map[string, struct{}]
+}
+
+// NewNetworkServiceRegistryServer creates a new NS server chain element publishing to the bus:
+//   - events.TypeNSRegistered on a successful Register of a new NS;
+//   - events.TypeNSUpdated on a successful Register of an already registered NS;
+//   - events.TypeNSUnregistered on a successful Unregister of a registered NS.
+//
+// The events have the NS "name" and "payload" attributes. The interdomain NSs are registered in the remote registry
+// and have no events.
+func NewNetworkServiceRegistryServer(bus *events.Bus) registry.NetworkServiceRegistryServer {
+	return &nsEventsNSServer{
+		bus: bus,
+	}
+}
+
+func (s *nsEventsNSServer) Register(ctx context.Context, ns *registry.NetworkService) (*registry.NetworkService, error) {
+	resp, err := next.NetworkServiceRegistryServer(ctx).Register(ctx, ns)
+	if err != nil || interdomain.Is(resp.GetName()) {
+		return resp, err
+	}
+	eventType := events.TypeNSRegistered
+	if _, loaded := s.registered.LoadOrStore(resp.GetName(), struct{}{}); loaded {
+		eventType = events.TypeNSUpdated
+	}
+	s.publish(ctx, eventType, resp)
+	return resp, nil
+}
+
+func (s *nsEventsNSServer) Find(query *registry.NetworkServiceQuery, server registry.NetworkServiceRegistry_FindServer) error {
+	return next.NetworkServiceRegistryServer(server.Context()).Find(query, server)
+}
+
+func (s *nsEventsNSServer) Unregister(ctx context.Context, ns *registry.NetworkService) (*empty.Empty, error) {
+	resp, err := next.NetworkServiceRegistryServer(ctx).Unregister(ctx, ns)
+	if err != nil {
+		return nil, err
+	}
+	if _, loaded := s.registered.LoadAndDelete(ns.GetName()); loaded {
+		s.publish(ctx, events.TypeNSUnregistered, ns)
+	}
+	return resp, nil
+}
+
+func (s *nsEventsNSServer) publish(ctx context.Context, eventType string, ns *registry.NetworkService) {
+	s.bus.Publish(ctx, events.New(eventType, map[string]string{
+		"name":    ns.GetName(),
+		"payload": ns.GetPayload(),
+	}))
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nsevents_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/chain"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/events"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/nsevents"
+)
+
+func TestNSEventsNSServer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	bus := events.NewBus()
+	defer bus.Close()
+	ch := bus.Subscribe(10)
+
+	client := adapters.NetworkServiceServerToClient(chain.NewNetworkServiceRegistryServer(
+		nsevents.NewNetworkServiceRegistryServer(bus),
+		memory.NewNetworkServiceRegistryServer(),
+	))
+	ns := &registry.NetworkService{
+		Name:    "ns-1",
+		Payload: "IP",
+	}
+
+	_, err := client.Register(ctx, ns)
+	require.NoError(t, err)
+	event := <-ch
+	require.Equal(t, events.TypeNSRegistered, event.Type)
+	require.Equal(t, map[string]string{"name": "ns-1", "payload": "IP"}, event.Attributes)
+
+	_, err = client.Register(ctx, ns)
+	require.NoError(t, err)
+	require.Equal(t, events.TypeNSUpdated, (<-ch).Type)
+
+	_, err = client.Unregister(ctx, ns)
+	require.NoError(t, err)
+	require.Equal(t, events.TypeNSUnregistered, (<-ch).Type)
+
+	// Unregistering the missing NS has no event, registering it again is a new NS
+	_, err = client.Unregister(ctx, ns)
+	require.NoError(t, err)
+	_, err = client.Register(ctx, ns)
+	require.NoError(t, err)
+	require.Equal(t, events.TypeNSRegistered, (<-ch).Type)
+	require.Empty(t, ch)
+}
@@ -0,0 +1,43 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pagination
+
+import (
+	"context"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+)
+
+// Filter returns the NSE to send in place of nse, or nil to drop it
+type Filter func(nse *registry.NetworkServiceEndpoint) *registry.NetworkServiceEndpoint
+
+type contextKeyType string
+
+const contextKey contextKeyType = "pagination.filter"
+
+// WithFilter stores the filter into ctx. The Find results are filtered before the pages are cut, so the callers
+// dropping some of the results still get the full pages.
+func WithFilter(ctx context.Context, filter Filter) context.Context {
+	return context.WithValue(ctx, contextKey, filter)
+}
+
+func filterFromContext(ctx context.Context) Filter {
+	if filter, ok := ctx.Value(contextKey).(Filter); ok {
+		return filter
+	}
+	return nil
+}
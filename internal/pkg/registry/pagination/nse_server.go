@@ -0,0 +1,153 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pagination provides NSE registry server chain element limiting the number of the Find results and paging
+// through them
+package pagination
+
+import (
+	"context"
+	"sort"
+	"strconv"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/queryparams"
+)
+
+const (
+	// PageSizeParam is the query parameter setting the page size, it cannot exceed the server max results
+	PageSizeParam = "page_size"
+	// PageTokenParam is the query parameter continuing the Find after the page token. The page token is the name of
+	// the last NSE received in the previous page.
+	PageTokenParam = "page_token"
+)
+
+type paginationNSEServer struct {
+	maxResults int
+}
+
+// NewNetworkServiceEndpointRegistryServer creates a new NSE server chain element sending at most maxResults
+// (0 means no limit) NSEs for the non watching Find queries. Paged results are sorted by name.
+func NewNetworkServiceEndpointRegistryServer(maxResults int) registry.NetworkServiceEndpointRegistryServer {
+	return &paginationNSEServer{
+		maxResults: maxResults,
+	}
+}
+
+func (s *paginationNSEServer) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*registry.NetworkServiceEndpoint, error) {
+	return next.NetworkServiceEndpointRegistryServer(ctx).Register(ctx, nse)
+}
+
+func (s *paginationNSEServer) Find(query *registry.NetworkServiceEndpointQuery, server registry.NetworkServiceEndpointRegistry_FindServer) error {
+	filter := filterFromContext(server.Context())
+	if query.GetWatch() {
+		return next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, withFilter(server, filter))
+	}
+
+	params := queryparams.FromContext(server.Context())
+	pageToken, paged := params[PageTokenParam]
+	pageSize := s.maxResults
+	if value, ok := params[PageSizeParam]; ok {
+		size, err := strconv.Atoi(value)
+		if err != nil || size <= 0 {
+			return status.Errorf(codes.InvalidArgument, "invalid %s: %s", PageSizeParam, value)
+		}
+		if pageSize == 0 || size < pageSize {
+			pageSize = size
+		}
+		paged = true
+	}
+	if pageSize == 0 && !paged {
+		return next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, withFilter(server, filter))
+	}
+
+	collector := &nseCollector{ctx: server.Context()}
+	if err := next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, withFilter(collector, filter)); err != nil {
+		return err
+	}
+
+	responses := collector.responses
+	if paged {
+		sort.Slice(responses, func(i, j int) bool {
+			return responses[i].GetNetworkServiceEndpoint().GetName() < responses[j].GetNetworkServiceEndpoint().GetName()
+		})
+		start := sort.Search(len(responses), func(i int) bool {
+			return responses[i].GetNetworkServiceEndpoint().GetName() > pageToken
+		})
+		responses = responses[start:]
+	}
+	if pageSize > 0 && len(responses) > pageSize {
+		responses = responses[:pageSize]
+	}
+
+	for _, resp := range responses {
+		if err := server.Send(resp); err != nil {
+			return errors.Wrapf(err, "NetworkServiceEndpointRegistry find server failed to send a response %s", resp.String())
+		}
+	}
+	return nil
+}
+
+func (s *paginationNSEServer) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*empty.Empty, error) {
+	return next.NetworkServiceEndpointRegistryServer(ctx).Unregister(ctx, nse)
+}
+
+type nseCollector struct {
+	grpc.ServerStream
+	ctx       context.Context
+	responses []*registry.NetworkServiceEndpointResponse
+}
+
+func (c *nseCollector) Send(resp *registry.NetworkServiceEndpointResponse) error {
+	c.responses = append(c.responses, resp)
+	return nil
+}
+
+func (c *nseCollector) Context() context.Context {
+	return c.ctx
+}
+
+func withFilter(server registry.NetworkServiceEndpointRegistry_FindServer, filter Filter) registry.NetworkServiceEndpointRegistry_FindServer {
+	if filter == nil {
+		return server
+	}
+	return &filterNSEFindServer{NetworkServiceEndpointRegistry_FindServer: server, filter: filter}
+}
+
+type filterNSEFindServer struct {
+	registry.NetworkServiceEndpointRegistry_FindServer
+	filter Filter
+}
+
+func (s *filterNSEFindServer) Send(resp *registry.NetworkServiceEndpointResponse) error {
+	nse := s.filter(resp.GetNetworkServiceEndpoint())
+	if nse == nil {
+		return nil
+	}
+	if nse != resp.GetNetworkServiceEndpoint() {
+		resp = &registry.NetworkServiceEndpointResponse{NetworkServiceEndpoint: nse, Deleted: resp.GetDeleted()}
+	}
+	return s.NetworkServiceEndpointRegistry_FindServer.Send(resp)
+}
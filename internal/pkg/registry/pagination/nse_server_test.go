@@ -0,0 +1,131 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pagination_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/chain"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/pagination"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/queryparams"
+)
+
+func find(ctx context.Context, t *testing.T, client registry.NetworkServiceEndpointRegistryClient, params map[string]string) []string {
+	query := &registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: new(registry.NetworkServiceEndpoint)}
+	if params != nil {
+		query.NetworkServiceEndpoint.NetworkServiceLabels = map[string]*registry.NetworkServiceLabels{
+			queryparams.Key: {Labels: params},
+		}
+	}
+	stream, err := client.Find(ctx, query)
+	require.NoError(t, err)
+
+	var names []string
+	for _, nse := range registry.ReadNetworkServiceEndpointList(stream) {
+		names = append(names, nse.GetName())
+	}
+	return names
+}
+
+func TestPaginationNSEServer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		queryparams.NewNetworkServiceEndpointRegistryServer(),
+		pagination.NewNetworkServiceEndpointRegistryServer(3),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+	for i := 0; i < 5; i++ {
+		_, err := client.Register(ctx, &registry.NetworkServiceEndpoint{Name: fmt.Sprintf("nse-%d", i)})
+		require.NoError(t, err)
+	}
+
+	require.Len(t, find(ctx, t, client, nil), 3)
+
+	require.Equal(t, []string{"nse-0", "nse-1"}, find(ctx, t, client, map[string]string{pagination.PageSizeParam: "2"}))
+	require.Equal(t, []string{"nse-2", "nse-3", "nse-4"}, find(ctx, t, client, map[string]string{
+		pagination.PageSizeParam:  "10",
+		pagination.PageTokenParam: "nse-1",
+	}))
+	require.Empty(t, find(ctx, t, client, map[string]string{pagination.PageTokenParam: "nse-4"}))
+}
+
+func TestPaginationNSEServer_InvalidPageSize(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		queryparams.NewNetworkServiceEndpointRegistryServer(),
+		pagination.NewNetworkServiceEndpointRegistryServer(3),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+	_, err := client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-0"})
+	require.NoError(t, err)
+
+	query := &registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{
+		NetworkServiceLabels: map[string]*registry.NetworkServiceLabels{
+			queryparams.Key: {Labels: map[string]string{pagination.PageSizeParam: "-1"}},
+		},
+	}}
+	_, err = client.Find(ctx, query)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	// The watch ignores the paging
+	query.Watch = true
+	stream, err := client.Find(ctx, query)
+	require.NoError(t, err)
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "nse-0", resp.GetNetworkServiceEndpoint().GetName())
+}
+
+func TestPaginationNSEServer_Filter(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		queryparams.NewNetworkServiceEndpointRegistryServer(),
+		pagination.NewNetworkServiceEndpointRegistryServer(2),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+	for i := 0; i < 6; i++ {
+		_, err := client.Register(ctx, &registry.NetworkServiceEndpoint{Name: fmt.Sprintf("nse-%d", i)})
+		require.NoError(t, err)
+	}
+
+	// The odd NSEs are dropped before the page is cut
+	ctx = pagination.WithFilter(ctx, func(nse *registry.NetworkServiceEndpoint) *registry.NetworkServiceEndpoint {
+		if nse.GetName() == "nse-1" || nse.GetName() == "nse-3" || nse.GetName() == "nse-5" {
+			return nil
+		}
+		return nse
+	})
+	require.Equal(t, []string{"nse-0", "nse-2"}, find(ctx, t, client, map[string]string{pagination.PageSizeParam: "2"}))
+	require.Equal(t, []string{"nse-4"}, find(ctx, t, client, map[string]string{pagination.PageTokenParam: "nse-2"}))
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package peerselect
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+)
+
+type peerSelectNSClient struct {
+	selector *Selector
+}
+
+// NewNetworkServiceRegistryClient creates a new NS client chain element setting the client URL of the healthiest peer
+func NewNetworkServiceRegistryClient(selector *Selector) registry.NetworkServiceRegistryClient {
+	return &peerSelectNSClient{
+		selector: selector,
+	}
+}
+
+func (c *peerSelectNSClient) Register(ctx context.Context, ns *registry.NetworkService, opts ...grpc.CallOption) (*registry.NetworkService, error) {
+	var resp *registry.NetworkService
+	_, err := c.selector.call(ctx, nil, func(ctx context.Context) (err error) {
+		resp, err = next.NetworkServiceRegistryClient(ctx).Register(ctx, ns, opts...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *peerSelectNSClient) Find(ctx context.Context, query *registry.NetworkServiceQuery, opts ...grpc.CallOption) (registry.NetworkServiceRegistry_FindClient, error) {
+	var resp registry.NetworkServiceRegistry_FindClient
+	_, err := c.selector.call(ctx, nil, func(ctx context.Context) (err error) {
+		resp, err = next.NetworkServiceRegistryClient(ctx).Find(ctx, query, opts...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *peerSelectNSClient) Unregister(ctx context.Context, ns *registry.NetworkService, opts ...grpc.CallOption) (*empty.Empty, error) {
+	var resp *empty.Empty
+	_, err := c.selector.call(ctx, nil, func(ctx context.Context) (err error) {
+		resp, err = next.NetworkServiceRegistryClient(ctx).Unregister(ctx, ns, opts...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
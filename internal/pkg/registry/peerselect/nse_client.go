@@ -0,0 +1,90 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package peerselect
+
+import (
+	"context"
+	"net/url"
+	"sync"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+)
+
+type peerSelectNSEClient struct {
+	selector *Selector
+	// peers are the peers the NSEs are registered at, so the refreshes and the unregisters go to the same peer
+	peers sync.Map
+}
+
+// NewNetworkServiceEndpointRegistryClient creates a new NSE client chain element setting the client URL of the
+// healthiest peer. The refreshes and the unregister of an NSE go to the peer it is registered at while it is reachable.
+func NewNetworkServiceEndpointRegistryClient(selector *Selector) registry.NetworkServiceEndpointRegistryClient {
+	return &peerSelectNSEClient{
+		selector: selector,
+	}
+}
+
+func (c *peerSelectNSEClient) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint, opts ...grpc.CallOption) (*registry.NetworkServiceEndpoint, error) {
+	var resp *registry.NetworkServiceEndpoint
+	u, err := c.selector.call(ctx, c.pinned(nse.GetName()), func(ctx context.Context) (err error) {
+		resp, err = next.NetworkServiceEndpointRegistryClient(ctx).Register(ctx, nse, opts...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	c.peers.Store(resp.GetName(), u)
+	return resp, nil
+}
+
+func (c *peerSelectNSEClient) Find(ctx context.Context, query *registry.NetworkServiceEndpointQuery, opts ...grpc.CallOption) (registry.NetworkServiceEndpointRegistry_FindClient, error) {
+	var resp registry.NetworkServiceEndpointRegistry_FindClient
+	_, err := c.selector.call(ctx, nil, func(ctx context.Context) (err error) {
+		resp, err = next.NetworkServiceEndpointRegistryClient(ctx).Find(ctx, query, opts...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *peerSelectNSEClient) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint, opts ...grpc.CallOption) (*empty.Empty, error) {
+	defer c.peers.Delete(nse.GetName())
+
+	var resp *empty.Empty
+	_, err := c.selector.call(ctx, c.pinned(nse.GetName()), func(ctx context.Context) (err error) {
+		resp, err = next.NetworkServiceEndpointRegistryClient(ctx).Unregister(ctx, nse, opts...)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+func (c *peerSelectNSEClient) pinned(name string) *url.URL {
+	if u, ok := c.peers.Load(name); ok {
+		return u.(*url.URL)
+	}
+	return nil
+}
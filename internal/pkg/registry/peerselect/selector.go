@@ -0,0 +1,255 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package peerselect provides the selection of the healthiest of the redundant peer registries, e.g. the proxy
+// registries, by the observed latency and errors, and the registry client chain elements forwarding to the selected
+// peer with the failover to the next one.
+package peerselect
+
+import (
+	"context"
+	"math"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clienturlctx"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/log"
+)
+
+const (
+	// alpha is the weight of the last observation in the EWMAs
+	alpha = 0.3
+	// failureCost is the cost of a failed call in the score of a peer
+	failureCost   = 10 * time.Second
+	peerAttribute = "peer"
+)
+
+type options struct {
+	recoveryHalfLife time.Duration
+}
+
+// Option is an option for the Selector
+type Option func(o *options)
+
+// WithRecoveryHalfLife sets how fast the error rate of a peer with no calls decays, so it is tried again. Default is
+// 30s.
+func WithRecoveryHalfLife(recoveryHalfLife time.Duration) Option {
+	return func(o *options) {
+		o.recoveryHalfLife = recoveryHalfLife
+	}
+}
+
+type peer struct {
+	url *url.URL
+	// latency is the EWMA of the successful call latency, 0 until the first observation
+	latency time.Duration
+	// errorRate is the EWMA of the failures from 0 to 1
+	errorRate    float64
+	lastObserved time.Time
+}
+
+// Selector orders the peers by their health: the expected cost of a call from the EWMAs of the call latency and the
+// error rate. The peers with no observations come first, so all of them get probed. The error rate decays while a
+// peer has no calls, so a failed peer is tried again after a while.
+type Selector struct {
+	options
+	ctx   context.Context
+	mu    sync.Mutex
+	peers []*peer
+
+	selected  metric.Int64Counter
+	failovers metric.Int64Counter
+}
+
+// NewSelector creates a new Selector of the peer URLs, ctx provides the clock
+func NewSelector(ctx context.Context, urls []*url.URL, opts ...Option) *Selector {
+	s := &Selector{
+		options: options{
+			recoveryHalfLife: 30 * time.Second,
+		},
+		ctx: ctx,
+	}
+	for _, opt := range opts {
+		opt(&s.options)
+	}
+	for _, u := range urls {
+		s.peers = append(s.peers, &peer{url: u})
+	}
+
+	meter := otel.Meter("registry-memory")
+	var err error
+	if s.selected, err = meter.Int64Counter("registry.peer_select.selected",
+		metric.WithDescription("Number of the calls forwarded to a peer registry by peer")); err != nil {
+		log.L().Errorf("failed to create peer selected counter: %s", err.Error())
+	}
+	if s.failovers, err = meter.Int64Counter("registry.peer_select.failovers",
+		metric.WithDescription("Number of the calls failed over from a peer registry by peer")); err != nil {
+		log.L().Errorf("failed to create peer failovers counter: %s", err.Error())
+	}
+	if _, err = meter.Float64ObservableGauge("registry.peer_select.latency",
+		metric.WithUnit("s"),
+		metric.WithDescription("EWMA of the call latency of a peer registry by peer"),
+		metric.WithFloat64Callback(s.observeLatency)); err != nil {
+		log.L().Errorf("failed to create peer latency gauge: %s", err.Error())
+	}
+	if _, err = meter.Float64ObservableGauge("registry.peer_select.error_rate",
+		metric.WithDescription("EWMA of the error rate from 0 to 1 of a peer registry by peer"),
+		metric.WithFloat64Callback(s.observeErrorRate)); err != nil {
+		log.L().Errorf("failed to create peer error rate gauge: %s", err.Error())
+	}
+	return s
+}
+
+// Ordered returns the peer URLs from the healthiest to the least healthy one
+func (s *Selector) Ordered() []*url.URL {
+	now := clock.FromContext(s.ctx).Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	peers := append([]*peer(nil), s.peers...)
+	scores := make(map[*peer]float64, len(peers))
+	for _, p := range peers {
+		errorRate := s.errorRate(now, p)
+		scores[p] = (1-errorRate)*float64(p.latency) + errorRate*float64(failureCost)
+	}
+	sort.SliceStable(peers, func(i, j int) bool {
+		return scores[peers[i]] < scores[peers[j]]
+	})
+
+	urls := make([]*url.URL, 0, len(peers))
+	for _, p := range peers {
+		urls = append(urls, p.url)
+	}
+	return urls
+}
+
+// errorRate returns the error rate of the peer decayed by the time since its last observation
+func (s *Selector) errorRate(now time.Time, p *peer) float64 {
+	if s.recoveryHalfLife <= 0 {
+		return p.errorRate
+	}
+	return p.errorRate * math.Pow(0.5, float64(now.Sub(p.lastObserved))/float64(s.recoveryHalfLife))
+}
+
+// Observe records the result of a call to the peer and its latency if it succeeded
+func (s *Selector) Observe(u *url.URL, latency time.Duration, failed bool) {
+	now := clock.FromContext(s.ctx).Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range s.peers {
+		if p.url.String() != u.String() {
+			continue
+		}
+		p.errorRate = (1 - alpha) * s.errorRate(now, p)
+		p.lastObserved = now
+		switch {
+		case failed:
+			p.errorRate += alpha
+		case p.latency == 0:
+			p.latency = latency
+		default:
+			p.latency = time.Duration(alpha*float64(latency) + (1-alpha)*float64(p.latency))
+		}
+		return
+	}
+}
+
+// call calls fn with the client URL of the healthiest peer in the ctx, failing over to the next peer when the peer is
+// unreachable. The pinned peer, if any, is tried first. It returns the peer answering the call.
+func (s *Selector) call(ctx context.Context, pinned *url.URL, fn func(ctx context.Context) error) (*url.URL, error) {
+	urls := s.Ordered()
+	if pinned != nil {
+		for i, u := range urls {
+			if u.String() == pinned.String() {
+				urls = append(append([]*url.URL{u}, urls[:i]...), urls[i+1:]...)
+				break
+			}
+		}
+	}
+
+	var err error
+	for _, u := range urls {
+		start := clock.FromContext(ctx).Now()
+		err = fn(clienturlctx.WithClientURL(ctx, u))
+		latency := clock.FromContext(ctx).Since(start)
+		if !unreachable(ctx, err) {
+			s.Observe(u, latency, false)
+			s.count(ctx, s.selected, u)
+			return u, err
+		}
+		s.Observe(u, latency, true)
+		s.count(ctx, s.failovers, u)
+		log.FromContext(ctx).WithField("peer", u.String()).Warnf("peer registry is unreachable, failing over: %s", err.Error())
+	}
+	return nil, err
+}
+
+// unreachable returns if the err means the peer can't answer the call, rather than the peer rejecting it
+func unreachable(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	st, ok := status.FromError(err)
+	if !ok {
+		return true
+	}
+	switch st.Code() {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *Selector) count(ctx context.Context, counter metric.Int64Counter, u *url.URL) {
+	if counter != nil {
+		counter.Add(ctx, 1, metric.WithAttributes(attribute.String(peerAttribute, u.String())))
+	}
+}
+
+func (s *Selector) observeLatency(_ context.Context, observer metric.Float64Observer) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range s.peers {
+		observer.Observe(p.latency.Seconds(), metric.WithAttributes(attribute.String(peerAttribute, p.url.String())))
+	}
+	return nil
+}
+
+func (s *Selector) observeErrorRate(_ context.Context, observer metric.Float64Observer) error {
+	now := clock.FromContext(s.ctx).Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, p := range s.peers {
+		observer.Observe(s.errorRate(now, p), metric.WithAttributes(attribute.String(peerAttribute, p.url.String())))
+	}
+	return nil
+}
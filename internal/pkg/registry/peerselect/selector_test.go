@@ -0,0 +1,147 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package peerselect_test
+
+import (
+	"context"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/chain"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clienturlctx"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clockmock"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/peerselect"
+)
+
+// peersClient is the fake peer registries answering with the latency of the peer on the mock clock, or with
+// Unavailable if the peer is down
+type peersClient struct {
+	clockMock *clockmock.Mock
+	latency   map[string]time.Duration
+	down      map[string]bool
+	calls     []string
+}
+
+func (c *peersClient) call(ctx context.Context) error {
+	u := clienturlctx.ClientURL(ctx).String()
+	c.calls = append(c.calls, u)
+	if c.down[u] {
+		return status.Error(codes.Unavailable, "peer is down")
+	}
+	c.clockMock.Add(c.latency[u])
+	return nil
+}
+
+func (c *peersClient) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint, _ ...grpc.CallOption) (*registry.NetworkServiceEndpoint, error) {
+	return nse, c.call(ctx)
+}
+
+func (c *peersClient) Find(ctx context.Context, _ *registry.NetworkServiceEndpointQuery, _ ...grpc.CallOption) (registry.NetworkServiceEndpointRegistry_FindClient, error) {
+	return nil, c.call(ctx)
+}
+
+func (c *peersClient) Unregister(ctx context.Context, _ *registry.NetworkServiceEndpoint, _ ...grpc.CallOption) (*empty.Empty, error) {
+	return new(empty.Empty), c.call(ctx)
+}
+
+func (c *peersClient) last() string {
+	return c.calls[len(c.calls)-1]
+}
+
+func testPeers(t *testing.T) (ctx context.Context, clockMock *clockmock.Mock, a, b *url.URL) {
+	clockMock = clockmock.New(context.Background())
+	ctx, cancel := context.WithCancel(clock.WithClock(context.Background(), clockMock))
+	t.Cleanup(cancel)
+
+	a, err := url.Parse("tcp://proxy-a:5002")
+	require.NoError(t, err)
+	b, err = url.Parse("tcp://proxy-b:5002")
+	require.NoError(t, err)
+	return ctx, clockMock, a, b
+}
+
+func TestSelector_PrefersLowerLatency(t *testing.T) {
+	ctx, clockMock, a, b := testPeers(t)
+
+	peers := &peersClient{
+		clockMock: clockMock,
+		latency:   map[string]time.Duration{a.String(): 100 * time.Millisecond, b.String(): 10 * time.Millisecond},
+	}
+	client := chain.NewNetworkServiceEndpointRegistryClient(
+		peerselect.NewNetworkServiceEndpointRegistryClient(peerselect.NewSelector(ctx, []*url.URL{a, b})),
+		peers,
+	)
+
+	// Both peers are probed first
+	for _, expected := range []*url.URL{a, b, b, b} {
+		_, err := client.Find(ctx, &registry.NetworkServiceEndpointQuery{})
+		require.NoError(t, err)
+		require.Equal(t, expected.String(), peers.last())
+	}
+}
+
+func TestSelector_Failover(t *testing.T) {
+	ctx, clockMock, a, b := testPeers(t)
+
+	peers := &peersClient{
+		clockMock: clockMock,
+		latency:   map[string]time.Duration{a.String(): 10 * time.Millisecond, b.String(): 100 * time.Millisecond},
+		down:      map[string]bool{a.String(): true},
+	}
+	selector := peerselect.NewSelector(ctx, []*url.URL{a, b}, peerselect.WithRecoveryHalfLife(10*time.Second))
+	client := chain.NewNetworkServiceEndpointRegistryClient(
+		peerselect.NewNetworkServiceEndpointRegistryClient(selector),
+		peers,
+	)
+
+	nse, err := client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-1@domain"})
+	require.NoError(t, err)
+	require.Equal(t, []string{a.String(), b.String()}, peers.calls)
+
+	// The failed peer is not tried while its error rate is high
+	require.Equal(t, []*url.URL{b, a}, selector.Ordered())
+
+	// The refreshes and the unregister go to the peer the NSE is registered at even if the other one recovers
+	peers.down[a.String()] = false
+	clockMock.Add(time.Minute)
+	require.Equal(t, []*url.URL{a, b}, selector.Ordered())
+
+	_, err = client.Register(ctx, nse)
+	require.NoError(t, err)
+	require.Equal(t, b.String(), peers.last())
+
+	_, err = client.Unregister(ctx, nse)
+	require.NoError(t, err)
+	require.Equal(t, b.String(), peers.last())
+
+	// All the peers are down
+	peers.down[a.String()] = true
+	peers.down[b.String()] = true
+	_, err = client.Find(ctx, &registry.NetworkServiceEndpointQuery{})
+	require.Equal(t, codes.Unavailable, status.Code(err))
+}
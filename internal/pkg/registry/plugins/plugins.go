@@ -0,0 +1,161 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package plugins provides the compile-time registry of the custom chain elements and the ordering of the optional
+// chain elements. A fork adds its own elements, e.g. a custom authorization or an audit, with a file registering them
+// on init, without changing main.go:
+//
+//	func init() {
+//		plugins.Register("audit", func(ctx context.Context) (plugins.Element, error) {
+//			return plugins.Element{NSE: audit.NewNetworkServiceEndpointRegistryServer()}, nil
+//		})
+//	}
+//
+// The file may have a build tag to keep the element out of the default build. The registered elements follow the
+// built-in optional ones unless ordered otherwise with the chain order.
+package plugins
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/null"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/elementerrors"
+)
+
+// Element is a named pair of the NS and the NSE chain elements, either of them may be nil
+type Element struct {
+	Name string
+	NS   registry.NetworkServiceRegistryServer
+	NSE  registry.NetworkServiceEndpointRegistryServer
+}
+
+// Factory creates the chain element with the server ctx. The name of the element is set by Register.
+type Factory func(ctx context.Context) (Element, error)
+
+type plugin struct {
+	name    string
+	factory Factory
+}
+
+var (
+	mu      sync.Mutex
+	plugins []plugin
+)
+
+// Register registers the chain element factory with the name. It panics if the name is empty or already registered.
+func Register(name string, factory Factory) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if name == "" {
+		panic("plugin name cannot be empty")
+	}
+	for _, p := range plugins {
+		if p.name == name {
+			panic("plugin " + name + " is already registered")
+		}
+	}
+	plugins = append(plugins, plugin{name: name, factory: factory})
+}
+
+// Names returns the names of the registered elements in the registration order
+func Names() []string {
+	mu.Lock()
+	defer mu.Unlock()
+
+	var names []string
+	for _, p := range plugins {
+		names = append(names, p.name)
+	}
+	return names
+}
+
+// Elements creates the registered elements in the registration order
+func Elements(ctx context.Context) ([]Element, error) {
+	mu.Lock()
+	registered := append([]plugin(nil), plugins...)
+	mu.Unlock()
+
+	var elements []Element
+	for _, p := range registered {
+		element, err := p.factory(ctx)
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to create chain element %s", p.name)
+		}
+		element.Name = p.name
+		elements = append(elements, element)
+	}
+	return elements, nil
+}
+
+// Order returns the elements with the ones named in order first, in that order, followed by the rest in their order.
+// It fails if order names an unknown element or names one twice.
+func Order(elements []Element, order []string) ([]Element, error) {
+	byName := make(map[string]Element, len(elements))
+	for _, element := range elements {
+		byName[element.Name] = element
+	}
+
+	result := make([]Element, 0, len(elements))
+	for _, name := range order {
+		element, ok := byName[name]
+		if !ok {
+			return nil, errors.Errorf("unknown or duplicate chain element %s", name)
+		}
+		delete(byName, name)
+		result = append(result, element)
+	}
+	for _, element := range elements {
+		if _, ok := byName[element.Name]; ok {
+			result = append(result, element)
+		}
+	}
+	return result, nil
+}
+
+// NSServers returns the NS chain elements, the missing ones replaced by null servers. The errors are tagged with the
+// element names.
+func NSServers(elements []Element) []registry.NetworkServiceRegistryServer {
+	servers := make([]registry.NetworkServiceRegistryServer, 0, len(elements))
+	for _, element := range elements {
+		var server registry.NetworkServiceRegistryServer = null.NewNetworkServiceRegistryServer()
+		if element.NS != nil {
+			server = element.NS
+		}
+		servers = append(servers, elementerrors.NamedNetworkServiceRegistryServer(element.Name, server))
+	}
+	return servers
+}
+
+// NSEServers returns the NSE chain elements, the missing ones replaced by null servers. The errors are tagged with the
+// element names.
+func NSEServers(elements []Element) []registry.NetworkServiceEndpointRegistryServer {
+	servers := make([]registry.NetworkServiceEndpointRegistryServer, 0, len(elements))
+	for _, element := range elements {
+		var server registry.NetworkServiceEndpointRegistryServer = null.NewNetworkServiceEndpointRegistryServer()
+		if element.NSE != nil {
+			server = element.NSE
+		}
+		servers = append(servers, elementerrors.NamedNetworkServiceEndpointRegistryServer(element.Name, server))
+	}
+	return servers
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package plugins_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/elementerrors"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/plugins"
+)
+
+type rejectNSEServer struct {
+	registry.NetworkServiceEndpointRegistryServer
+}
+
+func (s *rejectNSEServer) Register(context.Context, *registry.NetworkServiceEndpoint) (*registry.NetworkServiceEndpoint, error) {
+	return nil, status.Error(codes.PermissionDenied, "rejected")
+}
+
+func names(elements []plugins.Element) []string {
+	var result []string
+	for _, element := range elements {
+		result = append(result, element.Name)
+	}
+	return result
+}
+
+func TestOrder(t *testing.T) {
+	elements := []plugins.Element{{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "d"}}
+
+	ordered, err := plugins.Order(elements, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b", "c", "d"}, names(ordered))
+
+	ordered, err = plugins.Order(elements, []string{"c", "a"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"c", "a", "b", "d"}, names(ordered))
+
+	_, err = plugins.Order(elements, []string{"e"})
+	require.Error(t, err)
+	_, err = plugins.Order(elements, []string{"a", "a"})
+	require.Error(t, err)
+}
+
+func TestRegister(t *testing.T) {
+	plugins.Register("reject", func(ctx context.Context) (plugins.Element, error) {
+		return plugins.Element{NSE: &rejectNSEServer{NetworkServiceEndpointRegistryServer: next.NewNetworkServiceEndpointRegistryServer()}}, nil
+	})
+	require.Panics(t, func() {
+		plugins.Register("reject", nil)
+	})
+	require.Contains(t, plugins.Names(), "reject")
+
+	elements, err := plugins.Elements(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, []string{"reject"}, names(elements))
+
+	nsChain := adapters.NetworkServiceServerToClient(elementerrors.NewNetworkServiceRegistryServer(plugins.NSServers(elements)...))
+	_, err = nsChain.Register(context.Background(), &registry.NetworkService{Name: "ns"})
+	require.NoError(t, err)
+
+	nseChain := adapters.NetworkServiceEndpointServerToClient(elementerrors.NewNetworkServiceEndpointRegistryServer(plugins.NSEServers(elements)...))
+	_, err = nseChain.Register(context.Background(), &registry.NetworkServiceEndpoint{Name: "nse"})
+	require.Equal(t, codes.PermissionDenied, status.Code(errors.Cause(err)))
+	element, ok := elementerrors.Element(err)
+	require.True(t, ok)
+	require.Equal(t, "reject", element)
+}
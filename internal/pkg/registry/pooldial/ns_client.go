@@ -0,0 +1,76 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pooldial
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/connpool"
+)
+
+type poolDialNSClient struct {
+	pool *connpool.Pool
+}
+
+// NewNetworkServiceRegistryClient creates a new NS client chain element getting the connections from the pool
+func NewNetworkServiceRegistryClient(pool *connpool.Pool) registry.NetworkServiceRegistryClient {
+	return &poolDialNSClient{
+		pool: pool,
+	}
+}
+
+func (c *poolDialNSClient) Register(ctx context.Context, ns *registry.NetworkService, opts ...grpc.CallOption) (*registry.NetworkService, error) {
+	release, err := get(ctx, c.pool)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return next.NetworkServiceRegistryClient(ctx).Register(ctx, ns, opts...)
+}
+
+func (c *poolDialNSClient) Find(ctx context.Context, query *registry.NetworkServiceQuery, opts ...grpc.CallOption) (registry.NetworkServiceRegistry_FindClient, error) {
+	release, err := get(ctx, c.pool)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := next.NetworkServiceRegistryClient(ctx).Find(ctx, query, opts...)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	go func() {
+		<-resp.Context().Done()
+		release()
+	}()
+	return resp, nil
+}
+
+func (c *poolDialNSClient) Unregister(ctx context.Context, ns *registry.NetworkService, opts ...grpc.CallOption) (*empty.Empty, error) {
+	release, err := get(ctx, c.pool)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return next.NetworkServiceRegistryClient(ctx).Unregister(ctx, ns, opts...)
+}
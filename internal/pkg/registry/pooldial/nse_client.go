@@ -0,0 +1,100 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package pooldial provides registry client chain elements getting the connection to the client URL from the
+// connpool.Pool and putting it into the ctx using clientconn.Store(..), where it is retrievable by the connect chain
+// element. It replaces the sdk dial chain element dialing a connection per NSE and per Find.
+package pooldial
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/clientconn"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clienturlctx"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/grpcutils"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/connpool"
+)
+
+type poolDialNSEClient struct {
+	pool *connpool.Pool
+}
+
+// NewNetworkServiceEndpointRegistryClient creates a new NSE client chain element getting the connections from the pool
+func NewNetworkServiceEndpointRegistryClient(pool *connpool.Pool) registry.NetworkServiceEndpointRegistryClient {
+	return &poolDialNSEClient{
+		pool: pool,
+	}
+}
+
+func (c *poolDialNSEClient) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint, opts ...grpc.CallOption) (*registry.NetworkServiceEndpoint, error) {
+	release, err := get(ctx, c.pool)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return next.NetworkServiceEndpointRegistryClient(ctx).Register(ctx, nse, opts...)
+}
+
+func (c *poolDialNSEClient) Find(ctx context.Context, query *registry.NetworkServiceEndpointQuery, opts ...grpc.CallOption) (registry.NetworkServiceEndpointRegistry_FindClient, error) {
+	release, err := get(ctx, c.pool)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := next.NetworkServiceEndpointRegistryClient(ctx).Find(ctx, query, opts...)
+	if err != nil {
+		release()
+		return nil, err
+	}
+	go func() {
+		<-resp.Context().Done()
+		release()
+	}()
+	return resp, nil
+}
+
+func (c *poolDialNSEClient) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint, opts ...grpc.CallOption) (*empty.Empty, error) {
+	release, err := get(ctx, c.pool)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	return next.NetworkServiceEndpointRegistryClient(ctx).Unregister(ctx, nse, opts...)
+}
+
+// get stores the pooled connection to the client URL in the ctx for the time of the call, the returned function
+// deletes it and releases the connection. It does nothing if there is no client URL.
+func get(ctx context.Context, pool *connpool.Pool) (func(), error) {
+	clientURL := clienturlctx.ClientURL(ctx)
+	if clientURL == nil {
+		return func() {}, nil
+	}
+	cc, release, err := pool.Get(ctx, grpcutils.URLToTarget(clientURL))
+	if err != nil {
+		return nil, err
+	}
+	clientconn.Store(ctx, cc)
+	return func() {
+		clientconn.Delete(ctx)
+		release()
+	}, nil
+}
@@ -0,0 +1,44 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package queryparams provides NSE registry server chain element extracting the registry query parameters from the
+// NSE Find query. The parameters are carried as the labels of the reserved Key network service in the query
+// NetworkServiceLabels, so they can be set with the existing registry API.
+package queryparams
+
+import (
+	"context"
+)
+
+// Key is the reserved network service name in the query NetworkServiceLabels carrying the query parameters
+const Key = "registry-memory.query"
+
+type contextKeyType string
+
+const contextKey contextKeyType = "queryparams"
+
+// WithParams stores the query parameters into ctx
+func WithParams(ctx context.Context, params map[string]string) context.Context {
+	return context.WithValue(ctx, contextKey, params)
+}
+
+// FromContext returns the query parameters stored in ctx
+func FromContext(ctx context.Context) map[string]string {
+	if params, ok := ctx.Value(contextKey).(map[string]string); ok {
+		return params
+	}
+	return nil
+}
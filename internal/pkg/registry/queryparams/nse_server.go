@@ -0,0 +1,59 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package queryparams
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/streamcontext"
+)
+
+type queryParamsNSEServer struct{}
+
+// NewNetworkServiceEndpointRegistryServer creates a new NSE server chain element moving the query parameters from the
+// Find query into the stream context. It should be the first element of the chain, so the parameters never reach the
+// store matching.
+func NewNetworkServiceEndpointRegistryServer() registry.NetworkServiceEndpointRegistryServer {
+	return new(queryParamsNSEServer)
+}
+
+func (s *queryParamsNSEServer) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*registry.NetworkServiceEndpoint, error) {
+	return next.NetworkServiceEndpointRegistryServer(ctx).Register(ctx, nse)
+}
+
+func (s *queryParamsNSEServer) Find(query *registry.NetworkServiceEndpointQuery, server registry.NetworkServiceEndpointRegistry_FindServer) error {
+	params, ok := query.GetNetworkServiceEndpoint().GetNetworkServiceLabels()[Key]
+	if !ok {
+		return next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, server)
+	}
+
+	query = proto.Clone(query).(*registry.NetworkServiceEndpointQuery)
+	delete(query.GetNetworkServiceEndpoint().GetNetworkServiceLabels(), Key)
+
+	ctx := WithParams(server.Context(), params.GetLabels())
+	return next.NetworkServiceEndpointRegistryServer(ctx).Find(query, streamcontext.NetworkServiceEndpointRegistryFindServer(ctx, server))
+}
+
+func (s *queryParamsNSEServer) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*empty.Empty, error) {
+	return next.NetworkServiceEndpointRegistryServer(ctx).Unregister(ctx, nse)
+}
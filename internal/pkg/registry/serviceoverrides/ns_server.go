@@ -0,0 +1,54 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serviceoverrides
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/pkg/errors"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+)
+
+type serviceOverridesNSServer struct {
+	overrides Overrides
+}
+
+// NewNetworkServiceRegistryServer creates a new NS server chain element rejecting Unregister of the protected NSs
+func NewNetworkServiceRegistryServer(overrides Overrides) registry.NetworkServiceRegistryServer {
+	return &serviceOverridesNSServer{
+		overrides: overrides,
+	}
+}
+
+func (s *serviceOverridesNSServer) Register(ctx context.Context, ns *registry.NetworkService) (*registry.NetworkService, error) {
+	return next.NetworkServiceRegistryServer(ctx).Register(ctx, ns)
+}
+
+func (s *serviceOverridesNSServer) Find(query *registry.NetworkServiceQuery, server registry.NetworkServiceRegistry_FindServer) error {
+	return next.NetworkServiceRegistryServer(server.Context()).Find(query, server)
+}
+
+func (s *serviceOverridesNSServer) Unregister(ctx context.Context, ns *registry.NetworkService) (*empty.Empty, error) {
+	if override, ok := s.overrides[ns.GetName()]; ok && override.Protected {
+		return nil, errors.Errorf("network service %s is protected", ns.GetName())
+	}
+	return next.NetworkServiceRegistryServer(ctx).Unregister(ctx, ns)
+}
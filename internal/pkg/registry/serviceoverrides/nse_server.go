@@ -0,0 +1,137 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serviceoverrides
+
+import (
+	"context"
+	"sort"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+)
+
+type serviceOverridesNSEServer struct {
+	overrides Overrides
+}
+
+// NewNetworkServiceEndpointRegistryServer creates a new NSE server chain element applying default expiration,
+// endpoints limit and ordering overrides of the NSs
+func NewNetworkServiceEndpointRegistryServer(overrides Overrides) registry.NetworkServiceEndpointRegistryServer {
+	return &serviceOverridesNSEServer{
+		overrides: overrides,
+	}
+}
+
+func (s *serviceOverridesNSEServer) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*registry.NetworkServiceEndpoint, error) {
+	for _, name := range nse.GetNetworkServiceNames() {
+		override, ok := s.overrides[name]
+		if !ok {
+			continue
+		}
+		if nse.GetExpirationTime() == nil && override.DefaultExpiration > 0 {
+			nse.ExpirationTime = timestamppb.New(clock.FromContext(ctx).Now().Add(override.DefaultExpiration))
+		}
+		if override.MaxEndpoints <= 0 {
+			continue
+		}
+		nses, err := s.find(ctx, &registry.NetworkServiceEndpoint{NetworkServiceNames: []string{name}})
+		if err != nil {
+			return nil, err
+		}
+		var count int
+		for _, registered := range nses {
+			if registered.GetName() != nse.GetName() {
+				count++
+			}
+		}
+		if count >= override.MaxEndpoints {
+			return nil, errors.Errorf("network service %s already has maximum number of endpoints: %d", name, override.MaxEndpoints)
+		}
+	}
+	return next.NetworkServiceEndpointRegistryServer(ctx).Register(ctx, nse)
+}
+
+func (s *serviceOverridesNSEServer) Find(query *registry.NetworkServiceEndpointQuery, server registry.NetworkServiceEndpointRegistry_FindServer) error {
+	names := query.GetNetworkServiceEndpoint().GetNetworkServiceNames()
+	if query.GetWatch() || len(names) != 1 || s.overrides[names[0]] == nil || s.overrides[names[0]].Ordering == OrderingNone {
+		return next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, server)
+	}
+
+	collector := &nseCollector{ctx: server.Context()}
+	if err := next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, collector); err != nil {
+		return err
+	}
+
+	responses := collector.responses
+	switch s.overrides[names[0]].Ordering {
+	case OrderingName:
+		sort.SliceStable(responses, func(i, j int) bool {
+			return responses[i].GetNetworkServiceEndpoint().GetName() < responses[j].GetNetworkServiceEndpoint().GetName()
+		})
+	case OrderingExpiration:
+		sort.SliceStable(responses, func(i, j int) bool {
+			return responses[i].GetNetworkServiceEndpoint().GetExpirationTime().AsTime().After(
+				responses[j].GetNetworkServiceEndpoint().GetExpirationTime().AsTime())
+		})
+	}
+
+	for _, resp := range responses {
+		if err := server.Send(resp); err != nil {
+			return errors.Wrapf(err, "NetworkServiceEndpointRegistry find server failed to send a response %s", resp.String())
+		}
+	}
+	return nil
+}
+
+func (s *serviceOverridesNSEServer) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*empty.Empty, error) {
+	return next.NetworkServiceEndpointRegistryServer(ctx).Unregister(ctx, nse)
+}
+
+func (s *serviceOverridesNSEServer) find(ctx context.Context, nse *registry.NetworkServiceEndpoint) ([]*registry.NetworkServiceEndpoint, error) {
+	collector := &nseCollector{ctx: ctx}
+	query := &registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: nse}
+	if err := next.NetworkServiceEndpointRegistryServer(ctx).Find(query, collector); err != nil {
+		return nil, err
+	}
+	var nses []*registry.NetworkServiceEndpoint
+	for _, resp := range collector.responses {
+		nses = append(nses, resp.GetNetworkServiceEndpoint())
+	}
+	return nses, nil
+}
+
+type nseCollector struct {
+	grpc.ServerStream
+	ctx       context.Context
+	responses []*registry.NetworkServiceEndpointResponse
+}
+
+func (c *nseCollector) Send(resp *registry.NetworkServiceEndpointResponse) error {
+	c.responses = append(c.responses, resp)
+	return nil
+}
+
+func (c *nseCollector) Context() context.Context {
+	return c.ctx
+}
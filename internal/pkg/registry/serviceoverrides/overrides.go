@@ -0,0 +1,99 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package serviceoverrides provides registry server chain elements applying per NS overrides of the registry settings
+package serviceoverrides
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Ordering is an ordering policy of the Find results
+type Ordering string
+
+const (
+	// OrderingNone keeps the store order
+	OrderingNone Ordering = ""
+	// OrderingName sorts NSEs by name
+	OrderingName Ordering = "name"
+	// OrderingExpiration sorts NSEs by expiration time, the latest first
+	OrderingExpiration Ordering = "expiration"
+)
+
+// Override is a set of settings overriding the registry defaults for a NS
+type Override struct {
+	// DefaultExpiration is the expiration for the NSEs registered without one
+	DefaultExpiration time.Duration
+	// MaxEndpoints is the maximum number of the NSEs registered for the NS, 0 means no limit
+	MaxEndpoints int
+	// Ordering is the ordering policy of the NSE Find results for the NS
+	Ordering Ordering
+	// Protected NS cannot be unregistered
+	Protected bool
+}
+
+// Overrides is a map of NS names to their overrides
+type Overrides map[string]*Override
+
+type fileOverride struct {
+	DefaultExpiration string   `json:"default_expiration"`
+	MaxEndpoints      int      `json:"max_endpoints"`
+	Ordering          Ordering `json:"ordering"`
+	Protected         bool     `json:"protected"`
+}
+
+// LoadFile reads overrides from the JSON file:
+//
+//	{
+//	  "<ns name>": {"default_expiration": "30s", "max_endpoints": 10, "ordering": "name", "protected": true}
+//	}
+func LoadFile(filePath string) (Overrides, error) {
+	// #nosec G304 - the file path comes from the configuration
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to read service overrides %s", filePath)
+	}
+
+	var fileOverrides map[string]*fileOverride
+	if err = json.Unmarshal(data, &fileOverrides); err != nil {
+		return nil, errors.Wrapf(err, "failed to parse service overrides %s", filePath)
+	}
+
+	overrides := make(Overrides, len(fileOverrides))
+	for name, o := range fileOverrides {
+		override := &Override{
+			MaxEndpoints: o.MaxEndpoints,
+			Ordering:     o.Ordering,
+			Protected:    o.Protected,
+		}
+		if o.DefaultExpiration != "" {
+			if override.DefaultExpiration, err = time.ParseDuration(o.DefaultExpiration); err != nil {
+				return nil, errors.Wrapf(err, "invalid default_expiration for %s", name)
+			}
+		}
+		switch o.Ordering {
+		case OrderingNone, OrderingName, OrderingExpiration:
+		default:
+			return nil, errors.Errorf("invalid ordering for %s: %s", name, o.Ordering)
+		}
+		overrides[name] = override
+	}
+	return overrides, nil
+}
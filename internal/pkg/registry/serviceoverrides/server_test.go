@@ -0,0 +1,94 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package serviceoverrides_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/chain"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/serviceoverrides"
+)
+
+func TestServiceOverridesNSEServer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	overrides := serviceoverrides.Overrides{
+		"ns-1": {DefaultExpiration: time.Hour, MaxEndpoints: 2, Ordering: serviceoverrides.OrderingName},
+	}
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		serviceoverrides.NewNetworkServiceEndpointRegistryServer(overrides),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+
+	resp, err := client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-b", NetworkServiceNames: []string{"ns-1"}})
+	require.NoError(t, err)
+	require.True(t, resp.GetExpirationTime().AsTime().After(time.Now().Add(time.Hour-time.Minute)))
+
+	_, err = client.Register(ctx, &registry.NetworkServiceEndpoint{
+		Name:                "nse-a",
+		NetworkServiceNames: []string{"ns-1"},
+		ExpirationTime:      timestamppb.New(time.Now().Add(time.Minute)),
+	})
+	require.NoError(t, err)
+
+	_, err = client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-c", NetworkServiceNames: []string{"ns-1"}})
+	require.Error(t, err)
+
+	// Refresh of the already registered NSE is not limited
+	_, err = client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-a", NetworkServiceNames: []string{"ns-1"}})
+	require.NoError(t, err)
+
+	stream, err := client.Find(ctx, &registry.NetworkServiceEndpointQuery{
+		NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{NetworkServiceNames: []string{"ns-1"}},
+	})
+	require.NoError(t, err)
+	nses := registry.ReadNetworkServiceEndpointList(stream)
+	require.Len(t, nses, 2)
+	require.Equal(t, "nse-a", nses[0].GetName())
+	require.Equal(t, "nse-b", nses[1].GetName())
+}
+
+func TestServiceOverridesNSServer_Protected(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := adapters.NetworkServiceServerToClient(chain.NewNetworkServiceRegistryServer(
+		serviceoverrides.NewNetworkServiceRegistryServer(serviceoverrides.Overrides{"ns-1": {Protected: true}}),
+		memory.NewNetworkServiceRegistryServer(),
+	))
+
+	_, err := client.Register(ctx, &registry.NetworkService{Name: "ns-1"})
+	require.NoError(t, err)
+	_, err = client.Register(ctx, &registry.NetworkService{Name: "ns-2"})
+	require.NoError(t, err)
+
+	_, err = client.Unregister(ctx, &registry.NetworkService{Name: "ns-1"})
+	require.Error(t, err)
+	_, err = client.Unregister(ctx, &registry.NetworkService{Name: "ns-2"})
+	require.NoError(t, err)
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swap
+
+import (
+	"context"
+	"sync"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+)
+
+// NetworkServiceRegistryClient is a registry.NetworkServiceRegistryClient with replaceable underlying client
+type NetworkServiceRegistryClient struct {
+	mu     sync.RWMutex
+	client registry.NetworkServiceRegistryClient
+}
+
+// NewNetworkServiceRegistryClient creates a new swap NS client delegating to client
+func NewNetworkServiceRegistryClient(client registry.NetworkServiceRegistryClient) *NetworkServiceRegistryClient {
+	return &NetworkServiceRegistryClient{
+		client: client,
+	}
+}
+
+// Store replaces the underlying client. Calls already in progress are completed by the previous one.
+func (c *NetworkServiceRegistryClient) Store(client registry.NetworkServiceRegistryClient) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.client = client
+}
+
+func (c *NetworkServiceRegistryClient) load() registry.NetworkServiceRegistryClient {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.client
+}
+
+// Register calls Register of the underlying client
+func (c *NetworkServiceRegistryClient) Register(ctx context.Context, ns *registry.NetworkService, opts ...grpc.CallOption) (*registry.NetworkService, error) {
+	return c.load().Register(ctx, ns, opts...)
+}
+
+// Find calls Find of the underlying client
+func (c *NetworkServiceRegistryClient) Find(ctx context.Context, query *registry.NetworkServiceQuery, opts ...grpc.CallOption) (registry.NetworkServiceRegistry_FindClient, error) {
+	return c.load().Find(ctx, query, opts...)
+}
+
+// Unregister calls Unregister of the underlying client
+func (c *NetworkServiceRegistryClient) Unregister(ctx context.Context, ns *registry.NetworkService, opts ...grpc.CallOption) (*empty.Empty, error) {
+	return c.load().Unregister(ctx, ns, opts...)
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swap
+
+import (
+	"context"
+	"sync"
+
+	"github.com/golang/protobuf/ptypes/empty"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+)
+
+// NetworkServiceRegistryServer is a registry.NetworkServiceRegistryServer with replaceable underlying server
+type NetworkServiceRegistryServer struct {
+	mu     sync.RWMutex
+	server registry.NetworkServiceRegistryServer
+}
+
+// NewNetworkServiceRegistryServer creates a new swap NS server delegating to server
+func NewNetworkServiceRegistryServer(server registry.NetworkServiceRegistryServer) *NetworkServiceRegistryServer {
+	return &NetworkServiceRegistryServer{
+		server: server,
+	}
+}
+
+// Store replaces the underlying server. Calls already in progress are completed by the previous one.
+func (s *NetworkServiceRegistryServer) Store(server registry.NetworkServiceRegistryServer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.server = server
+}
+
+func (s *NetworkServiceRegistryServer) load() registry.NetworkServiceRegistryServer {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.server
+}
+
+// Register calls Register of the underlying server
+func (s *NetworkServiceRegistryServer) Register(ctx context.Context, ns *registry.NetworkService) (*registry.NetworkService, error) {
+	return s.load().Register(ctx, ns)
+}
+
+// Find calls Find of the underlying server
+func (s *NetworkServiceRegistryServer) Find(query *registry.NetworkServiceQuery, server registry.NetworkServiceRegistry_FindServer) error {
+	return s.load().Find(query, server)
+}
+
+// Unregister calls Unregister of the underlying server
+func (s *NetworkServiceRegistryServer) Unregister(ctx context.Context, ns *registry.NetworkService) (*empty.Empty, error) {
+	return s.load().Unregister(ctx, ns)
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swap
+
+import (
+	"context"
+	"sync"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+)
+
+// NetworkServiceEndpointRegistryClient is a registry.NetworkServiceEndpointRegistryClient with replaceable
+// underlying client
+type NetworkServiceEndpointRegistryClient struct {
+	mu     sync.RWMutex
+	client registry.NetworkServiceEndpointRegistryClient
+}
+
+// NewNetworkServiceEndpointRegistryClient creates a new swap NSE client delegating to client
+func NewNetworkServiceEndpointRegistryClient(client registry.NetworkServiceEndpointRegistryClient) *NetworkServiceEndpointRegistryClient {
+	return &NetworkServiceEndpointRegistryClient{
+		client: client,
+	}
+}
+
+// Store replaces the underlying client. Calls already in progress are completed by the previous one.
+func (c *NetworkServiceEndpointRegistryClient) Store(client registry.NetworkServiceEndpointRegistryClient) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.client = client
+}
+
+func (c *NetworkServiceEndpointRegistryClient) load() registry.NetworkServiceEndpointRegistryClient {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.client
+}
+
+// Register calls Register of the underlying client
+func (c *NetworkServiceEndpointRegistryClient) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint, opts ...grpc.CallOption) (*registry.NetworkServiceEndpoint, error) {
+	return c.load().Register(ctx, nse, opts...)
+}
+
+// Find calls Find of the underlying client
+func (c *NetworkServiceEndpointRegistryClient) Find(ctx context.Context, query *registry.NetworkServiceEndpointQuery, opts ...grpc.CallOption) (registry.NetworkServiceEndpointRegistry_FindClient, error) {
+	return c.load().Find(ctx, query, opts...)
+}
+
+// Unregister calls Unregister of the underlying client
+func (c *NetworkServiceEndpointRegistryClient) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint, opts ...grpc.CallOption) (*empty.Empty, error) {
+	return c.load().Unregister(ctx, nse, opts...)
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package swap provides registry chain elements delegating to the underlying chain element that can be replaced at
+// runtime, e.g. on configuration reload
+package swap
+
+import (
+	"context"
+	"sync"
+
+	"github.com/golang/protobuf/ptypes/empty"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+)
+
+// NetworkServiceEndpointRegistryServer is a registry.NetworkServiceEndpointRegistryServer with replaceable
+// underlying server
+type NetworkServiceEndpointRegistryServer struct {
+	mu     sync.RWMutex
+	server registry.NetworkServiceEndpointRegistryServer
+}
+
+// NewNetworkServiceEndpointRegistryServer creates a new swap NSE server delegating to server
+func NewNetworkServiceEndpointRegistryServer(server registry.NetworkServiceEndpointRegistryServer) *NetworkServiceEndpointRegistryServer {
+	return &NetworkServiceEndpointRegistryServer{
+		server: server,
+	}
+}
+
+// Store replaces the underlying server. Calls already in progress are completed by the previous one.
+func (s *NetworkServiceEndpointRegistryServer) Store(server registry.NetworkServiceEndpointRegistryServer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.server = server
+}
+
+func (s *NetworkServiceEndpointRegistryServer) load() registry.NetworkServiceEndpointRegistryServer {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.server
+}
+
+// Register calls Register of the underlying server
+func (s *NetworkServiceEndpointRegistryServer) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*registry.NetworkServiceEndpoint, error) {
+	return s.load().Register(ctx, nse)
+}
+
+// Find calls Find of the underlying server
+func (s *NetworkServiceEndpointRegistryServer) Find(query *registry.NetworkServiceEndpointQuery, server registry.NetworkServiceEndpointRegistry_FindServer) error {
+	return s.load().Find(query, server)
+}
+
+// Unregister calls Unregister of the underlying server
+func (s *NetworkServiceEndpointRegistryServer) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*empty.Empty, error) {
+	return s.load().Unregister(ctx, nse)
+}
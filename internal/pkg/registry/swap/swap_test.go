@@ -0,0 +1,89 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package swap_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/chain"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clockmock"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/defaultexpiration"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/swap"
+)
+
+func TestSwapNSEServer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	clockMock := clockmock.New(ctx)
+	ctx = clock.WithClock(ctx, clockMock)
+
+	element := swap.NewNetworkServiceEndpointRegistryServer(defaultexpiration.NewNetworkServiceEndpointRegistryServer(time.Minute))
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		element,
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+
+	resp, err := client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-1"})
+	require.NoError(t, err)
+	require.True(t, clockMock.Now().Add(time.Minute).Equal(resp.GetExpirationTime().AsTime()))
+
+	// The stored element serves the next calls
+	element.Store(defaultexpiration.NewNetworkServiceEndpointRegistryServer(time.Hour))
+	resp, err = client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-2"})
+	require.NoError(t, err)
+	require.True(t, clockMock.Now().Add(time.Hour).Equal(resp.GetExpirationTime().AsTime()))
+}
+
+func TestSwapNSClient(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	first := adapters.NetworkServiceServerToClient(memory.NewNetworkServiceRegistryServer())
+	second := adapters.NetworkServiceServerToClient(memory.NewNetworkServiceRegistryServer())
+
+	client := swap.NewNetworkServiceRegistryClient(first)
+	_, err := client.Register(ctx, &registry.NetworkService{Name: "ns-1"})
+	require.NoError(t, err)
+
+	client.Store(second)
+	_, err = client.Register(ctx, &registry.NetworkService{Name: "ns-2"})
+	require.NoError(t, err)
+
+	find := func(c registry.NetworkServiceRegistryClient) []string {
+		stream, findErr := c.Find(ctx, &registry.NetworkServiceQuery{NetworkService: new(registry.NetworkService)})
+		require.NoError(t, findErr)
+		var names []string
+		for _, ns := range registry.ReadNetworkServiceList(stream) {
+			names = append(names, ns.GetName())
+		}
+		return names
+	}
+	require.Equal(t, []string{"ns-1"}, find(first))
+	require.Equal(t, []string{"ns-2"}, find(second))
+	require.Equal(t, []string{"ns-2"}, find(client))
+}
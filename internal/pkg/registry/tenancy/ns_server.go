@@ -0,0 +1,138 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tenancy
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/inprocess"
+)
+
+type tenancyNSServer struct {
+	*tenants
+}
+
+// NewNetworkServiceRegistryServer creates a new NS server chain element allowing the callers to find and manage
+// only the NSs registered from their trust domain
+func NewNetworkServiceRegistryServer(opts ...Option) registry.NetworkServiceRegistryServer {
+	return &tenancyNSServer{
+		tenants: newTenants(opts...),
+	}
+}
+
+func (s *tenancyNSServer) Register(ctx context.Context, ns *registry.NetworkService) (*registry.NetworkService, error) {
+	rollback, err := s.claim(ctx, ns.GetName(), s.exists(ctx, ns.GetName()))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := next.NetworkServiceRegistryServer(ctx).Register(ctx, ns)
+	if err != nil {
+		rollback()
+		return nil, err
+	}
+	if resp.GetName() != ns.GetName() {
+		// The name is generated by the store
+		rollback()
+	}
+	s.own(ctx, resp.GetName(), nil)
+	return resp, nil
+}
+
+func (s *tenancyNSServer) Find(query *registry.NetworkServiceQuery, server registry.NetworkServiceRegistry_FindServer) error {
+	if _, admin := s.caller(server.Context()); admin {
+		return next.NetworkServiceRegistryServer(server.Context()).Find(query, server)
+	}
+	return next.NetworkServiceRegistryServer(server.Context()).Find(query, &tenantNSFindServer{
+		NetworkServiceRegistry_FindServer: server,
+		tenants:                           s.tenants,
+		sent:                              make(map[string]struct{}),
+	})
+}
+
+func (s *tenancyNSServer) Unregister(ctx context.Context, ns *registry.NetworkService) (*empty.Empty, error) {
+	if err := s.check(ctx, ns.GetName(), s.exists(ctx, ns.GetName())); err != nil {
+		return nil, err
+	}
+	resp, err := next.NetworkServiceRegistryServer(ctx).Unregister(ctx, ns)
+	if err != nil {
+		return nil, err
+	}
+	s.forget(ns.GetName())
+	return resp, nil
+}
+
+func (s *tenancyNSServer) exists(ctx context.Context, name string) func() (bool, error) {
+	// Only the local store is asked, the names cached as missing or registered in the other registries don't matter
+	localCtx := inprocess.WithLocalOnly(ctx)
+	return func() (bool, error) {
+		counter := &nsCounter{ctx: localCtx, name: name}
+		query := &registry.NetworkServiceQuery{NetworkService: &registry.NetworkService{Name: name}}
+		if err := next.NetworkServiceRegistryServer(localCtx).Find(query, counter); err != nil {
+			return false, err
+		}
+		return counter.count > 0, nil
+	}
+}
+
+// tenantNSFindServer drops the NSs of the other tenants, letting the deletions of the already sent NSs through
+type tenantNSFindServer struct {
+	registry.NetworkServiceRegistry_FindServer
+	tenants *tenants
+	sent    map[string]struct{}
+}
+
+func (s *tenantNSFindServer) Send(resp *registry.NetworkServiceResponse) error {
+	name := resp.GetNetworkService().GetName()
+	if _, ok := s.sent[name]; ok && resp.GetDeleted() {
+		delete(s.sent, name)
+		return s.NetworkServiceRegistry_FindServer.Send(resp)
+	}
+	if !s.tenants.visible(s.Context(), name) {
+		return nil
+	}
+	if resp.GetDeleted() {
+		delete(s.sent, name)
+	} else {
+		s.sent[name] = struct{}{}
+	}
+	return s.NetworkServiceRegistry_FindServer.Send(resp)
+}
+
+type nsCounter struct {
+	grpc.ServerStream
+	ctx   context.Context
+	name  string
+	count int
+}
+
+func (c *nsCounter) Send(resp *registry.NetworkServiceResponse) error {
+	if resp.GetNetworkService().GetName() == c.name {
+		c.count++
+	}
+	return nil
+}
+
+func (c *nsCounter) Context() context.Context {
+	return c.ctx
+}
@@ -0,0 +1,139 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tenancy
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/inprocess"
+)
+
+type tenancyNSEServer struct {
+	*tenants
+}
+
+// NewNetworkServiceEndpointRegistryServer creates a new NSE server chain element allowing the callers to find and
+// manage only the NSEs registered from their trust domain
+func NewNetworkServiceEndpointRegistryServer(opts ...Option) registry.NetworkServiceEndpointRegistryServer {
+	return &tenancyNSEServer{
+		tenants: newTenants(opts...),
+	}
+}
+
+func (s *tenancyNSEServer) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*registry.NetworkServiceEndpoint, error) {
+	rollback, err := s.claim(ctx, nse.GetName(), s.exists(ctx, nse.GetName()))
+	if err != nil {
+		return nil, err
+	}
+	resp, err := next.NetworkServiceEndpointRegistryServer(ctx).Register(ctx, nse)
+	if err != nil {
+		rollback()
+		return nil, err
+	}
+	if resp.GetName() != nse.GetName() {
+		// The name is generated by the store
+		rollback()
+	}
+	s.own(ctx, resp.GetName(), resp.GetExpirationTime())
+	return resp, nil
+}
+
+func (s *tenancyNSEServer) Find(query *registry.NetworkServiceEndpointQuery, server registry.NetworkServiceEndpointRegistry_FindServer) error {
+	if _, admin := s.caller(server.Context()); admin {
+		return next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, server)
+	}
+	return next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, &tenantNSEFindServer{
+		NetworkServiceEndpointRegistry_FindServer: server,
+		tenants: s.tenants,
+		sent:    make(map[string]struct{}),
+	})
+}
+
+func (s *tenancyNSEServer) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*empty.Empty, error) {
+	if err := s.check(ctx, nse.GetName(), s.exists(ctx, nse.GetName())); err != nil {
+		return nil, err
+	}
+	resp, err := next.NetworkServiceEndpointRegistryServer(ctx).Unregister(ctx, nse)
+	if err != nil {
+		return nil, err
+	}
+	s.forget(nse.GetName())
+	return resp, nil
+}
+
+func (s *tenancyNSEServer) exists(ctx context.Context, name string) func() (bool, error) {
+	// Only the local store is asked, the names cached as missing or registered in the other registries don't matter
+	localCtx := inprocess.WithLocalOnly(ctx)
+	return func() (bool, error) {
+		counter := &nseCounter{ctx: localCtx, name: name}
+		query := &registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{Name: name}}
+		if err := next.NetworkServiceEndpointRegistryServer(localCtx).Find(query, counter); err != nil {
+			return false, err
+		}
+		return counter.count > 0, nil
+	}
+}
+
+// tenantNSEFindServer drops the NSEs of the other tenants. The owner is forgotten before the store sends the
+// deletion event, so the deletions of the already sent NSEs are always let through.
+type tenantNSEFindServer struct {
+	registry.NetworkServiceEndpointRegistry_FindServer
+	tenants *tenants
+	sent    map[string]struct{}
+}
+
+func (s *tenantNSEFindServer) Send(resp *registry.NetworkServiceEndpointResponse) error {
+	name := resp.GetNetworkServiceEndpoint().GetName()
+	if _, ok := s.sent[name]; ok && resp.GetDeleted() {
+		delete(s.sent, name)
+		return s.NetworkServiceEndpointRegistry_FindServer.Send(resp)
+	}
+	if !s.tenants.visible(s.Context(), name) {
+		return nil
+	}
+	if resp.GetDeleted() {
+		delete(s.sent, name)
+	} else {
+		s.sent[name] = struct{}{}
+	}
+	return s.NetworkServiceEndpointRegistry_FindServer.Send(resp)
+}
+
+type nseCounter struct {
+	grpc.ServerStream
+	ctx   context.Context
+	name  string
+	count int
+}
+
+func (c *nseCounter) Send(resp *registry.NetworkServiceEndpointResponse) error {
+	if resp.GetNetworkServiceEndpoint().GetName() == c.name {
+		c.count++
+	}
+	return nil
+}
+
+func (c *nseCounter) Context() context.Context {
+	return c.ctx
+}
@@ -0,0 +1,311 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tenancy_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/chain"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clockmock"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/identity"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/pagination"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/tenancy"
+)
+
+func withPeer(ctx context.Context, spiffeID string) context.Context {
+	u, _ := url.Parse(spiffeID)
+	return peer.NewContext(ctx, &peer.Peer{
+		AuthInfo: credentials.TLSInfo{
+			State: tls.ConnectionState{PeerCertificates: []*x509.Certificate{{URIs: []*url.URL{u}}}},
+		},
+	})
+}
+
+func findNames(ctx context.Context, t *testing.T, client registry.NetworkServiceEndpointRegistryClient) []string {
+	stream, err := client.Find(ctx, &registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: new(registry.NetworkServiceEndpoint)})
+	require.NoError(t, err)
+	var names []string
+	for _, nse := range registry.ReadNetworkServiceEndpointList(stream) {
+		names = append(names, nse.GetName())
+	}
+	return names
+}
+
+func TestTenancyNSEServer(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		tenancy.NewNetworkServiceEndpointRegistryServer(tenancy.WithAdminIDs("spiffe://admin.com/admin")),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+
+	teamA := withPeer(ctx, "spiffe://a.com/nse")
+	teamB := withPeer(ctx, "spiffe://b.com/nse")
+	admin := withPeer(ctx, "spiffe://admin.com/admin")
+
+	_, err := client.Register(teamA, &registry.NetworkServiceEndpoint{Name: "nse-a"})
+	require.NoError(t, err)
+	_, err = client.Register(teamB, &registry.NetworkServiceEndpoint{Name: "nse-b"})
+	require.NoError(t, err)
+
+	require.Equal(t, []string{"nse-a"}, findNames(teamA, t, client))
+	require.Equal(t, []string{"nse-b"}, findNames(teamB, t, client))
+	require.ElementsMatch(t, []string{"nse-a", "nse-b"}, findNames(admin, t, client))
+
+	_, err = client.Register(teamB, &registry.NetworkServiceEndpoint{Name: "nse-a"})
+	require.Error(t, err)
+	_, err = client.Unregister(teamB, &registry.NetworkServiceEndpoint{Name: "nse-a"})
+	require.Error(t, err)
+
+	_, err = client.Unregister(teamA, &registry.NetworkServiceEndpoint{Name: "nse-a"})
+	require.NoError(t, err)
+	_, err = client.Register(teamB, &registry.NetworkServiceEndpoint{Name: "nse-a"})
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"nse-a", "nse-b"}, findNames(teamB, t, client))
+}
+
+func TestTenancyNSEServer_Identities(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	filePath := filepath.Join(t.TempDir(), "identities.json")
+	require.NoError(t, os.WriteFile(filePath, []byte(`[
+		{"spiffe_id": "spiffe://a.com/team-1/.*", "labels": {"tenant": "team-1"}},
+		{"spiffe_id": "spiffe://a.com/ops", "labels": {"role": "admin"}}
+	]`), 0o600))
+	identities := identity.NewMapping()
+	require.NoError(t, identities.Load(filePath))
+
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		tenancy.NewNetworkServiceEndpointRegistryServer(tenancy.WithIdentities(identities)),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+
+	team1 := withPeer(ctx, "spiffe://a.com/team-1/nse")
+	team2 := withPeer(ctx, "spiffe://a.com/team-2/nse")
+	ops := withPeer(ctx, "spiffe://a.com/ops")
+
+	_, err := client.Register(team1, &registry.NetworkServiceEndpoint{Name: "nse-1"})
+	require.NoError(t, err)
+	_, err = client.Register(team2, &registry.NetworkServiceEndpoint{Name: "nse-2"})
+	require.NoError(t, err)
+
+	// Same trust domain, different tenants
+	require.Equal(t, []string{"nse-1"}, findNames(team1, t, client))
+	require.Equal(t, []string{"nse-2"}, findNames(team2, t, client))
+	require.ElementsMatch(t, []string{"nse-1", "nse-2"}, findNames(ops, t, client))
+}
+
+type afterRegisterNSEServer struct {
+	afterRegister func()
+}
+
+func (s *afterRegisterNSEServer) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*registry.NetworkServiceEndpoint, error) {
+	resp, err := next.NetworkServiceEndpointRegistryServer(ctx).Register(ctx, nse)
+	if err == nil {
+		s.afterRegister()
+	}
+	return resp, err
+}
+
+func (s *afterRegisterNSEServer) Find(query *registry.NetworkServiceEndpointQuery, server registry.NetworkServiceEndpointRegistry_FindServer) error {
+	return next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, server)
+}
+
+func (s *afterRegisterNSEServer) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*empty.Empty, error) {
+	return next.NetworkServiceEndpointRegistryServer(ctx).Unregister(ctx, nse)
+}
+
+func TestTenancyNSEServer_RegisterInProgress(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	teamA := withPeer(ctx, "spiffe://a.com/nse")
+	teamB := withPeer(ctx, "spiffe://b.com/nse")
+
+	var client registry.NetworkServiceEndpointRegistryClient
+	var seen []string
+	client = adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		tenancy.NewNetworkServiceEndpointRegistryServer(),
+		&afterRegisterNSEServer{afterRegister: func() { seen = append(seen, findNames(teamB, t, client)...) }},
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+
+	// The registration is owned before it is stored, so the other tenants don't get it even while the Register is
+	// still in progress, e.g. by the store watch events
+	_, err := client.Register(teamA, &registry.NetworkServiceEndpoint{Name: "nse-a", Url: "tcp://a.com"})
+	require.NoError(t, err)
+	require.Empty(t, seen)
+}
+
+func TestTenancyNSEServer_Unowned(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		tenancy.NewNetworkServiceEndpointRegistryServer(tenancy.WithAdminIDs("spiffe://admin.com/admin")),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+
+	teamA := withPeer(ctx, "spiffe://a.com/nse")
+	admin := withPeer(ctx, "spiffe://admin.com/admin")
+
+	_, err := client.Register(admin, &registry.NetworkServiceEndpoint{Name: "nse-admin"})
+	require.NoError(t, err)
+	_, err = client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-in-process"})
+	require.NoError(t, err)
+
+	require.Empty(t, findNames(teamA, t, client))
+	require.ElementsMatch(t, []string{"nse-admin", "nse-in-process"}, findNames(admin, t, client))
+
+	// The admin and in-process registrations are owned by the admin owner, no tenant takes them over
+	for _, name := range []string{"nse-admin", "nse-in-process"} {
+		_, err = client.Register(teamA, &registry.NetworkServiceEndpoint{Name: name})
+		require.Error(t, err)
+		_, err = client.Unregister(teamA, &registry.NetworkServiceEndpoint{Name: name})
+		require.Error(t, err)
+	}
+	require.ElementsMatch(t, []string{"nse-admin", "nse-in-process"}, findNames(admin, t, client))
+}
+
+func TestTenancyNSEServer_Expiration(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	clockMock := clockmock.New(ctx)
+	ctx = clock.WithClock(ctx, clockMock)
+
+	// No expire element: the owner is forgotten on expiration even if the store still has the registration
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		tenancy.NewNetworkServiceEndpointRegistryServer(),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+
+	teamA := withPeer(ctx, "spiffe://a.com/nse")
+	teamB := withPeer(ctx, "spiffe://b.com/nse")
+
+	_, err := client.Register(teamA, &registry.NetworkServiceEndpoint{
+		Name:           "nse-1",
+		ExpirationTime: timestamppb.New(clockMock.Now().Add(time.Minute)),
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"nse-1"}, findNames(teamA, t, client))
+
+	_, err = client.Register(teamB, &registry.NetworkServiceEndpoint{Name: "nse-1"})
+	require.Error(t, err)
+
+	clockMock.Add(time.Minute)
+	require.Empty(t, findNames(teamA, t, client))
+
+	// The registration still stored has no owner, so it is foreign to all the tenants till the store drops it
+	_, err = client.Register(teamB, &registry.NetworkServiceEndpoint{Name: "nse-1"})
+	require.Error(t, err)
+	_, err = client.Unregister(ctx, &registry.NetworkServiceEndpoint{Name: "nse-1"})
+	require.NoError(t, err)
+
+	_, err = client.Register(teamB, &registry.NetworkServiceEndpoint{Name: "nse-1"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"nse-1"}, findNames(teamB, t, client))
+
+	// The owner is forgotten on Unregister as well
+	_, err = client.Unregister(teamB, &registry.NetworkServiceEndpoint{Name: "nse-1"})
+	require.NoError(t, err)
+	_, err = client.Register(teamA, &registry.NetworkServiceEndpoint{Name: "nse-1"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"nse-1"}, findNames(teamA, t, client))
+}
+
+func TestTenancyNSEServer_Pagination(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// Pagination goes first, so the pages are cut after the other tenants entries are dropped
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		pagination.NewNetworkServiceEndpointRegistryServer(2),
+		tenancy.NewNetworkServiceEndpointRegistryServer(),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+
+	teamA := withPeer(ctx, "spiffe://a.com/nse")
+	teamB := withPeer(ctx, "spiffe://b.com/nse")
+
+	for _, name := range []string{"nse-a-1", "nse-a-2", "nse-a-3"} {
+		_, err := client.Register(teamA, &registry.NetworkServiceEndpoint{Name: name})
+		require.NoError(t, err)
+	}
+	for _, name := range []string{"nse-b-1", "nse-b-2"} {
+		_, err := client.Register(teamB, &registry.NetworkServiceEndpoint{Name: name})
+		require.NoError(t, err)
+	}
+
+	require.ElementsMatch(t, []string{"nse-b-1", "nse-b-2"}, findNames(teamB, t, client))
+	require.Len(t, findNames(teamA, t, client), 2)
+}
+
+func TestTenancyNSEServer_WatchDelete(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		tenancy.NewNetworkServiceEndpointRegistryServer(),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+
+	teamA := withPeer(ctx, "spiffe://a.com/nse")
+
+	stream, err := client.Find(teamA, &registry.NetworkServiceEndpointQuery{
+		NetworkServiceEndpoint: new(registry.NetworkServiceEndpoint),
+		Watch:                  true,
+	})
+	require.NoError(t, err)
+
+	nse, err := client.Register(teamA, &registry.NetworkServiceEndpoint{Name: "nse-a"})
+	require.NoError(t, err)
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "nse-a", resp.GetNetworkServiceEndpoint().GetName())
+	require.False(t, resp.GetDeleted())
+
+	// The owner is forgotten before the store sends the deletion event
+	_, err = client.Unregister(teamA, nse)
+	require.NoError(t, err)
+	resp, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "nse-a", resp.GetNetworkServiceEndpoint().GetName())
+	require.True(t, resp.GetDeleted())
+}
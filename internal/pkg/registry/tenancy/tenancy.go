@@ -0,0 +1,235 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tenancy provides registry server chain elements partitioning the registrations by the caller SPIFFE trust
+// domain, or by the caller tenant label of the identity mapping
+package tenancy
+
+import (
+	"context"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/identity"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/inprocess"
+)
+
+type options struct {
+	adminIDs   map[string]struct{}
+	identities *identity.Mapping
+}
+
+// Option is an option for the tenancy chain elements
+type Option func(o *options)
+
+// WithAdminIDs sets the SPIFFE IDs seeing and managing the registrations of all the tenants
+func WithAdminIDs(ids ...string) Option {
+	return func(o *options) {
+		for _, id := range ids {
+			o.adminIDs[id] = struct{}{}
+		}
+	}
+}
+
+// WithIdentities sets the identity mapping: the tenant label overrides the caller trust domain and the admin role
+// makes the caller an admin
+func WithIdentities(identities *identity.Mapping) Option {
+	return func(o *options) {
+		o.identities = identities
+	}
+}
+
+// tenants keeps the tenants (trust domains) owning the registered names
+type tenants struct {
+	options
+	mu     sync.Mutex
+	owners map[string]*owner
+}
+
+// owner is the tenant owning a name until the registration expires. The names registered by the admins and in-process,
+// e.g. imported or mirrored, are owned by the reserved admin owner, no tenant manages them.
+type owner struct {
+	tenant string
+	admin  bool
+	expire clock.Timer
+}
+
+// ownedBy returns true if the tenant is the owner
+func (o *owner) ownedBy(tenant string) bool {
+	return !o.admin && o.tenant == tenant
+}
+
+func newTenants(opts ...Option) *tenants {
+	t := &tenants{
+		options: options{
+			adminIDs: make(map[string]struct{}),
+		},
+		owners: make(map[string]*owner),
+	}
+	for _, opt := range opts {
+		opt(&t.options)
+	}
+	return t
+}
+
+// caller returns the tenant of the caller. Admins and in-process callers (no peer or marked in-process) are not
+// restricted to a tenant, unless the in-process call is made on behalf of a tenant.
+func (t *tenants) caller(ctx context.Context) (tenant string, admin bool) {
+	if onBehalf, ok := inprocess.TenantFromContext(ctx); ok {
+		return onBehalf, false
+	}
+	if _, ok := peer.FromContext(ctx); !ok || inprocess.FromContext(ctx) {
+		return "", true
+	}
+	id, labels, ok := t.identities.FromContext(ctx)
+	if !ok {
+		return "", false
+	}
+	if _, isAdmin := t.adminIDs[id.String()]; isAdmin || labels[identity.LabelRole] == identity.RoleAdmin {
+		return "", true
+	}
+	if tenant := labels[identity.LabelTenant]; tenant != "" {
+		return tenant, false
+	}
+	return id.TrustDomain().String(), false
+}
+
+// visible returns true if the registration with the name is visible to the caller. Registrations without owner or
+// owned by the admin owner are visible to the admins only.
+func (t *tenants) visible(ctx context.Context, name string) bool {
+	tenant, admin := t.caller(ctx)
+	if admin {
+		return true
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	o, ok := t.owners[name]
+	return ok && o.ownedBy(tenant)
+}
+
+// check returns PermissionDenied if the name is not owned by the caller tenant and the registration still exists. An
+// existing registration without owner is foreign to all the tenants.
+func (t *tenants) check(ctx context.Context, name string, exists func() (bool, error)) error {
+	tenant, admin := t.caller(ctx)
+	if admin {
+		return nil
+	}
+	t.mu.Lock()
+	o, ok := t.owners[name]
+	t.mu.Unlock()
+	if ok && o.ownedBy(tenant) {
+		return nil
+	}
+	found, err := exists()
+	if err != nil {
+		return err
+	}
+	if found {
+		return status.Errorf(codes.PermissionDenied, "%s is registered by another tenant", name)
+	}
+	return nil
+}
+
+// claim records the caller tenant as the owner of the name before it is registered, so the registration is never
+// visible to the other tenants. It returns PermissionDenied if the name is not owned by the caller tenant and the
+// registration still exists, otherwise the function rolling the claim back if the registration fails.
+func (t *tenants) claim(ctx context.Context, name string, exists func() (bool, error)) (rollback func(), err error) {
+	tenant, admin := t.caller(ctx)
+	if admin {
+		return func() {}, nil
+	}
+	// A name without owner is claimed only once it is checked not to be registered
+	checked := false
+	for {
+		t.mu.Lock()
+		prev, ok := t.owners[name]
+		if ok && prev.ownedBy(tenant) {
+			t.mu.Unlock()
+			return func() {}, nil
+		}
+		if !ok && checked {
+			o := &owner{tenant: tenant}
+			t.owners[name] = o
+			t.mu.Unlock()
+			return func() { t.release(name, o) }, nil
+		}
+		t.mu.Unlock()
+
+		found, err := exists()
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			return nil, status.Errorf(codes.PermissionDenied, "%s is registered by another tenant", name)
+		}
+		if ok {
+			// The registration of the other owner is gone
+			t.release(name, prev)
+		}
+		checked = true
+	}
+}
+
+// own records the caller tenant, or the admin owner for the admins, as the owner of the registered name until the
+// expiration time, nil means until the name is unregistered
+func (t *tenants) own(ctx context.Context, name string, expirationTime *timestamppb.Timestamp) {
+	tenant, admin := t.caller(ctx)
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if prev, ok := t.owners[name]; ok && prev.expire != nil {
+		prev.expire.Stop()
+	}
+	o := &owner{tenant: tenant, admin: admin}
+	if expirationTime != nil {
+		timeClock := clock.FromContext(ctx)
+		o.expire = timeClock.AfterFunc(timeClock.Until(expirationTime.AsTime()), func() { t.release(name, o) })
+	}
+	t.owners[name] = o
+}
+
+// forget removes the owner of the unregistered name
+func (t *tenants) forget(name string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if o, ok := t.owners[name]; ok {
+		if o.expire != nil {
+			o.expire.Stop()
+		}
+		delete(t.owners, name)
+	}
+}
+
+// release removes the owner of the name if it is still o
+func (t *tenants) release(name string, o *owner) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.owners[name] == o {
+		if o.expire != nil {
+			o.expire.Stop()
+		}
+		delete(t.owners, name)
+	}
+}
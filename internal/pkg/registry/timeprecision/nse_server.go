@@ -0,0 +1,90 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package timeprecision provides NSE registry server chain element normalizing the NSE timestamps to a precision
+package timeprecision
+
+import (
+	"context"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+)
+
+type timePrecisionNSEServer struct {
+	precision time.Duration
+}
+
+// NewNetworkServiceEndpointRegistryServer creates a new NSE server chain element truncating the expiration time of
+// the registered NSEs and the timestamps of the returned NSEs to precision
+func NewNetworkServiceEndpointRegistryServer(precision time.Duration) registry.NetworkServiceEndpointRegistryServer {
+	return &timePrecisionNSEServer{
+		precision: precision,
+	}
+}
+
+func (s *timePrecisionNSEServer) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*registry.NetworkServiceEndpoint, error) {
+	nse.ExpirationTime = truncate(nse.GetExpirationTime(), s.precision)
+	resp, err := next.NetworkServiceEndpointRegistryServer(ctx).Register(ctx, nse)
+	if err != nil {
+		return nil, err
+	}
+	return normalize(resp, s.precision), nil
+}
+
+func (s *timePrecisionNSEServer) Find(query *registry.NetworkServiceEndpointQuery, server registry.NetworkServiceEndpointRegistry_FindServer) error {
+	return next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, &timePrecisionFindServer{
+		NetworkServiceEndpointRegistry_FindServer: server,
+		precision: s.precision,
+	})
+}
+
+func (s *timePrecisionNSEServer) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*empty.Empty, error) {
+	return next.NetworkServiceEndpointRegistryServer(ctx).Unregister(ctx, nse)
+}
+
+type timePrecisionFindServer struct {
+	registry.NetworkServiceEndpointRegistry_FindServer
+	precision time.Duration
+}
+
+func (s *timePrecisionFindServer) Send(resp *registry.NetworkServiceEndpointResponse) error {
+	resp = resp.Clone()
+	resp.NetworkServiceEndpoint = normalize(resp.GetNetworkServiceEndpoint(), s.precision)
+	return s.NetworkServiceEndpointRegistry_FindServer.Send(resp)
+}
+
+// normalize truncates the nse timestamps, the caller must own nse
+func normalize(nse *registry.NetworkServiceEndpoint, precision time.Duration) *registry.NetworkServiceEndpoint {
+	if nse == nil {
+		return nil
+	}
+	nse.ExpirationTime = truncate(nse.GetExpirationTime(), precision)
+	nse.InitialRegistrationTime = truncate(nse.GetInitialRegistrationTime(), precision)
+	return nse
+}
+
+func truncate(t *timestamppb.Timestamp, precision time.Duration) *timestamppb.Timestamp {
+	if t == nil {
+		return nil
+	}
+	return timestamppb.New(t.AsTime().UTC().Truncate(precision))
+}
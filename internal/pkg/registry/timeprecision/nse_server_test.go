@@ -0,0 +1,71 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package timeprecision_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/setregistrationtime"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/chain"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/utils/metadata"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/timeprecision"
+)
+
+func TestTimePrecisionNSEServer_RoundTrip(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		metadata.NewNetworkServiceEndpointServer(),
+		timeprecision.NewNetworkServiceEndpointRegistryServer(time.Second),
+		setregistrationtime.NewNetworkServiceEndpointRegistryServer(),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+
+	expirationTime := time.Date(2023, 7, 1, 12, 30, 15, 123456789, time.FixedZone("UTC+3", 3*60*60))
+	registered, err := client.Register(ctx, &registry.NetworkServiceEndpoint{
+		Name:           "nse-1",
+		ExpirationTime: timestamppb.New(expirationTime),
+	})
+	require.NoError(t, err)
+	require.Equal(t, expirationTime.UTC().Truncate(time.Second), registered.GetExpirationTime().AsTime())
+	require.Zero(t, registered.GetInitialRegistrationTime().GetNanos())
+
+	stream, err := client.Find(ctx, &registry.NetworkServiceEndpointQuery{
+		NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{Name: "nse-1"},
+	})
+	require.NoError(t, err)
+	nses := registry.ReadNetworkServiceEndpointList(stream)
+	require.Len(t, nses, 1)
+	require.True(t, proto.Equal(registered, nses[0]))
+
+	// Registering the returned NSE again keeps the timestamps
+	reregistered, err := client.Register(ctx, nses[0].Clone())
+	require.NoError(t, err)
+	require.True(t, proto.Equal(registered, reregistered))
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package upstream
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/pkg/errors"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/log"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/inprocess"
+)
+
+type upstreamNSServer struct {
+	client registry.NetworkServiceRegistryClient
+	pusher *pusher
+	options
+}
+
+// NewNetworkServiceRegistryServer creates a new NS server chain element using client to reach the upstream
+// registry
+func NewNetworkServiceRegistryServer(client registry.NetworkServiceRegistryClient, opts ...Option) registry.NetworkServiceRegistryServer {
+	s := &upstreamNSServer{
+		client: client,
+		options: options{
+			pushTimeout: defaultPushTimeout,
+		},
+	}
+	for _, opt := range opts {
+		opt(&s.options)
+	}
+	s.pusher = newPusher("NS", s.pushTimeout)
+	return s
+}
+
+func (s *upstreamNSServer) Register(ctx context.Context, ns *registry.NetworkService) (*registry.NetworkService, error) {
+	resp, err := next.NetworkServiceRegistryServer(ctx).Register(ctx, ns)
+	if err != nil || !s.push {
+		return resp, err
+	}
+	pushed := resp.Clone()
+	s.pusher.push(ctx, pushed.GetName(), func(ctx context.Context) error {
+		_, pushErr := s.client.Register(ctx, pushed)
+		return pushErr
+	})
+	return resp, nil
+}
+
+func (s *upstreamNSServer) Find(query *registry.NetworkServiceQuery, server registry.NetworkServiceRegistry_FindServer) error {
+	if !s.fallback || query.GetWatch() || inprocess.LocalOnlyFromContext(server.Context()) {
+		return next.NetworkServiceRegistryServer(server.Context()).Find(query, server)
+	}
+
+	counter := &nsFindServerCounter{NetworkServiceRegistry_FindServer: server}
+	if err := next.NetworkServiceRegistryServer(server.Context()).Find(query, counter); err != nil {
+		return err
+	}
+	if counter.count > 0 {
+		return nil
+	}
+
+	stream, err := s.client.Find(server.Context(), query)
+	if err != nil {
+		log.FromContext(server.Context()).Warnf("failed to forward NS query to the upstream registry: %s", err.Error())
+		return nil
+	}
+	for resp := range registry.ReadNetworkServiceChannel(stream) {
+		if err := server.Send(resp); err != nil {
+			return errors.Wrapf(err, "NetworkServiceRegistry find server failed to send a response %s", resp.String())
+		}
+	}
+	return nil
+}
+
+func (s *upstreamNSServer) Unregister(ctx context.Context, ns *registry.NetworkService) (*empty.Empty, error) {
+	pushed := ns.Clone()
+	resp, err := next.NetworkServiceRegistryServer(ctx).Unregister(ctx, ns)
+	if err != nil || !s.push {
+		return resp, err
+	}
+	s.pusher.push(ctx, pushed.GetName(), func(ctx context.Context) error {
+		_, pushErr := s.client.Unregister(ctx, pushed)
+		return pushErr
+	})
+	return resp, nil
+}
+
+type nsFindServerCounter struct {
+	registry.NetworkServiceRegistry_FindServer
+	count int
+}
+
+func (s *nsFindServerCounter) Send(resp *registry.NetworkServiceResponse) error {
+	s.count++
+	return s.NetworkServiceRegistry_FindServer.Send(resp)
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package upstream
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/pkg/errors"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/log"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/inprocess"
+)
+
+type upstreamNSEServer struct {
+	client registry.NetworkServiceEndpointRegistryClient
+	pusher *pusher
+	options
+}
+
+// NewNetworkServiceEndpointRegistryServer creates a new NSE server chain element using client to reach the upstream
+// registry
+func NewNetworkServiceEndpointRegistryServer(client registry.NetworkServiceEndpointRegistryClient, opts ...Option) registry.NetworkServiceEndpointRegistryServer {
+	s := &upstreamNSEServer{
+		client: client,
+		options: options{
+			pushTimeout: defaultPushTimeout,
+		},
+	}
+	for _, opt := range opts {
+		opt(&s.options)
+	}
+	s.pusher = newPusher("NSE", s.pushTimeout)
+	return s
+}
+
+func (s *upstreamNSEServer) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*registry.NetworkServiceEndpoint, error) {
+	resp, err := next.NetworkServiceEndpointRegistryServer(ctx).Register(ctx, nse)
+	if err != nil || !s.push {
+		return resp, err
+	}
+	pushed := resp.Clone()
+	s.pusher.push(ctx, pushed.GetName(), func(ctx context.Context) error {
+		_, pushErr := s.client.Register(ctx, pushed)
+		return pushErr
+	})
+	return resp, nil
+}
+
+func (s *upstreamNSEServer) Find(query *registry.NetworkServiceEndpointQuery, server registry.NetworkServiceEndpointRegistry_FindServer) error {
+	if !s.fallback || query.GetWatch() || inprocess.LocalOnlyFromContext(server.Context()) {
+		return next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, server)
+	}
+
+	counter := &nseFindServerCounter{NetworkServiceEndpointRegistry_FindServer: server}
+	if err := next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, counter); err != nil {
+		return err
+	}
+	if counter.count > 0 {
+		return nil
+	}
+
+	stream, err := s.client.Find(server.Context(), query)
+	if err != nil {
+		log.FromContext(server.Context()).Warnf("failed to forward NSE query to the upstream registry: %s", err.Error())
+		return nil
+	}
+	for resp := range registry.ReadNetworkServiceEndpointChannel(stream) {
+		if err := server.Send(resp); err != nil {
+			return errors.Wrapf(err, "NetworkServiceEndpointRegistry find server failed to send a response %s", resp.String())
+		}
+	}
+	return nil
+}
+
+func (s *upstreamNSEServer) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*empty.Empty, error) {
+	pushed := nse.Clone()
+	resp, err := next.NetworkServiceEndpointRegistryServer(ctx).Unregister(ctx, nse)
+	if err != nil || !s.push {
+		return resp, err
+	}
+	s.pusher.push(ctx, pushed.GetName(), func(ctx context.Context) error {
+		_, pushErr := s.client.Unregister(ctx, pushed)
+		return pushErr
+	})
+	return resp, nil
+}
+
+type nseFindServerCounter struct {
+	registry.NetworkServiceEndpointRegistry_FindServer
+	count int
+}
+
+func (s *nseFindServerCounter) Send(resp *registry.NetworkServiceEndpointResponse) error {
+	s.count++
+	return s.NetworkServiceEndpointRegistry_FindServer.Send(resp)
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package upstream provides registry server chain elements using the upstream (proxy) registry as a fallback for
+// the queries with no local match and optionally pushing local registrations to it
+package upstream
+
+import "time"
+
+type options struct {
+	fallback    bool
+	push        bool
+	pushTimeout time.Duration
+}
+
+// Option is an option for the upstream chain elements
+type Option func(o *options)
+
+// WithFallback forwards non watching Find queries with no local match to the upstream registry
+func WithFallback() Option {
+	return func(o *options) {
+		o.fallback = true
+	}
+}
+
+// WithPush pushes local Register/Unregister to the upstream registry in the background
+func WithPush() Option {
+	return func(o *options) {
+		o.push = true
+	}
+}
+
+// WithPushTimeout sets the timeout of a push to the upstream registry, default is 5s
+func WithPushTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.pushTimeout = timeout
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package upstream
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/edwarnicke/serialize"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/log"
+)
+
+const defaultPushTimeout = 5 * time.Second
+
+// pusher pushes the local changes to the upstream registry in the background, so a slow upstream doesn't stall the
+// local registrations. The pushes run in order, each bounded by the timeout. A change queued for a name replaces the
+// change still pending for it, as only the latest one matters upstream.
+type pusher struct {
+	kind     string
+	timeout  time.Duration
+	executor serialize.Executor
+	mu       sync.Mutex
+	pending  map[string]func(ctx context.Context) error
+}
+
+func newPusher(kind string, timeout time.Duration) *pusher {
+	return &pusher{
+		kind:    kind,
+		timeout: timeout,
+		pending: make(map[string]func(ctx context.Context) error),
+	}
+}
+
+// push queues the push of the change of name. The push keeps the logger of ctx but not its cancellation, as it
+// outlives the call.
+func (p *pusher) push(ctx context.Context, name string, push func(ctx context.Context) error) {
+	logger := log.FromContext(ctx)
+
+	p.mu.Lock()
+	_, queued := p.pending[name]
+	p.pending[name] = push
+	p.mu.Unlock()
+	if queued {
+		return
+	}
+
+	p.executor.AsyncExec(func() {
+		p.mu.Lock()
+		push := p.pending[name]
+		delete(p.pending, name)
+		p.mu.Unlock()
+
+		pushCtx, cancel := context.WithTimeout(log.WithLog(context.Background(), logger), p.timeout)
+		defer cancel()
+		if err := push(pushCtx); err != nil {
+			logger.Warnf("failed to push %s %s to the upstream registry: %s", p.kind, name, err.Error())
+		}
+	})
+}
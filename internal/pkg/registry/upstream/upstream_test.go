@@ -0,0 +1,194 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package upstream_test
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/chain"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/utils/inject/injecterror"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/upstream"
+)
+
+// countingNSEClient counts the Find calls reaching the upstream registry
+type countingNSEClient struct {
+	registry.NetworkServiceEndpointRegistryClient
+	finds int32
+}
+
+func (c *countingNSEClient) Find(ctx context.Context, query *registry.NetworkServiceEndpointQuery, opts ...grpc.CallOption) (registry.NetworkServiceEndpointRegistry_FindClient, error) {
+	atomic.AddInt32(&c.finds, 1)
+	return c.NetworkServiceEndpointRegistryClient.Find(ctx, query, opts...)
+}
+
+// blockingNSEClient is an upstream registry not answering Register till the call is done
+type blockingNSEClient struct {
+	registry.NetworkServiceEndpointRegistryClient
+	errs chan error
+}
+
+func (c *blockingNSEClient) Register(ctx context.Context, _ *registry.NetworkServiceEndpoint, _ ...grpc.CallOption) (*registry.NetworkServiceEndpoint, error) {
+	<-ctx.Done()
+	c.errs <- ctx.Err()
+	return nil, ctx.Err()
+}
+
+func newLocalClient(upstreamClient registry.NetworkServiceEndpointRegistryClient, opts ...upstream.Option) registry.NetworkServiceEndpointRegistryClient {
+	return adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		upstream.NewNetworkServiceEndpointRegistryServer(upstreamClient, opts...),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+}
+
+func findNames(ctx context.Context, t *testing.T, client registry.NetworkServiceEndpointRegistryClient, name string) []string {
+	stream, err := client.Find(ctx, &registry.NetworkServiceEndpointQuery{
+		NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{Name: name},
+	})
+	require.NoError(t, err)
+	var names []string
+	for _, nse := range registry.ReadNetworkServiceEndpointList(stream) {
+		names = append(names, nse.GetName())
+	}
+	return names
+}
+
+func TestUpstreamNSEServer_Fallback(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	upstreamClient := &countingNSEClient{
+		NetworkServiceEndpointRegistryClient: adapters.NetworkServiceEndpointServerToClient(memory.NewNetworkServiceEndpointRegistryServer()),
+	}
+	_, err := upstreamClient.Register(ctx, &registry.NetworkServiceEndpoint{Name: "remote-nse"})
+	require.NoError(t, err)
+
+	client := newLocalClient(upstreamClient, upstream.WithFallback())
+	_, err = client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "local-nse"})
+	require.NoError(t, err)
+
+	// A local match is not forwarded
+	require.Equal(t, []string{"local-nse"}, findNames(ctx, t, client, "local-nse"))
+	require.Zero(t, atomic.LoadInt32(&upstreamClient.finds))
+
+	// A query with no local match is
+	require.Equal(t, []string{"remote-nse"}, findNames(ctx, t, client, "remote-nse"))
+	require.Equal(t, int32(1), atomic.LoadInt32(&upstreamClient.finds))
+
+	// No match upstream either
+	require.Empty(t, findNames(ctx, t, client, "missing-nse"))
+}
+
+func TestUpstreamNSEServer_Push(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	upstreamClient := adapters.NetworkServiceEndpointServerToClient(memory.NewNetworkServiceEndpointRegistryServer())
+	client := newLocalClient(upstreamClient, upstream.WithPush())
+
+	_, err := client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-1"})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return len(findNames(ctx, t, upstreamClient, "nse-1")) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	_, err = client.Unregister(ctx, &registry.NetworkServiceEndpoint{Name: "nse-1"})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return len(findNames(ctx, t, upstreamClient, "nse-1")) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestUpstreamNSEServer_PushLocalUnregisterFailure(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	upstreamClient := adapters.NetworkServiceEndpointServerToClient(memory.NewNetworkServiceEndpointRegistryServer())
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		upstream.NewNetworkServiceEndpointRegistryServer(upstreamClient, upstream.WithPush()),
+		injecterror.NewNetworkServiceEndpointRegistryServer(
+			injecterror.WithRegisterErrorTimes(),
+			injecterror.WithFindErrorTimes(),
+			injecterror.WithUnregisterErrorTimes(-1)),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+
+	_, err := client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-1"})
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return len(findNames(ctx, t, upstreamClient, "nse-1")) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// The failed local unregistration is not pushed
+	_, err = client.Unregister(ctx, &registry.NetworkServiceEndpoint{Name: "nse-1"})
+	require.Error(t, err)
+	require.Never(t, func() bool {
+		return len(findNames(ctx, t, upstreamClient, "nse-1")) == 0
+	}, 100*time.Millisecond, 10*time.Millisecond)
+}
+
+func TestUpstreamNSEServer_PushFailure(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	upstreamClient := injecterror.NewNetworkServiceEndpointRegistryClient(
+		injecterror.WithError(status.Error(codes.Unavailable, "upstream is down")))
+	client := newLocalClient(upstreamClient, upstream.WithPush())
+
+	// The local registration succeeds anyway
+	_, err := client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-1"})
+	require.NoError(t, err)
+	require.Equal(t, []string{"nse-1"}, findNames(ctx, t, client, "nse-1"))
+}
+
+func TestUpstreamNSEServer_SlowPush(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	upstreamClient := &blockingNSEClient{errs: make(chan error, 2)}
+	client := newLocalClient(upstreamClient, upstream.WithPush(), upstream.WithPushTimeout(100*time.Millisecond))
+
+	// The registration doesn't wait for the upstream registry
+	start := time.Now()
+	for _, name := range []string{"nse-1", "nse-2"} {
+		_, err := client.Register(ctx, &registry.NetworkServiceEndpoint{Name: name})
+		require.NoError(t, err)
+	}
+	require.Less(t, time.Since(start), 100*time.Millisecond)
+
+	// The pushes time out one after another
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-upstreamClient.errs:
+			require.ErrorIs(t, err, context.DeadlineExceeded)
+		case <-ctx.Done():
+			t.Fatal("the push has not timed out")
+		}
+	}
+}
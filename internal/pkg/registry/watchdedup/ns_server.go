@@ -0,0 +1,84 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watchdedup
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+)
+
+type watchDedupNSServer struct {
+	options
+}
+
+// NewNetworkServiceRegistryServer creates a new NS server chain element suppressing the watch updates changing
+// nothing
+func NewNetworkServiceRegistryServer(opts ...Option) registry.NetworkServiceRegistryServer {
+	s := new(watchDedupNSServer)
+	for _, opt := range opts {
+		opt(&s.options)
+	}
+	return s
+}
+
+func (s *watchDedupNSServer) Register(ctx context.Context, ns *registry.NetworkService) (*registry.NetworkService, error) {
+	return next.NetworkServiceRegistryServer(ctx).Register(ctx, ns)
+}
+
+func (s *watchDedupNSServer) Find(query *registry.NetworkServiceQuery, server registry.NetworkServiceRegistry_FindServer) error {
+	if !query.GetWatch() {
+		return next.NetworkServiceRegistryServer(server.Context()).Find(query, server)
+	}
+
+	ctx, cancel := context.WithCancel(server.Context())
+	defer cancel()
+
+	sender := newSender(server.Send, nsKey, nsEqual, s.coalesceWindow)
+	go sender.run(ctx)
+
+	return next.NetworkServiceRegistryServer(ctx).Find(query, &nsFindServer{
+		NetworkServiceRegistry_FindServer: server,
+		sender:                            sender,
+	})
+}
+
+func (s *watchDedupNSServer) Unregister(ctx context.Context, ns *registry.NetworkService) (*empty.Empty, error) {
+	return next.NetworkServiceRegistryServer(ctx).Unregister(ctx, ns)
+}
+
+type nsFindServer struct {
+	registry.NetworkServiceRegistry_FindServer
+	sender *sender[*registry.NetworkServiceResponse]
+}
+
+func (s *nsFindServer) Send(resp *registry.NetworkServiceResponse) error {
+	return s.sender.Send(resp)
+}
+
+func nsKey(resp *registry.NetworkServiceResponse) (key string, deleted bool) {
+	return resp.GetNetworkService().GetName(), resp.GetDeleted()
+}
+
+func nsEqual(a, b *registry.NetworkServiceResponse) bool {
+	return proto.Equal(a, b)
+}
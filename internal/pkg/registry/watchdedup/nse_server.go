@@ -0,0 +1,97 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watchdedup
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/streamcontext"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/listwatch"
+)
+
+type watchDedupNSEServer struct {
+	options
+}
+
+// NewNetworkServiceEndpointRegistryServer creates a new NSE server chain element suppressing the watch updates
+// changing nothing but the NSE expiration time
+func NewNetworkServiceEndpointRegistryServer(opts ...Option) registry.NetworkServiceEndpointRegistryServer {
+	s := new(watchDedupNSEServer)
+	for _, opt := range opts {
+		opt(&s.options)
+	}
+	return s
+}
+
+func (s *watchDedupNSEServer) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*registry.NetworkServiceEndpoint, error) {
+	return next.NetworkServiceEndpointRegistryServer(ctx).Register(ctx, nse)
+}
+
+func (s *watchDedupNSEServer) Find(query *registry.NetworkServiceEndpointQuery, server registry.NetworkServiceEndpointRegistry_FindServer) error {
+	if !query.GetWatch() {
+		return next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, server)
+	}
+
+	ctx, cancel := context.WithCancel(server.Context())
+	defer cancel()
+
+	sender := newSender(server.Send, nseKey, nseEqual, s.coalesceWindow)
+	go sender.run(ctx)
+
+	// The coalesced snapshot is sent before the listwatch snapshot marker
+	ctx = listwatch.OnSnapshotDone(ctx, sender.flushed)
+	if listwatch.ResyncRequested(ctx) {
+		ctx = listwatch.OnResync(ctx, sender.reset)
+	}
+	return next.NetworkServiceEndpointRegistryServer(ctx).Find(query, &nseFindServer{
+		NetworkServiceEndpointRegistry_FindServer: streamcontext.NetworkServiceEndpointRegistryFindServer(ctx, server),
+		sender: sender,
+	})
+}
+
+func (s *watchDedupNSEServer) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*empty.Empty, error) {
+	return next.NetworkServiceEndpointRegistryServer(ctx).Unregister(ctx, nse)
+}
+
+type nseFindServer struct {
+	registry.NetworkServiceEndpointRegistry_FindServer
+	sender *sender[*registry.NetworkServiceEndpointResponse]
+}
+
+func (s *nseFindServer) Send(resp *registry.NetworkServiceEndpointResponse) error {
+	return s.sender.Send(resp)
+}
+
+func nseKey(resp *registry.NetworkServiceEndpointResponse) (key string, deleted bool) {
+	return resp.GetNetworkServiceEndpoint().GetName(), resp.GetDeleted()
+}
+
+func nseEqual(a, b *registry.NetworkServiceEndpointResponse) bool {
+	if a.GetDeleted() != b.GetDeleted() {
+		return false
+	}
+	aNSE, bNSE := a.GetNetworkServiceEndpoint().Clone(), b.GetNetworkServiceEndpoint().Clone()
+	aNSE.ExpirationTime, bNSE.ExpirationTime = nil, nil
+	return proto.Equal(aNSE, bNSE)
+}
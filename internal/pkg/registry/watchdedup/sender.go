@@ -0,0 +1,150 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package watchdedup provides registry server chain elements suppressing no-op updates in the watching Find streams
+// and optionally coalescing bursts of updates
+package watchdedup
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type options struct {
+	coalesceWindow time.Duration
+}
+
+// Option is an option for the watchdedup chain elements
+type Option func(o *options)
+
+// WithCoalesceWindow buffers the updates for window and sends only the latest update for each entry
+func WithCoalesceWindow(window time.Duration) Option {
+	return func(o *options) {
+		o.coalesceWindow = window
+	}
+}
+
+// sender deduplicates and coalesces the responses sent with send
+type sender[T any] struct {
+	send   func(T) error
+	key    func(T) (key string, deleted bool)
+	equal  func(a, b T) bool
+	window time.Duration
+
+	mu      sync.Mutex
+	last    map[string]T
+	pending map[string]T
+	order   []string
+	err     error
+}
+
+func newSender[T any](send func(T) error, key func(T) (string, bool), equal func(a, b T) bool, window time.Duration) *sender[T] {
+	return &sender[T]{
+		send:    send,
+		key:     key,
+		equal:   equal,
+		window:  window,
+		last:    make(map[string]T),
+		pending: make(map[string]T),
+	}
+}
+
+// Send sends resp now or buffers it till the next flush if the coalesce window is set
+func (s *sender[T]) Send(resp T) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.window <= 0 {
+		return s.sendLocked(resp)
+	}
+	if s.err != nil {
+		return s.err
+	}
+	key, _ := s.key(resp)
+	if _, ok := s.pending[key]; !ok {
+		s.order = append(s.order, key)
+	}
+	s.pending[key] = resp
+	return nil
+}
+
+// run flushes the buffered responses each coalesce window until ctx is done
+func (s *sender[T]) run(ctx context.Context) {
+	if s.window <= 0 {
+		return
+	}
+	ticker := time.NewTicker(s.window)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.flush()
+		}
+	}
+}
+
+func (s *sender[T]) flush() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range s.order {
+		if s.err != nil {
+			break
+		}
+		s.err = s.sendLocked(s.pending[key])
+	}
+	s.pending = make(map[string]T)
+	s.order = nil
+}
+
+// flushed flushes the buffered responses and returns the send error, if any
+func (s *sender[T]) flushed() error {
+	s.flush()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.err
+}
+
+// reset flushes the buffered responses and forgets the sent ones, so the following resync snapshot is sent in full. It
+// returns the send error, if any.
+func (s *sender[T]) reset() error {
+	err := s.flushed()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.last = make(map[string]T)
+	return err
+}
+
+func (s *sender[T]) sendLocked(resp T) error {
+	key, deleted := s.key(resp)
+	if last, ok := s.last[key]; ok && s.equal(last, resp) {
+		return nil
+	}
+	if err := s.send(resp); err != nil {
+		return err
+	}
+	if deleted {
+		delete(s.last, key)
+	} else {
+		s.last[key] = resp
+	}
+	return nil
+}
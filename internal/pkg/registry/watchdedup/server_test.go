@@ -0,0 +1,98 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watchdedup_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/chain"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/watchdedup"
+)
+
+func TestWatchDedupNSEServer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		watchdedup.NewNetworkServiceEndpointRegistryServer(),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+
+	register := func(url string) {
+		_, err := client.Register(ctx, &registry.NetworkServiceEndpoint{
+			Name:           "nse-1",
+			Url:            url,
+			ExpirationTime: timestamppb.New(time.Now().Add(time.Minute)),
+		})
+		require.NoError(t, err)
+	}
+
+	register("tcp://1.1.1.1:5001")
+
+	stream, err := client.Find(ctx, &registry.NetworkServiceEndpointQuery{
+		NetworkServiceEndpoint: new(registry.NetworkServiceEndpoint),
+		Watch:                  true,
+	})
+	require.NoError(t, err)
+
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "tcp://1.1.1.1:5001", resp.GetNetworkServiceEndpoint().GetUrl())
+
+	// Refresh changes only the expiration time and is suppressed
+	register("tcp://1.1.1.1:5001")
+	register("tcp://2.2.2.2:5001")
+
+	resp, err = stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "tcp://2.2.2.2:5001", resp.GetNetworkServiceEndpoint().GetUrl())
+}
+
+func TestWatchDedupNSEServer_Coalesce(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		watchdedup.NewNetworkServiceEndpointRegistryServer(watchdedup.WithCoalesceWindow(100*time.Millisecond)),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+
+	stream, err := client.Find(ctx, &registry.NetworkServiceEndpointQuery{
+		NetworkServiceEndpoint: new(registry.NetworkServiceEndpoint),
+		Watch:                  true,
+	})
+	require.NoError(t, err)
+
+	for _, url := range []string{"tcp://1.1.1.1:5001", "tcp://2.2.2.2:5001", "tcp://3.3.3.3:5001"} {
+		_, err = client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-1", Url: url})
+		require.NoError(t, err)
+	}
+
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	require.Equal(t, "tcp://3.3.3.3:5001", resp.GetNetworkServiceEndpoint().GetUrl())
+}
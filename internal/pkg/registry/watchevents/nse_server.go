@@ -0,0 +1,237 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package watchevents provides NSE registry server chain element annotating the watch events with the versioned event
+// details for the clients asking for them.
+//
+// A client negotiates the event version with the "event-version" query parameter or the
+// "registry-memory-event-version" gRPC metadata. The events are then sent with the labels of the reserved Key network
+// service in the NSE NetworkServiceLabels:
+//
+//	version:  the event version, the minimum of the requested and the supported ones
+//	type:     registered, updated, refreshed (expiration time change only) or deleted
+//	revision: registry wide sequence number of the Register or Unregister producing the event
+//	reason:   expired, unregistered or evicted, for the deleted events if the tombstones are enabled
+//
+// The clients not asking for a version get the events unchanged.
+package watchevents
+
+import (
+	"context"
+	"strconv"
+	"sync"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/queryparams"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/tombstones"
+)
+
+const (
+	// Key is the reserved network service name in the NSE NetworkServiceLabels carrying the event details
+	Key = "registry-memory.event"
+	// VersionParam is the query parameter with the requested event version
+	VersionParam = "event-version"
+	// VersionMetadata is the gRPC metadata key with the requested event version
+	VersionMetadata = "registry-memory-event-version"
+	// Version is the latest supported event version
+	Version = 1
+
+	// historySize is the number of the last revisions kept per NSE name to find the revision of the delayed events
+	historySize = 8
+)
+
+// Event types
+const (
+	TypeRegistered = "registered"
+	TypeUpdated    = "updated"
+	TypeRefreshed  = "refreshed"
+	TypeDeleted    = "deleted"
+)
+
+type options struct {
+	tombstones *tombstones.Store
+}
+
+// Option is an option for the watchevents chain element
+type Option func(o *options)
+
+// WithTombstones sets the tombstones store providing the reason of the deleted events
+func WithTombstones(store *tombstones.Store) Option {
+	return func(o *options) {
+		o.tombstones = store
+	}
+}
+
+type revision struct {
+	nse     *registry.NetworkServiceEndpoint
+	deleted bool
+	value   uint64
+}
+
+type watchEventsNSEServer struct {
+	options
+	mu       sync.Mutex
+	sequence uint64
+	history  map[string][]revision
+}
+
+// NewNetworkServiceEndpointRegistryServer creates a new NSE server chain element annotating the watch events. It
+// should follow the queryparams chain element.
+func NewNetworkServiceEndpointRegistryServer(opts ...Option) registry.NetworkServiceEndpointRegistryServer {
+	s := &watchEventsNSEServer{
+		history: make(map[string][]revision),
+	}
+	for _, opt := range opts {
+		opt(&s.options)
+	}
+	return s
+}
+
+func (s *watchEventsNSEServer) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*registry.NetworkServiceEndpoint, error) {
+	resp, err := next.NetworkServiceEndpointRegistryServer(ctx).Register(ctx, nse)
+	if err != nil {
+		return nil, err
+	}
+	s.store(resp, false)
+	return resp, nil
+}
+
+func (s *watchEventsNSEServer) Find(query *registry.NetworkServiceEndpointQuery, server registry.NetworkServiceEndpointRegistry_FindServer) error {
+	version := requestedVersion(server.Context())
+	if !query.GetWatch() || version <= 0 {
+		return next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, server)
+	}
+	if version > Version {
+		version = Version
+	}
+	return next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, &eventsFindServer{
+		NetworkServiceEndpointRegistry_FindServer: server,
+		server:  s,
+		version: strconv.Itoa(version),
+		sent:    make(map[string]*registry.NetworkServiceEndpoint),
+	})
+}
+
+func (s *watchEventsNSEServer) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*empty.Empty, error) {
+	resp, err := next.NetworkServiceEndpointRegistryServer(ctx).Unregister(ctx, nse)
+	if err != nil {
+		return nil, err
+	}
+	s.store(nse, true)
+	return resp, nil
+}
+
+// store assigns the next revision to the NSE change
+func (s *watchEventsNSEServer) store(nse *registry.NetworkServiceEndpoint, deleted bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.sequence++
+	history := append(s.history[nse.GetName()], revision{nse: nse.Clone(), deleted: deleted, value: s.sequence})
+	if len(history) > historySize {
+		history = history[len(history)-historySize:]
+	}
+	s.history[nse.GetName()] = history
+}
+
+// revision returns the revision of the latest change matching the event. The events are sent asynchronously, so the
+// NSE may have changed again by the time it is sent.
+func (s *watchEventsNSEServer) revision(nse *registry.NetworkServiceEndpoint, deleted bool) (uint64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	history := s.history[nse.GetName()]
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].deleted == deleted && (deleted || proto.Equal(history[i].nse, nse)) {
+			return history[i].value, true
+		}
+	}
+	return 0, false
+}
+
+// requestedVersion returns the event version requested with the query parameter or the metadata, 0 if none
+func requestedVersion(ctx context.Context) int {
+	value, ok := queryparams.FromContext(ctx)[VersionParam]
+	if !ok {
+		md, _ := metadata.FromIncomingContext(ctx)
+		if values := md.Get(VersionMetadata); len(values) > 0 {
+			value = values[0]
+		}
+	}
+	version, err := strconv.Atoi(value)
+	if err != nil {
+		return 0
+	}
+	return version
+}
+
+type eventsFindServer struct {
+	registry.NetworkServiceEndpointRegistry_FindServer
+	server  *watchEventsNSEServer
+	version string
+	// sent are the last NSEs sent to the stream, without the event details
+	sent map[string]*registry.NetworkServiceEndpoint
+}
+
+func (s *eventsFindServer) Send(resp *registry.NetworkServiceEndpointResponse) error {
+	nse := resp.GetNetworkServiceEndpoint()
+	labels := map[string]string{
+		"version": s.version,
+		"type":    s.eventType(resp),
+	}
+	if value, ok := s.server.revision(nse, resp.GetDeleted()); ok {
+		labels["revision"] = strconv.FormatUint(value, 10)
+	}
+	if resp.GetDeleted() && s.server.tombstones != nil {
+		labels["reason"] = string(s.server.tombstones.Reason(nse.GetName()))
+	}
+
+	nse = nse.Clone()
+	if nse.NetworkServiceLabels == nil {
+		nse.NetworkServiceLabels = make(map[string]*registry.NetworkServiceLabels)
+	}
+	nse.NetworkServiceLabels[Key] = &registry.NetworkServiceLabels{Labels: labels}
+	return s.NetworkServiceEndpointRegistry_FindServer.Send(&registry.NetworkServiceEndpointResponse{
+		NetworkServiceEndpoint: nse,
+		Deleted:                resp.GetDeleted(),
+	})
+}
+
+func (s *eventsFindServer) eventType(resp *registry.NetworkServiceEndpointResponse) string {
+	nse := resp.GetNetworkServiceEndpoint()
+	prev, ok := s.sent[nse.GetName()]
+	if resp.GetDeleted() {
+		delete(s.sent, nse.GetName())
+		return TypeDeleted
+	}
+	s.sent[nse.GetName()] = nse.Clone()
+	if !ok {
+		return TypeRegistered
+	}
+	a, b := prev.Clone(), nse.Clone()
+	a.ExpirationTime, b.ExpirationTime = nil, nil
+	if proto.Equal(a, b) {
+		return TypeRefreshed
+	}
+	return TypeUpdated
+}
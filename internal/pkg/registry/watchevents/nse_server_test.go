@@ -0,0 +1,112 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watchevents_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/chain"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/queryparams"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/watchevents"
+)
+
+func watch(ctx context.Context, t *testing.T, client registry.NetworkServiceEndpointRegistryClient, params map[string]string) registry.NetworkServiceEndpointRegistry_FindClient {
+	query := &registry.NetworkServiceEndpointQuery{
+		NetworkServiceEndpoint: new(registry.NetworkServiceEndpoint),
+		Watch:                  true,
+	}
+	if params != nil {
+		query.NetworkServiceEndpoint.NetworkServiceLabels = map[string]*registry.NetworkServiceLabels{
+			queryparams.Key: {Labels: params},
+		}
+	}
+	stream, err := client.Find(ctx, query)
+	require.NoError(t, err)
+	return stream
+}
+
+type event struct {
+	eventType string
+	revision  string
+}
+
+func requireEvents(t *testing.T, newStream, oldStream registry.NetworkServiceEndpointRegistry_FindClient, events ...event) {
+	for i, expected := range events {
+		resp, err := newStream.Recv()
+		require.NoError(t, err)
+		labels := resp.GetNetworkServiceEndpoint().GetNetworkServiceLabels()[watchevents.Key].GetLabels()
+		require.Equal(t, "1", labels["version"], i)
+		require.Equal(t, expected.eventType, labels["type"], i)
+		require.Equal(t, expected.revision, labels["revision"], i)
+
+		resp, err = oldStream.Recv()
+		require.NoError(t, err)
+		require.NotContains(t, resp.GetNetworkServiceEndpoint().GetNetworkServiceLabels(), watchevents.Key, i)
+	}
+}
+
+func TestWatchEventsNSEServer(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		queryparams.NewNetworkServiceEndpointRegistryServer(),
+		watchevents.NewNetworkServiceEndpointRegistryServer(),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+
+	expirationTime := time.Now().Add(time.Minute)
+	nse := &registry.NetworkServiceEndpoint{
+		Name:                "nse",
+		NetworkServiceNames: []string{"ns"},
+		ExpirationTime:      timestamppb.New(expirationTime),
+	}
+	_, err := client.Register(ctx, nse.Clone())
+	require.NoError(t, err)
+
+	// The watchers are subscribed once they get the initial NSEs
+	newStream := watch(ctx, t, client, map[string]string{watchevents.VersionParam: "2"})
+	oldStream := watch(ctx, t, client, nil)
+	requireEvents(t, newStream, oldStream, event{watchevents.TypeRegistered, "1"})
+
+	nse.ExpirationTime = timestamppb.New(expirationTime.Add(time.Minute))
+	_, err = client.Register(ctx, nse.Clone())
+	require.NoError(t, err)
+
+	nse.NetworkServiceNames = []string{"ns", "ns-2"}
+	_, err = client.Register(ctx, nse.Clone())
+	require.NoError(t, err)
+
+	_, err = client.Unregister(ctx, nse.Clone())
+	require.NoError(t, err)
+
+	requireEvents(t, newStream, oldStream,
+		event{watchevents.TypeRefreshed, "2"},
+		event{watchevents.TypeUpdated, "3"},
+		event{watchevents.TypeDeleted, "4"},
+	)
+}
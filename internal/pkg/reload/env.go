@@ -0,0 +1,170 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reload
+
+import (
+	"encoding"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/kelseyhightower/envconfig"
+	"github.com/pkg/errors"
+)
+
+// Lookup returns the value of the variable named by key and true, or false if the variable is not set
+type Lookup func(key string) (string, bool)
+
+// FromMap returns the lookup of the vars
+func FromMap(vars map[string]string) Lookup {
+	return func(key string) (string, bool) {
+		value, ok := vars[key]
+		return value, ok
+	}
+}
+
+// Layers returns the lookup of the first of the lookups setting the variable, so each lookup overrides the next ones
+func Layers(lookups ...Lookup) Lookup {
+	return func(key string) (string, bool) {
+		for _, lookup := range lookups {
+			if value, ok := lookup(key); ok {
+				return value, true
+			}
+		}
+		return "", false
+	}
+}
+
+// field is a spec field with the variable names and the tags envconfig reads it with
+type field struct {
+	key   string
+	alt   string
+	value reflect.Value
+	tags  reflect.StructTag
+}
+
+// Process loads spec like envconfig.Process(prefix, spec), but with the variables from lookup: a reload never
+// changes the process environment the other goroutines read. The variable names are the envconfig ones.
+func Process(prefix string, spec interface{}, lookup Lookup) error {
+	var fields []field
+	tmpl := template.Must(template.New("fields").Funcs(template.FuncMap{
+		"field": func(key, alt string, value reflect.Value, tags reflect.StructTag) string {
+			fields = append(fields, field{key: key, alt: alt, value: value, tags: tags})
+			return ""
+		},
+	}).Parse(`{{range .}}{{field .Key .Alt .Field .Tags}}{{end}}`))
+	if err := envconfig.Usaget(prefix, spec, io.Discard, tmpl); err != nil {
+		return err
+	}
+
+	for _, f := range fields {
+		value, ok := lookup(f.key)
+		if !ok && f.alt != "" {
+			value, ok = lookup(f.alt)
+		}
+		if def := f.tags.Get("default"); !ok && def != "" {
+			value, ok = def, true
+		}
+		if !ok {
+			if required, _ := strconv.ParseBool(f.tags.Get("required")); required {
+				return errors.Errorf("required key %s missing value", f.key)
+			}
+			continue
+		}
+		if err := decode(value, f.value); err != nil {
+			return errors.Wrapf(err, "failed to parse %s=%q", f.key, value)
+		}
+	}
+	return nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// decode sets the field to the value parsed the way envconfig parses it
+func decode(value string, field reflect.Value) error {
+	if field.Kind() == reflect.Ptr {
+		if field.IsNil() {
+			field.Set(reflect.New(field.Type().Elem()))
+		}
+		field = field.Elem()
+	}
+	switch d := field.Addr().Interface().(type) {
+	case envconfig.Decoder:
+		return d.Decode(value)
+	case envconfig.Setter:
+		return d.Set(value)
+	case encoding.TextUnmarshaler:
+		return d.UnmarshalText([]byte(value))
+	case encoding.BinaryUnmarshaler:
+		return d.UnmarshalBinary([]byte(value))
+	}
+
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if field.Type() == durationType {
+			d, err := time.ParseDuration(value)
+			if err != nil {
+				return err
+			}
+			field.SetInt(int64(d))
+			return nil
+		}
+		i, err := strconv.ParseInt(value, 0, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 0, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	case reflect.Slice:
+		slice := reflect.MakeSlice(field.Type(), 0, 0)
+		if strings.TrimSpace(value) != "" {
+			values := strings.Split(value, ",")
+			slice = reflect.MakeSlice(field.Type(), len(values), len(values))
+			for i, v := range values {
+				if err := decode(v, slice.Index(i)); err != nil {
+					return err
+				}
+			}
+		}
+		field.Set(slice)
+	default:
+		return errors.Errorf("unsupported type %s", field.Type())
+	}
+	return nil
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package reload provides tools for reloading configuration at runtime
+package reload
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const checkPeriod = 5 * time.Second
+
+// Notify calls onReload each time the process receives SIGHUP or the modification time of the file changes.
+// Empty filePath disables watching the file. Notify stops watching when ctx is done.
+func Notify(ctx context.Context, filePath string, onReload func()) {
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, syscall.SIGHUP)
+
+	go func() {
+		defer signal.Stop(sigCh)
+
+		ticker := time.NewTicker(checkPeriod)
+		defer ticker.Stop()
+
+		lastModTime := modTime(filePath)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-sigCh:
+				onReload()
+			case <-ticker.C:
+				if filePath == "" {
+					continue
+				}
+				if t := modTime(filePath); !t.Equal(lastModTime) {
+					lastModTime = t
+					onReload()
+				}
+			}
+		}
+	}()
+}
+
+func modTime(filePath string) time.Time {
+	if filePath == "" {
+		return time.Time{}
+	}
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
+
+// ParseEnvFile returns the variables of the file containing KEY=VALUE lines. Empty lines and lines starting with '#'
+// are skipped.
+func ParseEnvFile(filePath string) (map[string]string, error) {
+	// #nosec G304 - the file path comes from the configuration
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to open config file %s", filePath)
+	}
+	defer func() { _ = f.Close() }()
+
+	vars := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, errors.Errorf("%s:%d: expected KEY=VALUE, got %q", filePath, lineNumber, line)
+		}
+		vars[strings.TrimSpace(key)] = strings.Trim(strings.TrimSpace(value), `"'`)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Wrapf(err, "failed to read config file %s", filePath)
+	}
+	return vars, nil
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reload_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/reload"
+)
+
+func TestParseEnvFile(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "config.env")
+	require.NoError(t, os.WriteFile(filePath, []byte(`
+# comment
+RELOAD_TEST_LOG_LEVEL=DEBUG
+RELOAD_TEST_POLICIES = "a.rego,b.rego"
+`), 0o600))
+
+	vars, err := reload.ParseEnvFile(filePath)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		"RELOAD_TEST_LOG_LEVEL": "DEBUG",
+		"RELOAD_TEST_POLICIES":  "a.rego,b.rego",
+	}, vars)
+}
+
+func TestParseEnvFile_Invalid(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "config.env")
+	require.NoError(t, os.WriteFile(filePath, []byte("RELOAD_TEST_LOG_LEVEL\n"), 0o600))
+
+	_, err := reload.ParseEnvFile(filePath)
+	require.Error(t, err)
+	_, err = reload.ParseEnvFile(filepath.Join(t.TempDir(), "missing.env"))
+	require.Error(t, err)
+}
+
+type testConfig struct {
+	LogLevel   string        `default:"INFO" split_words:"true"`
+	Policies   []string      `default:"a.rego"`
+	Timeout    time.Duration `default:"1s"`
+	MaxEntries int           `split_words:"true"`
+	Enabled    bool          `envconfig:"RELOAD_TEST_ENABLED"`
+}
+
+func TestProcess(t *testing.T) {
+	t.Setenv("RELOAD_TEST_LOG_LEVEL", "WARN")
+
+	vars := map[string]string{
+		"RELOAD_TEST_LOG_LEVEL":   "DEBUG",
+		"RELOAD_TEST_POLICIES":    "a.rego,b.rego",
+		"RELOAD_TEST_TIMEOUT":     "5s",
+		"RELOAD_TEST_MAX_ENTRIES": "10",
+		"RELOAD_TEST_ENABLED":     "true",
+	}
+	config := new(testConfig)
+	require.NoError(t, reload.Process("reload_test", config, reload.FromMap(vars)))
+	require.Equal(t, &testConfig{
+		LogLevel:   "DEBUG",
+		Policies:   []string{"a.rego", "b.rego"},
+		Timeout:    5 * time.Second,
+		MaxEntries: 10,
+		Enabled:    true,
+	}, config)
+
+	// The process environment is not changed
+	require.Equal(t, "WARN", os.Getenv("RELOAD_TEST_LOG_LEVEL"))
+	_, ok := os.LookupEnv("RELOAD_TEST_POLICIES")
+	require.False(t, ok)
+
+	// The unset variables fall back to the next layers and to the defaults
+	config = new(testConfig)
+	require.NoError(t, reload.Process("reload_test", config, reload.Layers(
+		reload.FromMap(map[string]string{"RELOAD_TEST_MAX_ENTRIES": "5"}),
+		os.LookupEnv)))
+	require.Equal(t, &testConfig{
+		LogLevel:   "WARN",
+		Policies:   []string{"a.rego"},
+		Timeout:    time.Second,
+		MaxEntries: 5,
+	}, config)
+
+	require.Error(t, reload.Process("reload_test", new(testConfig), reload.FromMap(map[string]string{
+		"RELOAD_TEST_TIMEOUT": "invalid",
+	})))
+}
+
+func TestNotify_SIGHUP(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no SIGHUP on windows")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	reloaded := make(chan struct{}, 1)
+	reload.Notify(ctx, "", func() {
+		reloaded <- struct{}{}
+	})
+
+	process, err := os.FindProcess(os.Getpid())
+	require.NoError(t, err)
+	require.NoError(t, process.Signal(syscall.SIGHUP))
+
+	select {
+	case <-reloaded:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no reload on SIGHUP")
+	}
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package revocation
+
+import (
+	"context"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/log"
+)
+
+// Expunge unregisters all NSs and NSEs having a revoked SPIFFE ID in their path ids. The clients are expected to
+// call the registry chain in-process.
+func Expunge(ctx context.Context, l *List, nsClient registry.NetworkServiceRegistryClient, nseClient registry.NetworkServiceEndpointRegistryClient) {
+	logger := log.FromContext(ctx).WithField("audit", "revocation")
+
+	nseStream, err := nseClient.Find(ctx, &registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: new(registry.NetworkServiceEndpoint)})
+	if err != nil {
+		logger.Errorf("failed to find NSEs to expunge: %s", err.Error())
+	} else {
+		for _, nse := range registry.ReadNetworkServiceEndpointList(nseStream) {
+			if id, ok := l.revokedPathID(nse.GetPathIds()); ok {
+				if _, unregisterErr := nseClient.Unregister(ctx, nse); unregisterErr != nil {
+					logger.Errorf("failed to expunge NSE %s registered by revoked %s: %s", nse.GetName(), id, unregisterErr.Error())
+					continue
+				}
+				logger.Warnf("expunged NSE %s registered by revoked %s", nse.GetName(), id)
+			}
+		}
+	}
+
+	nsStream, err := nsClient.Find(ctx, &registry.NetworkServiceQuery{NetworkService: new(registry.NetworkService)})
+	if err != nil {
+		logger.Errorf("failed to find NSs to expunge: %s", err.Error())
+		return
+	}
+	for _, ns := range registry.ReadNetworkServiceList(nsStream) {
+		if id, ok := l.revokedPathID(ns.GetPathIds()); ok {
+			if _, unregisterErr := nsClient.Unregister(ctx, ns); unregisterErr != nil {
+				logger.Errorf("failed to expunge NS %s registered by revoked %s: %s", ns.GetName(), id, unregisterErr.Error())
+				continue
+			}
+			logger.Warnf("expunged NS %s registered by revoked %s", ns.GetName(), id)
+		}
+	}
+}
+
+func (l *List) revokedPathID(pathIDs []string) (string, bool) {
+	for _, id := range pathIDs {
+		if l.IsRevokedID(id) {
+			return id, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package revocation
+
+import (
+	"context"
+	"crypto/x509"
+
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/log"
+)
+
+// VerifyPeerCertificate returns tls.Config.VerifyPeerCertificate rejecting revoked peers after the verification
+// done by verify (can be nil)
+func (l *List) VerifyPeerCertificate(verify func([][]byte, [][]*x509.Certificate) error) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, verifiedChains [][]*x509.Certificate) error {
+		if verify != nil {
+			if err := verify(rawCerts, verifiedChains); err != nil {
+				return err
+			}
+		}
+		if len(rawCerts) == 0 {
+			return nil
+		}
+		cert, err := x509.ParseCertificate(rawCerts[0])
+		if err != nil {
+			return errors.Wrap(err, "failed to parse peer certificate")
+		}
+		if l.IsRevoked(cert) {
+			log.L().WithField("audit", "revocation").Warnf("rejected TLS handshake of revoked peer %v (serial %s)",
+				cert.URIs, cert.SerialNumber.Text(16))
+			return errors.Errorf("peer certificate %s is revoked", cert.SerialNumber.Text(16))
+		}
+		return nil
+	}
+}
+
+// UnaryServerInterceptor returns a server interceptor rejecting calls from revoked peers with PermissionDenied
+func (l *List) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if err := l.check(ctx, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerInterceptor returns a server interceptor rejecting streams from revoked peers with PermissionDenied
+func (l *List) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := l.check(ss.Context(), info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+func (l *List) check(ctx context.Context, method string) error {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(tlsInfo.State.PeerCertificates) == 0 {
+		return nil
+	}
+	if cert := tlsInfo.State.PeerCertificates[0]; l.IsRevoked(cert) {
+		log.FromContext(ctx).WithField("audit", "revocation").Warnf("rejected %s from revoked peer %v (serial %s)",
+			method, cert.URIs, cert.SerialNumber.Text(16))
+		return status.Errorf(codes.PermissionDenied, "peer certificate %s is revoked", cert.SerialNumber.Text(16))
+	}
+	return nil
+}
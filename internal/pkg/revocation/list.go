@@ -0,0 +1,122 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package revocation provides a reloadable list of revoked identities (SPIFFE IDs or certificate serial numbers)
+package revocation
+
+import (
+	"bufio"
+	"crypto/x509"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// List is a list of revoked SPIFFE IDs and certificate serial numbers
+type List struct {
+	mu        sync.RWMutex
+	spiffeIDs map[string]struct{}
+	serials   map[string]struct{}
+}
+
+// NewList creates a new empty revocation list
+func NewList() *List {
+	return &List{
+		spiffeIDs: make(map[string]struct{}),
+		serials:   make(map[string]struct{}),
+	}
+}
+
+// Load replaces the list content with the file content. The file contains one entry per line: a SPIFFE ID
+// (spiffe://...) or a hex certificate serial number (with or without ':' separators). Empty lines and lines
+// starting with '#' are skipped.
+func (l *List) Load(filePath string) error {
+	// #nosec G304 - the file path comes from the configuration
+	f, err := os.Open(filePath)
+	if err != nil {
+		return errors.Wrapf(err, "failed to open revocation list %s", filePath)
+	}
+	defer func() { _ = f.Close() }()
+
+	spiffeIDs := make(map[string]struct{})
+	serials := make(map[string]struct{})
+
+	scanner := bufio.NewScanner(f)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		case strings.HasPrefix(line, "spiffe://"):
+			spiffeIDs[line] = struct{}{}
+		default:
+			serial, ok := new(big.Int).SetString(strings.ReplaceAll(line, ":", ""), 16)
+			if !ok {
+				return errors.Errorf("%s:%d: expected SPIFFE ID or hex serial number, got %q", filePath, lineNumber, line)
+			}
+			serials[serial.Text(16)] = struct{}{}
+		}
+	}
+	if scanErr := scanner.Err(); scanErr != nil {
+		return errors.Wrapf(scanErr, "failed to read revocation list %s", filePath)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.spiffeIDs = spiffeIDs
+	l.serials = serials
+
+	return nil
+}
+
+// IsRevokedID returns true if the SPIFFE ID is revoked
+func (l *List) IsRevokedID(spiffeID string) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	_, ok := l.spiffeIDs[spiffeID]
+	return ok
+}
+
+// IsRevoked returns true if the certificate serial number or any of its SPIFFE IDs is revoked
+func (l *List) IsRevoked(cert *x509.Certificate) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if cert.SerialNumber != nil {
+		if _, ok := l.serials[cert.SerialNumber.Text(16)]; ok {
+			return true
+		}
+	}
+	for _, uri := range cert.URIs {
+		if _, ok := l.spiffeIDs[uri.String()]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Len returns the number of entries in the list
+func (l *List) Len() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return len(l.spiffeIDs) + len(l.serials)
+}
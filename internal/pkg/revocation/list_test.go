@@ -0,0 +1,59 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package revocation_test
+
+import (
+	"crypto/x509"
+	"math/big"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/revocation"
+)
+
+func TestList_Load(t *testing.T) {
+	filePath := filepath.Join(t.TempDir(), "revoked")
+	require.NoError(t, os.WriteFile(filePath, []byte(`
+# revoked NSE
+spiffe://example.org/nse-1
+0A:BC:DE
+`), 0o600))
+
+	l := revocation.NewList()
+	require.NoError(t, l.Load(filePath))
+	require.Equal(t, 2, l.Len())
+
+	require.True(t, l.IsRevokedID("spiffe://example.org/nse-1"))
+	require.False(t, l.IsRevokedID("spiffe://example.org/nse-2"))
+
+	nse2, err := url.Parse("spiffe://example.org/nse-2")
+	require.NoError(t, err)
+	require.True(t, l.IsRevoked(&x509.Certificate{SerialNumber: big.NewInt(0xabcde), URIs: []*url.URL{nse2}}))
+	require.False(t, l.IsRevoked(&x509.Certificate{SerialNumber: big.NewInt(1), URIs: []*url.URL{nse2}}))
+
+	require.NoError(t, os.WriteFile(filePath, []byte("spiffe://example.org/nse-2\n"), 0o600))
+	require.NoError(t, l.Load(filePath))
+	require.False(t, l.IsRevokedID("spiffe://example.org/nse-1"))
+	require.True(t, l.IsRevoked(&x509.Certificate{SerialNumber: big.NewInt(1), URIs: []*url.URL{nse2}}))
+
+	require.NoError(t, os.WriteFile(filePath, []byte("not-a-serial\n"), 0o600))
+	require.Error(t, l.Load(filePath))
+}
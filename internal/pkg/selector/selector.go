@@ -0,0 +1,164 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package selector provides a label selector language:
+//
+//	env=prod           label equals the value ("==" is also accepted)
+//	env!=prod          label is missing or doesn't equal the value
+//	env in (prod,dev)  label equals one of the values
+//	env notin (dev)    label is missing or equals none of the values
+//	canary             label exists
+//	!canary            label doesn't exist
+//
+// Requirements are separated by commas and all of them must match.
+package selector
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type operator int
+
+const (
+	opEquals operator = iota
+	opNotEquals
+	opIn
+	opNotIn
+	opExists
+	opNotExists
+)
+
+type requirement struct {
+	key    string
+	op     operator
+	values map[string]struct{}
+}
+
+// Selector is a parsed label selector
+type Selector []requirement
+
+// Parse parses the label selector
+func Parse(selector string) (Selector, error) {
+	var result Selector
+	for _, term := range split(selector) {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		r, err := parseRequirement(term)
+		if err != nil {
+			return nil, errors.Wrapf(err, "invalid selector %q", selector)
+		}
+		result = append(result, r)
+	}
+	return result, nil
+}
+
+// Matches returns true if labels match all the selector requirements
+func (s Selector) Matches(labels map[string]string) bool {
+	for _, r := range s {
+		value, ok := labels[r.key]
+		_, in := r.values[value]
+		var matches bool
+		switch r.op {
+		case opEquals, opIn:
+			matches = ok && in
+		case opNotEquals, opNotIn:
+			matches = !ok || !in
+		case opExists:
+			matches = ok
+		case opNotExists:
+			matches = !ok
+		}
+		if !matches {
+			return false
+		}
+	}
+	return true
+}
+
+// split splits the selector by the commas outside of the parentheses
+func split(selector string) []string {
+	var terms []string
+	var depth, start int
+	for i, c := range selector {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				terms = append(terms, selector[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(terms, selector[start:])
+}
+
+func parseRequirement(term string) (requirement, error) {
+	if strings.HasPrefix(term, "!") && !strings.Contains(term, "=") {
+		return newRequirement(term[1:], opNotExists)
+	}
+	for _, setOp := range []struct {
+		keyword string
+		op      operator
+	}{{" notin ", opNotIn}, {" in ", opIn}} {
+		if i := strings.Index(term, setOp.keyword); i >= 0 {
+			r, err := newRequirement(term[:i], setOp.op)
+			if err != nil {
+				return r, err
+			}
+			set := strings.TrimSpace(term[i+len(setOp.keyword):])
+			if !strings.HasPrefix(set, "(") || !strings.HasSuffix(set, ")") {
+				return r, errors.Errorf("expected (values) after %s", strings.TrimSpace(setOp.keyword))
+			}
+			for _, value := range strings.Split(set[1:len(set)-1], ",") {
+				r.values[strings.TrimSpace(value)] = struct{}{}
+			}
+			return r, nil
+		}
+	}
+	for _, valueOp := range []struct {
+		token string
+		op    operator
+	}{{"!=", opNotEquals}, {"==", opEquals}, {"=", opEquals}} {
+		if i := strings.Index(term, valueOp.token); i >= 0 {
+			r, err := newRequirement(term[:i], valueOp.op)
+			if err != nil {
+				return r, err
+			}
+			r.values[strings.TrimSpace(term[i+len(valueOp.token):])] = struct{}{}
+			return r, nil
+		}
+	}
+	return newRequirement(term, opExists)
+}
+
+func newRequirement(key string, op operator) (requirement, error) {
+	key = strings.TrimSpace(key)
+	if key == "" || strings.ContainsAny(key, " \t()!=") {
+		return requirement{}, errors.Errorf("invalid label key %q", key)
+	}
+	return requirement{
+		key:    key,
+		op:     op,
+		values: make(map[string]struct{}),
+	}, nil
+}
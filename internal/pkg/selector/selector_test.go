@@ -0,0 +1,53 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package selector_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/selector"
+)
+
+func TestSelector(t *testing.T) {
+	labels := map[string]string{"env": "prod", "zone": "zone-a"}
+
+	for _, tc := range []struct {
+		selector string
+		matches  bool
+	}{
+		{"", true},
+		{"env=prod", true},
+		{"env==staging", false},
+		{"env!=staging", true},
+		{"env in (prod, staging), !canary", true},
+		{"env notin (prod,staging)", false},
+		{"zone, env in (prod)", true},
+		{"canary", false},
+		{"canary!=true", true},
+	} {
+		s, err := selector.Parse(tc.selector)
+		require.NoError(t, err, tc.selector)
+		require.Equal(t, tc.matches, s.Matches(labels), tc.selector)
+	}
+
+	for _, invalid := range []string{"=prod", "env in prod", "env in (prod", "!"} {
+		_, err := selector.Parse(invalid)
+		require.Error(t, err, invalid)
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sizemetrics
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/grpc/stats"
+)
+
+const (
+	compressionAttribute = "compression"
+	noCompression        = "identity"
+)
+
+type compressionKey struct{}
+
+// CompressionHandler is the gRPC server stats handler counting the sent payload bytes before and after the compression
+// by the compression algorithm of the call: rpc.server.sent.bytes and rpc.server.sent.compressed_bytes
+type CompressionHandler struct {
+	sent           metric.Int64Counter
+	sentCompressed metric.Int64Counter
+}
+
+// NewCompressionHandler creates a new CompressionHandler with the global Open Telemetry meter provider
+func NewCompressionHandler() (*CompressionHandler, error) {
+	meter := otel.Meter(meterName)
+	sent, err := meter.Int64Counter("rpc.server.sent.bytes",
+		metric.WithUnit("By"),
+		metric.WithDescription("Size of the sent payloads before the compression by compression"))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create sent bytes counter")
+	}
+	sentCompressed, err := meter.Int64Counter("rpc.server.sent.compressed_bytes",
+		metric.WithUnit("By"),
+		metric.WithDescription("Size of the sent payloads after the compression by compression"))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create sent compressed bytes counter")
+	}
+	return &CompressionHandler{
+		sent:           sent,
+		sentCompressed: sentCompressed,
+	}, nil
+}
+
+// TagRPC adds the holder of the call compression algorithm to ctx
+func (h *CompressionHandler) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return context.WithValue(ctx, compressionKey{}, new(atomic.Value))
+}
+
+// HandleRPC stores the compression algorithm of the call from its header and counts the sent payloads
+func (h *CompressionHandler) HandleRPC(ctx context.Context, s stats.RPCStats) {
+	compression, ok := ctx.Value(compressionKey{}).(*atomic.Value)
+	if !ok {
+		return
+	}
+	switch s := s.(type) {
+	case *stats.InHeader:
+		if s.Compression != "" {
+			compression.Store(s.Compression)
+		}
+	case *stats.OutPayload:
+		name, _ := compression.Load().(string)
+		if name == "" {
+			name = noCompression
+		}
+		attrs := metric.WithAttributes(attribute.String(compressionAttribute, name))
+		h.sent.Add(ctx, int64(s.Length), attrs)
+		h.sentCompressed.Add(ctx, int64(s.CompressedLength), attrs)
+	}
+}
+
+// TagConn returns ctx
+func (h *CompressionHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+// HandleConn does nothing
+func (h *CompressionHandler) HandleConn(context.Context, stats.ConnStats) {}
@@ -0,0 +1,133 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package sizemetrics provides gRPC server interceptors recording request and response message sizes per method
+// and peer identity
+package sizemetrics
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/peerid"
+)
+
+const (
+	meterName         = "registry-memory"
+	unknownIdentity   = "unknown"
+	methodAttribute   = "method"
+	identityAttribute = "identity"
+)
+
+// Recorder records request and response message sizes
+type Recorder struct {
+	requestSize  metric.Int64Histogram
+	responseSize metric.Int64Histogram
+}
+
+// NewRecorder creates a new Recorder with the global Open Telemetry meter provider
+func NewRecorder() (*Recorder, error) {
+	meter := otel.Meter(meterName)
+	requestSize, err := meter.Int64Histogram("rpc.server.request.size",
+		metric.WithUnit("By"),
+		metric.WithDescription("Size of the received request messages"))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create request size histogram")
+	}
+	responseSize, err := meter.Int64Histogram("rpc.server.response.size",
+		metric.WithUnit("By"),
+		metric.WithDescription("Size of the sent response messages"))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create response size histogram")
+	}
+	return &Recorder{
+		requestSize:  requestSize,
+		responseSize: responseSize,
+	}, nil
+}
+
+// UnaryServerInterceptor returns a server interceptor recording the request and response sizes
+func (r *Recorder) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		attrs := attributes(ctx, info.FullMethod)
+		record(ctx, r.requestSize, req, attrs)
+		resp, err := handler(ctx, req)
+		if err == nil {
+			record(ctx, r.responseSize, resp, attrs)
+		}
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor returns a server interceptor recording the sizes of the messages received and sent by the
+// stream
+func (r *Recorder) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &serverStream{
+			ServerStream: ss,
+			recorder:     r,
+			attrs:        attributes(ss.Context(), info.FullMethod),
+		})
+	}
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	recorder *Recorder
+	attrs    metric.MeasurementOption
+}
+
+func (s *serverStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+	record(s.Context(), s.recorder.requestSize, m, s.attrs)
+	return nil
+}
+
+func (s *serverStream) SendMsg(m interface{}) error {
+	if err := s.ServerStream.SendMsg(m); err != nil {
+		return err
+	}
+	record(s.Context(), s.recorder.responseSize, m, s.attrs)
+	return nil
+}
+
+func record(ctx context.Context, histogram metric.Int64Histogram, m interface{}, attrs metric.MeasurementOption) {
+	if msg, ok := m.(proto.Message); ok {
+		histogram.Record(ctx, int64(proto.Size(msg)), attrs)
+	}
+}
+
+func attributes(ctx context.Context, method string) metric.MeasurementOption {
+	return metric.WithAttributes(
+		attribute.String(methodAttribute, method),
+		attribute.String(identityAttribute, identity(ctx)))
+}
+
+// identity returns the SPIFFE ID of the peer
+func identity(ctx context.Context) string {
+	if id, ok := peerid.FromContext(ctx); ok {
+		return id.String()
+	}
+	return unknownIdentity
+}
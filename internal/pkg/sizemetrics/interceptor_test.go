@@ -0,0 +1,154 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sizemetrics_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/sizemetrics"
+)
+
+const nseMethod = "/registry.NetworkServiceEndpointRegistry/Register"
+
+type authInfo struct {
+	id spiffeid.ID
+}
+
+func (a authInfo) AuthType() string {
+	return "test"
+}
+
+func (a authInfo) SPIFFEID() spiffeid.ID {
+	return a.id
+}
+
+type serverStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	recv proto.Message
+}
+
+func (s *serverStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *serverStream) RecvMsg(m interface{}) error {
+	proto.Merge(m.(proto.Message), s.recv)
+	return nil
+}
+
+func (s *serverStream) SendMsg(interface{}) error {
+	return nil
+}
+
+// newRecorder returns the recorder with the global meter provider read by the returned reader
+func newRecorder(t *testing.T) (*sizemetrics.Recorder, sdkmetric.Reader) {
+	reader := sdkmetric.NewManualReader()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+
+	recorder, err := sizemetrics.NewRecorder()
+	require.NoError(t, err)
+	return recorder, reader
+}
+
+// histograms returns the sum and the count of the recorded sizes by histogram name
+func histograms(t *testing.T, reader sdkmetric.Reader, attrs ...attribute.KeyValue) map[string][2]int64 {
+	var rm metricdata.ResourceMetrics
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	result := make(map[string][2]int64)
+	want := attribute.NewSet(attrs...)
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			histogram, ok := m.Data.(metricdata.Histogram[int64])
+			if !ok {
+				continue
+			}
+			for _, dp := range histogram.DataPoints {
+				if dp.Attributes.Equals(&want) {
+					result[m.Name] = [2]int64{dp.Sum, int64(dp.Count)}
+				}
+			}
+		}
+	}
+	return result
+}
+
+func TestRecorder_Unary(t *testing.T) {
+	recorder, reader := newRecorder(t)
+
+	id := spiffeid.RequireFromString("spiffe://test.com/nse")
+	ctx := peer.NewContext(context.Background(), &peer.Peer{AuthInfo: authInfo{id: id}})
+	req := &registry.NetworkServiceEndpoint{Name: "nse-1", Url: "tcp://1.1.1.1:5001"}
+	resp := &registry.NetworkServiceEndpoint{Name: "nse-1", Url: "tcp://1.1.1.1:5001", NetworkServiceNames: []string{"ns-1"}}
+
+	_, err := recorder.UnaryServerInterceptor()(ctx, req, &grpc.UnaryServerInfo{FullMethod: nseMethod},
+		func(context.Context, interface{}) (interface{}, error) {
+			return resp, nil
+		})
+	require.NoError(t, err)
+
+	require.Equal(t, map[string][2]int64{
+		"rpc.server.request.size":  {int64(proto.Size(req)), 1},
+		"rpc.server.response.size": {int64(proto.Size(resp)), 1},
+	}, histograms(t, reader, attribute.String("method", nseMethod), attribute.String("identity", id.String())))
+}
+
+func TestRecorder_Stream(t *testing.T) {
+	recorder, reader := newRecorder(t)
+
+	const method = "/registry.NetworkServiceEndpointRegistry/Find"
+	query := &registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{Name: "nse"}}
+	resps := []*registry.NetworkServiceEndpointResponse{
+		{NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{Name: "nse-1"}},
+		{NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{Name: "nse-22"}},
+	}
+
+	// A peer with no identity is recorded as unknown
+	stream := &serverStream{ctx: context.Background(), recv: query}
+	err := recorder.StreamServerInterceptor()(nil, stream, &grpc.StreamServerInfo{FullMethod: method},
+		func(_ interface{}, ss grpc.ServerStream) error {
+			if recvErr := ss.RecvMsg(new(registry.NetworkServiceEndpointQuery)); recvErr != nil {
+				return recvErr
+			}
+			for _, resp := range resps {
+				if sendErr := ss.SendMsg(resp); sendErr != nil {
+					return sendErr
+				}
+			}
+			return nil
+		})
+	require.NoError(t, err)
+
+	require.Equal(t, map[string][2]int64{
+		"rpc.server.request.size":  {int64(proto.Size(query)), 1},
+		"rpc.server.response.size": {int64(proto.Size(resps[0]) + proto.Size(resps[1])), 2},
+	}, histograms(t, reader, attribute.String("method", method), attribute.String("identity", "unknown")))
+}
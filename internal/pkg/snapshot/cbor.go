@@ -0,0 +1,138 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+)
+
+// cborMagic is the self-described CBOR tag (RFC 8949, 3.4.6) starting the CBOR snapshots
+var cborMagic = []byte{0xd9, 0xd9, 0xf7}
+
+const (
+	// cborMaxNestedLevels is the least allowed limit, the snapshot is nested 2 levels deep
+	cborMaxNestedLevels = 4
+	// cborMaxEntries limits the NSs and the NSEs of a decoded snapshot
+	cborMaxEntries = 1 << 20
+)
+
+// cborSnapshot is the CBOR snapshot: a map with the integer keys of the binary protobuf NSs and NSEs
+type cborSnapshot struct {
+	NetworkServices         [][]byte `cbor:"1,keyasint,omitempty"`
+	NetworkServiceEndpoints [][]byte `cbor:"2,keyasint,omitempty"`
+}
+
+var (
+	cborEncMode = mustEncMode(cbor.CoreDetEncOptions())
+	cborDecMode = mustDecMode(cbor.DecOptions{
+		DupMapKey:         cbor.DupMapKeyEnforcedAPF,
+		MaxNestedLevels:   cborMaxNestedLevels,
+		MaxArrayElements:  cborMaxEntries,
+		IndefLength:       cbor.IndefLengthForbidden,
+		ExtraReturnErrors: cbor.ExtraDecErrorUnknownField,
+	})
+)
+
+type cborCodec struct{}
+
+func (c *cborCodec) Name() string {
+	return "cbor"
+}
+
+func (c *cborCodec) Encode(w io.Writer, s *Snapshot) error {
+	marshal := proto.MarshalOptions{Deterministic: true}
+	cs := new(cborSnapshot)
+	for _, ns := range s.NetworkServices {
+		data, err := marshal.Marshal(ns)
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal network service %s", ns.GetName())
+		}
+		cs.NetworkServices = append(cs.NetworkServices, data)
+	}
+	for _, nse := range s.NetworkServiceEndpoints {
+		data, err := marshal.Marshal(nse)
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal network service endpoint %s", nse.GetName())
+		}
+		cs.NetworkServiceEndpoints = append(cs.NetworkServiceEndpoints, data)
+	}
+
+	data, err := cborEncMode.Marshal(cs)
+	if err != nil {
+		return errors.Wrap(err, "failed to encode snapshot")
+	}
+	_, err = w.Write(append(append([]byte(nil), cborMagic...), data...))
+	return errors.Wrap(err, "failed to write snapshot")
+}
+
+func (c *cborCodec) Decode(r io.Reader) (*Snapshot, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read snapshot")
+	}
+	if !c.Detect(data) {
+		return nil, errors.New("not a cbor snapshot")
+	}
+	cs := new(cborSnapshot)
+	if err = cborDecMode.Unmarshal(data[len(cborMagic):], cs); err != nil {
+		return nil, errors.Wrap(err, "failed to read snapshot")
+	}
+
+	s := new(Snapshot)
+	for _, entry := range cs.NetworkServices {
+		ns := new(registry.NetworkService)
+		if err = proto.Unmarshal(entry, ns); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal network service")
+		}
+		s.NetworkServices = append(s.NetworkServices, ns)
+	}
+	for _, entry := range cs.NetworkServiceEndpoints {
+		nse := new(registry.NetworkServiceEndpoint)
+		if err = proto.Unmarshal(entry, nse); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal network service endpoint")
+		}
+		s.NetworkServiceEndpoints = append(s.NetworkServiceEndpoints, nse)
+	}
+	return s, nil
+}
+
+func (c *cborCodec) Detect(data []byte) bool {
+	return bytes.HasPrefix(data, cborMagic)
+}
+
+func mustEncMode(opts cbor.EncOptions) cbor.EncMode {
+	mode, err := opts.EncMode()
+	if err != nil {
+		panic(err)
+	}
+	return mode
+}
+
+func mustDecMode(opts cbor.DecOptions) cbor.DecMode {
+	mode, err := opts.DecMode()
+	if err != nil {
+		panic(err)
+	}
+	return mode
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+)
+
+type jsonSnapshot struct {
+	NetworkServices         []json.RawMessage `json:"network_services"`
+	NetworkServiceEndpoints []json.RawMessage `json:"network_service_endpoints"`
+}
+
+type jsonCodec struct{}
+
+func (c *jsonCodec) Name() string {
+	return "json"
+}
+
+func (c *jsonCodec) Encode(w io.Writer, s *Snapshot) error {
+	js := jsonSnapshot{
+		NetworkServices:         make([]json.RawMessage, 0, len(s.NetworkServices)),
+		NetworkServiceEndpoints: make([]json.RawMessage, 0, len(s.NetworkServiceEndpoints)),
+	}
+	for _, ns := range s.NetworkServices {
+		data, err := protojson.Marshal(ns)
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal network service %s", ns.GetName())
+		}
+		js.NetworkServices = append(js.NetworkServices, data)
+	}
+	for _, nse := range s.NetworkServiceEndpoints {
+		data, err := protojson.Marshal(nse)
+		if err != nil {
+			return errors.Wrapf(err, "failed to marshal network service endpoint %s", nse.GetName())
+		}
+		js.NetworkServiceEndpoints = append(js.NetworkServiceEndpoints, data)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return errors.Wrap(encoder.Encode(&js), "failed to write snapshot")
+}
+
+func (c *jsonCodec) Decode(r io.Reader) (*Snapshot, error) {
+	var js jsonSnapshot
+	if err := json.NewDecoder(r).Decode(&js); err != nil {
+		return nil, errors.Wrap(err, "failed to read snapshot")
+	}
+
+	s := new(Snapshot)
+	for _, data := range js.NetworkServices {
+		ns := new(registry.NetworkService)
+		if err := protojson.Unmarshal(data, ns); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal network service")
+		}
+		s.NetworkServices = append(s.NetworkServices, ns)
+	}
+	for _, data := range js.NetworkServiceEndpoints {
+		nse := new(registry.NetworkServiceEndpoint)
+		if err := protojson.Unmarshal(data, nse); err != nil {
+			return nil, errors.Wrap(err, "failed to unmarshal network service endpoint")
+		}
+		s.NetworkServiceEndpoints = append(s.NetworkServiceEndpoints, nse)
+	}
+	return s, nil
+}
+
+func (c *jsonCodec) Detect(data []byte) bool {
+	return bytes.HasPrefix(bytes.TrimSpace(data), []byte("{"))
+}
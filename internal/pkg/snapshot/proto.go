@@ -0,0 +1,110 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protodelim"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+)
+
+// protoMagic starts the proto snapshots, then each entry is a kind byte followed by the size delimited message
+const protoMagic = "NSMREGSNAP1\n"
+
+const (
+	kindNetworkService         byte = 's'
+	kindNetworkServiceEndpoint byte = 'e'
+)
+
+type protoCodec struct{}
+
+func (c *protoCodec) Name() string {
+	return "proto"
+}
+
+func (c *protoCodec) Encode(w io.Writer, s *Snapshot) error {
+	bw := bufio.NewWriter(w)
+	if _, err := bw.WriteString(protoMagic); err != nil {
+		return errors.Wrap(err, "failed to write snapshot")
+	}
+	for _, ns := range s.NetworkServices {
+		if err := writeEntry(bw, kindNetworkService, ns); err != nil {
+			return err
+		}
+	}
+	for _, nse := range s.NetworkServiceEndpoints {
+		if err := writeEntry(bw, kindNetworkServiceEndpoint, nse); err != nil {
+			return err
+		}
+	}
+	return errors.Wrap(bw.Flush(), "failed to write snapshot")
+}
+
+func (c *protoCodec) Decode(r io.Reader) (*Snapshot, error) {
+	br := bufio.NewReader(r)
+	magic := make([]byte, len(protoMagic))
+	if _, err := io.ReadFull(br, magic); err != nil || string(magic) != protoMagic {
+		return nil, errors.New("not a proto snapshot")
+	}
+
+	s := new(Snapshot)
+	for {
+		kind, err := br.ReadByte()
+		if errors.Is(err, io.EOF) {
+			return s, nil
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read snapshot")
+		}
+		switch kind {
+		case kindNetworkService:
+			ns := new(registry.NetworkService)
+			if unmarshalErr := protodelim.UnmarshalFrom(br, ns); unmarshalErr != nil {
+				return nil, errors.Wrap(unmarshalErr, "failed to read snapshot network service")
+			}
+			s.NetworkServices = append(s.NetworkServices, ns)
+		case kindNetworkServiceEndpoint:
+			nse := new(registry.NetworkServiceEndpoint)
+			if unmarshalErr := protodelim.UnmarshalFrom(br, nse); unmarshalErr != nil {
+				return nil, errors.Wrap(unmarshalErr, "failed to read snapshot network service endpoint")
+			}
+			s.NetworkServiceEndpoints = append(s.NetworkServiceEndpoints, nse)
+		default:
+			return nil, errors.Errorf("unknown snapshot entry kind: %q", kind)
+		}
+	}
+}
+
+func (c *protoCodec) Detect(data []byte) bool {
+	return bytes.HasPrefix(data, []byte(protoMagic))
+}
+
+func writeEntry(w *bufio.Writer, kind byte, m proto.Message) error {
+	if err := w.WriteByte(kind); err != nil {
+		return errors.Wrap(err, "failed to write snapshot")
+	}
+	if _, err := protodelim.MarshalTo(w, m); err != nil {
+		return errors.Wrap(err, "failed to write snapshot entry")
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package snapshot provides a registry state snapshot and its serialization formats
+package snapshot
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/pkg/errors"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+)
+
+// Snapshot is a point in time copy of the registry state
+type Snapshot struct {
+	NetworkServices         []*registry.NetworkService
+	NetworkServiceEndpoints []*registry.NetworkServiceEndpoint
+}
+
+// Codec encodes and decodes snapshots
+type Codec interface {
+	// Name is the codec name used in the configuration
+	Name() string
+	// Encode writes the snapshot to w
+	Encode(w io.Writer, s *Snapshot) error
+	// Decode reads the snapshot from r
+	Decode(r io.Reader) (*Snapshot, error)
+	// Detect returns true if data is in the codec format
+	Detect(data []byte) bool
+}
+
+var codecs = []Codec{
+	new(protoCodec),
+	new(jsonCodec),
+	new(cborCodec),
+}
+
+// CodecByName returns the codec with the name: "proto", "json" or "cbor"
+func CodecByName(name string) (Codec, error) {
+	for _, c := range codecs {
+		if c.Name() == name {
+			return c, nil
+		}
+	}
+	return nil, errors.Errorf("unknown snapshot format: %s", name)
+}
+
+// Decode decodes the snapshot detecting its format
+func Decode(data []byte) (*Snapshot, error) {
+	for _, c := range codecs {
+		if c.Detect(data) {
+			return c.Decode(bytes.NewReader(data))
+		}
+	}
+	return nil, errors.New("unknown snapshot format")
+}
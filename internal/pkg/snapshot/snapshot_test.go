@@ -0,0 +1,89 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snapshot_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/snapshot"
+)
+
+func TestCodecs(t *testing.T) {
+	s := &snapshot.Snapshot{
+		NetworkServices: []*registry.NetworkService{{Name: "ns-1", Payload: "IP"}},
+		NetworkServiceEndpoints: []*registry.NetworkServiceEndpoint{
+			{Name: "nse-1", Url: "tcp://1.1.1.1:5001", NetworkServiceNames: []string{"ns-1"}},
+			{Name: "nse-2", Url: "tcp://2.2.2.2:5001", NetworkServiceNames: []string{"ns-1"}},
+		},
+	}
+
+	for _, name := range []string{"proto", "json", "cbor"} {
+		codec, err := snapshot.CodecByName(name)
+		require.NoError(t, err)
+
+		buf := new(bytes.Buffer)
+		require.NoError(t, codec.Encode(buf, s))
+
+		decoded, err := snapshot.Decode(buf.Bytes())
+		require.NoError(t, err, name)
+		require.Len(t, decoded.NetworkServices, 1)
+		require.True(t, proto.Equal(s.NetworkServices[0], decoded.NetworkServices[0]))
+		require.Len(t, decoded.NetworkServiceEndpoints, 2)
+		for i := range s.NetworkServiceEndpoints {
+			require.True(t, proto.Equal(s.NetworkServiceEndpoints[i], decoded.NetworkServiceEndpoints[i]))
+		}
+	}
+
+	_, err := snapshot.CodecByName("yaml")
+	require.Error(t, err)
+	_, err = snapshot.Decode([]byte("garbage"))
+	require.Error(t, err)
+}
+
+func TestCBORCodec_Invalid(t *testing.T) {
+	codec, err := snapshot.CodecByName("cbor")
+	require.NoError(t, err)
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, codec.Encode(buf, &snapshot.Snapshot{
+		NetworkServices: []*registry.NetworkService{{Name: "ns-1", Payload: "IP"}},
+	}))
+	_, err = snapshot.Decode(buf.Bytes()[:buf.Len()-1])
+	require.Error(t, err, "truncated")
+
+	// Self-described tag, then {1: [[[[[[...]]]]]]}
+	nested := append([]byte{0xd9, 0xd9, 0xf7, 0xa1, 0x01}, bytes.Repeat([]byte{0x81}, 16)...)
+	_, err = snapshot.Decode(append(nested, 0x40))
+	var nestedErr *cbor.MaxNestedLevelError
+	require.ErrorAs(t, err, &nestedErr)
+
+	// Self-described tag, then {1: array of 2^20 + 1 byte strings}, the elements are not even there
+	_, err = snapshot.Decode([]byte{0xd9, 0xd9, 0xf7, 0xa1, 0x01, 0x9a, 0x00, 0x10, 0x00, 0x01})
+	var oversizedErr *cbor.MaxArrayElementsError
+	require.ErrorAs(t, err, &oversizedErr)
+
+	// Self-described tag, then {3: []}
+	_, err = snapshot.Decode([]byte{0xd9, 0xd9, 0xf7, 0xa1, 0x03, 0x80})
+	require.Error(t, err, "unknown key")
+}
@@ -0,0 +1,70 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package synced
+
+import (
+	"context"
+	"time"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/log"
+)
+
+// Check is a named check of the registry services, e.g. a dependency being reachable
+type Check struct {
+	// Name is the check name reported by Failures
+	Name string
+	// Services are the registry servers depending on the check, none means all the registry services
+	Services []interface{}
+	// Func returns the reason of the failure, or nil if the check passes
+	Func func(ctx context.Context) error
+}
+
+// RunChecks runs the checks right away, then every period until ctx is done, and reports the results to the
+// condition. Each check run times out after the period. The check failures and recoveries are logged.
+func (c *Condition) RunChecks(ctx context.Context, period time.Duration, checks ...Check) {
+	if len(checks) == 0 {
+		return
+	}
+	ticker := clock.FromContext(ctx).Ticker(period)
+	defer ticker.Stop()
+	for {
+		for _, check := range checks {
+			c.runCheck(ctx, period, check)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+		}
+	}
+}
+
+func (c *Condition) runCheck(ctx context.Context, timeout time.Duration, check Check) {
+	checkCtx, cancel := clock.FromContext(ctx).WithTimeout(ctx, timeout)
+	defer cancel()
+
+	err := check.Func(checkCtx)
+	if ctx.Err() != nil || !c.Report(check.Name, err, check.Services...) {
+		return
+	}
+	if err != nil {
+		log.FromContext(ctx).Warnf("health check %s failed, the dependent services are NOT_SERVING: %s", check.Name, err.Error())
+		return
+	}
+	log.FromContext(ctx).Infof("health check %s passed", check.Name)
+}
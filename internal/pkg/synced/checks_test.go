@@ -0,0 +1,55 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package synced_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/synced"
+)
+
+func TestCondition_RunChecks(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	condition := synced.NewCondition()
+	var healthy atomic.Bool
+	go condition.RunChecks(ctx, 10*time.Millisecond, synced.Check{
+		Name: "backend",
+		Func: func(context.Context) error {
+			if healthy.Load() {
+				return nil
+			}
+			return errors.New("backend is unreachable")
+		},
+	})
+
+	require.Eventually(t, func() bool {
+		return condition.Failures()["backend"] == "backend is unreachable"
+	}, time.Second, 10*time.Millisecond)
+
+	healthy.Store(true)
+	require.Eventually(t, func() bool {
+		return len(condition.Failures()) == 0
+	}, time.Second, 10*time.Millisecond)
+}
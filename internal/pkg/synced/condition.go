@@ -0,0 +1,198 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package synced provides the "synced" condition of the registry: it becomes true once the registry has restored its
+// initial state, so the dependent services never read from a half-empty registry after restart.
+//
+// The condition is the registry readiness. The overall health ("" service) is the liveness: it is SERVING as soon as
+// the gRPC server is up. The registry services, ServiceName and ReadinessServiceName are NOT_SERVING until the
+// condition is set. The same is served over HTTP on /livez and /readyz for the Kubernetes probes.
+//
+// The registry services also depend on the checks reported to the condition, e.g. the proxy registry being reachable: a
+// service is NOT_SERVING while any of its checks fails, even after the condition is set. The failure reasons are
+// returned by Failures. The readiness doesn't depend on the checks, so the registry keeps serving the local queries.
+package synced
+
+import (
+	"context"
+	"net/http"
+	"sync"
+
+	"github.com/NikitaSkrynnik/api/pkg/api"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const (
+	// ServiceName is the health service name reporting the synced condition
+	ServiceName = "registry-memory.synced"
+	// ReadinessServiceName is the health service name reporting the overall readiness
+	ReadinessServiceName = "readiness"
+)
+
+// Condition is the synced condition. The health server it registers reports the registry services, ServiceName and
+// ReadinessServiceName as NOT_SERVING until the condition is set.
+type Condition struct {
+	mu       sync.Mutex
+	done     chan struct{}
+	health   *health.Server
+	services []string
+	failures map[string]failure
+}
+
+// failure is a failing check with the registry services it affects
+type failure struct {
+	services []string
+	reason   string
+}
+
+// NewCondition creates a new unset Condition
+func NewCondition() *Condition {
+	return &Condition{
+		done:     make(chan struct{}),
+		health:   health.NewServer(),
+		failures: make(map[string]failure),
+	}
+}
+
+// RegisterHealthServer registers the health server reporting the condition for the services on s
+func (c *Condition) RegisterHealthServer(s grpc.ServiceRegistrar, services ...interface{}) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	grpc_health_v1.RegisterHealthServer(s, c.health)
+	c.health.SetServingStatus("", grpc_health_v1.HealthCheckResponse_SERVING)
+	c.services = []string{ServiceName, ReadinessServiceName}
+	for _, service := range services {
+		c.services = append(c.services, api.ServiceNames(service)...)
+	}
+	c.updateLocked()
+}
+
+// Set sets the condition
+func (c *Condition) Set() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.Synced() {
+		return
+	}
+	close(c.done)
+	c.updateLocked()
+}
+
+// Report records the result of the named check of the registry services: the services are NOT_SERVING while err is
+// not nil. No services mean all the registry services. It returns true if the check result has changed.
+func (c *Condition) Report(check string, err error, services ...interface{}) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	prev, failed := c.failures[check]
+	if err == nil {
+		if !failed {
+			return false
+		}
+		delete(c.failures, check)
+		c.updateLocked()
+		return true
+	}
+	var names []string
+	for _, service := range services {
+		names = append(names, api.ServiceNames(service)...)
+	}
+	c.failures[check] = failure{services: names, reason: err.Error()}
+	c.updateLocked()
+	return !failed || prev.reason != err.Error()
+}
+
+// Failures returns the reasons of the failing checks by the check name
+func (c *Condition) Failures() map[string]string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	failures := make(map[string]string, len(c.failures))
+	for check, f := range c.failures {
+		failures[check] = f.reason
+	}
+	return failures
+}
+
+// updateLocked sets the serving status of the services from the condition and the failing checks
+func (c *Condition) updateLocked() {
+	synced := c.Synced()
+	for _, name := range c.services {
+		status := grpc_health_v1.HealthCheckResponse_NOT_SERVING
+		if synced && !c.failingLocked(name) {
+			status = grpc_health_v1.HealthCheckResponse_SERVING
+		}
+		c.health.SetServingStatus(name, status)
+	}
+}
+
+// failingLocked returns true if any check of the service fails
+func (c *Condition) failingLocked(name string) bool {
+	if name == ServiceName || name == ReadinessServiceName {
+		return false
+	}
+	for _, f := range c.failures {
+		if len(f.services) == 0 {
+			return true
+		}
+		for _, service := range f.services {
+			if service == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Synced returns true if the condition is set
+func (c *Condition) Synced() bool {
+	select {
+	case <-c.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Wait waits for the condition or for ctx to be done
+func (c *Condition) Wait(ctx context.Context) error {
+	select {
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// HTTPHandler returns the HTTP handler serving /livez, always OK, and /readyz, OK once the condition is set
+func (c *Condition) HTTPHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/livez", func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, _ *http.Request) {
+		if !c.Synced() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	return mux
+}
@@ -0,0 +1,157 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package synced_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/null"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/synced"
+)
+
+func TestCondition_Health(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	condition := synced.NewCondition()
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	condition.RegisterHealthServer(server)
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	cc, err := grpc.DialContext(ctx, "bufconn",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer func() { _ = cc.Close() }()
+	client := grpc_health_v1.NewHealthClient(cc)
+
+	resp, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: synced.ServiceName})
+	require.NoError(t, err)
+	require.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, resp.GetStatus())
+	require.False(t, condition.Synced())
+
+	resp, err = client.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: synced.ReadinessServiceName})
+	require.NoError(t, err)
+	require.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, resp.GetStatus())
+
+	// Liveness doesn't depend on the condition
+	resp, err = client.Check(ctx, new(grpc_health_v1.HealthCheckRequest))
+	require.NoError(t, err)
+	require.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, resp.GetStatus())
+
+	waitCtx, waitCancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer waitCancel()
+	require.Error(t, condition.Wait(waitCtx))
+
+	condition.Set()
+	condition.Set()
+
+	require.NoError(t, condition.Wait(ctx))
+	require.True(t, condition.Synced())
+	resp, err = client.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: synced.ServiceName})
+	require.NoError(t, err)
+	require.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, resp.GetStatus())
+}
+
+func TestCondition_Report(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	nsServer, nseServer := null.NewNetworkServiceRegistryServer(), null.NewNetworkServiceEndpointRegistryServer()
+	condition := synced.NewCondition()
+
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	condition.RegisterHealthServer(server, nsServer, nseServer)
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	cc, err := grpc.DialContext(ctx, "bufconn",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+	defer func() { _ = cc.Close() }()
+	client := grpc_health_v1.NewHealthClient(cc)
+
+	check := func(service string) grpc_health_v1.HealthCheckResponse_ServingStatus {
+		resp, checkErr := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{Service: service})
+		require.NoError(t, checkErr)
+		return resp.GetStatus()
+	}
+	const nsService, nseService = "registry.NetworkServiceRegistry", "registry.NetworkServiceEndpointRegistry"
+
+	// A check failing before the condition is set keeps the service NOT_SERVING after
+	require.True(t, condition.Report("proxy", errors.New("proxy registry is unreachable"), nseServer))
+	require.False(t, condition.Report("proxy", errors.New("proxy registry is unreachable"), nseServer))
+	condition.Set()
+
+	require.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, check(nsService))
+	require.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, check(nseService))
+	require.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, check(synced.ReadinessServiceName))
+	require.Equal(t, map[string]string{"proxy": "proxy registry is unreachable"}, condition.Failures())
+
+	// No services mean all the registry services
+	require.True(t, condition.Report("policy", errors.New("no policies")))
+	require.Equal(t, grpc_health_v1.HealthCheckResponse_NOT_SERVING, check(nsService))
+	require.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, check(synced.ServiceName))
+
+	require.True(t, condition.Report("policy", nil))
+	require.False(t, condition.Report("policy", nil))
+	require.True(t, condition.Report("proxy", nil, nseServer))
+	require.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, check(nsService))
+	require.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, check(nseService))
+	require.Empty(t, condition.Failures())
+}
+
+func TestCondition_HTTPHandler(t *testing.T) {
+	condition := synced.NewCondition()
+	server := httptest.NewServer(condition.HTTPHandler())
+	defer server.Close()
+
+	get := func(path string) int {
+		resp, err := http.Get(server.URL + path)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	require.Equal(t, http.StatusOK, get("/livez"))
+	require.Equal(t, http.StatusServiceUnavailable, get("/readyz"))
+
+	condition.Set()
+
+	require.Equal(t, http.StatusOK, get("/livez"))
+	require.Equal(t, http.StatusOK, get("/readyz"))
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tlssource
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/url"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+)
+
+const ephemeralLifetime = 10 * 365 * 24 * time.Hour
+
+// EphemeralSource is an X.509 SVID and bundle source with a self-signed certificate generated at start. It is only
+// good for signing tokens in the insecure (no TLS) development mode.
+type EphemeralSource struct {
+	svid *x509svid.SVID
+}
+
+// NewEphemeralSource creates a new EphemeralSource with the SPIFFE ID
+func NewEphemeralSource(id spiffeid.ID) (*EphemeralSource, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate a key")
+	}
+	uri, err := url.Parse(id.String())
+	if err != nil {
+		return nil, errors.Wrapf(err, "invalid SPIFFE ID %s", id.String())
+	}
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(now.UnixNano()),
+		Subject:               pkix.Name{CommonName: id.String()},
+		URIs:                  []*url.URL{uri},
+		NotBefore:             now.Add(-time.Minute),
+		NotAfter:              now.Add(ephemeralLifetime),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create a certificate")
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to parse the created certificate")
+	}
+	return &EphemeralSource{
+		svid: &x509svid.SVID{
+			ID:           id,
+			Certificates: []*x509.Certificate{cert},
+			PrivateKey:   key,
+		},
+	}, nil
+}
+
+// GetX509SVID returns the ephemeral X.509 SVID
+func (s *EphemeralSource) GetX509SVID() (*x509svid.SVID, error) {
+	return s.svid, nil
+}
+
+// GetX509BundleForTrustDomain returns the bundle trusting the ephemeral certificate
+func (s *EphemeralSource) GetX509BundleForTrustDomain(trustDomain spiffeid.TrustDomain) (*x509bundle.Bundle, error) {
+	return x509bundle.FromX509Authorities(trustDomain, s.svid.Certificates), nil
+}
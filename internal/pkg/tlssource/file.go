@@ -0,0 +1,102 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tlssource provides X.509 SVID and bundle sources not depending on the SPIFFE workload API
+package tlssource
+
+import (
+	"context"
+	"crypto/x509"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/log"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/reload"
+)
+
+// caTrustDomain is a placeholder trust domain used to parse the CA file
+var caTrustDomain = spiffeid.RequireTrustDomainFromString("ca.local")
+
+// FileSource is an X.509 SVID and bundle source reading the certificate, the key and the CA certificates from PEM
+// files. The certificate must have a SPIFFE ID URI SAN. The CA certificates are trusted for all trust domains.
+type FileSource struct {
+	certFile, keyFile, caFile string
+
+	mu          sync.RWMutex
+	svid        *x509svid.SVID
+	authorities []*x509.Certificate
+}
+
+// NewFileSource creates a new FileSource reloading the files on change or on SIGHUP until ctx is done
+func NewFileSource(ctx context.Context, certFile, keyFile, caFile string) (*FileSource, error) {
+	s := &FileSource{
+		certFile: certFile,
+		keyFile:  keyFile,
+		caFile:   caFile,
+	}
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+	for _, filePath := range []string{certFile, keyFile, caFile} {
+		reload.Notify(ctx, filePath, func() {
+			if err := s.load(); err != nil {
+				log.FromContext(ctx).Errorf("failed to reload TLS files: %s", err.Error())
+				return
+			}
+			log.FromContext(ctx).Infof("TLS files are reloaded")
+		})
+	}
+	return s, nil
+}
+
+// GetX509SVID returns the current X.509 SVID
+func (s *FileSource) GetX509SVID() (*x509svid.SVID, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.svid, nil
+}
+
+// GetX509BundleForTrustDomain returns the bundle of the CA certificates for the trust domain
+func (s *FileSource) GetX509BundleForTrustDomain(trustDomain spiffeid.TrustDomain) (*x509bundle.Bundle, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return x509bundle.FromX509Authorities(trustDomain, s.authorities), nil
+}
+
+func (s *FileSource) load() error {
+	svid, err := x509svid.Load(s.certFile, s.keyFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load certificate %s and key %s", s.certFile, s.keyFile)
+	}
+	bundle, err := x509bundle.Load(caTrustDomain, s.caFile)
+	if err != nil {
+		return errors.Wrapf(err, "failed to load CA certificates %s", s.caFile)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.svid = svid
+	s.authorities = bundle.X509Authorities()
+	return nil
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tlssource_test
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/tlssource"
+)
+
+func TestEphemeralSource(t *testing.T) {
+	id := spiffeid.RequireFromString("spiffe://insecure.local/registry-memory")
+
+	source, err := tlssource.NewEphemeralSource(id)
+	require.NoError(t, err)
+
+	svid, err := source.GetX509SVID()
+	require.NoError(t, err)
+	require.Equal(t, id, svid.ID)
+	require.Len(t, svid.Certificates, 1)
+	require.Equal(t, id.String(), svid.Certificates[0].URIs[0].String())
+
+	bundle, err := source.GetX509BundleForTrustDomain(id.TrustDomain())
+	require.NoError(t, err)
+	require.True(t, bundle.HasX509Authority(svid.Certificates[0]))
+}
+
+func TestFileSource(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	id := spiffeid.RequireFromString("spiffe://example.org/registry-memory")
+	certFile, keyFile, caFile := writeFiles(t, id)
+
+	source, err := tlssource.NewFileSource(ctx, certFile, keyFile, caFile)
+	require.NoError(t, err)
+
+	svid, err := source.GetX509SVID()
+	require.NoError(t, err)
+	require.Equal(t, id, svid.ID)
+
+	// The CA certificates are trusted for any trust domain
+	bundle, err := source.GetX509BundleForTrustDomain(spiffeid.RequireTrustDomainFromString("other.org"))
+	require.NoError(t, err)
+	require.Len(t, bundle.X509Authorities(), 1)
+
+	verifiedID, _, err := x509svid.Verify(svid.Certificates, source)
+	require.NoError(t, err)
+	require.Equal(t, id, verifiedID)
+}
+
+func TestFileSource_Invalid(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	certFile, keyFile, caFile := writeFiles(t, spiffeid.RequireFromString("spiffe://example.org/registry-memory"))
+	missingFile := filepath.Join(t.TempDir(), "missing.pem")
+
+	_, err := tlssource.NewFileSource(ctx, missingFile, keyFile, caFile)
+	require.Error(t, err)
+
+	_, err = tlssource.NewFileSource(ctx, certFile, missingFile, caFile)
+	require.Error(t, err)
+
+	_, err = tlssource.NewFileSource(ctx, certFile, keyFile, missingFile)
+	require.Error(t, err)
+
+	// The key doesn't match the certificate
+	_, err = tlssource.NewFileSource(ctx, certFile, caFile, caFile)
+	require.Error(t, err)
+}
+
+// writeFiles writes a CA certificate and a certificate with the SPIFFE ID signed by it to PEM files
+func writeFiles(t *testing.T, id spiffeid.ID) (certFile, keyFile, caFile string) {
+	dir := t.TempDir()
+	now := time.Now()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca"},
+		NotBefore:             now.Add(-time.Minute),
+		NotAfter:              now.Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	caCert, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	uri, err := url.Parse(id.String())
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		URIs:         []*url.URL{uri},
+		NotBefore:    now.Add(-time.Minute),
+		NotAfter:     now.Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	certFile = filepath.Join(dir, "tls.crt")
+	keyFile = filepath.Join(dir, "tls.key")
+	caFile = filepath.Join(dir, "ca.crt")
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o600))
+	require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER}), 0o600))
+
+	return certFile, keyFile, caFile
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tombstones
+
+import (
+	"context"
+
+	"github.com/golang/protobuf/ptypes/empty"
+	"github.com/pkg/errors"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/queryparams"
+)
+
+// TombstonesParam is the query parameter making Find return the tombstones (as deleted NSEs) instead of the
+// registered NSEs if set to "true"
+const TombstonesParam = "tombstones"
+
+type tombstonesNSEServer struct {
+	store *Store
+}
+
+// NewNetworkServiceEndpointRegistryServer creates a new NSE server chain element marking unregistered NSEs in the
+// store and serving the tombstones Find queries
+func NewNetworkServiceEndpointRegistryServer(store *Store) registry.NetworkServiceEndpointRegistryServer {
+	return &tombstonesNSEServer{
+		store: store,
+	}
+}
+
+func (s *tombstonesNSEServer) Register(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*registry.NetworkServiceEndpoint, error) {
+	return next.NetworkServiceEndpointRegistryServer(ctx).Register(ctx, nse)
+}
+
+func (s *tombstonesNSEServer) Find(query *registry.NetworkServiceEndpointQuery, server registry.NetworkServiceEndpointRegistry_FindServer) error {
+	if queryparams.FromContext(server.Context())[TombstonesParam] != "true" {
+		return next.NetworkServiceEndpointRegistryServer(server.Context()).Find(query, server)
+	}
+
+	for _, t := range s.store.List() {
+		if !matches(query.GetNetworkServiceEndpoint(), t.NSE) {
+			continue
+		}
+		resp := &registry.NetworkServiceEndpointResponse{NetworkServiceEndpoint: t.NSE, Deleted: true}
+		if err := server.Send(resp); err != nil {
+			return errors.Wrapf(err, "NetworkServiceEndpointRegistry find server failed to send a response %s", resp.String())
+		}
+	}
+	return nil
+}
+
+func (s *tombstonesNSEServer) Unregister(ctx context.Context, nse *registry.NetworkServiceEndpoint) (*empty.Empty, error) {
+	s.store.Mark(ctx, nse.GetName(), ReasonUnregistered)
+	return next.NetworkServiceEndpointRegistryServer(ctx).Unregister(ctx, nse)
+}
+
+// matches returns true if nse has the query name (if set) and one of the query network service names (if set)
+func matches(query, nse *registry.NetworkServiceEndpoint) bool {
+	if query.GetName() != "" && query.GetName() != nse.GetName() {
+		return false
+	}
+	if len(query.GetNetworkServiceNames()) == 0 {
+		return true
+	}
+	for _, queryName := range query.GetNetworkServiceNames() {
+		for _, name := range nse.GetNetworkServiceNames() {
+			if queryName == name {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,207 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tombstones keeps the removed NSEs with the removal reason for a retention period
+package tombstones
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/log"
+)
+
+// Reason is the reason of the NSE removal
+type Reason string
+
+const (
+	// ReasonExpired is set for the NSEs removed by expiration
+	ReasonExpired Reason = "expired"
+	// ReasonUnregistered is set for the NSEs removed by Unregister
+	ReasonUnregistered Reason = "unregistered"
+	// ReasonEvicted is set for the NSEs removed by the registry itself
+	ReasonEvicted Reason = "evicted"
+)
+
+// markTimeout is how long a removal mark waits for the matching deleted event
+const markTimeout = time.Minute
+
+// Tombstone is a removed NSE
+type Tombstone struct {
+	NSE       *registry.NetworkServiceEndpoint
+	Reason    Reason
+	DeletedAt time.Time
+}
+
+type mark struct {
+	reason Reason
+	time   time.Time
+}
+
+// Store keeps the tombstones of the removed NSEs
+type Store struct {
+	retention time.Duration
+	removals  metric.Int64Counter
+
+	mu         sync.Mutex
+	tombstones map[string]*Tombstone
+	marks      map[string]mark
+}
+
+// NewStore creates a new Store keeping tombstones for retention
+func NewStore(retention time.Duration) *Store {
+	removals, err := otel.Meter("registry-memory").Int64Counter("registry.nse.removals",
+		metric.WithDescription("Number of removed NSEs by reason"))
+	if err != nil {
+		log.L().Errorf("failed to create NSE removals counter: %s", err.Error())
+	}
+	return &Store{
+		retention:  retention,
+		removals:   removals,
+		tombstones: make(map[string]*Tombstone),
+		marks:      make(map[string]mark),
+	}
+}
+
+// Mark sets the reason for the next removal of the NSE
+func (s *Store) Mark(ctx context.Context, name string, reason Reason) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.marks[name] = mark{reason: reason, time: clock.FromContext(ctx).Now()}
+}
+
+// Add adds the tombstone for the removed NSE. The reason is the one set with Mark, or expired if there is no mark.
+func (s *Store) Add(ctx context.Context, nse *registry.NetworkServiceEndpoint) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := clock.FromContext(ctx).Now()
+	reason := ReasonExpired
+	if m, ok := s.marks[nse.GetName()]; ok {
+		reason = m.reason
+		delete(s.marks, nse.GetName())
+	}
+	s.tombstones[nse.GetName()] = &Tombstone{
+		NSE:       proto.Clone(nse).(*registry.NetworkServiceEndpoint),
+		Reason:    reason,
+		DeletedAt: now,
+	}
+
+	log.FromContext(ctx).WithField("nse", nse.GetName()).WithField("reason", string(reason)).
+		Infof("NSE is removed, expiration time: %s", nse.GetExpirationTime().AsTime().UTC().Format(time.RFC3339))
+	if s.removals != nil {
+		s.removals.Add(ctx, 1, metric.WithAttributes(attribute.String("reason", string(reason))))
+	}
+}
+
+// Reason returns the reason of the last removal of the NSE: the pending mark, the tombstone reason, or expired if there
+// is neither
+func (s *Store) Reason(name string) Reason {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if m, ok := s.marks[name]; ok {
+		return m.reason
+	}
+	if t, ok := s.tombstones[name]; ok {
+		return t.Reason
+	}
+	return ReasonExpired
+}
+
+// List returns the tombstones sorted by removal time
+func (s *Store) List() []*Tombstone {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	result := make([]*Tombstone, 0, len(s.tombstones))
+	for _, t := range s.tombstones {
+		result = append(result, t)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].DeletedAt.Before(result[j].DeletedAt)
+	})
+	return result
+}
+
+// Run watches the NSEs with the client and adds tombstones for the deleted ones until ctx is done. The tombstones and
+// marks older than the retention are collected every retention/2.
+func (s *Store) Run(ctx context.Context, client registry.NetworkServiceEndpointRegistryClient) {
+	go s.collect(ctx)
+	for ctx.Err() == nil {
+		stream, err := client.Find(ctx, &registry.NetworkServiceEndpointQuery{
+			NetworkServiceEndpoint: new(registry.NetworkServiceEndpoint),
+			Watch:                  true,
+		})
+		if err != nil {
+			log.FromContext(ctx).Warnf("failed to watch NSEs for tombstones: %s", err.Error())
+			select {
+			case <-ctx.Done():
+			case <-clock.FromContext(ctx).After(time.Second):
+			}
+			continue
+		}
+		for resp := range registry.ReadNetworkServiceEndpointChannel(stream) {
+			if resp.GetDeleted() {
+				s.Add(ctx, resp.GetNetworkServiceEndpoint())
+			}
+		}
+	}
+}
+
+func (s *Store) collect(ctx context.Context) {
+	period := s.retention / 2
+	if period < time.Second {
+		period = time.Second
+	}
+	ticker := clock.FromContext(ctx).Ticker(period)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C():
+			s.gc(clock.FromContext(ctx).Now())
+		}
+	}
+}
+
+func (s *Store) gc(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for name, t := range s.tombstones {
+		if now.Sub(t.DeletedAt) > s.retention {
+			delete(s.tombstones, name)
+		}
+	}
+	for name, m := range s.marks {
+		if now.Sub(m.time) > markTimeout {
+			delete(s.marks, name)
+		}
+	}
+}
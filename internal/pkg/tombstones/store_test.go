@@ -0,0 +1,80 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tombstones_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/chain"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/queryparams"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/tombstones"
+)
+
+func TestTombstones(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	store := tombstones.NewStore(time.Hour)
+	client := adapters.NetworkServiceEndpointServerToClient(chain.NewNetworkServiceEndpointRegistryServer(
+		queryparams.NewNetworkServiceEndpointRegistryServer(),
+		tombstones.NewNetworkServiceEndpointRegistryServer(store),
+		memory.NewNetworkServiceEndpointRegistryServer(),
+	))
+	go store.Run(ctx, client)
+
+	// The watch starts in background, so repeat until the removal is seen
+	require.Eventually(t, func() bool {
+		_, err := client.Register(ctx, &registry.NetworkServiceEndpoint{Name: "nse-1", NetworkServiceNames: []string{"ns-1"}})
+		require.NoError(t, err)
+		_, err = client.Unregister(ctx, &registry.NetworkServiceEndpoint{Name: "nse-1"})
+		require.NoError(t, err)
+		return len(store.List()) == 1
+	}, time.Second, 10*time.Millisecond)
+	store.Add(ctx, &registry.NetworkServiceEndpoint{Name: "nse-2", NetworkServiceNames: []string{"ns-2"}})
+
+	reasons := make(map[string]tombstones.Reason)
+	for _, tombstone := range store.List() {
+		reasons[tombstone.NSE.GetName()] = tombstone.Reason
+	}
+	require.Equal(t, map[string]tombstones.Reason{
+		"nse-1": tombstones.ReasonUnregistered,
+		"nse-2": tombstones.ReasonExpired,
+	}, reasons)
+
+	stream, err := client.Find(ctx, &registry.NetworkServiceEndpointQuery{
+		NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{
+			NetworkServiceNames: []string{"ns-1"},
+			NetworkServiceLabels: map[string]*registry.NetworkServiceLabels{
+				queryparams.Key: {Labels: map[string]string{tombstones.TombstonesParam: "true"}},
+			},
+		},
+	})
+	require.NoError(t, err)
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	require.True(t, resp.GetDeleted())
+	require.Equal(t, "nse-1", resp.GetNetworkServiceEndpoint().GetName())
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package varz provides the plaintext HTTP status endpoints for the probes and the scripts not speaking gRPC with
+// SPIFFE mTLS: /healthz, /readyz and /varz with the basic counters as JSON
+package varz
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/events"
+)
+
+// Vars are the published variables and the counts of the registry events
+type Vars struct {
+	ctx   context.Context
+	start time.Time
+
+	mu     sync.Mutex
+	funcs  map[string]func() interface{}
+	events map[string]int64
+}
+
+// New creates new Vars, ctx provides the clock
+func New(ctx context.Context) *Vars {
+	return &Vars{
+		ctx:    ctx,
+		start:  clock.FromContext(ctx).Now(),
+		funcs:  make(map[string]func() interface{}),
+		events: make(map[string]int64),
+	}
+}
+
+// Publish publishes the variable with the value returned by f at each /varz request
+func (v *Vars) Publish(name string, f func() interface{}) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.funcs[name] = f
+}
+
+// Run counts the events by type until the channel is closed
+func (v *Vars) Run(ch <-chan events.Event) {
+	for event := range ch {
+		v.mu.Lock()
+		v.events[event.Type]++
+		v.mu.Unlock()
+	}
+}
+
+// Get returns the variables: uptime_seconds, events with the counts by type, and the published ones
+func (v *Vars) Get() map[string]interface{} {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	counts := make(map[string]int64, len(v.events))
+	for eventType, count := range v.events {
+		counts[eventType] = count
+	}
+	vars := map[string]interface{}{
+		"uptime_seconds": int64(clock.FromContext(v.ctx).Since(v.start).Seconds()),
+		"events":         counts,
+	}
+	for name, f := range v.funcs {
+		vars[name] = f()
+	}
+	return vars
+}
+
+// Handler returns the HTTP handler serving /healthz, always 200 while the process serves, /readyz, 200 if ready returns
+// true and 503 otherwise, and /varz with the variables as JSON
+func (v *Vars) Handler(ready func() bool) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte("ok\n"))
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, _ *http.Request) {
+		if !ready() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte("not ready\n"))
+			return
+		}
+		_, _ = w.Write([]byte("ok\n"))
+	})
+	mux.HandleFunc("/varz", func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		_ = encoder.Encode(v.Get())
+	})
+	return mux
+}
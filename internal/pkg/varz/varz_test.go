@@ -0,0 +1,77 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package varz_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clockmock"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/events"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/varz"
+)
+
+func TestVars_Handler(t *testing.T) {
+	clockMock := clockmock.New(context.Background())
+	ctx := clock.WithClock(context.Background(), clockMock)
+
+	vars := varz.New(ctx)
+	vars.Publish("version", func() interface{} { return "v1.0.0" })
+
+	ch := make(chan events.Event, 3)
+	ch <- events.New(events.TypeNSERegistered, nil)
+	ch <- events.New(events.TypeNSERegistered, nil)
+	ch <- events.New(events.TypeNSEExpired, nil)
+	close(ch)
+	vars.Run(ch)
+	clockMock.Add(time.Minute)
+
+	ready := false
+	handler := vars.Handler(func() bool { return ready })
+	get := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, http.NoBody))
+		return w
+	}
+
+	require.Equal(t, http.StatusOK, get("/healthz").Code)
+	require.Equal(t, http.StatusServiceUnavailable, get("/readyz").Code)
+	ready = true
+	require.Equal(t, http.StatusOK, get("/readyz").Code)
+
+	w := get("/varz")
+	require.Equal(t, http.StatusOK, w.Code)
+	require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	var result map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &result))
+	require.Equal(t, map[string]interface{}{
+		"uptime_seconds": float64(60),
+		"version":        "v1.0.0",
+		"events": map[string]interface{}{
+			events.TypeNSERegistered: float64(2),
+			events.TypeNSEExpired:    float64(1),
+		},
+	}, result)
+}
@@ -0,0 +1,179 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package webhooks provides delivery of the registry events to the HTTP(S) webhooks
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/log"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/events"
+)
+
+const (
+	queueSize  = 1024
+	maxBackoff = 30 * time.Second
+)
+
+type options struct {
+	client     *http.Client
+	maxRetries int
+	backoff    time.Duration
+}
+
+// Option is an option for the Sender
+type Option func(o *options)
+
+// WithHTTPClient sets the HTTP client, default has 5s timeout
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.client = client
+	}
+}
+
+// WithMaxRetries sets the number of retries of a failed delivery, default is 5
+func WithMaxRetries(maxRetries int) Option {
+	return func(o *options) {
+		o.maxRetries = maxRetries
+	}
+}
+
+// WithBackoff sets the delay before the first retry, it doubles with each retry up to 30s. Default is 1s.
+func WithBackoff(backoff time.Duration) Option {
+	return func(o *options) {
+		o.backoff = backoff
+	}
+}
+
+// Sender POSTs the events as JSON to the webhook URLs. Each URL has its own queue, so a failing webhook doesn't delay
+// the others. Network errors, 429 and 5xx responses are retried with exponential backoff.
+type Sender struct {
+	options
+	urls []string
+}
+
+// NewSender creates a new Sender
+func NewSender(urls []string, opts ...Option) *Sender {
+	s := &Sender{
+		options: options{
+			client:     &http.Client{Timeout: 5 * time.Second},
+			maxRetries: 5,
+			backoff:    time.Second,
+		},
+		urls: urls,
+	}
+	for _, opt := range opts {
+		opt(&s.options)
+	}
+	return s
+}
+
+// Run delivers the events until the channel is closed and the queues are drained, or until ctx is done
+func (s *Sender) Run(ctx context.Context, ch <-chan events.Event) {
+	queues := make([]chan events.Event, 0, len(s.urls))
+	done := make(chan struct{}, len(s.urls))
+	for _, url := range s.urls {
+		queue := make(chan events.Event, queueSize)
+		queues = append(queues, queue)
+		go func(url string) {
+			defer func() { done <- struct{}{} }()
+			for event := range queue {
+				s.deliver(ctx, url, event)
+			}
+		}(url)
+	}
+
+	for event := range ch {
+		for i, queue := range queues {
+			select {
+			case queue <- event:
+			default:
+				log.FromContext(ctx).WithField("webhook", s.urls[i]).Warnf("webhook queue is full, dropping %s event", event.Type)
+			}
+		}
+	}
+	for _, queue := range queues {
+		close(queue)
+	}
+	for range queues {
+		<-done
+	}
+}
+
+func (s *Sender) deliver(ctx context.Context, url string, event events.Event) {
+	logger := log.FromContext(ctx).WithField("webhook", url)
+	body, err := json.Marshal(event)
+	if err != nil {
+		logger.Errorf("failed to marshal %s event: %s", event.Type, err.Error())
+		return
+	}
+
+	backoff := s.backoff
+	for attempt := 0; ; attempt++ {
+		retry, postErr := s.post(ctx, url, body)
+		if postErr == nil {
+			return
+		}
+		if !retry || attempt >= s.maxRetries {
+			logger.Errorf("failed to deliver %s event: %s", event.Type, postErr.Error())
+			return
+		}
+		logger.Warnf("failed to deliver %s event, retrying in %s: %s", event.Type, backoff, postErr.Error())
+		select {
+		case <-ctx.Done():
+			return
+		case <-clock.FromContext(ctx).After(backoff):
+		}
+		if backoff *= 2; backoff > maxBackoff {
+			backoff = maxBackoff
+		}
+	}
+}
+
+// post sends the body to the url and returns if the failed request should be retried
+func (s *Sender) post(ctx context.Context, url string, body []byte) (retry bool, err error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return false, errors.Wrap(err, "invalid request")
+	}
+	request.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.client.Do(request)
+	if err != nil {
+		return true, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, errors.Errorf("unexpected status %s", resp.Status)
+	default:
+		return false, errors.Errorf("unexpected status %s", resp.Status)
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package webhooks_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/events"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/webhooks"
+)
+
+func TestSender_RetriesFailedDelivery(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var attempts int32
+	received := make(chan events.Event, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var event events.Event
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		received <- event
+	}))
+	defer server.Close()
+
+	ch := make(chan events.Event, 1)
+	ch <- events.New(events.TypeNSERegistered, map[string]string{"name": "nse-1"})
+	close(ch)
+
+	webhooks.NewSender([]string{server.URL}, webhooks.WithBackoff(time.Millisecond)).Run(ctx, ch)
+
+	event := <-received
+	require.Equal(t, events.TypeNSERegistered, event.Type)
+	require.Equal(t, "nse-1", event.Attributes["name"])
+	require.Equal(t, int32(3), atomic.LoadInt32(&attempts))
+}
+
+func TestSender_DoesNotRetryClientErrors(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var attempts int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	ch := make(chan events.Event, 1)
+	ch <- events.New(events.TypeNSEExpired, nil)
+	close(ch)
+
+	webhooks.NewSender([]string{server.URL}, webhooks.WithBackoff(time.Millisecond)).Run(ctx, ch)
+
+	require.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+}
@@ -0,0 +1,102 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package zstdcompressor provides the zstd gRPC compressor
+package zstdcompressor
+
+import (
+	"io"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc/encoding"
+)
+
+// Name is the name the compressor is advertised and negotiated with
+const Name = "zstd"
+
+// maxWindow limits the memory a client can make the server allocate for decoding a message, the encoders of the
+// default level use a smaller window
+const maxWindow = 8 << 20
+
+// Register registers the zstd compressor, so the server advertises zstd and compresses the responses to the clients
+// sending zstd compressed requests. It must be called before the gRPC servers and clients are created.
+func Register() {
+	encoding.RegisterCompressor(new(compressor))
+}
+
+type compressor struct {
+	encoders sync.Pool
+	decoders sync.Pool
+}
+
+type writer struct {
+	*zstd.Encoder
+	pool *sync.Pool
+}
+
+type reader struct {
+	*zstd.Decoder
+	pool *sync.Pool
+}
+
+func (c *compressor) Name() string {
+	return Name
+}
+
+func (c *compressor) Compress(w io.Writer) (io.WriteCloser, error) {
+	if zw, ok := c.encoders.Get().(*writer); ok {
+		zw.Reset(w)
+		return zw, nil
+	}
+	encoder, err := zstd.NewWriter(w, zstd.WithEncoderConcurrency(1))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create zstd encoder")
+	}
+	return &writer{Encoder: encoder, pool: &c.encoders}, nil
+}
+
+func (c *compressor) Decompress(r io.Reader) (io.Reader, error) {
+	if zr, ok := c.decoders.Get().(*reader); ok {
+		if err := zr.Reset(r); err != nil {
+			c.decoders.Put(zr)
+			return nil, errors.Wrap(err, "failed to reset zstd decoder")
+		}
+		return zr, nil
+	}
+	// The decoder of concurrency 1 decodes the stream synchronously, so the pooled decoders leak no goroutines
+	decoder, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1), zstd.WithDecoderMaxWindow(maxWindow))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create zstd decoder")
+	}
+	return &reader{Decoder: decoder, pool: &c.decoders}, nil
+}
+
+// Close finishes the zstd frame and returns the encoder to the pool
+func (w *writer) Close() error {
+	defer w.pool.Put(w)
+	return w.Encoder.Close()
+}
+
+// Read reads the decompressed data and returns the decoder to the pool at the end of the message
+func (r *reader) Read(p []byte) (int, error) {
+	n, err := r.Decoder.Read(p)
+	if errors.Is(err, io.EOF) {
+		r.pool.Put(r)
+	}
+	return n, err
+}
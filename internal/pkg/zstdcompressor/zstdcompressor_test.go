@@ -0,0 +1,103 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zstdcompressor_test
+
+import (
+	"context"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/zstdcompressor"
+)
+
+// compressionRecorder records the compression of the received headers
+type compressionRecorder struct {
+	mu          sync.Mutex
+	compression []string
+}
+
+func (r *compressionRecorder) TagRPC(ctx context.Context, _ *stats.RPCTagInfo) context.Context {
+	return ctx
+}
+
+func (r *compressionRecorder) HandleRPC(_ context.Context, s stats.RPCStats) {
+	if in, ok := s.(*stats.InHeader); ok {
+		r.mu.Lock()
+		r.compression = append(r.compression, in.Compression)
+		r.mu.Unlock()
+	}
+}
+
+func (r *compressionRecorder) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (r *compressionRecorder) HandleConn(context.Context, stats.ConnStats) {}
+
+func (r *compressionRecorder) get() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string(nil), r.compression...)
+}
+
+func TestZstd_Negotiation(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	zstdcompressor.Register()
+
+	serverStats := new(compressionRecorder)
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer(grpc.StatsHandler(serverStats))
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus("registry", grpc_health_v1.HealthCheckResponse_SERVING)
+	grpc_health_v1.RegisterHealthServer(server, healthServer)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+
+	clientStats := new(compressionRecorder)
+	cc, err := grpc.DialContext(ctx, "bufconn",
+		grpc.WithContextDialer(func(context.Context, string) (net.Conn, error) { return listener.Dial() }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithStatsHandler(clientStats),
+	)
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = cc.Close() })
+
+	// The second call reuses the pooled encoders and decoders
+	for i := 0; i < 2; i++ {
+		resp, checkErr := grpc_health_v1.NewHealthClient(cc).Check(ctx,
+			&grpc_health_v1.HealthCheckRequest{Service: "registry"},
+			grpc.UseCompressor(zstdcompressor.Name))
+		require.NoError(t, checkErr)
+		require.Equal(t, grpc_health_v1.HealthCheckResponse_SERVING, resp.GetStatus())
+	}
+
+	// The server decompresses the zstd requests and compresses the responses with zstd too
+	require.Equal(t, []string{zstdcompressor.Name, zstdcompressor.Name}, serverStats.get())
+	require.Equal(t, []string{zstdcompressor.Name, zstdcompressor.Name}, clientStats.get())
+}
@@ -14,21 +14,24 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-//go:build !windows
-
 // Package main defines a registry-memory application
 package main
 
 import (
 	"context"
 	"crypto/tls"
+	"flag"
+	"net"
+	"net/http"
 	"net/url"
 	"os"
 	"os/signal"
-	"syscall"
+	"strings"
 	"time"
 
+	"github.com/edwarnicke/genericsync"
 	"github.com/edwarnicke/grpcfd"
+	"github.com/pkg/errors"
 
 	"github.com/NikitaSkrynnik/sdk/pkg/tools/opentelemetry"
 	"github.com/NikitaSkrynnik/sdk/pkg/tools/spiffejwt"
@@ -38,41 +41,238 @@ import (
 	nested "github.com/antonfisher/nested-logrus-formatter"
 	"github.com/kelseyhightower/envconfig"
 	"github.com/sirupsen/logrus"
+	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
 	"github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
+	"github.com/spiffe/go-spiffe/v2/svid/x509svid"
 	"github.com/spiffe/go-spiffe/v2/workloadapi"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	// The server advertises gzip and compresses the responses to the clients sending gzip compressed requests
+	_ "google.golang.org/grpc/encoding/gzip"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/reflection"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
 
-	"github.com/NikitaSkrynnik/sdk/pkg/registry/chains/memory"
+	registryserver "github.com/NikitaSkrynnik/sdk/pkg/registry"
 	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/authorize"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/begin"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/null"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/refresh"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/chain"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
 	"github.com/NikitaSkrynnik/sdk/pkg/tools/debug"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/dnsutils"
 	"github.com/NikitaSkrynnik/sdk/pkg/tools/grpcutils"
 	"github.com/NikitaSkrynnik/sdk/pkg/tools/log"
 	"github.com/NikitaSkrynnik/sdk/pkg/tools/log/logruslogger"
+	"github.com/NikitaSkrynnik/sdk/pkg/tools/opa"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/admin"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/buildinfo"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/catalog"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/connpool"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/counters"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/deadlines"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/discovery"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/dnsexpose"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/events"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/expirenotify"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/export"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/fakestate"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/federation"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/handshake"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/history"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/identity"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/listen"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/natspublisher"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/nsgc"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/nsstats"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/outboundcreds"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/peercred"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/peerstats"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/probe"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/redact"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/admission"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/budget"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/chains/memory"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/chaos"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/clockskew"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/conflicts"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/defaultexpiration"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/derivedlabels"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/elementerrors"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/extauthz"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/inprocess"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/jitter"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/labelselector"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/listwatch"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/mirror"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/negativecache"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/nsestore"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/nsevents"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/pagination"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/peerselect"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/plugins"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/queryparams"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/serviceoverrides"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/swap"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/tenancy"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/timeprecision"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/upstream"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/watchdedup"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/watchevents"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/reload"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/revocation"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/sizemetrics"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/synced"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/tlssource"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/tombstones"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/varz"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/webhooks"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/zstdcompressor"
+)
+
+const subscriberBufferSize = 1024
+
+// countersSavePeriod is how often the persisted counters are saved
+const countersSavePeriod = 10 * time.Second
+
+const (
+	tlsModeSPIFFE   = "spiffe"
+	tlsModeFile     = "file"
+	tlsModeInsecure = "insecure"
+	tlsModePeerCred = "peercred"
 )
 
 // Config is configuration for cmd-registry-memory
 type Config struct {
-	ListenOn               []url.URL     `default:"unix:///listen.on.socket" desc:"url to listen on." split_words:"true"`
+	Preset                 string        `desc:"named combination of the optional features the other variables override: minimal (no tombstones and history), standard (the defaults), interdomain (proxy registry fallback with the negative cache) or secure (strict registration, tenancy and 0660 sockets)" enum:"minimal,standard,interdomain,secure"`
+	ListenOn               listen.URLs   `default:"unix:///listen.on.socket" desc:"url to listen on, e.g. tcp://[::]:5002 for dual-stack or tcp://[fe80::1%eth0]:5002 for IPv6 link-local" split_words:"true"`
+	ListenInterface        string        `desc:"name of the interface to listen on: tcp URLs with unspecified host listen on the interface addresses instead" split_words:"true"`
+	ListenSocketMode       string        `desc:"octal file mode of the unix listen sockets, e.g. 0660, empty keeps 0777" split_words:"true"`
+	ListenSocketOwner      string        `desc:"owner of the unix listen sockets: <uid>[:<gid>], empty keeps the process user" split_words:"true"`
 	MaxTokenLifetime       time.Duration `default:"10m" desc:"maximum lifetime of tokens" split_words:"true"`
 	RegistryServerPolicies []string      `default:"etc/nsm/opa/common/.*.rego,etc/nsm/opa/registry/.*.rego,etc/nsm/opa/server/.*.rego" desc:"paths to files and directories that contain registry server policies" split_words:"true"`
 	RegistryClientPolicies []string      `default:"etc/nsm/opa/common/.*.rego,etc/nsm/opa/registry/.*.rego,etc/nsm/opa/client/.*.rego" desc:"paths to files and directories that contain registry client policies" split_words:"true"`
 	ProxyRegistryURL       url.URL       `desc:"url to the proxy registry that handles this domain" split_words:"true"`
+	ProxyRegistryPeers     listen.URLs   `desc:"urls to the redundant proxy registries that handle this domain besides the proxy registry url, the interdomain queries go to the healthiest of them" split_words:"true"`
+	ProxyRegistryFallback  bool          `desc:"forward Find queries with no local match to the proxy registry" split_words:"true"`
+	ProxyRegistryPushLocal bool          `desc:"push local registrations to the proxy registry in the background" split_words:"true"`
+	NegativeCacheTTL       time.Duration `desc:"how long empty results of the interdomain and fallback Find queries are cached, 0 disables caching" split_words:"true"`
+	MirrorPrimaryURL       url.URL       `desc:"url of the primary registry to mirror: its state is watched and served by the local Find even while it is unreachable, Register and Unregister are forwarded to it, empty disables" split_words:"true"`
+	MirrorRetryPeriod      time.Duration `default:"5s" desc:"period the lost watch of the mirrored primary registry is retried with" split_words:"true"`
 	ExpirePeriod           time.Duration `default:"1s" desc:"period to check expired NSEs" split_words:"true"`
 	LogLevel               string        `default:"INFO" desc:"Log level" split_words:"true"`
+	LogFormat              string        `default:"text" desc:"Log format: text or json" split_words:"true" enum:"text,json"`
+	RedactFields           []string      `desc:"names of the sensitive fields and labels, e.g. url, the values of which are redacted from all the log lines and the trace attributes, the stored data is not changed" split_words:"true"`
+	RedactMode             string        `default:"strip" desc:"redaction of the sensitive values: strip or hash (truncated SHA-256, so the values can be correlated)" split_words:"true" enum:"strip,hash"`
+	TLSMode                string        `default:"spiffe" desc:"TLS mode: spiffe (workload API), file (TLS_CERT_FILE, TLS_KEY_FILE, TLS_CA_FILE) insecure (no TLS, development only) or peercred (unix sockets only, the local peers authenticated by SO_PEERCRED)" split_words:"true" enum:"spiffe,file,insecure,peercred"`
+	TLSCertFile            string        `desc:"path to the PEM certificate with a SPIFFE ID URI SAN for the file TLS mode, reloaded on change" split_words:"true"`
+	TLSKeyFile             string        `desc:"path to the PEM private key for the file TLS mode, reloaded on change" split_words:"true"`
+	TLSCAFile              string        `desc:"path to the PEM CA certificates for the file TLS mode, reloaded on change" split_words:"true"`
+	TLSSessionTickets      bool          `default:"true" desc:"issue TLS session tickets, so the reconnecting clients resume the sessions instead of the full handshakes" split_words:"true"`
+	TLSSessionCacheSize    int           `default:"1024" desc:"number of the TLS sessions cached to resume the outbound connections, 0 disables the resumption" split_words:"true" min:"0"`
+	TLSHandshakeTimeout    time.Duration `default:"120s" desc:"timeout of the connection establishment including the TLS handshake" split_words:"true"`
+	PeerCredUIDs           []uint32      `desc:"UIDs of the local peers allowed in the peercred TLS mode, all the local peers are allowed if neither UIDs nor GIDs are set" split_words:"true"`
+	PeerCredGIDs           []uint32      `desc:"GIDs of the local peers allowed in the peercred TLS mode" split_words:"true"`
+	PeerCredentialsFile    string        `desc:"path to a JSON file with the dedicated client certificates and token audiences of the outbound peers, e.g. the proxy registries of partner domains" split_words:"true"`
+	FederatesWith          []string      `desc:"federated SPIFFE trust domains with the Web PKI bundle endpoints: <trust domain>=<URL>,..." split_words:"true"`
 	OpenTelemetryEndpoint  string        `default:"otel-collector.observability.svc.cluster.local:4317" desc:"OpenTelemetry Collector Endpoint"`
+	DefaultExpiration      time.Duration `default:"1m" desc:"default expiration for endpoints registered without expiration time" split_words:"true"`
+	ExpirationJitter       int           `desc:"percent of the NSE remaining lifetime the granted expiration time is randomly shortened by, so refreshes spread out, 0 disables" split_words:"true" min:"0" max:"100"`
+	ExpirationMinLifetime  time.Duration `desc:"minimum lifetime of the NSE registration from the server receive time, so the clients with the clock ahead don't register expired NSEs, 0 disables" split_words:"true"`
+	ExpirationMaxLifetime  time.Duration `desc:"maximum lifetime of the NSE registration from the server receive time, so the clients with the clock behind don't register near-immortal NSEs, 0 disables" split_words:"true"`
+	ConfigFile             string        `desc:"path to a file with REGISTRY_MEMORY_* variables overriding the environment, reloaded on SIGHUP or on change" split_words:"true"`
+	DryRun                 bool          `desc:"check the config, print the effective values and exit, same as the check-config command" split_words:"true"`
+	CrashDumpDir           string        `desc:"directory the goroutine dump is written to on SIGQUIT and an unrecovered panic to, the fatal startup errors exit with the codes 3 config, 4 listen, 5 identity, 6 state, 7 dependency after a JSON FATAL line" split_words:"true"`
+	RevocationListFile     string        `desc:"path to a file with revoked SPIFFE IDs and certificate serial numbers, reloaded on SIGHUP or on change" split_words:"true"`
+	TimestampPrecision     time.Duration `desc:"precision to truncate NSE timestamps to, e.g. 1s, 0 keeps the client precision" split_words:"true"`
+	ServiceOverridesFile   string        `desc:"path to a JSON file with per network service overrides, reloaded with the config file" split_words:"true"`
+	DerivedLabelsFile      string        `desc:"path to a JSON file with the templates of the labels derived from the NSE fields, reloaded with the config file" split_words:"true"`
+	ExtAuthzURL            url.URL       `desc:"url of an Envoy ext_authz compatible gRPC service authorizing the registry calls instead of the registry server policies" split_words:"true"`
+	ExtAuthzCacheTTL       time.Duration `default:"10s" desc:"how long the ext_authz decisions are cached, 0 disables the cache" split_words:"true"`
+	ExtAuthzFailOpen       bool          `desc:"allow the registry calls if the ext_authz service can't be reached" split_words:"true"`
+	AdmissionWebhookURL    string        `desc:"URL of the webhook validating the NS and NSE registrations, empty disables" split_words:"true"`
+	AdmissionFailOpen      bool          `desc:"accept the registrations if the admission webhook fails" split_words:"true"`
+	StrictRegistration     bool          `desc:"reject the NSE registrations duplicating a URL of another NSE or a name registered by another SPIFFE ID" split_words:"true"`
+	TenancyEnabled         bool          `desc:"partition registrations by the caller SPIFFE trust domain" split_words:"true"`
+	IdentityMappingFile    string        `desc:"path to a JSON file mapping SPIFFE ID patterns to identity labels (tenant, role, ...), reloaded with the config file" split_words:"true"`
+	TenancyAdminIDs        []string      `desc:"SPIFFE IDs seeing and managing the registrations of all the trust domains" split_words:"true"`
+	FindMaxResults         int           `desc:"maximum number of NSEs sent for a Find query, 0 means no limit" split_words:"true"`
+	WatchDeduplication     bool          `desc:"suppress watch updates changing nothing but expiration time" split_words:"true"`
+	WatchCoalesceWindow    time.Duration `desc:"window to coalesce watch updates in when watch deduplication is enabled, 0 disables coalescing" split_words:"true"`
+	WatchResyncInterval    time.Duration `desc:"base interval of the periodic full resyncs of the NSE watch streams asking for them with the resync query parameter or metadata, spread by a jitter, 0 disables" split_words:"true"`
+	WatchResyncMaxRate     float64       `default:"1000" desc:"NSEs per second the resyncs of all the watch streams send at most, the resync interval is stretched under load to keep it, 0 is no limit" split_words:"true"`
+	BudgetMaxEntries       int           `desc:"maximum number of registered NSEs, 0 means no limit, reloaded with the config file" split_words:"true"`
+	BudgetMaxBytes         int64         `desc:"maximum total encoded size of the registered NSEs approximating their memory use, 0 means no limit, reloaded with the config file" split_words:"true"`
+	BudgetPolicy           string        `default:"reject" desc:"action on a registration exceeding the budget: reject (ResourceExhausted) or evict-expiring" split_words:"true" enum:"reject,evict-expiring"`
+	ProbePeriod            time.Duration `desc:"period of the synthetic register, find and unregister probe through the first listen URL, 0 disables" split_words:"true"`
+	InterdomainProbeDomain string        `desc:"remote domain to register a canary NSE in through the first listen URL and the proxy registry and find it with a peer query to the proxy registry, empty disables" split_words:"true"`
+	InterdomainProbePeriod time.Duration `default:"1m" desc:"period of the interdomain probe" split_words:"true"`
+	ChaosEnabled           bool          `desc:"inject faults for the integration testing of the registry clients, for testing only" split_words:"true"`
+	ChaosLatency           time.Duration `desc:"maximum random latency added to the calls when the chaos mode is enabled" split_words:"true"`
+	ChaosErrorRate         float64       `desc:"rate from 0 to 1 of the calls failing with Unavailable when the chaos mode is enabled" split_words:"true" min:"0" max:"1"`
+	ChaosDropRate          float64       `desc:"rate from 0 to 1 of the Find stream events dropped when the chaos mode is enabled" split_words:"true" min:"0" max:"1"`
+	CountersFile           string        `desc:"path to a file persisting the registration, expiration and restart counters across restarts, empty disables" split_words:"true"`
+	TombstoneRetention     time.Duration `default:"10m" desc:"how long to keep tombstones of the removed NSEs, 0 disables tombstones" split_words:"true"`
+	HistoryRevisions       int           `default:"10" desc:"number of the last revisions kept per NSE for the admin History API, 0 disables the history" split_words:"true" min:"0"`
+	HistoryRetention       time.Duration `default:"1h" desc:"how long to keep the history of an NSE after its last registration or unregistration" split_words:"true"`
+	ExpireNotifyEnabled    bool          `desc:"call Unregister at the URL of an expired NSE, so the endpoint side state is cleaned up" split_words:"true"`
+	ExpireNotifyMaxRetries int           `default:"3" desc:"number of retries with exponential backoff of a failed expire notification" split_words:"true"`
+	ExpireNotifyBackoff    time.Duration `default:"1s" desc:"delay before the first retry of a failed expire notification, doubled with each retry up to 30s" split_words:"true"`
+	ExpireNotifyTimeout    time.Duration `default:"5s" desc:"timeout of an expire notification attempt" split_words:"true"`
+	EmptyServiceRetention  time.Duration `desc:"how long a network service with no NSEs is kept before it is unregistered, the protected ones are kept, 0 keeps them forever" split_words:"true"`
+	AdminIDs               []string      `desc:"SPIFFE IDs allowed to call the admin API" split_words:"true"`
+	DNSListenOn            string        `desc:"address to serve DNS records of the registered NSEs on, e.g. :53, empty disables DNS" split_words:"true"`
+	DNSZone                string        `default:"nsm." desc:"DNS zone to serve NSE records in" split_words:"true"`
+	DNSTenant              string        `desc:"tenant whose NSEs are served over DNS when the tenancy is enabled, none are served by default" split_words:"true"`
+	CatalogListenOn        url.URL       `desc:"url of the unauthenticated plaintext listener serving the read-only Find of the catalog network services and their NSEs to the tooling outside the trust domain, e.g. tcp://:5003, empty disables" split_words:"true"`
+	CatalogNetworkServices []string      `desc:"names of the network services served by the catalog listener" split_words:"true"`
+	CatalogRateLimit       float64       `default:"10" desc:"Find calls per second the catalog listener serves to all the clients" split_words:"true"`
+	CatalogRateBurst       int           `default:"20" desc:"Find calls the catalog listener serves in a burst above the rate limit" split_words:"true"`
+	WebhookURLs            []string      `desc:"URLs to POST JSON registry events to, e.g. NSE registered, refreshed, expired, unregistered" split_words:"true"`
+	ListenAfterSync        bool          `desc:"open the listeners only after the registry is synced, see the registry-memory.synced health service" split_words:"true"`
+	HealthHTTPListenOn     string        `desc:"address to serve the /livez and /readyz HTTP probes on, e.g. :8080, empty disables" split_words:"true"`
+	HealthCheckPeriod      time.Duration `default:"10s" desc:"period of the checks of the persistence directory, proxy registry and policy engine turning the registry health services NOT_SERVING on failure, 0 disables" split_words:"true"`
+	StatusHTTPListenOn     string        `desc:"loopback address to serve the plaintext /healthz, /readyz, /varz and /stats (Prometheus snapshot) HTTP endpoints on, e.g. 127.0.0.1:8081, empty disables" split_words:"true"`
+	GRPCReflection         bool          `desc:"enable gRPC server reflection, e.g. for grpcurl" envconfig:"GRPC_REFLECTION"`
+	WebhookMaxRetries      int           `default:"5" desc:"number of retries with exponential backoff of a failed webhook delivery" split_words:"true"`
+	NATSURL                url.URL       `desc:"nats://[user:password@]host[:port], nats://token@host[:port] or tls://... NATS server URL to mirror registry events to, empty disables" envconfig:"NATS_URL"`
+	NATSSubject            string        `default:"registry-memory.events" desc:"NATS subject to publish registry events on, with no whitespace or wildcards" split_words:"true"`
+	NATSTLSCAFile          string        `desc:"path to the PEM CA certificates verifying the NATS server, the system roots if empty" envconfig:"NATS_TLS_CA_FILE"`
+	NATSTLSCertFile        string        `desc:"path to the PEM client certificate for the NATS server requiring it" envconfig:"NATS_TLS_CERT_FILE"`
+	NATSTLSKeyFile         string        `desc:"path to the PEM private key of the NATS client certificate" envconfig:"NATS_TLS_KEY_FILE"`
+	ChainOrder             []string      `desc:"order of the optional chain elements: chaos, extauthz, tenancy, admission, watchdedup and the plugin ones, the unlisted ones follow in the default order" split_words:"true"`
+
+	MaxConcurrentStreams         uint32        `desc:"maximum number of concurrent streams per client connection, 0 means grpc default" split_words:"true"`
+	MaxRecvMsgSize               int           `desc:"maximum message size in bytes the server can receive, 0 means grpc default" split_words:"true"`
+	MaxSendMsgSize               int           `desc:"maximum message size in bytes the server can send, 0 means grpc default" split_words:"true"`
+	ZstdCompression              bool          `desc:"advertise zstd besides gzip and compress the responses to the clients sending zstd compressed requests, not reloaded" split_words:"true"`
+	KeepaliveMinTime             time.Duration `desc:"minimum time clients should wait before sending a keepalive ping, 0 means grpc default" split_words:"true"`
+	KeepalivePermitWithoutStream bool          `desc:"allow clients to send keepalive pings when there are no active streams" split_words:"true"`
+	KeepaliveTime                time.Duration `desc:"period of server keepalive pings on idle connections, 0 means grpc default" split_words:"true"`
+	KeepaliveTimeout             time.Duration `desc:"timeout for server keepalive ping ack, 0 means grpc default" split_words:"true"`
+	MaxConnectionIdle            time.Duration `desc:"duration after which an idle client connection is closed, 0 means infinity" split_words:"true"`
+	MaxConnectionAge             time.Duration `desc:"duration after which a client connection is gracefully closed, 0 means infinity" split_words:"true"`
+	MaxConnectionAgeGrace        time.Duration `desc:"time the streams of a closing aged client connection have to complete, 0 means infinity" split_words:"true"`
+	UnaryTimeout                 time.Duration `desc:"server deadline of the unary calls, an earlier client deadline is kept, 0 disables" split_words:"true"`
+	StreamSendTimeout            time.Duration `desc:"how long a send to a stream, e.g. a Find watch, may block on the peer not reading it before the stream is closed, 0 disables" split_words:"true"`
+
+	OutboundIdleTimeout         time.Duration `default:"5m" desc:"how long an unused outbound connection to the proxy registry or the ext_authz service is kept open" split_words:"true"`
+	OutboundHealthCheckInterval time.Duration `default:"30s" desc:"interval of closing the idle and the failed outbound connections" split_words:"true"`
+	PeerRecoveryHalfLife        time.Duration `default:"30s" desc:"half-life of the error rate of a failed proxy registry peer, after which it is preferred again" split_words:"true"`
+	ProxyRegistryPushTimeout    time.Duration `default:"5s" desc:"timeout of a push of a local registration to the proxy registry, the pushes run in the background" split_words:"true"`
 }
 
 func main() {
+	fakeStateSize := parseFlags()
+
 	// Setup context to catch signals
-	ctx, cancel := signal.NotifyContext(
-		context.Background(),
-		os.Interrupt,
-		// More Linux signals here
-		syscall.SIGHUP,
-		syscall.SIGTERM,
-		syscall.SIGQUIT,
-	)
+	ctx, cancel := signal.NotifyContext(context.Background(), shutdownSignals...)
 	defer cancel()
 
 	// Setup logging
@@ -88,97 +288,196 @@ func main() {
 	startTime := time.Now()
 
 	// Get config from environment
-	config := &Config{}
-	if err := envconfig.Usage("registry_memory", config); err != nil {
-		logrus.Fatal(err)
+	config, err := loadConfig()
+	if err != nil {
+		fatal(exitConfig, err)
 	}
-	if err := envconfig.Process("registry_memory", config); err != nil {
-		logrus.Fatalf("error processing config from env: %+v", err)
+	if err = setupDiagnostics(ctx, cancel, config); err != nil {
+		fatal(exitConfig, err)
 	}
-
-	l, err := logrus.ParseLevel(config.LogLevel)
-	if err != nil {
-		logrus.Fatalf("invalid log level %s", config.LogLevel)
+	if flag.Arg(0) == checkConfigCommand || config.DryRun {
+		printConfig(os.Stdout, config)
+		if err = checkConfig(ctx, config); err != nil {
+			fatal(exitConfig, err)
+		}
+		return
+	}
+	if err = envconfig.Usage("registry_memory", &Config{}); err != nil {
+		fatal(exitConfig, err)
 	}
-	logrus.SetLevel(l)
 
+	info := buildinfo.Get()
+	log.FromContext(ctx).Infof("Version: %s, commit: %s, build date: %s, %s", info.Version, info.Commit, info.Date, info.GoVersion)
 	log.FromContext(ctx).Infof("Config: %#v", config)
+	warnAuthorizationDisabled(ctx, config)
 
 	// Configure Open Telemetry
-	if opentelemetry.IsEnabled() {
-		collectorAddress := config.OpenTelemetryEndpoint
-		spanExporter := opentelemetry.InitSpanExporter(ctx, collectorAddress)
-		metricExporter := opentelemetry.InitMetricExporter(ctx, collectorAddress)
-		o := opentelemetry.Init(ctx, spanExporter, metricExporter, "registry-memory")
-		defer func() {
-			if err = o.Close(); err != nil {
-				log.FromContext(ctx).Error(err.Error())
-			}
-		}()
-	}
+	defer initOpenTelemetry(ctx, config)()
 
 	// Get a X509Source
-	source, err := workloadapi.NewX509Source(ctx)
+	source, err := newX509Source(ctx, config)
 	if err != nil {
-		logrus.Fatalf("error getting x509 source: %+v", err)
+		fatal(exitIdentity, errors.Wrap(err, "error getting x509 source"))
 	}
 	svid, err := source.GetX509SVID()
 	if err != nil {
-		logrus.Fatalf("error getting x509 svid: %+v", err)
+		fatal(exitIdentity, errors.Wrap(err, "error getting x509 svid"))
 	}
 	logrus.Infof("SVID: %q", svid.ID)
 
-	tlsClientConfig := tlsconfig.MTLSClientConfig(source, source, tlsconfig.AuthorizeAny())
-	tlsClientConfig.MinVersion = tls.VersionTLS12
-	tlsServerConfig := tlsconfig.MTLSServerConfig(source, source, tlsconfig.AuthorizeAny())
-	tlsServerConfig.MinVersion = tls.VersionTLS12
+	bundleSource, err := newBundleSource(ctx, config, source)
+	if err != nil {
+		fatal(exitIdentity, errors.Wrap(err, "error configuring federation"))
+	}
+	tlsClientConfig, tlsServerConfig := newTLSConfigs(config, source, bundleSource)
+
+	revoked, err := loadRevocationList(config)
+	if err != nil {
+		fatal(exitConfig, err)
+	}
 
-	credsTLS := credentials.NewTLS(tlsServerConfig)
 	// Create GRPC Server and register services
-	serverOptions := append(tracing.WithTracing(), grpc.Creds(credsTLS))
-	server := grpc.NewServer(serverOptions...)
+	server, peers, err := newGRPCServer(ctx, config, tlsServerConfig, revoked)
+	if err != nil {
+		fatal(exitInternal, err)
+	}
 
-	clientOptions := append(
-		tracing.WithTracingDial(),
-		grpc.WithBlock(),
-		grpc.WithDefaultCallOptions(
-			grpc.WaitForReady(true),
-			grpc.PerRPCCredentials(token.NewPerRPCCredentials(spiffejwt.TokenGeneratorFunc(source, config.MaxTokenLifetime)))),
-		grpc.WithTransportCredentials(
-			grpcfd.TransportCredentials(credentials.NewTLS(tlsClientConfig))),
-		grpcfd.WithChainStreamInterceptor(),
-		grpcfd.WithChainUnaryInterceptor(),
-	)
+	clientOptions := newDialOptions(spiffejwt.TokenGeneratorFunc(source, config.MaxTokenLifetime), tlsClientConfig)
 
-	memory.NewServer(
-		ctx,
-		spiffejwt.TokenGeneratorFunc(source, config.MaxTokenLifetime),
-		memory.WithAuthorizeNSERegistryServer(authorize.NewNetworkServiceEndpointRegistryServer(
-			authorize.WithPolicies(config.RegistryServerPolicies...))),
-		memory.WithAuthorizeNSERegistryClient(authorize.NewNetworkServiceEndpointRegistryClient(
-			authorize.WithPolicies(config.RegistryClientPolicies...))),
-		memory.WithAuthorizeNSRegistryServer(authorize.NewNetworkServiceRegistryServer(
-			authorize.WithPolicies(config.RegistryServerPolicies...))),
-		memory.WithAuthorizeNSRegistryClient(authorize.NewNetworkServiceRegistryClient(
-			authorize.WithPolicies(config.RegistryClientPolicies...))),
-		memory.WithDefaultExpiration(time.Minute),
-		memory.WithProxyRegistryURL(&config.ProxyRegistryURL),
-		memory.WithDialOptions(clientOptions...)).Register(server)
+	bus := events.NewBus()
+	waitCounters := startCounters(ctx, config, bus)
+	elements := newReloadableElements(config)
+	tombstoneStore, historyStore := newTombstoneStore(config), newHistoryStore(config)
+	pool := newConnPool(ctx, config, source, clientOptions...)
+	registryServer := newRegistryServer(ctx, config, spiffejwt.TokenGeneratorFunc(source, config.MaxTokenLifetime), bus, elements, tombstoneStore, historyStore, pool)
+	nsClient := adapters.NetworkServiceServerToClient(registryServer.NetworkServiceRegistryServer())
+	nseClient := adapters.NetworkServiceEndpointServerToClient(registryServer.NetworkServiceEndpointRegistryServer())
+	syncedCondition := registerServices(ctx, config, server, bus, registryServer, nseClient, tombstoneStore, historyStore, elements.identities, peers, pool)
+
+	notifyReloads(ctx, config, elements, revoked, nsClient, nseClient)
+
+	if !config.ListenAfterSync {
+		listenAndServe(ctx, cancel, config, server)
+	}
+
+	generateFakeState(ctx, *fakeStateSize, nsClient, nseClient)
+
+	syncedCondition.Set()
+	if config.ListenAfterSync {
+		listenAndServe(ctx, cancel, config, server)
+	}
+
+	completeStartup(ctx, config, startTime, clientOptions...)
+	bus.Publish(ctx, events.New(events.TypeStarted, map[string]string{"svid": svid.ID.String()}))
+
+	<-ctx.Done()
+	bus.Publish(ctx, events.New(events.TypeShuttingDown, nil))
+	bus.Close()
+	waitCounters()
+}
+
+// parseFlags parses the command line flags and returns the number of the synthetic NSEs to generate. It applies the dev
+// mode, runs the bench, export-stats, export-state or import-state command or prints the config schema and exits if
+// asked to.
+func parseFlags() (fakeStateSize *int) {
+	fakeStateSize = flag.Int("generate-fake-state", 0, "dev mode: populate the registry with N synthetic NSEs at startup")
+	printSchema := flag.Bool("print-config-schema", false, "print the JSON Schema of the config environment variables and exit")
+	dev := flag.Bool("dev", false, "dev mode: serve plaintext on tcp://127.0.0.1:5002 with a self-signed identity, seeded NSEs, "+
+		"gRPC reflection, debug logging and the status HTTP endpoints on 127.0.0.1:8081, the environment overrides")
+	flag.Parse()
+
+	if *dev {
+		if err := applyDevMode(fakeStateSize); err != nil {
+			fatal(exitConfig, err)
+		}
+	}
+
+	switch flag.Arg(0) {
+	case benchCommand:
+		runCommand(runBench, flag.Args()[1:])
+	case exportStatsCommand:
+		runCommand(runExportStats, flag.Args()[1:])
+	case exportStateCommand:
+		runCommand(runExportState, flag.Args()[1:])
+	case importStateCommand:
+		runCommand(runImportState, flag.Args()[1:])
+	}
+	if *printSchema {
+		if err := printConfigSchema(os.Stdout); err != nil {
+			fatal(exitInternal, err)
+		}
+		os.Exit(0)
+	}
+	return fakeStateSize
+}
+
+// initOpenTelemetry configures Open Telemetry if it is enabled and returns a function closing it
+func initOpenTelemetry(ctx context.Context, config *Config) func() {
+	if !opentelemetry.IsEnabled() {
+		return func() {}
+	}
+	collectorAddress := config.OpenTelemetryEndpoint
+	spanExporter := opentelemetry.InitSpanExporter(ctx, collectorAddress)
+	redactor, err := newRedactor(config)
+	if err != nil {
+		fatal(exitConfig, err)
+	}
+	if redactor != nil {
+		spanExporter = redact.NewSpanExporter(spanExporter, redactor)
+	}
+	metricExporter := opentelemetry.InitMetricExporter(ctx, collectorAddress)
+	o := opentelemetry.Init(ctx, spanExporter, metricExporter, "registry-memory")
+	return func() {
+		if err := o.Close(); err != nil {
+			log.FromContext(ctx).Error(err.Error())
+		}
+	}
+}
 
+// notifyReloads reloads the config file and the revocation list on change or on SIGHUP
+func notifyReloads(
+	ctx context.Context,
+	config *Config,
+	elements *reloadableElements,
+	revoked *revocation.List,
+	nsClient registry.NetworkServiceRegistryClient,
+	nseClient registry.NetworkServiceEndpointRegistryClient,
+) {
+	reload.Notify(ctx, config.ConfigFile, func() {
+		reloadConfig(ctx, elements)
+	})
+	if config.RevocationListFile != "" {
+		reload.Notify(ctx, config.RevocationListFile, func() {
+			reloadRevocationList(ctx, config.RevocationListFile, revoked, nsClient, nseClient)
+		})
+	}
+}
+
+// listenAndServe starts serving the server on all the config.ListenOn URLs
+func listenAndServe(ctx context.Context, cancel context.CancelFunc, config *Config, server *grpc.Server) {
+	urls, err := listen.ExpandInterface(config.ListenOn, config.ListenInterface)
+	if err != nil {
+		fatal(exitListen, err)
+	}
+	config.ListenOn = urls
+	permissions, err := listen.ParseSocketPermissions(config.ListenSocketMode, config.ListenSocketOwner)
+	if err != nil {
+		fatal(exitConfig, err)
+	}
 	for i := 0; i < len(config.ListenOn); i++ {
 		srvErrCh := grpcutils.ListenAndServe(ctx, &config.ListenOn[i], server)
 		exitOnErr(ctx, cancel, srvErrCh)
+		if applyErr := permissions.Apply(&config.ListenOn[i]); applyErr != nil {
+			fatal(exitListen, applyErr)
+		}
 	}
-
-	log.FromContext(ctx).Infof("Startup completed in %v", time.Since(startTime))
-	<-ctx.Done()
 }
 
 func exitOnErr(ctx context.Context, cancel context.CancelFunc, errCh <-chan error) {
 	// If we already have an error, log it and exit
 	select {
 	case err := <-errCh:
-		log.FromContext(ctx).Fatal(err)
+		fatal(exitListen, err)
 	default:
 	}
 	// Otherwise wait for an error in the background to log and cancel
@@ -188,3 +487,1125 @@ func exitOnErr(ctx context.Context, cancel context.CancelFunc, errCh <-chan erro
 		cancel()
 	}(ctx, errCh)
 }
+
+// completeStartup reports the completed startup and starts the synthetic probes, the server must be listening already
+func completeStartup(ctx context.Context, config *Config, startTime time.Time, dialOptions ...grpc.DialOption) {
+	log.FromContext(ctx).Infof("Startup completed in %v", time.Since(startTime))
+	printReady(os.Stdout, config)
+
+	if len(config.ListenOn) == 0 {
+		return
+	}
+	if config.ProbePeriod > 0 {
+		startProbe(ctx, config.ProbePeriod, []*url.URL{&config.ListenOn[0]}, dialOptions)
+	}
+	if config.InterdomainProbeDomain != "" && config.ProxyRegistryURL.String() != "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			hostname = "registry"
+		}
+		startProbe(ctx, config.InterdomainProbePeriod, []*url.URL{&config.ListenOn[0], &config.ProxyRegistryURL}, dialOptions,
+			probe.WithName(probe.DefaultName+"-"+hostname+"@"+config.InterdomainProbeDomain),
+			probe.WithPath(probe.PathInterdomain))
+	}
+}
+
+// startProbe starts the probe registering through the first URL and finding through the last one until ctx is done
+func startProbe(ctx context.Context, period time.Duration, urls []*url.URL, dialOptions []grpc.DialOption, opts ...probe.Option) {
+	var clients []registry.NetworkServiceEndpointRegistryClient
+	for _, u := range urls {
+		cc, err := grpc.DialContext(ctx, grpcutils.URLToTarget(u), dialOptions...)
+		if err != nil {
+			log.FromContext(ctx).Errorf("failed to dial %s for the probe: %s", u.String(), err.Error())
+			return
+		}
+		go func() {
+			<-ctx.Done()
+			_ = cc.Close()
+		}()
+		clients = append(clients, registry.NewNetworkServiceEndpointRegistryClient(cc))
+	}
+	opts = append(opts, probe.WithFindClient(clients[len(clients)-1]))
+	go probe.New(clients[0], opts...).Run(ctx, period)
+}
+
+// serveHTTP serves the handler on the address until ctx is done
+func serveHTTP(ctx context.Context, address string, handler http.Handler) {
+	server := &http.Server{
+		Addr:              address,
+		Handler:           handler,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		_ = server.Close()
+	}()
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		log.FromContext(ctx).Errorf("failed to serve HTTP on %s: %s", address, err.Error())
+	}
+}
+
+// serveCatalog serves the read-only Find of the catalog network services on the unauthenticated catalog listener, all
+// the other calls stay on the authenticated listeners
+func serveCatalog(
+	ctx context.Context,
+	config *Config,
+	nsClient registry.NetworkServiceRegistryClient,
+	nseClient registry.NetworkServiceEndpointRegistryClient,
+) {
+	c := catalog.New(
+		catalog.WithNetworkServices(config.CatalogNetworkServices...),
+		catalog.WithRateLimit(config.CatalogRateLimit, config.CatalogRateBurst))
+	server := grpc.NewServer(append(tracing.WithTracing(), grpc.Creds(insecure.NewCredentials()))...)
+	registry.RegisterNetworkServiceRegistryServer(server, catalog.NewNetworkServiceRegistryServer(c, nsClient))
+	registry.RegisterNetworkServiceEndpointRegistryServer(server, catalog.NewNetworkServiceEndpointRegistryServer(c, nseClient))
+
+	errCh := grpcutils.ListenAndServe(ctx, &config.CatalogListenOn, server)
+	go func() {
+		if err := <-errCh; err != nil {
+			log.FromContext(ctx).Errorf("failed to serve the catalog on %s: %s", config.CatalogListenOn.String(), err.Error())
+		}
+	}()
+	log.FromContext(ctx).Infof("Catalog of %s served on %s", strings.Join(config.CatalogNetworkServices, ", "), config.CatalogListenOn.String())
+}
+
+// serveStatus serves the plaintext status endpoints and the Prometheus snapshot of the network service statistics on
+// the loopback address, if it is set
+func serveStatus(
+	ctx context.Context,
+	config *Config,
+	bus *events.Bus,
+	syncedCondition *synced.Condition,
+	stats *nsstats.Tracker,
+	peers *peerstats.Tracker,
+	pool *connpool.Pool,
+) {
+	if config.StatusHTTPListenOn == "" {
+		return
+	}
+	vars := varz.New(ctx)
+	vars.Publish("version", func() interface{} { return buildinfo.Get().Version })
+	vars.Publish("synced", func() interface{} { return syncedCondition.Synced() })
+	vars.Publish("failed_health_checks", func() interface{} { return syncedCondition.Failures() })
+	vars.Publish("network_services", func() interface{} { return len(stats.Stats(clock.FromContext(ctx).Now())) })
+	vars.Publish("peers", func() interface{} { return len(peers.Peers(clock.FromContext(ctx).Now())) })
+	vars.Publish("outbound_connections", func() interface{} { return pool.Len() })
+	go vars.Run(bus.Subscribe(subscriberBufferSize))
+	mux := http.NewServeMux()
+	mux.Handle("/", vars.Handler(syncedCondition.Synced))
+	mux.HandleFunc("/stats", func(w http.ResponseWriter, _ *http.Request) {
+		now := clock.FromContext(ctx).Now()
+		w.Header().Set("Content-Type", export.PrometheusContentType)
+		if err := export.StatsPrometheus(w, stats.Stats(now), now); err != nil {
+			log.FromContext(ctx).Warn(err.Error())
+		}
+	})
+	go serveHTTP(ctx, config.StatusHTTPListenOn, mux)
+}
+
+// startCounters loads the persisted counters and counts the bus events. It returns a function waiting for the final
+// save of the counters, to be called after the bus is closed.
+func startCounters(ctx context.Context, config *Config, bus *events.Bus) func() {
+	if config.CountersFile == "" {
+		return func() {}
+	}
+	c, err := counters.Load(config.CountersFile)
+	if err != nil {
+		fatal(exitState, err)
+	}
+	if err = c.RegisterMetrics(); err != nil {
+		log.FromContext(ctx).Error(err)
+	}
+	ch := bus.Subscribe(subscriberBufferSize)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		c.Run(ctx, ch, countersSavePeriod)
+	}()
+	return func() { <-done }
+}
+
+// newTombstoneStore returns the tombstones store, or nil if tombstones are disabled
+func newTombstoneStore(config *Config) *tombstones.Store {
+	if config.TombstoneRetention <= 0 {
+		return nil
+	}
+	return tombstones.NewStore(config.TombstoneRetention)
+}
+
+// newHistoryStore returns the NSE history store, or nil if the history is disabled
+func newHistoryStore(config *Config) *history.Store {
+	if config.HistoryRevisions <= 0 {
+		return nil
+	}
+	return history.NewStore(config.HistoryRevisions, config.HistoryRetention)
+}
+
+// registerServices registers the registry, health and auxiliary APIs on the server and starts their background
+// routines. The returned synced condition is to be set once the registry has restored its initial state.
+func registerServices(
+	ctx context.Context,
+	config *Config,
+	server *grpc.Server,
+	bus *events.Bus,
+	registryServer registryserver.Registry,
+	nseClient registry.NetworkServiceEndpointRegistryClient,
+	tombstoneStore *tombstones.Store,
+	historyStore *history.Store,
+	identities *identity.Mapping,
+	peers *peerstats.Tracker,
+	pool *connpool.Pool,
+) *synced.Condition {
+	syncedCondition := synced.NewCondition()
+	syncedCondition.RegisterHealthServer(server,
+		registryServer.NetworkServiceRegistryServer(), registryServer.NetworkServiceEndpointRegistryServer())
+	if config.HealthHTTPListenOn != "" {
+		go serveHTTP(ctx, config.HealthHTTPListenOn, syncedCondition.HTTPHandler())
+	}
+	if config.HealthCheckPeriod > 0 {
+		go syncedCondition.RunChecks(ctx, config.HealthCheckPeriod, newHealthChecks(config, pool)...)
+	}
+	registry.RegisterNetworkServiceRegistryServer(server, registryServer.NetworkServiceRegistryServer())
+	registry.RegisterNetworkServiceEndpointRegistryServer(server, registryServer.NetworkServiceEndpointRegistryServer())
+
+	nsClient := adapters.NetworkServiceServerToClient(registryServer.NetworkServiceRegistryServer())
+	go events.PublishNSEEvents(inprocess.WithContext(ctx), bus, nseClient)
+	if len(config.WebhookURLs) > 0 {
+		sender := webhooks.NewSender(config.WebhookURLs, webhooks.WithMaxRetries(config.WebhookMaxRetries))
+		go sender.Run(ctx, bus.Subscribe(subscriberBufferSize))
+	}
+	if config.NATSURL.Host != "" {
+		natsTLSConfig, err := natspublisher.LoadTLSConfig(config.NATSTLSCAFile, config.NATSTLSCertFile, config.NATSTLSKeyFile)
+		if err != nil {
+			fatal(exitConfig, err)
+		}
+		publisher := natspublisher.NewPublisher(&config.NATSURL, config.NATSSubject, natspublisher.WithTLSConfig(natsTLSConfig))
+		go publisher.Run(ctx, bus.Subscribe(subscriberBufferSize))
+	}
+
+	discovery.RegisterDiscoveryServer(server, discovery.NewServer(nseClient))
+	if config.DNSListenOn != "" {
+		dnsutils.ListenAndServe(ctx, dnsexpose.NewDNSHandler(config.DNSZone, nseClient, dnsexpose.WithTenant(config.DNSTenant)), config.DNSListenOn)
+	}
+
+	if config.ExpireNotifyEnabled {
+		notifier := expirenotify.NewNotifier(pool,
+			expirenotify.WithMaxRetries(config.ExpireNotifyMaxRetries),
+			expirenotify.WithBackoff(config.ExpireNotifyBackoff),
+			expirenotify.WithTimeout(config.ExpireNotifyTimeout))
+		go notifier.Run(inprocess.WithContext(ctx), nseClient)
+	}
+
+	if config.EmptyServiceRetention > 0 {
+		collector := nsgc.NewCollector(nsClient, nseClient, config.EmptyServiceRetention, nsgc.WithBus(bus))
+		go collector.Run(inprocess.WithContext(ctx))
+	}
+	if config.CatalogListenOn.String() != "" {
+		serveCatalog(ctx, config, nsClient, nseClient)
+	}
+	if config.MirrorPrimaryURL.String() != "" {
+		startMirror(ctx, config, pool, nsClient, nseClient)
+	}
+
+	adminOptions := []admin.Option{
+		admin.WithAdminIDs(config.AdminIDs...),
+		admin.WithNSClient(nsClient),
+		admin.WithNSEClient(nseClient),
+		admin.WithSynced(syncedCondition),
+		admin.WithIdentities(identities),
+		admin.WithBus(bus),
+	}
+	statsTracker := nsstats.NewTracker()
+	go statsTracker.Run(inprocess.WithContext(ctx), nseClient)
+	go peers.Run(inprocess.WithContext(ctx), nseClient)
+	serveStatus(ctx, config, bus, syncedCondition, statsTracker, peers, pool)
+	adminOptions = append(adminOptions, admin.WithStats(statsTracker), admin.WithPeers(peers))
+	if tombstoneStore != nil {
+		go tombstoneStore.Run(inprocess.WithContext(ctx), nseClient)
+		adminOptions = append(adminOptions, admin.WithTombstones(tombstoneStore))
+	}
+	if historyStore != nil {
+		go historyStore.Run(ctx)
+		adminOptions = append(adminOptions, admin.WithHistory(historyStore))
+	}
+	admin.RegisterAdminServer(server, admin.NewServer(adminOptions...))
+
+	buildinfo.Register(server, buildinfo.NewServer())
+	if config.GRPCReflection {
+		reflection.Register(server)
+	}
+
+	return syncedCondition
+}
+
+// newConnPool returns the pool of the outbound connections closed once ctx is done. The peers listed in the peer
+// credentials file are dialed with their dedicated credentials.
+func newConnPool(
+	ctx context.Context,
+	config *Config,
+	source x509svid.Source,
+	dialOptions ...grpc.DialOption,
+) *connpool.Pool {
+	opts := []connpool.Option{
+		connpool.WithDialOptions(dialOptions...),
+		connpool.WithIdleTimeout(config.OutboundIdleTimeout),
+		connpool.WithHealthCheckInterval(config.OutboundHealthCheckInterval),
+	}
+	if config.PeerCredentialsFile != "" {
+		peers, err := outboundcreds.LoadFile(config.PeerCredentialsFile)
+		if err != nil {
+			fatal(exitConfig, err)
+		}
+		targetDialOptions, err := outboundcreds.TargetDialOptions(ctx, peers, &outboundcreds.Defaults{
+			SVIDSource:       source,
+			MaxTokenLifetime: config.MaxTokenLifetime,
+			WrapCredentials:  wrapTransportCredentials,
+		})
+		if err != nil {
+			fatal(exitConfig, err)
+		}
+		opts = append(opts, connpool.WithTargetDialOptions(targetDialOptions))
+	}
+	pool := connpool.NewPool(ctx, opts...)
+	go pool.Run(ctx)
+	return pool
+}
+
+// proxyRegistryOption returns the option forwarding the interdomain queries to the proxy registry, or to the
+// healthiest of the redundant proxy registries if there are any
+func proxyRegistryOption(ctx context.Context, config *Config) memory.Option {
+	if len(config.ProxyRegistryPeers) == 0 {
+		return memory.WithProxyRegistryURL(&config.ProxyRegistryURL)
+	}
+	var urls []*url.URL
+	if config.ProxyRegistryURL.String() != "" {
+		urls = append(urls, &config.ProxyRegistryURL)
+	}
+	for i := range config.ProxyRegistryPeers {
+		urls = append(urls, &config.ProxyRegistryPeers[i])
+	}
+	return memory.WithProxyRegistrySelector(peerselect.NewSelector(ctx, urls,
+		peerselect.WithRecoveryHalfLife(config.PeerRecoveryHalfLife)))
+}
+
+func newRegistryServer(
+	ctx context.Context,
+	config *Config,
+	tokenGenerator token.GeneratorFunc,
+	bus *events.Bus,
+	elements *reloadableElements,
+	tombstoneStore *tombstones.Store,
+	historyStore *history.Store,
+	pool *connpool.Pool,
+) registryserver.Registry {
+	// The mirror elements follow the authorization, so only the authorized registrations are forwarded to the primary
+	mirrorNSServer, mirrorNSEServer := newMirrorServers(ctx, config, pool)
+	registryServer := memory.NewServer(
+		ctx,
+		tokenGenerator,
+		memory.WithAuthorizeNSERegistryServer(elementerrors.NewNetworkServiceEndpointRegistryServer(
+			elementerrors.NamedNetworkServiceEndpointRegistryServer("authorize",
+				inprocess.NewNetworkServiceEndpointRegistryServer(elements.authorizeNSEServer)),
+			mirrorNSEServer)),
+		memory.WithAuthorizeNSERegistryClient(elementerrors.NamedNetworkServiceEndpointRegistryClient("authorize",
+			elements.authorizeNSEClient)),
+		memory.WithAuthorizeNSRegistryServer(elementerrors.NewNetworkServiceRegistryServer(
+			elementerrors.NamedNetworkServiceRegistryServer("authorize",
+				inprocess.NewNetworkServiceRegistryServer(elements.authorizeNSServer)),
+			mirrorNSServer)),
+		memory.WithAuthorizeNSRegistryClient(elementerrors.NamedNetworkServiceRegistryClient("authorize",
+			elements.authorizeNSClient)),
+		memory.WithDefaultExpiration(config.DefaultExpiration),
+		proxyRegistryOption(ctx, config),
+		memory.WithConnPool(pool),
+		memory.WithNSEStoreOptions(newNSEStoreOptions(config)...))
+
+	upstreamNSServer, upstreamNSEServer := newUpstreamServers(ctx, config, pool)
+	watchDedupNSServer, watchDedupNSEServer := newWatchDedupServers(config)
+	negativeCacheNSServer, negativeCacheNSEServer := newNegativeCacheServers(config)
+	extAuthzNSServer, extAuthzNSEServer := newExtAuthzServers(ctx, config, pool)
+	tenancyNSServer, tenancyNSEServer := newTenancyServers(config, elements.identities)
+	admissionNSServer, admissionNSEServer := newAdmissionServers(config)
+	chaosNSServer, chaosNSEServer := newChaosServers(ctx, config)
+	var conflictsNSEServer registry.NetworkServiceEndpointRegistryServer = null.NewNetworkServiceEndpointRegistryServer()
+	if config.StrictRegistration {
+		conflictsNSEServer = conflicts.NewNetworkServiceEndpointRegistryServer()
+	}
+	var tombstonesNSEServer registry.NetworkServiceEndpointRegistryServer = null.NewNetworkServiceEndpointRegistryServer()
+	if tombstoneStore != nil {
+		tombstonesNSEServer = tombstones.NewNetworkServiceEndpointRegistryServer(tombstoneStore)
+	}
+	var historyNSEServer registry.NetworkServiceEndpointRegistryServer = null.NewNetworkServiceEndpointRegistryServer()
+	if historyStore != nil {
+		historyNSEServer = history.NewNetworkServiceEndpointRegistryServer(historyStore)
+	}
+
+	optional := newOptionalElements(ctx, config, []plugins.Element{
+		{Name: "chaos", NS: chaosNSServer, NSE: chaosNSEServer},
+		{Name: "extauthz", NS: extAuthzNSServer, NSE: extAuthzNSEServer},
+		{Name: "tenancy", NS: tenancyNSServer, NSE: tenancyNSEServer},
+		{Name: "admission", NS: admissionNSServer, NSE: admissionNSEServer},
+		{Name: "watchdedup", NS: watchDedupNSServer, NSE: watchDedupNSEServer},
+	})
+
+	return registryserver.NewServer(
+		elementerrors.NewNetworkServiceRegistryServer(append(plugins.NSServers(optional),
+			negativeCacheNSServer,
+			upstreamNSServer,
+			elementerrors.NamedNetworkServiceRegistryServer("serviceoverrides", elements.serviceOverridesNS),
+			// The memory NS store sends no deletions to the watches, so the NS events are published by the chain
+			nsevents.NewNetworkServiceRegistryServer(bus),
+			registryServer.NetworkServiceRegistryServer(),
+		)...),
+		elementerrors.NewNetworkServiceEndpointRegistryServer(append(append([]registry.NetworkServiceEndpointRegistryServer{
+			queryparams.NewNetworkServiceEndpointRegistryServer(),
+			listwatch.NewNetworkServiceEndpointRegistryServer(),
+			watchevents.NewNetworkServiceEndpointRegistryServer(watchevents.WithTombstones(tombstoneStore)),
+			// Pages are cut after the optional elements, e.g. tenancy, filter the results
+			pagination.NewNetworkServiceEndpointRegistryServer(config.FindMaxResults),
+		}, plugins.NSEServers(optional)...),
+			conflictsNSEServer,
+			labelselector.NewNetworkServiceEndpointRegistryServer(),
+			tombstonesNSEServer,
+			historyNSEServer,
+			negativeCacheNSEServer,
+			upstreamNSEServer,
+			elementerrors.NamedNetworkServiceEndpointRegistryServer("serviceoverrides", elements.serviceOverridesNSE),
+			elementerrors.NamedNetworkServiceEndpointRegistryServer("defaultexpiration", elements.defaultExpiration),
+			elementerrors.NamedNetworkServiceEndpointRegistryServer("derivedlabels", elements.derivedLabels),
+			clockskew.NewNetworkServiceEndpointRegistryServer(
+				clockskew.WithMinLifetime(config.ExpirationMinLifetime),
+				clockskew.WithMaxLifetime(config.ExpirationMaxLifetime),
+				clockskew.WithExpectedLifetime(config.DefaultExpiration)),
+			jitter.NewNetworkServiceEndpointRegistryServer(config.ExpirationJitter),
+			budget.NewNetworkServiceEndpointRegistryServer(
+				budget.WithLimits(elements.budgetLimits),
+				budget.WithPolicy(budget.Policy(config.BudgetPolicy)),
+				budget.WithTombstones(tombstoneStore)),
+			timeprecision.NewNetworkServiceEndpointRegistryServer(config.TimestampPrecision),
+			registryServer.NetworkServiceEndpointRegistryServer(),
+		)...),
+	)
+}
+
+// newOptionalElements returns the built-in optional chain elements followed by the plugin ones, ordered by the chain
+// order
+func newOptionalElements(ctx context.Context, config *Config, builtin []plugins.Element) []plugins.Element {
+	custom, err := plugins.Elements(ctx)
+	if err != nil {
+		fatal(exitConfig, err)
+	}
+	if len(custom) > 0 {
+		log.FromContext(ctx).Infof("Chain plugins: %s", strings.Join(plugins.Names(), ", "))
+	}
+	ordered, err := plugins.Order(append(builtin, custom...), config.ChainOrder)
+	if err != nil {
+		fatal(exitConfig, err)
+	}
+	return ordered
+}
+
+// newChaosServers returns the chain elements injecting the faults, or null servers if the chaos mode is disabled
+func newChaosServers(ctx context.Context, config *Config) (registry.NetworkServiceRegistryServer, registry.NetworkServiceEndpointRegistryServer) {
+	if !config.ChaosEnabled {
+		return null.NewNetworkServiceRegistryServer(), null.NewNetworkServiceEndpointRegistryServer()
+	}
+	log.FromContext(ctx).Warnf("Chaos mode is enabled, the registry injects faults: latency up to %s, %.2f error rate, %.2f drop rate",
+		config.ChaosLatency, config.ChaosErrorRate, config.ChaosDropRate)
+	injector := chaos.NewInjector(
+		chaos.WithLatency(config.ChaosLatency),
+		chaos.WithErrorRate(config.ChaosErrorRate),
+		chaos.WithDropRate(config.ChaosDropRate))
+	return chaos.NewNetworkServiceRegistryServer(injector), chaos.NewNetworkServiceEndpointRegistryServer(injector)
+}
+
+// newExtAuthzServers returns the chain elements authorizing the calls with the ext_authz service, or null servers if
+// it is disabled
+func newExtAuthzServers(
+	ctx context.Context,
+	config *Config,
+	pool *connpool.Pool,
+) (registry.NetworkServiceRegistryServer, registry.NetworkServiceEndpointRegistryServer) {
+	if config.ExtAuthzURL.String() == "" {
+		return null.NewNetworkServiceRegistryServer(), null.NewNetworkServiceEndpointRegistryServer()
+	}
+
+	cc, release, err := pool.Get(ctx, grpcutils.URLToTarget(&config.ExtAuthzURL))
+	if err != nil {
+		fatal(exitDependency, errors.Wrapf(err, "failed to dial the ext_authz service %s", config.ExtAuthzURL.String()))
+	}
+	go func() {
+		<-ctx.Done()
+		release()
+	}()
+
+	opts := []extauthz.Option{extauthz.WithCacheTTL(config.ExtAuthzCacheTTL)}
+	if config.ExtAuthzFailOpen {
+		opts = append(opts, extauthz.WithFailOpen())
+	}
+	authorizer := extauthz.NewAuthorizer(cc, opts...)
+	return extauthz.NewNetworkServiceRegistryServer(authorizer), extauthz.NewNetworkServiceEndpointRegistryServer(authorizer)
+}
+
+// newTenancyServers returns the tenancy chain elements, or null servers if it is disabled
+func newTenancyServers(
+	config *Config,
+	identities *identity.Mapping,
+) (registry.NetworkServiceRegistryServer, registry.NetworkServiceEndpointRegistryServer) {
+	if !config.TenancyEnabled {
+		return null.NewNetworkServiceRegistryServer(), null.NewNetworkServiceEndpointRegistryServer()
+	}
+	opts := []tenancy.Option{
+		tenancy.WithAdminIDs(config.TenancyAdminIDs...),
+		tenancy.WithIdentities(identities),
+	}
+	return tenancy.NewNetworkServiceRegistryServer(opts...), tenancy.NewNetworkServiceEndpointRegistryServer(opts...)
+}
+
+// newAdmissionServers returns the chain elements validating the registrations with the admission webhook, or null
+// servers if it is disabled
+func newAdmissionServers(config *Config) (registry.NetworkServiceRegistryServer, registry.NetworkServiceEndpointRegistryServer) {
+	if config.AdmissionWebhookURL == "" {
+		return null.NewNetworkServiceRegistryServer(), null.NewNetworkServiceEndpointRegistryServer()
+	}
+	var opts []admission.Option
+	if config.AdmissionFailOpen {
+		opts = append(opts, admission.WithFailOpen())
+	}
+	return admission.NewNetworkServiceRegistryServer(config.AdmissionWebhookURL, opts...),
+		admission.NewNetworkServiceEndpointRegistryServer(config.AdmissionWebhookURL, opts...)
+}
+
+// newWatchDedupServers returns the watch deduplication chain elements, or null servers if it is disabled
+func newWatchDedupServers(config *Config) (registry.NetworkServiceRegistryServer, registry.NetworkServiceEndpointRegistryServer) {
+	if !config.WatchDeduplication {
+		return null.NewNetworkServiceRegistryServer(), null.NewNetworkServiceEndpointRegistryServer()
+	}
+	return watchdedup.NewNetworkServiceRegistryServer(watchdedup.WithCoalesceWindow(config.WatchCoalesceWindow)),
+		watchdedup.NewNetworkServiceEndpointRegistryServer(watchdedup.WithCoalesceWindow(config.WatchCoalesceWindow))
+}
+
+// newNegativeCacheServers returns the chain elements caching the empty results of the Find queries going to the
+// remote domains, or null servers if it is disabled
+func newNegativeCacheServers(config *Config) (registry.NetworkServiceRegistryServer, registry.NetworkServiceEndpointRegistryServer) {
+	if config.NegativeCacheTTL <= 0 {
+		return null.NewNetworkServiceRegistryServer(), null.NewNetworkServiceEndpointRegistryServer()
+	}
+	var opts []negativecache.Option
+	if config.ProxyRegistryFallback {
+		opts = append(opts, negativecache.WithFallback())
+	}
+	return negativecache.NewNetworkServiceRegistryServer(config.NegativeCacheTTL, opts...),
+		negativecache.NewNetworkServiceEndpointRegistryServer(config.NegativeCacheTTL, opts...)
+}
+
+// newNSEStoreOptions returns the options of the NSE store
+func newNSEStoreOptions(config *Config) []nsestore.Option {
+	if config.WatchResyncInterval <= 0 {
+		return nil
+	}
+	return []nsestore.Option{nsestore.WithResync(config.WatchResyncInterval, config.WatchResyncMaxRate)}
+}
+
+// newMirrorServers returns the chain elements forwarding Register and Unregister to the mirrored primary registry, or
+// null servers if the mirror mode is disabled. They follow the authorize elements of the memory chain.
+func newMirrorServers(
+	ctx context.Context,
+	config *Config,
+	pool *connpool.Pool,
+) (registry.NetworkServiceRegistryServer, registry.NetworkServiceEndpointRegistryServer) {
+	if config.MirrorPrimaryURL.String() == "" {
+		return null.NewNetworkServiceRegistryServer(), null.NewNetworkServiceEndpointRegistryServer()
+	}
+	cc := getPrimaryConn(ctx, config, pool)
+	return mirror.NewNetworkServiceRegistryServer(registry.NewNetworkServiceRegistryClient(cc)),
+		mirror.NewNetworkServiceEndpointRegistryServer(registry.NewNetworkServiceEndpointRegistryClient(cc))
+}
+
+// startMirror mirrors the primary registry state to the local registry with the local clients
+func startMirror(
+	ctx context.Context,
+	config *Config,
+	pool *connpool.Pool,
+	nsClient registry.NetworkServiceRegistryClient,
+	nseClient registry.NetworkServiceEndpointRegistryClient,
+) {
+	cc := getPrimaryConn(ctx, config, pool)
+	m := mirror.NewMirror(
+		registry.NewNetworkServiceRegistryClient(cc), registry.NewNetworkServiceEndpointRegistryClient(cc),
+		nsClient, nseClient,
+		mirror.WithRetryPeriod(config.MirrorRetryPeriod))
+	go m.Run(ctx)
+	log.FromContext(ctx).Infof("Mirroring the primary registry %s", config.MirrorPrimaryURL.String())
+}
+
+// getPrimaryConn returns the pooled connection to the mirrored primary registry released once ctx is done
+func getPrimaryConn(ctx context.Context, config *Config, pool *connpool.Pool) *grpc.ClientConn {
+	cc, release, err := pool.Get(ctx, grpcutils.URLToTarget(&config.MirrorPrimaryURL))
+	if err != nil {
+		fatal(exitDependency, errors.Wrapf(err, "failed to dial the primary registry %s", config.MirrorPrimaryURL.String()))
+	}
+	go func() {
+		<-ctx.Done()
+		release()
+	}()
+	return cc
+}
+
+// newUpstreamServers returns the chain elements forwarding to the proxy registry, or null servers if it is disabled
+func newUpstreamServers(
+	ctx context.Context,
+	config *Config,
+	pool *connpool.Pool,
+) (registry.NetworkServiceRegistryServer, registry.NetworkServiceEndpointRegistryServer) {
+	var opts []upstream.Option
+	if config.ProxyRegistryFallback {
+		opts = append(opts, upstream.WithFallback())
+	}
+	if config.ProxyRegistryPushLocal {
+		opts = append(opts, upstream.WithPush(), upstream.WithPushTimeout(config.ProxyRegistryPushTimeout))
+	}
+	if len(opts) == 0 || config.ProxyRegistryURL.String() == "" {
+		return null.NewNetworkServiceRegistryServer(), null.NewNetworkServiceEndpointRegistryServer()
+	}
+
+	// The connection is shared with the interdomain forwarding of the memory chain
+	cc, release, err := pool.Get(ctx, grpcutils.URLToTarget(&config.ProxyRegistryURL))
+	if err != nil {
+		fatal(exitDependency, errors.Wrapf(err, "failed to dial the proxy registry %s", config.ProxyRegistryURL.String()))
+	}
+	go func() {
+		<-ctx.Done()
+		release()
+	}()
+
+	return upstream.NewNetworkServiceRegistryServer(registry.NewNetworkServiceRegistryClient(cc), opts...),
+		upstream.NewNetworkServiceEndpointRegistryServer(registry.NewNetworkServiceEndpointRegistryClient(cc), opts...)
+}
+
+type x509Source interface {
+	x509svid.Source
+	x509bundle.Source
+}
+
+// newX509Source returns the X.509 SVID and bundle source for the configured TLS mode
+func newX509Source(ctx context.Context, config *Config) (x509Source, error) {
+	switch strings.ToLower(config.TLSMode) {
+	case tlsModeSPIFFE:
+		return workloadapi.NewX509Source(ctx)
+	case tlsModeFile:
+		return tlssource.NewFileSource(ctx, config.TLSCertFile, config.TLSKeyFile, config.TLSCAFile)
+	case tlsModeInsecure:
+		log.FromContext(ctx).Warn("TLS is disabled, the insecure mode is for development only")
+		return tlssource.NewEphemeralSource(spiffeid.RequireFromString("spiffe://insecure.local/registry-memory"))
+	case tlsModePeerCred:
+		id, err := spiffeid.FromSegments(peercred.TrustDomain, "registry-memory")
+		if err != nil {
+			return nil, errors.WithStack(err)
+		}
+		return tlssource.NewEphemeralSource(id)
+	default:
+		return nil, errors.Errorf("invalid TLS mode %s", config.TLSMode)
+	}
+}
+
+// newBundleSource returns the bundle source trusting the federated trust domains in addition to the local one
+func newBundleSource(ctx context.Context, config *Config, source x509Source) (x509bundle.Source, error) {
+	endpoints, err := federation.ParseEndpoints(config.FederatesWith)
+	if err != nil {
+		return nil, err
+	}
+	if len(endpoints) == 0 {
+		return source, nil
+	}
+	federated := federation.NewSource(source)
+	for _, endpoint := range endpoints {
+		go federated.Watch(ctx, endpoint)
+	}
+	return federated, nil
+}
+
+// newTLSConfigs returns the client and server TLS configs, or nils in the insecure and peercred TLS modes
+func newTLSConfigs(config *Config, svidSource x509svid.Source, bundleSource x509bundle.Source) (tlsClientConfig, tlsServerConfig *tls.Config) {
+	if strings.EqualFold(config.TLSMode, tlsModeInsecure) || strings.EqualFold(config.TLSMode, tlsModePeerCred) {
+		return nil, nil
+	}
+	tlsClientConfig = tlsconfig.MTLSClientConfig(svidSource, bundleSource, tlsconfig.AuthorizeAny())
+	tlsClientConfig.MinVersion = tls.VersionTLS12
+	tlsServerConfig = tlsconfig.MTLSServerConfig(svidSource, bundleSource, tlsconfig.AuthorizeAny())
+	tlsServerConfig.MinVersion = tls.VersionTLS12
+	tlsServerConfig.SessionTicketsDisabled = !config.TLSSessionTickets
+	if config.TLSSessionCacheSize > 0 {
+		tlsClientConfig.ClientSessionCache = tls.NewLRUClientSessionCache(config.TLSSessionCacheSize)
+	}
+	return tlsClientConfig, tlsServerConfig
+}
+
+func newDialOptions(tokenGenerator token.GeneratorFunc, tlsClientConfig *tls.Config) []grpc.DialOption {
+	transportCredentials := insecure.NewCredentials()
+	if tlsClientConfig != nil {
+		transportCredentials = credentials.NewTLS(tlsClientConfig)
+	}
+	return append(
+		tracing.WithTracingDial(),
+		grpc.WithDefaultCallOptions(
+			grpc.WaitForReady(true),
+			grpc.PerRPCCredentials(token.NewPerRPCCredentials(tokenGenerator))),
+		grpc.WithTransportCredentials(
+			wrapTransportCredentials(transportCredentials)),
+		grpcfd.WithChainStreamInterceptor(),
+		grpcfd.WithChainUnaryInterceptor(),
+	)
+}
+
+// newGRPCServer creates the registry gRPC server and the tracker of its peers. The revocation list is checked by the
+// interceptors as well, as the resumed TLS sessions skip the peer certificate verification.
+func newGRPCServer(ctx context.Context, config *Config, tlsServerConfig *tls.Config, revoked *revocation.List) (*grpc.Server, *peerstats.Tracker, error) {
+	transportCredentials := insecure.NewCredentials()
+	switch {
+	case tlsServerConfig != nil:
+		tlsServerConfig.VerifyPeerCertificate = revoked.VerifyPeerCertificate(tlsServerConfig.VerifyPeerCertificate)
+		transportCredentials = handshake.NewCredentials(ctx, credentials.NewTLS(tlsServerConfig))
+	case strings.EqualFold(config.TLSMode, tlsModePeerCred):
+		transportCredentials = peercred.NewCredentials(
+			peercred.WithUIDs(config.PeerCredUIDs...),
+			peercred.WithGIDs(config.PeerCredGIDs...))
+	}
+
+	sizeRecorder, err := sizemetrics.NewRecorder()
+	if err != nil {
+		return nil, nil, err
+	}
+	if config.ZstdCompression {
+		zstdcompressor.Register()
+	}
+	compressionHandler, err := sizemetrics.NewCompressionHandler()
+	if err != nil {
+		return nil, nil, err
+	}
+	peers := peerstats.NewTracker()
+	reaper := deadlines.NewReaper(
+		deadlines.WithUnaryTimeout(config.UnaryTimeout),
+		deadlines.WithSendTimeout(config.StreamSendTimeout))
+
+	serverOptions := append(tracing.WithTracing(),
+		grpc.Creds(transportCredentials),
+		grpc.StatsHandler(compressionHandler),
+		grpc.ChainUnaryInterceptor(revoked.UnaryServerInterceptor(), reaper.UnaryServerInterceptor(),
+			sizeRecorder.UnaryServerInterceptor(), peers.UnaryServerInterceptor()),
+		grpc.ChainStreamInterceptor(revoked.StreamServerInterceptor(), reaper.StreamServerInterceptor(),
+			sizeRecorder.StreamServerInterceptor(), peers.StreamServerInterceptor()))
+	serverOptions = append(serverOptions, tuningServerOptions(config)...)
+
+	return grpc.NewServer(serverOptions...), peers, nil
+}
+
+// tuningServerOptions returns grpc.ServerOptions for the non zero tuning knobs of config
+func tuningServerOptions(config *Config) []grpc.ServerOption {
+	var opts []grpc.ServerOption
+	if config.TLSHandshakeTimeout > 0 {
+		opts = append(opts, grpc.ConnectionTimeout(config.TLSHandshakeTimeout))
+	}
+	if config.MaxConcurrentStreams > 0 {
+		opts = append(opts, grpc.MaxConcurrentStreams(config.MaxConcurrentStreams))
+	}
+	if config.MaxRecvMsgSize > 0 {
+		opts = append(opts, grpc.MaxRecvMsgSize(config.MaxRecvMsgSize))
+	}
+	if config.MaxSendMsgSize > 0 {
+		opts = append(opts, grpc.MaxSendMsgSize(config.MaxSendMsgSize))
+	}
+	if config.KeepaliveMinTime > 0 || config.KeepalivePermitWithoutStream {
+		opts = append(opts, grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             config.KeepaliveMinTime,
+			PermitWithoutStream: config.KeepalivePermitWithoutStream,
+		}))
+	}
+	if config.KeepaliveTime > 0 || config.KeepaliveTimeout > 0 || config.MaxConnectionIdle > 0 ||
+		config.MaxConnectionAge > 0 || config.MaxConnectionAgeGrace > 0 {
+		opts = append(opts, grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:                  config.KeepaliveTime,
+			Timeout:               config.KeepaliveTimeout,
+			MaxConnectionIdle:     config.MaxConnectionIdle,
+			MaxConnectionAge:      config.MaxConnectionAge,
+			MaxConnectionAgeGrace: config.MaxConnectionAgeGrace,
+		}))
+	}
+	return opts
+}
+
+// loadConfig reads Config from the preset overridden by the environment overridden by the variables from
+// Config.ConfigFile. The process environment is never changed, so it can be called on reload.
+func loadConfig() (*Config, error) {
+	config := new(Config)
+	if err := processConfig(config, os.LookupEnv); err != nil {
+		return nil, errors.Wrap(err, "error processing config from env")
+	}
+	if config.ConfigFile != "" {
+		configFile := config.ConfigFile
+		vars, err := reload.ParseEnvFile(configFile)
+		if err != nil {
+			return nil, err
+		}
+		config = new(Config)
+		if err := processConfig(config, reload.Layers(reload.FromMap(vars), os.LookupEnv)); err != nil {
+			return nil, errors.Wrapf(err, "error processing config from env and %s", configFile)
+		}
+	}
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// processConfig loads config from the variables of lookup overriding the preset
+func processConfig(config *Config, lookup reload.Lookup) error {
+	preset, err := presetLookup(lookup)
+	if err != nil {
+		return err
+	}
+	return reload.Process("registry_memory", config, reload.Layers(lookup, preset))
+}
+
+// validate checks the config values envconfig can't check
+func (c *Config) validate() error {
+	switch budget.Policy(c.BudgetPolicy) {
+	case budget.PolicyReject, budget.PolicyEvictExpiring:
+	default:
+		return errors.Errorf("invalid budget policy %s", c.BudgetPolicy)
+	}
+	if c.ExpirationJitter < 0 || c.ExpirationJitter > 100 {
+		return errors.Errorf("invalid expiration jitter %d, expected percent from 0 to 100", c.ExpirationJitter)
+	}
+	if err := c.validateFeatures(); err != nil {
+		return err
+	}
+	for name, rate := range map[string]float64{"error": c.ChaosErrorRate, "drop": c.ChaosDropRate} {
+		if rate < 0 || rate > 1 {
+			return errors.Errorf("invalid chaos %s rate %v, expected from 0 to 1", name, rate)
+		}
+	}
+	if c.OutboundHealthCheckInterval <= 0 {
+		return errors.Errorf("invalid outbound health check interval %v, expected positive", c.OutboundHealthCheckInterval)
+	}
+	if c.StatusHTTPListenOn != "" && !isLoopback(c.StatusHTTPListenOn) {
+		return errors.Errorf("invalid status HTTP listen address %s, expected a loopback one", c.StatusHTTPListenOn)
+	}
+	if c.HistoryRevisions > 0 && c.HistoryRetention <= 0 {
+		return errors.Errorf("invalid history retention %v, expected positive", c.HistoryRetention)
+	}
+	if len(c.ProxyRegistryPeers) > 0 && c.PeerRecoveryHalfLife <= 0 {
+		return errors.Errorf("invalid peer recovery half-life %v, expected positive", c.PeerRecoveryHalfLife)
+	}
+	if _, err := plugins.Order(chainOrderElements(), c.ChainOrder); err != nil {
+		return err
+	}
+	if _, err := listen.ParseSocketPermissions(c.ListenSocketMode, c.ListenSocketOwner); err != nil {
+		return err
+	}
+	if strings.EqualFold(c.TLSMode, tlsModePeerCred) {
+		for i := range c.ListenOn {
+			if c.ListenOn[i].Scheme != "unix" {
+				return errors.Errorf("invalid listen URL %s: the peercred TLS mode requires unix listen URLs", c.ListenOn[i].String())
+			}
+		}
+	}
+	return nil
+}
+
+// validateFeatures checks the values of the optional features depending on each other
+func (c *Config) validateFeatures() error {
+	if c.InterdomainProbeDomain != "" && (c.ProxyRegistryURL.String() == "" || c.InterdomainProbePeriod <= 0) {
+		return errors.New("invalid interdomain probe, the proxy registry url and a positive period are required")
+	}
+	if strings.EqualFold(c.Preset, "interdomain") && c.ProxyRegistryURL.String() == "" && len(c.ProxyRegistryPeers) == 0 {
+		return errors.New("invalid interdomain preset, the proxy registry url is required")
+	}
+	if c.MirrorPrimaryURL.String() != "" && c.MirrorRetryPeriod <= 0 {
+		return errors.New("invalid mirror, a positive retry period is required")
+	}
+	if c.CatalogListenOn.String() != "" && (len(c.CatalogNetworkServices) == 0 || c.CatalogRateLimit <= 0 || c.CatalogRateBurst < 1) {
+		return errors.New("invalid catalog, the network services, a positive rate limit and burst are required")
+	}
+	if c.ExpirationMaxLifetime > 0 && c.ExpirationMinLifetime > c.ExpirationMaxLifetime {
+		return errors.Errorf("invalid expiration min lifetime %v, expected not greater than the max lifetime %v",
+			c.ExpirationMinLifetime, c.ExpirationMaxLifetime)
+	}
+	if c.NATSURL.Host != "" {
+		if err := natspublisher.ValidateSubject(c.NATSSubject); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// chainOrderElements returns the names of the orderable chain elements
+func chainOrderElements() []plugins.Element {
+	var elements []plugins.Element
+	for _, name := range append([]string{"chaos", "extauthz", "tenancy", "admission", "watchdedup"}, plugins.Names()...) {
+		elements = append(elements, plugins.Element{Name: name})
+	}
+	return elements
+}
+
+// isLoopback returns if the host of the address is localhost or a loopback IP
+func isLoopback(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+func loadRevocationList(config *Config) (*revocation.List, error) {
+	revoked := revocation.NewList()
+	if config.RevocationListFile == "" {
+		return revoked, nil
+	}
+	if err := revoked.Load(config.RevocationListFile); err != nil {
+		return nil, err
+	}
+	return revoked, nil
+}
+
+func setupLogging(config *Config) error {
+	apply, err := prepareLogging(config)
+	if err != nil {
+		return err
+	}
+	apply()
+	return nil
+}
+
+// prepareLogging validates the logging configuration and returns the function applying it
+func prepareLogging(config *Config) (apply func(), err error) {
+	l, err := logrus.ParseLevel(config.LogLevel)
+	if err != nil {
+		return nil, errors.Errorf("invalid log level %s", config.LogLevel)
+	}
+	var formatter logrus.Formatter
+	switch strings.ToLower(config.LogFormat) {
+	case "text":
+		formatter = &nested.Formatter{}
+	case "json":
+		formatter = &logrus.JSONFormatter{}
+	default:
+		return nil, errors.Errorf("invalid log format %s", config.LogFormat)
+	}
+	redactor, err := newRedactor(config)
+	if err != nil {
+		return nil, err
+	}
+	return func() {
+		logrus.SetLevel(l)
+		logrus.SetFormatter(formatter)
+		logrus.StandardLogger().ReplaceHooks(make(logrus.LevelHooks))
+		if redactor != nil {
+			logrus.AddHook(redact.NewHook(redactor))
+		}
+	}, nil
+}
+
+// newRedactor returns the redactor of the sensitive fields, nil if there are none
+func newRedactor(config *Config) (*redact.Redactor, error) {
+	var opts []redact.Option
+	switch strings.ToLower(config.RedactMode) {
+	case "strip":
+	case "hash":
+		opts = append(opts, redact.WithHash())
+	default:
+		return nil, errors.Errorf("invalid redact mode %s", config.RedactMode)
+	}
+	return redact.New(config.RedactFields, opts...), nil
+}
+
+// reloadConfig applies the reloadable subset of the configuration: logging, policies, default expiration, service
+// overrides, derived labels, identity mapping and the budget limits. Other changes require restart, e.g. the budget
+// policy, the Find results limit and the catalog rate limit.
+func reloadConfig(ctx context.Context, elements *reloadableElements) {
+	config, err := loadConfig()
+	if err != nil {
+		log.FromContext(ctx).Errorf("failed to reload config: %+v", err)
+		return
+	}
+	// Everything is built and validated first, so a rejected config changes nothing
+	applyLogging, err := prepareLogging(config)
+	if err != nil {
+		log.FromContext(ctx).Errorf("failed to reload config: %+v", err)
+		return
+	}
+	applyElements, err := elements.prepare(config)
+	if err != nil {
+		log.FromContext(ctx).Errorf("failed to reload config: %+v", err)
+		return
+	}
+	applyLogging()
+	applyElements()
+	log.FromContext(ctx).Infof("Config reloaded: %#v", config)
+	warnAuthorizationDisabled(ctx, config)
+}
+
+// warnAuthorizationDisabled warns if the remote calls are not authorized: there are no registry server policies and
+// no ext_authz service
+func warnAuthorizationDisabled(ctx context.Context, config *Config) {
+	if len(config.RegistryServerPolicies) == 0 && config.ExtAuthzURL.String() == "" {
+		log.FromContext(ctx).Warn("Authorization is disabled: no registry server policies and no ext_authz service")
+	}
+}
+
+// reloadRevocationList reloads the revocation list and expunges the registrations made by the revoked identities
+func reloadRevocationList(
+	ctx context.Context,
+	filePath string,
+	revoked *revocation.List,
+	nsClient registry.NetworkServiceRegistryClient,
+	nseClient registry.NetworkServiceEndpointRegistryClient,
+) {
+	if err := revoked.Load(filePath); err != nil {
+		log.FromContext(ctx).Errorf("failed to reload revocation list: %+v", err)
+		return
+	}
+	log.FromContext(ctx).Infof("Revocation list reloaded: %d entries", revoked.Len())
+
+	revocation.Expunge(inprocess.WithContext(ctx), revoked, nsClient, nseClient)
+}
+
+// generateFakeState registers n synthetic NSEs refreshed until ctx is done, nothing if n is 0
+func generateFakeState(ctx context.Context, n int, nsClient registry.NetworkServiceRegistryClient, nseClient registry.NetworkServiceEndpointRegistryClient) {
+	if n <= 0 {
+		return
+	}
+	nseClient = chain.NewNetworkServiceEndpointRegistryClient(
+		begin.NewNetworkServiceEndpointRegistryClient(),
+		refresh.NewNetworkServiceEndpointRegistryClient(ctx),
+		nseClient,
+	)
+	if err := fakestate.Generate(inprocess.WithContext(ctx), n, nsClient, nseClient); err != nil {
+		log.FromContext(ctx).Errorf("failed to generate fake state: %+v", err)
+		return
+	}
+	log.FromContext(ctx).Warnf("Generated fake state: %d synthetic NSEs", n)
+}
+
+// reloadableElements are the chain elements replaced on configuration reload
+type reloadableElements struct {
+	nsPathIdsMap        *genericsync.Map[string, []string]
+	nsePathIdsMap       *genericsync.Map[string, []string]
+	nsClientPathIdsMap  *genericsync.Map[string, []string]
+	nseClientPathIdsMap *genericsync.Map[string, []string]
+
+	authorizeNSServer  *swap.NetworkServiceRegistryServer
+	authorizeNSEServer *swap.NetworkServiceEndpointRegistryServer
+	authorizeNSClient  *swap.NetworkServiceRegistryClient
+	authorizeNSEClient *swap.NetworkServiceEndpointRegistryClient
+	defaultExpiration  *swap.NetworkServiceEndpointRegistryServer
+
+	serviceOverridesNS  *swap.NetworkServiceRegistryServer
+	serviceOverridesNSE *swap.NetworkServiceEndpointRegistryServer
+	derivedLabels       *swap.NetworkServiceEndpointRegistryServer
+
+	identities   *identity.Mapping
+	budgetLimits *budget.Limits
+}
+
+func newReloadableElements(config *Config) *reloadableElements {
+	e := &reloadableElements{
+		nsPathIdsMap:        new(genericsync.Map[string, []string]),
+		nsePathIdsMap:       new(genericsync.Map[string, []string]),
+		nsClientPathIdsMap:  new(genericsync.Map[string, []string]),
+		nseClientPathIdsMap: new(genericsync.Map[string, []string]),
+		authorizeNSServer:   swap.NewNetworkServiceRegistryServer(nil),
+		authorizeNSEServer:  swap.NewNetworkServiceEndpointRegistryServer(nil),
+		authorizeNSClient:   swap.NewNetworkServiceRegistryClient(nil),
+		authorizeNSEClient:  swap.NewNetworkServiceEndpointRegistryClient(nil),
+		defaultExpiration:   swap.NewNetworkServiceEndpointRegistryServer(nil),
+		serviceOverridesNS:  swap.NewNetworkServiceRegistryServer(nil),
+		serviceOverridesNSE: swap.NewNetworkServiceEndpointRegistryServer(nil),
+		derivedLabels:       swap.NewNetworkServiceEndpointRegistryServer(nil),
+		identities:          identity.NewMapping(),
+		budgetLimits:        new(budget.Limits),
+	}
+	if err := e.apply(config); err != nil {
+		fatal(exitConfig, err)
+	}
+	return e
+}
+
+// apply replaces the elements with the ones built from config. Path ids maps are kept, so the authorization state
+// survives the reload.
+func (e *reloadableElements) apply(config *Config) error {
+	apply, err := e.prepare(config)
+	if err != nil {
+		return err
+	}
+	apply()
+	return nil
+}
+
+// prepare builds the elements from config and returns the function replacing the elements with them, nothing is
+// changed if config is rejected
+func (e *reloadableElements) prepare(config *Config) (apply func(), err error) {
+	if _, err = opa.PoliciesByFileMask(config.RegistryServerPolicies...); err != nil {
+		return nil, errors.Wrap(err, "failed to read registry server policies")
+	}
+	if _, err = opa.PoliciesByFileMask(config.RegistryClientPolicies...); err != nil {
+		return nil, errors.Wrap(err, "failed to read registry client policies")
+	}
+	var overrides serviceoverrides.Overrides
+	if config.ServiceOverridesFile != "" {
+		if overrides, err = serviceoverrides.LoadFile(config.ServiceOverridesFile); err != nil {
+			return nil, err
+		}
+	}
+	var derivedLabelRules []*derivedlabels.Rule
+	if config.DerivedLabelsFile != "" {
+		if derivedLabelRules, err = derivedlabels.LoadFile(config.DerivedLabelsFile); err != nil {
+			return nil, err
+		}
+	}
+	var identities *identity.Mapping
+	if config.IdentityMappingFile != "" {
+		if identities, err = identity.LoadFile(config.IdentityMappingFile); err != nil {
+			return nil, err
+		}
+	}
+
+	var authorizeNSServer registry.NetworkServiceRegistryServer = null.NewNetworkServiceRegistryServer()
+	var authorizeNSEServer registry.NetworkServiceEndpointRegistryServer = null.NewNetworkServiceEndpointRegistryServer()
+	// The ext_authz service replaces the registry server policies
+	if config.ExtAuthzURL.String() == "" {
+		authorizeNSServer = authorize.NewNetworkServiceRegistryServer(
+			authorize.WithPolicies(config.RegistryServerPolicies...),
+			authorize.WithResourcePathIdsMap(e.nsPathIdsMap))
+		authorizeNSEServer = authorize.NewNetworkServiceEndpointRegistryServer(
+			authorize.WithPolicies(config.RegistryServerPolicies...),
+			authorize.WithResourcePathIdsMap(e.nsePathIdsMap))
+	}
+	authorizeNSClient := authorize.NewNetworkServiceRegistryClient(
+		authorize.WithPolicies(config.RegistryClientPolicies...),
+		authorize.WithResourcePathIdsMap(e.nsClientPathIdsMap))
+	authorizeNSEClient := authorize.NewNetworkServiceEndpointRegistryClient(
+		authorize.WithPolicies(config.RegistryClientPolicies...),
+		authorize.WithResourcePathIdsMap(e.nseClientPathIdsMap))
+	defaultExpiration := defaultexpiration.NewNetworkServiceEndpointRegistryServer(config.DefaultExpiration)
+	serviceOverridesNS := serviceoverrides.NewNetworkServiceRegistryServer(overrides)
+	serviceOverridesNSE := serviceoverrides.NewNetworkServiceEndpointRegistryServer(overrides)
+	derivedLabels := derivedlabels.NewNetworkServiceEndpointRegistryServer(derivedLabelRules)
+
+	return func() {
+		e.identities.Set(identities)
+		e.authorizeNSServer.Store(authorizeNSServer)
+		e.authorizeNSEServer.Store(authorizeNSEServer)
+		e.authorizeNSClient.Store(authorizeNSClient)
+		e.authorizeNSEClient.Store(authorizeNSEClient)
+		e.defaultExpiration.Store(defaultExpiration)
+		e.serviceOverridesNS.Store(serviceOverridesNS)
+		e.serviceOverridesNSE.Store(serviceOverridesNSE)
+		e.derivedLabels.Store(derivedLabels)
+		e.budgetLimits.Store(config.BudgetMaxEntries, config.BudgetMaxBytes)
+	}, nil
+}
@@ -26,12 +26,10 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
-	"time"
 
 	nested "github.com/antonfisher/nested-logrus-formatter"
 	"github.com/edwarnicke/exechelper"
 	"github.com/edwarnicke/grpcfd"
-	"github.com/golang/protobuf/ptypes/timestamp"
 	"github.com/kelseyhightower/envconfig"
 	"github.com/sirupsen/logrus"
 	"github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
@@ -42,13 +40,7 @@ import (
 	"github.com/stretchr/testify/suite"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
-	"google.golang.org/grpc/health/grpc_health_v1"
 
-	"github.com/NikitaSkrynnik/api/pkg/api/registry"
-	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/begin"
-	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/grpcmetadata"
-	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/refresh"
-	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
 	"github.com/NikitaSkrynnik/sdk/pkg/tools/log"
 	"github.com/NikitaSkrynnik/sdk/pkg/tools/spiffejwt"
 	"github.com/NikitaSkrynnik/sdk/pkg/tools/spire"
@@ -57,10 +49,9 @@ import (
 	main "github.com/NikitaSkrynnik/cmd-registry-memory"
 )
 
+// RegistryTestSuite runs the registry cases against the registry getting its SVID from spire
 type RegistryTestSuite struct {
-	suite.Suite
-	ctx        context.Context
-	cancel     context.CancelFunc
+	registrySuite
 	x509source x509svid.Source
 	x509bundle x509bundle.Source
 	config     main.Config
@@ -108,6 +99,16 @@ func (t *RegistryTestSuite) SetupSuite() {
 
 	// Get config from env
 	require.NoError(t.T(), envconfig.Process("registry-memory", &t.config))
+	t.target = t.config.ListenOn[0].String()
+	t.dialOptions = []grpc.DialOption{
+		grpc.WithTransportCredentials(credentials.NewTLS(tlsconfig.MTLSClientConfig(t.x509source, t.x509bundle, tlsconfig.AuthorizeAny()))),
+		grpc.WithDefaultCallOptions(
+			grpc.WaitForReady(true),
+			grpc.PerRPCCredentials(token.NewPerRPCCredentials(spiffejwt.TokenGeneratorFunc(t.x509source, t.config.MaxTokenLifetime))),
+		),
+		grpcfd.WithChainStreamInterceptor(),
+		grpcfd.WithChainUnaryInterceptor(),
+	}
 }
 
 func (t *RegistryTestSuite) TearDownSuite() {
@@ -126,218 +127,6 @@ func (t *RegistryTestSuite) TearDownSuite() {
 	}
 }
 
-func (t *RegistryTestSuite) TestHealthCheck() {
-	ctx, cancel := context.WithTimeout(t.ctx, 100*time.Second)
-	defer cancel()
-	healthCC, err := grpc.DialContext(ctx,
-		t.config.ListenOn[0].String(),
-		grpc.WithTransportCredentials(credentials.NewTLS(tlsconfig.MTLSClientConfig(t.x509source, t.x509bundle, tlsconfig.AuthorizeAny()))),
-	)
-	if err != nil {
-		logrus.Fatalf("Failed healthcheck: %+v", err)
-	}
-	healthClient := grpc_health_v1.NewHealthClient(healthCC)
-	healthResponse, err := healthClient.Check(ctx,
-		&grpc_health_v1.HealthCheckRequest{
-			Service: "registry.NetworkServiceEndpointRegistry",
-		},
-		grpc.WaitForReady(true),
-	)
-	t.NoError(err)
-	t.NotNil(healthResponse)
-	t.Equal(grpc_health_v1.HealthCheckResponse_SERVING, healthResponse.Status)
-	healthResponse, err = healthClient.Check(ctx,
-		&grpc_health_v1.HealthCheckRequest{
-			Service: "registry.NetworkServiceRegistry",
-		},
-		grpc.WaitForReady(true),
-	)
-	t.NoError(err)
-	t.NotNil(healthResponse)
-	t.Equal(grpc_health_v1.HealthCheckResponse_SERVING, healthResponse.Status)
-}
-
-func (t *RegistryTestSuite) TestNetworkServiceRegistration() {
-	ctx, cancel := context.WithTimeout(t.ctx, 100*time.Second)
-	defer cancel()
-	cc, err := grpc.DialContext(ctx,
-		t.config.ListenOn[0].String(),
-		grpc.WithTransportCredentials(credentials.NewTLS(tlsconfig.MTLSClientConfig(t.x509source, t.x509bundle, tlsconfig.AuthorizeAny()))),
-		grpc.WithDefaultCallOptions(
-			grpc.WaitForReady(true),
-			grpc.PerRPCCredentials(token.NewPerRPCCredentials(spiffejwt.TokenGeneratorFunc(t.x509source, t.config.MaxTokenLifetime))),
-		),
-		grpcfd.WithChainStreamInterceptor(),
-		grpcfd.WithChainUnaryInterceptor(),
-	)
-	t.NoError(err)
-	client := next.NewNetworkServiceRegistryClient(
-		grpcmetadata.NewNetworkServiceRegistryClient(),
-		registry.NewNetworkServiceRegistryClient(cc),
-	)
-	_, err = client.Register(context.Background(), &registry.NetworkService{
-		Name: "ns-1",
-	})
-	t.Nil(err)
-	stream, err := client.Find(context.Background(), &registry.NetworkServiceQuery{NetworkService: &registry.NetworkService{Name: "ns-1"}})
-	t.Nil(err)
-	list := registry.ReadNetworkServiceList(stream)
-	t.Len(list, 1)
-	_, err = client.Unregister(context.Background(), &registry.NetworkService{
-		Name: "ns-1",
-	})
-	t.Nil(err)
-	stream, err = client.Find(context.Background(), &registry.NetworkServiceQuery{NetworkService: &registry.NetworkService{Name: "ns-1"}})
-	t.Nil(err)
-	list = registry.ReadNetworkServiceList(stream)
-	t.Len(list, 0)
-}
-
-func (t *RegistryTestSuite) TestNetworkServiceEndpointRegistration() {
-	ctx, cancel := context.WithTimeout(t.ctx, 100*time.Second)
-	defer cancel()
-	cc, err := grpc.DialContext(ctx,
-		t.config.ListenOn[0].String(),
-		grpc.WithTransportCredentials(credentials.NewTLS(tlsconfig.MTLSClientConfig(t.x509source, t.x509bundle, tlsconfig.AuthorizeAny()))),
-		grpc.WithDefaultCallOptions(
-			grpc.WaitForReady(true),
-			grpc.PerRPCCredentials(token.NewPerRPCCredentials(spiffejwt.TokenGeneratorFunc(t.x509source, t.config.MaxTokenLifetime))),
-		),
-		grpcfd.WithChainStreamInterceptor(),
-		grpcfd.WithChainUnaryInterceptor(),
-	)
-	t.NoError(err)
-
-	client := next.NewNetworkServiceEndpointRegistryClient(
-		begin.NewNetworkServiceEndpointRegistryClient(),
-		refresh.NewNetworkServiceEndpointRegistryClient(ctx),
-		grpcmetadata.NewNetworkServiceEndpointRegistryClient(),
-		registry.NewNetworkServiceEndpointRegistryClient(cc),
-	)
-
-	result, err := client.Register(context.Background(), &registry.NetworkServiceEndpoint{
-		Name: "nse-1",
-		Url:  "tcp://127.0.0.1",
-		NetworkServiceNames: []string{
-			"ns-1",
-		},
-	})
-
-	t.NoError(err)
-	t.NotEmpty(result.Name)
-	stream, err := client.Find(context.Background(), &registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{
-		Name: result.Name,
-	}})
-	t.NoError(err)
-	list := registry.ReadNetworkServiceEndpointList(stream)
-	t.Len(list, 1)
-	_, err = client.Unregister(context.Background(), result)
-	t.NoError(err)
-	stream, err = client.Find(context.Background(), &registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: result})
-	t.NoError(err)
-	list = registry.ReadNetworkServiceEndpointList(stream)
-	t.Len(list, 0)
-}
-
-func (t *RegistryTestSuite) TestNetworkServiceEndpointRegistrationExpiration() {
-	ctx, cancel := context.WithTimeout(t.ctx, 100*time.Second)
-	defer cancel()
-	cc, err := grpc.DialContext(ctx,
-		t.config.ListenOn[0].String(),
-		grpc.WithTransportCredentials(credentials.NewTLS(tlsconfig.MTLSClientConfig(t.x509source, t.x509bundle, tlsconfig.AuthorizeAny()))),
-		grpc.WithDefaultCallOptions(
-			grpc.WaitForReady(true),
-			grpc.PerRPCCredentials(token.NewPerRPCCredentials(spiffejwt.TokenGeneratorFunc(t.x509source, t.config.MaxTokenLifetime))),
-		),
-		grpcfd.WithChainStreamInterceptor(),
-		grpcfd.WithChainUnaryInterceptor(),
-	)
-	t.NoError(err)
-	client := next.NewNetworkServiceEndpointRegistryClient(
-		grpcmetadata.NewNetworkServiceEndpointRegistryClient(),
-		registry.NewNetworkServiceEndpointRegistryClient(cc),
-	)
-	expireTime := time.Now().Add(time.Second)
-	result, err := client.Register(context.Background(), &registry.NetworkServiceEndpoint{
-		Name: "nse-1",
-		Url:  "tcp://127.0.0.1",
-		NetworkServiceNames: []string{
-			"ns-1",
-		},
-		ExpirationTime: &timestamp.Timestamp{
-			Nanos:   int32(expireTime.Nanosecond()),
-			Seconds: expireTime.Unix(),
-		},
-	})
-	t.Nil(err)
-	t.NotEmpty(result.Name)
-	stream, err := client.Find(context.Background(), &registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{Name: result.Name}})
-	t.Nil(err)
-	list := registry.ReadNetworkServiceEndpointList(stream)
-	t.Len(list, 1)
-	t.Eventually(func() bool {
-		stream, err = client.Find(context.Background(), &registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{Name: result.Name}})
-		t.Nil(err)
-		list = registry.ReadNetworkServiceEndpointList(stream)
-		return len(list) == 0
-	}, time.Until(result.GetExpirationTime().AsTime())+time.Second*5, time.Millisecond*100)
-}
-
-func (t *RegistryTestSuite) TestNetworkServiceEndpointClientRefreshingTime() {
-	ctx, cancel := context.WithTimeout(t.ctx, 100*time.Second)
-	defer cancel()
-	cc, err := grpc.DialContext(ctx,
-		t.config.ListenOn[0].String(),
-		grpc.WithTransportCredentials(credentials.NewTLS(tlsconfig.MTLSClientConfig(t.x509source, t.x509bundle, tlsconfig.AuthorizeAny()))),
-		grpc.WithDefaultCallOptions(
-			grpc.WaitForReady(true),
-			grpc.PerRPCCredentials(token.NewPerRPCCredentials(spiffejwt.TokenGeneratorFunc(t.x509source, t.config.MaxTokenLifetime))),
-		),
-		grpcfd.WithChainStreamInterceptor(),
-		grpcfd.WithChainUnaryInterceptor(),
-	)
-	t.NoError(err)
-
-	clientCount := 10
-	var names []string
-	for i := 0; i < clientCount; i++ {
-		client := next.NewNetworkServiceEndpointRegistryClient(
-			begin.NewNetworkServiceEndpointRegistryClient(),
-			refresh.NewNetworkServiceEndpointRegistryClient(ctx),
-			grpcmetadata.NewNetworkServiceEndpointRegistryClient(),
-			registry.NewNetworkServiceEndpointRegistryClient(cc),
-		)
-		result, regErr := client.Register(context.Background(), &registry.NetworkServiceEndpoint{
-			Name: fmt.Sprintf("nse-%d", i),
-			Url:  "tcp://127.0.0.1",
-			NetworkServiceNames: []string{
-				"my-network-service",
-			},
-		})
-
-		t.NoError(regErr)
-		t.NotEmpty(result.Name)
-		names = append(names, result.Name)
-	}
-
-	client := next.NewNetworkServiceEndpointRegistryClient(
-		begin.NewNetworkServiceEndpointRegistryClient(),
-		registry.NewNetworkServiceEndpointRegistryClient(cc),
-	)
-
-	<-time.After(time.Second)
-	stream, err := client.Find(context.Background(), &registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{NetworkServiceNames: []string{
-		"my-network-service",
-	}}})
-	t.Nil(err)
-	list := registry.ReadNetworkServiceEndpointList(stream)
-	t.Len(list, clientCount)
-	for _, name := range names {
-		_, err = client.Unregister(ctx, &registry.NetworkServiceEndpoint{Name: name})
-	}
-	t.NoError(err)
-}
-
 func TestRegistryTestSuite(t *testing.T) {
 	suite.Run(t, new(RegistryTestSuite))
 }
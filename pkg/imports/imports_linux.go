@@ -2,45 +2,145 @@
 package imports
 
 import (
+	_ "bufio"
+	_ "bytes"
 	_ "context"
+	_ "crypto/ecdsa"
+	_ "crypto/elliptic"
+	_ "crypto/rand"
+	_ "crypto/sha256"
 	_ "crypto/tls"
+	_ "crypto/x509"
+	_ "crypto/x509/pkix"
+	_ "encoding/csv"
+	_ "encoding/hex"
+	_ "encoding/json"
+	_ "encoding/pem"
+	_ "errors"
+	_ "flag"
 	_ "fmt"
 	_ "github.com/antonfisher/nested-logrus-formatter"
 	_ "github.com/edwarnicke/exechelper"
+	_ "github.com/edwarnicke/genericsync"
 	_ "github.com/edwarnicke/grpcfd"
+	_ "github.com/edwarnicke/serialize"
+	_ "github.com/golang-jwt/jwt/v4"
+	_ "github.com/golang/protobuf/ptypes/empty"
 	_ "github.com/golang/protobuf/ptypes/timestamp"
+	_ "github.com/google/uuid"
 	_ "github.com/kelseyhightower/envconfig"
+	_ "github.com/klauspost/compress/zstd"
+	_ "github.com/miekg/dns"
+	_ "github.com/NikitaSkrynnik/api/pkg/api"
+	_ "github.com/NikitaSkrynnik/api/pkg/api/networkservice/payload"
 	_ "github.com/NikitaSkrynnik/api/pkg/api/registry"
-	_ "github.com/NikitaSkrynnik/sdk/pkg/registry/chains/memory"
+	_ "github.com/NikitaSkrynnik/sdk/pkg/registry"
+	_ "github.com/NikitaSkrynnik/sdk/pkg/registry/chains/client"
 	_ "github.com/NikitaSkrynnik/sdk/pkg/registry/common/authorize"
 	_ "github.com/NikitaSkrynnik/sdk/pkg/registry/common/begin"
+	_ "github.com/NikitaSkrynnik/sdk/pkg/registry/common/clientconn"
+	_ "github.com/NikitaSkrynnik/sdk/pkg/registry/common/clienturl"
+	_ "github.com/NikitaSkrynnik/sdk/pkg/registry/common/connect"
+	_ "github.com/NikitaSkrynnik/sdk/pkg/registry/common/dial"
+	_ "github.com/NikitaSkrynnik/sdk/pkg/registry/common/expire"
 	_ "github.com/NikitaSkrynnik/sdk/pkg/registry/common/grpcmetadata"
+	_ "github.com/NikitaSkrynnik/sdk/pkg/registry/common/memory"
+	_ "github.com/NikitaSkrynnik/sdk/pkg/registry/common/null"
 	_ "github.com/NikitaSkrynnik/sdk/pkg/registry/common/refresh"
+	_ "github.com/NikitaSkrynnik/sdk/pkg/registry/common/setpayload"
+	_ "github.com/NikitaSkrynnik/sdk/pkg/registry/common/setregistrationtime"
+	_ "github.com/NikitaSkrynnik/sdk/pkg/registry/common/updatepath"
+	_ "github.com/NikitaSkrynnik/sdk/pkg/registry/core/adapters"
+	_ "github.com/NikitaSkrynnik/sdk/pkg/registry/core/chain"
 	_ "github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+	_ "github.com/NikitaSkrynnik/sdk/pkg/registry/core/streamcontext"
+	_ "github.com/NikitaSkrynnik/sdk/pkg/registry/core/trace"
+	_ "github.com/NikitaSkrynnik/sdk/pkg/registry/switchcase"
+	_ "github.com/NikitaSkrynnik/sdk/pkg/registry/utils/inject/injecterror"
+	_ "github.com/NikitaSkrynnik/sdk/pkg/registry/utils/metadata"
+	_ "github.com/NikitaSkrynnik/sdk/pkg/tools/clienturlctx"
+	_ "github.com/NikitaSkrynnik/sdk/pkg/tools/clock"
+	_ "github.com/NikitaSkrynnik/sdk/pkg/tools/clockmock"
 	_ "github.com/NikitaSkrynnik/sdk/pkg/tools/debug"
+	_ "github.com/NikitaSkrynnik/sdk/pkg/tools/dnsutils"
 	_ "github.com/NikitaSkrynnik/sdk/pkg/tools/grpcutils"
+	_ "github.com/NikitaSkrynnik/sdk/pkg/tools/interdomain"
 	_ "github.com/NikitaSkrynnik/sdk/pkg/tools/log"
 	_ "github.com/NikitaSkrynnik/sdk/pkg/tools/log/logruslogger"
+	_ "github.com/NikitaSkrynnik/sdk/pkg/tools/matchutils"
+	_ "github.com/NikitaSkrynnik/sdk/pkg/tools/opa"
 	_ "github.com/NikitaSkrynnik/sdk/pkg/tools/opentelemetry"
 	_ "github.com/NikitaSkrynnik/sdk/pkg/tools/spiffejwt"
 	_ "github.com/NikitaSkrynnik/sdk/pkg/tools/spire"
 	_ "github.com/NikitaSkrynnik/sdk/pkg/tools/token"
 	_ "github.com/NikitaSkrynnik/sdk/pkg/tools/tracing"
+	_ "github.com/pkg/errors"
 	_ "github.com/sirupsen/logrus"
+	_ "github.com/spiffe/go-spiffe/v2/bundle/spiffebundle"
 	_ "github.com/spiffe/go-spiffe/v2/bundle/x509bundle"
+	_ "github.com/spiffe/go-spiffe/v2/federation"
+	_ "github.com/spiffe/go-spiffe/v2/spiffeid"
 	_ "github.com/spiffe/go-spiffe/v2/spiffetls/tlsconfig"
 	_ "github.com/spiffe/go-spiffe/v2/svid/x509svid"
 	_ "github.com/spiffe/go-spiffe/v2/workloadapi"
 	_ "github.com/stretchr/testify/require"
 	_ "github.com/stretchr/testify/suite"
+	_ "go.opentelemetry.io/otel"
+	_ "go.opentelemetry.io/otel/attribute"
+	_ "go.opentelemetry.io/otel/metric"
+	_ "go.opentelemetry.io/otel/sdk/metric"
+	_ "go.opentelemetry.io/otel/sdk/metric/metricdata"
+	_ "go.opentelemetry.io/otel/sdk/trace"
+	_ "go.opentelemetry.io/otel/sdk/trace/tracetest"
 	_ "google.golang.org/grpc"
+	_ "google.golang.org/grpc/codes"
+	_ "google.golang.org/grpc/connectivity"
 	_ "google.golang.org/grpc/credentials"
+	_ "google.golang.org/grpc/credentials/insecure"
+	_ "google.golang.org/grpc/encoding"
+	_ "google.golang.org/grpc/encoding/gzip"
+	_ "google.golang.org/grpc/health"
 	_ "google.golang.org/grpc/health/grpc_health_v1"
+	_ "google.golang.org/grpc/keepalive"
+	_ "google.golang.org/grpc/metadata"
+	_ "google.golang.org/grpc/peer"
+	_ "google.golang.org/grpc/reflection"
+	_ "google.golang.org/grpc/stats"
+	_ "google.golang.org/grpc/status"
+	_ "google.golang.org/grpc/test/bufconn"
+	_ "google.golang.org/protobuf/encoding/protodelim"
+	_ "google.golang.org/protobuf/encoding/protojson"
+	_ "google.golang.org/protobuf/encoding/protowire"
+	_ "google.golang.org/protobuf/proto"
+	_ "google.golang.org/protobuf/types/known/anypb"
+	_ "google.golang.org/protobuf/types/known/emptypb"
+	_ "google.golang.org/protobuf/types/known/structpb"
+	_ "google.golang.org/protobuf/types/known/timestamppb"
+	_ "google.golang.org/protobuf/types/known/wrapperspb"
+	_ "io"
+	_ "math"
+	_ "math/big"
+	_ "math/rand"
+	_ "net"
+	_ "net/http"
+	_ "net/http/httptest"
 	_ "net/url"
 	_ "os"
 	_ "os/signal"
 	_ "path/filepath"
+	_ "reflect"
+	_ "regexp"
+	_ "runtime"
+	_ "runtime/debug"
+	_ "runtime/pprof"
+	_ "sort"
+	_ "strconv"
+	_ "strings"
+	_ "sync"
+	_ "sync/atomic"
 	_ "syscall"
 	_ "testing"
+	_ "text/tabwriter"
+	_ "text/template"
 	_ "time"
 )
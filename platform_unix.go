@@ -0,0 +1,38 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !windows
+
+package main
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/edwarnicke/grpcfd"
+	"google.golang.org/grpc/credentials"
+)
+
+// shutdownSignals are the signals stopping the registry
+var shutdownSignals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+
+// dumpSignals are the signals writing the goroutine dump to the crash dump directory and then stopping the registry
+var dumpSignals = []os.Signal{syscall.SIGQUIT}
+
+// wrapTransportCredentials wraps the outbound transport credentials to pass the file descriptors over the unix sockets
+func wrapTransportCredentials(c credentials.TransportCredentials) credentials.TransportCredentials {
+	return grpcfd.TransportCredentials(c)
+}
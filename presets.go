@@ -0,0 +1,70 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/reload"
+)
+
+const presetEnv = "REGISTRY_MEMORY_PRESET"
+
+// presets are the named combinations of the optional features by the config variables they set. The standard preset
+// is the defaults. No preset disables the authorization, it takes the explicit empty policies.
+var presets = map[string]map[string]string{
+	"minimal": {
+		"REGISTRY_MEMORY_TOMBSTONE_RETENTION": "0",
+		"REGISTRY_MEMORY_HISTORY_REVISIONS":   "0",
+	},
+	"standard": {},
+	"interdomain": {
+		"REGISTRY_MEMORY_PROXY_REGISTRY_FALLBACK": "true",
+		"REGISTRY_MEMORY_NEGATIVE_CACHE_TTL":      "5s",
+	},
+	"secure": {
+		"REGISTRY_MEMORY_STRICT_REGISTRATION": "true",
+		"REGISTRY_MEMORY_TENANCY_ENABLED":     "true",
+		"REGISTRY_MEMORY_LISTEN_SOCKET_MODE":  "0660",
+	},
+}
+
+// presetLookup returns the lookup of the variables of the preset named by REGISTRY_MEMORY_PRESET from lookup, the
+// lookup setting none if the preset is not set
+func presetLookup(lookup reload.Lookup) (reload.Lookup, error) {
+	name, ok := lookup(presetEnv)
+	if !ok || name == "" {
+		return reload.FromMap(nil), nil
+	}
+	preset, ok := presets[strings.ToLower(name)]
+	if !ok {
+		return nil, errors.Errorf("invalid preset %s, expected one of %s", name, strings.Join(presetNames(), ", "))
+	}
+	return reload.FromMap(preset), nil
+}
+
+func presetNames() []string {
+	var names []string
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/buildinfo"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/registry/plugins"
+)
+
+// readyLine is the machine-readable line printed once the registry is fully serving
+type readyLine struct {
+	Msg       string   `json:"msg"`
+	Version   string   `json:"version"`
+	ListenOn  []string `json:"listen_on"`
+	HealthOn  string   `json:"health_http_listen_on,omitempty"`
+	StatusOn  string   `json:"status_http_listen_on,omitempty"`
+	DNSOn     string   `json:"dns_listen_on,omitempty"`
+	CatalogOn string   `json:"catalog_listen_on,omitempty"`
+	Features  []string `json:"features"`
+	Storage   string   `json:"storage"`
+}
+
+// printReady prints the single line JSON READY message to w, independent of the log format, so the orchestration
+// scripts can detect the readiness from the output
+func printReady(w io.Writer, config *Config) {
+	line := readyLine{
+		Msg:       "READY",
+		Version:   buildinfo.Get().Version,
+		ListenOn:  make([]string, 0, len(config.ListenOn)),
+		HealthOn:  config.HealthHTTPListenOn,
+		StatusOn:  config.StatusHTTPListenOn,
+		DNSOn:     config.DNSListenOn,
+		CatalogOn: config.CatalogListenOn.String(),
+		Features:  enabledFeatures(config),
+		Storage:   "memory",
+	}
+	for i := range config.ListenOn {
+		line.ListenOn = append(line.ListenOn, config.ListenOn[i].String())
+	}
+	data, err := json.Marshal(&line)
+	if err != nil {
+		return
+	}
+	_, _ = fmt.Fprintln(w, string(data))
+}
+
+// enabledFeatures returns the names of the enabled optional features
+func enabledFeatures(config *Config) []string {
+	features := []string{"tls-" + strings.ToLower(config.TLSMode)}
+	for name, enabled := range map[string]bool{
+		"proxy-fallback":      config.ProxyRegistryFallback && config.ProxyRegistryURL.String() != "",
+		"proxy-push":          config.ProxyRegistryPushLocal && config.ProxyRegistryURL.String() != "",
+		"mirror":              config.MirrorPrimaryURL.String() != "",
+		"expiration-jitter":   config.ExpirationJitter > 0,
+		"negative-cache":      config.NegativeCacheTTL > 0,
+		"federation":          len(config.FederatesWith) > 0,
+		"peer-credentials":    config.PeerCredentialsFile != "",
+		"tls-resumption":      config.TLSSessionTickets || config.TLSSessionCacheSize > 0,
+		"proxy-peer-select":   len(config.ProxyRegistryPeers) > 0,
+		"config-file":         config.ConfigFile != "",
+		"revocation-list":     config.RevocationListFile != "",
+		"service-overrides":   config.ServiceOverridesFile != "",
+		"derived-labels":      config.DerivedLabelsFile != "",
+		"ext-authz":           config.ExtAuthzURL.String() != "",
+		"admission-webhook":   config.AdmissionWebhookURL != "",
+		"strict-registration": config.StrictRegistration,
+		"tenancy":             config.TenancyEnabled,
+		"identity-mapping":    config.IdentityMappingFile != "",
+		"pagination":          config.FindMaxResults > 0,
+		"watch-deduplication": config.WatchDeduplication,
+		"watch-resync":        config.WatchResyncInterval > 0,
+		"budget":              config.BudgetMaxEntries > 0 || config.BudgetMaxBytes > 0,
+		"ns-gc":               config.EmptyServiceRetention > 0,
+		"expire-notify":       config.ExpireNotifyEnabled,
+		"tombstones":          config.TombstoneRetention > 0,
+		"crash-dumps":         config.CrashDumpDir != "",
+		"health-checks":       config.HealthCheckPeriod > 0,
+		"redaction":           len(config.RedactFields) > 0,
+		"expiration-clamp":    config.ExpirationMinLifetime > 0 || config.ExpirationMaxLifetime > 0,
+		"interdomain-probe":   config.InterdomainProbeDomain != "",
+		"history":             config.HistoryRevisions > 0,
+		"chaos":               config.ChaosEnabled,
+		"probe":               config.ProbePeriod > 0,
+		"persisted-counters":  config.CountersFile != "",
+		"dns":                 config.DNSListenOn != "",
+		"catalog":             config.CatalogListenOn.String() != "",
+		"webhooks":            len(config.WebhookURLs) > 0,
+		"nats":                config.NATSURL.Host != "",
+		"listen-after-sync":   config.ListenAfterSync,
+		"socket-permissions":  config.ListenSocketMode != "" || config.ListenSocketOwner != "",
+		"health-http":         config.HealthHTTPListenOn != "",
+		"status-http":         config.StatusHTTPListenOn != "",
+		"grpc-reflection":     config.GRPCReflection,
+		"chain-order":         len(config.ChainOrder) > 0,
+		"chain-plugins":       len(plugins.Names()) > 0,
+	} {
+		if enabled {
+			features = append(features, name)
+		}
+	}
+	sort.Strings(features)
+	return features
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2023 Cisco and/or its affiliates.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"flag"
+	"io"
+	"os"
+
+	"github.com/pkg/errors"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/admin"
+	"github.com/NikitaSkrynnik/cmd-registry-memory/internal/pkg/snapshot"
+)
+
+const (
+	// exportStateCommand is the command writing the full state of a registry as a snapshot, e.g. for a backup
+	exportStateCommand = "export-state"
+	// importStateCommand is the command registering the NSs and the NSEs of a snapshot in a registry
+	importStateCommand = "import-state"
+)
+
+// runExportState gets the state of the -target registry with the admin ExportState API and writes it to out in the
+// -format snapshot format
+func runExportState(ctx context.Context, out io.Writer, args []string) error {
+	flags := flag.NewFlagSet(exportStateCommand, flag.ContinueOnError)
+	target := flags.String("target", "", "url of the registry, e.g. tcp://registry:5002")
+	tlsMode := flags.String("tls-mode", tlsModeSPIFFE, "TLS mode of the target: spiffe (workload API) or insecure")
+	format := flags.String("format", "proto", "snapshot format: proto (compact), json (greppable) or cbor")
+	if err := flags.Parse(args); err != nil {
+		return errors.WithStack(err)
+	}
+	if *target == "" {
+		return errors.New("the target registry url is required")
+	}
+	codec, err := snapshot.CodecByName(*format)
+	if err != nil {
+		return err
+	}
+
+	cc, closeConn, err := dialCommandTarget(ctx, *target, *tlsMode)
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	s, err := admin.NewClient(cc).ExportState(ctx)
+	if err != nil {
+		return errors.Wrapf(err, "failed to export the state of %s", *target)
+	}
+	return codec.Encode(out, s)
+}
+
+// runImportState reads the -in snapshot detecting its format and registers it in the -target registry with the admin
+// ImportState API, then writes the import result to out. The target registry publishes the
+// registry.restored-from-snapshot event once the import completes.
+func runImportState(ctx context.Context, out io.Writer, args []string) error {
+	flags := flag.NewFlagSet(importStateCommand, flag.ContinueOnError)
+	target := flags.String("target", "", "url of the registry, e.g. tcp://registry:5002")
+	tlsMode := flags.String("tls-mode", tlsModeSPIFFE, "TLS mode of the target: spiffe (workload API) or insecure")
+	in := flags.String("in", "-", "snapshot file in the proto, json or cbor format, - reads the standard input")
+	if err := flags.Parse(args); err != nil {
+		return errors.WithStack(err)
+	}
+	if *target == "" {
+		return errors.New("the target registry url is required")
+	}
+
+	var data []byte
+	var err error
+	if *in == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(*in)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "failed to read the snapshot %s", *in)
+	}
+	s, err := snapshot.Decode(data)
+	if err != nil {
+		return err
+	}
+
+	cc, closeConn, err := dialCommandTarget(ctx, *target, *tlsMode)
+	if err != nil {
+		return err
+	}
+	defer closeConn()
+
+	result, err := admin.NewClient(cc).ImportState(ctx, s)
+	if err != nil {
+		return errors.Wrapf(err, "failed to import the state to %s", *target)
+	}
+	data, err = protojson.Marshal(result)
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	_, err = out.Write(append(data, '\n'))
+	return errors.WithStack(err)
+}
@@ -0,0 +1,224 @@
+// Copyright (c) 2020-2022 Doc.ai and/or its affiliates.
+//
+// Copyright (c) 2022 Cisco Systems, Inc.
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at:
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/timestamp"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/suite"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/NikitaSkrynnik/api/pkg/api/registry"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/begin"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/grpcmetadata"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/common/refresh"
+	"github.com/NikitaSkrynnik/sdk/pkg/registry/core/next"
+)
+
+// registrySuite has the test cases of a running registry independent of the platform and of the TLS mode. The suites
+// embedding it start the registry and set the target and the dial options.
+type registrySuite struct {
+	suite.Suite
+	ctx         context.Context
+	cancel      context.CancelFunc
+	target      string
+	dialOptions []grpc.DialOption
+}
+
+func (t *registrySuite) dial(ctx context.Context) (*grpc.ClientConn, error) {
+	return grpc.DialContext(ctx, t.target, t.dialOptions...)
+}
+
+func (t *registrySuite) TestHealthCheck() {
+	ctx, cancel := context.WithTimeout(t.ctx, 100*time.Second)
+	defer cancel()
+	healthCC, err := t.dial(ctx)
+	if err != nil {
+		logrus.Fatalf("Failed healthcheck: %+v", err)
+	}
+	healthClient := grpc_health_v1.NewHealthClient(healthCC)
+	healthResponse, err := healthClient.Check(ctx,
+		&grpc_health_v1.HealthCheckRequest{
+			Service: "registry.NetworkServiceEndpointRegistry",
+		},
+		grpc.WaitForReady(true),
+	)
+	t.NoError(err)
+	t.NotNil(healthResponse)
+	t.Equal(grpc_health_v1.HealthCheckResponse_SERVING, healthResponse.Status)
+	healthResponse, err = healthClient.Check(ctx,
+		&grpc_health_v1.HealthCheckRequest{
+			Service: "registry.NetworkServiceRegistry",
+		},
+		grpc.WaitForReady(true),
+	)
+	t.NoError(err)
+	t.NotNil(healthResponse)
+	t.Equal(grpc_health_v1.HealthCheckResponse_SERVING, healthResponse.Status)
+}
+
+func (t *registrySuite) TestNetworkServiceRegistration() {
+	ctx, cancel := context.WithTimeout(t.ctx, 100*time.Second)
+	defer cancel()
+	cc, err := t.dial(ctx)
+	t.NoError(err)
+	client := next.NewNetworkServiceRegistryClient(
+		grpcmetadata.NewNetworkServiceRegistryClient(),
+		registry.NewNetworkServiceRegistryClient(cc),
+	)
+	_, err = client.Register(context.Background(), &registry.NetworkService{
+		Name: "ns-1",
+	})
+	t.Nil(err)
+	stream, err := client.Find(context.Background(), &registry.NetworkServiceQuery{NetworkService: &registry.NetworkService{Name: "ns-1"}})
+	t.Nil(err)
+	list := registry.ReadNetworkServiceList(stream)
+	t.Len(list, 1)
+	_, err = client.Unregister(context.Background(), &registry.NetworkService{
+		Name: "ns-1",
+	})
+	t.Nil(err)
+	stream, err = client.Find(context.Background(), &registry.NetworkServiceQuery{NetworkService: &registry.NetworkService{Name: "ns-1"}})
+	t.Nil(err)
+	list = registry.ReadNetworkServiceList(stream)
+	t.Len(list, 0)
+}
+
+func (t *registrySuite) TestNetworkServiceEndpointRegistration() {
+	ctx, cancel := context.WithTimeout(t.ctx, 100*time.Second)
+	defer cancel()
+	cc, err := t.dial(ctx)
+	t.NoError(err)
+
+	client := next.NewNetworkServiceEndpointRegistryClient(
+		begin.NewNetworkServiceEndpointRegistryClient(),
+		refresh.NewNetworkServiceEndpointRegistryClient(ctx),
+		grpcmetadata.NewNetworkServiceEndpointRegistryClient(),
+		registry.NewNetworkServiceEndpointRegistryClient(cc),
+	)
+
+	result, err := client.Register(context.Background(), &registry.NetworkServiceEndpoint{
+		Name: "nse-1",
+		Url:  "tcp://127.0.0.1",
+		NetworkServiceNames: []string{
+			"ns-1",
+		},
+	})
+
+	t.NoError(err)
+	t.NotEmpty(result.Name)
+	stream, err := client.Find(context.Background(), &registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{
+		Name: result.Name,
+	}})
+	t.NoError(err)
+	list := registry.ReadNetworkServiceEndpointList(stream)
+	t.Len(list, 1)
+	_, err = client.Unregister(context.Background(), result)
+	t.NoError(err)
+	stream, err = client.Find(context.Background(), &registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: result})
+	t.NoError(err)
+	list = registry.ReadNetworkServiceEndpointList(stream)
+	t.Len(list, 0)
+}
+
+func (t *registrySuite) TestNetworkServiceEndpointRegistrationExpiration() {
+	ctx, cancel := context.WithTimeout(t.ctx, 100*time.Second)
+	defer cancel()
+	cc, err := t.dial(ctx)
+	t.NoError(err)
+	client := next.NewNetworkServiceEndpointRegistryClient(
+		grpcmetadata.NewNetworkServiceEndpointRegistryClient(),
+		registry.NewNetworkServiceEndpointRegistryClient(cc),
+	)
+	expireTime := time.Now().Add(time.Second)
+	result, err := client.Register(context.Background(), &registry.NetworkServiceEndpoint{
+		Name: "nse-1",
+		Url:  "tcp://127.0.0.1",
+		NetworkServiceNames: []string{
+			"ns-1",
+		},
+		ExpirationTime: &timestamp.Timestamp{
+			Nanos:   int32(expireTime.Nanosecond()),
+			Seconds: expireTime.Unix(),
+		},
+	})
+	t.Nil(err)
+	t.NotEmpty(result.Name)
+	stream, err := client.Find(context.Background(), &registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{Name: result.Name}})
+	t.Nil(err)
+	list := registry.ReadNetworkServiceEndpointList(stream)
+	t.Len(list, 1)
+	t.Eventually(func() bool {
+		stream, err = client.Find(context.Background(), &registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{Name: result.Name}})
+		t.Nil(err)
+		list = registry.ReadNetworkServiceEndpointList(stream)
+		return len(list) == 0
+	}, time.Until(result.GetExpirationTime().AsTime())+time.Second*5, time.Millisecond*100)
+}
+
+func (t *registrySuite) TestNetworkServiceEndpointClientRefreshingTime() {
+	ctx, cancel := context.WithTimeout(t.ctx, 100*time.Second)
+	defer cancel()
+	cc, err := t.dial(ctx)
+	t.NoError(err)
+
+	clientCount := 10
+	var names []string
+	for i := 0; i < clientCount; i++ {
+		client := next.NewNetworkServiceEndpointRegistryClient(
+			begin.NewNetworkServiceEndpointRegistryClient(),
+			refresh.NewNetworkServiceEndpointRegistryClient(ctx),
+			grpcmetadata.NewNetworkServiceEndpointRegistryClient(),
+			registry.NewNetworkServiceEndpointRegistryClient(cc),
+		)
+		result, regErr := client.Register(context.Background(), &registry.NetworkServiceEndpoint{
+			Name: fmt.Sprintf("nse-%d", i),
+			Url:  "tcp://127.0.0.1",
+			NetworkServiceNames: []string{
+				"my-network-service",
+			},
+		})
+
+		t.NoError(regErr)
+		t.NotEmpty(result.Name)
+		names = append(names, result.Name)
+	}
+
+	client := next.NewNetworkServiceEndpointRegistryClient(
+		begin.NewNetworkServiceEndpointRegistryClient(),
+		registry.NewNetworkServiceEndpointRegistryClient(cc),
+	)
+
+	<-time.After(time.Second)
+	stream, err := client.Find(context.Background(), &registry.NetworkServiceEndpointQuery{NetworkServiceEndpoint: &registry.NetworkServiceEndpoint{NetworkServiceNames: []string{
+		"my-network-service",
+	}}})
+	t.Nil(err)
+	list := registry.ReadNetworkServiceEndpointList(stream)
+	t.Len(list, clientCount)
+	for _, name := range names {
+		_, err = client.Unregister(ctx, &registry.NetworkServiceEndpoint{Name: name})
+	}
+	t.NoError(err)
+}